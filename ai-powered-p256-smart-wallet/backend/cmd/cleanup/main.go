@@ -1,6 +1,8 @@
 package main
 
 import (
+	"ai-wallet-backend/internal/models"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -11,6 +13,9 @@ import (
 )
 
 func main() {
+	purge := flag.Bool("purge", false, "permanently delete users, wallets, passkeys and their history")
+	flag.Parse()
+
 	// Load environment variables
 	if err := godotenv.Load(".env"); err != nil {
 		log.Println("⚠️  No .env file found in current directory")
@@ -37,18 +42,8 @@ func main() {
 
 	log.Println("🗑️  Cleaning up database...")
 
-	// Delete all records in order (respecting foreign keys)
-	tables := []string{
-		"balances",
-		"transactions",
-		"wallets",
-		"sessions",
-		"webauthn_sessions",
-		"passkey_credentials",
-		"users",
-	}
-
-	for _, table := range tables {
+	// Sessions are always cleared
+	for _, table := range []string{"sessions", "webauthn_sessions"} {
 		result := db.Exec(fmt.Sprintf("DELETE FROM %s", table))
 		if result.Error != nil {
 			log.Printf("⚠️  Error deleting from %s: %v", table, result.Error)
@@ -57,5 +52,31 @@ func main() {
 		}
 	}
 
+	// Users, wallets and passkeys are soft-deleted so they can be restored;
+	// balances and transactions are only removed with -purge
+	scope := db
+	if *purge {
+		scope = db.Unscoped()
+		for _, table := range []string{"balances", "transactions"} {
+			result := db.Exec(fmt.Sprintf("DELETE FROM %s", table))
+			if result.Error != nil {
+				log.Printf("⚠️  Error deleting from %s: %v", table, result.Error)
+			} else {
+				log.Printf("✓ Deleted %d rows from %s", result.RowsAffected, table)
+			}
+		}
+	}
+
+	for _, model := range []interface{}{&models.Wallet{}, &models.PasskeyCredential{}, &models.User{}} {
+		result := scope.Where("1 = 1").Delete(model)
+		if result.Error != nil {
+			log.Printf("⚠️  Error deleting %T: %v", model, result.Error)
+		} else if *purge {
+			log.Printf("✓ Purged %d %T rows", result.RowsAffected, model)
+		} else {
+			log.Printf("✓ Soft-deleted %d %T rows", result.RowsAffected, model)
+		}
+	}
+
 	log.Println("✅ Database cleanup completed!")
 }
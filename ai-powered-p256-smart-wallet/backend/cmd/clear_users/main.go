@@ -1,6 +1,8 @@
 package main
 
 import (
+	"ai-wallet-backend/internal/models"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -10,6 +12,11 @@ import (
 )
 
 func main() {
+	// By default users, wallets and passkeys are soft-deleted (recoverable);
+	// -purge removes them and their transactions for good
+	purge := flag.Bool("purge", false, "permanently delete rows instead of soft-deleting them")
+	flag.Parse()
+
 	// Get database URL from environment
 	dbURL := os.Getenv("DATABASE_URL")
 	if dbURL == "" {
@@ -30,12 +37,12 @@ func main() {
 		}
 	}()
 
-	// Count records before deletion
+	// Count records before deletion (live rows only)
 	var userCount, walletCount, passkeyCount, sessionCount int64
-	tx.Table("users").Count(&userCount)
-	tx.Table("wallets").Count(&walletCount)
-	tx.Table("passkey_credentials").Count(&passkeyCount)
-	tx.Table("sessions").Count(&sessionCount)
+	tx.Model(&models.User{}).Count(&userCount)
+	tx.Model(&models.Wallet{}).Count(&walletCount)
+	tx.Model(&models.PasskeyCredential{}).Count(&passkeyCount)
+	tx.Model(&models.Session{}).Count(&sessionCount)
 
 	fmt.Printf("Before deletion:\n")
 	fmt.Printf("  Users: %d\n", userCount)
@@ -43,35 +50,32 @@ func main() {
 	fmt.Printf("  Passkeys: %d\n", passkeyCount)
 	fmt.Printf("  Sessions: %d\n", sessionCount)
 
-	// Delete all records (in correct order due to foreign keys)
-	if err := tx.Exec("DELETE FROM transactions").Error; err != nil {
-		tx.Rollback()
-		log.Fatalf("Failed to delete transactions: %v", err)
-	}
-
-	if err := tx.Exec("DELETE FROM wallets").Error; err != nil {
-		tx.Rollback()
-		log.Fatalf("Failed to delete wallets: %v", err)
-	}
-
-	if err := tx.Exec("DELETE FROM passkey_credentials").Error; err != nil {
-		tx.Rollback()
-		log.Fatalf("Failed to delete passkey_credentials: %v", err)
-	}
-
-	if err := tx.Exec("DELETE FROM sessions").Error; err != nil {
+	// Sessions are short-lived and always removed
+	if err := tx.Where("1 = 1").Delete(&models.Session{}).Error; err != nil {
 		tx.Rollback()
 		log.Fatalf("Failed to delete sessions: %v", err)
 	}
 
-	if err := tx.Exec("DELETE FROM webauthn_sessions").Error; err != nil {
+	if err := tx.Where("1 = 1").Delete(&models.WebAuthnSession{}).Error; err != nil {
 		tx.Rollback()
 		log.Fatalf("Failed to delete webauthn_sessions: %v", err)
 	}
 
-	if err := tx.Exec("DELETE FROM users").Error; err != nil {
-		tx.Rollback()
-		log.Fatalf("Failed to delete users: %v", err)
+	// Users, wallets and passkeys are soft-deleted unless -purge (in order due to foreign keys)
+	scope := tx
+	if *purge {
+		scope = tx.Unscoped()
+		if err := tx.Exec("DELETE FROM transactions").Error; err != nil {
+			tx.Rollback()
+			log.Fatalf("Failed to delete transactions: %v", err)
+		}
+	}
+
+	for _, model := range []interface{}{&models.Wallet{}, &models.PasskeyCredential{}, &models.User{}} {
+		if err := scope.Where("1 = 1").Delete(model).Error; err != nil {
+			tx.Rollback()
+			log.Fatalf("Failed to delete %T: %v", model, err)
+		}
 	}
 
 	// Commit transaction
@@ -79,6 +83,10 @@ func main() {
 		log.Fatalf("Failed to commit: %v", err)
 	}
 
-	fmt.Printf("\n✅ All user data cleared successfully!\n")
+	if *purge {
+		fmt.Printf("\n✅ All user data purged!\n")
+	} else {
+		fmt.Printf("\n✅ All user data soft-deleted (recoverable; rerun with -purge to remove it)\n")
+	}
 	fmt.Printf("\nYou can now register a new user with the correct wallet address.\n")
 }
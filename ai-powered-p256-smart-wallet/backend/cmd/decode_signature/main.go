@@ -1,6 +1,7 @@
 package main
 
 import (
+	"ai-wallet-backend/internal/webauthnp256"
 	"encoding/hex"
 	"fmt"
 	"log"
@@ -17,40 +18,39 @@ func main() {
 
 	fmt.Printf("Total signature length: %d bytes\n\n", len(sigBytes))
 
-	// Extract components according to our format:
-	// r (32) || s (32) || authDataLength (2) || authenticatorData || clientDataJSON
-
-	if len(sigBytes) < 66 {
-		log.Fatal("Signature too short")
+	// Packed signatures start with the format version byte:
+	// v1 = version (1) || r (32) || s (32) || authDataLength (2) || authenticatorData || clientDataJSON
+	// Anything else is read as the unversioned legacy layout (the v1 layout without the version byte)
+	version, assertion, err := webauthnp256.UnpackSignature(sigBytes)
+	offset := 1
+	if err != nil {
+		version, offset = webauthnp256.SignatureFormatLegacy, 0
+		assertion, err = webauthnp256.ParseAssertion(sigBytes)
 	}
+	if err != nil {
+		log.Fatalf("Failed to parse signature: %v", err)
+	}
+	authDataLength := len(assertion.AuthenticatorData)
 
-	r := sigBytes[0:32]
-	s := sigBytes[32:64]
-	authDataLengthBytes := sigBytes[64:66]
-
-	// Decode authData length (big-endian uint16)
-	authDataLength := int(authDataLengthBytes[0])<<8 | int(authDataLengthBytes[1])
-
-	fmt.Printf("r: %x\n", r)
-	fmt.Printf("s: %x\n", s)
-	fmt.Printf("authDataLength bytes: %x\n", authDataLengthBytes)
-	fmt.Printf("authDataLength (decoded): %d bytes\n\n", authDataLength)
-
-	if len(sigBytes) < 66+authDataLength {
-		log.Fatalf("Signature too short for authData: have %d, need %d", len(sigBytes), 66+authDataLength)
+	if version == webauthnp256.SignatureFormatLegacy {
+		fmt.Println("format: legacy (no version byte)")
+	} else {
+		fmt.Printf("format: v%d (%s)\n", version, webauthnp256.SignatureLayoutV1)
 	}
 
-	authenticatorData := sigBytes[66 : 66+authDataLength]
-	clientDataJSON := sigBytes[66+authDataLength:]
+	fmt.Printf("r: %064x\n", assertion.R)
+	fmt.Printf("s (low-S): %064x\n", assertion.S)
+	fmt.Printf("authDataLength (decoded): %d bytes\n\n", authDataLength)
 
-	fmt.Printf("authenticatorData (%d bytes): %x\n", len(authenticatorData), authenticatorData)
-	fmt.Printf("clientDataJSON (%d bytes): %s\n\n", len(clientDataJSON), string(clientDataJSON))
+	fmt.Printf("authenticatorData (%d bytes): %x\n", authDataLength, assertion.AuthenticatorData)
+	fmt.Printf("clientDataJSON (%d bytes): %s\n", len(assertion.ClientDataJSON), string(assertion.ClientDataJSON))
+	fmt.Printf("origin: %s\n\n", assertion.ClientData.Origin)
 
 	// Verify the structure matches what the contract expects
 	fmt.Println("Contract will:")
-	fmt.Printf("1. Extract authDataLength from offset 64-66: %d\n", authDataLength)
-	fmt.Printf("2. Extract authenticatorData from offset 66 to %d\n", 66+authDataLength)
-	fmt.Printf("3. Extract clientDataJSON from offset %d to end\n", 66+authDataLength)
+	fmt.Printf("1. Extract authDataLength from offset %d-%d: %d\n", offset+64, offset+66, authDataLength)
+	fmt.Printf("2. Extract authenticatorData from offset %d to %d\n", offset+66, offset+66+authDataLength)
+	fmt.Printf("3. Extract clientDataJSON from offset %d to end\n", offset+66+authDataLength)
 	fmt.Printf("4. Compute: clientDataHash = SHA256(clientDataJSON)\n")
 	fmt.Printf("5. Compute: signedMessage = authenticatorData || clientDataHash\n")
 	fmt.Printf("6. Compute: messageHash = SHA256(signedMessage)\n")
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"ai-wallet-backend/internal/chains"
+	"ai-wallet-backend/internal/database"
+	"ai-wallet-backend/internal/models"
+	"ai-wallet-backend/internal/wallet"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+// deploy_wallet deploys a counterfactual wallet by address through the factory, the
+// same way as POST /api/wallet/deploy, and records is_deployed/deployed_at from the
+// receipt. Running it for an already deployed wallet only syncs the database.
+func main() {
+	address := flag.String("address", "", "wallet address to deploy")
+	flag.Parse()
+	if *address == "" {
+		log.Fatal("usage: deploy_wallet -address 0x...")
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("⚠️  No .env file found, using system environment variables")
+	}
+
+	db, err := database.NewPostgresDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	chainID := chains.ChainIDFromEnv()
+	manager, err := wallet.NewManager(db, os.Getenv("RPC_URL"), chainID, os.Getenv("FACTORY_ADDRESS"), os.Getenv("IMPLEMENTATION_ADDRESS"))
+	if err != nil {
+		log.Fatalf("Failed to initialize wallet manager: %v", err)
+	}
+	defer manager.Close()
+
+	var target models.Wallet
+	if err := db.Where("LOWER(address) = LOWER(?)", *address).First(&target).Error; err != nil {
+		log.Fatalf("Wallet %s not found: %v", *address, err)
+	}
+
+	deployment, err := manager.DeployWallet(context.Background(), &target, os.Getenv("BUNDLER_PRIVATE_KEY"))
+	if err != nil {
+		log.Fatalf("Failed to deploy wallet: %v", err)
+	}
+
+	if deployment.AlreadyDeployed {
+		fmt.Printf("✅ Wallet %s was already deployed (deployed_at %s)\n", target.Address, target.DeployedAt)
+		return
+	}
+	fmt.Printf("✅ Wallet %s deployed in block %d (tx %s)\n", target.Address, deployment.BlockNumber, deployment.TxHash)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"ai-wallet-backend/internal/database"
+	"ai-wallet-backend/migrations"
 	"fmt"
 	"log"
 	"os"
@@ -9,6 +10,8 @@ import (
 	"github.com/joho/godotenv"
 )
 
+// Applies pending SQL migrations without starting the server.
+// The server runs the same migrations on startup; this is for deploy pipelines.
 func main() {
 	// Load .env file
 	if err := godotenv.Load("../../.env"); err != nil {
@@ -24,26 +27,17 @@ func main() {
 	sqlDB, _ := db.DB()
 	defer sqlDB.Close()
 
-	// Read migration file
-	migrationSQL, err := os.ReadFile("../../migrations/002_p256_migration.sql")
+	applied, err := database.Migrate(db, migrations.FS, os.Getenv("DB_MIGRATIONS_BASELINE"))
 	if err != nil {
-		log.Fatalf("Failed to read migration file: %v", err)
+		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
-	fmt.Println("═══════════════════════════════════════════════════════════════")
-	fmt.Println("         Running P256 Migration")
-	fmt.Println("═══════════════════════════════════════════════════════════════\n")
-
-	// Execute migration
-	if err := db.Exec(string(migrationSQL)).Error; err != nil {
-		log.Fatalf("Failed to execute migration: %v", err)
+	if len(applied) == 0 {
+		fmt.Println("✓ Database schema is up to date")
+		return
+	}
+	fmt.Printf("✓ Applied %d migration(s):\n", len(applied))
+	for _, name := range applied {
+		fmt.Println("  -", name)
 	}
-
-	fmt.Println("✓ Migration completed successfully!")
-	fmt.Println("\nChanges applied:")
-	fmt.Println("  - Added public_key_x and public_key_y columns")
-	fmt.Println("  - Updated default chain_id to 133 (HashKey Chain Testnet)")
-	fmt.Println("  - Updated factory and implementation addresses")
-	fmt.Println("  - Created index on public keys")
-	fmt.Println("\nDatabase is now ready for P256 non-custodial wallets!")
 }
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"ai-wallet-backend/internal/chains"
+	"ai-wallet-backend/internal/database"
+	"ai-wallet-backend/internal/wallet"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+// reconcile_deployed syncs wallets.is_deployed/deployed_at with on-chain code once, the
+// same way the server's background reconciler does, e.g. after scripts or a testnet
+// reset left the flags out of date
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("⚠️  No .env file found, using system environment variables")
+	}
+	cfg := wallet.ReconcileConfigFromEnv()
+
+	batchSize := flag.Int("batch-size", cfg.BatchSize, "addresses per eth_getCode batch")
+	all := flag.Bool("all", cfg.All, "also check wallets already flagged deployed")
+	flag.Parse()
+
+	db, err := database.NewPostgresDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	chainID := chains.ChainIDFromEnv()
+	manager, err := wallet.NewManager(db, os.Getenv("RPC_URL"), chainID, os.Getenv("FACTORY_ADDRESS"), os.Getenv("IMPLEMENTATION_ADDRESS"))
+	if err != nil {
+		log.Fatalf("Failed to initialize wallet manager: %v", err)
+	}
+	defer manager.Close()
+
+	result, err := manager.ReconcileDeployed(context.Background(), *batchSize, *all)
+	if err != nil {
+		log.Fatalf("Failed to reconcile wallets (%d checked so far): %v", result.Checked, err)
+	}
+	fmt.Printf("✅ Checked %d wallets on chain %d: %d now deployed, %d now undeployed\n",
+		result.Checked, chainID, result.Deployed, result.Undeployed)
+}
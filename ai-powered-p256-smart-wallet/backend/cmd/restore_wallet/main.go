@@ -0,0 +1,67 @@
+package main
+
+import (
+	"ai-wallet-backend/internal/models"
+	"ai-wallet-backend/internal/wallet"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// restore_wallet brings back a wallet soft-deleted by cmd/clear_users or cmd/cleanup,
+// together with its owner and the owner's passkeys so the user can log in again.
+func main() {
+	address := flag.String("address", "", "address of the soft-deleted wallet")
+	flag.Parse()
+	if *address == "" {
+		log.Fatal("usage: restore_wallet -address 0x...")
+	}
+
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		log.Fatal("DATABASE_URL environment variable not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dbURL), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	var deleted models.Wallet
+	if err := db.Unscoped().Where("address = ?", *address).First(&deleted).Error; err != nil {
+		log.Fatalf("Wallet %s not found: %v", *address, err)
+	}
+
+	var owner models.User
+	if err := db.Unscoped().Where("id = ?", deleted.UserID).First(&owner).Error; err != nil {
+		log.Fatalf("Owner %s not found: %v", deleted.UserID, err)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if owner.DeletedAt.Valid {
+			if err := tx.Unscoped().Model(&owner).Update("deleted_at", nil).Error; err != nil {
+				return fmt.Errorf("failed to restore user: %w", err)
+			}
+			// Only passkeys deleted together with the user; ones the user revoked earlier stay revoked
+			since := owner.DeletedAt.Time.Add(-time.Minute)
+			if err := tx.Unscoped().Model(&models.PasskeyCredential{}).
+				Where("user_id = ? AND deleted_at >= ?", owner.ID, since).
+				Update("deleted_at", nil).Error; err != nil {
+				return fmt.Errorf("failed to restore passkeys: %w", err)
+			}
+		}
+		_, err := wallet.Restore(context.Background(), tx, deleted.UserID, deleted.Address)
+		return err
+	})
+	if err != nil {
+		log.Fatalf("Failed to restore wallet: %v", err)
+	}
+
+	fmt.Printf("✅ Restored wallet %s for user %s\n", deleted.Address, deleted.UserID)
+}
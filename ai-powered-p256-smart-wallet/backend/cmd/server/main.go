@@ -3,12 +3,16 @@ package main
 import (
 	"ai-wallet-backend/internal/api"
 	"ai-wallet-backend/internal/auth"
+	"ai-wallet-backend/internal/chains"
 	"ai-wallet-backend/internal/database"
+	"ai-wallet-backend/internal/logging"
 	"ai-wallet-backend/internal/wallet"
+	"ai-wallet-backend/migrations"
+	"context"
 	"fmt"
 	"log"
 	"os"
-	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -19,6 +23,9 @@ func main() {
 		log.Println("⚠️  No .env file found, using system environment variables")
 	}
 
+	// Structured request logging (LOG_LEVEL: debug, info, warn, error; LOG_FORMAT: json or console)
+	logging.Setup(os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT"))
+
 	// Validate critical configurations
 	validateEnv()
 
@@ -36,8 +43,12 @@ func main() {
 	}
 	log.Println("✓ Database connected successfully")
 
-	// Auto-migrate database schema
+	// Apply pending SQL migrations (recorded in schema_migrations), then let GORM
+	// add anything the SQL files don't cover yet
 	log.Println("🔄 Running database migrations...")
+	if _, err := database.Migrate(db, migrations.FS, os.Getenv("DB_MIGRATIONS_BASELINE")); err != nil {
+		log.Fatalf("❌ Failed to run migrations: %v", err)
+	}
 	if err := database.AutoMigrate(db); err != nil {
 		log.Fatalf("❌ Failed to run migrations: %v", err)
 	}
@@ -45,32 +56,67 @@ func main() {
 
 	// Initialize WebAuthn
 	log.Println("🔐 Initializing WebAuthn...")
+	authenticatorPolicy, err := auth.ParseAuthenticatorPolicy(
+		os.Getenv("WEBAUTHN_ATTACHMENT"),
+		os.Getenv("WEBAUTHN_USER_VERIFICATION"),
+		os.Getenv("WEBAUTHN_RESIDENT_KEY"),
+	)
+	if err != nil {
+		log.Fatalf("❌ Invalid WebAuthn policy: %v", err)
+	}
 	webAuthnService, err := auth.NewWebAuthnService(
 		db,
 		os.Getenv("RP_ID"),
 		os.Getenv("RP_NAME"),
 		os.Getenv("RP_ORIGIN"),
+		authenticatorPolicy,
 	)
 	if err != nil {
 		log.Fatalf("❌ Failed to initialize WebAuthn: %v", err)
 	}
-	log.Println("✓ WebAuthn initialized")
+	webAuthnTimeout, _ := time.ParseDuration(os.Getenv("WEBAUTHN_TIMEOUT"))
+	challengeTTL, _ := time.ParseDuration(os.Getenv("WEBAUTHN_CHALLENGE_TTL"))
+	webAuthnService.SetTimeouts(webAuthnTimeout, challengeTTL)
+	log.Printf("✓ WebAuthn initialized (timeout %s, challenge TTL %s)", webAuthnService.CeremonyTimeout(), webAuthnService.ChallengeTTL())
 
 	// Initialize services
 	log.Println("🛠️  Initializing services...")
 	sessionService := auth.NewSessionService(db)
+	sessionTTL, _ := time.ParseDuration(os.Getenv("SESSION_TTL"))
+	sessionMaxLifetime, _ := time.ParseDuration(os.Getenv("SESSION_MAX_LIFETIME"))
+	sessionService.SetLifetimes(sessionTTL, sessionMaxLifetime)
+	sessionStore, err := auth.NewSessionStore(os.Getenv("SESSION_STORE"), os.Getenv("REDIS_URL"), db)
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize session store: %v", err)
+	}
+	sessionService.SetStore(sessionStore)
+	if _, ok := sessionStore.(*auth.RedisSessionStore); ok {
+		log.Println("✓ Sessions stored in Redis")
+	}
 
-	// Parse chain ID from environment
-	chainID := 133 // Default to HashKey Chain Testnet
-	if chainIDStr := os.Getenv("CHAIN_ID"); chainIDStr != "" {
-		if parsed, err := strconv.Atoi(chainIDStr); err == nil {
-			chainID = parsed
+	// Chain registry (built-in networks, extended by CHAINS_CONFIG) and the configured chain
+	if err := chains.LoadFromEnv(); err != nil {
+		log.Fatalf("❌ Failed to load chain registry: %v", err)
+	}
+	chainID := chains.ChainIDFromEnv()
+	chain, known := chains.Default.Get(int64(chainID))
+	if !known {
+		log.Printf("⚠️  Chain %d is not in the chain registry; add it with CHAINS_CONFIG for explorer links", chainID)
+	} else if chain.TxURL("") == "" {
+		log.Printf("⚠️  Chain %d has no explorer configured; transfers are returned without explorer links", chainID)
+	}
+	rpcURL := os.Getenv("RPC_URL")
+	if rpcURL == "" {
+		if chain.RPCDefault == "" {
+			log.Fatalf("❌ RPC_URL is not set and chain %d has no default RPC", chainID)
 		}
+		rpcURL = chain.RPCDefault
+		log.Printf("⚠️  RPC_URL not set, using the public RPC for %s: %s", chain.Name, rpcURL)
 	}
 
 	walletManager, err := wallet.NewManager(
 		db,
-		os.Getenv("RPC_URL"),
+		rpcURL,
 		chainID,
 		os.Getenv("FACTORY_ADDRESS"),
 		os.Getenv("IMPLEMENTATION_ADDRESS"),
@@ -78,19 +124,39 @@ func main() {
 	if err != nil {
 		log.Fatalf("❌ Failed to initialize wallet manager: %v", err)
 	}
+	walletManager.SetSaltStrategy(os.Getenv("WALLET_SALT_STRATEGY"))
+	walletManager.SetEntryPoint(os.Getenv("ENTRYPOINT_VERSION"), os.Getenv("ENTRY_POINT_ADDRESS"))
+	log.Printf("✓ EntryPoint v%s at %s", walletManager.EntryPointVersion(), walletManager.EntryPointAddress().Hex())
+	if err := walletManager.SetBundler(os.Getenv("SUBMIT_MODE"), os.Getenv("BUNDLER_URL")); err != nil {
+		log.Fatalf("❌ Failed to configure UserOp submission: %v", err)
+	}
+	log.Printf("✓ UserOp submit mode: %s", walletManager.SubmitMode())
+	if err := walletManager.SetPaymaster(os.Getenv("PAYMASTER_URL"), os.Getenv("PAYMASTER_ADDRESS")); err != nil {
+		log.Fatalf("❌ Failed to configure paymaster: %v", err)
+	}
+	if walletManager.SponsorshipEnabled() {
+		log.Printf("✓ Gas sponsorship enabled (paymaster %s)", os.Getenv("PAYMASTER_ADDRESS"))
+	}
+	if ttl, err := time.ParseDuration(os.Getenv("BALANCE_CACHE_TTL")); err == nil {
+		walletManager.SetBalanceCacheTTL(ttl)
+	}
 	log.Println("✓ All services initialized")
 
 	// Initialize handler with all services
 	handler := api.NewHandler(db, webAuthnService, sessionService, walletManager)
+	transferLimits, err := wallet.TransferLimitsFromEnv()
+	if err != nil {
+		log.Fatalf("❌ Failed to load transfer limits: %v", err)
+	}
+	if len(transferLimits) > 0 {
+		handler.SetTransferLimits(transferLimits)
+		log.Printf("✓ Transfer limits enabled for %d token(s)", len(transferLimits))
+	}
+	handler.StartBackgroundJobs(context.Background())
 	router := api.SetupRouter(handler)
 
 	// Start server
-	networkName := "HashKey Chain Testnet"
-	if chainID == 11155111 {
-		networkName = "Sepolia Testnet"
-	} else if chainID == 1 {
-		networkName = "Ethereum Mainnet"
-	}
+	networkName := chains.Default.Name(int64(chainID))
 
 	fmt.Printf(`
 ╔═══════════════════════════════════════╗
@@ -117,7 +183,6 @@ func validateEnv() {
 		"DB_USER":                "Database user",
 		"DB_PASSWORD":            "Database password",
 		"DB_NAME":                "Database name",
-		"RPC_URL":                "Ethereum RPC URL",
 		"CHAIN_ID":               "Chain ID",
 		"FACTORY_ADDRESS":        "Smart wallet factory contract address",
 		"IMPLEMENTATION_ADDRESS": "Smart wallet implementation address",
@@ -128,6 +193,12 @@ func validateEnv() {
 		"OPENROUTER_API_KEY":     "OpenRouter API key",
 	}
 
+	// A remote bundler pays for gas itself, so no local bundler key is needed
+	if os.Getenv("SUBMIT_MODE") == "bundler" {
+		delete(required, "BUNDLER_PRIVATE_KEY")
+		required["BUNDLER_URL"] = "ERC-4337 bundler RPC URL"
+	}
+
 	missing := []string{}
 	for key, desc := range required {
 		if os.Getenv(key) == "" {
@@ -1,13 +1,12 @@
 package main
 
 import (
+	"ai-wallet-backend/internal/chains"
 	"ai-wallet-backend/internal/database"
 	"ai-wallet-backend/internal/wallet"
 	"fmt"
 	"log"
-	"math/big"
 	"os"
-	"strconv"
 
 	"github.com/joho/godotenv"
 )
@@ -33,12 +32,7 @@ func main() {
 		log.Fatal("IMPLEMENTATION_ADDRESS not found in environment")
 	}
 
-	chainID := 133
-	if chainIDStr := os.Getenv("CHAIN_ID"); chainIDStr != "" {
-		if parsed, err := strconv.Atoi(chainIDStr); err == nil {
-			chainID = parsed
-		}
-	}
+	chainID := chains.ChainIDFromEnv()
 
 	// Connect to database
 	db, err := database.NewPostgresDB()
@@ -62,13 +56,18 @@ func main() {
 
 	// Test with the existing owner address
 	testOwnerAddress := "0x3102486817599f5A5d484640713801b633d1CB92"
-	salt := big.NewInt(0)
+	testUserID := os.Getenv("TEST_USER_ID")
+	if testUserID == "" {
+		testUserID = "test-user"
+	}
+	salt := wallet.DeriveWalletSalt(os.Getenv("WALLET_SALT_STRATEGY"), testUserID, 0)
 
 	fmt.Printf("Testing AA Wallet Address Calculation:\n\n")
 	fmt.Printf("Factory Address:  %s\n", factoryAddr)
 	fmt.Printf("Implementation:   %s\n", implAddr)
 	fmt.Printf("Chain ID:         %d\n", chainID)
 	fmt.Printf("Owner Address:    %s\n", testOwnerAddress)
+	fmt.Printf("User ID:          %s\n", testUserID)
 	fmt.Printf("Salt:             %s\n\n", salt.String())
 
 	// Compute AA wallet address
@@ -1,12 +1,10 @@
 package main
 
 import (
-	"crypto/ecdsa"
-	"crypto/elliptic"
+	"ai-wallet-backend/internal/webauthnp256"
 	"encoding/hex"
 	"fmt"
 	"log"
-	"math/big"
 )
 
 func main() {
@@ -39,12 +37,6 @@ func main() {
 		log.Fatalf("Invalid signature length: expected 64, got %d", len(sigBytes))
 	}
 
-	r := new(big.Int).SetBytes(sigBytes[0:32])
-	s := new(big.Int).SetBytes(sigBytes[32:64])
-
-	fmt.Printf("r (decimal): %s\n", r.String())
-	fmt.Printf("s (decimal): %s\n\n", s.String())
-
 	// Decode public key
 	xBytes, err := hex.DecodeString(pubKeyX)
 	if err != nil {
@@ -56,28 +48,13 @@ func main() {
 		log.Fatalf("Failed to decode public key Y: %v", err)
 	}
 
-	x := new(big.Int).SetBytes(xBytes)
-	y := new(big.Int).SetBytes(yBytes)
-
-	fmt.Printf("Public Key X (decimal): %s\n", x.String())
-	fmt.Printf("Public Key Y (decimal): %s\n\n", y.String())
-
-	// Create public key
-	pubKey := &ecdsa.PublicKey{
-		Curve: elliptic.P256(),
-		X:     x,
-		Y:     y,
-	}
-
-	// Verify the point is on the curve
-	if !pubKey.Curve.IsOnCurve(pubKey.X, pubKey.Y) {
-		log.Fatalf("❌ Public key point is NOT on P-256 curve!")
+	// Verify signature (high-S values are normalized first, as the API does)
+	valid, err := webauthnp256.VerifyDigest(xBytes, yBytes, hashBytes, sigBytes)
+	if err != nil {
+		log.Fatalf("❌ Verification failed: %v", err)
 	}
 	fmt.Println("✅ Public key point is on P-256 curve")
 
-	// Verify signature
-	valid := ecdsa.Verify(pubKey, hashBytes, r, s)
-
 	fmt.Println()
 	if valid {
 		fmt.Println("✅✅✅ SIGNATURE IS VALID ✅✅✅")
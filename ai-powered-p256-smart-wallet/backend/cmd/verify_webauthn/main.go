@@ -1,13 +1,10 @@
 package main
 
 import (
-	"crypto/ecdsa"
-	"crypto/elliptic"
-	"crypto/sha256"
+	"ai-wallet-backend/internal/webauthnp256"
 	"encoding/hex"
 	"fmt"
 	"log"
-	"math/big"
 )
 
 func main() {
@@ -16,46 +13,37 @@ func main() {
 	pubKeyX := "f81f8ea92c0cf33bc5df8b48884531f0d0f68f01e75c588c098673176034f1c5"
 	pubKeyY := "cc430dcb14c1855bb9f55319bb3f7972201e72696baf00721fbb58bc51b6d8f9"
 
-	sigBytes, _ := hex.DecodeString(sigHex)
-
-	// Extract components
-	r := new(big.Int).SetBytes(sigBytes[0:32])
-	s := new(big.Int).SetBytes(sigBytes[32:64])
-	authDataLength := int(sigBytes[64])<<8 | int(sigBytes[65])
-	authenticatorData := sigBytes[66 : 66+authDataLength]
-	clientDataJSON := sigBytes[66+authDataLength:]
+	sigBytes, err := hex.DecodeString(sigHex)
+	if err != nil {
+		log.Fatalf("Failed to decode signature: %v", err)
+	}
+	xBytes, err := hex.DecodeString(pubKeyX)
+	if err != nil {
+		log.Fatalf("Failed to decode public key X: %v", err)
+	}
+	yBytes, err := hex.DecodeString(pubKeyY)
+	if err != nil {
+		log.Fatalf("Failed to decode public key Y: %v", err)
+	}
 
-	fmt.Println("=== WebAuthn Signature Verification Test ===\n")
+	fmt.Println("=== WebAuthn Signature Verification Test ===")
+	fmt.Println()
 
 	// Step 1: Compute what the contract computes
+	assertion, err := webauthnp256.ParseAssertion(sigBytes)
+	if err != nil {
+		log.Fatalf("Failed to parse signature: %v", err)
+	}
 	fmt.Println("Step 1: Contract computation")
-	clientDataHash := sha256.Sum256(clientDataJSON)
-	fmt.Printf("clientDataHash: %x\n", clientDataHash)
-
-	signedMessage := append(authenticatorData, clientDataHash[:]...)
-	messageHash := sha256.Sum256(signedMessage)
-	fmt.Printf("messageHash (what contract verifies): %x\n\n", messageHash)
+	fmt.Printf("messageHash (what contract verifies): %x\n\n", assertion.MessageHash())
 
 	// Step 2: Verify with public key
 	fmt.Println("Step 2: Verify signature with Go crypto")
-	x, _ := new(big.Int).SetString(pubKeyX, 16)
-	y, _ := new(big.Int).SetString(pubKeyY, 16)
-
-	pubKey := &ecdsa.PublicKey{
-		Curve: elliptic.P256(),
-		X:     x,
-		Y:     y,
+	valid, err := webauthnp256.VerifyAssertion(xBytes, yBytes, sigBytes)
+	if err != nil {
+		log.Fatalf("Verification failed: %v", err)
 	}
 
-	// Verify the point is on curve
-	if !pubKey.Curve.IsOnCurve(pubKey.X, pubKey.Y) {
-		log.Fatal("Public key not on curve!")
-	}
-	fmt.Println("✅ Public key is on P-256 curve")
-
-	// Verify signature
-	valid := ecdsa.Verify(pubKey, messageHash[:], r, s)
-
 	fmt.Println()
 	if valid {
 		fmt.Println("✅✅✅ SIGNATURE IS VALID! ✅✅✅")
@@ -74,9 +62,9 @@ func main() {
 
 	// Additional debug info
 	fmt.Println("\n=== Debug Information ===")
-	fmt.Printf("r: %s\n", r.String())
-	fmt.Printf("s: %s\n", s.String())
-	fmt.Printf("authenticatorData length: %d bytes\n", len(authenticatorData))
-	fmt.Printf("clientDataJSON length: %d bytes\n", len(clientDataJSON))
-	fmt.Printf("clientDataJSON: %s\n", string(clientDataJSON))
+	fmt.Printf("r: %s\n", assertion.R.String())
+	fmt.Printf("s (low-S): %s\n", assertion.S.String())
+	fmt.Printf("authenticatorData length: %d bytes\n", len(assertion.AuthenticatorData))
+	fmt.Printf("clientDataJSON length: %d bytes\n", len(assertion.ClientDataJSON))
+	fmt.Printf("clientDataJSON: %s\n", string(assertion.ClientDataJSON))
 }
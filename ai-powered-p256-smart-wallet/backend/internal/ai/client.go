@@ -0,0 +1,369 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultModel        = "anthropic/claude-3.5-sonnet"
+	defaultBaseURL      = "https://openrouter.ai/api/v1/chat/completions"
+	defaultTimeout      = 60 * time.Second
+	defaultMaxRetries   = 2
+	defaultRetryBackoff = 500 * time.Millisecond
+	maxRetryBackoff     = 10 * time.Second
+)
+
+var (
+	// ErrMissingAPIKey is returned when no OpenRouter API key is configured
+	ErrMissingAPIKey = errors.New("OPENROUTER_API_KEY not set")
+	// ErrCreditsExhausted is returned (wrapped in an *APIError) when OpenRouter
+	// rejects a request because the account's credits or budget are used up
+	ErrCreditsExhausted = errors.New("OpenRouter credits exhausted")
+)
+
+// APIError is a non-200 response from OpenRouter
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("OpenRouter API error (status %d): %s", e.StatusCode, e.Message)
+}
+
+// Unwrap lets callers test for ErrCreditsExhausted with errors.Is
+func (e *APIError) Unwrap() error {
+	if e.StatusCode == http.StatusPaymentRequired {
+		return ErrCreditsExhausted
+	}
+	return nil
+}
+
+// retryable reports whether the request may succeed if sent again
+func (e *APIError) retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// ClientConfig configures the OpenRouter client
+type ClientConfig struct {
+	APIKey       string
+	Model        string
+	BaseURL      string
+	Timeout      time.Duration // Deadline for a whole call, retries and streaming included
+	MaxRetries   int           // Retries on 429/5xx and connection errors; negative disables
+	RetryBackoff time.Duration // Wait before the first retry, doubled for each further retry
+}
+
+// ClientConfigFromEnv reads the client configuration from OPENROUTER_* variables
+func ClientConfigFromEnv() ClientConfig {
+	cfg := ClientConfig{
+		APIKey:  os.Getenv("OPENROUTER_API_KEY"),
+		Model:   os.Getenv("OPENROUTER_MODEL"),
+		BaseURL: os.Getenv("OPENROUTER_BASE_URL"),
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("OPENROUTER_TIMEOUT")); err == nil && timeout > 0 {
+		cfg.Timeout = timeout
+	}
+	if retries, err := strconv.Atoi(os.Getenv("OPENROUTER_MAX_RETRIES")); err == nil {
+		cfg.MaxRetries = retries
+		if retries == 0 {
+			cfg.MaxRetries = -1
+		}
+	}
+	return cfg
+}
+
+// Client handles communication with OpenRouter's chat completions API
+type Client struct {
+	cfg    ClientConfig
+	client *http.Client
+}
+
+// NewClient creates an OpenRouter client, filling unset fields with defaults
+func NewClient(cfg ClientConfig) *Client {
+	if cfg.Model == "" {
+		cfg.Model = defaultModel
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultBaseURL
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = defaultMaxRetries
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = defaultRetryBackoff
+	}
+	// No http.Client timeout: streamed responses are bounded by the call's context instead
+	return &Client{cfg: cfg, client: &http.Client{}}
+}
+
+// Model returns the configured model
+func (c *Client) Model() string {
+	return c.cfg.Model
+}
+
+// Message represents a chat message
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest represents OpenRouter API request
+type ChatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Temperature float64   `json:"temperature,omitempty"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Stream      bool      `json:"stream,omitempty"`
+}
+
+// ChatResponse represents OpenRouter API response
+type ChatResponse struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Message struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// chatStreamChunk is one server-sent event of a streamed completion
+type chatStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Error *apiErrorBody `json:"error"`
+}
+
+// apiErrorBody is the error object OpenRouter returns in error responses and stream chunks
+type apiErrorBody struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Chat sends the conversation to the model and returns the complete reply
+func (c *Client) Chat(ctx context.Context, messages []Message) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := c.send(ctx, messages, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var chatResp ChatResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("no response from API")
+	}
+
+	content := chatResp.Choices[0].Message.Content
+	zerolog.Ctx(ctx).Info().
+		Str("model", c.cfg.Model).
+		Dur("latency", time.Since(start)).
+		Int("promptTokens", chatResp.Usage.PromptTokens).
+		Int("completionTokens", chatResp.Usage.CompletionTokens).
+		Msg("LLM chat completed")
+	return content, nil
+}
+
+// ChatStream streams the model's reply, calling onToken with each piece of content as it
+// arrives, and returns the complete reply. An error from onToken stops the stream.
+// Failures are retried only before the first token, so onToken never sees repeated content.
+func (c *Client) ChatStream(ctx context.Context, messages []Message, onToken func(string) error) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := c.send(ctx, messages, true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Blank lines separate events; lines starting with ':' are keep-alive comments
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk chatStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return content.String(), fmt.Errorf("failed to parse stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return content.String(), &APIError{StatusCode: chunk.Error.Code, Message: c.redact(chunk.Error.Message)}
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			content.WriteString(choice.Delta.Content)
+			if err := onToken(choice.Delta.Content); err != nil {
+				return content.String(), err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return content.String(), fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	zerolog.Ctx(ctx).Info().
+		Str("model", c.cfg.Model).
+		Dur("latency", time.Since(start)).
+		Int("length", content.Len()).
+		Msg("LLM chat stream completed")
+	return content.String(), nil
+}
+
+// send posts the request, retrying 429/5xx responses and connection errors with
+// exponential backoff (or the server's Retry-After), and returns the 200 response
+func (c *Client) send(ctx context.Context, messages []Message, stream bool) (*http.Response, error) {
+	if c.cfg.APIKey == "" {
+		return nil, ErrMissingAPIKey
+	}
+
+	jsonData, err := json.Marshal(ChatRequest{
+		Model:       c.cfg.Model,
+		Messages:    messages,
+		Temperature: 0.7,
+		MaxTokens:   2000,
+		Stream:      stream,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	logger := zerolog.Ctx(ctx)
+	for attempt := 0; ; attempt++ {
+		resp, err := c.post(ctx, jsonData)
+		var retryAfter time.Duration
+		switch {
+		case err != nil && ctx.Err() != nil:
+			return nil, fmt.Errorf("failed to send request: %w", ctx.Err())
+		case err != nil:
+			err = fmt.Errorf("failed to send request: %s", c.redact(err.Error()))
+		case resp.StatusCode == http.StatusOK:
+			return resp, nil
+		default:
+			apiErr := c.readAPIError(resp)
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+			if !apiErr.retryable() {
+				return nil, apiErr
+			}
+			err = apiErr
+		}
+
+		if attempt >= c.cfg.MaxRetries {
+			return nil, err
+		}
+		wait := c.backoff(attempt, retryAfter)
+		logger.Warn().Err(err).Int("attempt", attempt+1).Dur("wait", wait).Msg("LLM request failed, retrying")
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// post sends one request to OpenRouter
+func (c *Client) post(ctx context.Context, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.BaseURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.cfg.APIKey)
+	req.Header.Set("HTTP-Referer", "https://ai-wallet.app")
+	req.Header.Set("X-Title", "AI Wallet")
+	return c.client.Do(req)
+}
+
+// readAPIError consumes an error response, preferring OpenRouter's error message over the raw body
+func (c *Client) readAPIError(resp *http.Response) *APIError {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	message := strings.TrimSpace(string(body))
+	var parsed struct {
+		Error *apiErrorBody `json:"error"`
+	}
+	if json.Unmarshal(body, &parsed) == nil && parsed.Error != nil && parsed.Error.Message != "" {
+		message = parsed.Error.Message
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: c.redact(message)}
+}
+
+// backoff returns how long to wait before retry attempt+1
+func (c *Client) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, maxRetryBackoff)
+	}
+	return min(c.cfg.RetryBackoff<<attempt, maxRetryBackoff)
+}
+
+// redact removes the API key from text that may end up in logs or error messages
+func (c *Client) redact(s string) string {
+	if c.cfg.APIKey == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, c.cfg.APIKey, "[REDACTED]")
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
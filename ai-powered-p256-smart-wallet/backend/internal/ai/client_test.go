@@ -0,0 +1,204 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testAPIKey = "sk-or-test-secret-key"
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *atomic.Int32) {
+	t.Helper()
+	hits := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if got := r.Header.Get("Authorization"); got != "Bearer "+testAPIKey {
+			t.Errorf("Authorization = %q", got)
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(ClientConfig{
+		APIKey:       testAPIKey,
+		Model:        "test/model",
+		BaseURL:      server.URL,
+		Timeout:      2 * time.Second,
+		RetryBackoff: time.Millisecond,
+	})
+	return client, hits
+}
+
+func writeCompletion(w http.ResponseWriter, content string) {
+	fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%q}}]}`, content)
+}
+
+func TestClientChatRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	client, hits := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "test/model" || req.Stream {
+			t.Errorf("unexpected request %+v (%v)", req, err)
+		}
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			writeCompletion(w, "你好")
+		}
+	})
+
+	content, err := client.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content != "你好" {
+		t.Fatalf("content = %q", content)
+	}
+	if hits.Load() != 3 {
+		t.Fatalf("expected 3 attempts, got %d", hits.Load())
+	}
+}
+
+func TestClientChatGivesUpAfterMaxRetries(t *testing.T) {
+	client, hits := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := client.Chat(context.Background(), nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 APIError, got %v", err)
+	}
+	if hits.Load() != defaultMaxRetries+1 {
+		t.Fatalf("expected %d attempts, got %d", defaultMaxRetries+1, hits.Load())
+	}
+}
+
+func TestClientChatCreditsExhausted(t *testing.T) {
+	client, hits := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPaymentRequired)
+		w.Write([]byte(`{"error":{"code":402,"message":"Insufficient credits for key ` + testAPIKey + `"}}`))
+	})
+
+	_, err := client.Chat(context.Background(), nil)
+	if !errors.Is(err, ErrCreditsExhausted) {
+		t.Fatalf("expected ErrCreditsExhausted, got %v", err)
+	}
+	if hits.Load() != 1 {
+		t.Fatalf("402 must not be retried, got %d attempts", hits.Load())
+	}
+	if strings.Contains(err.Error(), testAPIKey) {
+		t.Fatalf("API key leaked in error: %v", err)
+	}
+	if !strings.Contains(err.Error(), "Insufficient credits") {
+		t.Fatalf("expected OpenRouter's message in error, got %v", err)
+	}
+}
+
+func TestClientChatTimeout(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.Chat(ctx, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("call outlived its context: %v", elapsed)
+	}
+}
+
+func TestClientRequiresAPIKey(t *testing.T) {
+	_, err := NewClient(ClientConfig{}).Chat(context.Background(), nil)
+	if !errors.Is(err, ErrMissingAPIKey) {
+		t.Fatalf("expected ErrMissingAPIKey, got %v", err)
+	}
+}
+
+func writeStream(w http.ResponseWriter, tokens ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	fmt.Fprint(w, ": OPENROUTER PROCESSING\n\n")
+	for _, token := range tokens {
+		fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", token)
+		w.(http.Flusher).Flush()
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+func TestClientChatStream(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.Stream {
+			t.Errorf("expected a streaming request, got %+v (%v)", req, err)
+		}
+		writeStream(w, "你好", "，", "世界")
+	})
+
+	var tokens []string
+	content, err := client.ChatStream(context.Background(), nil, func(token string) error {
+		tokens = append(tokens, token)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content != "你好，世界" || strings.Join(tokens, "|") != "你好|，|世界" {
+		t.Fatalf("content = %q, tokens = %q", content, tokens)
+	}
+}
+
+func TestClientChatStreamError(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"error\":{\"code\":402,\"message\":\"credits exhausted\"}}\n\n")
+	})
+
+	content, err := client.ChatStream(context.Background(), nil, func(string) error { return nil })
+	if !errors.Is(err, ErrCreditsExhausted) {
+		t.Fatalf("expected ErrCreditsExhausted, got %v", err)
+	}
+	if content != "hi" {
+		t.Fatalf("content = %q", content)
+	}
+}
+
+func TestUITextFilterHidesUIBlock(t *testing.T) {
+	var out strings.Builder
+	filter := &uiTextFilter{emit: func(text string) error {
+		out.WriteString(text)
+		return nil
+	}}
+
+	// 标签被拆分在多个片段中
+	for _, token := range []string{"请确认：<", "ai", "ui>{\"operation\":", "{}}</ai", "ui>", "\n谢谢 <b", "> <"} {
+		if err := filter.write(token); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := filter.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := out.String(); got != "请确认：\n谢谢 <b> <" {
+		t.Fatalf("filtered text = %q", got)
+	}
+}
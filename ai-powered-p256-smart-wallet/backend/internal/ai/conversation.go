@@ -0,0 +1,188 @@
+package ai
+
+import (
+	"ai-wallet-backend/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ConversationStore persists each user's chat turns
+type ConversationStore interface {
+	// Append adds messages to userID's conversation, creating it on first use
+	Append(ctx context.Context, userID string, messages ...models.Message) error
+	// Recent returns up to limit of userID's latest messages, oldest first
+	Recent(ctx context.Context, userID string, limit int) ([]models.Message, error)
+	// Clear deletes userID's conversation and returns how many messages were removed
+	Clear(ctx context.Context, userID string) (int64, error)
+}
+
+// NewTurn builds the messages stored for one exchange: the user's message and the
+// assistant's reply, with its UI components kept as JSON
+func NewTurn(userMessage string, response *models.AIResponse) ([]models.Message, error) {
+	reply := models.Message{Role: models.RoleAssistant, Content: response.Message}
+	if response.AIResponse != nil {
+		ui, err := json.Marshal(response.AIResponse)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode UI components: %w", err)
+		}
+		reply.UI = string(ui)
+	}
+	return []models.Message{{Role: models.RoleUser, Content: userMessage}, reply}, nil
+}
+
+// ChatHistory converts stored messages into model history; an assistant turn's UI
+// components are restored as an <aiui> block so the model sees what it showed the user
+func ChatHistory(messages []models.Message) []models.ChatMessage {
+	history := make([]models.ChatMessage, 0, len(messages))
+	for _, msg := range messages {
+		content := msg.Content
+		if msg.UI != "" {
+			content += "\n\n" + uiStartTag + "\n" + msg.UI + "\n" + uiEndTag
+		}
+		history = append(history, models.ChatMessage{Role: msg.Role, Content: content})
+	}
+	return history
+}
+
+// prepareMessages assigns IDs and increasing timestamps so a turn keeps its order
+func prepareMessages(conversationID string, messages []models.Message) {
+	now := time.Now()
+	for i := range messages {
+		messages[i].ConversationID = conversationID
+		if messages[i].ID == "" {
+			messages[i].ID = uuid.New().String()
+		}
+		if messages[i].CreatedAt.IsZero() {
+			messages[i].CreatedAt = now.Add(time.Duration(i) * time.Microsecond)
+		}
+	}
+}
+
+// GormConversationStore stores conversations in Postgres
+type GormConversationStore struct {
+	db *gorm.DB
+}
+
+// NewGormConversationStore creates a Postgres-backed conversation store
+func NewGormConversationStore(db *gorm.DB) *GormConversationStore {
+	return &GormConversationStore{db: db}
+}
+
+// Append adds messages to userID's conversation
+func (s *GormConversationStore) Append(ctx context.Context, userID string, messages ...models.Message) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		conversation := models.Conversation{ID: uuid.New().String(), UserID: userID}
+		if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}}, DoNothing: true}).Create(&conversation).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).First(&conversation).Error; err != nil {
+			return err
+		}
+
+		prepareMessages(conversation.ID, messages)
+		if err := tx.Create(&messages).Error; err != nil {
+			return err
+		}
+		return tx.Model(&conversation).Update("updated_at", time.Now()).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save conversation messages: %w", err)
+	}
+	return nil
+}
+
+// Recent returns userID's latest messages, oldest first
+func (s *GormConversationStore) Recent(ctx context.Context, userID string, limit int) ([]models.Message, error) {
+	var messages []models.Message
+	err := s.db.WithContext(ctx).
+		Where("conversation_id = (?)", s.db.Model(&models.Conversation{}).Select("id").Where("user_id = ?", userID)).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation: %w", err)
+	}
+	reverseMessages(messages)
+	return messages, nil
+}
+
+// Clear deletes userID's conversation and its messages
+func (s *GormConversationStore) Clear(ctx context.Context, userID string) (int64, error) {
+	var deleted int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var conversation models.Conversation
+		if err := tx.Where("user_id = ?", userID).First(&conversation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		result := tx.Where("conversation_id = ?", conversation.ID).Delete(&models.Message{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		return tx.Delete(&conversation).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear conversation: %w", err)
+	}
+	return deleted, nil
+}
+
+// MemoryConversationStore keeps conversations in process memory (tests and local development)
+type MemoryConversationStore struct {
+	mu       sync.RWMutex
+	messages map[string][]models.Message // userID → messages, oldest first
+}
+
+// NewMemoryConversationStore creates an in-memory conversation store
+func NewMemoryConversationStore() *MemoryConversationStore {
+	return &MemoryConversationStore{messages: make(map[string][]models.Message)}
+}
+
+// Append adds messages to userID's conversation
+func (s *MemoryConversationStore) Append(ctx context.Context, userID string, messages ...models.Message) error {
+	prepareMessages(userID, messages)
+	s.mu.Lock()
+	s.messages[userID] = append(s.messages[userID], messages...)
+	s.mu.Unlock()
+	return nil
+}
+
+// Recent returns userID's latest messages, oldest first
+func (s *MemoryConversationStore) Recent(ctx context.Context, userID string, limit int) ([]models.Message, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	messages := s.messages[userID]
+	if len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+	return append([]models.Message(nil), messages...), nil
+}
+
+// Clear deletes userID's conversation
+func (s *MemoryConversationStore) Clear(ctx context.Context, userID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := int64(len(s.messages[userID]))
+	delete(s.messages, userID)
+	return deleted, nil
+}
+
+func reverseMessages(messages []models.Message) {
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+}
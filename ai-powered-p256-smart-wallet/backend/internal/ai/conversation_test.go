@@ -0,0 +1,81 @@
+package ai
+
+import (
+	"ai-wallet-backend/internal/models"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestMemoryConversationStoreRecentAndClear(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryConversationStore()
+
+	for _, text := range []string{"第一条", "第二条", "第三条"} {
+		turn, err := NewTurn(text, &models.AIResponse{Message: "回复" + text})
+		if err != nil {
+			t.Fatalf("NewTurn: %v", err)
+		}
+		if err := store.Append(ctx, "user-a", turn...); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	if err := store.Append(ctx, "user-b", models.Message{Role: models.RoleUser, Content: "other"}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	recent, err := store.Recent(ctx, "user-a", 3)
+	if err != nil {
+		t.Fatalf("Recent: %v", err)
+	}
+	var got []string
+	for _, msg := range recent {
+		got = append(got, msg.Role+":"+msg.Content)
+	}
+	if want := "assistant:回复第二条|user:第三条|assistant:回复第三条"; strings.Join(got, "|") != want {
+		t.Fatalf("Recent = %q, want %q", strings.Join(got, "|"), want)
+	}
+	if !recent[1].CreatedAt.Before(recent[2].CreatedAt) {
+		t.Fatalf("assistant reply must sort after the user message")
+	}
+
+	deleted, err := store.Clear(ctx, "user-a")
+	if err != nil || deleted != 6 {
+		t.Fatalf("Clear = %d, %v; want 6", deleted, err)
+	}
+	if recent, _ := store.Recent(ctx, "user-a", 10); len(recent) != 0 {
+		t.Fatalf("expected empty history after Clear, got %d messages", len(recent))
+	}
+	if recent, _ := store.Recent(ctx, "user-b", 10); len(recent) != 1 {
+		t.Fatalf("Clear must not touch other users, got %d messages", len(recent))
+	}
+}
+
+func TestChatHistoryRestoresUIBlock(t *testing.T) {
+	turn, err := NewTurn("转账 0.5 HSK", &models.AIResponse{
+		Message: "请确认转账",
+		AIResponse: &models.AIStructure{Operation: &models.Operation{
+			Action:    "transfer",
+			Amount:    0.5,
+			Recipient: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1",
+			ChainID:   WalletChainID,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewTurn: %v", err)
+	}
+
+	history := ChatHistory(turn)
+	if len(history) != 2 || history[0].Content != "转账 0.5 HSK" {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+
+	// 恢复后的助手消息可以被重新解析
+	text, ui, err := ParseResponse(history[1].Content)
+	if err != nil {
+		t.Fatalf("ParseResponse: %v", err)
+	}
+	if text != "请确认转账" || ui.Operation == nil || ui.Operation.Amount != 0.5 {
+		t.Fatalf("unexpected restored turn: %q %+v", text, ui)
+	}
+}
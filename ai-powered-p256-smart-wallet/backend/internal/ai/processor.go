@@ -2,57 +2,123 @@ package ai
 
 import (
 	"ai-wallet-backend/internal/models"
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 )
 
 // Processor 处理用户输入并生成AI响应
 type Processor struct {
-	llmClient *LLMClient
+	llmClient *Client
+	window    ContextWindow
+	chainID   int // 钱包所在的链，AI 生成的操作只能使用这条链
 }
 
-// NewProcessor 创建新的AI处理器
-func NewProcessor() *Processor {
+// NewProcessor 创建新的AI处理器，chainID 为钱包所在的链（<= 0 时使用 WalletChainID）
+func NewProcessor(client *Client, window ContextWindow, chainID int) *Processor {
+	if chainID <= 0 {
+		chainID = WalletChainID
+	}
 	return &Processor{
-		llmClient: NewLLMClient(),
+		llmClient: client,
+		window:    window.withDefaults(),
+		chainID:   chainID,
 	}
 }
 
-// ProcessMessage 处理用户消息并生成结构化响应
-func (p *Processor) ProcessMessage(message string, history []models.ChatMessage) (*models.AIResponse, error) {
-	log.Println("╔════════════════════════════════════════╗")
-	log.Println("║     AI PROCESSOR: New Message          ║")
-	log.Println("╚════════════════════════════════════════╝")
-	log.Printf("📝 User message: %s\n", message)
-	log.Printf("📚 History count: %d messages\n", len(history))
-	
-	// 构建消息列表（包含历史）
-	messages := []Message{{Role: "system", Content: SystemPrompt}}
-	
-	// 添加历史消息（最多保留最近10条）
-	maxHistory := 10
-	startIdx := 0
-	if len(history) > maxHistory {
-		startIdx = len(history) - maxHistory
+// HistoryLimit 发送给模型的最多历史消息数
+func (p *Processor) HistoryLimit() int {
+	return p.window.MaxMessages
+}
+
+// 上下文窗口默认值
+const (
+	DefaultContextMessages = 20
+	DefaultContextTokens   = 12000
+)
+
+// ContextWindow 发送给模型的历史范围：最近 MaxMessages 条，且总估算 token 数
+// （含系统提示词和当前消息）不超过 MaxTokens，超出时从最早的消息开始丢弃
+type ContextWindow struct {
+	MaxMessages int
+	MaxTokens   int
+}
+
+// ContextWindowFromEnv 读取 CHAT_CONTEXT_MESSAGES / CHAT_CONTEXT_TOKENS
+func ContextWindowFromEnv() ContextWindow {
+	var window ContextWindow
+	if n, err := strconv.Atoi(os.Getenv("CHAT_CONTEXT_MESSAGES")); err == nil {
+		window.MaxMessages = n
 	}
-	
-	for i := startIdx; i < len(history); i++ {
-		messages = append(messages, Message{
-			Role:    history[i].Role,
-			Content: history[i].Content,
-		})
+	if n, err := strconv.Atoi(os.Getenv("CHAT_CONTEXT_TOKENS")); err == nil {
+		window.MaxTokens = n
+	}
+	return window.withDefaults()
+}
+
+func (w ContextWindow) withDefaults() ContextWindow {
+	if w.MaxMessages <= 0 {
+		w.MaxMessages = DefaultContextMessages
+	}
+	if w.MaxTokens <= 0 {
+		w.MaxTokens = DefaultContextTokens
+	}
+	return w
+}
+
+// messageOverheadTokens 每条消息的角色与分隔符开销
+const messageOverheadTokens = 4
+
+// estimateTokens 粗略估算 token 数：ASCII 约 4 字符一个 token，其他字符（中文等）按一个 token 计
+func estimateTokens(content string) int {
+	ascii, other := 0, 0
+	for _, r := range content {
+		if r < 128 {
+			ascii++
+		} else {
+			other++
+		}
 	}
-	log.Printf("✓ Using %d history messages (from index %d)\n", len(history)-startIdx, startIdx)
-	
-	// 添加当前用户消息
-	messages = append(messages, Message{Role: "user", Content: message})
-	log.Printf("✓ Total messages to LLM: %d (1 system + %d history + 1 current)\n", len(messages), len(history)-startIdx)
-
-	log.Println("🚀 Calling LLM API...")
-	llmResponse, err := p.llmClient.Chat(messages)
+	return (ascii+3)/4 + other + messageOverheadTokens
+}
+
+// buildMessages 构建消息列表：系统提示词 + 窗口内的历史消息 + 当前用户消息
+func (p *Processor) buildMessages(message string, history []models.ChatMessage) []Message {
+	if len(history) > p.window.MaxMessages {
+		history = history[len(history)-p.window.MaxMessages:]
+	}
+
+	// 系统提示词和当前消息总是保留，历史从最新往前直到预算用完
+	budget := p.window.MaxTokens - estimateTokens(SystemPrompt) - estimateTokens(message)
+	start := len(history)
+	for start > 0 {
+		cost := estimateTokens(history[start-1].Content)
+		if cost > budget {
+			break
+		}
+		budget -= cost
+		start--
+	}
+	if start > 0 {
+		log.Printf("✂️  Context window: dropped %d oldest history messages\n", start)
+	}
+
+	messages := []Message{{Role: "system", Content: SystemPrompt}}
+	for _, msg := range history[start:] {
+		messages = append(messages, Message{Role: msg.Role, Content: msg.Content})
+	}
+	return append(messages, Message{Role: "user", Content: message})
+}
+
+// ProcessMessage 处理用户消息并生成结构化响应
+func (p *Processor) ProcessMessage(ctx context.Context, message string, history []models.ChatMessage) (*models.AIResponse, error) {
+	log.Printf("📝 User message: %s (history: %d messages)\n", message, len(history))
+
+	llmResponse, err := p.llmClient.Chat(ctx, p.buildMessages(message, history))
 	if err != nil {
 		// 如果 LLM 调用失败，回退到关键词匹配
 		log.Printf("❌ LLM error: %v\n", err)
@@ -61,32 +127,111 @@ func (p *Processor) ProcessMessage(message string, history []models.ChatMessage)
 	}
 
 	log.Printf("✓ LLM returned response (length: %d)\n", len(llmResponse))
-	log.Printf("📄 LLM response preview: %s...\n", truncateString(llmResponse, 150))
 
 	// 解析 LLM 返回的响应（支持纯文本或带 <aiui> 标签）
-	log.Println("🔍 Parsing LLM response...")
 	response, err := p.parseAIResponse(llmResponse)
 	if err != nil {
-		log.Printf("❌ Failed to parse LLM response: %v\n", err)
+		log.Println("⚠️  Falling back to keyword matching mode")
+		return p.fallbackResponse(message)
+	}
+	return response, nil
+}
+
+// ProcessMessageStream 流式处理用户消息：模型输出的文本（不含 <aiui> 块）逐段交给 onText，
+// 结束后返回与 ProcessMessage 相同的结构化响应。
+// 尚未输出任何文本时 LLM 失败会回退到关键词匹配；输出中途失败则返回错误。
+func (p *Processor) ProcessMessageStream(ctx context.Context, message string, history []models.ChatMessage, onText func(string) error) (*models.AIResponse, error) {
+	log.Printf("📝 User message (stream): %s (history: %d messages)\n", message, len(history))
+
+	filter := &uiTextFilter{emit: onText}
+	llmResponse, err := p.llmClient.ChatStream(ctx, p.buildMessages(message, history), filter.write)
+	if err == nil {
+		err = filter.flush()
+	}
+	if err != nil {
+		log.Printf("❌ LLM stream error: %v\n", err)
+		if filter.emitted {
+			return nil, err
+		}
 		log.Println("⚠️  Falling back to keyword matching mode")
 		return p.fallbackResponse(message)
 	}
 
-	log.Println("✅ Successfully parsed AI response")
-	if response.Message != "" {
-		log.Printf("💬 Response message: %s\n", truncateString(response.Message, 80))
+	response, err := p.parseAIResponse(llmResponse)
+	if err != nil {
+		// 文本已经发出，只丢弃未通过校验的 UI 组件
+		return &models.AIResponse{Message: strings.TrimSpace(filter.text.String())}, nil
 	}
-	if response.AIResponse != nil {
-		if response.AIResponse.Form != nil {
-			log.Printf("📋 Response contains form: %s\n", response.AIResponse.Form.Title)
+	return response, nil
+}
+
+// uiTextFilter 转发流式输出中 <aiui>...</aiui> 之外的文本；
+// 末尾可能是标签开头的片段先暂存，等后续内容到达再决定是否输出
+type uiTextFilter struct {
+	emit    func(string) error
+	pending string
+	inUI    bool
+	emitted bool
+	text    strings.Builder // 已输出的文本
+}
+
+func (f *uiTextFilter) write(token string) error {
+	f.pending += token
+	for {
+		if f.inUI {
+			idx := strings.Index(f.pending, uiEndTag)
+			if idx == -1 {
+				f.pending = f.pending[len(f.pending)-partialTagSuffix(f.pending, uiEndTag):]
+				return nil
+			}
+			f.pending = f.pending[idx+len(uiEndTag):]
+			f.inUI = false
+			continue
 		}
-		if response.AIResponse.Operation != nil {
-			log.Printf("⚡ Response contains operation: %s\n", response.AIResponse.Operation.Action)
+
+		if idx := strings.Index(f.pending, uiStartTag); idx != -1 {
+			if err := f.send(f.pending[:idx]); err != nil {
+				return err
+			}
+			f.pending = f.pending[idx+len(uiStartTag):]
+			f.inUI = true
+			continue
 		}
+
+		keep := partialTagSuffix(f.pending, uiStartTag)
+		text := f.pending[:len(f.pending)-keep]
+		f.pending = f.pending[len(f.pending)-keep:]
+		return f.send(text)
 	}
-	log.Println("════════════════════════════════════════")
+}
 
-	return response, nil
+// flush 输出暂存的文本（流结束时调用）
+func (f *uiTextFilter) flush() error {
+	if f.inUI {
+		return nil
+	}
+	text := f.pending
+	f.pending = ""
+	return f.send(text)
+}
+
+func (f *uiTextFilter) send(text string) error {
+	if text == "" {
+		return nil
+	}
+	f.emitted = true
+	f.text.WriteString(text)
+	return f.emit(text)
+}
+
+// partialTagSuffix 返回 s 末尾与 tag 前缀重合的长度
+func partialTagSuffix(s, tag string) int {
+	for n := min(len(s), len(tag)-1); n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
 }
 
 func truncateString(s string, maxLen int) string {
@@ -96,65 +241,23 @@ func truncateString(s string, maxLen int) string {
 	return s[:maxLen]
 }
 
-// parseAIResponse 解析 LLM 返回的响应（支持纯文本或带 <aiui> 标签的格式）
+// parseAIResponse 解析 LLM 返回的响应（支持纯文本或带 <aiui> 标签的格式），UI 组件校验失败时返回错误
 func (p *Processor) parseAIResponse(response string) (*models.AIResponse, error) {
-	response = strings.TrimSpace(response)
-	
-	// 检查是否包含 <aiui> 标签
-	startTag := "<aiui>"
-	endTag := "</aiui>"
-	startIdx := strings.Index(response, startTag)
-	
-	// 情况1: 没有 <aiui> 标签，纯文本响应
-	if startIdx == -1 {
-		log.Println("📝 Response format: Plain text (no UI components)")
-		return &models.AIResponse{
-			Message:    response,
-			AIResponse: nil,
-		}, nil
-	}
-	
-	// 情况2: 有 <aiui> 标签，需要解析
-	log.Println("📝 Response format: Text + XML tags (has UI components)")
-	
-	endIdx := strings.Index(response, endTag)
-	if endIdx == -1 {
-		log.Println("⚠️  Warning: Found <aiui> start tag but no closing tag")
-		return nil, fmt.Errorf("malformed response: found <aiui> but missing </aiui>")
-	}
-	
-	// 提取各部分
-	textBefore := strings.TrimSpace(response[:startIdx])
-	jsonContent := strings.TrimSpace(response[startIdx+len(startTag) : endIdx])
-	textAfter := strings.TrimSpace(response[endIdx+len(endTag):])
-	
-	log.Printf("📄 Text before tag: %s\n", truncateString(textBefore, 50))
-	log.Printf("📦 JSON content length: %d bytes\n", len(jsonContent))
-	log.Printf("📄 Text after tag: %s\n", truncateString(textAfter, 50))
-	
-	// 组合完整的消息文本
-	var messageParts []string
-	if textBefore != "" {
-		messageParts = append(messageParts, textBefore)
-	}
-	if textAfter != "" {
-		messageParts = append(messageParts, textAfter)
+	text, ui, err := ParseResponseForChain(response, p.chainID)
+	if err != nil {
+		log.Printf("❌ Rejected LLM response: %v\n", err)
+		return nil, err
 	}
-	finalMessage := strings.Join(messageParts, "\n\n")
-	
-	// 解析 JSON 内容
-	var aiStructure models.AIStructure
-	if err := json.Unmarshal([]byte(jsonContent), &aiStructure); err != nil {
-		log.Printf("❌ Failed to parse JSON inside <aiui> tag: %v\n", err)
-		log.Printf("📄 JSON content: %s\n", jsonContent)
-		return nil, fmt.Errorf("failed to parse <aiui> JSON content: %w", err)
+
+	if ui == nil {
+		log.Println("📝 Response format: Plain text (no UI components)")
+	} else {
+		log.Println("✓ Successfully parsed UI components from <aiui> tag")
 	}
-	
-	log.Println("✓ Successfully parsed UI components from <aiui> tag")
-	
+
 	return &models.AIResponse{
-		Message:    finalMessage,
-		AIResponse: &aiStructure,
+		Message:    text,
+		AIResponse: ui,
 	}, nil
 }
 
@@ -214,8 +317,8 @@ func (p *Processor) generateTransferResponse(message string) *models.AIStructure
 			Asset:       "USDT",
 			Amount:      100,
 			Recipient:   "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb",
-			ChainID:     1,
-			GasEstimate: "0.003 ETH (~$8.50)",
+			ChainID:     p.chainID,
+			GasEstimate: "0.002 HSK",
 			Parameters: map[string]interface{}{
 				"deadline":    time.Now().Add(20 * time.Minute).Unix(),
 				"slippage":    "0.5%",
@@ -260,8 +363,8 @@ func (p *Processor) generateSwapResponse(message string) *models.AIStructure {
 			Action:      "swap",
 			Asset:       "ETH → USDC",
 			Amount:      1.5,
-			ChainID:     1,
-			GasEstimate: "0.005 ETH (~$14.20)",
+			ChainID:     p.chainID,
+			GasEstimate: "0.003 HSK",
 			Parameters: map[string]interface{}{
 				"fromToken":     "ETH",
 				"toToken":       "USDC",
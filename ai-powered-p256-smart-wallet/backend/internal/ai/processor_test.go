@@ -0,0 +1,87 @@
+package ai
+
+import (
+	"ai-wallet-backend/internal/models"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func testHistory(n int, content string) []models.ChatMessage {
+	history := make([]models.ChatMessage, n)
+	for i := range history {
+		role := models.RoleUser
+		if i%2 == 1 {
+			role = models.RoleAssistant
+		}
+		history[i] = models.ChatMessage{Role: role, Content: fmt.Sprintf("%d:%s", i, content)}
+	}
+	return history
+}
+
+func TestBuildMessagesLimitsMessageCount(t *testing.T) {
+	p := NewProcessor(nil, ContextWindow{MaxMessages: 4}, WalletChainID)
+
+	messages := p.buildMessages("现在", testHistory(10, "hi"))
+	if len(messages) != 6 {
+		t.Fatalf("expected system + 4 history + current, got %d", len(messages))
+	}
+	if messages[0].Role != "system" || messages[1].Content != "6:hi" || messages[5].Content != "现在" {
+		t.Fatalf("unexpected messages: %+v", messages[1:])
+	}
+}
+
+func TestBuildMessagesTruncatesOldestOverTokenBudget(t *testing.T) {
+	long := strings.Repeat("转账", 500) // ~1000 tokens per message
+	base := estimateTokens(SystemPrompt) + estimateTokens("现在")
+	p := NewProcessor(nil, ContextWindow{MaxMessages: 20, MaxTokens: base + 3*estimateTokens("0:"+long)}, WalletChainID)
+
+	messages := p.buildMessages("现在", testHistory(8, long))
+	if len(messages) != 5 {
+		t.Fatalf("expected system + 3 newest history + current, got %d", len(messages))
+	}
+	if !strings.HasPrefix(messages[1].Content, "5:") || !strings.HasPrefix(messages[3].Content, "7:") {
+		t.Fatalf("expected the newest messages to be kept, got %q..%q", messages[1].Content[:2], messages[3].Content[:2])
+	}
+
+	total := 0
+	for _, msg := range messages {
+		total += estimateTokens(msg.Content)
+	}
+	if total > p.window.MaxTokens {
+		t.Fatalf("estimated %d tokens exceeds budget %d", total, p.window.MaxTokens)
+	}
+
+	// 预算不足时只保留系统提示词和当前消息
+	p = NewProcessor(nil, ContextWindow{MaxTokens: 1}, WalletChainID)
+	if messages := p.buildMessages("现在", testHistory(2, "hi")); len(messages) != 2 {
+		t.Fatalf("expected only system + current, got %d", len(messages))
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	if got := estimateTokens("abcdefgh"); got != 2+messageOverheadTokens {
+		t.Fatalf("ASCII estimate = %d", got)
+	}
+	if got := estimateTokens("你好"); got != 2+messageOverheadTokens {
+		t.Fatalf("CJK estimate = %d", got)
+	}
+}
+
+func TestProcessMessageRejectsForeignChainOperation(t *testing.T) {
+	const attacker = "0x00000000000000000000000000000000DeaDBeef"
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeCompletion(w, `好的<aiui>{"operation":{"action":"transfer","asset":"ETH","amount":5,"recipient":"`+attacker+`","chainId":1}}</aiui>`)
+	})
+	p := NewProcessor(client, ContextWindow{}, WalletChainID)
+
+	response, err := p.ProcessMessage(context.Background(), "转账 5 ETH", nil)
+	if err != nil {
+		t.Fatalf("ProcessMessage: %v", err)
+	}
+	if op := response.AIResponse.Operation; op != nil && (op.ChainID != WalletChainID || op.Recipient == attacker) {
+		t.Fatalf("foreign-chain operation reached the user: %+v", op)
+	}
+}
@@ -0,0 +1,160 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ai-wallet-backend/internal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	uiStartTag = "<aiui>"
+	uiEndTag   = "</aiui>"
+
+	// WalletChainID 钱包唯一支持的链（HashKey Chain Testnet），见 SystemPrompt
+	WalletChainID = 133
+)
+
+var (
+	// ErrMalformedResponse <aiui> 标签不完整或 JSON 无法解析
+	ErrMalformedResponse = errors.New("malformed AI response")
+	// ErrInvalidOperation 操作卡片缺少必要信息或参数非法
+	ErrInvalidOperation = errors.New("invalid AI operation")
+	// ErrForeignChain 操作卡片指定了钱包所在链以外的 chainId
+	ErrForeignChain = errors.New("AI operation targets a foreign chain")
+)
+
+// forbiddenFields 网络选择字段：表单字段和操作参数中出现时会被移除，链由服务端决定
+var forbiddenFields = map[string]bool{
+	"chainid": true,
+	"network": true,
+	"chain":   true,
+}
+
+// UIBlock <aiui> 标签内的 UI 组件
+type UIBlock = models.AIStructure
+
+// ParseResponse 使用 WalletChainID 调用 ParseResponseForChain
+func ParseResponse(raw string) (text string, ui *UIBlock, err error) {
+	return ParseResponseForChain(raw, WalletChainID)
+}
+
+// ParseResponseForChain 拆分模型输出的文本与 <aiui> 块，并在执行前校验 UI 组件：
+// 转账操作须有合法的收款地址和正数金额；表单和操作参数中的网络选择字段会被移除；
+// 操作卡片指定了 chainID 以外的链时返回 ErrForeignChain，否则 chainId 总是被设为 chainID。
+// 没有 <aiui> 标签时 ui 为 nil。
+func ParseResponseForChain(raw string, chainID int) (text string, ui *UIBlock, err error) {
+	raw = strings.TrimSpace(raw)
+
+	startIdx := strings.Index(raw, uiStartTag)
+	if startIdx == -1 {
+		if strings.Contains(raw, uiEndTag) {
+			return "", nil, fmt.Errorf("%w: found %s without %s", ErrMalformedResponse, uiEndTag, uiStartTag)
+		}
+		return raw, nil, nil
+	}
+
+	endIdx := strings.Index(raw[startIdx:], uiEndTag)
+	if endIdx == -1 {
+		return "", nil, fmt.Errorf("%w: found %s but missing %s", ErrMalformedResponse, uiStartTag, uiEndTag)
+	}
+	endIdx += startIdx
+
+	rest := raw[endIdx+len(uiEndTag):]
+	if strings.Contains(rest, uiStartTag) {
+		return "", nil, fmt.Errorf("%w: multiple %s blocks", ErrMalformedResponse, uiStartTag)
+	}
+
+	// 标签前后的文本合并为消息
+	var parts []string
+	for _, part := range []string{raw[:startIdx], rest} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	text = strings.Join(parts, "\n\n")
+
+	payload := []byte(strings.TrimSpace(raw[startIdx+len(uiStartTag) : endIdx]))
+	ui = &UIBlock{}
+	if err := json.Unmarshal(payload, ui); err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrMalformedResponse, err)
+	}
+	if err := validateUIBlock(payload, ui, chainID); err != nil {
+		return "", nil, err
+	}
+
+	return text, ui, nil
+}
+
+// validateUIBlock 校验解析后的 UI 组件并移除网络选择字段，payload 用于检查类型化结构之外的字段
+func validateUIBlock(payload []byte, ui *UIBlock, chainID int) error {
+	if ui.Form != nil {
+		fields := ui.Form.Fields[:0]
+		for _, field := range ui.Form.Fields {
+			if !forbiddenFields[strings.ToLower(field.Name)] {
+				fields = append(fields, field)
+			}
+		}
+		ui.Form.Fields = fields
+	}
+
+	if ui.Operation == nil {
+		return nil
+	}
+
+	// 链只能是钱包所在的链；network/chain 等其他字段不在 Operation 中，解析时已被丢弃
+	var raw struct {
+		Operation map[string]json.RawMessage `json:"operation"`
+	}
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedResponse, err)
+	}
+	for key := range raw.Operation {
+		if strings.EqualFold(key, "chainId") && ui.Operation.ChainID != chainID {
+			return fmt.Errorf("%w: operation chainId %d", ErrForeignChain, ui.Operation.ChainID)
+		}
+	}
+	for key, value := range ui.Operation.Parameters {
+		if !forbiddenFields[strings.ToLower(key)] {
+			continue
+		}
+		if strings.EqualFold(key, "chainId") && !sameChain(value, chainID) {
+			return fmt.Errorf("%w: operation parameter chainId %v", ErrForeignChain, value)
+		}
+		delete(ui.Operation.Parameters, key)
+	}
+	ui.Operation.ChainID = chainID
+
+	if ui.Operation.Action == "transfer" {
+		return validateTransfer(ui.Operation)
+	}
+	return nil
+}
+
+// sameChain 判断操作参数中的 chainId（数字或字符串）是否为 chainID
+func sameChain(value interface{}, chainID int) bool {
+	switch v := value.(type) {
+	case float64:
+		return v == float64(chainID)
+	case string:
+		return strings.TrimSpace(v) == strconv.Itoa(chainID)
+	default:
+		return false
+	}
+}
+
+// validateTransfer 转账操作必须有合法收款地址和正数金额
+func validateTransfer(op *models.Operation) error {
+	if !common.IsHexAddress(op.Recipient) {
+		return fmt.Errorf("%w: recipient %q is not a hex address", ErrInvalidOperation, op.Recipient)
+	}
+	if op.Amount <= 0 {
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidOperation)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package ai
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseResponsePlainText(t *testing.T) {
+	text, ui, err := ParseResponse("  你好！我是你的 HashKey Chain 钱包助手。\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text != "你好！我是你的 HashKey Chain 钱包助手。" {
+		t.Fatalf("text = %q", text)
+	}
+	if ui != nil {
+		t.Fatalf("expected no UI block, got %+v", ui)
+	}
+}
+
+func TestParseResponseTransferOperation(t *testing.T) {
+	raw := `收到！准备在 HashKey Chain Testnet 上转账 0.5 HSK。
+
+<aiui>
+{
+  "problem": {
+    "type": "warning",
+    "title": "转账确认",
+    "description": "区块链交易无法撤销，请仔细核对",
+    "suggestions": ["确认收款地址正确"]
+  },
+  "operation": {
+    "action": "transfer",
+    "asset": "HSK",
+    "amount": 0.5,
+    "recipient": "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1",
+    "chainId": 133,
+    "gasEstimate": "0.002 HSK"
+  }
+}
+</aiui>
+
+请确认后提交。`
+
+	text, ui, err := ParseResponse(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text != "收到！准备在 HashKey Chain Testnet 上转账 0.5 HSK。\n\n请确认后提交。" {
+		t.Fatalf("text = %q", text)
+	}
+	if ui == nil || ui.Operation == nil || ui.Problem == nil {
+		t.Fatalf("expected operation and problem, got %+v", ui)
+	}
+	op := ui.Operation
+	if op.Action != "transfer" || op.Amount != 0.5 || op.ChainID != WalletChainID || op.Recipient != "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1" {
+		t.Fatalf("unexpected operation: %+v", op)
+	}
+	if ui.Problem.Type != "warning" || len(ui.Problem.Suggestions) != 1 {
+		t.Fatalf("unexpected problem: %+v", ui.Problem)
+	}
+}
+
+func TestParseResponseForm(t *testing.T) {
+	raw := `好的！请告诉我您要转账的信息。
+<aiui>{"form":{"title":"转账信息","description":"将在 HashKey Chain Testnet 上转账","fields":[
+  {"name":"recipient","label":"收款地址","type":"text","required":true,"validation":"ethereum_address"},
+  {"name":"amount","label":"转账金额 (HSK)","type":"number","required":true}
+],"submitLabel":"下一步"}}</aiui>`
+
+	_, ui, err := ParseResponse(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ui.Form == nil || len(ui.Form.Fields) != 2 || ui.Form.Fields[0].Name != "recipient" {
+		t.Fatalf("unexpected form: %+v", ui.Form)
+	}
+}
+
+func TestParseResponseRejectsMalformedOutput(t *testing.T) {
+	cases := map[string]string{
+		"missing end tag":   `请确认 <aiui>{"operation":{"action":"transfer"}}`,
+		"missing start tag": `请确认 {"operation":{}}</aiui>`,
+		"invalid json":      `请确认 <aiui>{"operation":</aiui>`,
+		"string amount":     `<aiui>{"operation":{"action":"transfer","amount":"0.5","recipient":"0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1"}}</aiui>`,
+		"multiple blocks":   `<aiui>{}</aiui> 以及 <aiui>{}</aiui>`,
+	}
+	for name, raw := range cases {
+		if _, _, err := ParseResponse(raw); !errors.Is(err, ErrMalformedResponse) {
+			t.Errorf("%s: expected ErrMalformedResponse, got %v", name, err)
+		}
+	}
+}
+
+func TestParseResponseRejectsInvalidTransfer(t *testing.T) {
+	cases := map[string]string{
+		"bad recipient":     `<aiui>{"operation":{"action":"transfer","asset":"HSK","amount":1,"recipient":"0x742d35"}}</aiui>`,
+		"missing recipient": `<aiui>{"operation":{"action":"transfer","asset":"HSK","amount":1}}</aiui>`,
+		"zero amount":       `<aiui>{"operation":{"action":"transfer","asset":"HSK","amount":0,"recipient":"0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1"}}</aiui>`,
+		"negative amount":   `<aiui>{"operation":{"action":"transfer","asset":"HSK","amount":-2,"recipient":"0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1"}}</aiui>`,
+	}
+	for name, raw := range cases {
+		if _, _, err := ParseResponse(raw); !errors.Is(err, ErrInvalidOperation) {
+			t.Errorf("%s: expected ErrInvalidOperation, got %v", name, err)
+		}
+	}
+}
+
+func TestParseResponseStripsNetworkFields(t *testing.T) {
+	raw := `<aiui>{"form":{"title":"转账","fields":[{"name":"recipient","label":"收款地址","type":"text"},{"name":"chainId","label":"网络","type":"select"},{"name":"Network","label":"网络","type":"select"}]}}</aiui>`
+	_, ui, err := ParseResponse(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ui.Form.Fields) != 1 || ui.Form.Fields[0].Name != "recipient" {
+		t.Fatalf("expected network fields to be removed, got %+v", ui.Form.Fields)
+	}
+
+	raw = `<aiui>{"operation":{"action":"swap","network":"sepolia","parameters":{"chain":"ethereum","chainId":"133","dex":"HashSwap"}}}</aiui>`
+	_, ui, err = ParseResponse(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ui.Operation.ChainID != WalletChainID {
+		t.Fatalf("chainId = %d, want %d", ui.Operation.ChainID, WalletChainID)
+	}
+	if len(ui.Operation.Parameters) != 1 || ui.Operation.Parameters["dex"] != "HashSwap" {
+		t.Fatalf("expected network parameters to be removed, got %+v", ui.Operation.Parameters)
+	}
+}
+
+func TestParseResponseRejectsForeignChain(t *testing.T) {
+	cases := map[string]string{
+		"other chain":       `<aiui>{"operation":{"action":"transfer","amount":1,"recipient":"0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1","chainId":11155111}}</aiui>`,
+		"zero chain":        `<aiui>{"operation":{"action":"transfer","amount":1,"recipient":"0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1","chainId":0}}</aiui>`,
+		"case variant":      `<aiui>{"operation":{"action":"transfer","amount":1,"recipient":"0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1","ChainID":1}}</aiui>`,
+		"parameter chainId": `<aiui>{"operation":{"action":"swap","parameters":{"chainId":1}}}</aiui>`,
+	}
+	for name, raw := range cases {
+		if _, _, err := ParseResponse(raw); !errors.Is(err, ErrForeignChain) {
+			t.Errorf("%s: expected ErrForeignChain, got %v", name, err)
+		}
+	}
+
+	// 配置了其他链时，133 同样是外链
+	raw := `<aiui>{"operation":{"action":"transfer","amount":1,"recipient":"0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1","chainId":133}}</aiui>`
+	if _, _, err := ParseResponseForChain(raw, 177); !errors.Is(err, ErrForeignChain) {
+		t.Errorf("expected ErrForeignChain for chain 177, got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/wallet"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListWalletsAdminHandler returns all wallets with their owners, newest first (admin only)
+// Query params: limit (default 50, max 200), cursor (nextCursor of the previous page) and
+// search (substring of the wallet address or username)
+func (h *Handler) ListWalletsAdminHandler(c *gin.Context) {
+	limit := wallet.DefaultAdminWalletPageSize
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+
+	wallets, nextCursor, err := h.walletManager.ListWallets(c.Request.Context(), limit, c.Query("cursor"), c.Query("search"))
+	if errors.Is(err, wallet.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error listing wallets: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list wallets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"wallets":    wallets,
+		"nextCursor": nextCursor,
+	})
+}
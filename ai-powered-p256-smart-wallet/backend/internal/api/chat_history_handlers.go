@@ -0,0 +1,79 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/ai"
+	"ai-wallet-backend/internal/logging"
+	"ai-wallet-backend/internal/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Chat history page sizes
+const (
+	defaultChatHistoryLimit = 50
+	maxChatHistoryLimit     = 200
+)
+
+// chatHistory loads the user's persisted conversation as model history.
+// Conversations started before history was stored server-side fall back to
+// the history sent by the client.
+func (h *Handler) chatHistory(c *gin.Context, userID string, clientHistory []models.ChatMessage) []models.ChatMessage {
+	messages, err := h.conversations.Recent(c.Request.Context(), userID, h.aiProcessor.HistoryLimit())
+	if err != nil {
+		logging.FromContext(c).Warn().Err(err).Msg("failed to load chat history")
+		return clientHistory
+	}
+	if len(messages) == 0 {
+		return clientHistory
+	}
+	return ai.ChatHistory(messages)
+}
+
+// saveChatTurn persists the user's message and the assistant's reply
+// Failures are logged only: the reply has already been generated
+func (h *Handler) saveChatTurn(c *gin.Context, userID, message string, response *models.AIResponse) {
+	turn, err := ai.NewTurn(message, response)
+	if err == nil {
+		err = h.conversations.Append(c.Request.Context(), userID, turn...)
+	}
+	if err != nil {
+		logging.FromContext(c).Error().Err(err).Msg("failed to save chat turn")
+	}
+}
+
+// GetChatHistoryHandler returns the user's latest chat messages, oldest first
+// Query param: limit (default 50, max 200)
+func (h *Handler) GetChatHistoryHandler(c *gin.Context) {
+	limit := defaultChatHistoryLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(parsed, maxChatHistoryLimit)
+	}
+
+	messages, err := h.conversations.Recent(c.Request.Context(), c.GetString("userID"), limit)
+	if err != nil {
+		logging.FromContext(c).Error().Err(err).Msg("failed to load chat history")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load chat history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"messages": messages})
+}
+
+// DeleteChatHistoryHandler deletes the user's conversation
+func (h *Handler) DeleteChatHistoryHandler(c *gin.Context) {
+	deleted, err := h.conversations.Clear(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		logging.FromContext(c).Error().Err(err).Msg("failed to clear chat history")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear chat history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "deleted": deleted})
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/logging"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORS defaults used when the corresponding env var is empty
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	defaultCORSHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Session-Token", logging.RequestIDHeader}
+)
+
+// corsMiddleware allows credentialed cross-origin requests from the frontend.
+// Origins come from CORS_ALLOWED_ORIGINS and default to the WebAuthn RP origins,
+// since those are the only frontends that can complete a passkey login anyway.
+// Methods and headers come from CORS_ALLOWED_METHODS / CORS_ALLOWED_HEADERS.
+func corsMiddleware(rpOrigins []string) gin.HandlerFunc {
+	config := cors.DefaultConfig()
+	config.AllowOrigins = corsOrigins(os.Getenv("CORS_ALLOWED_ORIGINS"), rpOrigins)
+	config.AllowMethods = splitCORSList(os.Getenv("CORS_ALLOWED_METHODS"), defaultCORSMethods)
+	config.AllowHeaders = splitCORSList(os.Getenv("CORS_ALLOWED_HEADERS"), defaultCORSHeaders)
+	config.ExposeHeaders = []string{logging.RequestIDHeader}
+	config.AllowCredentials = true
+	config.MaxAge = time.Hour
+	return cors.New(config)
+}
+
+// corsOrigins parses CORS_ALLOWED_ORIGINS, falling back to the RP origins.
+// "*" is dropped: browsers refuse a wildcard origin on credentialed requests.
+func corsOrigins(value string, rpOrigins []string) []string {
+	var origins []string
+	for _, origin := range splitCORSList(value, rpOrigins) {
+		origin = strings.TrimRight(origin, "/")
+		if origin == "*" {
+			log.Println("⚠️  Ignoring \"*\" in CORS_ALLOWED_ORIGINS: not allowed with credentials")
+			continue
+		}
+		if !slices.Contains(origins, origin) {
+			origins = append(origins, origin)
+		}
+	}
+	if len(origins) == 0 {
+		origins = rpOrigins
+	}
+	return origins
+}
+
+// splitCORSList splits a comma-separated env value, returning defaults when it is empty
+func splitCORSList(value string, defaults []string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return defaults
+	}
+	return items
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/auth"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newCORSTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	webAuthnService, err := auth.NewWebAuthnService(nil, "localhost", "AI Wallet", "http://localhost:3000", auth.DefaultAuthenticatorPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return SetupRouter(&Handler{webAuthnService: webAuthnService})
+}
+
+func preflight(router *gin.Engine, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, "/api/chat", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "content-type,x-session-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSPreflightDefaultsToRPOrigins(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", "")
+	router := newCORSTestRouter(t)
+
+	w := preflight(router, "http://localhost:3000")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("allowed origin: status = %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "http://localhost:3000" {
+		t.Fatalf("Access-Control-Allow-Origin = %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Fatalf("Access-Control-Allow-Credentials = %q", got)
+	}
+
+	w = preflight(router, "https://evil.example.com")
+	if w.Code != http.StatusForbidden || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("disallowed origin: status = %d, allow-origin = %q", w.Code, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
+func TestCORSPreflightFromEnv(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://wallet.example.com/, *")
+	t.Setenv("CORS_ALLOWED_METHODS", "GET,POST")
+	router := newCORSTestRouter(t)
+
+	w := preflight(router, "https://wallet.example.com")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "https://wallet.example.com" {
+		t.Fatalf("configured origin: status = %d, allow-origin = %q", w.Code, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET,POST" {
+		t.Fatalf("Access-Control-Allow-Methods = %q", got)
+	}
+
+	// The env list replaces the RP origins, and "*" is ignored
+	for _, origin := range []string{"http://localhost:3000", "https://evil.example.com"} {
+		if w := preflight(router, origin); w.Code != http.StatusForbidden {
+			t.Errorf("%s: status = %d, want 403", origin, w.Code)
+		}
+	}
+}
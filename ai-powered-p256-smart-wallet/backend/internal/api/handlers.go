@@ -3,12 +3,15 @@ package api
 import (
 	"ai-wallet-backend/internal/ai"
 	"ai-wallet-backend/internal/auth"
+	"ai-wallet-backend/internal/logging"
 	"ai-wallet-backend/internal/mcp"
 	"ai-wallet-backend/internal/models"
 	"ai-wallet-backend/internal/wallet"
+	"context"
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -17,11 +20,18 @@ import (
 // Handler handles HTTP requests
 type Handler struct {
 	aiProcessor     *ai.Processor
+	conversations   ai.ConversationStore
 	skillManager    *mcp.SkillManager
 	webAuthnService *auth.WebAuthnService
 	sessionService  *auth.SessionService
 	walletManager   *wallet.Manager
+	pendingOps      wallet.PendingUserOpStore
+	crossDevice     wallet.CrossDeviceTokenStore // one-time tokens for signing a pending UserOp on another device
+	nonces          wallet.NonceReservationStore // EntryPoint nonces held by prepared UserOps
+	transferLimits  wallet.TransferLimits        // per-token min/max and daily caps (TRANSFER_LIMITS); nil = unlimited
+	transferUsage   wallet.TransferUsageStore    // amounts counted against the daily caps
 	db              *gorm.DB
+	chainID         int // the only chain transfers may target (CHAIN_ID)
 }
 
 // NewHandler creates a new handler with all required services
@@ -32,15 +42,34 @@ func NewHandler(
 	walletManager *wallet.Manager,
 ) *Handler {
 	return &Handler{
-		aiProcessor:     ai.NewProcessor(),
+		aiProcessor:     ai.NewProcessor(ai.NewClient(ai.ClientConfigFromEnv()), ai.ContextWindowFromEnv(), walletManager.ChainID()),
+		conversations:   ai.NewGormConversationStore(db),
 		skillManager:    mcp.NewSkillManager(),
 		db:              db,
 		webAuthnService: webAuthnService,
 		sessionService:  sessionService,
 		walletManager:   walletManager,
+		pendingOps:      wallet.NewGormPendingUserOpStore(db),
+		crossDevice:     wallet.NewGormCrossDeviceTokenStore(db),
+		nonces:          wallet.NewGormNonceReservationStore(db),
+		transferUsage:   wallet.NewGormTransferUsageStore(db),
+		chainID:         walletManager.ChainID(),
 	}
 }
 
+// StartBackgroundJobs starts the handler's periodic maintenance tasks until ctx is cancelled
+func (h *Handler) StartBackgroundJobs(ctx context.Context) {
+	go wallet.RunPendingUserOpSweeper(ctx, h.pendingOps, time.Minute)
+	go wallet.RunCrossDeviceTokenSweeper(ctx, h.crossDevice, time.Minute)
+	go wallet.RunNonceReservationSweeper(ctx, h.nonces, time.Minute)
+	go wallet.RunTransferUsageSweeper(ctx, h.transferUsage, time.Hour)
+	go wallet.RunTransactionStatusPoller(ctx, h.walletManager, 15*time.Second)
+	go wallet.RunDeployedReconciler(ctx, h.walletManager, wallet.ReconcileConfigFromEnv())
+	go wallet.RunBalanceCacheSweeper(ctx, h.walletManager, time.Minute)
+	go h.sessionService.RunSessionSweeper(ctx, time.Hour)
+	go h.webAuthnService.RunChallengeSweeper(ctx, 10*time.Minute)
+}
+
 // ChatHandler 处理聊天请求
 func (h *Handler) ChatHandler(c *gin.Context) {
 	log.Println("\n" + strings.Repeat("=", 60))
@@ -59,10 +88,12 @@ func (h *Handler) ChatHandler(c *gin.Context) {
 	
 	log.Printf("✓ Request parsed successfully\n")
 	log.Printf("📨 Message: %s\n", req.Message)
-	log.Printf("📚 History items: %d\n", len(req.History))
+	userID := c.GetString("userID")
+	history := h.chatHistory(c, userID, req.History)
+	log.Printf("📚 History items: %d\n", len(history))
 
 	// 使用AI处理器生成响应（传入历史消息）
-	response, err := h.aiProcessor.ProcessMessage(req.Message, req.History)
+	response, err := h.aiProcessor.ProcessMessage(logging.Context(c), req.Message, history)
 	if err != nil {
 		log.Printf("❌ Failed to process message: %v\n", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -74,10 +105,51 @@ func (h *Handler) ChatHandler(c *gin.Context) {
 	log.Println("✅ Response generated successfully")
 	log.Printf("📤 Sending response (message length: %d)\n", len(response.Message))
 	log.Println(strings.Repeat("=", 60))
-	
+
+	// 超出转账限额的转账操作填充问题卡片
+	h.flagTransferLimit(c, userID, response)
+	h.saveChatTurn(c, userID, req.Message, response)
 	c.JSON(http.StatusOK, response)
 }
 
+// ChatStreamHandler 以 SSE 流式返回聊天响应：
+// "token" 事件逐段推送文本（不含 UI 组件），"done" 事件携带完整的结构化响应，失败时发送 "error" 事件
+func (h *Handler) ChatStreamHandler(c *gin.Context) {
+	var req models.ChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	userID := c.GetString("userID")
+	history := h.chatHistory(c, userID, req.History)
+
+	response, err := h.aiProcessor.ProcessMessageStream(logging.Context(c), req.Message, history, func(text string) error {
+		c.SSEvent("token", gin.H{"content": text})
+		c.Writer.Flush()
+		// 客户端断开后停止生成
+		return c.Request.Context().Err()
+	})
+	if err != nil {
+		logging.FromContext(c).Error().Err(err).Msg("failed to stream chat response")
+		c.SSEvent("error", gin.H{"error": "Failed to process message"})
+		c.Writer.Flush()
+		return
+	}
+
+	h.flagTransferLimit(c, userID, response)
+	h.saveChatTurn(c, userID, req.Message, response)
+	c.SSEvent("done", response)
+	c.Writer.Flush()
+}
+
 // SkillsListHandler 列出所有可用技能
 func (h *Handler) SkillsListHandler(c *gin.Context) {
 	skills := h.skillManager.GetAvailableSkills()
@@ -111,6 +183,47 @@ func (h *Handler) SkillExecuteHandler(c *gin.Context) {
 	})
 }
 
+// readinessTimeout bounds each dependency check in ReadyzHandler
+const readinessTimeout = 2 * time.Second
+
+// HealthzHandler reports that the process is up (liveness)
+func (h *Handler) HealthzHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// ReadyzHandler checks Postgres and the chain RPC; returns 503 if either is unreachable
+func (h *Handler) ReadyzHandler(c *gin.Context) {
+	checks := gin.H{}
+	ready := true
+
+	dbCtx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+	if sqlDB, err := h.db.DB(); err != nil {
+		checks["database"] = gin.H{"status": "error", "error": err.Error()}
+		ready = false
+	} else if err := sqlDB.PingContext(dbCtx); err != nil {
+		checks["database"] = gin.H{"status": "error", "error": err.Error()}
+		ready = false
+	} else {
+		checks["database"] = gin.H{"status": "ok"}
+	}
+
+	rpcCtx, cancelRPC := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancelRPC()
+	if chainID, err := h.walletManager.PingRPC(rpcCtx); err != nil {
+		checks["rpc"] = gin.H{"status": "error", "error": err.Error()}
+		ready = false
+	} else {
+		checks["rpc"] = gin.H{"status": "ok", "chainId": chainID.String()}
+	}
+
+	status, code := "ready", http.StatusOK
+	if !ready {
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{"status": status, "checks": checks})
+}
+
 // HealthCheckHandler 健康检查
 func (h *Handler) HealthCheckHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
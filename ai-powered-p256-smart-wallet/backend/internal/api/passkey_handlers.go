@@ -1,12 +1,13 @@
 package api
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"time"
 
+	"ai-wallet-backend/internal/auth"
 	"ai-wallet-backend/internal/models"
-	"ai-wallet-backend/internal/wallet"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-webauthn/webauthn/protocol"
@@ -90,6 +91,7 @@ func (h *Handler) FinishPasskeyRegistration(c *gin.Context) {
 		ID:           req.UserID, // Use the same ID from Begin
 		Username:     req.Username,
 		CreatedAt:    time.Now(),
+		CreatedBy:    req.UserID, // self-registration
 		LastActiveAt: time.Now(),
 	}
 
@@ -113,6 +115,10 @@ func (h *Handler) FinishPasskeyRegistration(c *gin.Context) {
 	passkeyCredential, err := h.webAuthnService.FinishRegistration(user, req.SessionID, parsedResponse)
 	if err != nil {
 		tx.Rollback()
+		if errors.Is(err, auth.ErrChallengeExpired) {
+			c.JSON(http.StatusGone, gin.H{"error": "Registration challenge expired, please start again"})
+			return
+		}
 		log.Printf("Error finishing registration: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to finish registration: " + err.Error()})
 		return
@@ -135,15 +141,12 @@ func (h *Handler) FinishPasskeyRegistration(c *gin.Context) {
 
 	// Extract P256 public key from WebAuthn credential
 	log.Printf("Extracting P256 public key from COSE format (length: %d bytes)", len(passkeyCredential.PublicKey))
-	publicKey, err := wallet.ExtractP256PublicKeyFromCOSE(passkeyCredential.PublicKey)
+	publicKeyXHex, publicKeyYHex, err := auth.P256PublicKeyHex(passkeyCredential.PublicKey)
 	if err != nil {
 		log.Printf("Error extracting P256 public key: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to extract public key from passkey"})
 		return
 	}
-
-	// Convert public key to hex strings for storage
-	publicKeyXHex, publicKeyYHex := wallet.P256PublicKeyToHex(publicKey)
 	log.Printf("P256 Public Key extracted: X=%s, Y=%s", publicKeyXHex[:10]+"...", publicKeyYHex[:10]+"...")
 
 	// Create P256-based wallet for user
@@ -258,6 +261,15 @@ func (h *Handler) FinishPasskeyLogin(c *gin.Context) {
 
 	// Finish WebAuthn login
 	if err := h.webAuthnService.FinishLogin(&user, req.SessionID, parsedResponse); err != nil {
+		if errors.Is(err, auth.ErrChallengeExpired) {
+			c.JSON(http.StatusGone, gin.H{"error": "Login challenge expired, please start again"})
+			return
+		}
+		if errors.Is(err, auth.ErrSignCountRegression) {
+			log.Printf("⚠️  Possible cloned passkey for user %s: %v", user.ID, err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Passkey rejected: signature counter did not increase"})
+			return
+		}
 		log.Printf("Error finishing login: %v", err)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to finish login: " + err.Error()})
 		return
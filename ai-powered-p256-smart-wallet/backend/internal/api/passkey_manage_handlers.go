@@ -0,0 +1,172 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/auth"
+	"ai-wallet-backend/internal/models"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errLastPasskey is returned when deleting a passkey would leave the user without one
+var errLastPasskey = errors.New("cannot delete the last passkey")
+
+// errLastWalletSigner is returned when deleting a passkey would leave a wallet without a signer
+var errLastWalletSigner = errors.New("cannot delete the last passkey that signs for a wallet")
+
+// PasskeyInfo is the public view of a registered passkey
+type PasskeyInfo struct {
+	ID             string    `json:"id"`
+	CredentialID   string    `json:"credentialId"` // base64url
+	Nickname       string    `json:"nickname"`
+	BackupEligible bool      `json:"backupEligible"`
+	BackupState    bool      `json:"backupState"`
+	WalletSigner   bool      `json:"walletSigner"` // can sign UserOps for the user's wallet
+	CreatedAt      time.Time `json:"createdAt"`
+	LastUsedAt     time.Time `json:"lastUsedAt"`
+}
+
+// credentialSignsForWallet reports whether a passkey's P-256 key is the wallet's signer
+func credentialSignsForWallet(credential *models.PasskeyCredential, w *models.Wallet) bool {
+	x, y, err := auth.P256PublicKeyHex(credential.PublicKey)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(x, w.PublicKeyX) && strings.EqualFold(y, w.PublicKeyY)
+}
+
+// isLastWalletSigner reports whether credential is the only one of credentials that can
+// sign for one of wallets
+func isLastWalletSigner(credential *models.PasskeyCredential, credentials []models.PasskeyCredential, wallets []models.Wallet) bool {
+	for i := range wallets {
+		if !credentialSignsForWallet(credential, &wallets[i]) {
+			continue
+		}
+		otherSigner := false
+		for j := range credentials {
+			if credentials[j].ID != credential.ID && credentialSignsForWallet(&credentials[j], &wallets[i]) {
+				otherSigner = true
+				break
+			}
+		}
+		if !otherSigner {
+			return true
+		}
+	}
+	return false
+}
+
+// walletSignerCredentials returns the user's passkeys that can sign for the wallet,
+// most recently used first
+func (h *Handler) walletSignerCredentials(userID string, w *models.Wallet) ([]models.PasskeyCredential, error) {
+	var credentials []models.PasskeyCredential
+	if err := h.db.Where("user_id = ?", userID).Order("last_used_at DESC").Find(&credentials).Error; err != nil {
+		return nil, err
+	}
+
+	signers := make([]models.PasskeyCredential, 0, len(credentials))
+	for i := range credentials {
+		if credentialSignsForWallet(&credentials[i], w) {
+			signers = append(signers, credentials[i])
+		}
+	}
+	return signers, nil
+}
+
+// ListPasskeysHandler lists the user's registered passkeys
+func (h *Handler) ListPasskeysHandler(c *gin.Context) {
+	userIDRaw, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := fmt.Sprintf("%v", userIDRaw)
+
+	var credentials []models.PasskeyCredential
+	if err := h.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&credentials).Error; err != nil {
+		log.Printf("Error listing passkeys: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list passkeys"})
+		return
+	}
+
+	userWallet, walletErr := h.walletManager.GetWalletByUserID(userID)
+
+	passkeys := make([]PasskeyInfo, len(credentials))
+	for i := range credentials {
+		credential := &credentials[i]
+		passkeys[i] = PasskeyInfo{
+			ID:             credential.ID,
+			CredentialID:   base64URLEncodeBytes(credential.CredentialID),
+			Nickname:       credential.Nickname,
+			BackupEligible: credential.BackupEligible,
+			BackupState:    credential.BackupState,
+			WalletSigner:   walletErr == nil && credentialSignsForWallet(credential, userWallet),
+			CreatedAt:      credential.CreatedAt,
+			LastUsedAt:     credential.LastUsedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"passkeys": passkeys})
+}
+
+// DeletePasskeyHandler revokes one of the user's passkeys (the last one cannot be deleted)
+func (h *Handler) DeletePasskeyHandler(c *gin.Context) {
+	userIDRaw, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := fmt.Sprintf("%v", userIDRaw)
+	passkeyID := c.Param("id")
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var credential models.PasskeyCredential
+		if err := tx.Where("id = ? AND user_id = ?", passkeyID, userID).First(&credential).Error; err != nil {
+			return err
+		}
+
+		var credentials []models.PasskeyCredential
+		if err := tx.Where("user_id = ?", userID).Find(&credentials).Error; err != nil {
+			return err
+		}
+		if len(credentials) <= 1 {
+			return errLastPasskey
+		}
+
+		// Other passkeys only help if one of them can still sign for the wallet
+		var wallets []models.Wallet
+		if err := tx.Where("user_id = ?", userID).Find(&wallets).Error; err != nil {
+			return err
+		}
+		if isLastWalletSigner(&credential, credentials, wallets) {
+			return errLastWalletSigner
+		}
+
+		return tx.Delete(&credential).Error
+	})
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Passkey not found"})
+		return
+	case errors.Is(err, errLastPasskey):
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete your only passkey"})
+		return
+	case errors.Is(err, errLastWalletSigner):
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete the only passkey that signs for your wallet; register another signer first"})
+		return
+	case err != nil:
+		log.Printf("Error deleting passkey: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete passkey"})
+		return
+	}
+
+	log.Printf("🗑️  Passkey %s revoked by user %s", passkeyID, userID)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
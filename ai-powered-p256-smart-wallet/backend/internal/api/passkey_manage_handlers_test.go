@@ -0,0 +1,107 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIsLastWalletSigner(t *testing.T) {
+	w := models.Wallet{PublicKeyX: recoveryKeyX, PublicKeyY: recoveryKeyY}
+	signer := models.PasskeyCredential{ID: "signer", PublicKey: mustDecodeHex(t, recoveryCOSE)}
+	synced := models.PasskeyCredential{ID: "synced", PublicKey: mustDecodeHex(t, recoveryCOSE)}
+	other := models.PasskeyCredential{ID: "other", PublicKey: mustDecodeHex(t, lostSignerCOSE)}
+
+	// A second passkey with a different key does not sign for the wallet
+	if !isLastWalletSigner(&signer, []models.PasskeyCredential{signer, other}, []models.Wallet{w}) {
+		t.Fatal("signer with only a non-signing passkey left should be the last signer")
+	}
+	if isLastWalletSigner(&other, []models.PasskeyCredential{signer, other}, []models.Wallet{w}) {
+		t.Fatal("a passkey that does not sign for the wallet is never its last signer")
+	}
+	if isLastWalletSigner(&signer, []models.PasskeyCredential{signer, synced, other}, []models.Wallet{w}) {
+		t.Fatal("another passkey with the wallet's key can still sign")
+	}
+	if isLastWalletSigner(&signer, []models.PasskeyCredential{signer, other}, nil) {
+		t.Fatal("a user without a wallet has no wallet signer to protect")
+	}
+}
+
+// newPasskeyTestHandler stores the user's wallet (signed by the recovery test key) and passkeys
+func newPasskeyTestHandler(t *testing.T, passkeys ...models.PasskeyCredential) (*Handler, *gin.Engine) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&models.Wallet{}, &models.PasskeyCredential{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.Wallet{ID: "wallet-1", UserID: "user-1", Address: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", PublicKeyX: recoveryKeyX, PublicKeyY: recoveryKeyY, ChainID: 133}).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i := range passkeys {
+		if err := db.Create(&passkeys[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := &Handler{db: db, chainID: 133}
+	router := gin.New()
+	router.DELETE("/passkeys/:id", func(c *gin.Context) {
+		c.Set("userID", "user-1")
+		h.DeletePasskeyHandler(c)
+	})
+	return h, router
+}
+
+func deletePasskey(router *gin.Engine, id string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/passkeys/"+id, nil))
+	return w
+}
+
+func TestDeletePasskeyKeepsLastWalletSigner(t *testing.T) {
+	h, router := newPasskeyTestHandler(t,
+		models.PasskeyCredential{ID: "signer", UserID: "user-1", CredentialID: []byte{1}, PublicKey: mustDecodeHex(t, recoveryCOSE)},
+		models.PasskeyCredential{ID: "other", UserID: "user-1", CredentialID: []byte{2}, PublicKey: mustDecodeHex(t, lostSignerCOSE)},
+	)
+
+	// Two passkeys, but only one signs for the wallet
+	if w := deletePasskey(router, "signer"); w.Code != http.StatusConflict {
+		t.Fatalf("delete last signer: got %d %s, want 409", w.Code, w.Body.String())
+	}
+	var count int64
+	h.db.Model(&models.PasskeyCredential{}).Where("id = ?", "signer").Count(&count)
+	if count != 1 {
+		t.Fatal("last wallet signer was deleted")
+	}
+}
+
+func TestDeletePasskeyAllowsNonSignerAndSpareSigner(t *testing.T) {
+	h, router := newPasskeyTestHandler(t,
+		models.PasskeyCredential{ID: "signer", UserID: "user-1", CredentialID: []byte{1}, PublicKey: mustDecodeHex(t, recoveryCOSE)},
+		models.PasskeyCredential{ID: "synced", UserID: "user-1", CredentialID: []byte{2}, PublicKey: mustDecodeHex(t, recoveryCOSE)},
+		models.PasskeyCredential{ID: "other", UserID: "user-1", CredentialID: []byte{3}, PublicKey: mustDecodeHex(t, lostSignerCOSE)},
+	)
+
+	if w := deletePasskey(router, "other"); w.Code != http.StatusOK {
+		t.Fatalf("delete non-signer: got %d %s, want 200", w.Code, w.Body.String())
+	}
+	// Another passkey still signs for the wallet
+	if w := deletePasskey(router, "signer"); w.Code != http.StatusOK {
+		t.Fatalf("delete spare signer: got %d %s, want 200", w.Code, w.Body.String())
+	}
+	// Now the user's only passkey
+	if w := deletePasskey(router, "synced"); w.Code != http.StatusConflict {
+		t.Fatalf("delete only passkey: got %d %s, want 409", w.Code, w.Body.String())
+	}
+
+	var remaining int64
+	h.db.Model(&models.PasskeyCredential{}).Count(&remaining)
+	if remaining != 1 {
+		t.Fatalf("%d passkeys left, want 1", remaining)
+	}
+}
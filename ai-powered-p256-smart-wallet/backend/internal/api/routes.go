@@ -1,28 +1,35 @@
 package api
 
 import (
+	"ai-wallet-backend/internal/apierr"
 	"ai-wallet-backend/internal/auth"
+	"ai-wallet-backend/internal/bodylimit"
+	"ai-wallet-backend/internal/logging"
+	"ai-wallet-backend/internal/models"
+	"ai-wallet-backend/internal/ratelimit"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
 // SetupRouter configures all routes
 func SetupRouter(handler *Handler) *gin.Engine {
-	router := gin.Default()
-
-	// CORS configuration
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{
-		"http://localhost:3000",
-		"http://127.0.0.1:3000",
-		"http://localhost:3001",
-		"http://127.0.0.1:3001",
-	}
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Session-Token"}
-	config.AllowCredentials = true
-	router.Use(cors.New(config))
+	// Request logging is done by logging.Middleware instead of gin's text logger;
+	// errors recorded with apierr.Abort are rendered as {code, message, details}
+	router := gin.New()
+	router.Use(gin.Recovery(), logging.Middleware(), apierr.Middleware())
+
+	// CORS for the frontend origins (credentialed: the session token is sent from the browser)
+	router.Use(corsMiddleware(handler.webAuthnService.Origins()))
+
+	// Per-route rate limits (keyed by userID after auth, client IP otherwise)
+	limiter := ratelimit.NewFromEnv()
+
+	// Per-route request body size and JSON depth limits (413/400 before the handler reads the body)
+	bodyLimit := bodylimit.NewFromEnv()
+
+	// Liveness/readiness probes for the orchestrator (no auth, not rate limited)
+	router.GET("/healthz", handler.HealthzHandler)
+	router.GET("/readyz", handler.ReadyzHandler)
 
 	// API route group
 	api := router.Group("/api")
@@ -31,7 +38,7 @@ func SetupRouter(handler *Handler) *gin.Engine {
 		api.GET("/health", handler.HealthCheckHandler)
 
 		// Passkey authentication endpoints (no auth required)
-		passkey := api.Group("/passkey")
+		passkey := api.Group("/passkey", limiter.Middleware("passkey"), bodyLimit.Middleware("passkey"))
 		{
 			passkey.POST("/register/begin", handler.BeginPasskeyRegistration)
 			passkey.POST("/register/finish", handler.FinishPasskeyRegistration)
@@ -39,40 +46,73 @@ func SetupRouter(handler *Handler) *gin.Engine {
 			passkey.POST("/login/finish", handler.FinishPasskeyLogin)
 		}
 
+		// Session refresh (rotates the X-Session-Token)
+		api.POST("/session/refresh", limiter.Middleware("passkey"), bodyLimit.Middleware("passkey"), handler.RefreshSessionHandler)
+
+		// Passkey management (requires auth)
+		api.GET("/passkeys", auth.RequireAuth(handler.sessionService), handler.ListPasskeysHandler)
+		api.DELETE("/passkeys/:id", auth.RequireAuth(handler.sessionService), handler.DeletePasskeyHandler)
+
 		// Chat interface (requires auth)
-		api.POST("/chat", auth.RequireAuth(handler.sessionService), handler.ChatHandler)
+		api.POST("/chat", auth.RequireAuth(handler.sessionService), limiter.Middleware("chat"), bodyLimit.Middleware("chat"), handler.ChatHandler)
+		api.POST("/chat/stream", auth.RequireAuth(handler.sessionService), limiter.Middleware("chat"), bodyLimit.Middleware("chat"), handler.ChatStreamHandler)
+		api.GET("/chat/history", auth.RequireAuth(handler.sessionService), handler.GetChatHistoryHandler)
+		api.DELETE("/chat/history", auth.RequireAuth(handler.sessionService), handler.DeleteChatHistoryHandler)
 
 		// MCP skills endpoints (requires auth)
 		api.GET("/skills", auth.RequireAuth(handler.sessionService), handler.SkillsListHandler)
-		api.POST("/skills/:name", auth.RequireAuth(handler.sessionService), handler.SkillExecuteHandler)
+		api.POST("/skills/:name", auth.RequireAuth(handler.sessionService), limiter.Middleware("skills"), bodyLimit.Middleware("skills"), handler.SkillExecuteHandler)
 
 		// Chain endpoints (requires auth)
 		api.GET("/chains", auth.RequireAuth(handler.sessionService), handler.GetSupportedChains)
 
 		// Transfer endpoints (requires auth) - DEPRECATED for P256 wallets
-		transfer := api.Group("/transfer", auth.RequireAuth(handler.sessionService))
+		transfer := api.Group("/transfer", auth.RequireAuth(handler.sessionService), bodyLimit.Middleware("transfer"))
 		{
 			transfer.POST("/estimate", handler.EstimateTransfer)
 			transfer.POST("/execute", handler.ExecuteTransfer)
 			transfer.GET("/status/:txHash", handler.GetTransferStatus)
 		}
 
-		// UserOperation endpoints (requires auth) - For P256 non-custodial wallets
-		api.POST("/userop", auth.RequireAuth(handler.sessionService), handler.SubmitUserOperationHandler)
+		// P256 signing flow endpoints (requires auth); UserOps are only submitted through
+		// prepare/submit so every transfer passes the transfer limits and nonce reservation
+		api.POST("/transfer/prepare", auth.RequireAuth(handler.sessionService), limiter.Middleware("transfer"), bodyLimit.Middleware("transfer"), handler.PrepareTransferHandler)
+		api.POST("/transfer/submit", auth.RequireAuth(handler.sessionService), limiter.Middleware("transfer"), bodyLimit.Middleware("transfer"), handler.SubmitTransferHandler)
+
+		// Transfer limits and the remaining daily allowance
+		api.GET("/transfer/limits", auth.RequireAuth(handler.sessionService), handler.TransferLimitsHandler)
 
-		// P256 signing flow endpoints (requires auth)
-		api.POST("/transfer/prepare", auth.RequireAuth(handler.sessionService), handler.PrepareTransferHandler)
-		api.POST("/transfer/submit", auth.RequireAuth(handler.sessionService), handler.SubmitTransferHandler)
+		// Cross-device signing: prepare on the desktop, sign and submit from the phone holding the
+		// passkey; the submit is authenticated by the one-time token instead of a session
+		api.POST("/transfer/prepare-crossdevice", auth.RequireAuth(handler.sessionService), limiter.Middleware("transfer"), bodyLimit.Middleware("transfer"), handler.PrepareCrossDeviceTransferHandler)
+		api.POST("/transfer/submit-crossdevice", limiter.Middleware("transfer"), bodyLimit.Middleware("transfer"), handler.SubmitCrossDeviceTransferHandler)
+		api.POST("/estimate-gas", auth.RequireAuth(handler.sessionService), limiter.Middleware("transfer"), bodyLimit.Middleware("transfer"), handler.EstimateGasHandler)
+		api.POST("/transfer/simulate", auth.RequireAuth(handler.sessionService), limiter.Middleware("transfer"), bodyLimit.Middleware("transfer"), handler.SimulateTransferHandler)
 
 		// Simple transfer endpoint for MVP testing (requires auth)
-		api.POST("/transfer/simple", auth.RequireAuth(handler.sessionService), handler.SimpleTransferHandler)
-
-		// TODO: Add more wallet and transaction endpoints here
-		// wallet := api.Group("/wallet", auth.RequireAuth(handler.sessionService))
-		// {
-		// 	wallet.GET("/balance", handler.GetWalletBalance)
-		// 	wallet.GET("/info", handler.GetWalletInfo)
-		// }
+		api.POST("/transfer/simple", auth.RequireAuth(handler.sessionService), limiter.Middleware("transfer"), bodyLimit.Middleware("transfer"), handler.SimpleTransferHandler)
+
+		// Wallet endpoints (requires auth)
+		wallet := api.Group("/wallet", auth.RequireAuth(handler.sessionService), bodyLimit.Middleware("transfer"))
+		{
+			wallet.GET("/balance", handler.GetWalletBalanceHandler)
+			wallet.POST("/deploy", limiter.Middleware("transfer"), handler.DeployWalletHandler)
+
+			// Key recovery: register a backup passkey, then rotate the signer with it after device loss
+			wallet.POST("/recovery-key", limiter.Middleware("transfer"), handler.SetRecoveryKeyHandler)
+			wallet.POST("/recover/prepare", limiter.Middleware("transfer"), handler.PrepareRecoveryHandler)
+			wallet.POST("/recover/submit", limiter.Middleware("transfer"), handler.SubmitRecoveryHandler)
+		}
+		api.POST("/wallet", auth.RequireAuth(handler.sessionService), bodyLimit.Middleware("default"), handler.CreateWalletHandler)
+
+		// Transaction history (requires auth)
+		api.GET("/transactions", auth.RequireAuth(handler.sessionService), handler.ListTransactionsHandler)
+
+		// Operator endpoints (requires auth and the admin role)
+		admin := api.Group("/admin", auth.RequireAuth(handler.sessionService), auth.RequireRole(handler.sessionService, models.UserRoleAdmin))
+		{
+			admin.GET("/wallets", handler.ListWalletsAdminHandler)
+		}
 	}
 
 	return router
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/auth"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RefreshSessionHandler rotates the caller's session token and extends its expiry
+// The old token is invalidated; clients must store the returned one.
+func (h *Handler) RefreshSessionHandler(c *gin.Context) {
+	token := c.GetHeader("X-Session-Token")
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing session token"})
+		return
+	}
+
+	session, err := h.sessionService.RefreshSession(token)
+	switch {
+	case errors.Is(err, auth.ErrSessionExpired), errors.Is(err, auth.ErrSessionNotFound):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired session"})
+		return
+	case errors.Is(err, auth.ErrSessionLifetimeReached):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("Error refreshing session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session": gin.H{
+			"token":     session.Token,
+			"expiresAt": session.ExpiresAt,
+		},
+	})
+}
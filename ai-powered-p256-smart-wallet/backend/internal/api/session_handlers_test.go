@@ -0,0 +1,92 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/auth"
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRefreshSessionHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	store := auth.NewRedisSessionStore(client)
+
+	sessions := auth.NewSessionService(dbtest.DryRun(t))
+	sessions.SetStore(store)
+	sessions.SetLifetimes(time.Hour, 24*time.Hour)
+	h := &Handler{sessionService: sessions}
+
+	router := gin.New()
+	router.POST("/session/refresh", h.RefreshSessionHandler)
+	refresh := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/session/refresh", nil)
+		req.Header.Set("X-Session-Token", token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	storeSession := func(token string, expiresIn time.Duration) {
+		t.Helper()
+		now := time.Now()
+		session := &models.Session{ID: token, UserID: "user-1", Token: token, ExpiresAt: now.Add(expiresIn), AuthenticatedAt: now, CreatedAt: now}
+		if err := store.Create(context.Background(), session); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A session with a minute left is extended by the full TTL under a new token
+	storeSession("near-expiry", time.Minute)
+	w := refresh("near-expiry")
+	if w.Code != http.StatusOK {
+		t.Fatalf("refresh: got %d %s, want 200", w.Code, w.Body.String())
+	}
+	var body struct {
+		Session struct {
+			Token     string    `json:"token"`
+			ExpiresAt time.Time `json:"expiresAt"`
+		} `json:"session"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Session.Token == "" || body.Session.Token == "near-expiry" {
+		t.Fatalf("refreshed token = %q, want a new one", body.Session.Token)
+	}
+	if remaining := time.Until(body.Session.ExpiresAt); remaining < 59*time.Minute {
+		t.Fatalf("refreshed session expires in %s, want about an hour", remaining)
+	}
+	if _, err := sessions.ValidateSession(body.Session.Token); err != nil {
+		t.Fatalf("new token: %v", err)
+	}
+	if _, err := sessions.ValidateSession("near-expiry"); !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Fatalf("old token: err = %v, want ErrSessionNotFound", err)
+	}
+	// The rotated-out token cannot be refreshed again
+	if w := refresh("near-expiry"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh old token: got %d, want 401", w.Code)
+	}
+
+	// An expired session must log in again
+	storeSession("expired", time.Minute)
+	server.FastForward(2 * time.Minute)
+	if w := refresh("expired"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh expired: got %d %s, want 401", w.Code, w.Body.String())
+	}
+
+	if w := refresh(""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh without token: got %d, want 401", w.Code)
+	}
+}
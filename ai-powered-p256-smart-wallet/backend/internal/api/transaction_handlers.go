@@ -0,0 +1,90 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/models"
+	"ai-wallet-backend/internal/wallet"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListTransactionsHandler returns the user's transaction history, newest first
+// Query params: limit (default 20, max 100) and cursor (nextCursor of the previous page)
+func (h *Handler) ListTransactionsHandler(c *gin.Context) {
+	userIDRaw, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := fmt.Sprintf("%v", userIDRaw)
+
+	limit := wallet.DefaultTransactionPageSize
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+
+	txs, nextCursor, err := h.walletManager.ListTransactions(c.Request.Context(), userID, limit, c.Query("cursor"))
+	if errors.Is(err, wallet.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error listing transactions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": txs,
+		"nextCursor":   nextCursor,
+	})
+}
+
+// recordSubmittedUserOp stores a submitted UserOp in the transaction history, as pending or,
+// when failure is set, as failed with that message (e.g. a UserOp that reverted on-chain)
+// Failures are logged only: the UserOp is already on its way and the response must not fail
+func (h *Handler) recordSubmittedUserOp(ctx context.Context, userID string, userWallet *models.Wallet, userOp map[string]interface{}, userOpHash, txHash, failure string) {
+	tx := &models.Transaction{
+		UserID:        userID,
+		WalletID:      userWallet.ID,
+		WalletAddress: userWallet.Address,
+		UserOpHash:    userOpHash,
+		TxHash:        txHash,
+		Action:        models.TxActionUserOp,
+	}
+	if failure != "" {
+		tx.Status = models.TxStatusFailed
+		tx.ErrorMessage = failure
+	}
+
+	callData := fmt.Sprintf("%v", userOp["callData"])
+	if details, ok := wallet.DecodeTransferCallData(hexToBytes(callData)); ok {
+		tx.Action = models.TxActionTransfer
+		tx.Recipient = details.Recipient
+		tx.Amount = details.Amount
+		tx.Token = details.Token
+	} else if selector, x, y, ok := decodeP256KeyCall(callData); ok {
+		// A signer may register a recovery key or rotate its own key; the wallets table
+		// follows once the receipt poller sees the UserOp succeed
+		tx.Action = models.TxActionRotateKey
+		if bytes.Equal(selector, setRecoveryKeySelector) {
+			tx.Action = models.TxActionSetRecoveryKey
+		}
+		tx.PublicKeyX, tx.PublicKeyY = x, y
+	}
+
+	if err := h.walletManager.RecordTransaction(ctx, tx); err != nil {
+		log.Printf("Warning: Failed to record transaction for UserOp %s: %v", userOpHash, err)
+	}
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// parseTransferAmount parses a transfer amount given in base units (wei, or the
+// token's smallest unit). It must be a plain base-10 integer, greater than zero and
+// no larger than uint256. decimals is the ERC-20 token's decimals (nil for native
+// transfers); a fractional amount is rejected with a hint at the base-unit scale.
+func parseTransferAmount(s string, decimals *uint8) (*big.Int, error) {
+	switch {
+	case s == "":
+		return nil, fmt.Errorf("amount is required")
+	case strings.HasPrefix(s, "-"):
+		return nil, fmt.Errorf("amount must be positive")
+	case strings.Contains(s, "."):
+		if decimals != nil {
+			return nil, fmt.Errorf("amount must be a whole number of base units (the token has %d decimals: 1 token = 1%s)", *decimals, strings.Repeat("0", int(*decimals)))
+		}
+		return nil, fmt.Errorf("amount must be a whole number of wei (1 coin = 1%s)", strings.Repeat("0", 18))
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return nil, fmt.Errorf("amount must be a base-10 integer")
+		}
+	}
+
+	amount, _ := new(big.Int).SetString(s, 10)
+	if amount.Sign() == 0 {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+	if amount.Cmp(maxUint256) > 0 {
+		return nil, fmt.Errorf("amount exceeds the uint256 maximum")
+	}
+	return amount, nil
+}
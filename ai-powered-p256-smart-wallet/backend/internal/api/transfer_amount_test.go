@@ -0,0 +1,75 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/apierr"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseTransferAmount(t *testing.T) {
+	six := uint8(6)
+	overflow := new(big.Int).Add(maxUint256, big.NewInt(1)).String()
+
+	for _, tc := range []struct {
+		name, amount string
+		decimals     *uint8
+		wantErr      string
+	}{
+		{"empty", "", nil, "amount is required"},
+		{"zero", "0", nil, "greater than zero"},
+		{"zero padded", "000", nil, "greater than zero"},
+		{"negative", "-1", nil, "must be positive"},
+		{"plus sign", "+1", nil, "base-10 integer"},
+		{"hex", "0x10", nil, "base-10 integer"},
+		{"non-numeric", "ten", nil, "base-10 integer"},
+		{"whitespace", " 1", nil, "base-10 integer"},
+		{"exponent", "1e18", nil, "base-10 integer"},
+		{"fractional wei", "1.5", nil, "whole number of wei"},
+		{"fractional token", "1.5", &six, "the token has 6 decimals: 1 token = 1000000"},
+		{"overflow", overflow, nil, "exceeds the uint256 maximum"},
+	} {
+		if _, err := parseTransferAmount(tc.amount, tc.decimals); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%s: err = %v, want %q", tc.name, err, tc.wantErr)
+		}
+	}
+
+	for _, amount := range []string{"1", "1500000", maxUint256.String()} {
+		got, err := parseTransferAmount(amount, &six)
+		if err != nil || got.String() != amount {
+			t.Errorf("parseTransferAmount(%s) = %v, %v", amount, got, err)
+		}
+	}
+}
+
+func TestPrepareTransferRejectsInvalidAmount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// No wallet manager: reaching wallet lookup or UserOp building would panic
+	h := &Handler{chainID: 133}
+
+	router := gin.New()
+	router.Use(apierr.Middleware(), func(c *gin.Context) { c.Set("userID", "user-1") })
+	router.POST("/transfer/prepare", h.PrepareTransferHandler)
+	router.POST("/transfer/simulate", h.SimulateTransferHandler)
+	router.POST("/estimate-gas", h.EstimateGasHandler)
+
+	for name, amount := range map[string]string{
+		"zero":        "0",
+		"negative":    "-1000",
+		"non-numeric": "1 HSK",
+		"overflow":    new(big.Int).Lsh(big.NewInt(1), 256).String(),
+	} {
+		body := `{"recipient":"0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1","amount":"` + amount + `"}`
+		for _, path := range []string{"/transfer/prepare", "/transfer/simulate", "/estimate-gas"} {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"code":"validation_error"`) || !strings.Contains(w.Body.String(), `"details":"amount`) {
+				t.Errorf("%s %s: got %d %s, want 400 with amount details", name, path, w.Code, w.Body.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// maxBatchTransfers caps a batch so its UserOp stays within the bundler's gas limits
+const maxBatchTransfers = 20
+
+// executeBatchSelector is P256Account.executeBatch(address[],uint256[],bytes[])
+var executeBatchSelector = crypto.Keccak256([]byte("executeBatch(address[],uint256[],bytes[])"))[:4]
+
+var (
+	executeBatchArguments = func() abi.Arguments {
+		addressArray, _ := abi.NewType("address[]", "", nil)
+		uint256Array, _ := abi.NewType("uint256[]", "", nil)
+		bytesArray, _ := abi.NewType("bytes[]", "", nil)
+		return abi.Arguments{{Type: addressArray}, {Type: uint256Array}, {Type: bytesArray}}
+	}()
+	erc20TransferArguments = func() abi.Arguments {
+		addressType, _ := abi.NewType("address", "", nil)
+		uint256Type, _ := abi.NewType("uint256", "", nil)
+		return abi.Arguments{{Type: addressType}, {Type: uint256Type}}
+	}()
+)
+
+// maxUint256 bounds values and their total, as the EntryPoint and wallet use uint256
+var maxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// Call is one call made by the wallet: value wei to To, with calldata Data
+type Call struct {
+	To    common.Address
+	Value *big.Int
+	Data  []byte
+}
+
+// batchTransferCalls validates a batch of transfers and converts it to wallet calls.
+// Native transfers send value to the recipient; ERC-20 transfers call token.transfer
+// with zero value. totalValue is the native value the batch sends, excluding gas.
+func batchTransferCalls(items []TransferItem) (calls []Call, totalValue *big.Int, err error) {
+	if len(items) == 0 {
+		return nil, nil, fmt.Errorf("transfers must not be empty")
+	}
+	if len(items) > maxBatchTransfers {
+		return nil, nil, fmt.Errorf("too many transfers: %d (max %d)", len(items), maxBatchTransfers)
+	}
+
+	totalValue = new(big.Int)
+	for i, item := range items {
+		if !common.IsHexAddress(item.Recipient) {
+			return nil, nil, fmt.Errorf("transfer %d: invalid recipient address", i)
+		}
+		amount, err := parseTransferAmount(item.Amount, nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("transfer %d: %w", i, err)
+		}
+		recipient := common.HexToAddress(item.Recipient)
+
+		if item.Token == "" {
+			calls = append(calls, Call{To: recipient, Value: amount})
+			totalValue.Add(totalValue, amount)
+			continue
+		}
+		if !common.IsHexAddress(item.Token) {
+			return nil, nil, fmt.Errorf("transfer %d: invalid token address", i)
+		}
+		args, err := erc20TransferArguments.Pack(recipient, amount)
+		if err != nil {
+			return nil, nil, fmt.Errorf("transfer %d: %w", i, err)
+		}
+		data := append(common.FromHex("0xa9059cbb"), args...) // transfer(address,uint256)
+		calls = append(calls, Call{To: common.HexToAddress(item.Token), Value: big.NewInt(0), Data: data})
+	}
+
+	if totalValue.Cmp(maxUint256) > 0 {
+		return nil, nil, fmt.Errorf("total value of transfers overflows uint256")
+	}
+	return calls, totalValue, nil
+}
+
+// encodeExecuteBatchCallP256 encodes wallet.executeBatch(address[],uint256[],bytes[])
+// The three arrays are built from calls index by index, so they always have the same
+// length; the contract reverts with "array length mismatch" otherwise.
+func encodeExecuteBatchCallP256(calls []Call) (string, error) {
+	if len(calls) == 0 {
+		return "", fmt.Errorf("empty batch")
+	}
+
+	dest := make([]common.Address, len(calls))
+	values := make([]*big.Int, len(calls))
+	data := make([][]byte, len(calls))
+	total := new(big.Int)
+	for i, call := range calls {
+		value := call.Value
+		if value == nil {
+			value = big.NewInt(0)
+		}
+		if value.Sign() < 0 || value.Cmp(maxUint256) > 0 {
+			return "", fmt.Errorf("call %d: value out of uint256 range", i)
+		}
+		total.Add(total, value)
+
+		dest[i] = call.To
+		values[i] = value
+		data[i] = call.Data
+		if data[i] == nil {
+			data[i] = []byte{}
+		}
+	}
+	if total.Cmp(maxUint256) > 0 {
+		return "", fmt.Errorf("total value out of uint256 range")
+	}
+
+	args, err := executeBatchArguments.Pack(dest, values, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to pack executeBatch: %w", err)
+	}
+	return hexutil.Encode(append(append([]byte{}, executeBatchSelector...), args...)), nil
+}
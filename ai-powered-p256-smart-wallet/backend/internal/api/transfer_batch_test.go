@@ -0,0 +1,187 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/apierr"
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"ai-wallet-backend/internal/wallet"
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	batchRecipientA = "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1"
+	batchRecipientB = "0x1111111111111111111111111111111111111111"
+	batchToken      = "0x2222222222222222222222222222222222222222"
+)
+
+// decodeExecuteBatch unpacks executeBatch callData into its three arrays
+func decodeExecuteBatch(t *testing.T, callData string) ([]common.Address, []*big.Int, [][]byte) {
+	t.Helper()
+	raw := hexutil.MustDecode(callData)
+	if !bytes.Equal(raw[:4], executeBatchSelector) {
+		t.Fatalf("selector = %x, want executeBatch", raw[:4])
+	}
+	values, err := executeBatchArguments.Unpack(raw[4:])
+	if err != nil {
+		t.Fatalf("unpack executeBatch: %v", err)
+	}
+	return values[0].([]common.Address), values[1].([]*big.Int), values[2].([][]byte)
+}
+
+func TestEncodeExecuteBatchMixedNativeAndERC20(t *testing.T) {
+	calls, total, err := batchTransferCalls([]TransferItem{
+		{Recipient: batchRecipientA, Amount: "1000000000000000000"},
+		{Recipient: batchRecipientB, Amount: "2500000", Token: batchToken},
+		{Recipient: batchRecipientB, Amount: "500000000000000000"},
+	})
+	if err != nil {
+		t.Fatalf("batchTransferCalls: %v", err)
+	}
+	if total.String() != "1500000000000000000" {
+		t.Fatalf("totalValue = %s, want only the native transfers", total)
+	}
+
+	callData, err := encodeExecuteBatchCallP256(calls)
+	if err != nil {
+		t.Fatalf("encodeExecuteBatchCallP256: %v", err)
+	}
+	if !strings.HasPrefix(callData, "0x47e1da2a") {
+		t.Fatalf("callData = %s, want executeBatch selector 0x47e1da2a", callData[:10])
+	}
+
+	dest, values, data := decodeExecuteBatch(t, callData)
+	if len(dest) != 3 || len(values) != 3 || len(data) != 3 {
+		t.Fatalf("array lengths %d/%d/%d, want 3", len(dest), len(values), len(data))
+	}
+
+	// Native: value to the recipient with empty calldata
+	if dest[0] != common.HexToAddress(batchRecipientA) || values[0].String() != "1000000000000000000" || len(data[0]) != 0 {
+		t.Fatalf("native call = %s %s %x", dest[0].Hex(), values[0], data[0])
+	}
+	// ERC-20: token.transfer(recipient, amount) with no native value
+	wantTransfer := "0xa9059cbb" +
+		"0000000000000000000000001111111111111111111111111111111111111111" +
+		fmt.Sprintf("%064x", 2500000)
+	if dest[1] != common.HexToAddress(batchToken) || values[1].Sign() != 0 || hexutil.Encode(data[1]) != wantTransfer {
+		t.Fatalf("ERC-20 call = %s %s %x", dest[1].Hex(), values[1], data[1])
+	}
+	if dest[2] != common.HexToAddress(batchRecipientB) || values[2].String() != "500000000000000000" {
+		t.Fatalf("second native call = %s %s", dest[2].Hex(), values[2])
+	}
+}
+
+func TestBatchTransferCallsValidation(t *testing.T) {
+	tooMany := make([]TransferItem, maxBatchTransfers+1)
+	for i := range tooMany {
+		tooMany[i] = TransferItem{Recipient: batchRecipientA, Amount: "1"}
+	}
+	half := new(big.Int).Rsh(maxUint256, 1)
+
+	for name, items := range map[string][]TransferItem{
+		"empty":             nil,
+		"too many":          tooMany,
+		"bad recipient":     {{Recipient: "0x1234", Amount: "1"}},
+		"zero amount":       {{Recipient: batchRecipientA, Amount: "0"}},
+		"negative amount":   {{Recipient: batchRecipientA, Amount: "-1"}},
+		"non-decimal":       {{Recipient: batchRecipientA, Amount: "0x10"}},
+		"bad token":         {{Recipient: batchRecipientA, Amount: "1", Token: "usdt"}},
+		"total overflows":   {{Recipient: batchRecipientA, Amount: half.String()}, {Recipient: batchRecipientB, Amount: half.String()}, {Recipient: batchRecipientB, Amount: "2"}},
+		"amount overflows":  {{Recipient: batchRecipientA, Amount: new(big.Int).Add(maxUint256, big.NewInt(1)).String()}},
+		"second item fails": {{Recipient: batchRecipientA, Amount: "1"}, {Recipient: batchRecipientB, Amount: ""}},
+	} {
+		if _, _, err := batchTransferCalls(items); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	// Token values never count towards the native total, however large
+	calls, total, err := batchTransferCalls([]TransferItem{
+		{Recipient: batchRecipientA, Amount: maxUint256.String(), Token: batchToken},
+		{Recipient: batchRecipientB, Amount: maxUint256.String(), Token: batchToken},
+	})
+	if err != nil || len(calls) != 2 || total.Sign() != 0 {
+		t.Fatalf("token-only batch: %d calls, total %v, err %v", len(calls), total, err)
+	}
+
+	if _, err := encodeExecuteBatchCallP256(nil); err == nil {
+		t.Error("encoded an empty batch")
+	}
+	if _, err := encodeExecuteBatchCallP256([]Call{{To: common.HexToAddress(batchRecipientA), Value: big.NewInt(-1)}}); err == nil {
+		t.Error("encoded a negative value")
+	}
+}
+
+func TestBuildBatchTransferUserOp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(chainWithCode))
+	t.Cleanup(server.Close)
+	manager, err := wallet.NewManager(dbtest.DryRun(t), server.URL, 133, "0x5555555555555555555555555555555555555555", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(manager.Close)
+	h := &Handler{chainID: 133, walletManager: manager}
+
+	calls, _, err := batchTransferCalls([]TransferItem{
+		{Recipient: batchRecipientA, Amount: "1"},
+		{Recipient: batchRecipientB, Amount: "2", Token: batchToken},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &models.Wallet{Address: batchRecipientA, ChainID: 133, FactoryAddress: "0x5555555555555555555555555555555555555555"}
+	userOp, err := h.buildBatchTransferUserOpP256(context.Background(), w, calls)
+	if err != nil {
+		t.Fatalf("buildBatchTransferUserOpP256: %v", err)
+	}
+
+	// One UserOp carrying both transfers
+	dest, _, _ := decodeExecuteBatch(t, userOp["callData"].(string))
+	if len(dest) != 2 || userOp["sender"] != batchRecipientA || userOp["nonce"] != "0x3" {
+		t.Fatalf("userOp = %v", userOp)
+	}
+
+	w.ChainID = 1
+	if _, err := h.buildBatchTransferUserOpP256(context.Background(), w, calls); err == nil {
+		t.Fatal("built a batch for a wallet on another chain")
+	}
+}
+
+func TestPrepareBatchTransferRejectsInvalidRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// No wallet manager: reaching wallet lookup or UserOp building would panic
+	h := &Handler{chainID: 133}
+
+	router := gin.New()
+	router.Use(apierr.Middleware(), func(c *gin.Context) { c.Set("userID", "user-1") })
+	router.POST("/transfer/prepare", h.PrepareTransferHandler)
+	router.POST("/transfer/simulate", h.SimulateTransferHandler)
+	router.POST("/estimate-gas", h.EstimateGasHandler)
+
+	batch := `"transfers":[{"recipient":"` + batchRecipientA + `","amount":"1"}]`
+	for _, tc := range []struct {
+		path, body, want string
+	}{
+		{"/transfer/prepare", `{"recipient":"` + batchRecipientB + `","amount":"1",` + batch + `}`, "not both"},
+		{"/transfer/prepare", `{"transfers":[{"recipient":"` + batchRecipientA + `","amount":"1"},{"recipient":"nope","amount":"1"}]}`, "transfer 1: invalid recipient"},
+		{"/transfer/prepare", `{"transfers":[]}`, "Invalid recipient address"},
+		{"/transfer/simulate", `{` + batch + `}`, "not simulated"},
+		{"/estimate-gas", `{` + batch + `}`, "not simulated"},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body)))
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tc.want) {
+			t.Errorf("%s %s: got %d %s, want 400 containing %q", tc.path, tc.body, w.Code, w.Body.String(), tc.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/apierr"
+	"ai-wallet-backend/internal/auth"
+	"ai-wallet-backend/internal/logging"
+	"ai-wallet-backend/internal/wallet"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrepareCrossDeviceResponse is a prepared transfer plus the one-time token that lets
+// another device (e.g. the phone holding the passkey) sign and submit it
+type PrepareCrossDeviceResponse struct {
+	PrepareTransferResponse
+	Token     string    `json:"token"`     // one-time token for /transfer/submit-crossdevice
+	ExpiresAt time.Time `json:"expiresAt"` // the token cannot be redeemed after this
+	Deeplink  string    `json:"deeplink"`  // QR code payload that opens the signing page on the other device
+}
+
+// SubmitCrossDeviceRequest is sent by the signing device; the token stands in for the session
+type SubmitCrossDeviceRequest struct {
+	Token        string `json:"token" binding:"required"`
+	Signature    string `json:"signature" binding:"required"`
+	CredentialID string `json:"credentialId" binding:"required"` // base64url ID of the passkey that signed
+}
+
+// crossDeviceTokenTTL returns how long a cross-device token can be redeemed; it never
+// outlives the pending UserOp it points to
+func crossDeviceTokenTTL() time.Duration {
+	ttl := wallet.DefaultCrossDeviceTokenTTL
+	if parsed, err := time.ParseDuration(os.Getenv("CROSSDEVICE_TOKEN_TTL")); err == nil && parsed > 0 {
+		ttl = parsed
+	}
+	return min(ttl, pendingUserOpTTL())
+}
+
+// crossDeviceSignURL is the page the deeplink opens: CROSSDEVICE_SIGN_URL, or /sign on
+// the first RP origin (the passkey only works on an RP origin)
+func (h *Handler) crossDeviceSignURL() string {
+	if signURL := os.Getenv("CROSSDEVICE_SIGN_URL"); signURL != "" {
+		return signURL
+	}
+	origin := auth.DefaultRPOrigin
+	if h.webAuthnService != nil {
+		origin = h.webAuthnService.Origins()[0]
+	}
+	return origin + "/sign"
+}
+
+// crossDeviceDeeplink encodes what the signing device needs: the token, the challenge
+// and the passkeys allowed to answer it
+func crossDeviceDeeplink(signURL, token string, prepared *PrepareTransferResponse) string {
+	query := url.Values{}
+	query.Set("token", token)
+	query.Set("userOpHash", prepared.UserOpHash)
+	query.Set("credentials", strings.Join(prepared.AllowCredentials, ","))
+
+	separator := "?"
+	if strings.Contains(signURL, "?") {
+		separator = "&"
+	}
+	return signURL + separator + query.Encode()
+}
+
+// PrepareCrossDeviceTransferHandler prepares a UserOp like /transfer/prepare and issues a
+// one-time token so the signature can come from another device
+func (h *Handler) PrepareCrossDeviceTransferHandler(c *gin.Context) {
+	userIDRaw, exists := c.Get("userID")
+	if !exists {
+		apierr.Abort(c, apierr.Unauthorized("unauthorized"))
+		return
+	}
+	userID := fmt.Sprintf("%v", userIDRaw)
+
+	var req PrepareTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, apierr.Validation("Invalid request body"))
+		return
+	}
+
+	prepared, ok := h.prepareTransfer(c, userID, &req)
+	if !ok {
+		return
+	}
+
+	token, expiresAt, err := h.crossDevice.Create(c.Request.Context(), userID, prepared.UserOpHash, crossDeviceTokenTTL())
+	if err != nil {
+		apierr.Abort(c, apierr.Internal("Failed to issue cross-device token", err))
+		return
+	}
+
+	logging.FromContext(c).Info().Time("expiresAt", expiresAt).Msg("cross-device signing token issued")
+
+	c.JSON(http.StatusOK, PrepareCrossDeviceResponse{
+		PrepareTransferResponse: *prepared,
+		Token:                   token,
+		ExpiresAt:               expiresAt,
+		Deeplink:                crossDeviceDeeplink(h.crossDeviceSignURL(), token, prepared),
+	})
+}
+
+// SubmitCrossDeviceTransferHandler submits a UserOp signed on another device. The token
+// authenticates the request in place of a session and is used up by the first attempt,
+// so a failed signature needs a new prepare.
+func (h *Handler) SubmitCrossDeviceTransferHandler(c *gin.Context) {
+	var req SubmitCrossDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, apierr.Validation("Invalid request body"))
+		return
+	}
+
+	redeemed, err := h.crossDevice.Consume(c.Request.Context(), req.Token)
+	switch {
+	case errors.Is(err, wallet.ErrCrossDeviceTokenExpired):
+		apierr.Abort(c, apierr.Expired("Cross-device token expired, please prepare the transfer again"))
+		return
+	case errors.Is(err, wallet.ErrCrossDeviceTokenInvalid):
+		logging.FromContext(c).Warn().Msg("invalid or reused cross-device token")
+		apierr.Abort(c, apierr.Unauthorized("Invalid or already used cross-device token"))
+		return
+	case err != nil:
+		apierr.Abort(c, apierr.Internal("Failed to redeem cross-device token", err))
+		return
+	}
+
+	h.submitTransfer(c, redeemed.UserID, &SubmitTransferRequest{
+		Signature:    req.Signature,
+		UserOpHash:   redeemed.UserOpHash,
+		CredentialID: req.CredentialID,
+	})
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/apierr"
+	"ai-wallet-backend/internal/wallet"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSubmitCrossDeviceTokenSingleUseAndExpiry(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	tokens := wallet.NewMemoryCrossDeviceTokenStore()
+	// No wallet manager or database: a redeemed token stops at the pending UserOp lookup
+	h := &Handler{chainID: 133, crossDevice: tokens, pendingOps: wallet.NewMemoryPendingUserOpStore()}
+
+	// The signing device has no session: the route must not depend on a userID
+	router := gin.New()
+	router.Use(apierr.Middleware())
+	router.POST("/transfer/submit-crossdevice", h.SubmitCrossDeviceTransferHandler)
+	submit := func(token string) *httptest.ResponseRecorder {
+		body := `{"token":"` + token + `","signature":"0x01","credentialId":"AQID"}`
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/transfer/submit-crossdevice", strings.NewReader(body)))
+		return w
+	}
+
+	token, _, err := tokens.Create(ctx, "user-1", "0xprepared", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first attempt redeems the token (the UserOp itself has since been dropped)
+	if w := submit(token); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "UserOp not found") {
+		t.Fatalf("first submit: got %d %s, want 400 UserOp not found", w.Code, w.Body.String())
+	}
+	// Replaying the token is rejected before anything is loaded
+	if w := submit(token); w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "already used") {
+		t.Fatalf("replayed token: got %d %s, want 401", w.Code, w.Body.String())
+	}
+
+	expired, _, err := tokens.Create(ctx, "user-1", "0xprepared", -time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := submit(expired); w.Code != http.StatusGone || !strings.Contains(w.Body.String(), string(apierr.CodeExpired)) {
+		t.Fatalf("expired token: got %d %s, want 410 expired", w.Code, w.Body.String())
+	}
+
+	if w := submit("forged"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("unknown token: got %d %s, want 401", w.Code, w.Body.String())
+	}
+}
+
+func TestCrossDeviceDeeplink(t *testing.T) {
+	prepared := &PrepareTransferResponse{UserOpHash: "0xabc", AllowCredentials: []string{"cred-1", "cred-2"}}
+
+	for _, signURL := range []string{"https://app.example.com/sign", "https://app.example.com/sign?lang=en"} {
+		link, err := url.Parse(crossDeviceDeeplink(signURL, "tok+/=", prepared))
+		if err != nil {
+			t.Fatalf("deeplink does not parse: %v", err)
+		}
+		query := link.Query()
+		if query.Get("token") != "tok+/=" || query.Get("userOpHash") != "0xabc" || query.Get("credentials") != "cred-1,cred-2" {
+			t.Fatalf("deeplink query = %v", query)
+		}
+		if link.Path != "/sign" {
+			t.Fatalf("deeplink path = %s", link.Path)
+		}
+	}
+}
+
+func TestCrossDeviceTokenTTL(t *testing.T) {
+	t.Setenv("CROSSDEVICE_TOKEN_TTL", "")
+	t.Setenv("PENDING_USEROP_TTL", "")
+	if got := crossDeviceTokenTTL(); got != wallet.DefaultCrossDeviceTokenTTL {
+		t.Fatalf("default TTL = %s", got)
+	}
+
+	// A token never outlives the UserOp it signs
+	t.Setenv("CROSSDEVICE_TOKEN_TTL", "30m")
+	t.Setenv("PENDING_USEROP_TTL", "5m")
+	if got := crossDeviceTokenTTL(); got != 5*time.Minute {
+		t.Fatalf("TTL = %s, want the pending UserOp TTL", got)
+	}
+}
@@ -91,10 +91,10 @@ func (h *Handler) EstimateTransfer(c *gin.Context) {
 	defer txService.Close()
 
 	// DEPRECATED: This endpoint is for the old custodial flow
-	// For P256 wallets, use the UserOp flow where frontend signs and submits to /api/userop
+	// For P256 wallets, use the UserOp flow: /api/transfer/prepare, sign with Passkey, /api/transfer/submit
 	c.JSON(http.StatusBadRequest, gin.H{
 		"error":   "This endpoint is deprecated for P256 wallets. Please use UserOperation flow instead.",
-		"details": "Build and sign UserOp on frontend with Passkey, then submit to /api/userop",
+		"details": "Prepare the UserOp with /api/transfer/prepare, sign it with Passkey, then submit to /api/transfer/submit",
 	})
 	return
 
@@ -164,10 +164,10 @@ func (h *Handler) ExecuteTransfer(c *gin.Context) {
 
 	// DEPRECATED: This endpoint is for the old custodial flow
 	// For P256 wallets, backend no longer has private keys
-	// Use the UserOp flow where frontend signs with Passkey and submits to /api/userop
+	// Use the UserOp flow: /api/transfer/prepare, sign with Passkey, /api/transfer/submit
 	c.JSON(http.StatusBadRequest, gin.H{
 		"error":   "This endpoint is deprecated for P256 wallets. Please use UserOperation flow instead.",
-		"details": "Build UserOp on frontend, sign with Passkey, then submit to /api/userop",
+		"details": "Prepare the UserOp with /api/transfer/prepare, sign it with Passkey, then submit to /api/transfer/submit",
 	})
 	return
 
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/apierr"
+	"ai-wallet-backend/internal/chains"
+	"ai-wallet-backend/internal/logging"
+	"ai-wallet-backend/internal/models"
+	"ai-wallet-backend/internal/wallet"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetTransferLimits enables the transfer limits (TRANSFER_LIMITS). The native coin's
+// symbol defaults to the chain's, so AI operation cards naming it are checked too.
+func (h *Handler) SetTransferLimits(limits wallet.TransferLimits) {
+	if native, ok := limits[wallet.NativeToken]; ok && native.Symbol == "" {
+		if chain, known := chains.Default.Get(int64(h.chainID)); known {
+			native.Symbol = chain.Symbol
+			limits[wallet.NativeToken] = native
+		}
+	}
+	h.transferLimits = limits
+}
+
+// checkTransferLimits checks each transfer of a prepare request against its token's
+// min and max and returns the request's total per token for the daily caps. amount is
+// the parsed amount of a single transfer. It aborts the request and returns ok == false
+// when a transfer is out of bounds.
+func (h *Handler) checkTransferLimits(c *gin.Context, req *PrepareTransferRequest, amount *big.Int) (amounts map[string]*big.Int, ok bool) {
+	if len(h.transferLimits) == 0 {
+		return nil, true
+	}
+
+	amounts = make(map[string]*big.Int)
+	check := func(token string, amount *big.Int) bool {
+		if err := h.transferLimits.CheckTransfer(token, amount); err != nil {
+			abortTransferLimit(c, err)
+			return false
+		}
+		token = wallet.TokenKey(token)
+		if amounts[token] == nil {
+			amounts[token] = new(big.Int)
+		}
+		amounts[token].Add(amounts[token], amount)
+		return true
+	}
+
+	if len(req.Transfers) == 0 {
+		return amounts, check(req.Token, amount)
+	}
+	for _, item := range req.Transfers {
+		// Already validated by batchTransferCalls
+		itemAmount, err := parseTransferAmount(item.Amount, nil)
+		if err != nil {
+			apierr.Abort(c, apierr.Validation("Invalid amount").WithDetails(err.Error()))
+			return nil, false
+		}
+		if !check(item.Token, itemAmount) {
+			return nil, false
+		}
+	}
+	return amounts, true
+}
+
+// reserveTransferUsage counts a prepared UserOp's amounts towards the user's daily caps.
+// When a cap would be exceeded it discards the prepared UserOp, aborts the request and
+// returns false.
+func (h *Handler) reserveTransferUsage(c *gin.Context, userID string, userWallet *models.Wallet, userOp map[string]interface{}, userOpHash string, amounts map[string]*big.Int) bool {
+	if len(amounts) == 0 {
+		return true
+	}
+	err := h.transferUsage.Reserve(c.Request.Context(), userID, userOpHash, amounts, h.transferLimits, pendingUserOpTTL())
+	if err == nil {
+		return true
+	}
+
+	// The UserOp will never be signed: free its nonce and drop it
+	h.releaseNonce(c, userWallet.Address, hexToBigIntHelper(userOp["nonce"]))
+	if deleteErr := h.pendingOps.Delete(c.Request.Context(), userOpHash); deleteErr != nil {
+		logging.FromContext(c).Warn().Err(deleteErr).Msg("failed to delete pending UserOp")
+	}
+	if errors.Is(err, wallet.ErrTransferLimit) {
+		abortTransferLimit(c, err)
+		return false
+	}
+	apierr.Abort(c, apierr.Internal("Failed to check daily transfer limit", err))
+	return false
+}
+
+// abortTransferLimit rejects a transfer outside the limits with its details
+func abortTransferLimit(c *gin.Context, err error) {
+	var limitErr *wallet.TransferLimitError
+	if !errors.As(err, &limitErr) {
+		apierr.Abort(c, apierr.Internal("Failed to check transfer limits", err))
+		return
+	}
+	logging.FromContext(c).Info().Str("token", limitErr.Token).Str("limit", limitErr.Kind).Msg("transfer rejected by transfer limits")
+	apierr.Abort(c, apierr.TransferLimit(capitalize(limitErr.Error()), limitErr.Details()))
+}
+
+// confirmTransferUsage keeps a submitted UserOp's usage counting for the rest of the day
+func (h *Handler) confirmTransferUsage(c *gin.Context, userOpHash string) {
+	if h.transferUsage == nil {
+		return
+	}
+	if err := h.transferUsage.Confirm(c.Request.Context(), userOpHash); err != nil {
+		logging.FromContext(c).Warn().Err(err).Msg("failed to confirm transfer usage")
+	}
+}
+
+// releaseTransferUsage frees the daily allowance held by a UserOp that was not submitted
+func (h *Handler) releaseTransferUsage(c *gin.Context, userOpHash string) {
+	if h.transferUsage == nil {
+		return
+	}
+	if err := h.transferUsage.Release(c.Request.Context(), userOpHash); err != nil {
+		logging.FromContext(c).Warn().Err(err).Msg("failed to release transfer usage")
+	}
+}
+
+// TransferLimitInfo describes one token's limits and what the user has left today; amounts
+// are base-unit strings
+type TransferLimitInfo struct {
+	Token          string `json:"token"` // "native" or ERC-20 address
+	Symbol         string `json:"symbol,omitempty"`
+	Decimals       uint8  `json:"decimals"`
+	Min            string `json:"min,omitempty"`
+	Max            string `json:"max,omitempty"`
+	Daily          string `json:"daily,omitempty"`
+	UsedToday      string `json:"usedToday"`
+	RemainingToday string `json:"remainingToday,omitempty"` // empty without a daily cap
+}
+
+// TransferLimitsResponse lists the configured transfer limits
+type TransferLimitsResponse struct {
+	Limits   []TransferLimitInfo `json:"limits"`
+	ResetsAt time.Time           `json:"resetsAt"` // when the daily caps reset (00:00 UTC)
+}
+
+// TransferLimitsHandler returns the transfer limits and the caller's remaining daily allowance
+func (h *Handler) TransferLimitsHandler(c *gin.Context) {
+	userIDRaw, exists := c.Get("userID")
+	if !exists {
+		apierr.Abort(c, apierr.Unauthorized("unauthorized"))
+		return
+	}
+	userID := fmt.Sprintf("%v", userIDRaw)
+
+	_, resetsAt := wallet.DailyWindow(time.Now())
+	response := TransferLimitsResponse{Limits: []TransferLimitInfo{}, ResetsAt: resetsAt}
+	if len(h.transferLimits) == 0 {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	used, err := h.transferUsage.UsedToday(c.Request.Context(), userID)
+	if err != nil {
+		apierr.Abort(c, apierr.Internal("Failed to load transfer usage", err))
+		return
+	}
+
+	for token, limit := range h.transferLimits {
+		spent := used[token]
+		if spent == nil {
+			spent = new(big.Int)
+		}
+		info := TransferLimitInfo{
+			Token:     token,
+			Symbol:    limit.Symbol,
+			Decimals:  limit.Decimals,
+			Min:       bigString(limit.Min),
+			Max:       bigString(limit.Max),
+			Daily:     bigString(limit.Daily),
+			UsedToday: spent.String(),
+		}
+		if limit.Daily != nil {
+			info.RemainingToday = wallet.Remaining(limit.Daily, spent).String()
+		}
+		response.Limits = append(response.Limits, info)
+	}
+	// Native coin first, then tokens by address
+	sort.Slice(response.Limits, func(i, j int) bool {
+		a, b := response.Limits[i].Token, response.Limits[j].Token
+		if a == wallet.NativeToken || b == wallet.NativeToken {
+			return a == wallet.NativeToken
+		}
+		return a < b
+	})
+	c.JSON(http.StatusOK, response)
+}
+
+// bigString renders an optional bound, "" when it is not set
+func bigString(n *big.Int) string {
+	if n == nil {
+		return ""
+	}
+	return n.String()
+}
+
+// flagTransferLimit fills the problem card of an AI transfer operation that the transfer
+// limits would reject, so the user learns before signing instead of from a failed prepare
+func (h *Handler) flagTransferLimit(c *gin.Context, userID string, response *models.AIResponse) {
+	if len(h.transferLimits) == 0 || response == nil || response.AIResponse == nil {
+		return
+	}
+	op := response.AIResponse.Operation
+	if op == nil || op.Action != "transfer" || op.Amount <= 0 {
+		return
+	}
+
+	// Operation cards name the asset by symbol; no asset means the native coin
+	token, limit, ok := wallet.NativeToken, wallet.TransferLimit{}, false
+	if op.Asset == "" {
+		limit, ok = h.transferLimits[wallet.NativeToken]
+	} else {
+		token, limit, ok = h.transferLimits.BySymbol(op.Asset)
+	}
+	if !ok {
+		return
+	}
+	amount, ok := decimalToBaseUnits(strconv.FormatFloat(op.Amount, 'f', -1, 64), limit.Decimals)
+	if !ok {
+		return
+	}
+
+	err := h.transferLimits.CheckTransfer(token, amount)
+	if err == nil {
+		used, usedErr := h.transferUsage.UsedToday(c.Request.Context(), userID)
+		if usedErr != nil {
+			logging.FromContext(c).Warn().Err(usedErr).Msg("failed to load transfer usage for AI operation")
+			return
+		}
+		err = h.transferLimits.CheckDaily(used, map[string]*big.Int{token: amount})
+	}
+	var limitErr *wallet.TransferLimitError
+	if !errors.As(err, &limitErr) {
+		return
+	}
+
+	format := func(n *big.Int) string {
+		if limit.Symbol == "" {
+			return wallet.FormatUnits(n, limit.Decimals)
+		}
+		return wallet.FormatUnits(n, limit.Decimals) + " " + limit.Symbol
+	}
+	var suggestions []string
+	switch limitErr.Kind {
+	case wallet.TransferLimitMin:
+		suggestions = []string{fmt.Sprintf("Send at least %s", format(limitErr.Limit))}
+	case wallet.TransferLimitMax:
+		suggestions = []string{fmt.Sprintf("Send at most %s per transfer", format(limitErr.Limit))}
+	default:
+		suggestions = []string{fmt.Sprintf("You can still send %s today", format(limitErr.Remaining)), "The daily limit resets at 00:00 UTC"}
+	}
+	response.AIResponse.Problem = &models.ProblemAnalysis{
+		Type:        "error",
+		Title:       "Transfer limit exceeded",
+		Description: capitalize(limitErr.Error()),
+		Suggestions: suggestions,
+	}
+}
+
+// decimalToBaseUnits converts a decimal amount (e.g. "1.5") to base units, truncating
+// digits beyond decimals
+func decimalToBaseUnits(s string, decimals uint8) (*big.Int, bool) {
+	amount, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, false
+	}
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	amount.Mul(amount, new(big.Rat).SetInt(unit))
+	return new(big.Int).Quo(amount.Num(), amount.Denom()), true
+}
+
+// capitalize upper-cases the first letter of an error message used as an API message
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
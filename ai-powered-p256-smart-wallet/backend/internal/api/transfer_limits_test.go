@@ -0,0 +1,231 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/apierr"
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"ai-wallet-backend/internal/wallet"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testLimits: native 0.01-5 HSK per transfer and 10 HSK per day
+func testLimits(t *testing.T) wallet.TransferLimits {
+	t.Helper()
+	limits, err := wallet.ParseTransferLimits([]byte(`{"native": {"min": "10000000000000000", "max": "5000000000000000000", "daily": "10000000000000000000"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return limits
+}
+
+func TestPrepareTransferEnforcesSingleTransferLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// No wallet manager: limits are checked before the wallet is looked up
+	h := &Handler{chainID: 133}
+	h.SetTransferLimits(testLimits(t))
+
+	router := gin.New()
+	router.Use(apierr.Middleware(), func(c *gin.Context) { c.Set("userID", "user-1") })
+	router.POST("/transfer/prepare", h.PrepareTransferHandler)
+
+	const recipient = "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1"
+	for _, tc := range []struct {
+		name    string
+		body    string
+		limit   string
+		message string
+	}{
+		{"above max", `{"recipient":"` + recipient + `","amount":"6000000000000000000"}`, "max", "Transfer of 6 HSK exceeds the maximum of 5 HSK per transfer"},
+		{"below min", `{"recipient":"` + recipient + `","amount":"1000"}`, "min", "Transfer of 0.000000000000001 HSK is below the minimum of 0.01 HSK"},
+		{"batch item above max", `{"transfers":[{"recipient":"` + recipient + `","amount":"1000000000000000000"},{"recipient":"` + recipient + `","amount":"6000000000000000000"}]}`, "max", "Transfer of 6 HSK exceeds the maximum of 5 HSK per transfer"},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/transfer/prepare", strings.NewReader(tc.body)))
+
+		var body struct {
+			Code    apierr.Code       `json:"code"`
+			Message string            `json:"message"`
+			Details map[string]string `json:"details"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != http.StatusUnprocessableEntity || body.Code != apierr.CodeTransferLimit || body.Details["limit"] != tc.limit || body.Message != tc.message {
+			t.Errorf("%s: got %d %s", tc.name, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestPreparedTransfersHitDailyCap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := httptest.NewServer(http.HandlerFunc(chainWithCode))
+	t.Cleanup(server.Close)
+	manager, err := wallet.NewManager(dbtest.DryRun(t), server.URL, 133, "0x5555555555555555555555555555555555555555", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(manager.Close)
+	pendingOps := wallet.NewMemoryPendingUserOpStore()
+	h := &Handler{
+		chainID:       133,
+		walletManager: manager,
+		pendingOps:    pendingOps,
+		nonces:        wallet.NewMemoryNonceReservationStore(),
+		transferUsage: wallet.NewMemoryTransferUsageStore(),
+	}
+	h.SetTransferLimits(testLimits(t))
+
+	// Prepares 4 HSK the way prepareTransfer does, returning the UserOp hash and nonce
+	w := &models.Wallet{Address: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", ChainID: 133}
+	amount, _ := new(big.Int).SetString("4000000000000000000", 10)
+	prepare := func() (userOpHash, nonce string, c *gin.Context) {
+		t.Helper()
+		userOp, err := h.buildTransferUserOpP256(context.Background(), w, w.Address, amount, "")
+		if err != nil {
+			t.Fatalf("buildTransferUserOpP256: %v", err)
+		}
+		c, _ = gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/transfer/prepare", nil)
+		amounts, ok := h.checkTransferLimits(c, &PrepareTransferRequest{Recipient: w.Address, Amount: amount.String()}, amount)
+		if !ok {
+			t.Fatalf("checkTransferLimits failed: %v", c.Errors)
+		}
+		userOpHash, _, ok = h.storePreparedUserOp(c, "user-1", w, userOp)
+		if !ok {
+			t.Fatalf("storePreparedUserOp failed: %v", c.Errors)
+		}
+		h.reserveTransferUsage(c, "user-1", w, userOp, userOpHash, amounts)
+		return userOpHash, userOp["nonce"].(string), c
+	}
+
+	// 4 + 4 HSK fit under the 10 HSK cap
+	for i := 0; i < 2; i++ {
+		if _, _, c := prepare(); len(c.Errors) > 0 {
+			t.Fatalf("prepare %d rejected: %v", i+1, c.Errors)
+		}
+	}
+
+	// The third is rejected and its UserOp discarded
+	userOpHash, nonce, c := prepare()
+	var apiErr *apierr.Error
+	if len(c.Errors) == 0 || !errors.As(c.Errors.Last().Err, &apiErr) || apiErr.Code != apierr.CodeTransferLimit {
+		t.Fatalf("third prepare: errors = %v, want transfer limit", c.Errors)
+	}
+	if details := apiErr.Details.(map[string]interface{}); details["limit"] != wallet.TransferLimitDaily || details["remaining"] != "2000000000000000000" {
+		t.Fatalf("details = %v", details)
+	}
+	if _, err := pendingOps.Get(context.Background(), userOpHash, "user-1"); !errors.Is(err, wallet.ErrPendingUserOpNotFound) {
+		t.Fatalf("rejected UserOp still pending: %v", err)
+	}
+	// Its nonce is handed out again
+	if nonce != "0x5" {
+		t.Fatalf("third nonce = %s, want 0x5", nonce)
+	}
+	if _, next, _ := prepare(); next != "0x5" {
+		t.Fatalf("nonce after rejection = %s, want 0x5 reused", next)
+	}
+
+	// A failed submission frees its allowance
+	h.releaseTransferUsage(c, userOpHash)
+	used, err := h.transferUsage.UsedToday(context.Background(), "user-1")
+	if err != nil || used[wallet.NativeToken].String() != "8000000000000000000" {
+		t.Fatalf("UsedToday = %v, %v; want 8 HSK", used, err)
+	}
+}
+
+func TestTransferLimitsHandlerReportsRemainingAllowance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{chainID: 133, transferUsage: wallet.NewMemoryTransferUsageStore()}
+	h.SetTransferLimits(testLimits(t))
+	amount, _ := new(big.Int).SetString("3500000000000000000", 10)
+	if err := h.transferUsage.Reserve(context.Background(), "user-1", "0xop", map[string]*big.Int{wallet.NativeToken: amount}, h.transferLimits, pendingUserOpTTL()); err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.Use(apierr.Middleware(), func(c *gin.Context) { c.Set("userID", "user-1") })
+	router.GET("/transfer/limits", h.TransferLimitsHandler)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/transfer/limits", nil))
+
+	var response TransferLimitsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("got %d %s", w.Code, w.Body.String())
+	}
+	if len(response.Limits) != 1 {
+		t.Fatalf("limits = %+v", response.Limits)
+	}
+	native := response.Limits[0]
+	if native.Token != wallet.NativeToken || native.Symbol != "HSK" || native.UsedToday != "3500000000000000000" || native.RemainingToday != "6500000000000000000" {
+		t.Fatalf("native limit = %+v", native)
+	}
+	if response.ResetsAt.Hour() != 0 || response.ResetsAt.Minute() != 0 {
+		t.Fatalf("resetsAt = %s, want midnight UTC", response.ResetsAt)
+	}
+}
+
+func TestFlagTransferLimitFillsProblemCard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{chainID: 133, transferUsage: wallet.NewMemoryTransferUsageStore()}
+	h.SetTransferLimits(testLimits(t))
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/chat", nil)
+
+	card := func(asset string, amount float64) *models.AIResponse {
+		return &models.AIResponse{AIResponse: &models.AIStructure{Operation: &models.Operation{
+			Action: "transfer", Asset: asset, Amount: amount, Recipient: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1",
+		}}}
+	}
+
+	// Above the per-transfer max
+	response := card("HSK", 6)
+	h.flagTransferLimit(c, "user-1", response)
+	problem := response.AIResponse.Problem
+	if problem == nil || problem.Type != "error" || problem.Description != "Transfer of 6 HSK exceeds the maximum of 5 HSK per transfer" {
+		t.Fatalf("problem = %+v", problem)
+	}
+
+	// Within the max but over what is left of the daily cap
+	used, _ := new(big.Int).SetString("8000000000000000000", 10)
+	if err := h.transferUsage.Reserve(context.Background(), "user-1", "0xop", map[string]*big.Int{wallet.NativeToken: used}, h.transferLimits, pendingUserOpTTL()); err != nil {
+		t.Fatal(err)
+	}
+	response = card("", 2.5)
+	h.flagTransferLimit(c, "user-1", response)
+	problem = response.AIResponse.Problem
+	if problem == nil || len(problem.Suggestions) == 0 || problem.Suggestions[0] != "You can still send 2 HSK today" {
+		t.Fatalf("problem = %+v", problem)
+	}
+
+	// Allowed transfers and assets without limits keep the model's card
+	for _, response := range []*models.AIResponse{card("HSK", 1.5), card("USDT", 1000)} {
+		h.flagTransferLimit(c, "user-1", response)
+		if response.AIResponse.Problem != nil {
+			t.Fatalf("%s: unexpected problem %+v", response.AIResponse.Operation.Asset, response.AIResponse.Problem)
+		}
+	}
+}
+
+func TestDecimalToBaseUnits(t *testing.T) {
+	for input, want := range map[string]string{
+		"1.5":          "1500000000000000000",
+		"0.000001":     "1000000000000",
+		"2":            "2000000000000000000",
+		"0.1234567891": "123456789100000000",
+	} {
+		got, ok := decimalToBaseUnits(input, 18)
+		if !ok || got.String() != want {
+			t.Errorf("decimalToBaseUnits(%s) = %v, want %s", input, got, want)
+		}
+	}
+	if got, _ := decimalToBaseUnits("1.2345678", 6); got.String() != "1234567" {
+		t.Errorf("6 decimals: got %s, want truncation to 1234567", got)
+	}
+}
@@ -1,256 +1,633 @@
 package api
 
 import (
+	"ai-wallet-backend/internal/apierr"
+	"ai-wallet-backend/internal/auth"
+	"ai-wallet-backend/internal/chains"
+	"ai-wallet-backend/internal/logging"
 	"ai-wallet-backend/internal/models"
+	"ai-wallet-backend/internal/wallet"
+	"ai-wallet-backend/internal/webauthnp256"
+	"cmp"
 	"context"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
+	"maps"
 	"math/big"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
-	"sync"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
 )
 
 // PrepareTransferRequest for signing flow
+// Either Recipient/Amount/Token describe a single transfer, or Transfers lists a batch
+// that is executed atomically in one UserOp
 type PrepareTransferRequest struct {
-	Recipient string `json:"recipient" binding:"required"`
-	Amount    string `json:"amount" binding:"required"` // in wei
+	Recipient string         `json:"recipient"`
+	Amount    string         `json:"amount"`              // in wei (or token base units when Token is set)
+	Token     string         `json:"token,omitempty"`     // ERC-20 contract address; empty for native HSK
+	Transfers []TransferItem `json:"transfers,omitempty"` // batch transfer (/transfer/prepare only)
+	ChainID   int64          `json:"chainId,omitempty"`   // Optional; must match the server's CHAIN_ID
+}
+
+// TransferItem is one transfer of a batch
+type TransferItem struct {
+	Recipient string `json:"recipient"`
+	Amount    string `json:"amount"`          // in wei (or token base units when Token is set)
+	Token     string `json:"token,omitempty"` // ERC-20 contract address; empty for native HSK
 }
 
 // PrepareTransferResponse contains UserOp hash for signing
 type PrepareTransferResponse struct {
-	UserOpHash   string `json:"userOpHash"`   // Hash to use as WebAuthn challenge
-	CredentialID string `json:"credentialId"` // Passkey credential ID
+	UserOpHash        string   `json:"userOpHash"`        // Hash to use as WebAuthn challenge
+	CredentialID      string   `json:"credentialId"`      // Most recently used allowed credential (kept for older clients)
+	AllowCredentials  []string `json:"allowCredentials"`  // Passkey credential IDs (base64url) that can sign for the wallet
+	EntryPointVersion string   `json:"entryPointVersion"` // "0.6" (UserOperation) or "0.7" (PackedUserOperation)
+	Token             string   `json:"token,omitempty"`   // ERC-20 contract being transferred, if any
+	TokenDecimals     *uint8   `json:"tokenDecimals,omitempty"`
+	TransferCount     int      `json:"transferCount"`       // 1, or the number of transfers in the batch
+	TotalValue        string   `json:"totalValue"`          // native wei sent by the UserOp, excluding gas
+	Sponsored         bool     `json:"sponsored"`           // gas is paid by the paymaster, not the wallet
+	Paymaster         string   `json:"paymaster,omitempty"` // sponsoring paymaster contract
 }
 
 // SubmitTransferRequest contains the signature
 type SubmitTransferRequest struct {
-	Signature  string `json:"signature" binding:"required"`
-	UserOpHash string `json:"userOpHash" binding:"required"`
+	Signature    string `json:"signature" binding:"required"`
+	UserOpHash   string `json:"userOpHash" binding:"required"`
+	CredentialID string `json:"credentialId" binding:"required"` // base64url ID of the passkey that signed
 }
 
-// Temporary storage for pending UserOps
-// In production, use Redis or database
-var pendingUserOps = struct {
-	sync.RWMutex
-	ops map[string]map[string]interface{} // userOpHash -> UserOp
-}{ops: make(map[string]map[string]interface{})}
+// pendingUserOpTTL returns how long a prepared UserOp may wait for its signature
+func pendingUserOpTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("PENDING_USEROP_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return wallet.DefaultPendingUserOpTTL
+}
 
 // PrepareTransferHandler prepares a UserOp for signing
 func (h *Handler) PrepareTransferHandler(c *gin.Context) {
 	userIDRaw, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		apierr.Abort(c, apierr.Unauthorized("unauthorized"))
 		return
 	}
 	userID := fmt.Sprintf("%v", userIDRaw)
 
 	var req PrepareTransferRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		apierr.Abort(c, apierr.Validation("Invalid request body"))
 		return
 	}
 
-	// Validate recipient
-	if !common.IsHexAddress(req.Recipient) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recipient address"})
+	response, ok := h.prepareTransfer(c, userID, &req)
+	if !ok {
 		return
 	}
+	c.JSON(http.StatusOK, response)
+}
 
-	// Parse amount
-	amount := new(big.Int)
-	if _, ok := amount.SetString(req.Amount, 10); !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid amount"})
-		return
+// prepareTransfer builds, stores and describes the UserOp for a transfer request.
+// It aborts the request and returns ok == false on failure.
+func (h *Handler) prepareTransfer(c *gin.Context, userID string, req *PrepareTransferRequest) (*PrepareTransferResponse, bool) {
+	// The chain is fixed by the server; a request (e.g. built from an AI operation card) cannot pick another one
+	if req.ChainID != 0 && req.ChainID != int64(h.chainID) {
+		logging.FromContext(c).Warn().Int64("chainId", req.ChainID).Msg("transfer rejected for foreign chain")
+		apierr.Abort(c, apierr.Validation(fmt.Sprintf("Unsupported chain ID %d: this wallet only supports chain %d", req.ChainID, h.chainID)))
+		return nil, false
+	}
+
+	// A batch is a list of transfers; otherwise the request is a single transfer
+	var calls []Call
+	var tokenDecimals *uint8
+	var amount *big.Int
+	totalValue := new(big.Int) // native value leaving the wallet, excluding gas
+	if len(req.Transfers) > 0 {
+		if req.Recipient != "" || req.Amount != "" || req.Token != "" {
+			apierr.Abort(c, apierr.Validation("Use either recipient/amount/token or transfers, not both"))
+			return nil, false
+		}
+		var err error
+		if calls, totalValue, err = batchTransferCalls(req.Transfers); err != nil {
+			apierr.Abort(c, apierr.Validation(err.Error()))
+			return nil, false
+		}
+		for i, item := range req.Transfers {
+			if item.Token == "" {
+				continue
+			}
+			if _, err := h.walletManager.GetTokenDecimals(c.Request.Context(), item.Token); err != nil {
+				logging.FromContext(c).Warn().Err(err).Str("token", item.Token).Msg("failed to get token decimals")
+				apierr.Abort(c, apierr.Validation(fmt.Sprintf("transfer %d: token contract does not look like an ERC-20", i)))
+				return nil, false
+			}
+		}
+	} else {
+		// Validate recipient
+		if !common.IsHexAddress(req.Recipient) {
+			apierr.Abort(c, apierr.Validation("Invalid recipient address"))
+			return nil, false
+		}
+
+		// Validate token (amount is already in the token's base units)
+		if req.Token != "" {
+			if !common.IsHexAddress(req.Token) {
+				apierr.Abort(c, apierr.Validation("Invalid token address"))
+				return nil, false
+			}
+			decimals, err := h.walletManager.GetTokenDecimals(c.Request.Context(), req.Token)
+			if err != nil {
+				logging.FromContext(c).Warn().Err(err).Str("token", req.Token).Msg("failed to get token decimals")
+				apierr.Abort(c, apierr.Validation("Token contract does not look like an ERC-20"))
+				return nil, false
+			}
+			tokenDecimals = &decimals
+		}
+
+		// Parse amount: a positive uint256 in base units
+		var err error
+		if amount, err = parseTransferAmount(req.Amount, tokenDecimals); err != nil {
+			apierr.Abort(c, apierr.Validation("Invalid amount").WithDetails(err.Error()))
+			return nil, false
+		}
+		if req.Token == "" {
+			totalValue = amount
+		}
+	}
+
+	// Each transfer must be within its token's min/max; the daily caps are checked
+	// once the UserOp is stored
+	amounts, ok := h.checkTransferLimits(c, req, amount)
+	if !ok {
+		return nil, false
 	}
 
 	// Get user's wallet
-	wallet, err := h.walletManager.GetWalletByUserID(userID)
+	userWallet, err := h.walletManager.GetWalletByUserID(userID)
 	if err != nil {
-		log.Printf("Error getting wallet: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get wallet"})
-		return
+		apierr.Abort(c, apierr.Internal("Failed to get wallet", err))
+		return nil, false
 	}
 
-	// Get user's passkey credential
-	var credential models.PasskeyCredential
-	err = h.db.Where("user_id = ?", userID).First(&credential).Error
+	// Get the user's passkeys that can sign for this wallet
+	credentials, err := h.walletSignerCredentials(userID, userWallet)
 	if err != nil {
-		log.Printf("Error getting credential: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get credential"})
-		return
+		apierr.Abort(c, apierr.Internal("Failed to get credential", err))
+		return nil, false
+	}
+	if len(credentials) == 0 {
+		apierr.Abort(c, apierr.Conflict("No registered passkey can sign for this wallet"))
+		return nil, false
 	}
 
 	// Build UserOperation
-	userOp, err := h.buildTransferUserOpP256(c.Request.Context(), wallet, req.Recipient, amount)
+	var userOp map[string]interface{}
+	if len(req.Transfers) > 0 {
+		userOp, err = h.buildBatchTransferUserOpP256(logging.Context(c), userWallet, calls)
+	} else {
+		userOp, err = h.buildTransferUserOpP256(logging.Context(c), userWallet, req.Recipient, amount, req.Token)
+	}
 	if err != nil {
-		log.Printf("Error building UserOp: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build UserOperation"})
+		apierr.Abort(c, buildUserOpError(err))
+		return nil, false
+	}
+
+	userOpHash, paymaster, ok := h.storePreparedUserOp(c, userID, userWallet, userOp)
+	if !ok {
+		return nil, false
+	}
+	if !h.reserveTransferUsage(c, userID, userWallet, userOp, userOpHash, amounts) {
+		return nil, false
+	}
+
+	logging.FromContext(c).Info().
+		Str("wallet", userWallet.Address).
+		Str("publicKeyX", userWallet.PublicKeyX).
+		Str("publicKeyY", userWallet.PublicKeyY).
+		Bool("deployed", userWallet.IsDeployed).
+		Int("transfers", max(len(calls), 1)).
+		Bool("sponsored", paymaster != "").
+		Msg("UserOp prepared for signing")
+
+	// Convert credential IDs to base64url for frontend
+	allowCredentials := make([]string, len(credentials))
+	for i, credential := range credentials {
+		allowCredentials[i] = base64URLEncodeBytes(credential.CredentialID)
+	}
+
+	return &PrepareTransferResponse{
+		UserOpHash:        userOpHash,
+		CredentialID:      allowCredentials[0],
+		AllowCredentials:  allowCredentials,
+		EntryPointVersion: h.walletManager.EntryPointVersion(),
+		Token:             req.Token,
+		TokenDecimals:     tokenDecimals,
+		TransferCount:     max(len(calls), 1),
+		TotalValue:        totalValue.String(),
+		Sponsored:         paymaster != "",
+		Paymaster:         paymaster,
+	}, true
+}
+
+// SubmitTransferHandler receives the signature and submits the UserOp
+func (h *Handler) SubmitTransferHandler(c *gin.Context) {
+	userIDRaw, exists := c.Get("userID")
+	if !exists {
+		apierr.Abort(c, apierr.Unauthorized("unauthorized"))
 		return
 	}
+	userID := fmt.Sprintf("%v", userIDRaw)
 
-	// Calculate UserOp hash
-	userOpHash, err := h.calculateUserOpHashP256(userOp, wallet.Address)
+	var req SubmitTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, apierr.Validation("Invalid request body"))
+		return
+	}
+
+	h.submitTransfer(c, userID, &req)
+}
+
+// submitTransfer verifies the signature of userID's pending UserOp, submits it and
+// writes the response. It aborts the request on failure.
+func (h *Handler) submitTransfer(c *gin.Context, userID string, req *SubmitTransferRequest) {
+	userOp, credential, ok := h.loadPendingSubmission(c, userID, req)
+	if !ok {
+		return
+	}
+
+	userWallet, err := h.walletManager.GetWalletByUserID(userID)
 	if err != nil {
-		log.Printf("Error calculating hash: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate hash"})
+		apierr.Abort(c, apierr.Internal("Failed to get wallet", err))
 		return
 	}
+	if !credentialSignsForWallet(credential, userWallet) {
+		apierr.Abort(c, apierr.Validation("This passkey is not a signer of the wallet"))
+		return
+	}
+
+	// Check the assertion the same way the contract will, so a bad signature
+	// is rejected here instead of in a reverted transaction
+	signatureBlob := hexToBytes(req.Signature)
+	if err := h.verifyUserOpAssertion(userWallet.PublicKeyX, userWallet.PublicKeyY, signatureBlob); err != nil {
+		logging.FromContext(c).Warn().Err(err).Msg("UserOp signature rejected")
+		apierr.Abort(c, apierr.Validation("Invalid signature").WithDetails(err.Error()))
+		return
+	}
+
+	txHash, ok := h.submitSignedUserOp(c, userID, userWallet, credential, userOp, req.UserOpHash, signatureBlob)
+	if !ok {
+		return
+	}
+
+	logging.FromContext(c).Info().Str("txHash", txHash).Msg("transaction submitted")
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"txHash":      txHash,
+		"explorerUrl": h.explorerTxURL(txHash),
+	})
+}
+
+// explorerTxURL links to txHash on the configured chain's explorer (CHAINS_CONFIG can set
+// one), or is nil when the chain has none rather than pointing at another chain's explorer
+func (h *Handler) explorerTxURL(txHash string) *string {
+	explorerURL := chains.Default.TxURL(int64(h.chainID), txHash)
+	if explorerURL == "" {
+		return nil
+	}
+	return &explorerURL
+}
+
+// storePreparedUserOp fills in the gas limits and sponsorship of a built UserOp, stores
+// it until it is signed and returns the hash the passkey must sign. It aborts the
+// request and returns ok == false on failure.
+func (h *Handler) storePreparedUserOp(c *gin.Context, userID string, userWallet *models.Wallet, userOp map[string]interface{}) (userOpHash, paymaster string, ok bool) {
+	// The on-chain nonce only moves once a UserOp lands: hold one so a transfer prepared
+	// before the previous one lands gets the next nonce instead of the same one
+	nonce, err := h.nonces.Reserve(c.Request.Context(), userWallet.Address, hexToBigIntHelper(userOp["nonce"]), pendingUserOpTTL())
+	if errors.Is(err, wallet.ErrNonceContention) {
+		apierr.Abort(c, apierr.Conflict(err.Error()))
+		return "", "", false
+	}
+	if err != nil {
+		apierr.Abort(c, apierr.Internal("Failed to reserve nonce", err))
+		return "", "", false
+	}
+	userOp["nonce"] = "0x" + nonce.Text(16)
+
+	// Replace the default gas limits with the bundler's estimate when available
+	h.applyUserOpGasEstimate(logging.Context(c), userWallet, userOp)
+
+	// Ask the paymaster to pay for gas; the gas limits must not change after this
+	paymaster = h.applySponsorship(logging.Context(c), userOp)
+
+	// EntryPoint v0.7 expects packed accountGasLimits/gasFees fields
+	if h.walletManager.EntryPointVersion() == wallet.EntryPointV07 {
+		userOp = wallet.BuildUserOpV07(userOp)
+	}
+
+	// Calculate UserOp hash
+	userOpHash, err = h.calculateUserOpHashP256(userOp, userWallet.Address)
+	if err != nil {
+		h.releaseNonce(c, userWallet.Address, nonce)
+		apierr.Abort(c, apierr.Internal("Failed to calculate hash", err))
+		return "", "", false
+	}
+	logging.SetUserOpHash(c, userOpHash)
 
 	// WebAuthn will wrap the challenge in its own structure (clientDataJSON + authenticatorData)
 	// So we pass the raw userOpHash as the challenge
 	// The contract will verify the WebAuthn assertion format
 
-	// Store UserOp temporarily (without signature)
-	pendingUserOps.Lock()
-	pendingUserOps.ops[userOpHash] = userOp
-	pendingUserOps.Unlock()
+	// Store UserOp until it is signed (without signature)
+	if err := h.pendingOps.Save(c.Request.Context(), userID, userOpHash, userOp, pendingUserOpTTL()); err != nil {
+		h.releaseNonce(c, userWallet.Address, nonce)
+		apierr.Abort(c, apierr.Internal("Failed to store UserOperation", err))
+		return "", "", false
+	}
+	return userOpHash, paymaster, true
+}
 
-	log.Printf("✅ UserOp prepared for signing. Hash: %s", userOpHash)
-	log.Printf("🔑 Wallet Public Key stored in database:")
-	log.Printf("   Address: %s", wallet.Address)
-	log.Printf("   X: %s", wallet.PublicKeyX)
-	log.Printf("   Y: %s", wallet.PublicKeyY)
-	log.Printf("   IsDeployed: %v", wallet.IsDeployed)
+// releaseNonce frees a reserved nonce whose UserOp will not reach the chain, so the next
+// prepare reuses it instead of leaving a gap that would stall later UserOps
+func (h *Handler) releaseNonce(c *gin.Context, walletAddress string, nonce *big.Int) {
+	if err := h.nonces.Release(c.Request.Context(), walletAddress, nonce); err != nil {
+		logging.FromContext(c).Warn().Err(err).Str("nonce", nonce.String()).Msg("failed to release nonce reservation")
+	}
+}
 
-	// Convert credential ID to base64url for frontend
-	credentialIDBase64 := base64URLEncodeBytes(credential.CredentialID)
+// loadPendingSubmission loads the caller's pending UserOp and the passkey that signed it.
+// It aborts the request and returns ok == false on failure.
+func (h *Handler) loadPendingSubmission(c *gin.Context, userID string, req *SubmitTransferRequest) (userOp map[string]interface{}, credential *models.PasskeyCredential, ok bool) {
+	logging.SetUserOpHash(c, req.UserOpHash)
+
+	// Retrieve the pending UserOp (scoped to the caller)
+	pending, err := h.pendingOps.Get(c.Request.Context(), req.UserOpHash, userID)
+	switch {
+	case errors.Is(err, wallet.ErrPendingUserOpNotOwner):
+		logging.FromContext(c).Warn().Msg("attempt to submit UserOp owned by another user")
+		apierr.Abort(c, apierr.Forbidden("UserOp does not belong to this user"))
+		return nil, nil, false
+	case errors.Is(err, wallet.ErrPendingUserOpExpired):
+		apierr.Abort(c, apierr.Expired("UserOp expired, please prepare the transfer again"))
+		return nil, nil, false
+	case errors.Is(err, wallet.ErrPendingUserOpNotFound):
+		apierr.Abort(c, apierr.Validation("UserOp not found or expired"))
+		return nil, nil, false
+	case err != nil:
+		apierr.Abort(c, apierr.Internal("Failed to load UserOperation", err))
+		return nil, nil, false
+	}
 
-	c.JSON(http.StatusOK, PrepareTransferResponse{
-		UserOpHash:   userOpHash,
-		CredentialID: credentialIDBase64,
-	})
+	userOp, err = pending.Op()
+	if err != nil {
+		apierr.Abort(c, apierr.Internal("Failed to load UserOperation", err))
+		return nil, nil, false
+	}
+
+	// Look up the passkey that produced the assertion
+	credentialID, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(req.CredentialID, "="))
+	if err != nil {
+		apierr.Abort(c, apierr.Validation("Invalid credential ID"))
+		return nil, nil, false
+	}
+	credential = &models.PasskeyCredential{}
+	if err := h.db.Where("user_id = ? AND credential_id = ?", userID, credentialID).First(credential).Error; err != nil {
+		logging.FromContext(c).Warn().Str("credentialId", req.CredentialID).Msg("UserOp submitted with unknown credential")
+		apierr.Abort(c, apierr.Forbidden("Passkey does not belong to this user"))
+		return nil, nil, false
+	}
+	return userOp, credential, true
 }
 
-// SubmitTransferHandler receives the signature and submits the UserOp
-func (h *Handler) SubmitTransferHandler(c *gin.Context) {
-	_, exists := c.Get("userID")
+// submitSignedUserOp attaches a verified WebAuthn signature to the pending UserOp, submits
+// it and records it in the transaction history. It aborts the request and returns
+// ok == false on failure.
+func (h *Handler) submitSignedUserOp(c *gin.Context, userID string, userWallet *models.Wallet, credential *models.PasskeyCredential, userOp map[string]interface{}, userOpHash string, signatureBlob []byte) (txHash string, ok bool) {
+	// Pack the signature in the layout this wallet's implementation decodes
+	format, err := h.walletManager.SignatureFormat(c.Request.Context(), userWallet)
+	if err != nil {
+		apierr.Abort(c, apierr.Upstream("Failed to check the wallet's signature format", err))
+		return "", false
+	}
+
+	// Normalize the assertion signature (DER -> r||s, high-S -> low-S) and pack it,
+	// behind the format version byte for wallets that read one
+	packedSig, err := webauthnp256.PackSignature(signatureBlob, format)
+	if err != nil {
+		logging.FromContext(c).Warn().Err(err).Msg("invalid UserOp signature")
+		apierr.Abort(c, apierr.Validation("Invalid signature").WithDetails(err.Error()))
+		return "", false
+	}
+
+	// Add signature to UserOp
+	userOp["signature"] = "0x" + hex.EncodeToString(packedSig)
+
+	logging.FromContext(c).Debug().
+		Str("signature", "0x"+hex.EncodeToString(signatureBlob)).
+		Int("signatureBytes", len(signatureBlob)).
+		Msg("signature received")
+
+	// Get bundler private key
+	bundlerPrivateKey := os.Getenv("BUNDLER_PRIVATE_KEY")
+	if bundlerPrivateKey == "" && h.walletManager.SubmitMode() == wallet.SubmitModeDirect {
+		apierr.Abort(c, apierr.Internal("Bundler configuration error", errors.New("BUNDLER_PRIVATE_KEY not set")))
+		return "", false
+	}
+
+	// Claim the pending UserOp: of several concurrent submissions of the same hash only
+	// one gets past this point, and the op is gone whether or not the submission succeeds
+	if _, err := h.pendingOps.Take(c.Request.Context(), userOpHash, userID); err != nil {
+		switch {
+		case errors.Is(err, wallet.ErrPendingUserOpExpired):
+			apierr.Abort(c, apierr.Expired("UserOp expired, please prepare the transfer again"))
+		case errors.Is(err, wallet.ErrPendingUserOpNotFound), errors.Is(err, wallet.ErrPendingUserOpNotOwner):
+			apierr.Abort(c, apierr.Conflict("UserOp has already been submitted"))
+		default:
+			apierr.Abort(c, apierr.Internal("Failed to claim UserOperation", err))
+		}
+		return "", false
+	}
+
+	// Submit to chain
+	txHash, err = h.walletManager.SubmitUserOperation(c.Request.Context(), userOp, bundlerPrivateKey)
+	var (
+		reverted *wallet.UserOpRevertedError
+		pending  *wallet.UserOpPendingError
+	)
+	switch {
+	case errors.As(err, &reverted):
+		// The op was mined: its nonce is used, and it belongs in the history even though it failed
+		h.confirmTransferUsage(c, userOpHash)
+		h.recordSubmittedUserOp(c.Request.Context(), userID, userWallet, userOp, userOpHash, reverted.TxHash, cmp.Or(reverted.Reason, "user operation reverted"))
+	case errors.As(err, &pending):
+		// The bundler accepted the op and it may still land; the status poller resolves it
+		h.confirmTransferUsage(c, userOpHash)
+		h.recordSubmittedUserOp(c.Request.Context(), userID, userWallet, userOp, userOpHash, "", "")
+	case err != nil:
+		// Only a UserOp rejected before broadcast never uses its nonce or moves value; any
+		// other keeps the nonce reservation until the on-chain nonce passes it
+		h.releaseNonce(c, userWallet.Address, hexToBigIntHelper(userOp["nonce"]))
+		h.releaseTransferUsage(c, userOpHash)
+	default:
+		// The transfer counts towards the daily caps even after its pending UserOp is gone
+		h.confirmTransferUsage(c, userOpHash)
+		// Record in transaction history; the status poller resolves it later
+		h.recordSubmittedUserOp(c.Request.Context(), userID, userWallet, userOp, userOpHash, txHash, "")
+	}
+	if err != nil {
+		apierr.Abort(c, submitUserOpError(c, err))
+		return "", false
+	}
+
+	if err := h.db.Model(credential).Update("last_used_at", time.Now()).Error; err != nil {
+		logging.FromContext(c).Warn().Err(err).Msg("failed to update passkey last used time")
+	}
+	return txHash, true
+}
+
+// EstimateGasResponse contains the gas limits that would be used for a transfer
+type EstimateGasResponse struct {
+	CallGasLimit         string `json:"callGasLimit"`
+	VerificationGasLimit string `json:"verificationGasLimit"`
+	PreVerificationGas   string `json:"preVerificationGas"`
+	MaxFeePerGas         string `json:"maxFeePerGas"`
+	Source               string `json:"source"` // "bundler" or "default"
+}
+
+// EstimateGasHandler builds the transfer UserOp and asks the bundler for its gas limits
+func (h *Handler) EstimateGasHandler(c *gin.Context) {
+	userIDRaw, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		apierr.Abort(c, apierr.Unauthorized("unauthorized"))
 		return
 	}
+	userID := fmt.Sprintf("%v", userIDRaw)
 
-	var req SubmitTransferRequest
+	var req PrepareTransferRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		apierr.Abort(c, apierr.Validation("Invalid request body"))
 		return
 	}
 
-	// Retrieve the pending UserOp
-	pendingUserOps.RLock()
-	userOp, exists := pendingUserOps.ops[req.UserOpHash]
-	pendingUserOps.RUnlock()
+	// The chain is fixed by the server; a request (e.g. built from an AI operation card) cannot pick another one
+	if req.ChainID != 0 && req.ChainID != int64(h.chainID) {
+		logging.FromContext(c).Warn().Int64("chainId", req.ChainID).Msg("transfer rejected for foreign chain")
+		apierr.Abort(c, apierr.Validation(fmt.Sprintf("Unsupported chain ID %d: this wallet only supports chain %d", req.ChainID, h.chainID)))
+		return
+	}
 
-	if !exists {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "UserOp not found or expired"})
+	// Balance changes and gas estimates are only modelled for a single execute() transfer
+	if len(req.Transfers) > 0 {
+		apierr.Abort(c, apierr.Validation("Batch transfers can be prepared but not simulated or estimated"))
 		return
 	}
 
-	// Add signature to UserOp
-	userOp["signature"] = req.Signature
+	if !common.IsHexAddress(req.Recipient) {
+		apierr.Abort(c, apierr.Validation("Invalid recipient address"))
+		return
+	}
+	if req.Token != "" && !common.IsHexAddress(req.Token) {
+		apierr.Abort(c, apierr.Validation("Invalid token address"))
+		return
+	}
 
-	log.Printf("📝 Signature received:")
-	log.Printf("   UserOpHash: %s", req.UserOpHash)
-	log.Printf("   Signature (hex): %s", req.Signature)
-	log.Printf("   Signature length: %d bytes", (len(req.Signature)-2)/2) // -2 for "0x", /2 for hex encoding
+	amount, err := parseTransferAmount(req.Amount, nil)
+	if err != nil {
+		apierr.Abort(c, apierr.Validation("Invalid amount").WithDetails(err.Error()))
+		return
+	}
 
-	// Get bundler private key
-	bundlerPrivateKey := os.Getenv("BUNDLER_PRIVATE_KEY")
-	if bundlerPrivateKey == "" {
-		log.Printf("Error: BUNDLER_PRIVATE_KEY not set")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Bundler configuration error"})
+	userWallet, err := h.walletManager.GetWalletByUserID(userID)
+	if err != nil {
+		apierr.Abort(c, apierr.Internal("Failed to get wallet", err))
 		return
 	}
 
-	// Submit to chain
-	txHash, err := h.walletManager.SubmitUserOperation(c.Request.Context(), userOp, bundlerPrivateKey)
+	userOp, err := h.buildTransferUserOpP256(logging.Context(c), userWallet, req.Recipient, amount, req.Token)
 	if err != nil {
-		log.Printf("Error submitting UserOp: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to submit transaction",
-			"details": err.Error(),
-		})
+		apierr.Abort(c, buildUserOpError(err))
 		return
 	}
 
-	// Clean up pending UserOp
-	pendingUserOps.Lock()
-	delete(pendingUserOps.ops, req.UserOpHash)
-	pendingUserOps.Unlock()
+	source, _ := h.applyUserOpGasEstimate(logging.Context(c), userWallet, userOp)
 
-	explorerURL := fmt.Sprintf("https://testnet-explorer.hsk.xyz/tx/%s", txHash)
+	c.JSON(http.StatusOK, EstimateGasResponse{
+		CallGasLimit:         userOp["callGasLimit"].(string),
+		VerificationGasLimit: userOp["verificationGasLimit"].(string),
+		PreVerificationGas:   userOp["preVerificationGas"].(string),
+		MaxFeePerGas:         userOp["maxFeePerGas"].(string),
+		Source:               source,
+	})
+}
 
-	log.Printf("✅ Transaction submitted: %s", txHash)
+// verifyUserOpAssertion checks that a WebAuthn signature blob comes from an allowed
+// origin and verifies against a P-256 public key (hex coordinates, as stored on the wallet)
+func (h *Handler) verifyUserOpAssertion(pubKeyX, pubKeyY string, signatureBlob []byte) error {
+	assertion, err := webauthnp256.ParseAssertion(signatureBlob)
+	if err != nil {
+		return err
+	}
+	if h.webAuthnService != nil {
+		if !slices.Contains(h.webAuthnService.Origins(), assertion.ClientData.Origin) {
+			return fmt.Errorf("%w: %s", auth.ErrOriginNotAllowed, assertion.ClientData.Origin)
+		}
+		if h.webAuthnService.Policy().RequiresUserVerification() && !assertion.UserVerified() {
+			return auth.ErrUserVerificationRequired
+		}
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success":     true,
-		"txHash":      txHash,
-		"explorerUrl": explorerURL,
-	})
+	valid, err := webauthnp256.VerifyAssertion(hexToBytes(pubKeyX), hexToBytes(pubKeyY), signatureBlob)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return errors.New("signature does not match the passkey's public key")
+	}
+	return nil
 }
 
 // calculateUserOpHashP256 computes the EIP-4337 UserOperation hash
-// Following EIP-4337 spec: keccak256(keccak256(userOp) || entryPoint || chainId)
+// Following EIP-4337 spec: keccak256(abi.encode(keccak256(abi.encode(userOp)), entryPoint, chainId))
 func (h *Handler) calculateUserOpHashP256(userOp map[string]interface{}, walletAddr string) (string, error) {
-	// Get chain ID from environment
-	chainIDStr := os.Getenv("CHAIN_ID")
-	if chainIDStr == "" {
-		chainIDStr = "133" // Default to HashKey Chain
+	chainID := big.NewInt(int64(h.chainID))
+
+	// v0.7 PackedUserOperation uses a different hash preimage
+	if wallet.IsPackedUserOp(userOp) {
+		hash, err := wallet.GetUserOpHashV07(userOp, h.walletManager.EntryPointAddress(), chainID)
+		if err != nil {
+			return "", err
+		}
+		return hash.Hex(), nil
 	}
-	chainID := new(big.Int)
-	chainID.SetString(chainIDStr, 10)
 
 	// Get EntryPoint address
-	entryPointAddr := common.HexToAddress(os.Getenv("ENTRY_POINT_ADDRESS"))
-
-	// Hash individual fields according to EIP-4337
-	initCodeHash := crypto.Keccak256(hexToBytes(userOp["initCode"]))
-	callDataHash := crypto.Keccak256(hexToBytes(userOp["callData"]))
-	paymasterHash := crypto.Keccak256(hexToBytes(userOp["paymasterAndData"]))
-
-	// Pack UserOperation fields for hashing (excluding signature)
-	// Format: keccak256(abi.encode(sender, nonce, initCodeHash, callDataHash,
-	//                                callGasLimit, verificationGasLimit, preVerificationGas,
-	//                                maxFeePerGas, maxPriorityFeePerGas, paymasterHash))
-	sender := common.HexToAddress(userOp["sender"].(string))
-	nonce := hexToBigIntHelper(userOp["nonce"])
-	callGasLimit := hexToBigIntHelper(userOp["callGasLimit"])
-	verificationGasLimit := hexToBigIntHelper(userOp["verificationGasLimit"])
-	preVerificationGas := hexToBigIntHelper(userOp["preVerificationGas"])
-	maxFeePerGas := hexToBigIntHelper(userOp["maxFeePerGas"])
-	maxPriorityFeePerGas := hexToBigIntHelper(userOp["maxPriorityFeePerGas"])
-
-	// Concatenate all fields for hashing
-	packedUserOp := append(sender.Bytes(), common.BigToHash(nonce).Bytes()...)
-	packedUserOp = append(packedUserOp, initCodeHash...)
-	packedUserOp = append(packedUserOp, callDataHash...)
-	packedUserOp = append(packedUserOp, common.BigToHash(callGasLimit).Bytes()...)
-	packedUserOp = append(packedUserOp, common.BigToHash(verificationGasLimit).Bytes()...)
-	packedUserOp = append(packedUserOp, common.BigToHash(preVerificationGas).Bytes()...)
-	packedUserOp = append(packedUserOp, common.BigToHash(maxFeePerGas).Bytes()...)
-	packedUserOp = append(packedUserOp, common.BigToHash(maxPriorityFeePerGas).Bytes()...)
-	packedUserOp = append(packedUserOp, paymasterHash...)
-
-	// First hash: keccak256(userOp)
-	userOpHash := crypto.Keccak256(packedUserOp)
-
-	// Second hash: keccak256(userOpHash || entryPoint || chainId)
-	finalData := append(userOpHash, entryPointAddr.Bytes()...)
-	finalData = append(finalData, common.BigToHash(chainID).Bytes()...)
-	finalHash := crypto.Keccak256(finalData)
+	entryPointAddr := h.walletManager.EntryPointAddress()
+
+	// keccak256(abi.encode(keccak256(abi.encode(userOp fields)), entryPoint, chainId))
+	// abi.encode left-pads the sender to 32 bytes, matching EntryPoint.getUserOpHash
+	finalHash, err := wallet.GetUserOpHashV06(userOp, entryPointAddr, chainID)
+	if err != nil {
+		return "", err
+	}
 
 	// NOTE: Do NOT add Ethereum prefix here!
 	// The contract's _validateSignature() will add it via toEthSignedMessageHash()
 	// Frontend should sign this hash, and contract will verify with prefix added
 
-	return "0x" + hex.EncodeToString(finalHash), nil
+	return "0x" + hex.EncodeToString(finalHash.Bytes()), nil
 }
 
 // addEthereumMessagePrefix adds Ethereum Signed Message prefix to a hash
@@ -306,31 +683,56 @@ func hexToBytes(val interface{}) []byte {
 }
 
 // buildTransferUserOpP256 creates a UserOperation for P256 signing
-func (h *Handler) buildTransferUserOpP256(ctx context.Context, wallet *models.Wallet, recipient string, amount *big.Int) (map[string]interface{}, error) {
-	callData, err := encodeExecuteCallP256(recipient, amount)
+// When token is set the UserOp calls token.transfer(recipient, amount) with zero native value
+func (h *Handler) buildTransferUserOpP256(ctx context.Context, wallet *models.Wallet, recipient string, amount *big.Int, token string) (map[string]interface{}, error) {
+	var callData string
+	var err error
+	if token != "" {
+		callData, err = encodeERC20TransferCallP256(token, recipient, amount)
+	} else {
+		callData, err = encodeExecuteCallP256(recipient, amount)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode call data: %w", err)
 	}
+	return h.buildUserOpP256(ctx, wallet, callData)
+}
+
+// buildBatchTransferUserOpP256 creates a UserOperation that performs calls atomically via executeBatch
+func (h *Handler) buildBatchTransferUserOpP256(ctx context.Context, wallet *models.Wallet, calls []Call) (map[string]interface{}, error) {
+	callData, err := encodeExecuteBatchCallP256(calls)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode call data: %w", err)
+	}
+	return h.buildUserOpP256(ctx, wallet, callData)
+}
+
+// buildUserOpP256 creates an unsigned UserOperation from wallet executing callData
+func (h *Handler) buildUserOpP256(ctx context.Context, wallet *models.Wallet, callData string) (map[string]interface{}, error) {
+	if wallet.ChainID != h.chainID {
+		return nil, fmt.Errorf("wallet %s is on chain %d, transfers are only allowed on chain %d", wallet.Address, wallet.ChainID, h.chainID)
+	}
 
-	// Check if wallet is deployed
+	// Check on-chain whether the wallet is deployed (this also corrects a drifted is_deployed flag);
+	// sending initCode to a deployed wallet makes the UserOp revert.
+	// The RPC reads below are retried on transient errors; if they still fail the UserOp
+	// is not built, since a guessed nonce, initCode or gas price makes it fail on-chain.
 	isDeployed, err := h.walletManager.IsWalletDeployed(ctx, wallet.Address)
 	if err != nil {
-		log.Printf("Warning: Failed to check wallet deployment: %v", err)
-		isDeployed = false // Assume not deployed if check fails
+		return nil, err
 	}
 
 	// Get nonce from EntryPoint
 	nonce, err := h.walletManager.GetWalletNonce(ctx, wallet.Address)
 	if err != nil {
-		log.Printf("Warning: Failed to get nonce: %v, using 0", err)
-		nonce = big.NewInt(0)
+		return nil, err
 	}
 	nonceHex := "0x" + nonce.Text(16)
 
 	// Generate initCode if wallet is not deployed
 	initCode := "0x"
 	if !isDeployed {
-		log.Printf("Wallet not deployed, generating initCode for deployment")
+		zerolog.Ctx(ctx).Debug().Msg("wallet not deployed, generating initCode for deployment")
 		initCode, err = h.generateInitCodeP256(wallet)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate initCode: %w", err)
@@ -340,8 +742,7 @@ func (h *Handler) buildTransferUserOpP256(ctx context.Context, wallet *models.Wa
 	// Get dynamic gas price
 	gasPrice, err := h.walletManager.GetGasPrice(ctx)
 	if err != nil {
-		log.Printf("Warning: Failed to get gas price: %v, using default", err)
-		gasPrice = big.NewInt(1000000000) // 1 gwei default
+		return nil, err
 	}
 
 	userOp := map[string]interface{}{
@@ -358,11 +759,106 @@ func (h *Handler) buildTransferUserOpP256(ctx context.Context, wallet *models.Wa
 		"signature":            "0x", // Will be filled by frontend
 	}
 
-	log.Printf("UserOp built: nonce=%s, initCode length=%d, deployed=%v", nonceHex, len(initCode), isDeployed)
+	zerolog.Ctx(ctx).Debug().
+		Str("nonce", nonceHex).
+		Int("initCodeLength", len(initCode)).
+		Bool("deployed", isDeployed).
+		Msg("UserOp built")
 
 	return userOp, nil
 }
 
+// buildUserOpError is the API error for a UserOp that could not be built: a 502 the
+// client may retry when the RPC node is unavailable, a 500 otherwise
+func buildUserOpError(err error) *apierr.Error {
+	if errors.Is(err, wallet.ErrRPCUnavailable) {
+		return apierr.Upstream("Blockchain RPC unavailable, please try again", err)
+	}
+	return apierr.Internal("Failed to build UserOperation", err)
+}
+
+// submitUserOpError is the API error for a UserOp that could not be submitted or reverted.
+// An EntryPoint AA code becomes a 422 whose message explains it and whose details carry the
+// code, a revert a 422 with the bundle transaction; the raw bundler or node error is only logged.
+func submitUserOpError(c *gin.Context, err error) *apierr.Error {
+	var reverted *wallet.UserOpRevertedError
+	if errors.As(err, &reverted) {
+		logging.FromContext(c).Warn().Err(err).Str("txHash", reverted.TxHash).Msg("UserOp reverted on-chain")
+		return apierr.BundlerRevert("Transaction reverted on-chain", err).WithDetails(gin.H{"txHash": reverted.TxHash, "reason": reverted.Reason})
+	}
+	var pending *wallet.UserOpPendingError
+	if errors.As(err, &pending) {
+		logging.FromContext(c).Warn().Err(err).Msg("UserOp accepted but not confirmed")
+		return apierr.Upstream("Transaction was submitted but is not confirmed yet; check the transaction history", err).
+			WithDetails(gin.H{"userOpHash": pending.UserOpHash, "status": models.TxStatusPending})
+	}
+
+	if aaErr, ok := wallet.DecodeAAError(err); ok {
+		logging.FromContext(c).Warn().Err(err).Str("aaCode", aaErr.Code).Msg("UserOp rejected by EntryPoint")
+		return apierr.BundlerRevert(aaErr.Explanation, err).WithDetails(aaErr)
+	}
+
+	var rejected *wallet.BundlerError
+	if errors.As(err, &rejected) {
+		logging.FromContext(c).Warn().Err(err).Msg("bundler rejected UserOp")
+		return apierr.BundlerRevert("Transaction rejected by the bundler", err)
+	}
+	return apierr.Upstream("Failed to submit transaction", err).WithDetails(err.Error())
+}
+
+// applyUserOpGasEstimate replaces the default gas limits with the bundler's estimate
+// On failure the defaults are kept; the returned source is "bundler" or "default".
+// rejected is set when the bundler simulated the UserOp and refused it (e.g. "AA21 didn't pay prefund").
+func (h *Handler) applyUserOpGasEstimate(ctx context.Context, userWallet *models.Wallet, userOp map[string]interface{}) (source string, rejected *wallet.BundlerError) {
+	// The op is not signed yet; estimate with a dummy assertion of the real size and the
+	// wallet's layout so simulated validation gets past signature decoding and
+	// preVerificationGas covers it
+	format, err := h.walletManager.SignatureFormat(ctx, userWallet)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("gas estimation unavailable, using default limits")
+		return "default", nil
+	}
+	dummySig, err := webauthnp256.DummySignature(format)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("gas estimation unavailable, using default limits")
+		return "default", nil
+	}
+	estimateOp := maps.Clone(userOp)
+	estimateOp["signature"] = hexutil.Encode(dummySig)
+
+	estimate, err := h.walletManager.EstimateUserOpGas(ctx, estimateOp)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("gas estimation unavailable, using default limits")
+		errors.As(err, &rejected)
+		return "default", rejected
+	}
+
+	userOp["callGasLimit"] = "0x" + estimate.CallGasLimit.Text(16)
+	userOp["verificationGasLimit"] = "0x" + estimate.VerificationGasLimit.Text(16)
+	userOp["preVerificationGas"] = "0x" + estimate.PreVerificationGas.Text(16)
+
+	zerolog.Ctx(ctx).Debug().
+		Str("callGasLimit", estimate.CallGasLimit.String()).
+		Str("verificationGasLimit", estimate.VerificationGasLimit.String()).
+		Str("preVerificationGas", estimate.PreVerificationGas.String()).
+		Msg("gas limits from bundler")
+	return "bundler", nil
+}
+
+// applySponsorship asks the paymaster (if configured) to pay for the UserOp's gas and
+// returns its address, or "" when the wallet pays: sponsorship disabled, declined or failed
+func (h *Handler) applySponsorship(ctx context.Context, userOp map[string]interface{}) string {
+	if !h.walletManager.SponsorshipEnabled() {
+		return ""
+	}
+	paymaster, err := h.walletManager.SponsorUserOp(ctx, userOp)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("gas sponsorship unavailable, wallet pays gas")
+		return ""
+	}
+	return paymaster.Hex()
+}
+
 // generateInitCodeP256 generates initCode for deploying a P256 wallet
 func (h *Handler) generateInitCodeP256(wallet *models.Wallet) (string, error) {
 	// initCode = factoryAddress + abi.encode(createAccount(publicKeyX, publicKeyY, salt))
@@ -383,8 +879,16 @@ func (h *Handler) generateInitCodeP256(wallet *models.Wallet) (string, error) {
 	publicKeyXPadded := strings.Repeat("0", 64-len(publicKeyX)) + publicKeyX
 	publicKeyYPadded := strings.Repeat("0", 64-len(publicKeyY)) + publicKeyY
 
-	// Salt = 0 (32 bytes)
-	salt := strings.Repeat("0", 64)
+	// Salt the wallet address was computed with (32 bytes); pre-salt rows used 0
+	saltValue := big.NewInt(0)
+	if wallet.Salt != "" {
+		parsed, ok := new(big.Int).SetString(wallet.Salt, 10)
+		if !ok {
+			return "", fmt.Errorf("invalid wallet salt %q", wallet.Salt)
+		}
+		saltValue = parsed
+	}
+	salt := fmt.Sprintf("%064x", saltValue)
 
 	// Construct initCode
 	initCode := "0x" + factoryAddr + selector + publicKeyXPadded + publicKeyYPadded + salt
@@ -410,6 +914,36 @@ func encodeExecuteCallP256(recipient string, amount *big.Int) (string, error) {
 	return callData, nil
 }
 
+// executeSelector is P256Account.execute(address,uint256,bytes)
+var executeSelector = crypto.Keccak256([]byte("execute(address,uint256,bytes)"))[:4]
+
+var executeArguments = func() abi.Arguments {
+	addressType, _ := abi.NewType("address", "", nil)
+	uint256Type, _ := abi.NewType("uint256", "", nil)
+	bytesType, _ := abi.NewType("bytes", "", nil)
+	return abi.Arguments{{Type: addressType}, {Type: uint256Type}, {Type: bytesType}}
+}()
+
+// encodeERC20TransferCallP256 encodes wallet.execute(token, 0, transfer(recipient, amount))
+// amount is in the token's base units (already scaled by its decimals)
+func encodeERC20TransferCallP256(tokenAddr, recipient string, amount *big.Int) (string, error) {
+	if amount.Sign() < 0 || amount.Cmp(maxUint256) > 0 {
+		return "", fmt.Errorf("amount out of uint256 range")
+	}
+
+	transferArgs, err := erc20TransferArguments.Pack(common.HexToAddress(recipient), amount)
+	if err != nil {
+		return "", fmt.Errorf("failed to pack transfer: %w", err)
+	}
+	transferData := append(common.FromHex("0xa9059cbb"), transferArgs...) // transfer(address,uint256)
+
+	args, err := executeArguments.Pack(common.HexToAddress(tokenAddr), big.NewInt(0), transferData)
+	if err != nil {
+		return "", fmt.Errorf("failed to pack execute: %w", err)
+	}
+	return hexutil.Encode(append(append([]byte{}, executeSelector...), args...)), nil
+}
+
 // base64URLEncodeBytes encodes bytes to base64url
 func base64URLEncodeBytes(data []byte) string {
 	return base64.RawURLEncoding.EncodeToString(data)
@@ -0,0 +1,718 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/apierr"
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"ai-wallet-backend/internal/wallet"
+	"ai-wallet-backend/internal/webauthnp256"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestPrepareTransferRejectsForeignChain(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// No wallet manager: reaching wallet lookup or UserOp building would panic
+	h := &Handler{chainID: 133}
+
+	router := gin.New()
+	router.Use(apierr.Middleware(), func(c *gin.Context) { c.Set("userID", "user-1") })
+	router.POST("/transfer/prepare", h.PrepareTransferHandler)
+	router.POST("/transfer/simulate", h.SimulateTransferHandler)
+	router.POST("/estimate-gas", h.EstimateGasHandler)
+
+	// An operation card produced by the AI for Ethereum mainnet
+	body := `{"recipient":"0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1","amount":"1000000000000000000","chainId":1}`
+	for _, path := range []string{"/transfer/prepare", "/transfer/simulate", "/estimate-gas"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "Unsupported chain ID 1") {
+			t.Errorf("%s: got %d %s, want 400 for foreign chain", path, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestBuildTransferUserOpRejectsForeignChainWallet(t *testing.T) {
+	h := &Handler{chainID: 133}
+	wallet := &models.Wallet{Address: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", ChainID: 11155111}
+
+	userOp, err := h.buildTransferUserOpP256(context.Background(), wallet, "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", big.NewInt(1), "")
+	if err == nil || userOp != nil {
+		t.Fatalf("expected foreign-chain wallet to be rejected, got %v, %v", userOp, err)
+	}
+}
+
+// chainWithCode is a JSON-RPC node on which every address has contract code
+func chainWithCode(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	result := map[string]string{
+		"eth_chainId":  "0x85",
+		"eth_getCode":  "0x6080",
+		"eth_gasPrice": "0x3b9aca00",
+		"eth_call":     "0x0000000000000000000000000000000000000000000000000000000000000003",
+	}[req.Method]
+	json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+}
+
+func TestBuildTransferUserOpTrustsChainOverDeployedFlag(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(chainWithCode))
+	t.Cleanup(server.Close)
+	manager, err := wallet.NewManager(dbtest.DryRun(t), server.URL, 133, "0x5555555555555555555555555555555555555555", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(manager.Close)
+	h := &Handler{chainID: 133, walletManager: manager}
+
+	// The row says undeployed (e.g. after a DB wipe) but the contract exists on-chain:
+	// sending initCode again would revert the UserOp
+	drifted := &models.Wallet{
+		Address:        "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1",
+		ChainID:        133,
+		FactoryAddress: "0x5555555555555555555555555555555555555555",
+		PublicKeyX:     "0x01",
+		PublicKeyY:     "0x02",
+		IsDeployed:     false,
+	}
+	userOp, err := h.buildTransferUserOpP256(context.Background(), drifted, "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", big.NewInt(1), "")
+	if err != nil {
+		t.Fatalf("buildTransferUserOpP256: %v", err)
+	}
+	if userOp["initCode"] != "0x" {
+		t.Fatalf("initCode = %v, want none for a deployed wallet", userOp["initCode"])
+	}
+	if userOp["nonce"] != "0x3" {
+		t.Fatalf("nonce = %v", userOp["nonce"])
+	}
+}
+
+func TestBuildTransferUserOpFailsWhenRPCUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream down", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+	manager, err := wallet.NewManager(dbtest.DryRun(t), server.URL, 133, "0x5555555555555555555555555555555555555555", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(manager.Close)
+	h := &Handler{chainID: 133, walletManager: manager}
+
+	// No nonce 0 or 1 gwei guesses: the UserOp is not built and the client gets a retryable 502
+	w := &models.Wallet{Address: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", ChainID: 133}
+	userOp, err := h.buildTransferUserOpP256(context.Background(), w, "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", big.NewInt(1), "")
+	if !errors.Is(err, wallet.ErrRPCUnavailable) || userOp != nil {
+		t.Fatalf("buildTransferUserOpP256 = %v, %v", userOp, err)
+	}
+	if apiErr := buildUserOpError(err); apiErr.Status != http.StatusBadGateway || apiErr.Code != apierr.CodeUpstream {
+		t.Fatalf("API error = %d %s", apiErr.Status, apiErr.Code)
+	}
+}
+
+func TestBuildTransferUserOpRetriesFlakyNonceFetch(t *testing.T) {
+	// The EntryPoint getNonce call fails once, then the node recovers
+	var mu sync.Mutex
+	nonceCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if bytes.Contains(body, []byte("35567e1a")) {
+			mu.Lock()
+			nonceCalls++
+			first := nonceCalls == 1
+			mu.Unlock()
+			if first {
+				http.Error(w, "upstream down", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		chainWithCode(w, r)
+	}))
+	t.Cleanup(server.Close)
+	manager, err := wallet.NewManager(dbtest.DryRun(t), server.URL, 133, "0x5555555555555555555555555555555555555555", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(manager.Close)
+	h := &Handler{chainID: 133, walletManager: manager}
+
+	w := &models.Wallet{Address: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", ChainID: 133}
+	userOp, err := h.buildTransferUserOpP256(context.Background(), w, "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", big.NewInt(1), "")
+	if err != nil {
+		t.Fatalf("buildTransferUserOpP256: %v", err)
+	}
+	if userOp["nonce"] != "0x3" {
+		t.Fatalf("nonce = %v, want the EntryPoint's 0x3", userOp["nonce"])
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if nonceCalls != 2 {
+		t.Fatalf("%d getNonce calls, want 2", nonceCalls)
+	}
+}
+
+func TestSubmitUserOpErrorDecodesAACode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(apierr.Middleware())
+	router.POST("/submit", func(c *gin.Context) {
+		apierr.Abort(c, submitUserOpError(c, &wallet.BundlerError{Code: -32500, Message: "AA23 reverted (or OOG)"}))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/submit", nil))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", w.Code)
+	}
+
+	var body struct {
+		Code    apierr.Code    `json:"code"`
+		Message string         `json:"message"`
+		Details wallet.AAError `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != apierr.CodeBundlerRevert || body.Details.Code != "AA23" || body.Message != body.Details.Explanation {
+		t.Fatalf("body = %s", w.Body.String())
+	}
+	if strings.Contains(body.Message, "OOG") {
+		t.Fatalf("raw bundler error leaked: %s", w.Body.String())
+	}
+}
+
+func TestSubmitUserOpErrorWithoutAACode(t *testing.T) {
+	if apiErr := submitUserOpError(&gin.Context{}, errors.New("connection refused")); apiErr.Status != http.StatusBadGateway {
+		t.Fatalf("status = %d, want 502", apiErr.Status)
+	}
+}
+
+func TestPrepareTwoTransfersBackToBackGetDistinctNonces(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := httptest.NewServer(http.HandlerFunc(chainWithCode))
+	t.Cleanup(server.Close)
+	manager, err := wallet.NewManager(dbtest.DryRun(t), server.URL, 133, "0x5555555555555555555555555555555555555555", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(manager.Close)
+	pendingOps := wallet.NewMemoryPendingUserOpStore()
+	h := &Handler{chainID: 133, walletManager: manager, pendingOps: pendingOps, nonces: wallet.NewMemoryNonceReservationStore()}
+
+	// The chain reports nonce 3 for both prepares: nothing has landed in between
+	w := &models.Wallet{Address: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", ChainID: 133}
+	prepare := func() string {
+		t.Helper()
+		userOp, err := h.buildTransferUserOpP256(context.Background(), w, "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", big.NewInt(1), "")
+		if err != nil {
+			t.Fatalf("buildTransferUserOpP256: %v", err)
+		}
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/transfer/prepare", nil)
+		userOpHash, _, ok := h.storePreparedUserOp(c, "user-1", w, userOp)
+		if !ok {
+			t.Fatalf("storePreparedUserOp failed: %v", c.Errors)
+		}
+
+		pending, err := pendingOps.Get(context.Background(), userOpHash, "user-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		op, err := pending.Op()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return op["nonce"].(string)
+	}
+
+	first, second := prepare(), prepare()
+	if first != "0x3" || second != "0x4" {
+		t.Fatalf("nonces = %s, %s; want 0x3, 0x4", first, second)
+	}
+}
+
+func TestExplorerTxURLFollowsConfiguredChain(t *testing.T) {
+	const txHash = "0xabc"
+	for chainID, want := range map[int]string{
+		133:      "https://testnet-explorer.hsk.xyz/tx/0xabc",
+		11155111: "https://sepolia.etherscan.io/tx/0xabc",
+		1:        "https://etherscan.io/tx/0xabc",
+		8453:     "https://basescan.org/tx/0xabc",
+	} {
+		h := &Handler{chainID: chainID}
+		if got := h.explorerTxURL(txHash); got == nil || *got != want {
+			t.Errorf("chain %d: explorerTxURL = %v, want %s", chainID, got, want)
+		}
+	}
+
+	// No explorer: null rather than a HashKey link for a transaction on another chain
+	if got := (&Handler{chainID: 999}).explorerTxURL(txHash); got != nil {
+		t.Errorf("unknown chain: explorerTxURL = %s, want nil", *got)
+	}
+}
+
+// bundlerStub answers the ERC-4337 methods a bundler-mode submission uses, replying to every
+// receipt poll with receipt and to gas estimates with estimate, and serves the chain
+// methods from chainWithCode
+type bundlerStub struct {
+	mu         sync.Mutex
+	receipt    map[string]interface{}
+	sendErr    map[string]interface{} // JSON-RPC error for eth_sendUserOperation
+	receiptErr map[string]interface{} // JSON-RPC error for eth_getUserOperationReceipt
+	estimate   func(op map[string]interface{}) (result interface{}, rpcErr map[string]interface{})
+	sent       int
+}
+
+func (b *bundlerStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	json.Unmarshal(body, &req)
+	reply := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+
+	b.mu.Lock()
+	switch req.Method {
+	case "eth_sendUserOperation":
+		if b.sendErr != nil {
+			reply["error"] = b.sendErr
+			break
+		}
+		b.sent++
+		reply["result"] = "0x1d5e1a7fae9f1a02e1d5c1b0c2d4a7cf9b1a3f0e6a5c7d9e2b4f6a8c0e1d3f5a"
+	case "eth_getUserOperationReceipt":
+		if b.receiptErr != nil {
+			reply["error"] = b.receiptErr
+			break
+		}
+		reply["result"] = b.receipt
+	case "eth_estimateUserOperationGas":
+		var op map[string]interface{}
+		json.Unmarshal(req.Params[0], &op)
+		if b.estimate == nil {
+			reply["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+		} else if result, rpcErr := b.estimate(op); rpcErr != nil {
+			reply["error"] = rpcErr
+		} else {
+			reply["result"] = result
+		}
+	default:
+		b.mu.Unlock()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		chainWithCode(w, r)
+		return
+	}
+	b.mu.Unlock()
+	json.NewEncoder(w).Encode(reply)
+}
+
+func (b *bundlerStub) setReceipt(receipt map[string]interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.receipt = receipt
+}
+
+func (b *bundlerStub) sentCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sent
+}
+
+// includedReceipt is a receipt for a UserOp that executed successfully
+var includedReceipt = map[string]interface{}{
+	"success": true,
+	"receipt": map[string]interface{}{"transactionHash": "0xabc123", "status": "0x1"},
+}
+
+// newBundlerHandler returns a Handler whose wallet manager submits through stub
+func newBundlerHandler(t *testing.T, stub *bundlerStub) *Handler {
+	t.Helper()
+	return newBundlerHandlerWithDB(t, stub, dbtest.DryRun(t))
+}
+
+// newBundlerHandlerWithDB is newBundlerHandler backed by db
+func newBundlerHandlerWithDB(t *testing.T, stub *bundlerStub, db *gorm.DB) *Handler {
+	t.Helper()
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	manager, err := wallet.NewManager(db, server.URL, 133, "0x5555555555555555555555555555555555555555", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(manager.Close)
+	if err := manager.SetBundler(wallet.SubmitModeBundler, server.URL); err != nil {
+		t.Fatal(err)
+	}
+	return &Handler{
+		db:            db,
+		chainID:       133,
+		walletManager: manager,
+		pendingOps:    wallet.NewMemoryPendingUserOpStore(),
+		nonces:        wallet.NewMemoryNonceReservationStore(),
+	}
+}
+
+// submitPrepared runs submitSignedUserOp for user-1's pending op userOpHash and returns
+// the API error it aborted with, if any
+func submitPrepared(t *testing.T, h *Handler, userOpHash string) (string, *apierr.Error) {
+	t.Helper()
+	pending := map[string]interface{}{"sender": "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", "nonce": "0x3", "callData": "0x"}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/transfer/submit", nil)
+	w := &models.Wallet{Address: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", ChainID: 133}
+	txHash, ok := h.submitSignedUserOp(c, "user-1", w, &models.PasskeyCredential{ID: "cred-1"}, pending, userOpHash, mustDecodeHex(t, recoveryBlob))
+	if ok {
+		return txHash, nil
+	}
+	var apiErr *apierr.Error
+	if !errors.As(c.Errors.Last(), &apiErr) {
+		t.Fatalf("submit aborted without an API error: %v", c.Errors)
+	}
+	return "", apiErr
+}
+
+func TestSubmitPendingUserOpOnlyOnce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	stub := &bundlerStub{receipt: includedReceipt}
+	h := newBundlerHandler(t, stub)
+	if err := h.pendingOps.Save(context.Background(), "user-1", "0xprepared", map[string]interface{}{}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	// Racing submissions of the same signed op: exactly one reaches the bundler
+	const attempts = 8
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		submitted int
+		conflicts int
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			txHash, apiErr := submitPrepared(t, h, "0xprepared")
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case apiErr == nil && txHash == "0xabc123":
+				submitted++
+			case apiErr != nil && apiErr.Status == http.StatusConflict:
+				conflicts++
+			default:
+				t.Errorf("submit = %q, %v", txHash, apiErr)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if submitted != 1 || conflicts != attempts-1 {
+		t.Fatalf("submitted %d, conflicts %d; want 1 and %d", submitted, conflicts, attempts-1)
+	}
+	if n := stub.sentCount(); n != 1 {
+		t.Fatalf("bundler received %d UserOps, want 1", n)
+	}
+}
+
+func TestSubmitExpiredPendingUserOp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	stub := &bundlerStub{receipt: includedReceipt}
+	h := newBundlerHandler(t, stub)
+	if err := h.pendingOps.Save(context.Background(), "user-1", "0xstale", map[string]interface{}{}, -time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, apiErr := submitPrepared(t, h, "0xstale"); apiErr == nil || apiErr.Status != http.StatusGone {
+		t.Fatalf("expired submit = %v, want 410", apiErr)
+	}
+	// The expired op is gone: a retry is a conflict, not another 410
+	if _, apiErr := submitPrepared(t, h, "0xstale"); apiErr == nil || apiErr.Status != http.StatusConflict {
+		t.Fatalf("second submit = %v, want 409", apiErr)
+	}
+	if n := stub.sentCount(); n != 0 {
+		t.Fatalf("bundler received %d UserOps, want 0", n)
+	}
+}
+
+// revertedReceipt is a receipt for a UserOp that was mined but whose execution reverted
+var revertedReceipt = map[string]interface{}{
+	"success": false,
+	"reason":  "0x08c379a0",
+	"receipt": map[string]interface{}{"transactionHash": "0xdef456", "status": "0x1"},
+}
+
+// capturedTransactions returns the transaction history rows written to the dry-run db
+func capturedTransactions(t *testing.T, db *gorm.DB) func() []models.Transaction {
+	t.Helper()
+	var (
+		mu  sync.Mutex
+		txs []models.Transaction
+	)
+	err := db.Callback().Create().After("gorm:create").Register("test:capture_transactions", func(tx *gorm.DB) {
+		if recorded, ok := tx.Statement.Dest.(*models.Transaction); ok {
+			mu.Lock()
+			txs = append(txs, *recorded)
+			mu.Unlock()
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func() []models.Transaction {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(txs)
+	}
+}
+
+func TestSubmitRevertedUserOpIsRecordedAsFailed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	stub := &bundlerStub{receipt: revertedReceipt}
+	h := newBundlerHandler(t, stub)
+	recorded := capturedTransactions(t, h.db)
+	if err := h.pendingOps.Save(context.Background(), "user-1", "0xreverts", map[string]interface{}{}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	_, apiErr := submitPrepared(t, h, "0xreverts")
+	if apiErr == nil || apiErr.Code != apierr.CodeBundlerRevert {
+		t.Fatalf("reverted submit = %v, want a bundler_revert error", apiErr)
+	}
+	txs := recorded()
+	if len(txs) != 1 {
+		t.Fatalf("recorded %d transactions, want 1", len(txs))
+	}
+	if tx := txs[0]; tx.TxHash != "0xdef456" || tx.UserOpHash != "0xreverts" || tx.Status != models.TxStatusFailed || tx.ErrorMessage != "0x08c379a0" {
+		t.Fatalf("recorded %+v, want a failed transaction with the bundle hash", tx)
+	}
+}
+
+func TestSubmitReleasesReservationsOnlyWhenRejected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const sender = "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1"
+
+	for _, tc := range []struct {
+		name     string
+		stub     *bundlerStub
+		code     apierr.Code
+		released bool
+		recorded string // status of the recorded transaction, "" for none
+	}{
+		{
+			name:     "rejected before broadcast",
+			stub:     &bundlerStub{sendErr: map[string]interface{}{"code": -32500, "message": "AA25 invalid account nonce"}},
+			code:     apierr.CodeBundlerRevert,
+			released: true,
+		},
+		{
+			name:     "mined but reverted",
+			stub:     &bundlerStub{receipt: revertedReceipt},
+			code:     apierr.CodeBundlerRevert,
+			recorded: models.TxStatusFailed,
+		},
+		{
+			name:     "accepted but unconfirmed",
+			stub:     &bundlerStub{receiptErr: map[string]interface{}{"code": -32000, "message": "receipt lookup failed"}},
+			code:     apierr.CodeUpstream,
+			recorded: models.TxStatusPending,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newBundlerHandler(t, tc.stub)
+			recorded := capturedTransactions(t, h.db)
+			ctx := context.Background()
+			if _, err := h.nonces.Reserve(ctx, sender, big.NewInt(3), time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			if err := h.pendingOps.Save(ctx, "user-1", "0xprepared", map[string]interface{}{}, time.Minute); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, apiErr := submitPrepared(t, h, "0xprepared"); apiErr == nil || apiErr.Code != tc.code {
+				t.Fatalf("submit = %v, want %s", apiErr, tc.code)
+			}
+
+			// A released nonce goes to the next prepare; a held one must not
+			next, err := h.nonces.Reserve(ctx, sender, big.NewInt(3), time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if released := next.Int64() == 3; released != tc.released {
+				t.Fatalf("next prepare got nonce %s, released = %v, want %v", next, released, tc.released)
+			}
+
+			txs := recorded()
+			switch {
+			case tc.recorded == "" && len(txs) != 0:
+				t.Fatalf("recorded %+v for a rejected UserOp", txs)
+			case tc.recorded != "" && (len(txs) != 1 || txs[0].Status != tc.recorded):
+				t.Fatalf("recorded %+v, want one %s transaction", txs, tc.recorded)
+			}
+		})
+	}
+}
+
+func TestSubmitTransferRejectsOtherUsersUserOp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	stub := &bundlerStub{receipt: includedReceipt}
+	h := newBundlerHandler(t, stub)
+
+	// User A prepares a transfer
+	w := &models.Wallet{Address: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", ChainID: 133}
+	userOp, err := h.buildTransferUserOpP256(context.Background(), w, "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", big.NewInt(1), "")
+	if err != nil {
+		t.Fatalf("buildTransferUserOpP256: %v", err)
+	}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/transfer/prepare", nil)
+	userOpHash, _, ok := h.storePreparedUserOp(c, "user-a", w, userOp)
+	if !ok {
+		t.Fatalf("storePreparedUserOp failed: %v", c.Errors)
+	}
+
+	// User B submits it with their own session
+	router := gin.New()
+	router.Use(apierr.Middleware())
+	router.POST("/transfer/submit", func(c *gin.Context) {
+		c.Set("userID", "user-b")
+		h.SubmitTransferHandler(c)
+	})
+	body := `{"userOpHash":"` + userOpHash + `","signature":"0x` + recoveryBlob + `","credentialId":"AQID"}`
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/transfer/submit", strings.NewReader(body)))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("submit as user B: got %d %s, want 403", rec.Code, rec.Body.String())
+	}
+
+	// Nothing was sent and user A can still submit their op
+	if n := stub.sentCount(); n != 0 {
+		t.Fatalf("bundler received %d UserOps, want 0", n)
+	}
+	if _, err := h.pendingOps.Get(context.Background(), userOpHash, "user-a"); err != nil {
+		t.Fatalf("user A's pending UserOp: %v", err)
+	}
+}
+
+func TestEncodeERC20TransferCallRoundTrip(t *testing.T) {
+	amount := new(big.Int).Set(maxUint256)
+	callData, err := encodeERC20TransferCallP256(batchToken, batchRecipientA, amount)
+	if err != nil {
+		t.Fatalf("encodeERC20TransferCallP256: %v", err)
+	}
+
+	raw := hexutil.MustDecode(callData)
+	if !bytes.Equal(raw[:4], executeSelector) {
+		t.Fatalf("selector = %x, want execute", raw[:4])
+	}
+	outer, err := executeArguments.Unpack(raw[4:])
+	if err != nil {
+		t.Fatalf("unpack execute: %v", err)
+	}
+	if to := outer[0].(common.Address); to != common.HexToAddress(batchToken) {
+		t.Fatalf("execute target = %s, want the token", to.Hex())
+	}
+	if value := outer[1].(*big.Int); value.Sign() != 0 {
+		t.Fatalf("execute value = %s, want 0", value)
+	}
+
+	inner := outer[2].([]byte)
+	if !bytes.Equal(inner[:4], common.FromHex("0xa9059cbb")) {
+		t.Fatalf("inner selector = %x, want transfer", inner[:4])
+	}
+	args, err := erc20TransferArguments.Unpack(inner[4:])
+	if err != nil {
+		t.Fatalf("unpack transfer: %v", err)
+	}
+	if to := args[0].(common.Address); to != common.HexToAddress(batchRecipientA) {
+		t.Fatalf("transfer recipient = %s", to.Hex())
+	}
+	if got := args[1].(*big.Int); got.Cmp(amount) != 0 {
+		t.Fatalf("transfer amount = %s, want %s", got, amount)
+	}
+
+	if _, err := encodeERC20TransferCallP256(batchToken, batchRecipientA, new(big.Int).Add(maxUint256, big.NewInt(1))); err == nil {
+		t.Fatal("expected an error for an amount above uint256")
+	}
+}
+
+func TestApplyUserOpGasEstimateSignsWithDummyAssertion(t *testing.T) {
+	var estimatedSig string
+	stub := &bundlerStub{estimate: func(op map[string]interface{}) (interface{}, map[string]interface{}) {
+		// Like a real bundler, an empty signature makes simulated validation revert
+		estimatedSig, _ = op["signature"].(string)
+		if len(hexutil.MustDecode(estimatedSig)) < 64 {
+			return nil, map[string]interface{}{"code": -32500, "message": "AA23 reverted: invalid signature length"}
+		}
+		return map[string]interface{}{"preVerificationGas": "0xc350", "verificationGasLimit": "0x30d40", "callGasLimit": "0x9c40"}, nil
+	}}
+	h := newBundlerHandler(t, stub)
+	userOp := map[string]interface{}{
+		"sender":               "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1",
+		"nonce":                "0x0",
+		"callData":             "0x",
+		"callGasLimit":         "0x1",
+		"verificationGasLimit": "0x1",
+		"preVerificationGas":   "0x1",
+		"signature":            "0x",
+	}
+
+	format := webauthnp256.SignatureFormatV1
+	userWallet := &models.Wallet{Address: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", SignatureFormat: &format}
+
+	source, rejected := h.applyUserOpGasEstimate(context.Background(), userWallet, userOp)
+	if source != "bundler" || rejected != nil {
+		t.Fatalf("source = %s, rejected = %v; want the bundler estimate", source, rejected)
+	}
+	if userOp["preVerificationGas"] != "0xc350" || userOp["verificationGasLimit"] != "0x30d40" || userOp["callGasLimit"] != "0x9c40" {
+		t.Fatalf("gas limits = %v", userOp)
+	}
+	dummy, _ := webauthnp256.DummySignature(format)
+	if estimatedSig != hexutil.Encode(dummy) {
+		t.Fatalf("estimated with signature %s, want the dummy assertion", estimatedSig)
+	}
+	// The dummy never reaches the op the user signs
+	if userOp["signature"] != "0x" {
+		t.Fatalf("signature = %v, want it left unsigned", userOp["signature"])
+	}
+}
+
+func TestApplyUserOpGasEstimateFallsBackOnBundlerError(t *testing.T) {
+	stub := &bundlerStub{estimate: func(op map[string]interface{}) (interface{}, map[string]interface{}) {
+		return nil, map[string]interface{}{"code": -32500, "message": "AA21 didn't pay prefund"}
+	}}
+	h := newBundlerHandler(t, stub)
+	userOp := map[string]interface{}{"sender": "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", "callGasLimit": "0x1", "verificationGasLimit": "0x2", "preVerificationGas": "0x3", "signature": "0x"}
+
+	format := webauthnp256.SignatureFormatLegacy
+	userWallet := &models.Wallet{Address: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", SignatureFormat: &format}
+
+	source, rejected := h.applyUserOpGasEstimate(context.Background(), userWallet, userOp)
+	if source != "default" || rejected == nil {
+		t.Fatalf("source = %s, rejected = %v; want the defaults and the bundler's rejection", source, rejected)
+	}
+	if userOp["callGasLimit"] != "0x1" || userOp["verificationGasLimit"] != "0x2" || userOp["preVerificationGas"] != "0x3" {
+		t.Fatalf("default gas limits were overwritten: %v", userOp)
+	}
+}
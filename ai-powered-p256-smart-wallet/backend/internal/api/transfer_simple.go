@@ -1,6 +1,7 @@
 package api
 
 import (
+	"ai-wallet-backend/internal/wallet"
 	"encoding/hex"
 	"fmt"
 	"log"
@@ -80,6 +81,9 @@ func (h *Handler) SimpleTransferHandler(c *gin.Context) {
 	txHash, err := h.walletManager.SubmitUserOperation(c.Request.Context(), userOp, bundlerPrivateKey)
 	if err != nil {
 		log.Printf("Error submitting UserOp: %v", err)
+		if respondAARejection(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to submit transaction",
 			"details": err.Error(),
@@ -87,12 +91,10 @@ func (h *Handler) SimpleTransferHandler(c *gin.Context) {
 		return
 	}
 
-	explorerURL := fmt.Sprintf("https://testnet-explorer.hsk.xyz/tx/%s", txHash)
-
 	c.JSON(http.StatusOK, gin.H{
 		"success":     true,
 		"txHash":      txHash,
-		"explorerUrl": explorerURL,
+		"explorerUrl": h.explorerTxURL(txHash),
 	})
 }
 
@@ -183,3 +185,17 @@ func hexStringToBytes(hexStr string) ([]byte, error) {
 	hexStr = strings.TrimPrefix(hexStr, "0x")
 	return hex.DecodeString(hexStr)
 }
+
+// respondAARejection answers 422 with the explanation and code when err carries an
+// EntryPoint AA code, and reports whether it did
+func respondAARejection(c *gin.Context, err error) bool {
+	aaErr, ok := wallet.DecodeAAError(err)
+	if !ok {
+		return false
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":   aaErr.Explanation,
+		"details": aaErr,
+	})
+	return true
+}
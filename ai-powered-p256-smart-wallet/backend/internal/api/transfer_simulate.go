@@ -0,0 +1,153 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/apierr"
+	"ai-wallet-backend/internal/blockchain"
+	"ai-wallet-backend/internal/logging"
+	"ai-wallet-backend/internal/wallet"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// SimulateTransferResponse is the expected effect of a transfer, computed without submitting it
+type SimulateTransferResponse struct {
+	Success          bool                   `json:"success"`                // false if the transfer would revert
+	RevertReason     string                 `json:"revertReason,omitempty"` // from eth_call or the bundler's simulation
+	GasLimit         string                 `json:"gasLimit"`               // total of the UserOp's gas limits
+	MaxFeePerGas     string                 `json:"maxFeePerGas"`           // wei
+	EstimatedGasCost string                 `json:"estimatedGasCost"`       // upper bound in wei
+	EstimatedGasFee  string                 `json:"estimatedGasFee"`        // e.g. "0.00042 HSK"
+	GasSource        string                 `json:"gasSource"`              // "bundler" or "default"
+	Sponsored        bool                   `json:"sponsored"`              // gas paid by the paymaster; not in balanceChanges
+	BalanceChanges   []wallet.BalanceChange `json:"balanceChanges"`
+}
+
+// InsufficientBalanceDetails are the details of the insufficient_balance error (422)
+// returned when the wallet cannot cover the transfer and its gas
+type InsufficientBalanceDetails struct {
+	Token     string `json:"token"`
+	Symbol    string `json:"symbol"`
+	Decimals  uint8  `json:"decimals"`
+	Required  string `json:"required"`  // base units
+	Available string `json:"available"` // base units
+	Shortfall string `json:"shortfall"` // formatted, e.g. "0.25"
+}
+
+// SimulateTransferHandler builds the same UserOp as /transfer/prepare and dry-runs it:
+// expected balance changes, gas cost in the native token and any revert reason.
+// Nothing is stored or submitted.
+func (h *Handler) SimulateTransferHandler(c *gin.Context) {
+	userIDRaw, exists := c.Get("userID")
+	if !exists {
+		apierr.Abort(c, apierr.Unauthorized("unauthorized"))
+		return
+	}
+	userID := fmt.Sprintf("%v", userIDRaw)
+
+	var req PrepareTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, apierr.Validation("Invalid request body"))
+		return
+	}
+
+	// The chain is fixed by the server; a request (e.g. built from an AI operation card) cannot pick another one
+	if req.ChainID != 0 && req.ChainID != int64(h.chainID) {
+		apierr.Abort(c, apierr.Validation(fmt.Sprintf("Unsupported chain ID %d: this wallet only supports chain %d", req.ChainID, h.chainID)))
+		return
+	}
+
+	// Balance changes and gas estimates are only modelled for a single execute() transfer
+	if len(req.Transfers) > 0 {
+		apierr.Abort(c, apierr.Validation("Batch transfers can be prepared but not simulated or estimated"))
+		return
+	}
+
+	if !common.IsHexAddress(req.Recipient) {
+		apierr.Abort(c, apierr.Validation("Invalid recipient address"))
+		return
+	}
+	if req.Token != "" && !common.IsHexAddress(req.Token) {
+		apierr.Abort(c, apierr.Validation("Invalid token address"))
+		return
+	}
+
+	amount, err := parseTransferAmount(req.Amount, nil)
+	if err != nil {
+		apierr.Abort(c, apierr.Validation("Invalid amount").WithDetails(err.Error()))
+		return
+	}
+
+	userWallet, err := h.walletManager.GetWalletByUserID(userID)
+	if err != nil {
+		apierr.Abort(c, apierr.Internal("Failed to get wallet", err))
+		return
+	}
+
+	userOp, err := h.buildTransferUserOpP256(logging.Context(c), userWallet, req.Recipient, amount, req.Token)
+	if err != nil {
+		apierr.Abort(c, buildUserOpError(err))
+		return
+	}
+
+	// The bundler's estimate also simulates validation and execution of the op
+	source, rejected := h.applyUserOpGasEstimate(logging.Context(c), userWallet, userOp)
+	sponsored := h.applySponsorship(logging.Context(c), userOp) != ""
+
+	nativeSymbol := "HSK"
+	if chain, ok := blockchain.GetChainConfig(int64(h.chainID)); ok {
+		nativeSymbol = chain.Symbol
+	}
+
+	sim, err := h.walletManager.SimulateTransfer(c.Request.Context(), userOp, nativeSymbol)
+	var insufficient *wallet.InsufficientBalanceError
+	switch {
+	case errors.As(err, &insufficient):
+		shortfall := new(big.Int).Sub(insufficient.Required, insufficient.Available)
+		apierr.Abort(c, apierr.InsufficientFunds(fmt.Sprintf("Insufficient %s balance", insufficient.Symbol), InsufficientBalanceDetails{
+			Token:     insufficient.Token,
+			Symbol:    insufficient.Symbol,
+			Decimals:  insufficient.Decimals,
+			Required:  insufficient.Required.String(),
+			Available: insufficient.Available.String(),
+			Shortfall: wallet.FormatUnits(shortfall, insufficient.Decimals),
+		}))
+		return
+	case err != nil:
+		apierr.Abort(c, apierr.Upstream("Failed to simulate transfer", err))
+		return
+	}
+
+	// The bundler estimated the op without the paymaster, so a wallet with no HSK is
+	// rejected for not paying the prefund even though the paymaster will
+	if sponsored && rejected != nil && strings.Contains(rejected.Message, "AA21") {
+		rejected = nil
+	}
+	if sim.Success && rejected != nil {
+		sim.Success = false
+		sim.RevertReason = rejected.Message
+	}
+
+	logging.FromContext(c).Info().
+		Str("wallet", userWallet.Address).
+		Bool("success", sim.Success).
+		Str("revertReason", sim.RevertReason).
+		Msg("transfer simulated")
+
+	c.JSON(http.StatusOK, SimulateTransferResponse{
+		Success:          sim.Success,
+		RevertReason:     sim.RevertReason,
+		GasLimit:         sim.GasLimit.String(),
+		MaxFeePerGas:     sim.MaxFeePerGas.String(),
+		EstimatedGasCost: sim.MaxGasCost.String(),
+		EstimatedGasFee:  wallet.FormatUnits(sim.MaxGasCost, 18) + " " + nativeSymbol,
+		GasSource:        source,
+		Sponsored:        sim.Sponsored,
+		BalanceChanges:   sim.BalanceChanges,
+	})
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/blockchain"
+	"ai-wallet-backend/internal/wallet"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// balanceTokens returns the ERC-20 tokens listed in BALANCE_TOKENS (comma-separated addresses)
+func balanceTokens() []string {
+	var tokens []string
+	for _, token := range strings.Split(os.Getenv("BALANCE_TOKENS"), ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		if !common.IsHexAddress(token) {
+			log.Printf("Warning: Ignoring invalid BALANCE_TOKENS entry %q", token)
+			continue
+		}
+		tokens = append(tokens, common.HexToAddress(token).Hex())
+	}
+	return tokens
+}
+
+// GetWalletBalanceHandler returns the native and ERC-20 balances of the user's wallet
+// Works for counterfactual wallets too, since balances are read by address
+func (h *Handler) GetWalletBalanceHandler(c *gin.Context) {
+	userIDRaw, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := fmt.Sprintf("%v", userIDRaw)
+
+	userWallet, err := h.walletManager.GetWalletByUserID(userID)
+	if err != nil {
+		log.Printf("Error getting wallet: %v", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
+		return
+	}
+
+	nativeSymbol := "HSK"
+	if chain, ok := blockchain.GetChainConfig(int64(userWallet.ChainID)); ok {
+		nativeSymbol = chain.Symbol
+	}
+
+	balances, err := h.walletManager.GetTokenBalances(c.Request.Context(), userWallet.Address, nativeSymbol, balanceTokens())
+	if err != nil {
+		log.Printf("Error getting balances for %s: %v", userWallet.Address, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch balances"})
+		return
+	}
+
+	// Report what the chain says, not the stored flag (which is synced as a side effect)
+	deployed, err := h.walletManager.IsWalletDeployed(c.Request.Context(), userWallet.Address)
+	if err != nil {
+		log.Printf("Warning: Failed to check deployment of %s: %v", userWallet.Address, err)
+		deployed = userWallet.IsDeployed
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"address":    userWallet.Address,
+		"chainId":    userWallet.ChainID,
+		"isDeployed": deployed,
+		"balances":   balances,
+	})
+}
+
+// CreateWalletRequest selects the new wallet's index and optionally an explicit salt
+type CreateWalletRequest struct {
+	Index *uint64 `json:"index"` // defaults to the next free index
+	Salt  string  `json:"salt"`  // decimal or 0x hex; defaults to the configured salt strategy
+}
+
+// CreateWalletHandler creates an additional counterfactual wallet for the user
+// The wallet shares the user's passkey signer and differs only by CREATE2 salt,
+// so the same index/salt always yields the same address.
+func (h *Handler) CreateWalletHandler(c *gin.Context) {
+	userIDRaw, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := fmt.Sprintf("%v", userIDRaw)
+
+	var req CreateWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	wallets, err := h.walletManager.GetWalletsByUserID(userID)
+	if err != nil || len(wallets) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Register a passkey wallet first"})
+		return
+	}
+	primary := wallets[0]
+
+	index := wallets[len(wallets)-1].WalletIndex + 1
+	if req.Index != nil {
+		index = *req.Index
+	}
+
+	salt := h.walletManager.WalletSalt(userID, index)
+	if req.Salt != "" {
+		salt, err = wallet.ParseSalt(req.Salt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Creating the same index/salt again is idempotent; reusing an index with another salt is not
+	for i := range wallets {
+		if wallets[i].WalletIndex != index {
+			continue
+		}
+		if wallets[i].Salt == salt.String() {
+			c.JSON(http.StatusOK, gin.H{"wallet": wallets[i]})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "Wallet index already in use"})
+		return
+	}
+
+	created, err := h.walletManager.CreateP256WalletWithSalt(c.Request.Context(), userID, primary.PublicKeyX, primary.PublicKeyY, index, salt)
+	if err != nil {
+		log.Printf("Error creating wallet: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create wallet"})
+		return
+	}
+
+	log.Printf("✓ Wallet #%d created for user %s: %s (salt %s)", index, userID, created.Address, created.Salt)
+	c.JSON(http.StatusCreated, gin.H{"wallet": created})
+}
+
+// DeployWalletRequest selects which of the user's wallets to deploy
+type DeployWalletRequest struct {
+	Address string `json:"address"` // defaults to the primary wallet
+}
+
+// DeployWalletHandler deploys the user's counterfactual wallet now instead of on its
+// first transfer. The backend's deployer key calls the factory, so no passkey signature
+// is needed. Calling it again for a deployed wallet returns 200 without a transaction.
+func (h *Handler) DeployWalletHandler(c *gin.Context) {
+	userIDRaw, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := fmt.Sprintf("%v", userIDRaw)
+
+	var req DeployWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	wallets, err := h.walletManager.GetWalletsByUserID(userID)
+	if err != nil || len(wallets) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
+		return
+	}
+	target := &wallets[0]
+	if req.Address != "" {
+		target = nil
+		for i := range wallets {
+			if strings.EqualFold(wallets[i].Address, req.Address) {
+				target = &wallets[i]
+				break
+			}
+		}
+		if target == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
+			return
+		}
+	}
+
+	deployment, err := h.walletManager.DeployWallet(c.Request.Context(), target, os.Getenv("BUNDLER_PRIVATE_KEY"))
+	switch {
+	case errors.Is(err, wallet.ErrNoDeployerKey):
+		log.Printf("Error: BUNDLER_PRIVATE_KEY not set, cannot deploy %s", target.Address)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Wallet deployment is not configured"})
+		return
+	case err != nil:
+		log.Printf("Error deploying wallet %s: %v", target.Address, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to deploy wallet"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deployment)
+}
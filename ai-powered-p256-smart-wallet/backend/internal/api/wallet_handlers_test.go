@@ -0,0 +1,117 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"ai-wallet-backend/internal/wallet"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+)
+
+// factoryByCalldata answers eth_call like a wallet factory: getAddress returns an address
+// derived from the call's key and salt, so each salt gets its own wallet
+func factoryByCalldata(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params []struct {
+			Input string `json:"input"`
+			Data  string `json:"data"`
+		} `json:"params"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	var result string
+	switch req.Method {
+	case "eth_chainId":
+		result = "0x85"
+	case "eth_call":
+		calldata := req.Params[0].Input
+		if calldata == "" {
+			calldata = req.Params[0].Data
+		}
+		address := common.BytesToAddress(crypto.Keccak256(hexutil.MustDecode(calldata)))
+		result = hexutil.Encode(common.LeftPadBytes(address.Bytes(), 32))
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+}
+
+func TestCreateWalletHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&models.Wallet{}); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(factoryByCalldata))
+	t.Cleanup(server.Close)
+	manager, err := wallet.NewManager(db, server.URL, 133, "0x5555555555555555555555555555555555555555", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(manager.Close)
+	h := &Handler{db: db, chainID: 133, walletManager: manager}
+
+	primary := &models.Wallet{ID: "wallet-0", UserID: "user-1", Address: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", PublicKeyX: recoveryKeyX, PublicKeyY: recoveryKeyY, ChainID: 133, Salt: "0"}
+	if err := db.Create(primary).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.POST("/wallet", func(c *gin.Context) {
+		c.Set("userID", c.GetHeader("X-Test-User"))
+		h.CreateWalletHandler(c)
+	})
+	create := func(userID, body string) (int, models.Wallet) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/wallet", strings.NewReader(body))
+		req.Header.Set("X-Test-User", userID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var resp struct {
+			Wallet models.Wallet `json:"wallet"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Wallet
+	}
+
+	// The next index gets its own salt and address under the primary wallet's key
+	code, created := create("user-1", "")
+	if code != http.StatusCreated {
+		t.Fatalf("create: got %d, want 201", code)
+	}
+	if created.WalletIndex != 1 || created.Salt != manager.WalletSalt("user-1", 1).String() {
+		t.Fatalf("created index %d salt %s", created.WalletIndex, created.Salt)
+	}
+	if created.Address == primary.Address || created.PublicKeyX != primary.PublicKeyX {
+		t.Fatalf("created wallet %+v", created)
+	}
+
+	// Asking for the same wallet again returns it instead of a duplicate
+	code, again := create("user-1", `{"index":1}`)
+	if code != http.StatusOK || again.ID != created.ID {
+		t.Fatalf("create again: got %d %s, want 200 %s", code, again.ID, created.ID)
+	}
+	// Reusing the index with another salt is a conflict
+	if code, _ := create("user-1", `{"index":1,"salt":"42"}`); code != http.StatusConflict {
+		t.Fatalf("reuse index: got %d, want 409", code)
+	}
+
+	var count int64
+	db.Model(&models.Wallet{}).Where("user_id = ?", "user-1").Count(&count)
+	if count != 2 {
+		t.Fatalf("user has %d wallets, want 2", count)
+	}
+
+	// Additional wallets need a passkey wallet to take the key from
+	if code, _ := create("user-2", ""); code != http.StatusNotFound {
+		t.Fatalf("create without primary wallet: got %d, want 404", code)
+	}
+}
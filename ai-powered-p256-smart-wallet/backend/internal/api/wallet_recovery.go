@@ -0,0 +1,381 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/apierr"
+	"ai-wallet-backend/internal/auth"
+	"ai-wallet-backend/internal/logging"
+	"ai-wallet-backend/internal/models"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+)
+
+// Key recovery relies on P256Account (contracts/P256Account.sol) storing an optional
+// recovery passkey next to its signing key:
+//   - setRecoveryKey(x, y) is signed by the current key and registers the recovery passkey
+//   - rotatePublicKey(x, y) replaces the signing key; besides the current key, the
+//     recovery key may sign a UserOp whose callData is exactly this call
+//
+// Both keys are mirrored in the wallets table so the backend can offer the right
+// passkeys for signing and check assertions before submitting. A submitted change is
+// recorded with its transaction and reaches the wallets table once the receipt poller
+// sees it succeed, so a reverted UserOp leaves the keys as they are. Guardian signatures
+// are not supported by the contract, so a recovery passkey is the only recovery proof.
+
+var (
+	// setRecoveryKeySelector is P256Account.setRecoveryKey(uint256,uint256)
+	setRecoveryKeySelector = crypto.Keccak256([]byte("setRecoveryKey(uint256,uint256)"))[:4]
+	// rotatePublicKeySelector is P256Account.rotatePublicKey(uint256,uint256)
+	rotatePublicKeySelector = crypto.Keccak256([]byte("rotatePublicKey(uint256,uint256)"))[:4]
+
+	p256KeyArguments = func() abi.Arguments {
+		uint256Type, _ := abi.NewType("uint256", "", nil)
+		return abi.Arguments{{Type: uint256Type}, {Type: uint256Type}}
+	}()
+)
+
+var (
+	// errNoRecoveryKey is returned when recovery is attempted on a wallet without a recovery key
+	errNoRecoveryKey = errors.New("no recovery key is registered for this wallet")
+	// errNotRecoveryCredential is returned when the signing passkey is not the recovery key
+	errNotRecoveryCredential = errors.New("passkey is not the wallet's recovery key")
+)
+
+// RecoveryKeyRequest selects a passkey by its base64url credential ID
+type RecoveryKeyRequest struct {
+	CredentialID string `json:"credentialId" binding:"required"`
+}
+
+// PrepareKeyChangeResponse contains the hash of a UserOp that changes one of the wallet's keys
+type PrepareKeyChangeResponse struct {
+	UserOpHash        string   `json:"userOpHash"`        // Hash to use as WebAuthn challenge
+	AllowCredentials  []string `json:"allowCredentials"`  // Passkey credential IDs (base64url) that must sign
+	EntryPointVersion string   `json:"entryPointVersion"` // "0.6" (UserOperation) or "0.7" (PackedUserOperation)
+	PublicKeyX        string   `json:"publicKeyX"`        // key being registered or rotated to
+	PublicKeyY        string   `json:"publicKeyY"`
+	Sponsored         bool     `json:"sponsored"`
+	Paymaster         string   `json:"paymaster,omitempty"`
+}
+
+// encodeP256KeyCall encodes setRecoveryKey or rotatePublicKey for a key in hex coordinates
+func encodeP256KeyCall(selector []byte, pubKeyX, pubKeyY string) (string, error) {
+	x, okX := new(big.Int).SetString(strings.TrimPrefix(pubKeyX, "0x"), 16)
+	y, okY := new(big.Int).SetString(strings.TrimPrefix(pubKeyY, "0x"), 16)
+	if !okX || !okY || x.Sign() == 0 || y.Sign() == 0 {
+		return "", fmt.Errorf("invalid public key (%s, %s)", pubKeyX, pubKeyY)
+	}
+	args, err := p256KeyArguments.Pack(x, y)
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(append(append([]byte{}, selector...), args...)), nil
+}
+
+// decodeP256KeyCall decodes a setRecoveryKey or rotatePublicKey call; ok is false for other calldata
+func decodeP256KeyCall(callData string) (selector []byte, pubKeyX, pubKeyY string, ok bool) {
+	data, err := hexutil.Decode(callData)
+	if err != nil || len(data) < 4 {
+		return nil, "", "", false
+	}
+	if !bytes.Equal(data[:4], setRecoveryKeySelector) && !bytes.Equal(data[:4], rotatePublicKeySelector) {
+		return nil, "", "", false
+	}
+	values, err := p256KeyArguments.Unpack(data[4:])
+	if err != nil {
+		return nil, "", "", false
+	}
+	return data[:4], fmt.Sprintf("0x%064x", values[0].(*big.Int)), fmt.Sprintf("0x%064x", values[1].(*big.Int)), true
+}
+
+// credentialIsRecoveryKey reports whether a passkey's P-256 key is the wallet's recovery key
+func credentialIsRecoveryKey(credential *models.PasskeyCredential, w *models.Wallet) bool {
+	if w.RecoveryPublicKeyX == "" || w.RecoveryPublicKeyY == "" {
+		return false
+	}
+	x, y, err := auth.P256PublicKeyHex(credential.PublicKey)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(x, w.RecoveryPublicKeyX) && strings.EqualFold(y, w.RecoveryPublicKeyY)
+}
+
+// authorizeRecovery checks that a key rotation is signed by the wallet's recovery passkey
+func (h *Handler) authorizeRecovery(w *models.Wallet, credential *models.PasskeyCredential, signatureBlob []byte) error {
+	if w.RecoveryPublicKeyX == "" || w.RecoveryPublicKeyY == "" {
+		return errNoRecoveryKey
+	}
+	if !credentialIsRecoveryKey(credential, w) {
+		return errNotRecoveryCredential
+	}
+	return h.verifyUserOpAssertion(w.RecoveryPublicKeyX, w.RecoveryPublicKeyY, signatureBlob)
+}
+
+// userCredential looks up one of the user's passkeys by its base64url credential ID
+func (h *Handler) userCredential(userID, credentialIDBase64 string) (*models.PasskeyCredential, error) {
+	credentialID, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(credentialIDBase64, "="))
+	if err != nil {
+		return nil, err
+	}
+	var credential models.PasskeyCredential
+	if err := h.db.Where("user_id = ? AND credential_id = ?", userID, credentialID).First(&credential).Error; err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// requireRecoverySupport aborts with 409 unless the wallet's implementation has the
+// recovery key calls
+func (h *Handler) requireRecoverySupport(c *gin.Context, userWallet *models.Wallet) bool {
+	supported, err := h.walletManager.SupportsRecoveryKey(logging.Context(c), userWallet)
+	if err != nil {
+		apierr.Abort(c, apierr.Upstream("Failed to check the wallet's implementation", err))
+		return false
+	}
+	if !supported {
+		apierr.Abort(c, apierr.Conflict("This wallet's implementation does not support recovery keys"))
+		return false
+	}
+	return true
+}
+
+// recoveryCredentials returns the user's passkeys that hold the wallet's recovery key
+func (h *Handler) recoveryCredentials(userID string, w *models.Wallet) ([]models.PasskeyCredential, error) {
+	var credentials []models.PasskeyCredential
+	if err := h.db.Where("user_id = ?", userID).Order("last_used_at DESC").Find(&credentials).Error; err != nil {
+		return nil, err
+	}
+
+	recovery := make([]models.PasskeyCredential, 0, len(credentials))
+	for i := range credentials {
+		if credentialIsRecoveryKey(&credentials[i], w) {
+			recovery = append(recovery, credentials[i])
+		}
+	}
+	return recovery, nil
+}
+
+// prepareKeyChange builds and stores a UserOp that calls selector(pubKeyX, pubKeyY) on the
+// wallet, to be signed by one of signers
+func (h *Handler) prepareKeyChange(c *gin.Context, userID string, userWallet *models.Wallet, selector []byte, pubKeyX, pubKeyY string, signers []models.PasskeyCredential) {
+	callData, err := encodeP256KeyCall(selector, pubKeyX, pubKeyY)
+	if err != nil {
+		apierr.Abort(c, apierr.Validation(err.Error()))
+		return
+	}
+
+	userOp, err := h.buildUserOpP256(logging.Context(c), userWallet, callData)
+	if err != nil {
+		apierr.Abort(c, buildUserOpError(err))
+		return
+	}
+	// The recovery key is only set on a deployed wallet; initCode would deploy it with the lost key
+	if bytes.Equal(selector, rotatePublicKeySelector) && userOp["initCode"] != "0x" {
+		apierr.Abort(c, apierr.Conflict("Wallet is not deployed, so it has no recovery key on-chain"))
+		return
+	}
+
+	userOpHash, paymaster, ok := h.storePreparedUserOp(c, userID, userWallet, userOp)
+	if !ok {
+		return
+	}
+
+	allowCredentials := make([]string, len(signers))
+	for i, credential := range signers {
+		allowCredentials[i] = base64URLEncodeBytes(credential.CredentialID)
+	}
+
+	c.JSON(http.StatusOK, PrepareKeyChangeResponse{
+		UserOpHash:        userOpHash,
+		AllowCredentials:  allowCredentials,
+		EntryPointVersion: h.walletManager.EntryPointVersion(),
+		PublicKeyX:        pubKeyX,
+		PublicKeyY:        pubKeyY,
+		Sponsored:         paymaster != "",
+		Paymaster:         paymaster,
+	})
+}
+
+// SetRecoveryKeyHandler prepares a setRecoveryKey UserOp that makes one of the user's other
+// passkeys the wallet's recovery key. The current signer signs it and submits it through
+// /transfer/submit, which records the recovery key.
+func (h *Handler) SetRecoveryKeyHandler(c *gin.Context) {
+	userIDRaw, exists := c.Get("userID")
+	if !exists {
+		apierr.Abort(c, apierr.Unauthorized("unauthorized"))
+		return
+	}
+	userID := fmt.Sprintf("%v", userIDRaw)
+
+	var req RecoveryKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, apierr.Validation("Invalid request body"))
+		return
+	}
+
+	credential, err := h.userCredential(userID, req.CredentialID)
+	if err != nil {
+		apierr.Abort(c, apierr.NotFound("Passkey not found"))
+		return
+	}
+	recoveryX, recoveryY, err := auth.P256PublicKeyHex(credential.PublicKey)
+	if err != nil {
+		apierr.Abort(c, apierr.Validation("Passkey is not a P-256 key").WithDetails(err.Error()))
+		return
+	}
+
+	userWallet, err := h.walletManager.GetWalletByUserID(userID)
+	if err != nil {
+		apierr.Abort(c, apierr.Internal("Failed to get wallet", err))
+		return
+	}
+	if credentialSignsForWallet(credential, userWallet) {
+		apierr.Abort(c, apierr.Validation("The recovery passkey must not be the wallet's signing passkey"))
+		return
+	}
+	if !h.requireRecoverySupport(c, userWallet) {
+		return
+	}
+
+	signers, err := h.walletSignerCredentials(userID, userWallet)
+	if err != nil {
+		apierr.Abort(c, apierr.Internal("Failed to get credential", err))
+		return
+	}
+	if len(signers) == 0 {
+		apierr.Abort(c, apierr.Conflict("No registered passkey can sign for this wallet"))
+		return
+	}
+
+	h.prepareKeyChange(c, userID, userWallet, setRecoveryKeySelector, recoveryX, recoveryY, signers)
+}
+
+// PrepareRecoveryHandler prepares a rotatePublicKey UserOp that makes the given passkey the
+// wallet's signer; it must be signed with the wallet's recovery passkey
+func (h *Handler) PrepareRecoveryHandler(c *gin.Context) {
+	userIDRaw, exists := c.Get("userID")
+	if !exists {
+		apierr.Abort(c, apierr.Unauthorized("unauthorized"))
+		return
+	}
+	userID := fmt.Sprintf("%v", userIDRaw)
+
+	var req RecoveryKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, apierr.Validation("Invalid request body"))
+		return
+	}
+
+	userWallet, err := h.walletManager.GetWalletByUserID(userID)
+	if err != nil {
+		apierr.Abort(c, apierr.Internal("Failed to get wallet", err))
+		return
+	}
+	if userWallet.RecoveryPublicKeyX == "" || userWallet.RecoveryPublicKeyY == "" {
+		apierr.Abort(c, apierr.Conflict("No recovery passkey is registered for this wallet"))
+		return
+	}
+	if !h.requireRecoverySupport(c, userWallet) {
+		return
+	}
+
+	credential, err := h.userCredential(userID, req.CredentialID)
+	if err != nil {
+		apierr.Abort(c, apierr.NotFound("Passkey not found"))
+		return
+	}
+	newX, newY, err := auth.P256PublicKeyHex(credential.PublicKey)
+	if err != nil {
+		apierr.Abort(c, apierr.Validation("Passkey is not a P-256 key").WithDetails(err.Error()))
+		return
+	}
+	if credentialSignsForWallet(credential, userWallet) {
+		apierr.Abort(c, apierr.Validation("This passkey is already the wallet's signer"))
+		return
+	}
+
+	signers, err := h.recoveryCredentials(userID, userWallet)
+	if err != nil {
+		apierr.Abort(c, apierr.Internal("Failed to get credential", err))
+		return
+	}
+	if len(signers) == 0 {
+		apierr.Abort(c, apierr.Conflict("The wallet's recovery passkey is no longer registered"))
+		return
+	}
+
+	h.prepareKeyChange(c, userID, userWallet, rotatePublicKeySelector, newX, newY, signers)
+}
+
+// SubmitRecoveryHandler submits a rotatePublicKey UserOp signed by the recovery passkey; the
+// new signing key is stored once the UserOp succeeds
+func (h *Handler) SubmitRecoveryHandler(c *gin.Context) {
+	userIDRaw, exists := c.Get("userID")
+	if !exists {
+		apierr.Abort(c, apierr.Unauthorized("unauthorized"))
+		return
+	}
+	userID := fmt.Sprintf("%v", userIDRaw)
+
+	var req SubmitTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, apierr.Validation("Invalid request body"))
+		return
+	}
+
+	userOp, credential, ok := h.loadPendingSubmission(c, userID, &req)
+	if !ok {
+		return
+	}
+	callData, _ := userOp["callData"].(string)
+	selector, newX, newY, ok := decodeP256KeyCall(callData)
+	if !ok || !bytes.Equal(selector, rotatePublicKeySelector) {
+		apierr.Abort(c, apierr.Validation("UserOp is not a key rotation"))
+		return
+	}
+
+	userWallet, err := h.walletManager.GetWalletByUserID(userID)
+	if err != nil {
+		apierr.Abort(c, apierr.Internal("Failed to get wallet", err))
+		return
+	}
+
+	signatureBlob := hexToBytes(req.Signature)
+	switch err := h.authorizeRecovery(userWallet, credential, signatureBlob); {
+	case errors.Is(err, errNoRecoveryKey):
+		apierr.Abort(c, apierr.Conflict("No recovery passkey is registered for this wallet"))
+		return
+	case errors.Is(err, errNotRecoveryCredential):
+		logging.FromContext(c).Warn().Str("credentialId", req.CredentialID).Msg("recovery signed by a non-recovery passkey")
+		apierr.Abort(c, apierr.Forbidden("This passkey is not the wallet's recovery key"))
+		return
+	case err != nil:
+		logging.FromContext(c).Warn().Err(err).Msg("recovery signature rejected")
+		apierr.Abort(c, apierr.Validation("Invalid signature").WithDetails(err.Error()))
+		return
+	}
+
+	txHash, ok := h.submitSignedUserOp(c, userID, userWallet, credential, userOp, req.UserOpHash, signatureBlob)
+	if !ok {
+		return
+	}
+
+	// The wallet keeps its signing key until the receipt poller sees the rotation succeed
+	logging.FromContext(c).Info().Str("txHash", txHash).Str("wallet", userWallet.Address).Msg("wallet key rotation submitted by recovery passkey")
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"txHash":      txHash,
+		"explorerUrl": h.explorerTxURL(txHash),
+		"publicKeyX":  newX,
+		"publicKeyY":  newY,
+	})
+}
@@ -0,0 +1,287 @@
+package api
+
+import (
+	"ai-wallet-backend/internal/apierr"
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"ai-wallet-backend/internal/wallet"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// A WebAuthn assertion captured from a browser, signed by the passkey (recoveryKeyX, recoveryKeyY)
+const (
+	recoveryKeyX   = "0xf81f8ea92c0cf33bc5df8b48884531f0d0f68f01e75c588c098673176034f1c5"
+	recoveryKeyY   = "0xcc430dcb14c1855bb9f55319bb3f7972201e72696baf00721fbb58bc51b6d8f9"
+	recoveryCOSE   = "a5010203262001215820" + "f81f8ea92c0cf33bc5df8b48884531f0d0f68f01e75c588c098673176034f1c5" + "225820" + "cc430dcb14c1855bb9f55319bb3f7972201e72696baf00721fbb58bc51b6d8f9"
+	recoveryBlob   = "050eb3a1048e6a1dde9bd2d384478cca1eec7e5171222c52d1f25eff7e214c5f7ceeccdcee9fa28ee5f0253a1fa6bee9770cc3dbd12913eee514db79313ee983002549960de5880e8c687434170f6476605b8fe4aeb9a28632c7995cf3ba831d97631d000000007b2274797065223a22776562617574686e2e676574222c226368616c6c656e6765223a22626e44436f48456c75306b736d51414147592d36444d4a6c6b6a6b69734e6a5362435a74395a49564e6463222c226f726967696e223a22687474703a2f2f6c6f63616c686f73743a33303030222c2263726f73734f726967696e223a66616c73657d"
+	lostSignerKeyX = "0x0c5eb37395ea6da0b462730cc55d1d646ae9fd81186636eb3d81aaac2cca2c66"
+	lostSignerKeyY = "0x3d00c3f46d19d9e9aaae3532e60339a88816be0c87a540e981ebd0c4197d741d"
+	lostSignerCOSE = "a5010203262001215820" + "0c5eb37395ea6da0b462730cc55d1d646ae9fd81186636eb3d81aaac2cca2c66" + "225820" + "3d00c3f46d19d9e9aaae3532e60339a88816be0c87a540e981ebd0c4197d741d"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("hex.DecodeString: %v", err)
+	}
+	return b
+}
+
+func TestP256KeyCallRoundTrip(t *testing.T) {
+	for _, selector := range [][]byte{setRecoveryKeySelector, rotatePublicKeySelector} {
+		callData, err := encodeP256KeyCall(selector, recoveryKeyX, recoveryKeyY)
+		if err != nil {
+			t.Fatalf("encodeP256KeyCall: %v", err)
+		}
+		if len(callData) != 2+2*(4+64) {
+			t.Fatalf("callData length = %d", len(callData))
+		}
+		gotSelector, x, y, ok := decodeP256KeyCall(callData)
+		if !ok || !bytes.Equal(gotSelector, selector) || x != recoveryKeyX || y != recoveryKeyY {
+			t.Fatalf("decodeP256KeyCall = %x, %s, %s, %v", gotSelector, x, y, ok)
+		}
+	}
+
+	// A transfer is not a key change
+	transfer, _ := encodeExecuteCallP256(batchRecipientA, big.NewInt(1))
+	if _, _, _, ok := decodeP256KeyCall(transfer); ok {
+		t.Fatal("decoded an execute call as a key change")
+	}
+	if _, err := encodeP256KeyCall(rotatePublicKeySelector, "0x0", recoveryKeyY); err == nil {
+		t.Fatal("encoded a zero public key")
+	}
+}
+
+func TestAuthorizeRecovery(t *testing.T) {
+	h := &Handler{}
+	signature := mustDecodeHex(t, recoveryBlob)
+	recoveryPasskey := &models.PasskeyCredential{PublicKey: mustDecodeHex(t, recoveryCOSE)}
+	signerPasskey := &models.PasskeyCredential{PublicKey: mustDecodeHex(t, lostSignerCOSE)}
+
+	withRecovery := &models.Wallet{
+		PublicKeyX: lostSignerKeyX, PublicKeyY: lostSignerKeyY,
+		RecoveryPublicKeyX: recoveryKeyX, RecoveryPublicKeyY: recoveryKeyY,
+	}
+	if err := h.authorizeRecovery(withRecovery, recoveryPasskey, signature); err != nil {
+		t.Fatalf("recovery passkey: %v", err)
+	}
+
+	// Upper-case hex in the database still matches the passkey
+	upper := *withRecovery
+	upper.RecoveryPublicKeyX = "0x" + strings.ToUpper(recoveryKeyX[2:])
+	if err := h.authorizeRecovery(&upper, recoveryPasskey, signature); err != nil {
+		t.Fatalf("upper-case recovery key: %v", err)
+	}
+
+	noRecovery := &models.Wallet{PublicKeyX: lostSignerKeyX, PublicKeyY: lostSignerKeyY}
+	if err := h.authorizeRecovery(noRecovery, recoveryPasskey, signature); !errors.Is(err, errNoRecoveryKey) {
+		t.Fatalf("wallet without recovery key: err = %v, want errNoRecoveryKey", err)
+	}
+
+	// The signing passkey cannot use the recovery path
+	if err := h.authorizeRecovery(withRecovery, signerPasskey, signature); !errors.Is(err, errNotRecoveryCredential) {
+		t.Fatalf("signing passkey: err = %v, want errNotRecoveryCredential", err)
+	}
+
+	// A recovery passkey claiming a signature made by another key
+	swapped := *withRecovery
+	swapped.RecoveryPublicKeyX, swapped.RecoveryPublicKeyY = lostSignerKeyX, lostSignerKeyY
+	err := h.authorizeRecovery(&swapped, signerPasskey, signature)
+	if err == nil || errors.Is(err, errNotRecoveryCredential) || errors.Is(err, errNoRecoveryKey) {
+		t.Fatalf("signature from another key: err = %v, want a signature error", err)
+	}
+
+	// A tampered assertion
+	tampered := bytes.Clone(signature)
+	tampered[70] ^= 0x01
+	if err := h.authorizeRecovery(withRecovery, recoveryPasskey, tampered); err == nil {
+		t.Fatal("accepted a tampered assertion")
+	}
+}
+
+func TestRecoveryHandlersRejectInvalidRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// No wallet manager or database: reaching wallet lookup would panic
+	h := &Handler{chainID: 133}
+
+	router := gin.New()
+	router.Use(apierr.Middleware(), func(c *gin.Context) { c.Set("userID", "user-1") })
+	router.POST("/wallet/recovery-key", h.SetRecoveryKeyHandler)
+	router.POST("/wallet/recover/prepare", h.PrepareRecoveryHandler)
+	router.POST("/wallet/recover/submit", h.SubmitRecoveryHandler)
+
+	for _, tc := range []struct{ path, body string }{
+		{"/wallet/recovery-key", `{}`},
+		{"/wallet/recover/prepare", `{"credentialId":""}`},
+		{"/wallet/recover/submit", `{"signature":"0x00","userOpHash":"0x01"}`},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s %s: got %d %s, want 400", tc.path, tc.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+// chainWithoutRecovery answers like a wallet deployed with an implementation that predates
+// recovery keys: the wallet has code and recoveryPublicKeyX() reverts
+func chainWithoutRecovery(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	reply := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	switch req.Method {
+	case "eth_chainId":
+		reply["result"] = "0x85"
+	case "eth_getCode":
+		reply["result"] = "0x6080"
+	case "eth_call":
+		reply["error"] = map[string]interface{}{"code": 3, "message": "execution reverted"}
+	}
+	json.NewEncoder(w).Encode(reply)
+}
+
+func TestRecoveryHandlersRequireRecoveryImplementation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&models.Wallet{}, &models.PasskeyCredential{}); err != nil {
+		t.Fatal(err)
+	}
+	w := models.Wallet{
+		ID: "wallet-1", UserID: "user-1", Address: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", ChainID: 133,
+		PublicKeyX: lostSignerKeyX, PublicKeyY: lostSignerKeyY,
+		RecoveryPublicKeyX: recoveryKeyX, RecoveryPublicKeyY: recoveryKeyY,
+	}
+	if err := db.Create(&w).Error; err != nil {
+		t.Fatal(err)
+	}
+	passkey := models.PasskeyCredential{ID: "cred-1", UserID: "user-1", CredentialID: []byte{1, 2, 3}, PublicKey: mustDecodeHex(t, recoveryCOSE)}
+	if err := db.Create(&passkey).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(chainWithoutRecovery))
+	t.Cleanup(server.Close)
+	manager, err := wallet.NewManager(db, server.URL, 133, "0x5555555555555555555555555555555555555555", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(manager.Close)
+	h := &Handler{db: db, chainID: 133, walletManager: manager}
+
+	router := gin.New()
+	router.Use(apierr.Middleware(), func(c *gin.Context) { c.Set("userID", "user-1") })
+	router.POST("/wallet/recovery-key", h.SetRecoveryKeyHandler)
+	router.POST("/wallet/recover/prepare", h.PrepareRecoveryHandler)
+
+	// A setRecoveryKey or rotatePublicKey UserOp would revert on this wallet
+	for _, path := range []string{"/wallet/recovery-key", "/wallet/recover/prepare"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"credentialId":"AQID"}`)))
+		if rec.Code != http.StatusConflict {
+			t.Errorf("%s: got %d %s, want 409", path, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestSubmitRecoveryStoresKeyOnlyAfterSuccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&models.Wallet{}, &models.PasskeyCredential{}, &models.Transaction{}); err != nil {
+		t.Fatal(err)
+	}
+	w := models.Wallet{
+		ID: "wallet-1", UserID: "user-1", Address: "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1", ChainID: 133,
+		PublicKeyX: lostSignerKeyX, PublicKeyY: lostSignerKeyY,
+		RecoveryPublicKeyX: recoveryKeyX, RecoveryPublicKeyY: recoveryKeyY,
+	}
+	if err := db.Create(&w).Error; err != nil {
+		t.Fatal(err)
+	}
+	passkey := models.PasskeyCredential{ID: "cred-1", UserID: "user-1", CredentialID: []byte{1, 2, 3}, PublicKey: mustDecodeHex(t, recoveryCOSE)}
+	if err := db.Create(&passkey).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	stub := &bundlerStub{receipt: includedReceipt}
+	h := newBundlerHandlerWithDB(t, stub, db)
+	router := gin.New()
+	router.Use(apierr.Middleware(), func(c *gin.Context) { c.Set("userID", "user-1") })
+	router.POST("/wallet/recover/submit", h.SubmitRecoveryHandler)
+
+	// submit signs a rotation to the recovery passkey and refreshes its status with receipt
+	submit := func(userOpHash string, receipt map[string]interface{}) {
+		t.Helper()
+		callData, err := encodeP256KeyCall(rotatePublicKeySelector, recoveryKeyX, recoveryKeyY)
+		if err != nil {
+			t.Fatal(err)
+		}
+		op := map[string]interface{}{"sender": w.Address, "nonce": "0x3", "callData": callData}
+		if err := h.pendingOps.Save(context.Background(), "user-1", userOpHash, op, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		stub.setReceipt(includedReceipt)
+		body := `{"userOpHash":"` + userOpHash + `","signature":"0x` + recoveryBlob + `","credentialId":"AQID"}`
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wallet/recover/submit", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("submit %s: got %d %s", userOpHash, rec.Code, rec.Body.String())
+		}
+
+		// Submitted is not yet included: the wallet keeps its signing key
+		if got := walletSigningKey(t, h); got != lostSignerKeyX {
+			t.Fatalf("signing key after submit = %s, want the old key", got)
+		}
+		stub.setReceipt(receipt)
+		if _, err := h.walletManager.RefreshPendingTransactions(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A rotation that reverts on-chain, as in direct mode where submission returns early
+	submit("0xreverted", map[string]interface{}{
+		"success": false,
+		"reason":  "execution reverted",
+		"receipt": map[string]interface{}{"transactionHash": "0xdef456", "status": "0x1"},
+	})
+	if got := walletSigningKey(t, h); got != lostSignerKeyX {
+		t.Fatalf("signing key after a reverted rotation = %s, want the old key", got)
+	}
+	var reverted models.Transaction
+	if err := db.Where("user_op_hash = ?", "0xreverted").First(&reverted).Error; err != nil {
+		t.Fatal(err)
+	}
+	if reverted.Status != models.TxStatusFailed || reverted.Action != models.TxActionRotateKey {
+		t.Fatalf("reverted rotation recorded as %s %s", reverted.Action, reverted.Status)
+	}
+
+	submit("0xincluded", includedReceipt)
+	if got := walletSigningKey(t, h); got != recoveryKeyX {
+		t.Fatalf("signing key after a successful rotation = %s, want the recovery key", got)
+	}
+}
+
+// walletSigningKey returns the X coordinate of user-1's stored signing key
+func walletSigningKey(t *testing.T, h *Handler) string {
+	t.Helper()
+	w, err := h.walletManager.GetWalletByUserID("user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w.PublicKeyX
+}
@@ -0,0 +1,158 @@
+// Package apierr defines the errors returned by the API and renders them as
+// {code, message, details} so clients can branch on code instead of message text.
+package apierr
+
+import (
+	"ai-wallet-backend/internal/logging"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Code is a stable, machine-readable error code
+type Code string
+
+// Error codes; clients may rely on these, messages may change
+const (
+	CodeValidation        Code = "validation_error"     // 400: the request is malformed or invalid
+	CodeUnauthorized      Code = "unauthorized"         // 401: no valid session
+	CodeForbidden         Code = "forbidden"            // 403: the resource belongs to another user or signer
+	CodeNotFound          Code = "not_found"            // 404
+	CodeConflict          Code = "conflict"             // 409: e.g. no passkey can sign for the wallet
+	CodeExpired           Code = "expired"              // 410: e.g. a prepared UserOp waited too long for its signature
+	CodeInsufficientFunds Code = "insufficient_balance" // 422: the wallet cannot cover the amount and gas
+	CodeTransferLimit     Code = "transfer_limit"       // 422: the transfer is outside the configured min/max or daily cap
+	CodeBundlerRevert     Code = "bundler_revert"       // 422: the bundler or EntryPoint rejected the UserOp
+	CodeUpstream          Code = "upstream_error"       // 502: the RPC node, bundler or paymaster failed
+	CodeInternal          Code = "internal_error"       // 500
+)
+
+// Error is an API error: the HTTP status, code and message sent to the client,
+// optional details, and the underlying cause, which is logged but never sent
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	Details interface{}
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithDetails attaches client-visible details (e.g. the bundler's revert reason)
+func (e *Error) WithDetails(details interface{}) *Error {
+	e.Details = details
+	return e
+}
+
+// Response is the JSON body of every error response
+type Response struct {
+	Code    Code        `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// Validation reports an invalid request
+func Validation(message string) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: CodeValidation, Message: message}
+}
+
+// Unauthorized reports a missing or invalid session
+func Unauthorized(message string) *Error {
+	return &Error{Status: http.StatusUnauthorized, Code: CodeUnauthorized, Message: message}
+}
+
+// Forbidden reports access to another user's resource
+func Forbidden(message string) *Error {
+	return &Error{Status: http.StatusForbidden, Code: CodeForbidden, Message: message}
+}
+
+// NotFound reports a missing resource
+func NotFound(message string) *Error {
+	return &Error{Status: http.StatusNotFound, Code: CodeNotFound, Message: message}
+}
+
+// Conflict reports a request the current state does not allow
+func Conflict(message string) *Error {
+	return &Error{Status: http.StatusConflict, Code: CodeConflict, Message: message}
+}
+
+// Expired reports a resource that is gone because it timed out
+func Expired(message string) *Error {
+	return &Error{Status: http.StatusGone, Code: CodeExpired, Message: message}
+}
+
+// InsufficientFunds reports a wallet that cannot pay for a transfer; details
+// describe the shortfall
+func InsufficientFunds(message string, details interface{}) *Error {
+	return &Error{Status: http.StatusUnprocessableEntity, Code: CodeInsufficientFunds, Message: message, Details: details}
+}
+
+// TransferLimit reports a transfer outside the configured transfer limits; details
+// name the limit and, for the daily cap, what is left today
+func TransferLimit(message string, details interface{}) *Error {
+	return &Error{Status: http.StatusUnprocessableEntity, Code: CodeTransferLimit, Message: message, Details: details}
+}
+
+// BundlerRevert reports a UserOp rejected by the bundler or EntryPoint; the
+// reason is sent as details
+func BundlerRevert(message string, err error) *Error {
+	apiErr := &Error{Status: http.StatusUnprocessableEntity, Code: CodeBundlerRevert, Message: message, Err: err}
+	if err != nil {
+		apiErr.Details = err.Error()
+	}
+	return apiErr
+}
+
+// Upstream reports a failure of the RPC node, bundler or paymaster
+func Upstream(message string, err error) *Error {
+	return &Error{Status: http.StatusBadGateway, Code: CodeUpstream, Message: message, Err: err}
+}
+
+// Internal reports a server-side failure
+func Internal(message string, err error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: message, Err: err}
+}
+
+// From returns err as an *Error; any other error becomes an internal error
+// whose message does not leak err
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return Internal("Internal server error", err)
+}
+
+// Abort records err on the request for Middleware to render and stops the handler chain
+func Abort(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
+
+// Middleware renders the last error recorded with Abort (or c.Error) when the
+// handler has not written a response itself
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		apiErr := From(c.Errors.Last().Err)
+		if apiErr.Status >= http.StatusInternalServerError {
+			logging.FromContext(c).Error().Err(apiErr.Err).Str("code", string(apiErr.Code)).Msg(apiErr.Message)
+		}
+		c.JSON(apiErr.Status, Response{Code: apiErr.Code, Message: apiErr.Message, Details: apiErr.Details})
+	}
+}
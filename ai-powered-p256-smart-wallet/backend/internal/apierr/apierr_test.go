@@ -0,0 +1,126 @@
+package apierr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serve(t *testing.T, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/", handler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder) Response {
+	t.Helper()
+	var body Response
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body %q: %v", w.Body.String(), err)
+	}
+	return body
+}
+
+func TestMiddlewareRendersTypedErrors(t *testing.T) {
+	cause := errors.New("dial tcp: connection refused")
+	for _, tc := range []struct {
+		err    *Error
+		status int
+		code   Code
+	}{
+		{Validation("Invalid amount"), http.StatusBadRequest, CodeValidation},
+		{Unauthorized("unauthorized"), http.StatusUnauthorized, CodeUnauthorized},
+		{Forbidden("not yours"), http.StatusForbidden, CodeForbidden},
+		{Expired("UserOp expired"), http.StatusGone, CodeExpired},
+		{InsufficientFunds("Insufficient HSK balance", map[string]string{"shortfall": "0.5"}), http.StatusUnprocessableEntity, CodeInsufficientFunds},
+		{TransferLimit("Transfer exceeds the daily limit", map[string]string{"limit": "daily"}), http.StatusUnprocessableEntity, CodeTransferLimit},
+		{BundlerRevert("Transaction rejected by the bundler", errors.New("AA21 didn't pay prefund")), http.StatusUnprocessableEntity, CodeBundlerRevert},
+		{Upstream("Failed to simulate transfer", cause), http.StatusBadGateway, CodeUpstream},
+		{Internal("Failed to get wallet", cause), http.StatusInternalServerError, CodeInternal},
+	} {
+		w := serve(t, func(c *gin.Context) { Abort(c, tc.err) })
+		body := decode(t, w)
+		if w.Code != tc.status || body.Code != tc.code || body.Message != tc.err.Message {
+			t.Errorf("%s: got %d %+v", tc.code, w.Code, body)
+		}
+		// The cause is logged, never sent, unless it was made a detail
+		if tc.code != CodeBundlerRevert && strings.Contains(w.Body.String(), "connection refused") {
+			t.Errorf("%s: cause leaked: %s", tc.code, w.Body.String())
+		}
+	}
+}
+
+func TestMiddlewareDetails(t *testing.T) {
+	w := serve(t, func(c *gin.Context) {
+		Abort(c, BundlerRevert("Transaction rejected by the bundler", errors.New("AA21 didn't pay prefund")))
+	})
+	if body := decode(t, w); body.Details != "AA21 didn't pay prefund" {
+		t.Fatalf("details = %v", body.Details)
+	}
+
+	w = serve(t, func(c *gin.Context) { Abort(c, Validation("Invalid signature").WithDetails("bad DER")) })
+	if body := decode(t, w); body.Details != "bad DER" {
+		t.Fatalf("details = %v", body.Details)
+	}
+
+	// Without details the field is omitted
+	w = serve(t, func(c *gin.Context) { Abort(c, Validation("Invalid amount")) })
+	if strings.Contains(w.Body.String(), "details") {
+		t.Fatalf("body = %s", w.Body.String())
+	}
+}
+
+func TestMiddlewareWrappedAndUnknownErrors(t *testing.T) {
+	// A wrapped *Error keeps its status and code
+	w := serve(t, func(c *gin.Context) {
+		Abort(c, fmt.Errorf("prepare: %w", Conflict("No registered passkey can sign for this wallet")))
+	})
+	if body := decode(t, w); w.Code != http.StatusConflict || body.Code != CodeConflict {
+		t.Fatalf("wrapped: got %d %+v", w.Code, body)
+	}
+
+	// Any other error is an internal error with a generic message
+	w = serve(t, func(c *gin.Context) { Abort(c, errors.New("pq: password authentication failed")) })
+	body := decode(t, w)
+	if w.Code != http.StatusInternalServerError || body.Code != CodeInternal || strings.Contains(body.Message, "pq:") {
+		t.Fatalf("unknown: got %d %+v", w.Code, body)
+	}
+}
+
+func TestMiddlewareLeavesWrittenResponses(t *testing.T) {
+	w := serve(t, func(c *gin.Context) {
+		c.Error(errors.New("logged only"))
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+	if w.Code != http.StatusOK || w.Body.String() != `{"success":true}` {
+		t.Fatalf("got %d %s", w.Code, w.Body.String())
+	}
+
+	w = serve(t, func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Fatalf("got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestErrorUnwrap(t *testing.T) {
+	cause := errors.New("timeout")
+	err := Upstream("Failed to simulate transfer", cause)
+	if !errors.Is(err, cause) || err.Error() != "Failed to simulate transfer: timeout" {
+		t.Fatalf("err = %v", err)
+	}
+	if From(err) != err {
+		t.Fatal("From did not return the *Error itself")
+	}
+}
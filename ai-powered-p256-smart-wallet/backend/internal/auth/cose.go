@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+// ErrUnsupportedPasskey is returned when a passkey's public key is not an ES256 (P-256) key,
+// the only kind the wallet contract can verify
+var ErrUnsupportedPasskey = errors.New("passkey must use an ES256 (P-256) key")
+
+// extractP256FromCOSE parses a COSE_Key (RFC 8152 section 7) and returns the P-256 coordinates
+// The key must be kty=EC2 (2), crv=P-256 (1) and, when present, alg=ES256 (-7)
+func extractP256FromCOSE(cose []byte) (x, y [32]byte, err error) {
+	parsed, err := webauthncose.ParsePublicKey(cose)
+	if err != nil {
+		return x, y, fmt.Errorf("invalid COSE key: %w", err)
+	}
+
+	key, ok := parsed.(webauthncose.EC2PublicKeyData)
+	if !ok {
+		return x, y, fmt.Errorf("%w: key type %T", ErrUnsupportedPasskey, parsed)
+	}
+	if key.Curve != int64(webauthncose.P256) {
+		return x, y, fmt.Errorf("%w: curve %d", ErrUnsupportedPasskey, key.Curve)
+	}
+	if key.Algorithm != 0 && key.Algorithm != int64(webauthncose.AlgES256) {
+		return x, y, fmt.Errorf("%w: algorithm %d", ErrUnsupportedPasskey, key.Algorithm)
+	}
+	if len(key.XCoord) != 32 || len(key.YCoord) != 32 {
+		return x, y, fmt.Errorf("invalid COSE key: coordinates must be 32 bytes, got %d and %d", len(key.XCoord), len(key.YCoord))
+	}
+
+	if !elliptic.P256().IsOnCurve(new(big.Int).SetBytes(key.XCoord), new(big.Int).SetBytes(key.YCoord)) {
+		return x, y, fmt.Errorf("invalid COSE key: point is not on the P-256 curve")
+	}
+
+	copy(x[:], key.XCoord)
+	copy(y[:], key.YCoord)
+	return x, y, nil
+}
+
+// P256PublicKeyHex returns a passkey's P-256 coordinates as 0x-prefixed hex,
+// the format stored in Wallet.PublicKeyX/Y
+func P256PublicKeyHex(cose []byte) (xHex, yHex string, err error) {
+	x, y, err := extractP256FromCOSE(cose)
+	if err != nil {
+		return "", "", err
+	}
+	return fmt.Sprintf("0x%x", x), fmt.Sprintf("0x%x", y), nil
+}
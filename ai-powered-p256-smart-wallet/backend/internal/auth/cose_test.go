@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	coseX = "f81f8ea92c0cf33bc5df8b48884531f0d0f68f01e75c588c098673176034f1c5"
+	coseY = "cc430dcb14c1855bb9f55319bb3f7972201e72696baf00721fbb58bc51b6d8f9"
+
+	// {1: 2, 3: -7, -1: 1, -2: x, -3: y}
+	coseP256 = "a5010203262001215820" + coseX + "225820" + coseY
+	// The same key with its entries in a different order, as some authenticators emit
+	coseP256Reordered = "a5215820" + coseX + "225820" + coseY + "200103260102"
+	// {1: 2, 3: -35, -1: 2, ...}: the P-384 base point
+	coseP384 = "a501020338222002215830aa87ca22be8b05378eb1c71ef320ad746e1d3b628ba79b9859f741e082542a385502f25dbf55296c3a545e3872760ab72258303617de4a96262c6f5d9e98bf9292dc29f8f41dbd289a147ce9da3113b5f0b8c00a60b1ce1d7e819d7a431d7c90ea0e5f"
+	// {1: 1, 3: -8, -1: 6, -2: x}: an Ed25519 OKP key
+	coseEd25519 = "a4010103272006215820d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("hex.DecodeString: %v", err)
+	}
+	return b
+}
+
+func TestExtractP256FromCOSE(t *testing.T) {
+	for name, key := range map[string]string{"canonical": coseP256, "reordered": coseP256Reordered} {
+		t.Run(name, func(t *testing.T) {
+			x, y, err := extractP256FromCOSE(mustHex(t, key))
+			if err != nil {
+				t.Fatalf("extractP256FromCOSE: %v", err)
+			}
+			if hex.EncodeToString(x[:]) != coseX || hex.EncodeToString(y[:]) != coseY {
+				t.Fatalf("got (%x, %x), want (%s, %s)", x, y, coseX, coseY)
+			}
+		})
+	}
+}
+
+func TestExtractP256FromCOSERejectsInvalid(t *testing.T) {
+	// Flip the last byte of y so the point is off the curve
+	offCurve := coseP256[:len(coseP256)-2] + "f8"
+
+	cases := []struct {
+		name        string
+		cose        string
+		unsupported bool // non-P-256 keys report ErrUnsupportedPasskey
+	}{
+		{"P-384", coseP384, true},
+		{"Ed25519", coseEd25519, true},
+		{"ES384 algorithm on P-256", strings.Replace(coseP256, "0326", "033822", 1), true},
+		{"empty", "", false},
+		{"truncated", coseP256[:len(coseP256)-20], false},
+		{"not a map", "5820" + coseX, false},
+		{"short x coordinate", "a501020326200121581f" + coseX[2:] + "225820" + coseY, false},
+		{"point not on curve", offCurve, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := extractP256FromCOSE(mustHex(t, tc.cose))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if got := errors.Is(err, ErrUnsupportedPasskey); got != tc.unsupported {
+				t.Fatalf("errors.Is(err, ErrUnsupportedPasskey) = %v, want %v (err: %v)", got, tc.unsupported, err)
+			}
+		})
+	}
+}
+
+func TestP256PublicKeyHex(t *testing.T) {
+	x, y, err := P256PublicKeyHex(mustHex(t, coseP256))
+	if err != nil {
+		t.Fatalf("P256PublicKeyHex: %v", err)
+	}
+	if x != "0x"+coseX || y != "0x"+coseY {
+		t.Fatalf("got (%s, %s)", x, y)
+	}
+}
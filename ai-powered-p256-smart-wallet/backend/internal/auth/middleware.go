@@ -1,12 +1,15 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // RequireAuth creates an authentication middleware that requires a valid session
+// The token is checked against the session store only; the user row is not loaded.
 func RequireAuth(sessionService *SessionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := c.GetHeader("X-Session-Token")
@@ -19,7 +22,14 @@ func RequireAuth(sessionService *SessionService) gin.HandlerFunc {
 			return
 		}
 
-		user, err := sessionService.GetUserBySession(token)
+		session, err := sessionService.ValidateSession(token)
+		if errors.Is(err, ErrSessionExpired) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "session expired",
+			})
+			c.Abort()
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "invalid or expired session",
@@ -28,9 +38,32 @@ func RequireAuth(sessionService *SessionService) gin.HandlerFunc {
 			return
 		}
 
-		// Store user in context
-		c.Set("user", user)
-		c.Set("userID", user.ID)
+		// Store the authenticated user's ID in context
+		c.Set("userID", session.UserID)
+
+		c.Next()
+	}
+}
+
+// RequireRole allows only users with role; it must run after RequireAuth
+// The role is read from the users table on every request, so revoking it takes effect immediately.
+func RequireRole(sessionService *SessionService, role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userRole, err := sessionService.UserRole(c.Request.Context(), c.GetString("userID"))
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to check permissions",
+			})
+			c.Abort()
+			return
+		}
+		if userRole != role {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "forbidden",
+			})
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
@@ -47,10 +80,8 @@ func OptionalAuth(sessionService *SessionService) gin.HandlerFunc {
 		token := c.GetHeader("X-Session-Token")
 
 		if token != "" {
-			user, err := sessionService.GetUserBySession(token)
-			if err == nil {
-				c.Set("user", user)
-				c.Set("userID", user.ID)
+			if session, err := sessionService.ValidateSession(token); err == nil {
+				c.Set("userID", session.UserID)
 			}
 		}
 
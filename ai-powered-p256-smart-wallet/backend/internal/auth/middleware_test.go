@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&models.User{}, &models.Session{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []models.User{{ID: "admin-1", Role: models.UserRoleAdmin}, {ID: "user-1"}} {
+		if err := db.Create(&u).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	sessions := NewSessionService(db)
+
+	router := gin.New()
+	router.GET("/admin", RequireAuth(sessions), RequireRole(sessions, models.UserRoleAdmin), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	get := func(userID string) int {
+		session, err := sessions.CreateSession(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("X-Session-Token", session.Token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := get("admin-1"); code != http.StatusOK {
+		t.Fatalf("admin: %d, want 200", code)
+	}
+	if code := get("user-1"); code != http.StatusForbidden {
+		t.Fatalf("user: %d, want 403", code)
+	}
+	if code := get("deleted-user"); code != http.StatusForbidden {
+		t.Fatalf("unknown user: %d, want 403", code)
+	}
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-webauthn/webauthn/protocol"
+)
+
+// ErrUserVerificationRequired is returned when an assertion lacks the UV flag
+// while the policy requires user verification
+var ErrUserVerificationRequired = errors.New("user verification required")
+
+// AuthenticatorPolicy controls which authenticators may register passkeys and
+// whether registrations and assertions must be user-verified
+type AuthenticatorPolicy struct {
+	Attachment       protocol.AuthenticatorAttachment     // empty allows any authenticator
+	UserVerification protocol.UserVerificationRequirement // required, preferred or discouraged
+	ResidentKey      protocol.ResidentKeyRequirement      // empty leaves the browser default
+}
+
+// DefaultAuthenticatorPolicy allows any authenticator and prefers user verification
+var DefaultAuthenticatorPolicy = AuthenticatorPolicy{
+	UserVerification: protocol.VerificationPreferred,
+}
+
+// ParseAuthenticatorPolicy builds a policy from WEBAUTHN_ATTACHMENT (platform/cross-platform/any),
+// WEBAUTHN_USER_VERIFICATION (required/preferred/discouraged) and
+// WEBAUTHN_RESIDENT_KEY (required/preferred/discouraged) values; empty values keep the defaults
+func ParseAuthenticatorPolicy(attachment, userVerification, residentKey string) (AuthenticatorPolicy, error) {
+	policy := DefaultAuthenticatorPolicy
+
+	switch v := strings.ToLower(strings.TrimSpace(attachment)); v {
+	case "", "any":
+	case string(protocol.Platform), string(protocol.CrossPlatform):
+		policy.Attachment = protocol.AuthenticatorAttachment(v)
+	default:
+		return policy, fmt.Errorf("invalid WEBAUTHN_ATTACHMENT %q: want platform, cross-platform or any", attachment)
+	}
+
+	switch v := strings.ToLower(strings.TrimSpace(userVerification)); v {
+	case "":
+	case string(protocol.VerificationRequired), string(protocol.VerificationPreferred), string(protocol.VerificationDiscouraged):
+		policy.UserVerification = protocol.UserVerificationRequirement(v)
+	default:
+		return policy, fmt.Errorf("invalid WEBAUTHN_USER_VERIFICATION %q: want required, preferred or discouraged", userVerification)
+	}
+
+	switch v := strings.ToLower(strings.TrimSpace(residentKey)); v {
+	case "":
+	case string(protocol.ResidentKeyRequirementRequired), string(protocol.ResidentKeyRequirementPreferred), string(protocol.ResidentKeyRequirementDiscouraged):
+		policy.ResidentKey = protocol.ResidentKeyRequirement(v)
+	default:
+		return policy, fmt.Errorf("invalid WEBAUTHN_RESIDENT_KEY %q: want required, preferred or discouraged", residentKey)
+	}
+
+	return policy, nil
+}
+
+// authenticatorSelection converts the policy into the default options for
+// credential creation; UserVerification also applies to assertion requests
+func (p AuthenticatorPolicy) authenticatorSelection() protocol.AuthenticatorSelection {
+	selection := protocol.AuthenticatorSelection{
+		AuthenticatorAttachment: p.Attachment,
+		ResidentKey:             p.ResidentKey,
+		UserVerification:        p.UserVerification,
+	}
+	// Level 1 browsers only understand requireResidentKey
+	switch p.ResidentKey {
+	case protocol.ResidentKeyRequirementRequired:
+		selection.RequireResidentKey = protocol.ResidentKeyRequired()
+	case protocol.ResidentKeyRequirementPreferred, protocol.ResidentKeyRequirementDiscouraged:
+		selection.RequireResidentKey = protocol.ResidentKeyNotRequired()
+	}
+	return selection
+}
+
+// RequiresUserVerification reports whether assertions must carry the UV flag
+func (p AuthenticatorPolicy) RequiresUserVerification() bool {
+	return p.UserVerification == protocol.VerificationRequired
+}
+
+// checkUserVerification enforces the UV flag when the policy requires it
+func (p AuthenticatorPolicy) checkUserVerification(flags protocol.AuthenticatorFlags) error {
+	if p.RequiresUserVerification() && !flags.UserVerified() {
+		return ErrUserVerificationRequired
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// stubUser is a webauthn.User that does not need the database
+type stubUser struct {
+	credentials []webauthn.Credential
+}
+
+func (u stubUser) WebAuthnID() []byte                         { return []byte("user-1") }
+func (u stubUser) WebAuthnName() string                       { return "alice" }
+func (u stubUser) WebAuthnDisplayName() string                { return "alice" }
+func (u stubUser) WebAuthnCredentials() []webauthn.Credential { return u.credentials }
+
+func TestParseAuthenticatorPolicy(t *testing.T) {
+	policy, err := ParseAuthenticatorPolicy("", "", "")
+	if err != nil || policy != DefaultAuthenticatorPolicy {
+		t.Fatalf("empty values = %+v, %v; want defaults", policy, err)
+	}
+
+	policy, err = ParseAuthenticatorPolicy(" Platform ", "required", "preferred")
+	if err != nil {
+		t.Fatalf("ParseAuthenticatorPolicy: %v", err)
+	}
+	want := AuthenticatorPolicy{
+		Attachment:       protocol.Platform,
+		UserVerification: protocol.VerificationRequired,
+		ResidentKey:      protocol.ResidentKeyRequirementPreferred,
+	}
+	if policy != want {
+		t.Fatalf("policy = %+v, want %+v", policy, want)
+	}
+
+	if policy, err := ParseAuthenticatorPolicy("any", "discouraged", ""); err != nil || policy.Attachment != "" {
+		t.Fatalf("any = %+v, %v; want no attachment", policy, err)
+	}
+
+	for _, values := range [][3]string{{"usb", "", ""}, {"", "always", ""}, {"", "", "yes"}} {
+		if _, err := ParseAuthenticatorPolicy(values[0], values[1], values[2]); err == nil {
+			t.Fatalf("ParseAuthenticatorPolicy(%q) succeeded, want an error", values)
+		}
+	}
+}
+
+func TestWebAuthnOptionsReflectPolicy(t *testing.T) {
+	policy := AuthenticatorPolicy{
+		Attachment:       protocol.Platform,
+		UserVerification: protocol.VerificationRequired,
+		ResidentKey:      protocol.ResidentKeyRequirementRequired,
+	}
+	s, err := NewWebAuthnService(nil, "example.com", "AI Wallet", "https://app.example.com", policy)
+	if err != nil {
+		t.Fatalf("NewWebAuthnService: %v", err)
+	}
+
+	creation, session, err := s.webAuthn.BeginRegistration(stubUser{})
+	if err != nil {
+		t.Fatalf("BeginRegistration: %v", err)
+	}
+	selection := creation.Response.AuthenticatorSelection
+	if selection.AuthenticatorAttachment != protocol.Platform {
+		t.Errorf("authenticatorAttachment = %q, want platform", selection.AuthenticatorAttachment)
+	}
+	if selection.UserVerification != protocol.VerificationRequired {
+		t.Errorf("userVerification = %q, want required", selection.UserVerification)
+	}
+	if selection.ResidentKey != protocol.ResidentKeyRequirementRequired || selection.RequireResidentKey == nil || !*selection.RequireResidentKey {
+		t.Errorf("residentKey = %q, requireResidentKey = %v; want required", selection.ResidentKey, selection.RequireResidentKey)
+	}
+	if session.UserVerification != protocol.VerificationRequired {
+		t.Errorf("registration session userVerification = %q, want required", session.UserVerification)
+	}
+
+	user := stubUser{credentials: []webauthn.Credential{{ID: []byte("credential-1")}}}
+	assertion, session, err := s.webAuthn.BeginLogin(user)
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	if assertion.Response.UserVerification != protocol.VerificationRequired {
+		t.Errorf("assertion userVerification = %q, want required", assertion.Response.UserVerification)
+	}
+	if session.UserVerification != protocol.VerificationRequired {
+		t.Errorf("login session userVerification = %q, want required", session.UserVerification)
+	}
+}
+
+func TestWebAuthnOptionsDefaultPolicy(t *testing.T) {
+	s, err := NewWebAuthnService(nil, "example.com", "AI Wallet", "https://app.example.com", DefaultAuthenticatorPolicy)
+	if err != nil {
+		t.Fatalf("NewWebAuthnService: %v", err)
+	}
+
+	creation, _, err := s.webAuthn.BeginRegistration(stubUser{})
+	if err != nil {
+		t.Fatalf("BeginRegistration: %v", err)
+	}
+	selection := creation.Response.AuthenticatorSelection
+	if selection.AuthenticatorAttachment != "" || selection.ResidentKey != "" || selection.RequireResidentKey != nil {
+		t.Errorf("default selection = %+v, want any authenticator and no resident key preference", selection)
+	}
+	if selection.UserVerification != protocol.VerificationPreferred {
+		t.Errorf("userVerification = %q, want preferred", selection.UserVerification)
+	}
+}
+
+func TestCheckUserVerification(t *testing.T) {
+	required := AuthenticatorPolicy{UserVerification: protocol.VerificationRequired}
+	preferred := AuthenticatorPolicy{UserVerification: protocol.VerificationPreferred}
+	presentOnly := protocol.FlagUserPresent
+	verified := protocol.FlagUserPresent | protocol.FlagUserVerified
+
+	if err := required.checkUserVerification(presentOnly); !errors.Is(err, ErrUserVerificationRequired) {
+		t.Fatalf("required without UV: err = %v, want ErrUserVerificationRequired", err)
+	}
+	if err := required.checkUserVerification(verified); err != nil {
+		t.Fatalf("required with UV: %v", err)
+	}
+	if err := preferred.checkUserVerification(presentOnly); err != nil {
+		t.Fatalf("preferred without UV: %v", err)
+	}
+}
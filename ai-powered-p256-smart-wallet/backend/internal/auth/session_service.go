@@ -3,61 +3,144 @@ package auth
 import (
 	"ai-wallet-backend/internal/models"
 	"ai-wallet-backend/pkg/crypto"
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// Session lifetimes
+const (
+	// DefaultSessionTTL is how long a session is valid after login or refresh
+	DefaultSessionTTL = 7 * 24 * time.Hour
+	// DefaultSessionMaxLifetime caps how long refreshes can keep a login alive
+	DefaultSessionMaxLifetime = 30 * 24 * time.Hour
+)
+
+// Session errors
+var (
+	ErrSessionNotFound        = errors.New("session not found")
+	ErrSessionExpired         = errors.New("session expired")
+	ErrSessionLifetimeReached = errors.New("session reached its maximum lifetime, please log in again")
+)
+
 // SessionService manages user sessions
 type SessionService struct {
-	db *gorm.DB
+	db          *gorm.DB     // users
+	store       SessionStore // sessions (the sessions table unless SetStore picks another)
+	ttl         time.Duration
+	maxLifetime time.Duration
 }
 
-// NewSessionService creates a new session service
+// NewSessionService creates a new session service that keeps sessions in the database
 func NewSessionService(db *gorm.DB) *SessionService {
-	return &SessionService{db: db}
+	return &SessionService{
+		db:          db,
+		store:       NewGormSessionStore(db),
+		ttl:         DefaultSessionTTL,
+		maxLifetime: DefaultSessionMaxLifetime,
+	}
+}
+
+// SetStore replaces where sessions are kept (e.g. a RedisSessionStore)
+func (s *SessionService) SetStore(store SessionStore) {
+	s.store = store
+}
+
+// SetLifetimes overrides the session TTL and the maximum lifetime reachable through refreshes
+func (s *SessionService) SetLifetimes(ttl, maxLifetime time.Duration) {
+	if ttl > 0 {
+		s.ttl = ttl
+	}
+	if maxLifetime > 0 {
+		s.maxLifetime = maxLifetime
+	}
 }
 
 // CreateSession creates a new session for a user
 func (s *SessionService) CreateSession(userID string) (*models.Session, error) {
+	now := time.Now()
+	session, err := newSession(userID, now, now.Add(s.ttl))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Create(context.Background(), session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	return session, nil
+}
+
+// newSession builds a session with a fresh random token
+func newSession(userID string, authenticatedAt, expiresAt time.Time) (*models.Session, error) {
 	token, err := crypto.GenerateRandomToken()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
-	session := &models.Session{
-		ID:        uuid.New().String(),
-		UserID:    userID,
-		Token:     token,
-		ExpiresAt: time.Now().Add(7 * 24 * time.Hour), // 7 days
-		CreatedAt: time.Now(),
+	return &models.Session{
+		ID:              uuid.New().String(),
+		UserID:          userID,
+		Token:           token,
+		ExpiresAt:       expiresAt,
+		AuthenticatedAt: authenticatedAt,
+		CreatedAt:       time.Now(),
+	}, nil
+}
+
+// ValidateSession validates a session token against the session store
+func (s *SessionService) ValidateSession(token string) (*models.Session, error) {
+	return s.store.Get(context.Background(), token)
+}
+
+// RefreshSession rotates a valid session's token and extends its expiry
+// The new expiry slides forward by the TTL but never past AuthenticatedAt + max lifetime;
+// the old token stops working immediately.
+func (s *SessionService) RefreshSession(token string) (*models.Session, error) {
+	ctx := context.Background()
+	session, err := s.store.Get(ctx, token)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.db.Create(session).Error; err != nil {
-		return nil, fmt.Errorf("failed to create session: %w", err)
+	// Sessions created before AuthenticatedAt existed count from their creation
+	authenticatedAt := session.AuthenticatedAt
+	if authenticatedAt.IsZero() {
+		authenticatedAt = session.CreatedAt
 	}
 
-	return session, nil
-}
+	expiresAt, err := refreshedExpiry(time.Now(), authenticatedAt, s.ttl, s.maxLifetime)
+	if err != nil {
+		return nil, err
+	}
 
-// ValidateSession validates a session token
-func (s *SessionService) ValidateSession(token string) (*models.Session, error) {
-	var session models.Session
-	if err := s.db.Where("token = ?", token).First(&session).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("session not found")
-		}
+	refreshed, err := newSession(session.UserID, authenticatedAt, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	// The store drops the old token atomically, so a concurrent refresh of it cannot also succeed
+	if err := s.store.Rotate(ctx, token, refreshed); err != nil {
 		return nil, err
 	}
+	return refreshed, nil
+}
 
-	if session.IsExpired() {
-		s.DeleteSession(session.ID)
-		return nil, fmt.Errorf("session expired")
+// refreshedExpiry returns now+ttl capped at authenticatedAt+maxLifetime,
+// or ErrSessionLifetimeReached if the cap has already passed
+func refreshedExpiry(now, authenticatedAt time.Time, ttl, maxLifetime time.Duration) (time.Time, error) {
+	limit := authenticatedAt.Add(maxLifetime)
+	if !now.Before(limit) {
+		return time.Time{}, ErrSessionLifetimeReached
 	}
 
-	return &session, nil
+	expiresAt := now.Add(ttl)
+	if expiresAt.After(limit) {
+		expiresAt = limit
+	}
+	return expiresAt, nil
 }
 
 // GetUserBySession gets user by session token
@@ -79,17 +162,44 @@ func (s *SessionService) GetUserBySession(token string) (*models.User, error) {
 	return &user, nil
 }
 
-// DeleteSession deletes a session
-func (s *SessionService) DeleteSession(sessionID string) error {
-	return s.db.Where("id = ?", sessionID).Delete(&models.Session{}).Error
+// UserRole returns the role of userID (models.UserRoleUser or models.UserRoleAdmin)
+func (s *SessionService) UserRole(ctx context.Context, userID string) (string, error) {
+	var user models.User
+	if err := s.db.WithContext(ctx).Select("role").Where("id = ?", userID).First(&user).Error; err != nil {
+		return "", err
+	}
+	return user.Role, nil
 }
 
 // DeleteSessionByToken deletes a session by token
 func (s *SessionService) DeleteSessionByToken(token string) error {
-	return s.db.Where("token = ?", token).Delete(&models.Session{}).Error
+	return s.store.Delete(context.Background(), token)
+}
+
+// CleanupExpiredSessions removes expired sessions and returns how many were deleted
+// (always 0 for stores that expire sessions themselves)
+func (s *SessionService) CleanupExpiredSessions() (int64, error) {
+	return s.store.DeleteExpired(context.Background(), time.Now())
 }
 
-// CleanupExpiredSessions removes expired sessions
-func (s *SessionService) CleanupExpiredSessions() {
-	s.db.Where("expires_at < ?", time.Now()).Delete(&models.Session{})
+// RunSessionSweeper periodically deletes expired sessions until ctx is cancelled
+func (s *SessionService) RunSessionSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.CleanupExpiredSessions()
+			if err != nil {
+				log.Printf("⚠️  Failed to sweep expired sessions: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("🧹 Swept %d expired sessions", deleted)
+			}
+		}
+	}
 }
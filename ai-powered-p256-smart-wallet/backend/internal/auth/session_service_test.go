@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRefreshedExpiry(t *testing.T) {
+	login := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ttl := 7 * 24 * time.Hour
+	maxLifetime := 30 * 24 * time.Hour
+
+	// Early refresh slides the expiry forward by the full TTL
+	now := login.Add(24 * time.Hour)
+	got, err := refreshedExpiry(now, login, ttl, maxLifetime)
+	if err != nil || !got.Equal(now.Add(ttl)) {
+		t.Fatalf("early refresh = %v, %v; want %v", got, err, now.Add(ttl))
+	}
+
+	// Near the cap the expiry is clamped to login + max lifetime
+	now = login.Add(28 * 24 * time.Hour)
+	got, err = refreshedExpiry(now, login, ttl, maxLifetime)
+	if err != nil || !got.Equal(login.Add(maxLifetime)) {
+		t.Fatalf("late refresh = %v, %v; want %v", got, err, login.Add(maxLifetime))
+	}
+
+	// Past the cap a new passkey login is required
+	if _, err := refreshedExpiry(login.Add(maxLifetime), login, ttl, maxLifetime); !errors.Is(err, ErrSessionLifetimeReached) {
+		t.Fatalf("err = %v, want ErrSessionLifetimeReached", err)
+	}
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"ai-wallet-backend/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session store backends selectable with SESSION_STORE
+const (
+	SessionStorePostgres = "postgres"
+	SessionStoreRedis    = "redis"
+)
+
+// SessionStore keeps sessions by token
+type SessionStore interface {
+	// Create stores a new session
+	Create(ctx context.Context, session *models.Session) error
+	// Get returns the session for token, ErrSessionNotFound for an unknown token and
+	// ErrSessionExpired for an expired one (stores that drop expired sessions themselves
+	// report ErrSessionNotFound instead)
+	Get(ctx context.Context, token string) (*models.Session, error)
+	// Delete removes the session for token, if any
+	Delete(ctx context.Context, token string) error
+	// Rotate replaces the session for oldToken with next. Only one of several concurrent
+	// rotations of the same token succeeds; the others get ErrSessionNotFound.
+	Rotate(ctx context.Context, oldToken string, next *models.Session) error
+	// DeleteExpired removes sessions that expired before now and returns how many were removed
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// NewSessionStore returns the store selected by kind (SESSION_STORE): "postgres" (the
+// default) keeps sessions in the sessions table, "redis" keeps them in Redis at redisURL
+func NewSessionStore(kind, redisURL string, db *gorm.DB) (SessionStore, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", SessionStorePostgres:
+		return NewGormSessionStore(db), nil
+	case SessionStoreRedis:
+		if redisURL == "" {
+			return nil, errors.New("SESSION_STORE=redis requires REDIS_URL")
+		}
+		return NewRedisSessionStoreFromURL(redisURL)
+	default:
+		return nil, fmt.Errorf("invalid SESSION_STORE %q: want postgres or redis", kind)
+	}
+}
+
+// GormSessionStore keeps sessions in the sessions table
+type GormSessionStore struct {
+	db *gorm.DB
+}
+
+// NewGormSessionStore creates a store backed by the sessions table
+func NewGormSessionStore(db *gorm.DB) *GormSessionStore {
+	return &GormSessionStore{db: db}
+}
+
+// Create inserts the session row
+func (s *GormSessionStore) Create(ctx context.Context, session *models.Session) error {
+	return s.db.WithContext(ctx).Create(session).Error
+}
+
+// Get loads the session row for token; an expired row is deleted
+func (s *GormSessionStore) Get(ctx context.Context, token string) (*models.Session, error) {
+	var session models.Session
+	if err := s.db.WithContext(ctx).Where("token = ?", token).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	if session.IsExpired() {
+		s.Delete(ctx, token)
+		return nil, ErrSessionExpired
+	}
+	return &session, nil
+}
+
+// Delete removes the session row for token
+func (s *GormSessionStore) Delete(ctx context.Context, token string) error {
+	return s.db.WithContext(ctx).Where("token = ?", token).Delete(&models.Session{}).Error
+}
+
+// Rotate deletes the old row and inserts next in one transaction
+func (s *GormSessionStore) Rotate(ctx context.Context, oldToken string, next *models.Session) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Delete first so a concurrent refresh of the same token cannot also succeed
+		result := tx.Where("token = ?", oldToken).Delete(&models.Session{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrSessionNotFound
+		}
+		return tx.Create(next).Error
+	})
+}
+
+// DeleteExpired removes rows that expired before now
+func (s *GormSessionStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&models.Session{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"ai-wallet-backend/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisSessionPrefix namespaces session keys: session:<token>
+const redisSessionPrefix = "session:"
+
+// RedisSessionStore keeps each session as a JSON value under session:<token> whose
+// Redis TTL is the session's expiry, so expired sessions disappear on their own
+type RedisSessionStore struct {
+	client *redis.Client
+}
+
+// NewRedisSessionStore creates a store on an existing Redis client
+func NewRedisSessionStore(client *redis.Client) *RedisSessionStore {
+	return &RedisSessionStore{client: client}
+}
+
+// NewRedisSessionStoreFromURL connects to Redis at url (redis://[:password@]host:port/db)
+func NewRedisSessionStoreFromURL(url string) (*RedisSessionStore, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+	client := redis.NewClient(options)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	return NewRedisSessionStore(client), nil
+}
+
+// Create stores the session until it expires
+func (s *RedisSessionStore) Create(ctx context.Context, session *models.Session) error {
+	ttl := time.Until(session.ExpiresAt)
+	if ttl <= 0 {
+		return ErrSessionExpired
+	}
+	value, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, redisSessionPrefix+session.Token, value, ttl).Err()
+}
+
+// Get loads the session for token
+func (s *RedisSessionStore) Get(ctx context.Context, token string) (*models.Session, error) {
+	value, err := s.client.Get(ctx, redisSessionPrefix+token).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeRedisSession(value)
+}
+
+// Delete removes the session for token
+func (s *RedisSessionStore) Delete(ctx context.Context, token string) error {
+	return s.client.Del(ctx, redisSessionPrefix+token).Err()
+}
+
+// Rotate takes the old session with GETDEL, so only one concurrent rotation sees it,
+// and stores next
+func (s *RedisSessionStore) Rotate(ctx context.Context, oldToken string, next *models.Session) error {
+	err := s.client.GetDel(ctx, redisSessionPrefix+oldToken).Err()
+	if errors.Is(err, redis.Nil) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return s.Create(ctx, next)
+}
+
+// DeleteExpired is a no-op: Redis drops sessions when their TTL runs out
+func (s *RedisSessionStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+// decodeRedisSession parses a stored session, reporting one past its expiry as expired
+// (the key's TTL and ExpiresAt can disagree by clock skew between servers)
+func decodeRedisSession(value []byte) (*models.Session, error) {
+	var session models.Session
+	if err := json.Unmarshal(value, &session); err != nil {
+		return nil, fmt.Errorf("invalid stored session: %w", err)
+	}
+	if session.IsExpired() {
+		return nil, ErrSessionExpired
+	}
+	return &session, nil
+}
@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedisStore(t *testing.T) (*RedisSessionStore, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisSessionStore(client), server
+}
+
+func testSession(t *testing.T, userID string, expiresIn time.Duration) *models.Session {
+	t.Helper()
+	session, err := newSession(userID, time.Now(), time.Now().Add(expiresIn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+// testSessionStoreHitMissRotate runs the behaviour both stores share
+func testSessionStoreHitMissRotate(t *testing.T, store SessionStore) {
+	ctx := context.Background()
+	session := testSession(t, "user-1", time.Hour)
+	if err := store.Create(ctx, session); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Hit
+	got, err := store.Get(ctx, session.Token)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.UserID != "user-1" || !got.ExpiresAt.Equal(session.ExpiresAt) || got.ID != session.ID {
+		t.Fatalf("Get = %+v, want %+v", got, session)
+	}
+
+	// Miss
+	if _, err := store.Get(ctx, "unknown-token"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("Get(unknown) err = %v, want ErrSessionNotFound", err)
+	}
+
+	// Rotate: the old token stops working, and a second rotation of it fails
+	next := testSession(t, "user-1", 2*time.Hour)
+	if err := store.Rotate(ctx, session.Token, next); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if _, err := store.Get(ctx, session.Token); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("Get(old token) err = %v, want ErrSessionNotFound", err)
+	}
+	if got, err := store.Get(ctx, next.Token); err != nil || got.UserID != "user-1" {
+		t.Fatalf("Get(new token) = %+v, %v", got, err)
+	}
+	if err := store.Rotate(ctx, session.Token, testSession(t, "user-1", time.Hour)); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("second Rotate err = %v, want ErrSessionNotFound", err)
+	}
+
+	// Delete
+	if err := store.Delete(ctx, next.Token); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, next.Token); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("Get(deleted) err = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestRedisSessionStore(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+	testSessionStoreHitMissRotate(t, store)
+}
+
+func TestRedisSessionStoreExpiry(t *testing.T) {
+	store, server := newTestRedisStore(t)
+	ctx := context.Background()
+
+	session := testSession(t, "user-1", time.Minute)
+	if err := store.Create(ctx, session); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if ttl := server.TTL(redisSessionPrefix + session.Token); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("key TTL = %v, want the session lifetime", ttl)
+	}
+
+	// Redis drops the key once the session expires
+	server.FastForward(2 * time.Minute)
+	if _, err := store.Get(ctx, session.Token); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("Get(expired) err = %v, want ErrSessionNotFound", err)
+	}
+
+	// A session already past its expiry is not stored
+	if err := store.Create(ctx, testSession(t, "user-1", -time.Second)); !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("Create(expired) err = %v, want ErrSessionExpired", err)
+	}
+
+	// A key that outlives ExpiresAt (clock skew) is still reported as expired
+	stale := testSession(t, "user-1", time.Hour)
+	if err := store.Create(ctx, stale); err != nil {
+		t.Fatal(err)
+	}
+	stale.ExpiresAt = time.Now().Add(-time.Second)
+	value, _ := json.Marshal(stale)
+	if err := store.client.Set(ctx, redisSessionPrefix+stale.Token, value, time.Hour).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, stale.Token); !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("Get(stale) err = %v, want ErrSessionExpired", err)
+	}
+}
+
+func TestGormSessionStore(t *testing.T) {
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&models.Session{}); err != nil {
+		t.Fatal(err)
+	}
+	testSessionStoreHitMissRotate(t, NewGormSessionStore(db))
+}
+
+func TestGormSessionStoreExpiry(t *testing.T) {
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&models.Session{}); err != nil {
+		t.Fatal(err)
+	}
+	store := NewGormSessionStore(db)
+	ctx := context.Background()
+
+	expired := testSession(t, "user-1", -time.Minute)
+	live := testSession(t, "user-2", time.Hour)
+	for _, session := range []*models.Session{expired, live} {
+		if err := store.Create(ctx, session); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	// An expired row is reported and removed
+	if _, err := store.Get(ctx, expired.Token); !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("Get(expired) err = %v, want ErrSessionExpired", err)
+	}
+	if _, err := store.Get(ctx, expired.Token); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("Get(expired again) err = %v, want ErrSessionNotFound", err)
+	}
+
+	// The sweeper only removes expired rows
+	if err := store.Create(ctx, testSession(t, "user-3", -time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if deleted, err := store.DeleteExpired(ctx, time.Now()); err != nil || deleted != 1 {
+		t.Fatalf("DeleteExpired = %d, %v; want 1", deleted, err)
+	}
+	if _, err := store.Get(ctx, live.Token); err != nil {
+		t.Fatalf("Get(live) after sweep: %v", err)
+	}
+}
+
+func TestRequireAuthWithRedisStore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store, server := newTestRedisStore(t)
+
+	// No database: validation must only touch the store
+	sessions := &SessionService{store: store, ttl: DefaultSessionTTL, maxLifetime: DefaultSessionMaxLifetime}
+	session, err := sessions.CreateSession("user-1")
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/me", RequireAuth(sessions), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("userID"))
+	})
+	get := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("X-Session-Token", token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := get(session.Token); w.Code != http.StatusOK || w.Body.String() != "user-1" {
+		t.Fatalf("valid token: %d %s", w.Code, w.Body.String())
+	}
+	if w := get("unknown-token"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("unknown token: %d, want 401", w.Code)
+	}
+
+	// Refresh rotates the token in Redis
+	refreshed, err := sessions.RefreshSession(session.Token)
+	if err != nil {
+		t.Fatalf("RefreshSession: %v", err)
+	}
+	if w := get(session.Token); w.Code != http.StatusUnauthorized {
+		t.Fatalf("old token after refresh: %d, want 401", w.Code)
+	}
+	if w := get(refreshed.Token); w.Code != http.StatusOK {
+		t.Fatalf("refreshed token: %d, want 200", w.Code)
+	}
+
+	server.FastForward(DefaultSessionTTL + time.Minute)
+	if w := get(refreshed.Token); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expired token: %d, want 401", w.Code)
+	}
+}
+
+func TestNewSessionStore(t *testing.T) {
+	if store, err := NewSessionStore("", "", nil); err != nil {
+		t.Fatalf("default: %v", err)
+	} else if _, ok := store.(*GormSessionStore); !ok {
+		t.Fatalf("default store = %T, want *GormSessionStore", store)
+	}
+
+	server := miniredis.RunT(t)
+	store, err := NewSessionStore("Redis", "redis://"+server.Addr()+"/0", nil)
+	if err != nil {
+		t.Fatalf("redis: %v", err)
+	}
+	if _, ok := store.(*RedisSessionStore); !ok {
+		t.Fatalf("redis store = %T, want *RedisSessionStore", store)
+	}
+
+	if _, err := NewSessionStore("redis", "", nil); err == nil {
+		t.Fatal("redis without REDIS_URL succeeded")
+	}
+	if _, err := NewSessionStore("memcached", "", nil); err == nil {
+		t.Fatal("unknown store succeeded")
+	}
+}
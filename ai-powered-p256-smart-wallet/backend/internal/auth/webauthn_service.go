@@ -2,8 +2,12 @@ package auth
 
 import (
 	"ai-wallet-backend/internal/models"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/go-webauthn/webauthn/protocol"
@@ -12,31 +16,91 @@ import (
 	"gorm.io/gorm"
 )
 
+// DefaultRPOrigin is used when RP_ORIGIN is empty
+const DefaultRPOrigin = "http://localhost:3000"
+
+// WebAuthn ceremony lifetimes
+const (
+	// DefaultCeremonyTimeout is the timeout sent in creation/request options: how long
+	// the browser waits for the authenticator
+	DefaultCeremonyTimeout = 60 * time.Second
+	// DefaultChallengeTTL is how long a stored challenge can be answered
+	DefaultChallengeTTL = 5 * time.Minute
+)
+
+// ErrOriginNotAllowed is returned when an assertion comes from an origin not in RP_ORIGIN
+var ErrOriginNotAllowed = errors.New("origin not allowed")
+
+// ErrChallengeExpired is returned when a registration or login is finished after its
+// challenge expired; the client has to begin the ceremony again
+var ErrChallengeExpired = errors.New("webauthn challenge expired")
+
 // WebAuthnService handles Passkey authentication
 type WebAuthnService struct {
-	webAuthn *webauthn.WebAuthn
-	db       *gorm.DB
+	webAuthn     *webauthn.WebAuthn
+	db           *gorm.DB
+	origins      []string
+	policy       AuthenticatorPolicy
+	challengeTTL time.Duration
+}
+
+// ParseRPOrigins splits a comma-separated RP_ORIGIN value into origins
+// Trailing slashes are dropped since browsers report origins without them
+func ParseRPOrigins(value string) []string {
+	var origins []string
+	for _, origin := range strings.Split(value, ",") {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	if len(origins) == 0 {
+		origins = []string{DefaultRPOrigin}
+	}
+	return origins
+}
+
+// originAllowed reports whether origin exactly matches one of the allowed origins
+func originAllowed(allowed []string, origin string) bool {
+	for _, o := range allowed {
+		if o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOrigin validates the origin recorded in the client data against the allowed set
+func (s *WebAuthnService) checkOrigin(origin string) error {
+	if !originAllowed(s.origins, origin) {
+		return fmt.Errorf("%w: %s", ErrOriginNotAllowed, origin)
+	}
+	return nil
+}
+
+// Origins returns the allowed RP origins
+func (s *WebAuthnService) Origins() []string {
+	return s.origins
+}
+
+// Policy returns the authenticator attachment and user-verification policy
+func (s *WebAuthnService) Policy() AuthenticatorPolicy {
+	return s.policy
 }
 
 // NewWebAuthnService creates a new WebAuthn service
-func NewWebAuthnService(db *gorm.DB, rpID, rpName, rpOrigin string) (*WebAuthnService, error) {
+// rpOrigins is a comma-separated list of allowed origins (e.g. production and staging)
+// policy sets the authenticator selection of registration options and the user
+// verification requested (and, if required, enforced) for registrations and assertions
+func NewWebAuthnService(db *gorm.DB, rpID, rpName, rpOrigins string, policy AuthenticatorPolicy) (*WebAuthnService, error) {
+	origins := ParseRPOrigins(rpOrigins)
+
 	wconfig := &webauthn.Config{
-		RPDisplayName: rpName,
-		RPID:          rpID,
-		RPOrigins:     []string{rpOrigin, "http://localhost:3001", "http://localhost:8080"},
-		// Timeout for registration/login (60 seconds)
-		Timeouts: webauthn.TimeoutsConfig{
-			Login: webauthn.TimeoutConfig{
-				Enforce:    true,
-				Timeout:    time.Second * 60,
-				TimeoutUVD: time.Second * 60,
-			},
-			Registration: webauthn.TimeoutConfig{
-				Enforce:    true,
-				Timeout:    time.Second * 60,
-				TimeoutUVD: time.Second * 60,
-			},
-		},
+		RPDisplayName:          rpName,
+		RPID:                   rpID,
+		RPOrigins:              origins,
+		AuthenticatorSelection: policy.authenticatorSelection(),
+		Timeouts:               ceremonyTimeouts(DefaultCeremonyTimeout),
 	}
 
 	wa, err := webauthn.New(wconfig)
@@ -45,11 +109,43 @@ func NewWebAuthnService(db *gorm.DB, rpID, rpName, rpOrigin string) (*WebAuthnSe
 	}
 
 	return &WebAuthnService{
-		webAuthn: wa,
-		db:       db,
+		webAuthn:     wa,
+		db:           db,
+		origins:      origins,
+		policy:       policy,
+		challengeTTL: DefaultChallengeTTL,
 	}, nil
 }
 
+// ceremonyTimeouts enforces the same timeout for registration and login
+func ceremonyTimeouts(timeout time.Duration) webauthn.TimeoutsConfig {
+	config := webauthn.TimeoutConfig{Enforce: true, Timeout: timeout, TimeoutUVD: timeout}
+	return webauthn.TimeoutsConfig{Login: config, Registration: config}
+}
+
+// SetTimeouts overrides the ceremony timeout advertised in creation/request options and
+// the challenge TTL; zero keeps the current value. The TTL never drops below the ceremony
+// timeout, so a challenge cannot expire while the browser is still waiting on the user.
+func (s *WebAuthnService) SetTimeouts(ceremonyTimeout, challengeTTL time.Duration) {
+	if ceremonyTimeout > 0 {
+		s.webAuthn.Config.Timeouts = ceremonyTimeouts(ceremonyTimeout)
+	}
+	if challengeTTL > 0 {
+		s.challengeTTL = challengeTTL
+	}
+	s.challengeTTL = max(s.challengeTTL, s.webAuthn.Config.Timeouts.Registration.Timeout)
+}
+
+// CeremonyTimeout returns the timeout advertised in creation/request options
+func (s *WebAuthnService) CeremonyTimeout() time.Duration {
+	return s.webAuthn.Config.Timeouts.Registration.Timeout
+}
+
+// ChallengeTTL returns how long a stored challenge can be answered
+func (s *WebAuthnService) ChallengeTTL() time.Duration {
+	return s.challengeTTL
+}
+
 // BeginRegistration starts the registration process
 func (s *WebAuthnService) BeginRegistration(user *models.User) (*protocol.CredentialCreation, string, error) {
 	// Wrap user to implement webauthn.User interface
@@ -76,9 +172,16 @@ func (s *WebAuthnService) BeginRegistration(user *models.User) (*protocol.Creden
 // FinishRegistration completes the registration and returns the credential to be saved
 // The caller is responsible for saving the credential within their transaction
 func (s *WebAuthnService) FinishRegistration(user *models.User, sessionID string, response *protocol.ParsedCredentialCreationData) (*models.PasskeyCredential, error) {
+	if err := s.checkOrigin(response.Response.CollectedClientData.Origin); err != nil {
+		return nil, err
+	}
+
 	// Retrieve session
 	session, err := s.getSession(sessionID)
 	if err != nil {
+		if errors.Is(err, ErrChallengeExpired) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
 
@@ -91,6 +194,14 @@ func (s *WebAuthnService) FinishRegistration(user *models.User, sessionID string
 	if err != nil {
 		return nil, fmt.Errorf("failed to create credential: %w", err)
 	}
+	if err := s.policy.checkUserVerification(response.Response.AttestationObject.AuthData.Flags); err != nil {
+		return nil, err
+	}
+
+	// The wallet contract only verifies P-256 signatures; reject other keys before anything is saved
+	if _, _, err := extractP256FromCOSE(credential.PublicKey); err != nil {
+		return nil, err
+	}
 
 	// Create credential object (but don't save it - let caller do that in their transaction)
 	passkeyCredential := &models.PasskeyCredential{
@@ -100,9 +211,11 @@ func (s *WebAuthnService) FinishRegistration(user *models.User, sessionID string
 		PublicKey:      credential.PublicKey,
 		SignCount:      credential.Authenticator.SignCount,
 		AAGUID:         credential.Authenticator.AAGUID,
+		Nickname:       "Passkey",
 		BackupEligible: credential.Flags.BackupEligible,
 		BackupState:    credential.Flags.BackupState,
 		CreatedAt:      time.Now(),
+		CreatedBy:      user.ID,
 		LastUsedAt:     time.Now(),
 	}
 
@@ -135,9 +248,16 @@ func (s *WebAuthnService) BeginLogin(user *models.User) (*protocol.CredentialAss
 
 // FinishLogin completes the login process
 func (s *WebAuthnService) FinishLogin(user *models.User, sessionID string, response *protocol.ParsedCredentialAssertionData) error {
+	if err := s.checkOrigin(response.Response.CollectedClientData.Origin); err != nil {
+		return err
+	}
+
 	// Retrieve session
 	session, err := s.getSession(sessionID)
 	if err != nil {
+		if errors.Is(err, ErrChallengeExpired) {
+			return err
+		}
 		return fmt.Errorf("failed to get session: %w", err)
 	}
 
@@ -150,13 +270,13 @@ func (s *WebAuthnService) FinishLogin(user *models.User, sessionID string, respo
 	if err != nil {
 		return fmt.Errorf("failed to validate login: %w", err)
 	}
+	if err := s.policy.checkUserVerification(response.Response.AuthenticatorData.Flags); err != nil {
+		return err
+	}
 
-	// Update credential sign count and last used time
-	var credential models.PasskeyCredential
-	if err := s.db.Where("credential_id = ?", response.RawID).First(&credential).Error; err == nil {
-		credential.SignCount = response.Response.AuthenticatorData.Counter
-		credential.LastUsedAt = time.Now()
-		s.db.Save(&credential)
+	// Verify and persist the authenticator's signature counter
+	if err := s.updateSignCount(response.RawID, response.Response.AuthenticatorData.Counter); err != nil {
+		return err
 	}
 
 	// Delete temporary session
@@ -165,6 +285,54 @@ func (s *WebAuthnService) FinishLogin(user *models.User, sessionID string, respo
 	return nil
 }
 
+// ErrSignCountRegression means the assertion's signCount did not increase,
+// which indicates a cloned authenticator
+var ErrSignCountRegression = errors.New("authenticator sign count did not increase, possible cloned credential")
+
+// checkSignCount compares a reported signCount against the stored one
+// A reported value of 0 means the authenticator does not implement a counter
+func checkSignCount(stored, reported uint32) error {
+	if reported == 0 {
+		return nil
+	}
+	if reported <= stored {
+		return fmt.Errorf("%w (stored %d, reported %d)", ErrSignCountRegression, stored, reported)
+	}
+	return nil
+}
+
+// updateSignCount checks the reported signCount and stores it with the last used time
+// The counter is only advanced if it is still below the reported value, so two
+// concurrent assertions with the same counter cannot both succeed.
+func (s *WebAuthnService) updateSignCount(credentialID []byte, reported uint32) error {
+	var credential models.PasskeyCredential
+	if err := s.db.Where("credential_id = ?", credentialID).First(&credential).Error; err != nil {
+		return fmt.Errorf("failed to load credential: %w", err)
+	}
+
+	if err := checkSignCount(credential.SignCount, reported); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if reported == 0 {
+		return s.db.Model(&models.PasskeyCredential{}).
+			Where("id = ?", credential.ID).
+			Update("last_used_at", now).Error
+	}
+
+	result := s.db.Model(&models.PasskeyCredential{}).
+		Where("id = ? AND sign_count < ?", credential.ID, reported).
+		Updates(map[string]interface{}{"sign_count": reported, "last_used_at": now})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update sign count: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w (concurrent assertion with counter %d)", ErrSignCountRegression, reported)
+	}
+	return nil
+}
+
 // storeSession stores a WebAuthn session temporarily
 func (s *WebAuthnService) storeSession(userID string, session *webauthn.SessionData) (string, error) {
 	sessionID := uuid.New().String()
@@ -180,7 +348,7 @@ func (s *WebAuthnService) storeSession(userID string, session *webauthn.SessionD
 		UserID:      userID,
 		Challenge:   []byte(session.Challenge),
 		SessionData: sessionBytes,
-		ExpiresAt:   time.Now().Add(5 * time.Minute),
+		ExpiresAt:   time.Now().Add(s.challengeTTL),
 		CreatedAt:   time.Now(),
 	}
 
@@ -198,17 +366,28 @@ func (s *WebAuthnService) getSession(sessionID string) (*webauthn.SessionData, e
 		return nil, err
 	}
 
-	// Check expiration
-	if webAuthnSession.IsExpired() {
+	session, err := decodeSession(&webAuthnSession, time.Now())
+	if errors.Is(err, ErrChallengeExpired) {
+		// An expired challenge can never be answered; drop it so it cannot be replayed
 		s.deleteSession(sessionID)
-		return nil, fmt.Errorf("session expired")
+	}
+	return session, err
+}
+
+// decodeSession deserializes a stored session, rejecting it once either the stored
+// challenge TTL or the ceremony timeout recorded by the library has passed
+func decodeSession(webAuthnSession *models.WebAuthnSession, now time.Time) (*webauthn.SessionData, error) {
+	if now.After(webAuthnSession.ExpiresAt) {
+		return nil, fmt.Errorf("%w at %s", ErrChallengeExpired, webAuthnSession.ExpiresAt.Format(time.RFC3339))
 	}
 
-	// Deserialize session data
 	var session webauthn.SessionData
 	if err := json.Unmarshal(webAuthnSession.SessionData, &session); err != nil {
 		return nil, err
 	}
+	if !session.Expires.IsZero() && now.After(session.Expires) {
+		return nil, fmt.Errorf("%w at %s", ErrChallengeExpired, session.Expires.Format(time.RFC3339))
+	}
 
 	return &session, nil
 }
@@ -218,9 +397,32 @@ func (s *WebAuthnService) deleteSession(sessionID string) {
 	s.db.Where("id = ?", sessionID).Delete(&models.WebAuthnSession{})
 }
 
-// CleanupExpiredSessions removes expired WebAuthn sessions
-func (s *WebAuthnService) CleanupExpiredSessions() {
-	s.db.Where("expires_at < ?", time.Now()).Delete(&models.WebAuthnSession{})
+// CleanupExpiredSessions removes expired WebAuthn sessions and returns how many were deleted
+func (s *WebAuthnService) CleanupExpiredSessions() (int64, error) {
+	result := s.db.Where("expires_at < ?", time.Now()).Delete(&models.WebAuthnSession{})
+	return result.RowsAffected, result.Error
+}
+
+// RunChallengeSweeper periodically deletes expired WebAuthn sessions until ctx is cancelled
+func (s *WebAuthnService) RunChallengeSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.CleanupExpiredSessions()
+			if err != nil {
+				log.Printf("⚠️  Failed to sweep expired WebAuthn challenges: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("🧹 Swept %d expired WebAuthn challenges", deleted)
+			}
+		}
+	}
 }
 
 // WebAuthnUser implements the webauthn.User interface
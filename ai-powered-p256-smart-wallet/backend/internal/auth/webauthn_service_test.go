@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+func TestCheckSignCount(t *testing.T) {
+	cases := []struct {
+		name     string
+		stored   uint32
+		reported uint32
+		wantErr  bool
+	}{
+		{"first use", 0, 1, false},
+		{"increment", 5, 6, false},
+		{"jump", 5, 100, false},
+		{"no counter", 0, 0, false},
+		{"counter reset to zero", 7, 0, false},
+		{"replayed counter", 5, 5, true},
+		{"cloned authenticator", 5, 3, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkSignCount(tc.stored, tc.reported)
+			if tc.wantErr {
+				if !errors.Is(err, ErrSignCountRegression) {
+					t.Fatalf("err = %v, want ErrSignCountRegression", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseRPOrigins(t *testing.T) {
+	got := ParseRPOrigins(" https://app.example.com/ , https://staging.example.com,,")
+	want := []string{"https://app.example.com", "https://staging.example.com"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("ParseRPOrigins = %v, want %v", got, want)
+	}
+
+	if got := ParseRPOrigins(""); len(got) != 1 || got[0] != DefaultRPOrigin {
+		t.Fatalf("ParseRPOrigins(\"\") = %v, want [%s]", got, DefaultRPOrigin)
+	}
+}
+
+func TestAssertionOriginValidation(t *testing.T) {
+	s, err := NewWebAuthnService(nil, "example.com", "AI Wallet", "https://app.example.com,https://staging.example.com", DefaultAuthenticatorPolicy)
+	if err != nil {
+		t.Fatalf("NewWebAuthnService: %v", err)
+	}
+
+	for _, origin := range []string{"https://app.example.com", "https://staging.example.com"} {
+		if err := s.checkOrigin(origin); err != nil {
+			t.Fatalf("origin %s rejected: %v", origin, err)
+		}
+	}
+
+	// An unlisted origin is rejected before the session is even loaded
+	assertion := &protocol.ParsedCredentialAssertionData{}
+	assertion.Response.CollectedClientData.Origin = "https://evil.example.com"
+	if err := s.FinishLogin(nil, "session", assertion); !errors.Is(err, ErrOriginNotAllowed) {
+		t.Fatalf("err = %v, want ErrOriginNotAllowed", err)
+	}
+
+	// Origins must match exactly (scheme and port included)
+	if err := s.checkOrigin("http://app.example.com"); !errors.Is(err, ErrOriginNotAllowed) {
+		t.Fatalf("err = %v, want ErrOriginNotAllowed", err)
+	}
+}
+
+func TestSetTimeouts(t *testing.T) {
+	s, err := NewWebAuthnService(nil, "example.com", "AI Wallet", "https://app.example.com", DefaultAuthenticatorPolicy)
+	if err != nil {
+		t.Fatalf("NewWebAuthnService: %v", err)
+	}
+	if s.CeremonyTimeout() != DefaultCeremonyTimeout || s.ChallengeTTL() != DefaultChallengeTTL {
+		t.Fatalf("defaults = %s, %s", s.CeremonyTimeout(), s.ChallengeTTL())
+	}
+
+	s.SetTimeouts(2*time.Minute, 0)
+	login := s.webAuthn.Config.Timeouts.Login
+	if !login.Enforce || login.Timeout != 2*time.Minute || login.TimeoutUVD != 2*time.Minute {
+		t.Fatalf("login timeout = %+v", login)
+	}
+	if s.ChallengeTTL() != DefaultChallengeTTL {
+		t.Fatalf("challenge TTL = %s, want unchanged", s.ChallengeTTL())
+	}
+
+	// A TTL shorter than the ceremony would expire challenges the user is still answering
+	s.SetTimeouts(0, 30*time.Second)
+	if s.ChallengeTTL() != 2*time.Minute {
+		t.Fatalf("challenge TTL = %s, want the ceremony timeout", s.ChallengeTTL())
+	}
+}
+
+func TestDecodeSessionRejectsExpiredChallenge(t *testing.T) {
+	now := time.Now()
+	stored := func(expiresAt, ceremonyExpires time.Time) *models.WebAuthnSession {
+		data, err := json.Marshal(webauthn.SessionData{Challenge: "challenge", Expires: ceremonyExpires})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &models.WebAuthnSession{ID: "session", SessionData: data, ExpiresAt: expiresAt}
+	}
+
+	session, err := decodeSession(stored(now.Add(time.Minute), now.Add(time.Minute)), now)
+	if err != nil || session.Challenge != "challenge" {
+		t.Fatalf("live challenge: %v, %v", session, err)
+	}
+	if _, err := decodeSession(stored(now.Add(-time.Second), now.Add(time.Minute)), now); !errors.Is(err, ErrChallengeExpired) {
+		t.Fatalf("challenge past its TTL: err = %v, want ErrChallengeExpired", err)
+	}
+	if _, err := decodeSession(stored(now.Add(time.Minute), now.Add(-time.Second)), now); !errors.Is(err, ErrChallengeExpired) {
+		t.Fatalf("challenge past the ceremony timeout: err = %v, want ErrChallengeExpired", err)
+	}
+}
+
+func TestFinishRegistrationRejectsExpiredChallenge(t *testing.T) {
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&models.User{}, &models.PasskeyCredential{}, &models.WebAuthnSession{}); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewWebAuthnService(db, "example.com", "AI Wallet", "https://app.example.com", DefaultAuthenticatorPolicy)
+	if err != nil {
+		t.Fatalf("NewWebAuthnService: %v", err)
+	}
+	s.SetTimeouts(30*time.Second, time.Minute)
+
+	user := &models.User{ID: "user-1", Username: "alice"}
+	options, sessionID, err := s.BeginRegistration(user)
+	if err != nil {
+		t.Fatalf("BeginRegistration: %v", err)
+	}
+	if options.Response.Timeout != 30000 {
+		t.Fatalf("options timeout = %d ms, want 30000", options.Response.Timeout)
+	}
+
+	// The user took longer than the challenge TTL
+	if err := db.Model(&models.WebAuthnSession{}).Where("id = ?", sessionID).
+		Update("expires_at", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+	response := &protocol.ParsedCredentialCreationData{}
+	response.Response.CollectedClientData.Origin = "https://app.example.com"
+	if _, err := s.FinishRegistration(user, sessionID, response); !errors.Is(err, ErrChallengeExpired) {
+		t.Fatalf("err = %v, want ErrChallengeExpired", err)
+	}
+
+	// The expired challenge is gone, so it cannot be answered later
+	var count int64
+	db.Model(&models.WebAuthnSession{}).Where("id = ?", sessionID).Count(&count)
+	if count != 0 {
+		t.Fatalf("expired challenge still stored")
+	}
+}
+
+func TestCleanupExpiredWebAuthnSessions(t *testing.T) {
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&models.WebAuthnSession{}); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewWebAuthnService(db, "example.com", "AI Wallet", "https://app.example.com", DefaultAuthenticatorPolicy)
+	if err != nil {
+		t.Fatalf("NewWebAuthnService: %v", err)
+	}
+	now := time.Now()
+	for id, expiresAt := range map[string]time.Time{"stale": now.Add(-time.Minute), "live": now.Add(time.Minute)} {
+		if err := db.Create(&models.WebAuthnSession{ID: id, ExpiresAt: expiresAt, CreatedAt: now}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := s.CleanupExpiredSessions()
+	if err != nil || deleted != 1 {
+		t.Fatalf("CleanupExpiredSessions = %d, %v", deleted, err)
+	}
+	var remaining []models.WebAuthnSession
+	db.Find(&remaining)
+	if len(remaining) != 1 || remaining[0].ID != "live" {
+		t.Fatalf("remaining = %+v", remaining)
+	}
+}
@@ -24,12 +24,15 @@ func NewTransactionService() (*TransactionService, error) {
 	clients := make(map[int64]*ethclient.Client)
 	
 	// Initialize clients for all supported chains
-	for chainID, config := range SupportedChains {
-		client, err := ethclient.Dial(config.RpcURL)
+	for _, config := range GetAllChains() {
+		if config.RPCDefault == "" {
+			continue
+		}
+		client, err := ethclient.Dial(config.RPCDefault)
 		if err != nil {
-			return nil, fmt.Errorf("failed to connect to chain %d: %w", chainID, err)
+			return nil, fmt.Errorf("failed to connect to chain %d: %w", config.ChainID, err)
 		}
-		clients[chainID] = client
+		clients[config.ChainID] = client
 	}
 	
 	return &TransactionService{
@@ -0,0 +1,166 @@
+// Package bodylimit caps the size and JSON nesting depth of request bodies per route,
+// so a handler never reads (or decodes) an arbitrarily large or deep body
+package bodylimit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultMaxDepth is the deepest JSON nesting accepted when BODY_MAX_JSON_DEPTH is not set
+const DefaultMaxDepth = 32
+
+// DefaultLimits are the per-route body limits in bytes used when no BODY_LIMIT_<ROUTE>
+// env var is set; routes without an entry use the "default" limit
+var DefaultLimits = map[string]int64{
+	"default":  64 << 10,
+	"chat":     256 << 10, // the message plus the client-side history
+	"transfer": 16 << 10,  // a batch of transfers or a signed UserOp
+	"skills":   64 << 10,
+	"passkey":  64 << 10, // attestations may carry certificate chains
+}
+
+// Limiter holds the per-route body limits
+type Limiter struct {
+	limits   map[string]int64
+	maxDepth int
+}
+
+// New creates a limiter with the given per-route limits (bytes) and maximum JSON depth
+func New(limits map[string]int64, maxDepth int) *Limiter {
+	return &Limiter{limits: limits, maxDepth: maxDepth}
+}
+
+// NewFromEnv creates a limiter from DefaultLimits, overridden by BODY_LIMIT_<ROUTE> env vars
+// in bytes (e.g. BODY_LIMIT_CHAT=262144, "0" disables), and BODY_MAX_JSON_DEPTH
+func NewFromEnv() *Limiter {
+	limits := make(map[string]int64, len(DefaultLimits))
+	for route, limit := range DefaultLimits {
+		limits[route] = limit
+		value := os.Getenv("BODY_LIMIT_" + strings.ToUpper(route))
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil || parsed < 0 {
+			log.Printf("⚠️  Ignoring BODY_LIMIT_%s: invalid byte count %q", strings.ToUpper(route), value)
+			continue
+		}
+		limits[route] = parsed
+	}
+
+	maxDepth := DefaultMaxDepth
+	if value := os.Getenv("BODY_MAX_JSON_DEPTH"); value != "" {
+		parsed, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || parsed < 0 {
+			log.Printf("⚠️  Ignoring BODY_MAX_JSON_DEPTH: invalid depth %q", value)
+		} else {
+			maxDepth = parsed
+		}
+	}
+	return New(limits, maxDepth)
+}
+
+// Limit returns the body limit in bytes for route; 0 means unlimited
+func (l *Limiter) Limit(route string) int64 {
+	if limit, ok := l.limits[route]; ok {
+		return limit
+	}
+	return l.limits["default"]
+}
+
+// Middleware rejects bodies larger than the route's limit with 413 and bodies nested deeper
+// than the maximum JSON depth with 400, before the handler runs. Accepted bodies are
+// buffered and handed to the handler unchanged.
+func (l *Limiter) Middleware(route string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := l.Limit(route)
+		if c.Request.Body == nil || c.Request.Body == http.NoBody || (limit <= 0 && l.maxDepth <= 0) {
+			c.Next()
+			return
+		}
+
+		// A declared length over the limit is rejected without reading the body
+		if limit > 0 && c.Request.ContentLength > limit {
+			tooLarge(c, limit)
+			return
+		}
+
+		var body []byte
+		var err error
+		if limit > 0 {
+			body, err = io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, limit))
+		} else {
+			body, err = io.ReadAll(c.Request.Body)
+		}
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			tooLarge(c, limit)
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			return
+		}
+
+		if l.maxDepth > 0 && jsonDepthExceeds(body, l.maxDepth) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":    "request body is nested too deeply",
+				"maxDepth": l.maxDepth,
+			})
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+// tooLarge aborts with 413 and the route's limit
+func tooLarge(c *gin.Context, limit int64) {
+	c.Header("Connection", "close")
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":    fmt.Sprintf("request body exceeds %d bytes", limit),
+		"maxBytes": limit,
+	})
+}
+
+// jsonDepthExceeds reports whether objects and arrays in body nest deeper than maxDepth.
+// It only tracks brackets outside strings, so malformed JSON is left for the handler's
+// decoder to reject.
+func jsonDepthExceeds(body []byte, maxDepth int) bool {
+	depth := 0
+	inString, escaped := false, false
+	for _, b := range body {
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			switch b {
+			case '\\':
+				escaped = true
+			case '"':
+				inString = false
+			}
+		case b == '"':
+			inString = true
+		case b == '{' || b == '[':
+			depth++
+			if depth > maxDepth {
+				return true
+			}
+		case b == '}' || b == ']':
+			depth--
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package bodylimit
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter serves POST /test behind the "test" limit; ran reports whether the
+// handler was reached and body is what it read
+func newTestRouter(l *Limiter) (router *gin.Engine, ran *bool, body *string) {
+	gin.SetMode(gin.TestMode)
+	ran, body = new(bool), new(string)
+	router = gin.New()
+	router.POST("/test", l.Middleware("test"), func(c *gin.Context) {
+		*ran = true
+		data, _ := io.ReadAll(c.Request.Body)
+		*body = string(data)
+		c.Status(http.StatusOK)
+	})
+	return router, ran, body
+}
+
+func TestMiddlewareRejectsOversizedBody(t *testing.T) {
+	router, ran, _ := newTestRouter(New(map[string]int64{"test": 100}, DefaultMaxDepth))
+	oversized := `{"message":"` + strings.Repeat("a", 200) + `"}`
+
+	// Declared Content-Length over the limit
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(oversized)))
+	if w.Code != http.StatusRequestEntityTooLarge || *ran {
+		t.Fatalf("got %d (handler ran: %v), want 413 without running the handler", w.Code, *ran)
+	}
+
+	// Unknown length (chunked upload): the body is cut off while reading
+	req := httptest.NewRequest(http.MethodPost, "/test", io.NopCloser(strings.NewReader(oversized)))
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge || *ran {
+		t.Fatalf("chunked: got %d (handler ran: %v), want 413 without running the handler", w.Code, *ran)
+	}
+	if !strings.Contains(w.Body.String(), "exceeds 100 bytes") {
+		t.Fatalf("body = %s", w.Body.String())
+	}
+}
+
+func TestMiddlewarePassesBodyWithinLimit(t *testing.T) {
+	router, ran, body := newTestRouter(New(map[string]int64{"test": 100}, DefaultMaxDepth))
+	payload := `{"recipient":"0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1","amount":"1"}`
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(payload)))
+	if w.Code != http.StatusOK || !*ran || *body != payload {
+		t.Fatalf("got %d, handler ran: %v, body %q", w.Code, *ran, *body)
+	}
+}
+
+func TestMiddlewareRejectsDeepJSON(t *testing.T) {
+	router, ran, _ := newTestRouter(New(map[string]int64{"test": 1 << 20}, 8))
+
+	deep := strings.Repeat("[", 9) + strings.Repeat("]", 9)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(deep)))
+	if w.Code != http.StatusBadRequest || *ran {
+		t.Fatalf("got %d (handler ran: %v), want 400 without running the handler", w.Code, *ran)
+	}
+
+	// Brackets inside strings do not count
+	shallow := `{"a":{"b":["[[[[[[[[[[\"{{{{{{{{"]}}`
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(shallow)))
+	if w.Code != http.StatusOK || !*ran {
+		t.Fatalf("shallow: got %d (handler ran: %v), want 200", w.Code, *ran)
+	}
+}
+
+func TestLimitFallsBackToDefault(t *testing.T) {
+	l := New(map[string]int64{"default": 10, "chat": 20}, DefaultMaxDepth)
+	if l.Limit("chat") != 20 || l.Limit("unknown") != 10 {
+		t.Fatalf("Limit(chat) = %d, Limit(unknown) = %d", l.Limit("chat"), l.Limit("unknown"))
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("BODY_LIMIT_CHAT", "1024")
+	t.Setenv("BODY_LIMIT_TRANSFER", "lots")
+	t.Setenv("BODY_MAX_JSON_DEPTH", "4")
+
+	l := NewFromEnv()
+	if l.Limit("chat") != 1024 {
+		t.Errorf("chat limit = %d, want 1024", l.Limit("chat"))
+	}
+	if l.Limit("transfer") != DefaultLimits["transfer"] {
+		t.Errorf("invalid override: transfer limit = %d, want the default", l.Limit("transfer"))
+	}
+	if l.maxDepth != 4 {
+		t.Errorf("maxDepth = %d, want 4", l.maxDepth)
+	}
+}
@@ -0,0 +1,264 @@
+// Package chains is the registry of networks the backend knows about: display
+// names, native currency, a default public RPC and the block explorer.
+// Built-in entries can be overridden or extended with CHAINS_CONFIG.
+package chains
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultChainID is used when CHAIN_ID is not set (HashKey Chain Testnet)
+const DefaultChainID = 133
+
+// Chain represents a blockchain network configuration
+type Chain struct {
+	ChainID     int64  `json:"chainId"`
+	Name        string `json:"name"`
+	ShortName   string `json:"shortName"`
+	NativeCoin  string `json:"nativeCoin"`
+	Symbol      string `json:"symbol"`
+	RPCDefault  string `json:"rpcUrl"` // public RPC, used when RPC_URL is not set
+	ExplorerURL string `json:"explorerUrl"`
+	// TxURLTemplate links to a transaction for explorers without <explorerUrl>/tx/<hash>
+	// pages, e.g. "https://scan.example.com/transaction/{txHash}"
+	TxURLTemplate string `json:"txUrlTemplate,omitempty"`
+	IsTestnet     bool   `json:"isTestnet"`
+}
+
+// TxHashPlaceholder is replaced by the transaction hash in TxURLTemplate
+const TxHashPlaceholder = "{txHash}"
+
+// TxURL links to a transaction on the chain's explorer ("" if it has none)
+func (c Chain) TxURL(txHash string) string {
+	if c.TxURLTemplate != "" {
+		return strings.ReplaceAll(c.TxURLTemplate, TxHashPlaceholder, txHash)
+	}
+	if c.ExplorerURL == "" {
+		return ""
+	}
+	return strings.TrimRight(c.ExplorerURL, "/") + "/tx/" + txHash
+}
+
+// Registry maps chain IDs to their configuration; it is safe for concurrent use
+type Registry struct {
+	mu     sync.RWMutex
+	chains map[int64]Chain
+}
+
+// NewRegistry creates a registry holding the given chains
+func NewRegistry(chains ...Chain) *Registry {
+	r := &Registry{chains: make(map[int64]Chain, len(chains))}
+	for _, chain := range chains {
+		r.chains[chain.ChainID] = chain
+	}
+	return r
+}
+
+// Default is the process-wide registry, seeded with the built-in chains
+var Default = NewRegistry(builtin...)
+
+// Get returns the configuration for a chain
+func (r *Registry) Get(chainID int64) (Chain, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	chain, ok := r.chains[chainID]
+	return chain, ok
+}
+
+// All returns every registered chain, ordered by chain ID
+func (r *Registry) All() []Chain {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	all := make([]Chain, 0, len(r.chains))
+	for _, chain := range r.chains {
+		all = append(all, chain)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].ChainID < all[j].ChainID })
+	return all
+}
+
+// Name returns the chain's display name, or "Chain <id>" for an unknown chain
+func (r *Registry) Name(chainID int64) string {
+	if chain, ok := r.Get(chainID); ok && chain.Name != "" {
+		return chain.Name
+	}
+	return fmt.Sprintf("Chain %d", chainID)
+}
+
+// TxURL links to a transaction on the explorer of chainID ("" if unknown)
+func (r *Registry) TxURL(chainID int64, txHash string) string {
+	chain, _ := r.Get(chainID)
+	return chain.TxURL(txHash)
+}
+
+// Register adds a chain or replaces the one with the same ID
+func (r *Registry) Register(chain Chain) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.chains[chain.ChainID] = chain
+}
+
+// LoadJSON applies a JSON array of chains. An entry for a known chain only
+// overrides the fields it sets, e.g. [{"chainId":133,"explorerUrl":"https://..."}];
+// an entry for a new chain adds it.
+func (r *Registry) LoadJSON(data []byte) error {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("invalid chains config: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, raw := range entries {
+		var id struct {
+			ChainID int64 `json:"chainId"`
+		}
+		if err := json.Unmarshal(raw, &id); err != nil || id.ChainID <= 0 {
+			return fmt.Errorf("invalid chains config: entry %d needs a positive chainId", i)
+		}
+		chain := r.chains[id.ChainID]
+		if err := json.Unmarshal(raw, &chain); err != nil {
+			return fmt.Errorf("invalid chains config: entry %d: %w", i, err)
+		}
+		r.chains[id.ChainID] = chain
+	}
+	return nil
+}
+
+// LoadFromEnv applies CHAINS_CONFIG to the default registry. The value is either
+// inline JSON (starting with "[") or the path of a JSON file; empty is a no-op.
+func LoadFromEnv() error {
+	value := strings.TrimSpace(os.Getenv("CHAINS_CONFIG"))
+	if value == "" {
+		return nil
+	}
+	data := []byte(value)
+	if !strings.HasPrefix(value, "[") {
+		var err error
+		if data, err = os.ReadFile(value); err != nil {
+			return fmt.Errorf("failed to read CHAINS_CONFIG: %w", err)
+		}
+	}
+	return Default.LoadJSON(data)
+}
+
+// ChainIDFromEnv returns CHAIN_ID, or DefaultChainID if it is unset or invalid
+func ChainIDFromEnv() int {
+	if parsed, err := strconv.Atoi(strings.TrimSpace(os.Getenv("CHAIN_ID"))); err == nil && parsed > 0 {
+		return parsed
+	}
+	return DefaultChainID
+}
+
+// builtin are the chains known without any configuration
+var builtin = []Chain{
+	// Mainnets
+	{
+		ChainID:     1,
+		Name:        "Ethereum Mainnet",
+		ShortName:   "Ethereum",
+		NativeCoin:  "Ether",
+		Symbol:      "ETH",
+		RPCDefault:  "https://eth.llamarpc.com",
+		ExplorerURL: "https://etherscan.io",
+		IsTestnet:   false,
+	},
+	{
+		ChainID:     56,
+		Name:        "BNB Smart Chain",
+		ShortName:   "BSC",
+		NativeCoin:  "BNB",
+		Symbol:      "BNB",
+		RPCDefault:  "https://bsc-dataseed1.binance.org",
+		ExplorerURL: "https://bscscan.com",
+		IsTestnet:   false,
+	},
+	{
+		ChainID:     137,
+		Name:        "Polygon Mainnet",
+		ShortName:   "Polygon",
+		NativeCoin:  "MATIC",
+		Symbol:      "MATIC",
+		RPCDefault:  "https://polygon-rpc.com",
+		ExplorerURL: "https://polygonscan.com",
+		IsTestnet:   false,
+	},
+	{
+		ChainID:     42161,
+		Name:        "Arbitrum One",
+		ShortName:   "Arbitrum",
+		NativeCoin:  "Ether",
+		Symbol:      "ETH",
+		RPCDefault:  "https://arb1.arbitrum.io/rpc",
+		ExplorerURL: "https://arbiscan.io",
+		IsTestnet:   false,
+	},
+	{
+		ChainID:     10,
+		Name:        "Optimism",
+		ShortName:   "Optimism",
+		NativeCoin:  "Ether",
+		Symbol:      "ETH",
+		RPCDefault:  "https://mainnet.optimism.io",
+		ExplorerURL: "https://optimistic.etherscan.io",
+		IsTestnet:   false,
+	},
+	{
+		ChainID:     8453,
+		Name:        "Base",
+		ShortName:   "Base",
+		NativeCoin:  "Ether",
+		Symbol:      "ETH",
+		RPCDefault:  "https://mainnet.base.org",
+		ExplorerURL: "https://basescan.org",
+		IsTestnet:   false,
+	},
+
+	// Testnets
+	{
+		ChainID:     133,
+		Name:        "HashKey Chain Testnet",
+		ShortName:   "HashKey Testnet",
+		NativeCoin:  "HashKey Token",
+		Symbol:      "HSK",
+		RPCDefault:  "https://hashkeychain-testnet.alt.technology",
+		ExplorerURL: "https://testnet-explorer.hsk.xyz",
+		IsTestnet:   true,
+	},
+	{
+		ChainID:     11155111,
+		Name:        "Sepolia Testnet",
+		ShortName:   "Sepolia",
+		NativeCoin:  "Sepolia Ether",
+		Symbol:      "ETH",
+		RPCDefault:  "https://ethereum-sepolia-rpc.publicnode.com",
+		ExplorerURL: "https://sepolia.etherscan.io",
+		IsTestnet:   true,
+	},
+	{
+		ChainID:     97,
+		Name:        "BSC Testnet",
+		ShortName:   "BSC Testnet",
+		NativeCoin:  "Test BNB",
+		Symbol:      "tBNB",
+		RPCDefault:  "https://data-seed-prebsc-1-s1.binance.org:8545",
+		ExplorerURL: "https://testnet.bscscan.com",
+		IsTestnet:   true,
+	},
+	{
+		ChainID:     80001,
+		Name:        "Mumbai Testnet",
+		ShortName:   "Mumbai",
+		NativeCoin:  "Test MATIC",
+		Symbol:      "MATIC",
+		RPCDefault:  "https://rpc-mumbai.maticvigil.com",
+		ExplorerURL: "https://mumbai.polygonscan.com",
+		IsTestnet:   true,
+	},
+}
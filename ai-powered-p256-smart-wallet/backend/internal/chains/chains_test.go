@@ -0,0 +1,101 @@
+package chains
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuiltinChains(t *testing.T) {
+	r := NewRegistry(builtin...)
+
+	chain, ok := r.Get(DefaultChainID)
+	if !ok || chain.Symbol != "HSK" {
+		t.Fatalf("default chain = %+v, %v", chain, ok)
+	}
+	if got := r.TxURL(133, "0xabc"); got != "https://testnet-explorer.hsk.xyz/tx/0xabc" {
+		t.Errorf("TxURL(133) = %q", got)
+	}
+	if got := r.Name(11155111); got != "Sepolia Testnet" {
+		t.Errorf("Name(11155111) = %q", got)
+	}
+	if got, url := r.Name(999), r.TxURL(999, "0xabc"); got != "Chain 999" || url != "" {
+		t.Errorf("unknown chain: name %q, url %q", got, url)
+	}
+
+	all := r.All()
+	for i := 1; i < len(all); i++ {
+		if all[i-1].ChainID >= all[i].ChainID {
+			t.Fatalf("All() not sorted by chain ID: %d before %d", all[i-1].ChainID, all[i].ChainID)
+		}
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	r := NewRegistry(builtin...)
+	err := r.LoadJSON([]byte(`[
+		{"chainId": 133, "explorerUrl": "https://explorer.example.com/"},
+		{"chainId": 177, "name": "HashKey Chain", "symbol": "HSK", "rpcUrl": "https://mainnet.hsk.xyz", "explorerUrl": "https://explorer.hsk.xyz"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Overrides keep the fields they do not set
+	testnet, _ := r.Get(133)
+	if testnet.Name != "HashKey Chain Testnet" || testnet.TxURL("0x1") != "https://explorer.example.com/tx/0x1" {
+		t.Errorf("overridden chain = %+v", testnet)
+	}
+	mainnet, ok := r.Get(177)
+	if !ok || mainnet.RPCDefault != "https://mainnet.hsk.xyz" || r.Name(177) != "HashKey Chain" {
+		t.Errorf("added chain = %+v, %v", mainnet, ok)
+	}
+
+	// A template wins over the /tx/ path for explorers that link transactions differently
+	if err := r.LoadJSON([]byte(`[{"chainId": 177, "txUrlTemplate": "https://scan.example.com/transaction/{txHash}?network=hsk"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.TxURL(177, "0x1"); got != "https://scan.example.com/transaction/0x1?network=hsk" {
+		t.Errorf("TxURL(177) with template = %q", got)
+	}
+
+	for _, bad := range []string{`{"chainId": 1}`, `[{"name": "no id"}]`, `[{"chainId": "1"}]`} {
+		if err := r.LoadJSON([]byte(bad)); err == nil {
+			t.Errorf("LoadJSON(%s) succeeded", bad)
+		}
+	}
+}
+
+func TestLoadFromEnv(t *testing.T) {
+	saved := Default
+	t.Cleanup(func() { Default = saved })
+
+	Default = NewRegistry(builtin...)
+	t.Setenv("CHAINS_CONFIG", `[{"chainId": 31337, "name": "Anvil"}]`)
+	if err := LoadFromEnv(); err != nil || Default.Name(31337) != "Anvil" {
+		t.Fatalf("inline config: %v, name %q", err, Default.Name(31337))
+	}
+
+	path := filepath.Join(t.TempDir(), "chains.json")
+	if err := os.WriteFile(path, []byte(`[{"chainId": 1337, "name": "Local"}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CHAINS_CONFIG", path)
+	if err := LoadFromEnv(); err != nil || Default.Name(1337) != "Local" {
+		t.Fatalf("file config: %v, name %q", err, Default.Name(1337))
+	}
+
+	t.Setenv("CHAINS_CONFIG", filepath.Join(t.TempDir(), "missing.json"))
+	if err := LoadFromEnv(); err == nil {
+		t.Fatal("expected missing file to fail")
+	}
+}
+
+func TestChainIDFromEnv(t *testing.T) {
+	for value, want := range map[string]int{"": DefaultChainID, "11155111": 11155111, "abc": DefaultChainID, "-1": DefaultChainID} {
+		t.Setenv("CHAIN_ID", value)
+		if got := ChainIDFromEnv(); got != want {
+			t.Errorf("CHAIN_ID=%q: got %d, want %d", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationLockID is the pg_advisory_xact_lock key serialising migrations across replicas
+const migrationLockID = 7_133_001
+
+// initialSchemaTable is created by the first migration; finding it with nothing recorded
+// means the schema predates schema_migrations
+const initialSchemaTable = "users"
+
+// SchemaMigration records a SQL migration file that has been applied
+type SchemaMigration struct {
+	Version   string    `gorm:"primaryKey;size:255"` // migration filename, e.g. "005_pending_user_ops.sql"
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName specifies the table name
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// migrationFiles returns the .sql files at the root of fsys, sorted by name
+func migrationFiles(fsys fs.FS) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && path.Ext(entry.Name()) == ".sql" {
+			files = append(files, entry.Name())
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Migrate applies the pending .sql files of fsys in filename order and returns the
+// ones it applied. Each file runs in its own transaction together with its
+// schema_migrations row, so a failing file leaves neither its changes nor its record
+// behind, and files already recorded are skipped: running Migrate again is a no-op.
+//
+// baseline is for databases whose schema predates schema_migrations (e.g. created by
+// AutoMigrate or the old one-off scripts): when no migration has been recorded yet,
+// files up to and including baseline are recorded as applied without running them.
+// Without a baseline, such a database (one that already has the users table) gets the
+// first file recorded, since the later files only add to the schema if it lacks them.
+func Migrate(db *gorm.DB, fsys fs.FS, baseline string) ([]string, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	files, err := migrationFiles(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if baseline == "" && len(files) > 0 && db.Migrator().HasTable(initialSchemaTable) {
+		baseline = files[0]
+	}
+	if baseline != "" {
+		if err := recordBaseline(db, files, baseline); err != nil {
+			return nil, err
+		}
+	}
+
+	var applied []string
+	for _, name := range files {
+		ran := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			// Another replica may be applying the same file; wait for it, then re-check
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID).Error; err != nil {
+				return err
+			}
+			var count int64
+			if err := tx.Model(&SchemaMigration{}).Where("version = ?", name).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return nil
+			}
+
+			script, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return err
+			}
+			if err := tx.Exec(string(script)).Error; err != nil {
+				return err
+			}
+			ran = true
+			return tx.Create(&SchemaMigration{Version: name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %s failed: %w", name, err)
+		}
+		if ran {
+			log.Printf("✓ Applied migration %s", name)
+			applied = append(applied, name)
+		}
+	}
+	return applied, nil
+}
+
+// recordBaseline marks files up to baseline as applied if nothing has been recorded yet
+func recordBaseline(db *gorm.DB, files []string, baseline string) error {
+	idx := sort.SearchStrings(files, baseline)
+	if idx == len(files) || files[idx] != baseline {
+		return fmt.Errorf("baseline migration %q not found", baseline)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID).Error; err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&SchemaMigration{}).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		now := time.Now()
+		records := make([]SchemaMigration, 0, idx+1)
+		for _, name := range files[:idx+1] {
+			records = append(records, SchemaMigration{Version: name, AppliedAt: now})
+		}
+		log.Printf("✓ Baselined %d existing migrations (up to %s)", len(records), baseline)
+		return tx.Create(&records).Error
+	})
+}
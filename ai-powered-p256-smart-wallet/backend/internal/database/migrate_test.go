@@ -0,0 +1,162 @@
+package database
+
+import (
+	"io/fs"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/migrations"
+)
+
+func TestMigrationFilesSorted(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_b.sql":     {Data: []byte("SELECT 1")},
+		"002_a.sql":     {Data: []byte("SELECT 1")},
+		"README.md":     {Data: []byte("docs")},
+		"old/001_x.sql": {Data: []byte("SELECT 1")},
+	}
+	files, err := migrationFiles(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(files, ","); got != "002_a.sql,010_b.sql" {
+		t.Fatalf("migrationFiles = %s", got)
+	}
+
+	// The embedded migrations start with the initial schema
+	files, err = migrationFiles(migrations.FS)
+	if err != nil || len(files) == 0 || files[0] != "001_init.sql" {
+		t.Fatalf("embedded migrations = %v, %v", files, err)
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	db := dbtest.Open(t)
+	fsys := fstest.MapFS{
+		"001_widgets.sql": {Data: []byte("CREATE TABLE widgets (id INT PRIMARY KEY);")},
+		"002_name.sql":    {Data: []byte("ALTER TABLE widgets ADD COLUMN name TEXT; INSERT INTO widgets VALUES (1, 'a');")},
+	}
+
+	applied, err := Migrate(db, fsys, "")
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if strings.Join(applied, ",") != "001_widgets.sql,002_name.sql" {
+		t.Fatalf("applied = %v", applied)
+	}
+
+	// A second run applies nothing (002 would fail on the duplicate column otherwise)
+	applied, err = Migrate(db, fsys, "")
+	if err != nil || len(applied) != 0 {
+		t.Fatalf("second run applied %v, %v", applied, err)
+	}
+
+	var recorded []SchemaMigration
+	db.Order("version").Find(&recorded)
+	if len(recorded) != 2 || recorded[1].Version != "002_name.sql" {
+		t.Fatalf("schema_migrations = %+v", recorded)
+	}
+}
+
+func TestMigrateRollsBackFailedFile(t *testing.T) {
+	db := dbtest.Open(t)
+	fsys := fstest.MapFS{
+		"001_ok.sql":     {Data: []byte("CREATE TABLE ok (id INT);")},
+		"002_broken.sql": {Data: []byte("CREATE TABLE half (id INT); SELECT no_such_column FROM ok;")},
+	}
+
+	applied, err := Migrate(db, fsys, "")
+	if err == nil || !strings.Contains(err.Error(), "002_broken.sql") {
+		t.Fatalf("expected 002_broken.sql to fail, got %v", err)
+	}
+	if len(applied) != 1 {
+		t.Fatalf("applied = %v", applied)
+	}
+
+	var count int64
+	db.Model(&SchemaMigration{}).Where("version = ?", "002_broken.sql").Count(&count)
+	if count != 0 || db.Migrator().HasTable("half") {
+		t.Fatalf("failed migration left changes behind (recorded=%d, table=%v)", count, db.Migrator().HasTable("half"))
+	}
+}
+
+func TestMigrateBaseline(t *testing.T) {
+	db := dbtest.Open(t)
+	fsys := fstest.MapFS{
+		"001_existing.sql": {Data: []byte("CREATE TABLE existing (id INT);")},
+		"002_new.sql":      {Data: []byte("CREATE TABLE added (id INT);")},
+	}
+	// The schema already exists, as if created before schema_migrations
+	if err := db.Exec("CREATE TABLE existing (id INT)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := Migrate(db, fsys, "001_existing.sql")
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if strings.Join(applied, ",") != "002_new.sql" {
+		t.Fatalf("applied = %v", applied)
+	}
+
+	if _, err := Migrate(db, fsys, "003_missing.sql"); err == nil {
+		t.Fatal("expected unknown baseline to fail")
+	}
+}
+
+// createWithoutIfNotExists matches CREATE TABLE/INDEX statements that fail on a schema
+// AutoMigrate already created
+var createWithoutIfNotExists = regexp.MustCompile(`(?im)^\s*CREATE\s+(UNIQUE\s+)?(TABLE|INDEX)\s+(?:IF\s+NOT\s+EXISTS\b)?`)
+
+func TestMigrationsCreateIfNotExists(t *testing.T) {
+	files, err := migrationFiles(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range files {
+		script, err := fs.ReadFile(migrations.FS, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, stmt := range createWithoutIfNotExists.FindAllString(string(script), -1) {
+			if !strings.Contains(strings.ToUpper(stmt), "IF NOT EXISTS") {
+				t.Errorf("%s: %q without IF NOT EXISTS", name, strings.TrimSpace(stmt))
+			}
+		}
+	}
+}
+
+func TestMigrateAutoMigratedDatabase(t *testing.T) {
+	db := dbtest.Open(t)
+	// The schema the server created with AutoMigrate before schema_migrations existed
+	if err := AutoMigrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := Migrate(db, migrations.FS, "")
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	files, err := migrationFiles(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(applied, ",") != strings.Join(files[1:], ",") {
+		t.Fatalf("applied = %v, want every file after %s", applied, files[0])
+	}
+
+	var count int64
+	db.Model(&SchemaMigration{}).Count(&count)
+	if int(count) != len(files) {
+		t.Fatalf("%d migrations recorded, want %d", count, len(files))
+	}
+	if applied, err := Migrate(db, migrations.FS, ""); err != nil || len(applied) != 0 {
+		t.Fatalf("second run applied %v, %v", applied, err)
+	}
+	if err := AutoMigrate(db); err != nil {
+		t.Fatalf("AutoMigrate after migrations: %v", err)
+	}
+}
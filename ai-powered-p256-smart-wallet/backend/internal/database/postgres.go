@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"ai-wallet-backend/internal/models"
@@ -21,6 +22,55 @@ type Config struct {
 	Password string
 	DBName   string
 	SSLMode  string
+	Pool     PoolConfig
+}
+
+// Connection pool defaults, sized for a managed Postgres with a modest connection limit
+const (
+	DefaultMaxOpenConns    = 25
+	DefaultMaxIdleConns    = 5
+	DefaultConnMaxLifetime = 30 * time.Minute
+)
+
+// PoolConfig holds the database/sql connection pool settings
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// PoolConfigFromEnv reads DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS and DB_CONN_MAX_LIFETIME
+// (a Go duration such as "30m"); missing or invalid values use the defaults
+func PoolConfigFromEnv() PoolConfig {
+	pool := PoolConfig{
+		MaxOpenConns:    DefaultMaxOpenConns,
+		MaxIdleConns:    DefaultMaxIdleConns,
+		ConnMaxLifetime: DefaultConnMaxLifetime,
+	}
+	if value := os.Getenv("DB_MAX_OPEN_CONNS"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			pool.MaxOpenConns = n
+		} else {
+			log.Printf("⚠️  Invalid DB_MAX_OPEN_CONNS %q, using %d", value, pool.MaxOpenConns)
+		}
+	}
+	if value := os.Getenv("DB_MAX_IDLE_CONNS"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			pool.MaxIdleConns = n
+		} else {
+			log.Printf("⚠️  Invalid DB_MAX_IDLE_CONNS %q, using %d", value, pool.MaxIdleConns)
+		}
+	}
+	if value := os.Getenv("DB_CONN_MAX_LIFETIME"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			pool.ConnMaxLifetime = d
+		} else {
+			log.Printf("⚠️  Invalid DB_CONN_MAX_LIFETIME %q, using %s", value, pool.ConnMaxLifetime)
+		}
+	}
+	// Idle connections beyond the open limit would be closed immediately anyway
+	pool.MaxIdleConns = min(pool.MaxIdleConns, pool.MaxOpenConns)
+	return pool
 }
 
 // NewPostgresDB creates a new database connection using environment variables
@@ -32,6 +82,7 @@ func NewPostgresDB() (*gorm.DB, error) {
 		Password: os.Getenv("DB_PASSWORD"),
 		DBName:   os.Getenv("DB_NAME"),
 		SSLMode:  "disable", // Use "require" in production
+		Pool:     PoolConfigFromEnv(),
 	}
 
 	return NewConnection(config)
@@ -65,12 +116,20 @@ func NewConnection(config Config) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to get SQL DB: %w", err)
 	}
 
-	// Set connection pool settings
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	// Set connection pool settings (zero values fall back to the defaults)
+	pool := config.Pool
+	if pool.MaxOpenConns <= 0 {
+		pool.MaxOpenConns = DefaultMaxOpenConns
+	}
+	if pool.ConnMaxLifetime <= 0 {
+		pool.ConnMaxLifetime = DefaultConnMaxLifetime
+	}
+	sqlDB.SetMaxOpenConns(pool.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(min(pool.MaxIdleConns, pool.MaxOpenConns))
+	sqlDB.SetConnMaxLifetime(pool.ConnMaxLifetime)
 
-	log.Println("✓ Database connection established")
+	log.Printf("✓ Database connection established (pool: max open %d, max idle %d, max lifetime %s)",
+		pool.MaxOpenConns, min(pool.MaxIdleConns, pool.MaxOpenConns), pool.ConnMaxLifetime)
 
 	return db, nil
 }
@@ -86,6 +145,12 @@ func AutoMigrate(db *gorm.DB) error {
 		&models.WebAuthnSession{},
 		&models.Wallet{},
 		&models.Transaction{},
+		&models.PendingUserOp{},
+		&models.CrossDeviceToken{},
+		&models.NonceReservation{},
+		&models.TransferUsage{},
+		&models.Conversation{},
+		&models.Message{},
 	)
 
 	if err != nil {
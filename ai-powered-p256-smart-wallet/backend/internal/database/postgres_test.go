@@ -0,0 +1,32 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPoolConfigFromEnv(t *testing.T) {
+	t.Setenv("DB_MAX_OPEN_CONNS", "")
+	t.Setenv("DB_MAX_IDLE_CONNS", "")
+	t.Setenv("DB_CONN_MAX_LIFETIME", "")
+	want := PoolConfig{MaxOpenConns: 25, MaxIdleConns: 5, ConnMaxLifetime: 30 * time.Minute}
+	if got := PoolConfigFromEnv(); got != want {
+		t.Fatalf("defaults = %+v, want %+v", got, want)
+	}
+
+	t.Setenv("DB_MAX_OPEN_CONNS", "8")
+	t.Setenv("DB_MAX_IDLE_CONNS", "20")
+	t.Setenv("DB_CONN_MAX_LIFETIME", "5m")
+	want = PoolConfig{MaxOpenConns: 8, MaxIdleConns: 8, ConnMaxLifetime: 5 * time.Minute}
+	if got := PoolConfigFromEnv(); got != want {
+		t.Fatalf("from env = %+v, want %+v", got, want)
+	}
+
+	t.Setenv("DB_MAX_OPEN_CONNS", "0")
+	t.Setenv("DB_MAX_IDLE_CONNS", "-1")
+	t.Setenv("DB_CONN_MAX_LIFETIME", "forever")
+	want = PoolConfig{MaxOpenConns: 25, MaxIdleConns: 5, ConnMaxLifetime: 30 * time.Minute}
+	if got := PoolConfigFromEnv(); got != want {
+		t.Fatalf("invalid values = %+v, want defaults %+v", got, want)
+	}
+}
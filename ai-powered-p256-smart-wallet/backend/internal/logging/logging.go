@@ -0,0 +1,106 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// RequestIDHeader carries the request ID in and out of the API
+const RequestIDHeader = "X-Request-ID"
+
+// Gin context keys read by FromContext
+const (
+	requestIDKey  = "requestID"
+	userOpHashKey = "userOpHash"
+)
+
+// Setup configures the global zerolog logger
+// level is a zerolog level name (debug, info, warn, error; default info).
+// format "console" gives human-readable output for local development; anything else is JSON.
+func Setup(level, format string) {
+	zerolog.TimeFieldFormat = time.RFC3339Nano
+
+	parsed, err := zerolog.ParseLevel(strings.ToLower(strings.TrimSpace(level)))
+	if err != nil || parsed == zerolog.NoLevel {
+		parsed = zerolog.InfoLevel
+	}
+	zerolog.SetGlobalLevel(parsed)
+
+	var out io.Writer = os.Stderr
+	if strings.EqualFold(format, "console") {
+		out = zerolog.ConsoleWriter{Out: os.Stderr}
+	}
+	log.Logger = zerolog.New(out).With().Timestamp().Str("service", "ai-wallet-backend").Logger()
+	// Code that only has a context.Context (zerolog.Ctx) falls back to the global logger
+	zerolog.DefaultContextLogger = &log.Logger
+}
+
+// Middleware assigns every request an ID (reusing an incoming X-Request-ID)
+// and logs one line per request once it completes
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.New().String()
+		}
+		c.Set(requestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		start := time.Now()
+		c.Next()
+
+		logger := FromContext(c)
+		event := logger.Info()
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			event = logger.Error()
+		} else if c.Writer.Status() >= http.StatusBadRequest {
+			event = logger.Warn()
+		}
+		event.
+			Str("method", c.Request.Method).
+			Str("path", c.FullPath()).
+			Int("status", c.Writer.Status()).
+			Dur("latency", time.Since(start)).
+			Str("clientIp", c.ClientIP()).
+			Msg("request completed")
+	}
+}
+
+// SetUserOpHash attaches a UserOp hash to all subsequent log lines of the request,
+// so the prepare and submit steps of a transfer can be correlated
+func SetUserOpHash(c *gin.Context, userOpHash string) {
+	c.Set(userOpHashKey, userOpHash)
+}
+
+// FromContext returns a logger carrying the request ID, the authenticated userID
+// and the UserOp hash of the request, when known
+func FromContext(c *gin.Context) *zerolog.Logger {
+	ctx := log.Logger.With()
+	if requestID := c.GetString(requestIDKey); requestID != "" {
+		ctx = ctx.Str("requestId", requestID)
+	}
+	if userID, exists := c.Get("userID"); exists {
+		ctx = ctx.Str("userId", fmt.Sprintf("%v", userID))
+	}
+	if userOpHash := c.GetString(userOpHashKey); userOpHash != "" {
+		ctx = ctx.Str("userOpHash", userOpHash)
+	}
+	logger := ctx.Logger()
+	return &logger
+}
+
+// Context returns the request context carrying FromContext's logger, for helpers
+// that only receive a context.Context and log through zerolog.Ctx
+func Context(c *gin.Context) context.Context {
+	return FromContext(c).WithContext(c.Request.Context())
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Conversation message roles
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Conversation is a user's chat with the AI assistant (one per user)
+type Conversation struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	UserID    string    `json:"userId" gorm:"uniqueIndex"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TableName specifies the table name for Conversation
+func (Conversation) TableName() string {
+	return "conversations"
+}
+
+// Message is one persisted user or assistant turn of a conversation
+type Message struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	ConversationID string    `json:"conversationId" gorm:"index:idx_messages_conversation_created"`
+	Role           string    `json:"role"` // "user" or "assistant"
+	Content        string    `json:"content" gorm:"type:text"`
+	UI             string    `json:"ui,omitempty" gorm:"type:text"` // JSON-encoded AIStructure of an assistant turn
+	CreatedAt      time.Time `json:"createdAt" gorm:"index:idx_messages_conversation_created"`
+}
+
+// TableName specifies the table name for Message
+func (Message) TableName() string {
+	return "conversation_messages"
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// CrossDeviceToken lets another device (e.g. the phone holding the passkey) sign and
+// submit one prepared UserOp without a session
+type CrossDeviceToken struct {
+	TokenHash  string    `json:"-" gorm:"primaryKey"` // SHA-256 of the token; the token itself is never stored
+	UserID     string    `json:"userId" gorm:"index"`
+	UserOpHash string    `json:"userOpHash"`
+	ExpiresAt  time.Time `json:"expiresAt" gorm:"index"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// TableName specifies the table name for CrossDeviceToken
+func (CrossDeviceToken) TableName() string {
+	return "cross_device_tokens"
+}
+
+// IsExpired checks if the cross-device token has expired
+func (t *CrossDeviceToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// NonceReservation holds an EntryPoint nonce for a prepared UserOp, so UserOps prepared
+// for one wallet before the first lands on-chain get distinct nonces
+type NonceReservation struct {
+	WalletAddress string    `json:"walletAddress" gorm:"primaryKey"` // lowercase wallet address
+	Nonce         string    `json:"nonce" gorm:"primaryKey"`         // decimal nonce
+	ExpiresAt     time.Time `json:"expiresAt" gorm:"index"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// TableName specifies the table name for NonceReservation
+func (NonceReservation) TableName() string {
+	return "nonce_reservations"
+}
+
+// IsExpired checks if the nonce reservation has expired
+func (r *NonceReservation) IsExpired() bool {
+	return time.Now().After(r.ExpiresAt)
+}
@@ -1,20 +1,28 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // PasskeyCredential represents a WebAuthn credential
 type PasskeyCredential struct {
 	ID           string `json:"id" gorm:"primaryKey"`
 	UserID       string `json:"userId" gorm:"index"`
-	CredentialID []byte `json:"credentialId" gorm:"uniqueIndex"`
+	CredentialID []byte `json:"credentialId" gorm:"uniqueIndex:idx_passkey_credentials_credential_id_active,where:deleted_at IS NULL"` // unique among live passkeys
 	PublicKey    []byte `json:"publicKey"`
 	SignCount    uint32 `json:"signCount"`
 	AAGUID       []byte `json:"aaguid"`
+	Nickname     string `json:"nickname"`
 	// Flags stores authenticator flags (backup eligible, backup state, etc.)
-	BackupEligible bool      `json:"backupEligible"`
-	BackupState    bool      `json:"backupState"`
-	CreatedAt      time.Time `json:"createdAt"`
-	LastUsedAt     time.Time `json:"lastUsedAt"`
+	BackupEligible bool           `json:"backupEligible"`
+	BackupState    bool           `json:"backupState"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	CreatedBy      string         `json:"createdBy,omitempty"` // user ID, or the tool that created the row
+	UpdatedAt      time.Time      `json:"updatedAt"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"` // revoked passkeys are soft-deleted
+	LastUsedAt     time.Time      `json:"lastUsedAt"`
 }
 
 // TableName specifies the table name for PasskeyCredential
@@ -28,7 +36,10 @@ type Session struct {
 	UserID    string    `json:"userId" gorm:"index"`
 	Token     string    `json:"token" gorm:"uniqueIndex"`
 	ExpiresAt time.Time `json:"expiresAt" gorm:"index"`
-	CreatedAt time.Time `json:"createdAt"`
+	// AuthenticatedAt is when the user last logged in with a passkey; it is
+	// carried over on refresh and caps how long a session can be extended
+	AuthenticatedAt time.Time `json:"authenticatedAt"`
+	CreatedAt       time.Time `json:"createdAt"`
 }
 
 // TableName specifies the table name for Session
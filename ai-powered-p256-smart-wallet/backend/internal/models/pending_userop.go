@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PendingUserOp stores a prepared (unsigned) UserOperation until the user signs it
+type PendingUserOp struct {
+	UserOpHash string    `json:"userOpHash" gorm:"primaryKey"`
+	UserID     string    `json:"userId" gorm:"index"`
+	UserOp     string    `json:"userOp" gorm:"type:jsonb"` // JSON-encoded UserOp map
+	ExpiresAt  time.Time `json:"expiresAt" gorm:"index"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// TableName specifies the table name for PendingUserOp
+func (PendingUserOp) TableName() string {
+	return "pending_user_ops"
+}
+
+// IsExpired checks if the pending UserOp has expired
+func (p *PendingUserOp) IsExpired() bool {
+	return time.Now().After(p.ExpiresAt)
+}
+
+// Op decodes the stored UserOp map
+func (p *PendingUserOp) Op() (map[string]interface{}, error) {
+	var op map[string]interface{}
+	if err := json.Unmarshal([]byte(p.UserOp), &op); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
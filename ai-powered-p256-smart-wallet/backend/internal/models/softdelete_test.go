@@ -0,0 +1,75 @@
+package models
+
+import (
+	"ai-wallet-backend/internal/database/dbtest"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestSoftDeletedUsersAndPasskeysAreHidden(t *testing.T) {
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&User{}, &PasskeyCredential{}); err != nil {
+		t.Fatal(err)
+	}
+
+	user := User{ID: "u1", Username: "alice", CreatedBy: "u1"}
+	passkey := PasskeyCredential{ID: "p1", UserID: "u1", CredentialID: []byte{1}, CreatedBy: "u1"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&passkey).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	db.Delete(&passkey)
+	db.Delete(&user)
+
+	if err := db.Where("username = ?", "alice").First(&User{}).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("soft-deleted user found: %v", err)
+	}
+	var count int64
+	db.Model(&PasskeyCredential{}).Where("user_id = ?", "u1").Count(&count)
+	if count != 0 {
+		t.Fatalf("soft-deleted passkeys counted: %d", count)
+	}
+
+	db.Unscoped().Model(&user).Update("deleted_at", nil)
+	var found User
+	if err := db.Where("username = ?", "alice").First(&found).Error; err != nil || found.UpdatedAt.IsZero() {
+		t.Fatalf("restored user = %+v, %v", found, err)
+	}
+}
+
+func TestSoftDeletedUsersAndPasskeysFreeTheirKeys(t *testing.T) {
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&User{}, &PasskeyCredential{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Create(&User{ID: "u1", Username: "alice"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&PasskeyCredential{ID: "p1", UserID: "u1", CredentialID: []byte{1}}).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Live rows keep their username and credential ID to themselves
+	if err := db.Create(&User{ID: "u2", Username: "alice"}).Error; err == nil {
+		t.Fatal("duplicate username of a live user was accepted")
+	}
+	if err := db.Create(&PasskeyCredential{ID: "p2", UserID: "u1", CredentialID: []byte{1}}).Error; err == nil {
+		t.Fatal("duplicate credential ID of a live passkey was accepted")
+	}
+
+	db.Delete(&PasskeyCredential{ID: "p1"})
+	db.Delete(&User{ID: "u1"})
+
+	// Registering again after deletion reuses them
+	if err := db.Create(&User{ID: "u3", Username: "alice"}).Error; err != nil {
+		t.Fatalf("re-register username: %v", err)
+	}
+	if err := db.Create(&PasskeyCredential{ID: "p3", UserID: "u3", CredentialID: []byte{1}}).Error; err != nil {
+		t.Fatalf("re-register credential: %v", err)
+	}
+}
@@ -9,21 +9,36 @@ const (
 	TxStatusFailed    = "failed"
 )
 
+// Transaction actions
+const (
+	TxActionTransfer = "transfer"
+	TxActionUserOp   = "userop"
+	// Key changes carry the new key in PublicKeyX/PublicKeyY
+	TxActionSetRecoveryKey = "set_recovery_key"
+	TxActionRotateKey      = "rotate_key"
+)
+
 // Transaction represents a blockchain transaction
 type Transaction struct {
-	ID           string     `json:"id" gorm:"primaryKey"`
-	WalletID     string     `json:"walletId" gorm:"index"`
-	TxHash       string     `json:"txHash,omitempty" gorm:"index"`
-	UserOpHash   string     `json:"userOpHash,omitempty" gorm:"index"`
-	Action       string     `json:"action"`
-	Asset        string     `json:"asset,omitempty"`
-	Amount       string     `json:"amount,omitempty"`
-	Recipient    string     `json:"recipient,omitempty"`
-	Status       string     `json:"status" gorm:"index"`
-	GasUsed      string     `json:"gasUsed,omitempty"`
-	ErrorMessage string     `json:"errorMessage,omitempty"`
-	CreatedAt    time.Time  `json:"createdAt" gorm:"index"`
-	ConfirmedAt  *time.Time `json:"confirmedAt,omitempty"`
+	ID            string     `json:"id" gorm:"primaryKey"`
+	UserID        string     `json:"userId" gorm:"index"`
+	WalletID      string     `json:"walletId" gorm:"index"`
+	WalletAddress string     `json:"walletAddress"`
+	TxHash        string     `json:"txHash,omitempty" gorm:"index"`
+	UserOpHash    string     `json:"userOpHash,omitempty" gorm:"index"`
+	Action        string     `json:"action"`
+	Asset         string     `json:"asset,omitempty"`
+	Token         string     `json:"token,omitempty"` // ERC-20 contract address, empty for native transfers
+	Amount        string     `json:"amount,omitempty"`
+	Recipient     string     `json:"recipient,omitempty"`
+	Status        string     `json:"status" gorm:"index"`
+	GasUsed       string     `json:"gasUsed,omitempty"`
+	ErrorMessage  string     `json:"errorMessage,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt" gorm:"index"`
+	ConfirmedAt   *time.Time `json:"confirmedAt,omitempty"`
+	// New wallet key of a key change, written to the wallet once the UserOp succeeds
+	PublicKeyX string `json:"-"`
+	PublicKeyY string `json:"-"`
 }
 
 // TableName specifies the table name for Transaction
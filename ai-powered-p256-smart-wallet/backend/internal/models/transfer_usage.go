@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// TransferUsage records how much of a token a prepared or submitted UserOp transfers, so
+// daily transfer caps count a user's transfers across requests. A prepared UserOp's usage
+// expires with it unless it is submitted.
+type TransferUsage struct {
+	UserOpHash string     `json:"userOpHash" gorm:"primaryKey"`
+	Token      string     `json:"token" gorm:"primaryKey"`                         // "native" or lowercase ERC-20 address
+	UserID     string     `json:"userId" gorm:"index:idx_transfer_usage_user_day"` // owner of the wallet
+	Amount     string     `json:"amount" gorm:"type:numeric(78,0)"`                // base units
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty" gorm:"index"`                // nil once submitted
+	CreatedAt  time.Time  `json:"createdAt" gorm:"index:idx_transfer_usage_user_day"`
+}
+
+// TableName specifies the table name for TransferUsage
+func (TransferUsage) TableName() string {
+	return "transfer_usage"
+}
+
+// Counts reports whether the usage still counts towards the daily cap: the UserOp was
+// submitted, or is prepared and may still be signed
+func (u *TransferUsage) Counts() bool {
+	return u.ExpiresAt == nil || time.Now().Before(*u.ExpiresAt)
+}
@@ -1,12 +1,26 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// User roles
+const (
+	UserRoleUser  = "user"
+	UserRoleAdmin = "admin" // may use the /api/admin endpoints
+)
 
 // User represents a user account
 type User struct {
 	ID                 string              `json:"id" gorm:"primaryKey"`
-	Username           string              `json:"username,omitempty" gorm:"index"`
+	Username           string              `json:"username,omitempty" gorm:"index;uniqueIndex:idx_users_username_active,where:deleted_at IS NULL"` // unique among live users
+	Role               string              `json:"role,omitempty" gorm:"default:user"`                                                             // UserRoleUser or UserRoleAdmin
 	CreatedAt          time.Time           `json:"createdAt"`
+	CreatedBy          string              `json:"createdBy,omitempty"` // user ID for self-registration, or the tool that created the row
+	UpdatedAt          time.Time           `json:"updatedAt"`
+	DeletedAt          gorm.DeletedAt      `json:"-" gorm:"index"` // soft delete: hidden from queries unless Unscoped
 	LastActiveAt       time.Time           `json:"lastActiveAt"`
 	PasskeyCredentials []PasskeyCredential `json:"passkeyCredentials" gorm:"foreignKey:UserID;references:ID"`
 }
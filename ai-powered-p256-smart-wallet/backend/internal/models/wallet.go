@@ -1,21 +1,33 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // Wallet represents a P256-based smart contract wallet (Non-custodial)
 // Private keys are stored in user's device Secure Enclave/TPM, not in backend
 type Wallet struct {
-	ID                    string     `json:"id" gorm:"primaryKey"`
-	UserID                string     `json:"userId" gorm:"index"`
-	Address               string     `json:"address" gorm:"uniqueIndex"`
-	PublicKeyX            string     `json:"publicKeyX"` // P-256 public key X coordinate (hex)
-	PublicKeyY            string     `json:"publicKeyY"` // P-256 public key Y coordinate (hex)
-	ChainID               int        `json:"chainId"`
-	FactoryAddress        string     `json:"factoryAddress"`
-	ImplementationAddress string     `json:"implementationAddress"`
-	IsDeployed            bool       `json:"isDeployed"`
-	DeployedAt            *time.Time `json:"deployedAt,omitempty"`
-	CreatedAt             time.Time  `json:"createdAt"`
+	ID                    string         `json:"id" gorm:"primaryKey"`
+	UserID                string         `json:"userId" gorm:"index"`
+	Address               string         `json:"address" gorm:"uniqueIndex"`
+	PublicKeyX            string         `json:"publicKeyX"`                   // P-256 public key X coordinate (hex)
+	PublicKeyY            string         `json:"publicKeyY"`                   // P-256 public key Y coordinate (hex)
+	RecoveryPublicKeyX    string         `json:"recoveryPublicKeyX,omitempty"` // recovery passkey X coordinate (hex); empty when none is registered
+	RecoveryPublicKeyY    string         `json:"recoveryPublicKeyY,omitempty"` // recovery passkey Y coordinate (hex)
+	ChainID               int            `json:"chainId"`
+	FactoryAddress        string         `json:"factoryAddress"`
+	ImplementationAddress string         `json:"implementationAddress"`
+	Salt                  string         `json:"salt" gorm:"default:0"` // CREATE2 salt (decimal uint256)
+	WalletIndex           uint64         `json:"walletIndex"`           // 0 for the user's primary wallet
+	SignatureFormat       *uint8         `json:"-"`                     // UserOp signature layout the implementation reads (webauthnp256.SignatureFormat*); nil until probed
+	IsDeployed            bool           `json:"isDeployed"`
+	DeployedAt            *time.Time     `json:"deployedAt,omitempty"`
+	CreatedAt             time.Time      `json:"createdAt"`
+	CreatedBy             string         `json:"createdBy,omitempty"` // user ID, or the tool that created the row
+	UpdatedAt             time.Time      `json:"updatedAt"`
+	DeletedAt             gorm.DeletedAt `json:"-" gorm:"index"` // soft delete: see wallet.Manager.RestoreWallet
 }
 
 // TableName specifies the table name for Wallet
@@ -31,3 +43,12 @@ type WalletBalance struct {
 	Tokens       map[string]string `json:"tokens,omitempty"`
 	UpdatedAt    time.Time         `json:"updatedAt"`
 }
+
+// TokenBalance is a single native or ERC-20 balance of a wallet
+type TokenBalance struct {
+	Token     string `json:"token"` // ERC-20 contract address, or "native"
+	Symbol    string `json:"symbol"`
+	Decimals  uint8  `json:"decimals"`
+	Raw       string `json:"raw"`       // base units
+	Formatted string `json:"formatted"` // human-readable units
+}
@@ -0,0 +1,167 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// idleTTL is how long an unused bucket is kept before it is swept
+const idleTTL = 10 * time.Minute
+
+// Limit is a token-bucket limit: PerMinute tokens are refilled per minute, up to Burst
+// A zero PerMinute disables limiting.
+type Limit struct {
+	PerMinute float64
+	Burst     int
+}
+
+// DefaultLimits are the per-route limits used when no RATE_LIMIT_<ROUTE> env var is set
+var DefaultLimits = map[string]Limit{
+	"chat":     {PerMinute: 12, Burst: 5},  // LLM calls cost OpenRouter tokens
+	"transfer": {PerMinute: 30, Burst: 10}, // prepare/submit/estimate hit the RPC and bundler
+	"skills":   {PerMinute: 30, Burst: 10},
+	"passkey":  {PerMinute: 30, Burst: 10},
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiter keeps an in-memory token bucket per route and client
+type Limiter struct {
+	mu        sync.Mutex
+	limits    map[string]Limit
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// New creates a limiter with the given per-route limits
+func New(limits map[string]Limit) *Limiter {
+	return &Limiter{
+		limits:  limits,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// NewFromEnv creates a limiter from DefaultLimits, overridden by RATE_LIMIT_<ROUTE>
+// env vars in the form "<requests per minute>:<burst>" (e.g. RATE_LIMIT_CHAT=12:5, "0" disables)
+func NewFromEnv() *Limiter {
+	limits := make(map[string]Limit, len(DefaultLimits))
+	for route, limit := range DefaultLimits {
+		limits[route] = limit
+
+		value := os.Getenv("RATE_LIMIT_" + strings.ToUpper(route))
+		if value == "" {
+			continue
+		}
+		parsed, err := ParseLimit(value)
+		if err != nil {
+			log.Printf("⚠️  Ignoring RATE_LIMIT_%s: %v", strings.ToUpper(route), err)
+			continue
+		}
+		limits[route] = parsed
+	}
+	return New(limits)
+}
+
+// ParseLimit parses "<requests per minute>:<burst>"; burst defaults to 1
+func ParseLimit(value string) (Limit, error) {
+	parts := strings.SplitN(strings.TrimSpace(value), ":", 2)
+
+	perMinute, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || perMinute < 0 {
+		return Limit{}, fmt.Errorf("invalid rate %q", parts[0])
+	}
+
+	burst := 1
+	if len(parts) == 2 {
+		burst, err = strconv.Atoi(parts[1])
+		if err != nil || burst < 1 {
+			return Limit{}, fmt.Errorf("invalid burst %q", parts[1])
+		}
+	}
+	return Limit{PerMinute: perMinute, Burst: burst}, nil
+}
+
+// Allow consumes a token for key on route and reports how long to wait if none is available
+func (l *Limiter) Allow(route, key string) (bool, time.Duration) {
+	limit, ok := l.limits[route]
+	if !ok || limit.PerMinute <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	bucketKey := route + "|" + key
+	b, ok := l.buckets[bucketKey]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(limit.PerMinute/60), limit.Burst)}
+		l.buckets[bucketKey] = b
+	}
+	b.lastSeen = now
+
+	reservation := b.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, time.Minute
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// sweep drops idle buckets; called with mu held
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleTTL {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > idleTTL {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// Middleware limits requests to route, keyed by the authenticated userID when
+// present (so place it after auth.RequireAuth) and the client IP otherwise
+func (l *Limiter) Middleware(route string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := "ip:" + c.ClientIP()
+		if userID, exists := c.Get("userID"); exists {
+			key = fmt.Sprintf("user:%v", userID)
+		}
+
+		allowed, retryAfter := l.Allow(route, key)
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":      "rate limit exceeded",
+				"retryAfter": seconds,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
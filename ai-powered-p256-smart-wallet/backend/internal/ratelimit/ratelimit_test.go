@@ -0,0 +1,140 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeClock lets tests advance time without sleeping
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestLimiter(limit Limit) (*Limiter, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	l := New(map[string]Limit{"test": limit})
+	l.now = clock.now
+	return l, clock
+}
+
+func TestAllowBurst(t *testing.T) {
+	l, _ := newTestLimiter(Limit{PerMinute: 60, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("test", "alice"); !ok {
+			t.Fatalf("request %d within burst was rejected", i+1)
+		}
+	}
+
+	ok, retryAfter := l.Allow("test", "alice")
+	if ok {
+		t.Fatal("request beyond burst was allowed")
+	}
+	if retryAfter <= 0 || retryAfter > time.Second {
+		t.Fatalf("retryAfter = %v, want (0, 1s]", retryAfter)
+	}
+}
+
+func TestAllowSustainedRate(t *testing.T) {
+	l, clock := newTestLimiter(Limit{PerMinute: 60, Burst: 1})
+
+	if ok, _ := l.Allow("test", "alice"); !ok {
+		t.Fatal("first request rejected")
+	}
+
+	// One token per second: each request a second apart is allowed, faster ones are not
+	for i := 0; i < 5; i++ {
+		clock.advance(500 * time.Millisecond)
+		if ok, _ := l.Allow("test", "alice"); ok {
+			t.Fatalf("request %d after 500ms was allowed", i)
+		}
+		clock.advance(500 * time.Millisecond)
+		if ok, _ := l.Allow("test", "alice"); !ok {
+			t.Fatalf("request %d after 1s was rejected", i)
+		}
+	}
+}
+
+func TestAllowKeysAreIndependent(t *testing.T) {
+	l, _ := newTestLimiter(Limit{PerMinute: 1, Burst: 1})
+
+	if ok, _ := l.Allow("test", "alice"); !ok {
+		t.Fatal("alice rejected")
+	}
+	if ok, _ := l.Allow("test", "bob"); !ok {
+		t.Fatal("bob rejected after alice used her token")
+	}
+	if ok, _ := l.Allow("test", "alice"); ok {
+		t.Fatal("alice allowed twice")
+	}
+}
+
+func TestAllowUnknownOrDisabledRoute(t *testing.T) {
+	l := New(map[string]Limit{"off": {PerMinute: 0, Burst: 1}})
+	for i := 0; i < 100; i++ {
+		if ok, _ := l.Allow("off", "alice"); !ok {
+			t.Fatal("disabled route was limited")
+		}
+		if ok, _ := l.Allow("missing", "alice"); !ok {
+			t.Fatal("unconfigured route was limited")
+		}
+	}
+}
+
+func TestMiddlewareReturns429WithRetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	l, _ := newTestLimiter(Limit{PerMinute: 6, Burst: 1})
+
+	router := gin.New()
+	router.GET("/user", func(c *gin.Context) { c.Set("userID", "u1") }, l.Middleware("test"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/anon", l.Middleware("test"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	do := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do("/user"); w.Code != http.StatusOK {
+		t.Fatalf("first request: status %d", w.Code)
+	}
+	w := do("/user")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: status %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "10" {
+		t.Fatalf("Retry-After = %q, want 10", got)
+	}
+
+	// Same IP without a session is a different bucket
+	if w := do("/anon"); w.Code != http.StatusOK {
+		t.Fatalf("anonymous request: status %d", w.Code)
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	if l, err := ParseLimit("12:5"); err != nil || l.PerMinute != 12 || l.Burst != 5 {
+		t.Fatalf("ParseLimit(12:5) = %+v, %v", l, err)
+	}
+	if l, err := ParseLimit("30"); err != nil || l.Burst != 1 {
+		t.Fatalf("ParseLimit(30) = %+v, %v", l, err)
+	}
+	for _, bad := range []string{"", "abc", "-1:2", "5:0", "5:x"} {
+		if _, err := ParseLimit(bad); err == nil {
+			t.Fatalf("ParseLimit(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package wallet
+
+import (
+	"regexp"
+)
+
+// AAError is an ERC-4337 EntryPoint failure code ("AA23 reverted (or OOG)") decoded into
+// an explanation users can act on
+type AAError struct {
+	Code        string `json:"code"`        // e.g. "AA23"
+	Entity      string `json:"entity"`      // what failed: factory, account, paymaster, gas or postOp
+	Explanation string `json:"explanation"` // user-facing explanation
+}
+
+// aaCodePattern finds an EntryPoint error code anywhere in a bundler or node error
+// (e.g. `FailedOp(0, "AA24 signature error")`)
+var aaCodePattern = regexp.MustCompile(`\bAA(\d)(\d)\b`)
+
+// aaErrorExplanations maps the EntryPoint v0.6/v0.7 codes (AA10–AA51) to explanations
+var aaErrorExplanations = map[string]string{
+	// Factory (wallet deployment through initCode)
+	"AA10": "The wallet is already deployed, so the transfer must not deploy it again. Prepare the transfer again.",
+	"AA13": "Deploying the wallet failed or ran out of gas. Try again; if it keeps failing the wallet factory may be misconfigured.",
+	"AA14": "The wallet factory deployed a different address than this wallet. The wallet's deployment data is inconsistent.",
+	"AA15": "The wallet factory did not deploy the wallet contract.",
+
+	// Account (the smart wallet's own validation)
+	"AA20": "The wallet contract is not deployed yet. Deploy the wallet before sending this transfer.",
+	"AA21": "The wallet does not hold enough native token to pay the gas for this transfer. Top it up and try again.",
+	"AA22": "The transfer's signature is expired or not yet valid. Prepare and sign the transfer again.",
+	"AA23": "The wallet rejected the transfer during validation (or ran out of gas while validating it).",
+	"AA24": "The passkey signature was not accepted by the wallet. Sign again with a passkey registered to this wallet.",
+	"AA25": "The transfer's nonce is out of date, usually because another transfer was sent first. Prepare the transfer again.",
+	"AA26": "Validating the transfer used more gas than its verification gas limit. Prepare the transfer again.",
+
+	// Paymaster (gas sponsorship)
+	"AA30": "The gas sponsor (paymaster) contract is not deployed on this chain.",
+	"AA31": "The gas sponsor (paymaster) has run out of deposit and cannot pay for this transfer.",
+	"AA32": "The gas sponsorship has expired or is not yet valid. Prepare the transfer again.",
+	"AA33": "The gas sponsor (paymaster) refused to pay for this transfer (or ran out of gas while validating it).",
+	"AA34": "The gas sponsor's (paymaster's) approval is invalid. Prepare the transfer again.",
+	"AA36": "The gas sponsor (paymaster) used more gas than allowed while validating the transfer.",
+
+	// Verification gas
+	"AA40": "Validating the transfer used more gas than its verification gas limit. Prepare the transfer again.",
+	"AA41": "The transfer was sent with too little verification gas. Prepare the transfer again.",
+
+	// Post-operation
+	"AA50": "The gas sponsor's (paymaster's) post-transfer step reverted.",
+	"AA51": "The gas prepaid for the transfer did not cover its actual cost.",
+}
+
+// aaEntities names the component each code family points at (the first digit)
+var aaEntities = map[string]string{
+	"1": "factory",
+	"2": "account",
+	"3": "paymaster",
+	"4": "gas",
+	"5": "postOp",
+}
+
+// aaFamilyExplanations covers codes a newer EntryPoint may add within a known family
+var aaFamilyExplanations = map[string]string{
+	"factory":   "Deploying the wallet failed.",
+	"account":   "The wallet rejected the transfer during validation.",
+	"paymaster": "The gas sponsor (paymaster) rejected the transfer.",
+	"gas":       "The transfer's gas limits are too low. Prepare the transfer again.",
+	"postOp":    "The transfer failed after execution while settling gas.",
+}
+
+// DecodeAAError extracts the first EntryPoint error code from err and explains it;
+// ok is false when err carries no recognizable code
+func DecodeAAError(err error) (aaErr *AAError, ok bool) {
+	if err == nil {
+		return nil, false
+	}
+	match := aaCodePattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return nil, false
+	}
+
+	code := match[0]
+	entity, known := aaEntities[match[1]]
+	if !known {
+		return nil, false
+	}
+	explanation, known := aaErrorExplanations[code]
+	if !known {
+		explanation = aaFamilyExplanations[entity]
+	}
+	return &AAError{Code: code, Entity: entity, Explanation: explanation}, true
+}
@@ -0,0 +1,52 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDecodeAAError(t *testing.T) {
+	tests := []struct {
+		err         error
+		code        string
+		entity      string
+		explanation string
+	}{
+		{&BundlerError{Code: -32500, Message: `FailedOp(0, "AA21 didn't pay prefund")`}, "AA21", "account", aaErrorExplanations["AA21"]},
+		{&BundlerError{Code: -32500, Message: "AA23 reverted (or OOG)"}, "AA23", "account", aaErrorExplanations["AA23"]},
+		{fmt.Errorf("failed to send transaction: %w", errors.New("execution reverted: AA24 signature error")), "AA24", "account", aaErrorExplanations["AA24"]},
+		{&BundlerError{Code: -32501, Message: "AA33 reverted: paymaster rejected"}, "AA33", "paymaster", aaErrorExplanations["AA33"]},
+		{errors.New("AA13 initCode failed or OOG"), "AA13", "factory", aaErrorExplanations["AA13"]},
+		{errors.New("user operation 0xabc reverted in tx 0xdef: AA51 prefund below actualGasCost"), "AA51", "postOp", aaErrorExplanations["AA51"]},
+		// Unknown codes fall back to their family
+		{errors.New("AA49 new verification failure"), "AA49", "gas", aaFamilyExplanations["gas"]},
+		{errors.New("AA39 new paymaster failure"), "AA39", "paymaster", aaFamilyExplanations["paymaster"]},
+	}
+	for _, tc := range tests {
+		aaErr, ok := DecodeAAError(tc.err)
+		if !ok {
+			t.Fatalf("DecodeAAError(%q) found no code", tc.err)
+		}
+		if aaErr.Code != tc.code || aaErr.Entity != tc.entity || aaErr.Explanation != tc.explanation {
+			t.Fatalf("DecodeAAError(%q) = %+v, want %s/%s", tc.err, aaErr, tc.code, tc.entity)
+		}
+		if aaErr.Explanation == "" {
+			t.Fatalf("%s has no explanation", tc.code)
+		}
+	}
+}
+
+func TestDecodeAAErrorWithoutCode(t *testing.T) {
+	for _, err := range []error{
+		nil,
+		errors.New("connection refused"),
+		errors.New("nonce too low"),
+		errors.New("AA99 unknown family"),
+		errors.New("0xAA21ff is not a code"),
+	} {
+		if aaErr, ok := DecodeAAError(err); ok {
+			t.Fatalf("DecodeAAError(%v) = %+v", err, aaErr)
+		}
+	}
+}
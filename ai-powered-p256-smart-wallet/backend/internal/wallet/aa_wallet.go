@@ -211,10 +211,13 @@ func (m *Manager) BuildUserOperation(
 // SignUserOperation signs a user operation with the owner's private key
 func (m *Manager) SignUserOperation(userOp *UserOperation, privateKey *ecdsa.PrivateKey, chainID int64) ([]byte, error) {
 	// Get the user operation hash
-	userOpHash := m.getUserOperationHash(userOp, common.HexToAddress(DefaultEntryPointAddress), big.NewInt(chainID))
+	userOpHash, err := UserOpHashV06(userOp, common.HexToAddress(DefaultEntryPointAddress), big.NewInt(chainID))
+	if err != nil {
+		return nil, err
+	}
 	
 	// Sign with Ethereum prefix
-	prefixedHash := crypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n32%s", userOpHash)))
+	prefixedHash := crypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n32%s", userOpHash.Bytes())))
 	
 	signature, err := crypto.Sign(prefixedHash, privateKey)
 	if err != nil {
@@ -229,54 +232,6 @@ func (m *Manager) SignUserOperation(userOp *UserOperation, privateKey *ecdsa.Pri
 	return signature, nil
 }
 
-// getUserOperationHash computes the hash of a user operation
-func (m *Manager) getUserOperationHash(userOp *UserOperation, entryPoint common.Address, chainID *big.Int) []byte {
-	// Pack user operation for hashing
-	// This follows ERC-4337 specification
-	
-	// Hash of initCode
-	initCodeHash := crypto.Keccak256(userOp.InitCode)
-	
-	// Hash of callData
-	callDataHash := crypto.Keccak256(userOp.CallData)
-	
-	// Hash of paymasterAndData
-	paymasterHash := crypto.Keccak256(userOp.PaymasterAndData)
-	
-	// Pack the user operation fields
-	packed := crypto.Keccak256(
-		append(
-			append(
-				append(
-					append(
-						append(
-							append(
-								append(
-									append(
-										append(
-											userOp.Sender.Bytes(),
-											common.BigToHash(userOp.Nonce).Bytes()...),
-										initCodeHash...),
-									callDataHash...),
-								common.BigToHash(userOp.CallGasLimit).Bytes()...),
-							common.BigToHash(userOp.VerificationGasLimit).Bytes()...),
-						common.BigToHash(userOp.PreVerificationGas).Bytes()...),
-					common.BigToHash(userOp.MaxFeePerGas).Bytes()...),
-				common.BigToHash(userOp.MaxPriorityFeePerGas).Bytes()...),
-			paymasterHash...),
-	)
-	
-	// Hash with EntryPoint and chainID
-	finalHash := crypto.Keccak256(
-		append(
-			append(packed, entryPoint.Bytes()...),
-			common.BigToHash(chainID).Bytes()...,
-		),
-	)
-	
-	return finalHash
-}
-
 // DeployAAWallet deploys an AA wallet by sending a user operation
 func (m *Manager) DeployAAWallet(ctx context.Context, walletAddress, ownerAddress, privateKeyHex string) error {
 	// Parse private key
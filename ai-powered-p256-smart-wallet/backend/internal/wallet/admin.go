@@ -0,0 +1,73 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Admin wallet listing page sizes
+const (
+	DefaultAdminWalletPageSize = 50
+	MaxAdminWalletPageSize     = 200
+)
+
+// AdminWallet is a wallet row with its owner, as listed by GET /api/admin/wallets
+type AdminWallet struct {
+	ID         string     `json:"id"`
+	Address    string     `json:"address"`
+	ChainID    int        `json:"chainId"`
+	UserID     string     `json:"userId"`
+	Username   string     `json:"username"`
+	IsDeployed bool       `json:"isDeployed"`
+	DeployedAt *time.Time `json:"deployedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// ListWallets returns a page of all wallets, newest first
+// search, when set, matches a substring of the wallet address or the owner's username
+// (case-insensitive); cursor is the nextCursor of the previous page ("" for the first page).
+func (m *Manager) ListWallets(ctx context.Context, limit int, cursor, search string) ([]AdminWallet, string, error) {
+	if limit <= 0 {
+		limit = DefaultAdminWalletPageSize
+	}
+	if limit > MaxAdminWalletPageSize {
+		limit = MaxAdminWalletPageSize
+	}
+
+	query := m.db.WithContext(ctx).Table("wallets").
+		Select("wallets.id, wallets.address, wallets.chain_id, wallets.user_id, COALESCE(users.username, '') AS username, wallets.is_deployed, wallets.deployed_at, wallets.created_at").
+		Joins("LEFT JOIN users ON users.id = wallets.user_id").
+		Where("wallets.deleted_at IS NULL")
+	if search = strings.TrimSpace(search); search != "" {
+		pattern := "%" + escapeLike(search) + "%"
+		query = query.Where("(wallets.address ILIKE ? OR users.username ILIKE ?)", pattern, pattern)
+	}
+	if cursor != "" {
+		createdAt, id, err := decodePageCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query = query.Where("(wallets.created_at < ? OR (wallets.created_at = ? AND wallets.id < ?))", createdAt, createdAt, id)
+	}
+
+	// Fetch one extra row to know whether there is a next page
+	var wallets []AdminWallet
+	if err := query.Order("wallets.created_at DESC, wallets.id DESC").Limit(limit + 1).Find(&wallets).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to list wallets: %w", err)
+	}
+
+	nextCursor := ""
+	if len(wallets) > limit {
+		wallets = wallets[:limit]
+		last := wallets[len(wallets)-1]
+		nextCursor = encodePageCursor(last.CreatedAt, last.ID)
+	}
+	return wallets, nextCursor, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
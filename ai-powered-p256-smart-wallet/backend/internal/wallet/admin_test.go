@@ -0,0 +1,99 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestListWalletsQuery(t *testing.T) {
+	db := dbtest.DryRun(t)
+	var statement string
+	err := db.Callback().Query().After("gorm:query").Register("test:record", func(tx *gorm.DB) {
+		statement = tx.Statement.SQL.String()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Manager{db: db}
+
+	cursor := encodePageCursor(time.Unix(1700000000, 0), "w9")
+	if _, _, err := m.ListWallets(t.Context(), 500, cursor, " 50%_off "); err != nil {
+		t.Fatalf("ListWallets: %v", err)
+	}
+	for _, want := range []string{
+		"LEFT JOIN users ON users.id = wallets.user_id",
+		"wallets.deleted_at IS NULL",
+		"(wallets.address ILIKE $1 OR users.username ILIKE $2)",
+		"(wallets.created_at < $3 OR (wallets.created_at = $4 AND wallets.id < $5))",
+		"ORDER BY wallets.created_at DESC, wallets.id DESC LIMIT $6",
+	} {
+		if !strings.Contains(statement, want) {
+			t.Errorf("query %q does not contain %q", statement, want)
+		}
+	}
+
+	if _, _, err := m.ListWallets(t.Context(), 10, "not a cursor!", ""); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("err = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if got := escapeLike(`50%_off\`); got != `50\%\_off\\` {
+		t.Fatalf("escapeLike = %s", got)
+	}
+}
+
+func TestListWalletsPagesAndSearches(t *testing.T) {
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&models.User{}, &models.Wallet{}); err != nil {
+		t.Fatal(err)
+	}
+	m := &Manager{db: db}
+
+	base := time.Now().Add(-time.Hour)
+	for i, u := range []models.User{{ID: "u1", Username: "alice"}, {ID: "u2", Username: "bob"}, {ID: "u3", Username: "carol"}} {
+		if err := db.Create(&u).Error; err != nil {
+			t.Fatal(err)
+		}
+		w := models.Wallet{
+			ID:         "w" + u.ID[1:],
+			UserID:     u.ID,
+			Address:    []string{"0xAAAA000000000000000000000000000000000001", "0xBBBB000000000000000000000000000000000002", "0xCCCC000000000000000000000000000000000003"}[i],
+			IsDeployed: i == 0,
+			CreatedAt:  base.Add(time.Duration(i) * time.Minute),
+		}
+		if err := db.Create(&w).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Newest first, two pages
+	page, next, err := m.ListWallets(t.Context(), 2, "", "")
+	if err != nil {
+		t.Fatalf("ListWallets: %v", err)
+	}
+	if len(page) != 2 || page[0].ID != "w3" || page[1].ID != "w2" || page[0].Username != "carol" || next == "" {
+		t.Fatalf("first page = %+v, next %q", page, next)
+	}
+	page, next, err = m.ListWallets(t.Context(), 2, next, "")
+	if err != nil {
+		t.Fatalf("ListWallets(next): %v", err)
+	}
+	if len(page) != 1 || page[0].ID != "w1" || !page[0].IsDeployed || next != "" {
+		t.Fatalf("second page = %+v, next %q", page, next)
+	}
+
+	// Search by username and by address, case-insensitively
+	if page, _, _ := m.ListWallets(t.Context(), 10, "", "BOB"); len(page) != 1 || page[0].ID != "w2" {
+		t.Fatalf("search username = %+v", page)
+	}
+	if page, _, _ := m.ListWallets(t.Context(), 10, "", "0xcccc"); len(page) != 1 || page[0].ID != "w3" {
+		t.Fatalf("search address = %+v", page)
+	}
+}
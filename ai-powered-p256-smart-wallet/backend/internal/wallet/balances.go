@@ -0,0 +1,158 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/models"
+	"context"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NativeToken is the Token value used for the chain's native coin in balance lists
+const NativeToken = "native"
+
+// DefaultBalanceCacheTTL is how long balances are served from cache per wallet address
+const DefaultBalanceCacheTTL = 15 * time.Second
+
+type balanceCacheEntry struct {
+	balances  []models.TokenBalance
+	expiresAt time.Time
+}
+
+// SetBalanceCacheTTL sets how long balances are cached per wallet address (0 disables caching)
+func (m *Manager) SetBalanceCacheTTL(ttl time.Duration) {
+	m.balanceCacheMu.Lock()
+	defer m.balanceCacheMu.Unlock()
+	m.balanceCacheTTL = ttl
+	m.balanceCache = make(map[string]balanceCacheEntry)
+}
+
+// GetTokenBalances returns the native balance followed by each ERC-20 token balance
+// Balances are read for the (possibly counterfactual) address, so undeployed wallets work too.
+// Tokens whose calls fail are skipped and logged rather than failing the whole request.
+func (m *Manager) GetTokenBalances(ctx context.Context, address, nativeSymbol string, tokens []string) ([]models.TokenBalance, error) {
+	cacheKey := strings.ToLower(address)
+
+	m.balanceCacheMu.Lock()
+	if entry, ok := m.balanceCache[cacheKey]; ok && time.Now().Before(entry.expiresAt) {
+		m.balanceCacheMu.Unlock()
+		return entry.balances, nil
+	}
+	m.balanceCacheMu.Unlock()
+
+	native, err := m.GetBalance(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := []models.TokenBalance{{
+		Token:     NativeToken,
+		Symbol:    nativeSymbol,
+		Decimals:  18,
+		Raw:       native.String(),
+		Formatted: FormatUnits(native, 18),
+	}}
+
+	for _, token := range tokens {
+		balance, err := m.getERC20Balance(ctx, token, address)
+		if err != nil {
+			log.Printf("Warning: Failed to get %s balance for %s: %v", token, address, err)
+			continue
+		}
+		balances = append(balances, *balance)
+	}
+
+	m.balanceCacheMu.Lock()
+	if m.balanceCacheTTL > 0 {
+		m.balanceCache[cacheKey] = balanceCacheEntry{balances: balances, expiresAt: time.Now().Add(m.balanceCacheTTL)}
+	}
+	m.balanceCacheMu.Unlock()
+
+	return balances, nil
+}
+
+// EvictExpiredBalances drops cached balances past their TTL and returns how many were removed
+func (m *Manager) EvictExpiredBalances() int {
+	m.balanceCacheMu.Lock()
+	defer m.balanceCacheMu.Unlock()
+
+	now := time.Now()
+	evicted := 0
+	for key, entry := range m.balanceCache {
+		if !now.Before(entry.expiresAt) {
+			delete(m.balanceCache, key)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// RunBalanceCacheSweeper periodically evicts expired cached balances until ctx is cancelled,
+// so addresses that are looked up once do not stay in memory forever
+func RunBalanceCacheSweeper(ctx context.Context, m *Manager, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.EvictExpiredBalances()
+		}
+	}
+}
+
+// getERC20Balance reads balanceOf, decimals and symbol for a token
+func (m *Manager) getERC20Balance(ctx context.Context, token, owner string) (*models.TokenBalance, error) {
+	var raw *big.Int
+	if err := m.callERC20(ctx, token, "balanceOf", &raw, common.HexToAddress(owner)); err != nil {
+		return nil, err
+	}
+
+	decimals, err := m.GetTokenDecimals(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var symbol string
+	if err := m.callERC20(ctx, token, "symbol", &symbol); err != nil {
+		symbol = "" // symbol() is optional in ERC-20
+	}
+
+	return &models.TokenBalance{
+		Token:     token,
+		Symbol:    symbol,
+		Decimals:  decimals,
+		Raw:       raw.String(),
+		Formatted: FormatUnits(raw, decimals),
+	}, nil
+}
+
+// FormatUnits renders a base-unit amount with the given decimals (e.g. 1500000, 6 -> "1.5")
+func FormatUnits(raw *big.Int, decimals uint8) string {
+	if decimals == 0 {
+		return raw.String()
+	}
+
+	sign := ""
+	abs := new(big.Int).Set(raw)
+	if abs.Sign() < 0 {
+		sign = "-"
+		abs.Neg(abs)
+	}
+
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	whole, frac := new(big.Int).QuoRem(abs, unit, new(big.Int))
+	if frac.Sign() == 0 {
+		return sign + whole.String()
+	}
+
+	fracStr := frac.String()
+	fracStr = strings.Repeat("0", int(decimals)-len(fracStr)) + fracStr
+	fracStr = strings.TrimRight(fracStr, "0")
+	return sign + whole.String() + "." + fracStr
+}
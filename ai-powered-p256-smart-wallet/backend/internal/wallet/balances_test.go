@@ -0,0 +1,70 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/models"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestFormatUnits(t *testing.T) {
+	cases := []struct {
+		raw      string
+		decimals uint8
+		want     string
+	}{
+		{"0", 18, "0"},
+		{"1000000000000000000", 18, "1"},
+		{"10000000000000000", 18, "0.01"},
+		{"1500000", 6, "1.5"},
+		{"1", 6, "0.000001"},
+		{"42", 0, "42"},
+		{"-2500000", 6, "-2.5"},
+	}
+
+	for _, tc := range cases {
+		raw, _ := new(big.Int).SetString(tc.raw, 10)
+		if got := FormatUnits(raw, tc.decimals); got != tc.want {
+			t.Errorf("FormatUnits(%s, %d) = %q, want %q", tc.raw, tc.decimals, got, tc.want)
+		}
+	}
+}
+
+func TestBalanceCacheSweeperEvictsExpiredEntries(t *testing.T) {
+	m := &Manager{balanceCacheTTL: time.Minute, balanceCache: map[string]balanceCacheEntry{
+		"0xstale": {balances: []models.TokenBalance{{Token: NativeToken}}, expiresAt: time.Now().Add(-time.Second)},
+		"0xlive":  {balances: []models.TokenBalance{{Token: NativeToken}}, expiresAt: time.Now().Add(time.Minute)},
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		RunBalanceCacheSweeper(ctx, m, 10*time.Millisecond)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		m.balanceCacheMu.Lock()
+		_, stale := m.balanceCache["0xstale"]
+		_, live := m.balanceCache["0xlive"]
+		m.balanceCacheMu.Unlock()
+		if !stale {
+			if !live {
+				t.Fatal("sweeper evicted an entry that has not expired")
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expired balance entry was never evicted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	<-done
+	if evicted := m.EvictExpiredBalances(); evicted != 0 {
+		t.Fatalf("EvictExpiredBalances after sweep = %d, want 0", evicted)
+	}
+}
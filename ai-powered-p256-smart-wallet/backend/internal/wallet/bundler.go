@@ -0,0 +1,342 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Submission modes for UserOperations
+const (
+	// SubmitModeDirect: the backend's bundler EOA calls EntryPoint.handleOps itself
+	SubmitModeDirect = "direct"
+	// SubmitModeBundler: UserOps are relayed to an ERC-4337 bundler via eth_sendUserOperation
+	SubmitModeBundler = "bundler"
+)
+
+// Receipt polling settings for bundler mode (variables so tests can poll faster)
+var (
+	bundlerReceiptPollInterval = 2 * time.Second
+	bundlerReceiptTimeout      = 2 * time.Minute
+)
+
+// BundlerError is a JSON-RPC error returned by the bundler (e.g. "AA23 reverted")
+type BundlerError struct {
+	Code    int
+	Message string
+	Data    interface{}
+}
+
+func (e *BundlerError) Error() string {
+	if e.Data != nil {
+		return fmt.Sprintf("bundler error %d: %s (data: %v)", e.Code, e.Message, e.Data)
+	}
+	return fmt.Sprintf("bundler error %d: %s", e.Code, e.Message)
+}
+
+// UserOpRevertedError is returned for a UserOp that was included on-chain but reverted:
+// unlike a rejection its nonce is used and the bundle transaction TxHash exists
+type UserOpRevertedError struct {
+	UserOpHash string
+	TxHash     string
+	Reason     string
+}
+
+func (e *UserOpRevertedError) Error() string {
+	return fmt.Sprintf("user operation %s reverted in tx %s: %s", e.UserOpHash, e.TxHash, e.Reason)
+}
+
+// UserOpPendingError is returned for a UserOp the bundler accepted but whose receipt could
+// not be fetched in time: it may still be included, so its nonce must not be reused
+type UserOpPendingError struct {
+	UserOpHash string
+	Err        error
+}
+
+func (e *UserOpPendingError) Error() string {
+	return fmt.Sprintf("user operation %s accepted but not confirmed: %v", e.UserOpHash, e.Err)
+}
+
+func (e *UserOpPendingError) Unwrap() error {
+	return e.Err
+}
+
+// UserOperationReceipt is the result of eth_getUserOperationReceipt
+type UserOperationReceipt struct {
+	UserOpHash    string `json:"userOpHash"`
+	Sender        string `json:"sender"`
+	Nonce         string `json:"nonce"`
+	Success       bool   `json:"success"`
+	Reason        string `json:"reason"`
+	ActualGasCost string `json:"actualGasCost"`
+	ActualGasUsed string `json:"actualGasUsed"`
+	Receipt       struct {
+		TransactionHash string `json:"transactionHash"`
+		BlockNumber     string `json:"blockNumber"`
+		Status          string `json:"status"`
+	} `json:"receipt"`
+}
+
+// BundlerClient talks to an ERC-4337 bundler using the standard JSON-RPC methods
+type BundlerClient struct {
+	rpcClient *rpc.Client
+}
+
+// NewBundlerClient creates a bundler client for the given RPC URL
+func NewBundlerClient(bundlerURL string) (*BundlerClient, error) {
+	rpcClient, err := rpc.Dial(bundlerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to bundler: %w", err)
+	}
+	return &BundlerClient{rpcClient: rpcClient}, nil
+}
+
+// call performs a JSON-RPC call and converts RPC errors to *BundlerError
+func (b *BundlerClient) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	err := b.rpcClient.CallContext(ctx, result, method, args...)
+	if err == nil {
+		return nil
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		bundlerErr := &BundlerError{Code: rpcErr.ErrorCode(), Message: rpcErr.Error()}
+		var dataErr rpc.DataError
+		if errors.As(err, &dataErr) {
+			bundlerErr.Data = dataErr.ErrorData()
+		}
+		return bundlerErr
+	}
+	return fmt.Errorf("%s failed: %w", method, err)
+}
+
+// SendUserOperation submits a signed UserOp and returns the userOpHash assigned by the bundler
+func (b *BundlerClient) SendUserOperation(ctx context.Context, userOp map[string]interface{}, entryPoint common.Address) (string, error) {
+	var userOpHash string
+	if err := b.call(ctx, &userOpHash, "eth_sendUserOperation", toBundlerUserOp(userOp), entryPoint.Hex()); err != nil {
+		return "", err
+	}
+	return userOpHash, nil
+}
+
+// GetUserOperationReceipt returns the receipt for a UserOp, or nil if it is not yet included
+func (b *BundlerClient) GetUserOperationReceipt(ctx context.Context, userOpHash string) (*UserOperationReceipt, error) {
+	var raw json.RawMessage
+	if err := b.call(ctx, &raw, "eth_getUserOperationReceipt", userOpHash); err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var receipt UserOperationReceipt
+	if err := json.Unmarshal(raw, &receipt); err != nil {
+		return nil, fmt.Errorf("failed to decode user operation receipt: %w", err)
+	}
+	return &receipt, nil
+}
+
+// WaitForUserOperationReceipt polls eth_getUserOperationReceipt until the UserOp is included
+func (b *BundlerClient) WaitForUserOperationReceipt(ctx context.Context, userOpHash string) (*UserOperationReceipt, error) {
+	ctx, cancel := context.WithTimeout(ctx, bundlerReceiptTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(bundlerReceiptPollInterval)
+	defer ticker.Stop()
+
+	for {
+		receipt, err := b.GetUserOperationReceipt(ctx, userOpHash)
+		if err != nil {
+			return nil, err
+		}
+		if receipt != nil {
+			return receipt, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for user operation %s: %w", userOpHash, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close closes the bundler RPC connection
+func (b *BundlerClient) Close() {
+	b.rpcClient.Close()
+}
+
+// toBundlerUserOp converts a UserOp map to the JSON-RPC shape expected by bundlers
+// v0.6 maps are sent as-is; v0.7 packed maps are unpacked into the v0.7 RPC fields
+func toBundlerUserOp(userOp map[string]interface{}) map[string]interface{} {
+	if !IsPackedUserOp(userOp) {
+		return userOp
+	}
+
+	accountGasLimits := hexToBytes(userOp["accountGasLimits"])
+	gasFees := hexToBytes(userOp["gasFees"])
+	if len(accountGasLimits) != 32 || len(gasFees) != 32 {
+		return userOp
+	}
+
+	rpcOp := map[string]interface{}{
+		"sender":               userOp["sender"],
+		"nonce":                userOp["nonce"],
+		"callData":             userOp["callData"],
+		"verificationGasLimit": quantity(accountGasLimits[:16]),
+		"callGasLimit":         quantity(accountGasLimits[16:]),
+		"preVerificationGas":   userOp["preVerificationGas"],
+		"maxPriorityFeePerGas": quantity(gasFees[:16]),
+		"maxFeePerGas":         quantity(gasFees[16:]),
+		"signature":            userOp["signature"],
+	}
+
+	// initCode = factory (20 bytes) || factoryData
+	if initCode := hexToBytes(userOp["initCode"]); len(initCode) >= 20 {
+		rpcOp["factory"] = common.BytesToAddress(initCode[:20]).Hex()
+		rpcOp["factoryData"] = hexutil.Encode(initCode[20:])
+	}
+
+	// paymasterAndData = paymaster (20) || verificationGasLimit (16) || postOpGasLimit (16) || paymasterData
+	if pm := hexToBytes(userOp["paymasterAndData"]); len(pm) >= 52 {
+		rpcOp["paymaster"] = common.BytesToAddress(pm[:20]).Hex()
+		rpcOp["paymasterVerificationGasLimit"] = quantity(pm[20:36])
+		rpcOp["paymasterPostOpGasLimit"] = quantity(pm[36:52])
+		rpcOp["paymasterData"] = hexutil.Encode(pm[52:])
+	}
+
+	return rpcOp
+}
+
+// quantity encodes big-endian bytes as a JSON-RPC hex quantity
+func quantity(b []byte) string {
+	return hexutil.EncodeBig(new(big.Int).SetBytes(b))
+}
+
+// SetBundler selects how UserOps are submitted
+// In bundler mode, bundlerURL must point to an ERC-4337 bundler RPC.
+// In direct mode an optional bundlerURL is still used for gas estimation.
+func (m *Manager) SetBundler(mode, bundlerURL string) error {
+	if mode == SubmitModeBundler && bundlerURL == "" {
+		return fmt.Errorf("BUNDLER_URL is required when SUBMIT_MODE=%s", SubmitModeBundler)
+	}
+
+	if bundlerURL != "" {
+		bundler, err := NewBundlerClient(bundlerURL)
+		if err != nil {
+			return err
+		}
+		m.bundler = bundler
+	}
+
+	if mode == SubmitModeBundler {
+		m.submitMode = SubmitModeBundler
+	} else {
+		m.submitMode = SubmitModeDirect
+	}
+	return nil
+}
+
+// UserOpGasEstimate holds the gas limits returned by eth_estimateUserOperationGas
+type UserOpGasEstimate struct {
+	PreVerificationGas   *big.Int
+	VerificationGasLimit *big.Int
+	CallGasLimit         *big.Int
+}
+
+// rpcQuantity accepts both hex-string and plain-number JSON quantities (bundlers differ)
+type rpcQuantity struct {
+	*big.Int
+}
+
+func (q *rpcQuantity) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		v, err := hexutil.DecodeBig(str)
+		if err != nil {
+			return err
+		}
+		q.Int = v
+		return nil
+	}
+
+	v, ok := new(big.Int).SetString(string(data), 10)
+	if !ok {
+		return fmt.Errorf("invalid quantity %s", string(data))
+	}
+	q.Int = v
+	return nil
+}
+
+// EstimateUserOperationGas calls eth_estimateUserOperationGas for an (unsigned) UserOp
+func (b *BundlerClient) EstimateUserOperationGas(ctx context.Context, userOp map[string]interface{}, entryPoint common.Address) (*UserOpGasEstimate, error) {
+	var result struct {
+		PreVerificationGas   rpcQuantity `json:"preVerificationGas"`
+		VerificationGasLimit rpcQuantity `json:"verificationGasLimit"`
+		CallGasLimit         rpcQuantity `json:"callGasLimit"`
+	}
+	if err := b.call(ctx, &result, "eth_estimateUserOperationGas", toBundlerUserOp(userOp), entryPoint.Hex()); err != nil {
+		return nil, err
+	}
+	if result.PreVerificationGas.Int == nil || result.VerificationGasLimit.Int == nil || result.CallGasLimit.Int == nil {
+		return nil, fmt.Errorf("incomplete gas estimate from bundler")
+	}
+
+	return &UserOpGasEstimate{
+		PreVerificationGas:   result.PreVerificationGas.Int,
+		VerificationGasLimit: result.VerificationGasLimit.Int,
+		CallGasLimit:         result.CallGasLimit.Int,
+	}, nil
+}
+
+// EstimateUserOpGas asks the configured bundler for gas limits of a v0.6-shaped UserOp map
+func (m *Manager) EstimateUserOpGas(ctx context.Context, userOp map[string]interface{}) (*UserOpGasEstimate, error) {
+	if m.bundler == nil {
+		return nil, fmt.Errorf("no bundler configured for gas estimation")
+	}
+
+	// The bundler validates the op against the configured EntryPoint's layout
+	op := userOp
+	if m.entryPointVersion == EntryPointV07 && !IsPackedUserOp(op) {
+		op = BuildUserOpV07(op)
+	}
+
+	return m.bundler.EstimateUserOperationGas(ctx, op, m.EntryPointAddress())
+}
+
+// SubmitMode returns the configured submission mode ("direct" or "bundler")
+func (m *Manager) SubmitMode() string {
+	return m.submitMode
+}
+
+// submitViaBundler relays a signed UserOp to the bundler and waits for the real transaction hash
+func (m *Manager) submitViaBundler(ctx context.Context, userOpData map[string]interface{}) (string, error) {
+	log.Printf("🚀 Relaying UserOperation to bundler (EntryPoint %s)...", m.EntryPointAddress().Hex())
+
+	userOpHash, err := m.bundler.SendUserOperation(ctx, userOpData, m.EntryPointAddress())
+	if err != nil {
+		return "", err
+	}
+	log.Printf("📨 Bundler accepted UserOperation: %s", userOpHash)
+
+	receipt, err := m.bundler.WaitForUserOperationReceipt(ctx, userOpHash)
+	if err != nil {
+		return "", &UserOpPendingError{UserOpHash: userOpHash, Err: err}
+	}
+
+	txHash := receipt.Receipt.TransactionHash
+	if !receipt.Success {
+		return txHash, &UserOpRevertedError{UserOpHash: userOpHash, TxHash: txHash, Reason: receipt.Reason}
+	}
+
+	log.Printf("✅ UserOperation included! Tx: %s", txHash)
+	return txHash, nil
+}
@@ -0,0 +1,231 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testUserOpHash = "0x1d5e1a7fae9f1a02e1d5c1b0c2d4a7cf9b1a3f0e6a5c7d9e2b4f6a8c0e1d3f5a"
+
+// fakeBundler answers ERC-4337 JSON-RPC methods from handlers keyed by method name and
+// records the params of every call
+type fakeBundler struct {
+	mu       sync.Mutex
+	handlers map[string]func(params []json.RawMessage) (interface{}, map[string]interface{})
+	calls    map[string][][]json.RawMessage
+}
+
+func (b *fakeBundler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	reply := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+
+	b.mu.Lock()
+	if b.calls == nil {
+		b.calls = make(map[string][][]json.RawMessage)
+	}
+	b.calls[req.Method] = append(b.calls[req.Method], req.Params)
+	handler, ok := b.handlers[req.Method]
+	b.mu.Unlock()
+
+	if !ok {
+		reply["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+	} else if result, rpcErr := handler(req.Params); rpcErr != nil {
+		reply["error"] = rpcErr
+	} else {
+		reply["result"] = result
+	}
+	json.NewEncoder(w).Encode(reply)
+}
+
+func (b *fakeBundler) callCount(method string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.calls[method])
+}
+
+func newBundlerManager(t *testing.T, b *fakeBundler, version string) *Manager {
+	t.Helper()
+	server := httptest.NewServer(b)
+	t.Cleanup(server.Close)
+
+	m := &Manager{entryPointVersion: version, entryPointAddr: DefaultEntryPointAddress}
+	if version == EntryPointV07 {
+		m.entryPointAddr = EntryPointV07Address
+	}
+	if err := m.SetBundler(SubmitModeBundler, server.URL); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(m.bundler.Close)
+
+	interval := bundlerReceiptPollInterval
+	bundlerReceiptPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { bundlerReceiptPollInterval = interval })
+	return m
+}
+
+// acceptUserOp answers eth_sendUserOperation with testUserOpHash
+func acceptUserOp(params []json.RawMessage) (interface{}, map[string]interface{}) {
+	return testUserOpHash, nil
+}
+
+func TestSubmitUserOperationViaBundler(t *testing.T) {
+	var polls int
+	b := &fakeBundler{handlers: map[string]func([]json.RawMessage) (interface{}, map[string]interface{}){
+		"eth_sendUserOperation": acceptUserOp,
+		"eth_getUserOperationReceipt": func(params []json.RawMessage) (interface{}, map[string]interface{}) {
+			// Not included on the first poll
+			if polls++; polls == 1 {
+				return nil, nil
+			}
+			return map[string]interface{}{
+				"userOpHash": testUserOpHash,
+				"success":    true,
+				"receipt":    map[string]interface{}{"transactionHash": "0xabc123", "status": "0x1"},
+			}, nil
+		},
+	}}
+	m := newBundlerManager(t, b, EntryPointV06)
+
+	txHash, err := m.SubmitUserOperation(context.Background(), sponsorTestUserOp(), "")
+	if err != nil {
+		t.Fatalf("SubmitUserOperation: %v", err)
+	}
+	if txHash != "0xabc123" {
+		t.Fatalf("txHash = %s, want the bundle transaction hash", txHash)
+	}
+	if n := b.callCount("eth_getUserOperationReceipt"); n != 2 {
+		t.Fatalf("receipt polled %d times, want 2", n)
+	}
+
+	var sent map[string]interface{}
+	var entryPoint string
+	json.Unmarshal(b.calls["eth_sendUserOperation"][0][0], &sent)
+	json.Unmarshal(b.calls["eth_sendUserOperation"][0][1], &entryPoint)
+	if sent["sender"] != "0x4444444444444444444444444444444444444444" || sent["callGasLimit"] != "0x186a0" {
+		t.Fatalf("sent UserOp = %v", sent)
+	}
+	if !strings.EqualFold(entryPoint, DefaultEntryPointAddress) {
+		t.Fatalf("entryPoint = %s", entryPoint)
+	}
+}
+
+func TestSubmitUserOperationViaBundlerSurfacesRejection(t *testing.T) {
+	b := &fakeBundler{handlers: map[string]func([]json.RawMessage) (interface{}, map[string]interface{}){
+		"eth_sendUserOperation": func(params []json.RawMessage) (interface{}, map[string]interface{}) {
+			return nil, map[string]interface{}{"code": -32500, "message": "AA23 reverted (or OOG)", "data": "0xdeadbeef"}
+		},
+	}}
+	m := newBundlerManager(t, b, EntryPointV06)
+
+	_, err := m.SubmitUserOperation(context.Background(), sponsorTestUserOp(), "")
+	var bundlerErr *BundlerError
+	if !errors.As(err, &bundlerErr) {
+		t.Fatalf("err = %v, want *BundlerError", err)
+	}
+	if bundlerErr.Code != -32500 || bundlerErr.Message != "AA23 reverted (or OOG)" || bundlerErr.Data != "0xdeadbeef" {
+		t.Fatalf("bundler error = %+v", bundlerErr)
+	}
+	if aaErr, ok := DecodeAAError(err); !ok || aaErr.Code != "AA23" {
+		t.Fatalf("DecodeAAError = %+v, %v", aaErr, ok)
+	}
+	if n := b.callCount("eth_getUserOperationReceipt"); n != 0 {
+		t.Fatalf("receipt polled %d times after a rejected send", n)
+	}
+}
+
+func TestSubmitUserOperationViaBundlerReverted(t *testing.T) {
+	b := &fakeBundler{handlers: map[string]func([]json.RawMessage) (interface{}, map[string]interface{}){
+		"eth_sendUserOperation": acceptUserOp,
+		"eth_getUserOperationReceipt": func(params []json.RawMessage) (interface{}, map[string]interface{}) {
+			return map[string]interface{}{
+				"userOpHash": testUserOpHash,
+				"success":    false,
+				"reason":     "0x08c379a0",
+				"receipt":    map[string]interface{}{"transactionHash": "0xdef456", "status": "0x1"},
+			}, nil
+		},
+	}}
+	m := newBundlerManager(t, b, EntryPointV06)
+
+	txHash, err := m.SubmitUserOperation(context.Background(), sponsorTestUserOp(), "")
+	var reverted *UserOpRevertedError
+	if !errors.As(err, &reverted) || reverted.Reason != "0x08c379a0" || reverted.UserOpHash != testUserOpHash {
+		t.Fatalf("err = %v, want *UserOpRevertedError with reason", err)
+	}
+	// The bundle was mined, so its hash is still reported
+	if txHash != "0xdef456" || reverted.TxHash != "0xdef456" {
+		t.Fatalf("txHash = %s, error txHash = %s", txHash, reverted.TxHash)
+	}
+}
+
+func TestSubmitUserOperationViaBundlerUnconfirmed(t *testing.T) {
+	b := &fakeBundler{handlers: map[string]func([]json.RawMessage) (interface{}, map[string]interface{}){
+		"eth_sendUserOperation": acceptUserOp,
+		"eth_getUserOperationReceipt": func(params []json.RawMessage) (interface{}, map[string]interface{}) {
+			return nil, map[string]interface{}{"code": -32000, "message": "receipt lookup failed"}
+		},
+	}}
+	m := newBundlerManager(t, b, EntryPointV06)
+
+	// Accepted by the bundler: the op may still land, so it is not reported as rejected
+	_, err := m.SubmitUserOperation(context.Background(), sponsorTestUserOp(), "")
+	var pending *UserOpPendingError
+	if !errors.As(err, &pending) || pending.UserOpHash != testUserOpHash {
+		t.Fatalf("err = %v, want *UserOpPendingError", err)
+	}
+}
+
+func TestSubmitUserOperationViaBundlerUnpacksV07(t *testing.T) {
+	b := &fakeBundler{handlers: map[string]func([]json.RawMessage) (interface{}, map[string]interface{}){
+		"eth_sendUserOperation": acceptUserOp,
+		"eth_getUserOperationReceipt": func(params []json.RawMessage) (interface{}, map[string]interface{}) {
+			return map[string]interface{}{"success": true, "receipt": map[string]interface{}{"transactionHash": "0x07"}}, nil
+		},
+	}}
+	m := newBundlerManager(t, b, EntryPointV07)
+
+	op := BuildUserOpV07(sponsorTestUserOp())
+	op["initCode"] = "0x9406Cc6185a346906296840746125a0E449764545fbfb9cf"
+	if _, err := m.SubmitUserOperation(context.Background(), op, ""); err != nil {
+		t.Fatalf("SubmitUserOperation: %v", err)
+	}
+
+	var sent map[string]interface{}
+	var entryPoint string
+	json.Unmarshal(b.calls["eth_sendUserOperation"][0][0], &sent)
+	json.Unmarshal(b.calls["eth_sendUserOperation"][0][1], &entryPoint)
+	if _, packed := sent["accountGasLimits"]; packed {
+		t.Fatalf("bundler received the packed layout: %v", sent)
+	}
+	if sent["verificationGasLimit"] != "0x30d40" || sent["callGasLimit"] != "0x186a0" || sent["maxFeePerGas"] != "0x3b9aca00" {
+		t.Fatalf("unpacked gas fields = %v", sent)
+	}
+	if sent["factory"] != "0x9406Cc6185a346906296840746125a0E44976454" || sent["factoryData"] != "0x5fbfb9cf" {
+		t.Fatalf("factory fields = %v, %v", sent["factory"], sent["factoryData"])
+	}
+	if entryPoint != EntryPointV07Address {
+		t.Fatalf("entryPoint = %s", entryPoint)
+	}
+}
+
+func TestSetBundlerRequiresURLInBundlerMode(t *testing.T) {
+	m := &Manager{}
+	if err := m.SetBundler(SubmitModeBundler, ""); err == nil {
+		t.Fatal("expected an error without BUNDLER_URL")
+	}
+	if err := m.SetBundler("", ""); err != nil || m.SubmitMode() != SubmitModeDirect {
+		t.Fatalf("default mode = %q, %v; want direct", m.SubmitMode(), err)
+	}
+}
@@ -0,0 +1,187 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/models"
+	"ai-wallet-backend/pkg/crypto"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultCrossDeviceTokenTTL is how long a cross-device signing token can be redeemed
+const DefaultCrossDeviceTokenTTL = 3 * time.Minute
+
+// Cross-device token errors
+var (
+	ErrCrossDeviceTokenInvalid = errors.New("cross-device token is invalid or already used")
+	ErrCrossDeviceTokenExpired = errors.New("cross-device token expired")
+)
+
+// CrossDeviceTokenStore issues and redeems the one-time tokens that let another device
+// sign and submit a pending UserOp
+type CrossDeviceTokenStore interface {
+	// Create issues a token for userID's pending UserOp
+	Create(ctx context.Context, userID, userOpHash string, ttl time.Duration) (token string, expiresAt time.Time, err error)
+	// Consume redeems a token exactly once, even under concurrent requests, or returns
+	// ErrCrossDeviceTokenInvalid / ErrCrossDeviceTokenExpired
+	Consume(ctx context.Context, token string) (*models.CrossDeviceToken, error)
+	// DeleteExpired removes all expired tokens and returns how many were deleted
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// hashCrossDeviceToken is the stored form of a token
+func hashCrossDeviceToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newCrossDeviceToken generates a token and the record stored for it
+func newCrossDeviceToken(userID, userOpHash string, ttl time.Duration) (string, *models.CrossDeviceToken, error) {
+	token, err := crypto.GenerateRandomToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate cross-device token: %w", err)
+	}
+
+	now := time.Now()
+	return token, &models.CrossDeviceToken{
+		TokenHash:  hashCrossDeviceToken(token),
+		UserID:     userID,
+		UserOpHash: userOpHash,
+		ExpiresAt:  now.Add(ttl),
+		CreatedAt:  now,
+	}, nil
+}
+
+// GormCrossDeviceTokenStore stores cross-device tokens in Postgres
+type GormCrossDeviceTokenStore struct {
+	db *gorm.DB
+}
+
+// NewGormCrossDeviceTokenStore creates a Postgres-backed cross-device token store
+func NewGormCrossDeviceTokenStore(db *gorm.DB) *GormCrossDeviceTokenStore {
+	return &GormCrossDeviceTokenStore{db: db}
+}
+
+// Create issues a token for a pending UserOp
+func (s *GormCrossDeviceTokenStore) Create(ctx context.Context, userID, userOpHash string, ttl time.Duration) (string, time.Time, error) {
+	token, record, err := newCrossDeviceToken(userID, userOpHash, ttl)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if err := s.db.WithContext(ctx).Create(record).Error; err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to save cross-device token: %w", err)
+	}
+	return token, record.ExpiresAt, nil
+}
+
+// Consume deletes the token and returns it; DELETE ... RETURNING makes the lookup and
+// the removal one statement, so only one request can redeem a token
+func (s *GormCrossDeviceTokenStore) Consume(ctx context.Context, token string) (*models.CrossDeviceToken, error) {
+	var records []models.CrossDeviceToken
+	err := s.db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ?", hashCrossDeviceToken(token)).
+		Delete(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeem cross-device token: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, ErrCrossDeviceTokenInvalid
+	}
+	if records[0].IsExpired() {
+		return nil, ErrCrossDeviceTokenExpired
+	}
+	return &records[0], nil
+}
+
+// DeleteExpired removes expired cross-device tokens
+func (s *GormCrossDeviceTokenStore) DeleteExpired(ctx context.Context) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.CrossDeviceToken{})
+	return result.RowsAffected, result.Error
+}
+
+// MemoryCrossDeviceTokenStore keeps cross-device tokens in process memory (tests and local development)
+type MemoryCrossDeviceTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]*models.CrossDeviceToken
+}
+
+// NewMemoryCrossDeviceTokenStore creates an in-memory cross-device token store
+func NewMemoryCrossDeviceTokenStore() *MemoryCrossDeviceTokenStore {
+	return &MemoryCrossDeviceTokenStore{tokens: make(map[string]*models.CrossDeviceToken)}
+}
+
+// Create issues a token for a pending UserOp
+func (s *MemoryCrossDeviceTokenStore) Create(ctx context.Context, userID, userOpHash string, ttl time.Duration) (string, time.Time, error) {
+	token, record, err := newCrossDeviceToken(userID, userOpHash, ttl)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	s.mu.Lock()
+	s.tokens[record.TokenHash] = record
+	s.mu.Unlock()
+	return token, record.ExpiresAt, nil
+}
+
+// Consume deletes the token and returns it
+func (s *MemoryCrossDeviceTokenStore) Consume(ctx context.Context, token string) (*models.CrossDeviceToken, error) {
+	hash := hashCrossDeviceToken(token)
+
+	s.mu.Lock()
+	record, ok := s.tokens[hash]
+	delete(s.tokens, hash)
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, ErrCrossDeviceTokenInvalid
+	}
+	if record.IsExpired() {
+		return nil, ErrCrossDeviceTokenExpired
+	}
+	return record, nil
+}
+
+// DeleteExpired removes expired cross-device tokens
+func (s *MemoryCrossDeviceTokenStore) DeleteExpired(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for hash, record := range s.tokens {
+		if record.IsExpired() {
+			delete(s.tokens, hash)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// RunCrossDeviceTokenSweeper periodically deletes expired cross-device tokens until ctx is cancelled
+func RunCrossDeviceTokenSweeper(ctx context.Context, store CrossDeviceTokenStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := store.DeleteExpired(ctx)
+			if err != nil {
+				log.Printf("⚠️  Failed to sweep expired cross-device tokens: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("🧹 Swept %d expired cross-device tokens", deleted)
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testCrossDeviceTokenSingleUse runs the behaviour both stores share
+func testCrossDeviceTokenSingleUse(t *testing.T, store CrossDeviceTokenStore) {
+	ctx := context.Background()
+	token, expiresAt, err := store.Create(ctx, "user-a", "0xhash", time.Minute)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if time.Until(expiresAt) <= 0 || time.Until(expiresAt) > time.Minute {
+		t.Fatalf("expiresAt = %s", expiresAt)
+	}
+
+	if _, err := store.Consume(ctx, "not-a-token"); !errors.Is(err, ErrCrossDeviceTokenInvalid) {
+		t.Fatalf("Consume(unknown) = %v, want ErrCrossDeviceTokenInvalid", err)
+	}
+
+	// Two devices race to redeem the same token: exactly one wins
+	var redeemed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			record, err := store.Consume(ctx, token)
+			switch {
+			case err == nil:
+				if record.UserID != "user-a" || record.UserOpHash != "0xhash" {
+					t.Errorf("Consume = %+v", record)
+				}
+				redeemed.Add(1)
+			case !errors.Is(err, ErrCrossDeviceTokenInvalid):
+				t.Errorf("Consume: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if redeemed.Load() != 1 {
+		t.Fatalf("token redeemed %d times, want once", redeemed.Load())
+	}
+}
+
+// testCrossDeviceTokenExpiry checks expired tokens are rejected and swept
+func testCrossDeviceTokenExpiry(t *testing.T, store CrossDeviceTokenStore) {
+	ctx := context.Background()
+	expired, _, err := store.Create(ctx, "user-a", "0xexpired", -time.Second)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := store.Consume(ctx, expired); !errors.Is(err, ErrCrossDeviceTokenExpired) {
+		t.Fatalf("Consume(expired) = %v, want ErrCrossDeviceTokenExpired", err)
+	}
+	// Rejecting the token also used it up
+	if _, err := store.Consume(ctx, expired); !errors.Is(err, ErrCrossDeviceTokenInvalid) {
+		t.Fatalf("Consume(expired) again = %v, want ErrCrossDeviceTokenInvalid", err)
+	}
+
+	if _, _, err := store.Create(ctx, "user-a", "0xstale", -time.Second); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	live, _, err := store.Create(ctx, "user-a", "0xlive", time.Minute)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	deleted, err := store.DeleteExpired(ctx)
+	if err != nil || deleted != 1 {
+		t.Fatalf("DeleteExpired = %d, %v; want 1, nil", deleted, err)
+	}
+	if _, err := store.Consume(ctx, live); err != nil {
+		t.Fatalf("Consume(live) after sweep: %v", err)
+	}
+}
+
+func TestMemoryCrossDeviceTokenStore(t *testing.T) {
+	testCrossDeviceTokenSingleUse(t, NewMemoryCrossDeviceTokenStore())
+	testCrossDeviceTokenExpiry(t, NewMemoryCrossDeviceTokenStore())
+}
+
+func TestGormCrossDeviceTokenStore(t *testing.T) {
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&models.CrossDeviceToken{}); err != nil {
+		t.Fatal(err)
+	}
+	// One connection serializes the racing redemptions; the single DELETE ... RETURNING
+	// statement is what makes them safe across connections too
+	testCrossDeviceTokenSingleUse(t, NewGormCrossDeviceTokenStore(db))
+	testCrossDeviceTokenExpiry(t, NewGormCrossDeviceTokenStore(db))
+}
+
+func TestCrossDeviceTokenStoredHashed(t *testing.T) {
+	store := NewMemoryCrossDeviceTokenStore()
+	token, _, err := store.Create(context.Background(), "user-a", "0xhash", time.Minute)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	for hash := range store.tokens {
+		if hash == token || hash != hashCrossDeviceToken(token) {
+			t.Fatalf("stored key %q for token %q", hash, token)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrDeploymentFailed is returned when the createAccount transaction is mined but
+// reverts, or succeeds without leaving code at the wallet address
+var ErrDeploymentFailed = errors.New("wallet deployment failed")
+
+// ErrNoDeployerKey is returned when an undeployed wallet cannot be deployed for lack of a key
+var ErrNoDeployerKey = errors.New("no deployer key configured (BUNDLER_PRIVATE_KEY)")
+
+// DeployTimeout bounds how long DeployWallet waits for the createAccount receipt
+const DeployTimeout = 2 * time.Minute
+
+// createAccountSelector is P256AccountFactory.createAccount(uint256,uint256,uint256)
+var createAccountSelector = common.FromHex("0x4c1ed7f5")
+
+var createAccountArguments = func() abi.Arguments {
+	uint256Type, _ := abi.NewType("uint256", "", nil)
+	return abi.Arguments{{Type: uint256Type}, {Type: uint256Type}, {Type: uint256Type}}
+}()
+
+// Deployment is the outcome of DeployWallet
+type Deployment struct {
+	Wallet          *models.Wallet `json:"wallet"`
+	TxHash          string         `json:"txHash,omitempty"`      // empty if the wallet was already deployed
+	BlockNumber     uint64         `json:"blockNumber,omitempty"` // block that included createAccount
+	AlreadyDeployed bool           `json:"alreadyDeployed"`
+}
+
+// DeployWallet deploys a counterfactual wallet ahead of its first transfer by calling
+// the factory's createAccount from the deployer EOA (BUNDLER_PRIVATE_KEY). No UserOp
+// is involved, so the user does not have to sign anything.
+//
+// It is idempotent: a wallet that already has code is only marked as deployed.
+// is_deployed and deployed_at are set from the chain, never assumed.
+func (m *Manager) DeployWallet(ctx context.Context, w *models.Wallet, deployerKeyHex string) (*Deployment, error) {
+	// One deployment at a time, so concurrent requests cannot pay for createAccount twice
+	m.deployMu.Lock()
+	defer m.deployMu.Unlock()
+
+	deployed, err := m.IsWalletDeployed(ctx, w.Address)
+	if err != nil {
+		return nil, err
+	}
+	if deployed {
+		// Deployed lazily through initCode, or by an earlier request; IsWalletDeployed
+		// has already synced the row, so only w needs updating
+		if !w.IsDeployed {
+			now := time.Now().UTC()
+			w.IsDeployed = true
+			if w.DeployedAt == nil {
+				w.DeployedAt = &now
+			}
+		}
+		log.Printf("✓ Wallet %s already deployed", w.Address)
+		return &Deployment{Wallet: w, AlreadyDeployed: true}, nil
+	}
+
+	if deployerKeyHex == "" {
+		return nil, ErrNoDeployerKey
+	}
+	privateKey, err := crypto.HexToECDSA(deployerKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse deployer private key: %w", err)
+	}
+	deployer := crypto.PubkeyToAddress(privateKey.PublicKey)
+
+	data, err := createAccountCallData(w)
+	if err != nil {
+		return nil, err
+	}
+	factory := common.HexToAddress(w.FactoryAddress)
+
+	gasLimit, err := m.ethClient.EstimateGas(ctx, ethereum.CallMsg{From: deployer, To: &factory, Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate createAccount gas: %w", err)
+	}
+	gasLimit = gasLimit * 120 / 100
+
+	gasPrice, err := m.GetGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := m.ethClient.PendingNonceAt(ctx, deployer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployer nonce: %w", err)
+	}
+
+	tx := types.NewTransaction(nonce, factory, big.NewInt(0), gasLimit, gasPrice, data)
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(big.NewInt(int64(w.ChainID))), privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	if err := m.ethClient.SendTransaction(ctx, signedTx); err != nil {
+		return nil, fmt.Errorf("failed to send createAccount: %w", err)
+	}
+	log.Printf("📤 createAccount for %s sent by %s: %s", w.Address, deployer.Hex(), signedTx.Hash().Hex())
+
+	waitCtx, cancel := context.WithTimeout(ctx, DeployTimeout)
+	defer cancel()
+	receipt, err := bind.WaitMined(waitCtx, m.ethClient, signedTx)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for createAccount %s: %w", signedTx.Hash().Hex(), err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("%w: createAccount %s reverted", ErrDeploymentFailed, signedTx.Hash().Hex())
+	}
+	if deployed, err := m.IsWalletDeployed(ctx, w.Address); err != nil {
+		return nil, err
+	} else if !deployed {
+		return nil, fmt.Errorf("%w: no code at %s after %s", ErrDeploymentFailed, w.Address, signedTx.Hash().Hex())
+	}
+
+	header, err := m.ethClient.HeaderByNumber(ctx, receipt.BlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block %s: %w", receipt.BlockNumber, err)
+	}
+	if err := m.markDeployed(ctx, w, time.Unix(int64(header.Time), 0)); err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ Wallet %s deployed in block %d", w.Address, receipt.BlockNumber.Uint64())
+	return &Deployment{
+		Wallet:      w,
+		TxHash:      signedTx.Hash().Hex(),
+		BlockNumber: receipt.BlockNumber.Uint64(),
+	}, nil
+}
+
+// markDeployed records the deployment on the wallet row and on w
+func (m *Manager) markDeployed(ctx context.Context, w *models.Wallet, deployedAt time.Time) error {
+	deployedAt = deployedAt.UTC()
+	if err := m.db.WithContext(ctx).Model(w).Updates(map[string]interface{}{
+		"is_deployed": true,
+		"deployed_at": deployedAt,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to mark wallet deployed: %w", err)
+	}
+	w.IsDeployed = true
+	w.DeployedAt = &deployedAt
+	return nil
+}
+
+// createAccountCallData encodes the factory call that deploys w at its counterfactual address
+func createAccountCallData(w *models.Wallet) ([]byte, error) {
+	publicKey, err := P256PublicKeyFromHex(w.PublicKeyX, w.PublicKeyY)
+	if err != nil {
+		return nil, err
+	}
+	salt := big.NewInt(0)
+	if w.Salt != "" {
+		if salt, err = ParseSalt(w.Salt); err != nil {
+			return nil, err
+		}
+	}
+	args, err := createAccountArguments.Pack(publicKey.X, publicKey.Y, salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack createAccount: %w", err)
+	}
+	return append(append([]byte{}, createAccountSelector...), args...), nil
+}
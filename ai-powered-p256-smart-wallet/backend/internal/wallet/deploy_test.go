@@ -0,0 +1,215 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const deployBlockTime = 1_700_000_000
+
+// deployNode answers the JSON-RPC calls DeployWallet makes. The wallet has code
+// once deployed is set, which a createAccount transaction does.
+type deployNode struct {
+	mu       sync.Mutex
+	deployed bool
+	reverts  bool
+	sent     []*types.Transaction
+}
+
+func (n *deployNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	reply := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	switch req.Method {
+	case "eth_getCode":
+		reply["result"] = "0x"
+		if n.deployed {
+			reply["result"] = "0x6080"
+		}
+	case "eth_estimateGas":
+		reply["result"] = "0x30d40"
+	case "eth_gasPrice":
+		reply["result"] = "0x3b9aca00"
+	case "eth_getTransactionCount":
+		reply["result"] = "0x7"
+	case "eth_sendRawTransaction":
+		var raw hexutil.Bytes
+		json.Unmarshal(req.Params[0], &raw)
+		tx := new(types.Transaction)
+		tx.UnmarshalBinary(raw)
+		n.sent = append(n.sent, tx)
+		n.deployed = !n.reverts
+		reply["result"] = tx.Hash().Hex()
+	case "eth_getTransactionReceipt":
+		status := "0x1"
+		if n.reverts {
+			status = "0x0"
+		}
+		reply["result"] = map[string]interface{}{
+			"transactionHash":   n.sent[0].Hash().Hex(),
+			"blockNumber":       "0x10",
+			"blockHash":         common.Hash{1}.Hex(),
+			"status":            status,
+			"cumulativeGasUsed": "0x30d40",
+			"gasUsed":           "0x30d40",
+			"logsBloom":         hexutil.Encode(make([]byte, 256)),
+			"logs":              []interface{}{},
+		}
+	case "eth_getBlockByNumber":
+		reply["result"] = map[string]interface{}{
+			"parentHash":       common.Hash{}.Hex(),
+			"sha3Uncles":       types.EmptyUncleHash.Hex(),
+			"miner":            common.Address{}.Hex(),
+			"stateRoot":        common.Hash{}.Hex(),
+			"transactionsRoot": types.EmptyTxsHash.Hex(),
+			"receiptsRoot":     types.EmptyReceiptsHash.Hex(),
+			"logsBloom":        hexutil.Encode(make([]byte, 256)),
+			"difficulty":       "0x0",
+			"number":           "0x10",
+			"gasLimit":         "0x1c9c380",
+			"gasUsed":          "0x0",
+			"timestamp":        hexutil.EncodeUint64(deployBlockTime),
+			"extraData":        "0x",
+		}
+	default:
+		reply["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+	}
+	json.NewEncoder(w).Encode(reply)
+}
+
+func newDeployManager(t *testing.T, node *deployNode) *Manager {
+	t.Helper()
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+	client, err := ethclient.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return &Manager{db: dbtest.DryRun(t), ethClient: NewRPCClient(client), chainID: 133}
+}
+
+func undeployedWallet(t *testing.T) *models.Wallet {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, y := P256PublicKeyToHex(&P256PublicKey{X: key.X, Y: key.Y})
+	return &models.Wallet{
+		ID:             "w1",
+		Address:        "0x4444444444444444444444444444444444444444",
+		PublicKeyX:     x,
+		PublicKeyY:     y,
+		ChainID:        133,
+		FactoryAddress: "0x5555555555555555555555555555555555555555",
+		Salt:           "42",
+	}
+}
+
+func TestCreateAccountCallData(t *testing.T) {
+	w := undeployedWallet(t)
+	data, err := createAccountCallData(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Same layout as the initCode suffix: selector, publicKeyX, publicKeyY, salt
+	want := "0x4c1ed7f5" + strings.TrimPrefix(w.PublicKeyX, "0x") + strings.TrimPrefix(w.PublicKeyY, "0x") +
+		"000000000000000000000000000000000000000000000000000000000000002a"
+	if got := hexutil.Encode(data); got != want {
+		t.Fatalf("callData = %s\nwant       %s", got, want)
+	}
+}
+
+func TestDeployWallet(t *testing.T) {
+	node := &deployNode{}
+	m := newDeployManager(t, node)
+	w := undeployedWallet(t)
+	deployerKey, _ := crypto.GenerateKey()
+
+	deployment, err := m.DeployWallet(t.Context(), w, hexutil.Encode(crypto.FromECDSA(deployerKey))[2:])
+	if err != nil {
+		t.Fatalf("DeployWallet: %v", err)
+	}
+	if deployment.AlreadyDeployed || deployment.BlockNumber != 16 || deployment.TxHash != node.sent[0].Hash().Hex() {
+		t.Fatalf("deployment = %+v", deployment)
+	}
+
+	// One createAccount call to the factory, signed for the wallet's chain
+	tx := node.sent[0]
+	if *tx.To() != common.HexToAddress(w.FactoryAddress) || !bytes.HasPrefix(tx.Data(), createAccountSelector) {
+		t.Fatalf("sent tx to %s with data %x", tx.To().Hex(), tx.Data())
+	}
+	if tx.ChainId().Int64() != 133 || tx.Gas() != 240000 {
+		t.Fatalf("chainId %s, gas %d", tx.ChainId(), tx.Gas())
+	}
+
+	// deployed_at comes from the receipt's block, not the wall clock
+	if !w.IsDeployed || w.DeployedAt == nil || !w.DeployedAt.Equal(time.Unix(deployBlockTime, 0)) {
+		t.Fatalf("wallet not marked from receipt: deployed=%v at=%v", w.IsDeployed, w.DeployedAt)
+	}
+
+	// Deploying again is a no-op
+	deployment, err = m.DeployWallet(t.Context(), w, "")
+	if err != nil || !deployment.AlreadyDeployed || len(node.sent) != 1 {
+		t.Fatalf("second deploy = %+v, %v (sent %d txs)", deployment, err, len(node.sent))
+	}
+}
+
+func TestDeployWalletAlreadyOnChain(t *testing.T) {
+	node := &deployNode{deployed: true}
+	m := newDeployManager(t, node)
+	w := undeployedWallet(t)
+
+	// Deployed lazily via initCode: no key or transaction needed, the row is synced
+	deployment, err := m.DeployWallet(t.Context(), w, "")
+	if err != nil || !deployment.AlreadyDeployed || len(node.sent) != 0 {
+		t.Fatalf("deployment = %+v, %v", deployment, err)
+	}
+	if !w.IsDeployed || w.DeployedAt == nil {
+		t.Fatalf("wallet not marked deployed: %+v", w)
+	}
+}
+
+func TestDeployWalletErrors(t *testing.T) {
+	m := newDeployManager(t, &deployNode{})
+	if _, err := m.DeployWallet(t.Context(), undeployedWallet(t), ""); !errors.Is(err, ErrNoDeployerKey) {
+		t.Fatalf("without key: %v", err)
+	}
+
+	node := &deployNode{reverts: true}
+	m = newDeployManager(t, node)
+	w := undeployedWallet(t)
+	deployerKey, _ := crypto.GenerateKey()
+	if _, err := m.DeployWallet(t.Context(), w, hexutil.Encode(crypto.FromECDSA(deployerKey))[2:]); !errors.Is(err, ErrDeploymentFailed) {
+		t.Fatalf("reverted createAccount: %v", err)
+	}
+	if w.IsDeployed {
+		t.Fatal("reverted deployment marked the wallet deployed")
+	}
+}
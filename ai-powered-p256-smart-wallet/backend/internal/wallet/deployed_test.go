@@ -0,0 +1,82 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// recordUpdates collects the UPDATE statements db builds
+func recordUpdates(t *testing.T, db *gorm.DB) *[]string {
+	t.Helper()
+	var statements []string
+	err := db.Callback().Update().After("gorm:update").Register("test:record", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &statements
+}
+
+func TestIsWalletDeployedSyncsFlag(t *testing.T) {
+	node := &deployNode{deployed: true}
+	m := newDeployManager(t, node)
+	updates := recordUpdates(t, m.db)
+
+	// Deployed on-chain: a row still flagged false is corrected
+	deployed, err := m.IsWalletDeployed(t.Context(), "0x4444444444444444444444444444444444444444")
+	if err != nil || !deployed {
+		t.Fatalf("IsWalletDeployed = %v, %v", deployed, err)
+	}
+	if len(*updates) != 1 || !strings.Contains((*updates)[0], `"is_deployed"=$`) || !strings.Contains((*updates)[0], "COALESCE(deployed_at") {
+		t.Fatalf("sync statements = %v", *updates)
+	}
+
+	// No code (e.g. a reset testnet): a row flagged true is corrected the other way
+	node.deployed = false
+	if deployed, err := m.IsWalletDeployed(t.Context(), "0x4444444444444444444444444444444444444444"); err != nil || deployed {
+		t.Fatalf("IsWalletDeployed = %v, %v", deployed, err)
+	}
+	if len(*updates) != 2 || !strings.Contains((*updates)[1], `"deployed_at"=$`) {
+		t.Fatalf("sync statements = %v", *updates)
+	}
+}
+
+func TestIsWalletDeployedCorrectsDriftedRow(t *testing.T) {
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&models.Wallet{}); err != nil {
+		t.Fatal(err)
+	}
+	node := &deployNode{deployed: true}
+	m := newDeployManager(t, node)
+	m.db = db
+
+	// After a DB wipe and re-registration the row says undeployed, but the contract exists
+	address := "0x4444444444444444444444444444444444444444"
+	if err := db.Create(&models.Wallet{ID: "w1", UserID: "u1", Address: address, ChainID: 133}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if deployed, err := m.IsWalletDeployed(t.Context(), address); err != nil || !deployed {
+		t.Fatalf("IsWalletDeployed = %v, %v", deployed, err)
+	}
+	var row models.Wallet
+	db.First(&row, "id = ?", "w1")
+	if !row.IsDeployed || row.DeployedAt == nil || time.Since(*row.DeployedAt) > time.Minute {
+		t.Fatalf("row not synced: deployed=%v at=%v", row.IsDeployed, row.DeployedAt)
+	}
+
+	node.deployed = false
+	if deployed, _ := m.IsWalletDeployed(t.Context(), address); deployed {
+		t.Fatal("expected undeployed")
+	}
+	db.First(&row, "id = ?", "w1")
+	if row.IsDeployed || row.DeployedAt != nil {
+		t.Fatalf("row not reset: deployed=%v at=%v", row.IsDeployed, row.DeployedAt)
+	}
+}
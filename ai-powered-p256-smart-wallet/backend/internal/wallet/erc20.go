@@ -0,0 +1,72 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Minimal ERC-20 ABI (read-only methods used by the backend)
+const ERC20ABI = `[
+	{
+		"inputs": [],
+		"name": "decimals",
+		"outputs": [{"internalType": "uint8", "name": "", "type": "uint8"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "symbol",
+		"outputs": [{"internalType": "string", "name": "", "type": "string"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [{"internalType": "address", "name": "account", "type": "address"}],
+		"name": "balanceOf",
+		"outputs": [{"internalType": "uint256", "name": "", "type": "uint256"}],
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
+
+// callERC20 calls a view method on an ERC-20 token and unpacks the single return value
+func (m *Manager) callERC20(ctx context.Context, tokenAddress, method string, out interface{}, args ...interface{}) error {
+	tokenABI, err := abi.JSON(strings.NewReader(ERC20ABI))
+	if err != nil {
+		return fmt.Errorf("failed to parse ERC-20 ABI: %w", err)
+	}
+
+	data, err := tokenABI.Pack(method, args...)
+	if err != nil {
+		return fmt.Errorf("failed to pack %s call: %w", method, err)
+	}
+
+	token := common.HexToAddress(tokenAddress)
+	result, err := m.ethClient.CallContract(ctx, ethereum.CallMsg{
+		To:   &token,
+		Data: data,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to call %s on %s: %w", method, tokenAddress, err)
+	}
+
+	if err := tokenABI.UnpackIntoInterface(out, method, result); err != nil {
+		return fmt.Errorf("failed to unpack %s result: %w", method, err)
+	}
+	return nil
+}
+
+// GetTokenDecimals returns the ERC-20 token's decimals()
+func (m *Manager) GetTokenDecimals(ctx context.Context, tokenAddress string) (uint8, error) {
+	var decimals uint8
+	if err := m.callERC20(ctx, tokenAddress, "decimals", &decimals); err != nil {
+		return 0, err
+	}
+	return decimals, nil
+}
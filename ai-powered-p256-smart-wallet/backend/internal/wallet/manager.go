@@ -3,13 +3,15 @@ package wallet
 import (
 	"ai-wallet-backend/internal/models"
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -17,43 +19,170 @@ import (
 // Manager handles wallet operations
 type Manager struct {
 	db          *gorm.DB
-	ethClient   *ethclient.Client
+	ethClient   *RPCClient // retries idempotent reads on transient errors
 	chainID     int
 	factoryAddr string
 	implAddr    string
+
+	// ERC-4337 EntryPoint the wallets are bound to (v0.6 by default)
+	entryPointVersion string
+	entryPointAddr    string
+
+	// How signed UserOps reach the chain (direct handleOps or an ERC-4337 bundler)
+	submitMode string
+	bundler    *BundlerClient
+
+	// Optional ERC-4337 paymaster that sponsors gas (nil when PAYMASTER_URL is unset)
+	paymaster *PaymasterClient
+
+	// How CREATE2 salts are derived for new wallets ("user" or "index")
+	saltStrategy string
+
+	// Serializes DeployWallet so a wallet is never deployed twice
+	deployMu sync.Mutex
+
+	// Short-lived per-address balance cache to avoid hammering the RPC
+	balanceCacheTTL time.Duration
+	balanceCacheMu  sync.Mutex
+	balanceCache    map[string]balanceCacheEntry
+}
+
+// ErrChainIDMismatch is returned when the RPC node serves a different chain than CHAIN_ID:
+// every UserOp hash and signature would be built for the wrong chain
+var ErrChainIDMismatch = errors.New("RPC chain ID does not match the configured chain ID")
+
+// chainIDCheckTimeout bounds the eth_chainId call made at startup
+const chainIDCheckTimeout = 10 * time.Second
+
+// chainIDReader is the part of the RPC client checkChainID needs
+type chainIDReader interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+// checkChainID compares the node's eth_chainId with the configured chain ID
+func checkChainID(ctx context.Context, client chainIDReader, chainID int) error {
+	actual, err := client.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("eth_chainId failed: %w", err)
+	}
+	if !actual.IsInt64() || actual.Int64() != int64(chainID) {
+		return fmt.Errorf("%w: CHAIN_ID is %d but the RPC node reports %s", ErrChainIDMismatch, chainID, actual)
+	}
+	return nil
 }
 
 // NewManager creates a new wallet manager
 // Parameters should be read from environment variables in the caller
 func NewManager(db *gorm.DB, rpcURL string, chainID int, factoryAddr, implAddr string) (*Manager, error) {
-	ethClient, err := ethclient.Dial(rpcURL)
+	ethClient, err := DialRPC(rpcURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Ethereum client: %w", err)
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), chainIDCheckTimeout)
+	defer cancel()
+	if err := checkChainID(ctx, ethClient, chainID); err != nil {
+		if errors.Is(err, ErrChainIDMismatch) {
+			ethClient.Close()
+			return nil, err
+		}
+		// An unreachable node is not fatal: /ready reports it until the RPC comes back
+		log.Printf("⚠️  Could not verify the RPC chain ID at startup: %v", err)
+	}
+
 	return &Manager{
 		db:          db,
 		ethClient:   ethClient,
 		chainID:     chainID,
 		factoryAddr: factoryAddr,
 		implAddr:    implAddr,
+
+		entryPointVersion: EntryPointV06,
+		entryPointAddr:    DefaultEntryPointAddress,
+
+		submitMode: SubmitModeDirect,
+
+		saltStrategy: SaltStrategyUser,
+
+		balanceCacheTTL: DefaultBalanceCacheTTL,
+		balanceCache:    make(map[string]balanceCacheEntry),
 	}, nil
 }
 
+// SetEntryPoint selects the EntryPoint version (and optionally address) used for UserOps
+// An empty address selects the canonical deployment for the chosen version
+func (m *Manager) SetEntryPoint(version, address string) {
+	m.entryPointVersion = NormalizeEntryPointVersion(version)
+
+	switch {
+	case address != "":
+		m.entryPointAddr = address
+	case m.entryPointVersion == EntryPointV07:
+		m.entryPointAddr = EntryPointV07Address
+	default:
+		m.entryPointAddr = DefaultEntryPointAddress
+	}
+}
+
+// ChainID returns the chain the manager's wallets live on
+func (m *Manager) ChainID() int {
+	return m.chainID
+}
+
+// EntryPointVersion returns the configured EntryPoint version ("0.6" or "0.7")
+func (m *Manager) EntryPointVersion() string {
+	return m.entryPointVersion
+}
+
+// EntryPointAddress returns the configured EntryPoint contract address
+func (m *Manager) EntryPointAddress() common.Address {
+	return common.HexToAddress(m.entryPointAddr)
+}
+
+// SetSaltStrategy selects how CREATE2 salts are derived for new wallets
+func (m *Manager) SetSaltStrategy(strategy string) {
+	m.saltStrategy = NormalizeSaltStrategy(strategy)
+}
+
+// SaltStrategy returns the configured salt strategy
+func (m *Manager) SaltStrategy() string {
+	return m.saltStrategy
+}
+
+// WalletSalt returns the salt the configured strategy assigns to a user's index-th wallet
+func (m *Manager) WalletSalt(userID string, index uint64) *big.Int {
+	return DeriveWalletSalt(m.saltStrategy, userID, index)
+}
+
 // CreateP256Wallet creates a new P256-based smart contract wallet for a user
+// Returns the user's existing primary wallet if there is one.
 func (m *Manager) CreateP256Wallet(ctx context.Context, userID string, publicKeyX, publicKeyY string) (*models.Wallet, error) {
 	// Check if user already has a wallet
 	var existingWallet models.Wallet
-	if err := m.db.Where("user_id = ? AND chain_id = ?", userID, m.chainID).First(&existingWallet).Error; err == nil {
+	if err := m.db.Where("user_id = ? AND chain_id = ?", userID, m.chainID).Order("wallet_index ASC").First(&existingWallet).Error; err == nil {
 		return &existingWallet, nil
 	}
 
+	return m.CreateP256WalletWithSalt(ctx, userID, publicKeyX, publicKeyY, 0, m.WalletSalt(userID, 0))
+}
+
+// CreateP256WalletWithSalt creates the user's index-th wallet at the address given by salt
+func (m *Manager) CreateP256WalletWithSalt(ctx context.Context, userID string, publicKeyX, publicKeyY string, index uint64, salt *big.Int) (*models.Wallet, error) {
 	// Compute wallet address from P256 public key by calling Factory contract
-	walletAddress, err := m.ComputeWalletAddress(ctx, publicKeyX, publicKeyY, 0)
+	walletAddress, err := m.ComputeWalletAddress(ctx, publicKeyX, publicKeyY, salt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compute wallet address: %w", err)
 	}
 
+	// The same key and salt give the same address: bring back a wallet the user deleted
+	// rather than colliding with its row
+	if deleted, err := m.findDeletedWallet(ctx, walletAddress); err == nil {
+		if deleted.UserID != userID {
+			return nil, fmt.Errorf("wallet address %s belongs to a deleted wallet of another user", walletAddress)
+		}
+		return m.RestoreWallet(ctx, userID, walletAddress)
+	}
+
 	// Create wallet record
 	wallet := &models.Wallet{
 		ID:                    uuid.New().String(),
@@ -64,8 +193,11 @@ func (m *Manager) CreateP256Wallet(ctx context.Context, userID string, publicKey
 		ChainID:               m.chainID,
 		FactoryAddress:        m.factoryAddr,
 		ImplementationAddress: m.implAddr,
+		Salt:                  salt.String(),
+		WalletIndex:           index,
 		IsDeployed:            false,
 		CreatedAt:             time.Now(),
+		CreatedBy:             userID,
 	}
 
 	if err := m.db.Create(wallet).Error; err != nil {
@@ -81,15 +213,24 @@ func (m *Manager) CreateWallet(ctx context.Context, userID string) (*models.Wall
 	return nil, fmt.Errorf("CreateWallet is deprecated - use CreateP256Wallet with WebAuthn public key instead")
 }
 
-// GetWalletByUserID gets a user's wallet
+// GetWalletByUserID gets a user's primary wallet (lowest wallet index)
 func (m *Manager) GetWalletByUserID(userID string) (*models.Wallet, error) {
 	var wallet models.Wallet
-	if err := m.db.Where("user_id = ? AND chain_id = ?", userID, m.chainID).First(&wallet).Error; err != nil {
+	if err := m.db.Where("user_id = ? AND chain_id = ?", userID, m.chainID).Order("wallet_index ASC").First(&wallet).Error; err != nil {
 		return nil, err
 	}
 	return &wallet, nil
 }
 
+// GetWalletsByUserID lists all of a user's wallets on the configured chain
+func (m *Manager) GetWalletsByUserID(userID string) ([]models.Wallet, error) {
+	var wallets []models.Wallet
+	if err := m.db.Where("user_id = ? AND chain_id = ?", userID, m.chainID).Order("wallet_index ASC").Find(&wallets).Error; err != nil {
+		return nil, err
+	}
+	return wallets, nil
+}
+
 // GetWalletByAddress gets a wallet by address
 func (m *Manager) GetWalletByAddress(address string) (*models.Wallet, error) {
 	var wallet models.Wallet
@@ -156,24 +297,67 @@ func (m *Manager) Close() {
 	if m.ethClient != nil {
 		m.ethClient.Close()
 	}
+	if m.bundler != nil {
+		m.bundler.Close()
+	}
+	if m.paymaster != nil {
+		m.paymaster.Close()
+	}
 }
 
-// IsWalletDeployed checks if a wallet contract is deployed at the given address
+// PingRPC performs a lightweight eth_chainId call to verify RPC connectivity
+func (m *Manager) PingRPC(ctx context.Context) (*big.Int, error) {
+	chainID, err := m.ethClient.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("eth_chainId failed: %w", err)
+	}
+	return chainID, nil
+}
+
+// IsWalletDeployed checks if a wallet contract is deployed at the given address.
+// The chain is authoritative: the wallets.is_deployed flag can drift (e.g. after a DB
+// wipe, or a lazy deploy through initCode), so it is synced to what eth_getCode reports.
 func (m *Manager) IsWalletDeployed(ctx context.Context, address string) (bool, error) {
 	code, err := m.ethClient.CodeAt(ctx, common.HexToAddress(address), nil)
 	if err != nil {
 		return false, fmt.Errorf("failed to get code at address: %w", err)
 	}
 	// If code exists (length > 0), the contract is deployed
-	return len(code) > 0, nil
+	deployed := len(code) > 0
+
+	if err := m.syncDeployedFlag(ctx, address, deployed); err != nil {
+		log.Printf("⚠️  Failed to sync is_deployed for %s: %v", address, err)
+	}
+	return deployed, nil
+}
+
+// syncDeployedFlag updates the wallet row at address if its is_deployed flag disagrees with the chain
+func (m *Manager) syncDeployedFlag(ctx context.Context, address string, deployed bool) error {
+	updates := map[string]interface{}{"is_deployed": deployed, "deployed_at": nil}
+	if deployed {
+		// When the wallet was deployed is unknown here; keep a recorded time if there is one
+		updates["deployed_at"] = gorm.Expr("COALESCE(deployed_at, ?)", time.Now().UTC())
+	}
+
+	result := m.db.WithContext(ctx).Model(&models.Wallet{}).
+		Where("LOWER(address) = LOWER(?) AND is_deployed <> ?", address, deployed).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("🔄 Wallet %s is_deployed was out of sync with the chain, now %v", address, deployed)
+	}
+	return nil
 }
 
 // GetWalletNonce gets the nonce for a wallet from the EntryPoint contract
-// The nonce is stored in the EntryPoint contract per wallet address
+// The nonce is stored in the EntryPoint contract per wallet address; getNonce also
+// answers 0 for undeployed wallets, so a failed call is an error, never nonce 0
 func (m *Manager) GetWalletNonce(ctx context.Context, walletAddress string) (*big.Int, error) {
 	// EntryPoint.getNonce(address sender, uint192 key) returns (uint256 nonce)
 	// For simplicity, we use key=0
-	entryPointAddr := common.HexToAddress(DefaultEntryPointAddress)
+	entryPointAddr := m.EntryPointAddress()
 
 	// Function selector for getNonce(address,uint192)
 	// getNonce selector: 0x35567e1a
@@ -197,13 +381,12 @@ func (m *Manager) GetWalletNonce(ctx context.Context, walletAddress string) (*bi
 	}, nil)
 
 	if err != nil {
-		// If call fails, wallet might not be deployed yet, return nonce=0
-		return big.NewInt(0), nil
+		return nil, fmt.Errorf("failed to get nonce from EntryPoint: %w", err)
 	}
 
-	// Parse result as uint256
-	if len(result) == 0 {
-		return big.NewInt(0), nil
+	// No return data means there is no EntryPoint contract at the configured address
+	if len(result) != 32 {
+		return nil, fmt.Errorf("EntryPoint %s returned %d bytes for getNonce, is it deployed?", entryPointAddr.Hex(), len(result))
 	}
 
 	nonce := new(big.Int).SetBytes(result)
@@ -225,7 +408,7 @@ func (m *Manager) GetGasPrice(ctx context.Context) (*big.Int, error) {
 }
 
 // ComputeWalletAddress calls the Factory contract's getAddress method
-func (m *Manager) ComputeWalletAddress(ctx context.Context, publicKeyX, publicKeyY string, salt uint64) (string, error) {
+func (m *Manager) ComputeWalletAddress(ctx context.Context, publicKeyX, publicKeyY string, salt *big.Int) (string, error) {
 	// Function selector for getAddress(uint256,uint256,uint256)
 	selector := "e81b22ea"
 
@@ -238,7 +421,7 @@ func (m *Manager) ComputeWalletAddress(ctx context.Context, publicKeyX, publicKe
 	// Encode parameters: publicKeyX (32 bytes), publicKeyY (32 bytes), salt (32 bytes)
 	pubKeyXBytes := common.LeftPadBytes(pubKeyX.Bytes(), 32)
 	pubKeyYBytes := common.LeftPadBytes(pubKeyY.Bytes(), 32)
-	saltBytes := common.LeftPadBytes(new(big.Int).SetUint64(salt).Bytes(), 32)
+	saltBytes := common.LeftPadBytes(salt.Bytes(), 32)
 
 	// Construct calldata
 	calldata := append(common.Hex2Bytes(selector), pubKeyXBytes...)
@@ -0,0 +1,65 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ai-wallet-backend/internal/database/dbtest"
+)
+
+// staticChainID answers eth_chainId with a fixed chain ID or error
+type staticChainID struct {
+	id  int64
+	err error
+}
+
+func (c staticChainID) ChainID(context.Context) (*big.Int, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return big.NewInt(c.id), nil
+}
+
+func TestCheckChainID(t *testing.T) {
+	if err := checkChainID(context.Background(), staticChainID{id: 133}, 133); err != nil {
+		t.Fatalf("matching chain ID: %v", err)
+	}
+
+	err := checkChainID(context.Background(), staticChainID{id: 11155111}, 133)
+	if !errors.Is(err, ErrChainIDMismatch) {
+		t.Fatalf("mismatched chain ID: %v", err)
+	}
+
+	err = checkChainID(context.Background(), staticChainID{err: errors.New("connection refused")}, 133)
+	if err == nil || errors.Is(err, ErrChainIDMismatch) {
+		t.Fatalf("unreachable node: %v", err)
+	}
+}
+
+func TestNewManagerRejectsChainIDMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		// Sepolia, while CHAIN_ID says HashKey testnet
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": "0xaa36a7"})
+	}))
+	t.Cleanup(server.Close)
+
+	manager, err := NewManager(dbtest.DryRun(t), server.URL, 133, "", "")
+	if !errors.Is(err, ErrChainIDMismatch) || manager != nil {
+		t.Fatalf("NewManager = %v, %v", manager, err)
+	}
+
+	manager, err = NewManager(dbtest.DryRun(t), server.URL, 11155111, "", "")
+	if err != nil {
+		t.Fatalf("NewManager on the matching chain: %v", err)
+	}
+	manager.Close()
+}
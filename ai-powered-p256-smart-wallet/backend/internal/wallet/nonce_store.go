@@ -0,0 +1,224 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxNonceReserveAttempts bounds the retries when concurrent prepares race for a nonce
+const maxNonceReserveAttempts = 8
+
+// ErrNonceContention is returned when a nonce could not be reserved because other
+// prepares for the same wallet kept taking the candidates first
+var ErrNonceContention = errors.New("too many concurrent UserOps for this wallet, please try again")
+
+// NonceReservationStore hands out EntryPoint nonces to prepared UserOps. The on-chain
+// nonce only moves once a UserOp lands, so without reservations two transfers prepared
+// back-to-back get the same nonce and the second reverts.
+type NonceReservationStore interface {
+	// Reserve holds the lowest nonce >= chainNonce that no unexpired reservation of
+	// walletAddress holds, for ttl. Reservations below chainNonce have been used on-chain
+	// and are dropped.
+	Reserve(ctx context.Context, walletAddress string, chainNonce *big.Int, ttl time.Duration) (*big.Int, error)
+	// Release frees a nonce whose UserOp will not be submitted, so the next prepare reuses it
+	Release(ctx context.Context, walletAddress string, nonce *big.Int) error
+	// DeleteExpired removes all expired reservations and returns how many were deleted
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// lowestFreeNonce returns the lowest nonce >= chainNonce not in held
+func lowestFreeNonce(chainNonce *big.Int, held map[string]bool) *big.Int {
+	nonce := new(big.Int).Set(chainNonce)
+	for held[nonce.String()] {
+		nonce.Add(nonce, big.NewInt(1))
+	}
+	return nonce
+}
+
+// liveReservations returns the nonces a wallet's unexpired reservations hold and the
+// reserved nonces below chainNonce, which have been used on-chain
+func liveReservations(records []models.NonceReservation, chainNonce *big.Int) (held map[string]bool, used []string) {
+	held = make(map[string]bool, len(records))
+	for _, record := range records {
+		nonce, ok := new(big.Int).SetString(record.Nonce, 10)
+		switch {
+		case !ok || nonce.Cmp(chainNonce) < 0:
+			used = append(used, record.Nonce)
+		case !record.IsExpired():
+			held[record.Nonce] = true
+		}
+	}
+	return held, used
+}
+
+// GormNonceReservationStore stores nonce reservations in Postgres, so they survive restarts
+type GormNonceReservationStore struct {
+	db *gorm.DB
+}
+
+// NewGormNonceReservationStore creates a Postgres-backed nonce reservation store
+func NewGormNonceReservationStore(db *gorm.DB) *GormNonceReservationStore {
+	return &GormNonceReservationStore{db: db}
+}
+
+// Reserve holds the lowest free nonce. The (wallet, nonce) primary key decides races:
+// a prepare whose insert conflicts picks the next free nonce and tries again.
+func (s *GormNonceReservationStore) Reserve(ctx context.Context, walletAddress string, chainNonce *big.Int, ttl time.Duration) (*big.Int, error) {
+	walletAddress = strings.ToLower(walletAddress)
+	db := s.db.WithContext(ctx)
+
+	for attempt := 0; attempt < maxNonceReserveAttempts; attempt++ {
+		var records []models.NonceReservation
+		if err := db.Where("wallet_address = ?", walletAddress).Find(&records).Error; err != nil {
+			return nil, fmt.Errorf("failed to load nonce reservations: %w", err)
+		}
+
+		// Expired rows would block re-reserving their nonce; rows re-reserved since the
+		// read are no longer expired and stay
+		held, used := liveReservations(records, chainNonce)
+		stale := db.Where("wallet_address = ?", walletAddress)
+		if len(used) > 0 {
+			stale = stale.Where("(expires_at < ? OR nonce IN ?)", time.Now(), used)
+		} else {
+			stale = stale.Where("expires_at < ?", time.Now())
+		}
+		if err := stale.Delete(&models.NonceReservation{}).Error; err != nil {
+			return nil, fmt.Errorf("failed to drop stale nonce reservations: %w", err)
+		}
+
+		nonce := lowestFreeNonce(chainNonce, held)
+		now := time.Now()
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.NonceReservation{
+			WalletAddress: walletAddress,
+			Nonce:         nonce.String(),
+			ExpiresAt:     now.Add(ttl),
+			CreatedAt:     now,
+		})
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to reserve nonce: %w", result.Error)
+		}
+		if result.RowsAffected == 1 {
+			return nonce, nil
+		}
+	}
+	return nil, ErrNonceContention
+}
+
+// Release frees a reserved nonce
+func (s *GormNonceReservationStore) Release(ctx context.Context, walletAddress string, nonce *big.Int) error {
+	return s.db.WithContext(ctx).
+		Where("wallet_address = ? AND nonce = ?", strings.ToLower(walletAddress), nonce.String()).
+		Delete(&models.NonceReservation{}).Error
+}
+
+// DeleteExpired removes expired nonce reservations
+func (s *GormNonceReservationStore) DeleteExpired(ctx context.Context) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.NonceReservation{})
+	return result.RowsAffected, result.Error
+}
+
+// MemoryNonceReservationStore keeps nonce reservations in process memory (tests and local development)
+type MemoryNonceReservationStore struct {
+	mu           sync.Mutex
+	reservations map[string]map[string]*models.NonceReservation // wallet address -> nonce -> reservation
+}
+
+// NewMemoryNonceReservationStore creates an in-memory nonce reservation store
+func NewMemoryNonceReservationStore() *MemoryNonceReservationStore {
+	return &MemoryNonceReservationStore{reservations: make(map[string]map[string]*models.NonceReservation)}
+}
+
+// Reserve holds the lowest free nonce
+func (s *MemoryNonceReservationStore) Reserve(ctx context.Context, walletAddress string, chainNonce *big.Int, ttl time.Duration) (*big.Int, error) {
+	walletAddress = strings.ToLower(walletAddress)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reserved := s.reservations[walletAddress]
+	if reserved == nil {
+		reserved = make(map[string]*models.NonceReservation)
+		s.reservations[walletAddress] = reserved
+	}
+
+	records := make([]models.NonceReservation, 0, len(reserved))
+	for _, record := range reserved {
+		records = append(records, *record)
+	}
+	held, _ := liveReservations(records, chainNonce)
+	for nonce := range reserved {
+		if !held[nonce] {
+			delete(reserved, nonce)
+		}
+	}
+
+	nonce := lowestFreeNonce(chainNonce, held)
+	now := time.Now()
+	reserved[nonce.String()] = &models.NonceReservation{
+		WalletAddress: walletAddress,
+		Nonce:         nonce.String(),
+		ExpiresAt:     now.Add(ttl),
+		CreatedAt:     now,
+	}
+	return nonce, nil
+}
+
+// Release frees a reserved nonce
+func (s *MemoryNonceReservationStore) Release(ctx context.Context, walletAddress string, nonce *big.Int) error {
+	s.mu.Lock()
+	delete(s.reservations[strings.ToLower(walletAddress)], nonce.String())
+	s.mu.Unlock()
+	return nil
+}
+
+// DeleteExpired removes expired nonce reservations
+func (s *MemoryNonceReservationStore) DeleteExpired(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for walletAddress, reserved := range s.reservations {
+		for nonce, record := range reserved {
+			if record.IsExpired() {
+				delete(reserved, nonce)
+				deleted++
+			}
+		}
+		if len(reserved) == 0 {
+			delete(s.reservations, walletAddress)
+		}
+	}
+	return deleted, nil
+}
+
+// RunNonceReservationSweeper periodically deletes expired nonce reservations until ctx is cancelled
+func RunNonceReservationSweeper(ctx context.Context, store NonceReservationStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := store.DeleteExpired(ctx)
+			if err != nil {
+				log.Printf("⚠️  Failed to sweep expired nonce reservations: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("🧹 Swept %d expired nonce reservations", deleted)
+			}
+		}
+	}
+}
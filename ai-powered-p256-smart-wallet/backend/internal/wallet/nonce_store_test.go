@@ -0,0 +1,108 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+const nonceTestWallet = "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb1"
+
+// testNonceReservations runs the behaviour both stores share
+func testNonceReservations(t *testing.T, store NonceReservationStore) {
+	ctx := context.Background()
+	reserve := func(chainNonce int64) int64 {
+		t.Helper()
+		nonce, err := store.Reserve(ctx, nonceTestWallet, big.NewInt(chainNonce), time.Minute)
+		if err != nil {
+			t.Fatalf("Reserve: %v", err)
+		}
+		return nonce.Int64()
+	}
+
+	// Prepares racing before anything lands get distinct nonces from the chain nonce up
+	nonces := make(chan int64, 4)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonce, err := store.Reserve(ctx, nonceTestWallet, big.NewInt(5), time.Minute)
+			if err != nil {
+				t.Errorf("Reserve: %v", err)
+				return
+			}
+			nonces <- nonce.Int64()
+		}()
+	}
+	wg.Wait()
+	close(nonces)
+	seen := make(map[int64]bool)
+	for nonce := range nonces {
+		if nonce < 5 || nonce > 8 || seen[nonce] {
+			t.Fatalf("concurrent Reserve returned %d (already seen: %v)", nonce, seen)
+		}
+		seen[nonce] = true
+	}
+
+	// A released nonce is handed out again before higher ones
+	if err := store.Release(ctx, nonceTestWallet, big.NewInt(6)); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if got := reserve(5); got != 6 {
+		t.Fatalf("Reserve after release = %d, want 6", got)
+	}
+
+	// Once nonces 5-7 land on-chain their reservations are dropped, 8 is still held
+	if got := reserve(8); got != 9 {
+		t.Fatalf("Reserve at chain nonce 8 = %d, want 9", got)
+	}
+	// Addresses are case-insensitive
+	other, err := store.Reserve(ctx, "0x742D35CC6634C0532925A3B844BC9E7595F0BEB1", big.NewInt(8), time.Minute)
+	if err != nil || other.Int64() != 10 {
+		t.Fatalf("Reserve(upper-case address) = %v, %v; want 10", other, err)
+	}
+}
+
+// testNonceReservationExpiry checks expired reservations free their nonce and are swept
+func testNonceReservationExpiry(t *testing.T, store NonceReservationStore) {
+	ctx := context.Background()
+	if _, err := store.Reserve(ctx, nonceTestWallet, big.NewInt(0), -time.Second); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	// The abandoned prepare's nonce is reused
+	nonce, err := store.Reserve(ctx, nonceTestWallet, big.NewInt(0), time.Minute)
+	if err != nil || nonce.Int64() != 0 {
+		t.Fatalf("Reserve after expiry = %v, %v; want 0", nonce, err)
+	}
+
+	if _, err := store.Reserve(ctx, "0x0000000000000000000000000000000000000001", big.NewInt(0), -time.Second); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	deleted, err := store.DeleteExpired(ctx)
+	if err != nil || deleted != 1 {
+		t.Fatalf("DeleteExpired = %d, %v; want 1, nil", deleted, err)
+	}
+	if nonce, err := store.Reserve(ctx, nonceTestWallet, big.NewInt(0), time.Minute); err != nil || nonce.Int64() != 1 {
+		t.Fatalf("Reserve after sweep = %v, %v; want 1", nonce, err)
+	}
+}
+
+func TestMemoryNonceReservationStore(t *testing.T) {
+	testNonceReservations(t, NewMemoryNonceReservationStore())
+	testNonceReservationExpiry(t, NewMemoryNonceReservationStore())
+}
+
+func TestGormNonceReservationStore(t *testing.T) {
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&models.NonceReservation{}); err != nil {
+		t.Fatal(err)
+	}
+	testNonceReservations(t, NewGormNonceReservationStore(db))
+	db.Where("1 = 1").Delete(&models.NonceReservation{})
+	testNonceReservationExpiry(t, NewGormNonceReservationStore(db))
+}
@@ -12,58 +12,6 @@ type P256PublicKey struct {
 	Y *big.Int
 }
 
-// ExtractP256PublicKeyFromCOSE extracts P-256 public key from WebAuthn COSE format
-// COSE Key format (RFC 8152): https://www.rfc-editor.org/rfc/rfc8152#section-7
-//
-// For P-256 (ES256), the public key is encoded as CBOR map:
-// - kty (1): 2 (EC2 - Elliptic Curve Keys)
-// - alg (3): -7 (ES256)
-// - crv (-1): 1 (P-256)
-// - x (-2): 32 bytes (X coordinate)
-// - y (-3): 32 bytes (Y coordinate)
-func ExtractP256PublicKeyFromCOSE(cosePublicKey []byte) (*P256PublicKey, error) {
-	if len(cosePublicKey) < 70 {
-		return nil, fmt.Errorf("COSE public key too short: %d bytes", len(cosePublicKey))
-	}
-
-	// Find x coordinate (CBOR label -2 = 0x21)
-	// Format: 0x21 0x58 0x20 [32 bytes of x]
-	var xStart, yStart int = -1, -1
-
-	for i := 0; i < len(cosePublicKey)-33; i++ {
-		// Look for x coordinate: 0x21 (label -2), 0x58 0x20 (byte string, 32 bytes)
-		if cosePublicKey[i] == 0x21 && cosePublicKey[i+1] == 0x58 && cosePublicKey[i+2] == 0x20 {
-			xStart = i + 3
-		}
-		// Look for y coordinate: 0x22 (label -3), 0x58 0x20 (byte string, 32 bytes)
-		if cosePublicKey[i] == 0x22 && cosePublicKey[i+1] == 0x58 && cosePublicKey[i+2] == 0x20 {
-			yStart = i + 3
-		}
-	}
-
-	if xStart == -1 || yStart == -1 {
-		return nil, fmt.Errorf("failed to find P-256 coordinates in COSE key (xStart=%d, yStart=%d)", xStart, yStart)
-	}
-
-	// Extract 32 bytes for x and y
-	xBytes := cosePublicKey[xStart : xStart+32]
-	yBytes := cosePublicKey[yStart : yStart+32]
-
-	x := new(big.Int).SetBytes(xBytes)
-	y := new(big.Int).SetBytes(yBytes)
-
-	// Validate the point is on the P-256 curve
-	curve := elliptic.P256()
-	if !curve.IsOnCurve(x, y) {
-		return nil, fmt.Errorf("public key point not on P-256 curve")
-	}
-
-	return &P256PublicKey{
-		X: x,
-		Y: y,
-	}, nil
-}
-
 // ComputeP256WalletAddress computes the smart contract wallet address
 // from P-256 public key coordinates using CREATE2
 //
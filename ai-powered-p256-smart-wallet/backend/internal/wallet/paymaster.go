@@ -0,0 +1,158 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// paymasterTimeout bounds pm_sponsorUserOperation so a slow paymaster only delays
+// prepare briefly before falling back to self-paid gas
+const paymasterTimeout = 10 * time.Second
+
+// ErrSponsorshipDeclined is returned when the paymaster refuses to sponsor a UserOp
+var ErrSponsorshipDeclined = errors.New("paymaster declined sponsorship")
+
+// PaymasterClient requests gas sponsorship from an ERC-4337 paymaster service
+// using pm_sponsorUserOperation
+type PaymasterClient struct {
+	rpcClient *rpc.Client
+	address   common.Address // the only paymaster contract accepted in responses
+}
+
+// NewPaymasterClient creates a paymaster client for the given service URL and paymaster contract
+func NewPaymasterClient(paymasterURL, paymasterAddress string) (*PaymasterClient, error) {
+	if !common.IsHexAddress(paymasterAddress) {
+		return nil, fmt.Errorf("invalid PAYMASTER_ADDRESS %q", paymasterAddress)
+	}
+	rpcClient, err := rpc.Dial(paymasterURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to paymaster: %w", err)
+	}
+	return &PaymasterClient{rpcClient: rpcClient, address: common.HexToAddress(paymasterAddress)}, nil
+}
+
+// Close closes the paymaster RPC connection
+func (p *PaymasterClient) Close() {
+	p.rpcClient.Close()
+}
+
+// sponsorResult is the pm_sponsorUserOperation result. v0.6 paymasters return
+// paymasterAndData; v0.7 paymasters return the unpacked paymaster fields.
+// Either may also return adjusted gas limits, which their signature covers.
+type sponsorResult struct {
+	PaymasterAndData              hexutil.Bytes   `json:"paymasterAndData"`
+	Paymaster                     *common.Address `json:"paymaster"`
+	PaymasterData                 hexutil.Bytes   `json:"paymasterData"`
+	PaymasterVerificationGasLimit rpcQuantity     `json:"paymasterVerificationGasLimit"`
+	PaymasterPostOpGasLimit       rpcQuantity     `json:"paymasterPostOpGasLimit"`
+	PreVerificationGas            rpcQuantity     `json:"preVerificationGas"`
+	VerificationGasLimit          rpcQuantity     `json:"verificationGasLimit"`
+	CallGasLimit                  rpcQuantity     `json:"callGasLimit"`
+}
+
+// SponsorUserOperation asks the paymaster to sponsor userOp (in the RPC shape for
+// the EntryPoint) and returns its result
+func (p *PaymasterClient) SponsorUserOperation(ctx context.Context, userOp map[string]interface{}, entryPoint common.Address) (*sponsorResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, paymasterTimeout)
+	defer cancel()
+
+	var result sponsorResult
+	err := p.rpcClient.CallContext(ctx, &result, "pm_sponsorUserOperation", toBundlerUserOp(userOp), entryPoint.Hex())
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return nil, fmt.Errorf("%w: %s", ErrSponsorshipDeclined, rpcErr.Error())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("pm_sponsorUserOperation failed: %w", err)
+	}
+	return &result, nil
+}
+
+// SetPaymaster enables gas sponsorship through the paymaster service at paymasterURL.
+// An empty URL leaves sponsorship disabled.
+func (m *Manager) SetPaymaster(paymasterURL, paymasterAddress string) error {
+	if paymasterURL == "" {
+		return nil
+	}
+	paymaster, err := NewPaymasterClient(paymasterURL, paymasterAddress)
+	if err != nil {
+		return err
+	}
+	m.paymaster = paymaster
+	return nil
+}
+
+// SponsorshipEnabled reports whether a paymaster is configured
+func (m *Manager) SponsorshipEnabled() bool {
+	return m.paymaster != nil
+}
+
+// SponsorUserOp asks the configured paymaster to pay for a v0.6-shaped UserOp map and,
+// if it agrees, fills paymasterAndData (and any gas limits the paymaster adjusted) in place.
+// Call it after the gas limits are final: the paymaster's signature covers them.
+// On any error userOp is left unchanged, so the caller can fall back to self-paid gas.
+func (m *Manager) SponsorUserOp(ctx context.Context, userOp map[string]interface{}) (paymaster common.Address, err error) {
+	if m.paymaster == nil {
+		return common.Address{}, fmt.Errorf("no paymaster configured")
+	}
+
+	// The paymaster validates the op against the configured EntryPoint's layout
+	op := userOp
+	if m.entryPointVersion == EntryPointV07 {
+		op = BuildUserOpV07(op)
+	}
+	result, err := m.paymaster.SponsorUserOperation(ctx, op, m.EntryPointAddress())
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	paymasterAndData, err := m.paymasterAndData(result)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if !bytes.Equal(paymasterAndData[:common.AddressLength], m.paymaster.address.Bytes()) {
+		return common.Address{}, fmt.Errorf("paymaster service returned paymaster %s, expected %s",
+			common.BytesToAddress(paymasterAndData[:common.AddressLength]).Hex(), m.paymaster.address.Hex())
+	}
+
+	userOp["paymasterAndData"] = hexutil.Encode(paymasterAndData)
+	for field, value := range map[string]*big.Int{
+		"preVerificationGas":   result.PreVerificationGas.Int,
+		"verificationGasLimit": result.VerificationGasLimit.Int,
+		"callGasLimit":         result.CallGasLimit.Int,
+	} {
+		if value != nil {
+			userOp[field] = "0x" + value.Text(16)
+		}
+	}
+
+	log.Printf("⛽ UserOp for %v sponsored by paymaster %s", userOp["sender"], m.paymaster.address.Hex())
+	return m.paymaster.address, nil
+}
+
+// paymasterAndData builds the paymasterAndData bytes for the configured EntryPoint.
+// v0.7 packs paymaster (20) || verificationGasLimit (16) || postOpGasLimit (16) || paymasterData.
+func (m *Manager) paymasterAndData(result *sponsorResult) ([]byte, error) {
+	if len(result.PaymasterAndData) >= common.AddressLength {
+		return result.PaymasterAndData, nil
+	}
+	if result.Paymaster == nil || m.entryPointVersion != EntryPointV07 {
+		return nil, fmt.Errorf("paymaster response has no paymasterAndData")
+	}
+	if result.PaymasterVerificationGasLimit.Int == nil || result.PaymasterPostOpGasLimit.Int == nil {
+		return nil, fmt.Errorf("paymaster response is missing paymaster gas limits")
+	}
+
+	gasLimits := PackUint128Pair(result.PaymasterVerificationGasLimit.Int, result.PaymasterPostOpGasLimit.Int)
+	packed := append(result.Paymaster.Bytes(), gasLimits[:]...)
+	return append(packed, result.PaymasterData...), nil
+}
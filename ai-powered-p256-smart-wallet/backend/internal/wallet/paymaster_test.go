@@ -0,0 +1,159 @@
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testPaymaster = "0x6666666666666666666666666666666666666666"
+
+// fakePaymaster answers pm_sponsorUserOperation with result, or declines if result is nil
+type fakePaymaster struct {
+	result     map[string]interface{}
+	entryPoint string
+	userOp     map[string]interface{}
+}
+
+func (p *fakePaymaster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	reply := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+
+	if req.Method != "pm_sponsorUserOperation" || len(req.Params) != 2 {
+		reply["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+	} else if p.result == nil {
+		reply["error"] = map[string]interface{}{"code": -32500, "message": "sponsorship policy rejected"}
+	} else {
+		json.Unmarshal(req.Params[0], &p.userOp)
+		json.Unmarshal(req.Params[1], &p.entryPoint)
+		reply["result"] = p.result
+	}
+	json.NewEncoder(w).Encode(reply)
+}
+
+func newPaymasterManager(t *testing.T, pm *fakePaymaster, version, paymasterAddress string) *Manager {
+	t.Helper()
+	server := httptest.NewServer(pm)
+	t.Cleanup(server.Close)
+
+	m := &Manager{entryPointVersion: version, entryPointAddr: "0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789"}
+	if version == EntryPointV07 {
+		m.entryPointAddr = EntryPointV07Address
+	}
+	if err := m.SetPaymaster(server.URL, paymasterAddress); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(m.paymaster.Close)
+	return m
+}
+
+func sponsorTestUserOp() map[string]interface{} {
+	return map[string]interface{}{
+		"sender":               "0x4444444444444444444444444444444444444444",
+		"nonce":                "0x0",
+		"initCode":             "0x",
+		"callData":             "0xb61d27f6",
+		"callGasLimit":         "0x186a0",
+		"verificationGasLimit": "0x30d40",
+		"preVerificationGas":   "0xc350",
+		"maxFeePerGas":         "0x3b9aca00",
+		"maxPriorityFeePerGas": "0x3b9aca00",
+		"paymasterAndData":     "0x",
+		"signature":            "0x",
+	}
+}
+
+func TestSponsorUserOpV06(t *testing.T) {
+	pm := &fakePaymaster{result: map[string]interface{}{
+		"paymasterAndData":   testPaymaster + "abcd",
+		"preVerificationGas": "0xea60",
+	}}
+	m := newPaymasterManager(t, pm, EntryPointV06, testPaymaster)
+	if !m.SponsorshipEnabled() {
+		t.Fatal("sponsorship not enabled")
+	}
+
+	userOp := sponsorTestUserOp()
+	paymaster, err := m.SponsorUserOp(t.Context(), userOp)
+	if err != nil {
+		t.Fatalf("SponsorUserOp: %v", err)
+	}
+	if paymaster.Hex() != "0x6666666666666666666666666666666666666666" {
+		t.Fatalf("paymaster = %s", paymaster.Hex())
+	}
+	if userOp["paymasterAndData"] != testPaymaster+"abcd" {
+		t.Fatalf("paymasterAndData = %v", userOp["paymasterAndData"])
+	}
+	// Gas the paymaster adjusted is taken over; the rest is left alone
+	if userOp["preVerificationGas"] != "0xea60" || userOp["callGasLimit"] != "0x186a0" {
+		t.Fatalf("gas fields = %v / %v", userOp["preVerificationGas"], userOp["callGasLimit"])
+	}
+	if pm.entryPoint != m.EntryPointAddress().Hex() || pm.userOp["sender"] != userOp["sender"] {
+		t.Fatalf("paymaster saw op %v for %s", pm.userOp, pm.entryPoint)
+	}
+}
+
+func TestSponsorUserOpV07(t *testing.T) {
+	pm := &fakePaymaster{result: map[string]interface{}{
+		"paymaster":                     testPaymaster,
+		"paymasterData":                 "0xbeef",
+		"paymasterVerificationGasLimit": "0x7530",
+		"paymasterPostOpGasLimit":       "0x2710",
+	}}
+	m := newPaymasterManager(t, pm, EntryPointV07, testPaymaster)
+
+	userOp := sponsorTestUserOp()
+	if _, err := m.SponsorUserOp(t.Context(), userOp); err != nil {
+		t.Fatalf("SponsorUserOp: %v", err)
+	}
+	// paymaster || verificationGasLimit (uint128) || postOpGasLimit (uint128) || paymasterData
+	want := testPaymaster +
+		"00000000000000000000000000007530" +
+		"00000000000000000000000000002710" +
+		"beef"
+	if userOp["paymasterAndData"] != want {
+		t.Fatalf("paymasterAndData = %v\nwant               %s", userOp["paymasterAndData"], want)
+	}
+	// The paymaster is asked in the unpacked v0.7 RPC shape
+	if _, ok := pm.userOp["callGasLimit"]; !ok || pm.entryPoint != EntryPointV07Address {
+		t.Fatalf("paymaster saw op %v for %s", pm.userOp, pm.entryPoint)
+	}
+}
+
+func TestSponsorUserOpFallsBack(t *testing.T) {
+	// Declined: the op is untouched so the user can still pay for gas
+	m := newPaymasterManager(t, &fakePaymaster{}, EntryPointV06, testPaymaster)
+	userOp := sponsorTestUserOp()
+	if _, err := m.SponsorUserOp(t.Context(), userOp); !errors.Is(err, ErrSponsorshipDeclined) {
+		t.Fatalf("declined sponsorship: %v", err)
+	}
+	if userOp["paymasterAndData"] != "0x" {
+		t.Fatalf("declined sponsorship changed paymasterAndData to %v", userOp["paymasterAndData"])
+	}
+
+	// A paymaster other than the configured one is never accepted
+	pm := &fakePaymaster{result: map[string]interface{}{
+		"paymasterAndData": "0x7777777777777777777777777777777777777777",
+	}}
+	m = newPaymasterManager(t, pm, EntryPointV06, testPaymaster)
+	userOp = sponsorTestUserOp()
+	if _, err := m.SponsorUserOp(t.Context(), userOp); err == nil {
+		t.Fatal("accepted an unexpected paymaster")
+	}
+	if userOp["paymasterAndData"] != "0x" {
+		t.Fatalf("rejected sponsorship changed paymasterAndData to %v", userOp["paymasterAndData"])
+	}
+
+	// Without PAYMASTER_URL sponsorship stays off
+	m = &Manager{}
+	if err := m.SetPaymaster("", ""); err != nil || m.SponsorshipEnabled() {
+		t.Fatalf("empty PAYMASTER_URL: enabled=%v err=%v", m.SponsorshipEnabled(), err)
+	}
+}
@@ -0,0 +1,247 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultPendingUserOpTTL is how long a prepared UserOp waits for its signature
+const DefaultPendingUserOpTTL = 10 * time.Minute
+
+// Pending UserOp lookup errors
+var (
+	ErrPendingUserOpNotFound = errors.New("pending user operation not found")
+	ErrPendingUserOpExpired  = errors.New("pending user operation expired")
+	ErrPendingUserOpNotOwner = errors.New("pending user operation belongs to another user")
+)
+
+// PendingUserOpStore persists prepared UserOps between the prepare and submit steps
+type PendingUserOpStore interface {
+	// Save stores an unsigned UserOp for userID under its hash
+	Save(ctx context.Context, userID, userOpHash string, userOp map[string]interface{}, ttl time.Duration) error
+	// Get returns userID's pending UserOp, or ErrPendingUserOpNotFound /
+	// ErrPendingUserOpNotOwner / ErrPendingUserOpExpired
+	Get(ctx context.Context, userOpHash, userID string) (*models.PendingUserOp, error)
+	// Take atomically removes and returns userID's pending UserOp, so only one of several
+	// concurrent submissions of the same hash can claim it. Errors match Get; an expired
+	// op is removed as well.
+	Take(ctx context.Context, userOpHash, userID string) (*models.PendingUserOp, error)
+	// Delete removes a pending UserOp once it has been submitted
+	Delete(ctx context.Context, userOpHash string) error
+	// DeleteExpired removes all expired entries and returns how many were deleted
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// newPendingUserOp builds the stored record for a UserOp map
+func newPendingUserOp(userID, userOpHash string, userOp map[string]interface{}, ttl time.Duration) (*models.PendingUserOp, error) {
+	encoded, err := json.Marshal(userOp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode user operation: %w", err)
+	}
+
+	now := time.Now()
+	return &models.PendingUserOp{
+		UserOpHash: userOpHash,
+		UserID:     userID,
+		UserOp:     string(encoded),
+		ExpiresAt:  now.Add(ttl),
+		CreatedAt:  now,
+	}, nil
+}
+
+// checkPendingUserOp verifies that a stored UserOp belongs to userID and is still valid
+// Ownership is checked first so other users can't probe an op's expiry state
+func checkPendingUserOp(record *models.PendingUserOp, userID string) error {
+	if record.UserID != userID {
+		return ErrPendingUserOpNotOwner
+	}
+	if record.IsExpired() {
+		return ErrPendingUserOpExpired
+	}
+	return nil
+}
+
+// GormPendingUserOpStore stores pending UserOps in Postgres
+type GormPendingUserOpStore struct {
+	db *gorm.DB
+}
+
+// NewGormPendingUserOpStore creates a Postgres-backed pending UserOp store
+func NewGormPendingUserOpStore(db *gorm.DB) *GormPendingUserOpStore {
+	return &GormPendingUserOpStore{db: db}
+}
+
+// Save stores (or replaces) a pending UserOp
+func (s *GormPendingUserOpStore) Save(ctx context.Context, userID, userOpHash string, userOp map[string]interface{}, ttl time.Duration) error {
+	record, err := newPendingUserOp(userID, userOpHash, userOp, ttl)
+	if err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Save(record).Error; err != nil {
+		return fmt.Errorf("failed to save pending user operation: %w", err)
+	}
+	return nil
+}
+
+// Get loads userID's pending UserOp by hash
+func (s *GormPendingUserOpStore) Get(ctx context.Context, userOpHash, userID string) (*models.PendingUserOp, error) {
+	var record models.PendingUserOp
+	if err := s.db.WithContext(ctx).Where("user_op_hash = ?", userOpHash).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPendingUserOpNotFound
+		}
+		return nil, err
+	}
+	if err := checkPendingUserOp(&record, userID); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Take deletes and returns userID's pending UserOp in a single DELETE ... RETURNING statement
+func (s *GormPendingUserOpStore) Take(ctx context.Context, userOpHash, userID string) (*models.PendingUserOp, error) {
+	var records []models.PendingUserOp
+	if err := s.db.WithContext(ctx).Clauses(clause.Returning{}).
+		Where("user_op_hash = ? AND user_id = ?", userOpHash, userID).
+		Delete(&records).Error; err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		// Either another user's op or one a concurrent submission already took
+		var count int64
+		if err := s.db.WithContext(ctx).Model(&models.PendingUserOp{}).Where("user_op_hash = ?", userOpHash).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, ErrPendingUserOpNotOwner
+		}
+		return nil, ErrPendingUserOpNotFound
+	}
+	if err := checkPendingUserOp(&records[0], userID); err != nil {
+		return nil, err
+	}
+	return &records[0], nil
+}
+
+// Delete removes a pending UserOp
+func (s *GormPendingUserOpStore) Delete(ctx context.Context, userOpHash string) error {
+	return s.db.WithContext(ctx).Where("user_op_hash = ?", userOpHash).Delete(&models.PendingUserOp{}).Error
+}
+
+// DeleteExpired removes expired pending UserOps
+func (s *GormPendingUserOpStore) DeleteExpired(ctx context.Context) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.PendingUserOp{})
+	return result.RowsAffected, result.Error
+}
+
+// MemoryPendingUserOpStore keeps pending UserOps in process memory (tests and local development)
+type MemoryPendingUserOpStore struct {
+	mu  sync.RWMutex
+	ops map[string]*models.PendingUserOp
+}
+
+// NewMemoryPendingUserOpStore creates an in-memory pending UserOp store
+func NewMemoryPendingUserOpStore() *MemoryPendingUserOpStore {
+	return &MemoryPendingUserOpStore{ops: make(map[string]*models.PendingUserOp)}
+}
+
+// Save stores (or replaces) a pending UserOp
+func (s *MemoryPendingUserOpStore) Save(ctx context.Context, userID, userOpHash string, userOp map[string]interface{}, ttl time.Duration) error {
+	record, err := newPendingUserOp(userID, userOpHash, userOp, ttl)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.ops[userOpHash] = record
+	s.mu.Unlock()
+	return nil
+}
+
+// Get loads userID's pending UserOp by hash
+func (s *MemoryPendingUserOpStore) Get(ctx context.Context, userOpHash, userID string) (*models.PendingUserOp, error) {
+	s.mu.RLock()
+	record, ok := s.ops[userOpHash]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrPendingUserOpNotFound
+	}
+	copied := *record
+	if err := checkPendingUserOp(&copied, userID); err != nil {
+		return nil, err
+	}
+	return &copied, nil
+}
+
+// Take deletes and returns userID's pending UserOp under the store lock
+func (s *MemoryPendingUserOpStore) Take(ctx context.Context, userOpHash, userID string) (*models.PendingUserOp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.ops[userOpHash]
+	if !ok {
+		return nil, ErrPendingUserOpNotFound
+	}
+	if record.UserID != userID {
+		return nil, ErrPendingUserOpNotOwner
+	}
+	delete(s.ops, userOpHash)
+	if record.IsExpired() {
+		return nil, ErrPendingUserOpExpired
+	}
+	return record, nil
+}
+
+// Delete removes a pending UserOp
+func (s *MemoryPendingUserOpStore) Delete(ctx context.Context, userOpHash string) error {
+	s.mu.Lock()
+	delete(s.ops, userOpHash)
+	s.mu.Unlock()
+	return nil
+}
+
+// DeleteExpired removes expired pending UserOps
+func (s *MemoryPendingUserOpStore) DeleteExpired(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for hash, record := range s.ops {
+		if record.IsExpired() {
+			delete(s.ops, hash)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// RunPendingUserOpSweeper periodically deletes expired pending UserOps until ctx is cancelled
+func RunPendingUserOpSweeper(ctx context.Context, store PendingUserOpStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := store.DeleteExpired(ctx)
+			if err != nil {
+				log.Printf("⚠️  Failed to sweep expired pending UserOps: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("🧹 Swept %d expired pending UserOps", deleted)
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPendingUserOpStoreRejectsOtherUser(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryPendingUserOpStore()
+
+	// User A prepares a transfer
+	if err := store.Save(ctx, "user-a", "0xhash", fixtureUserOp(), time.Minute); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// User B replays the hash
+	if _, err := store.Get(ctx, "0xhash", "user-b"); !errors.Is(err, ErrPendingUserOpNotOwner) {
+		t.Fatalf("Get as user B: got %v, want ErrPendingUserOpNotOwner", err)
+	}
+
+	// User A can still submit
+	pending, err := store.Get(ctx, "0xhash", "user-a")
+	if err != nil {
+		t.Fatalf("Get as user A: %v", err)
+	}
+	op, err := pending.Op()
+	if err != nil {
+		t.Fatalf("Op: %v", err)
+	}
+	if op["sender"] != fixtureUserOp()["sender"] {
+		t.Fatalf("sender = %v, want %v", op["sender"], fixtureUserOp()["sender"])
+	}
+}
+
+func TestPendingUserOpStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryPendingUserOpStore()
+
+	if err := store.Save(ctx, "user-a", "0xexpired", fixtureUserOp(), -time.Second); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	if _, err := store.Get(ctx, "0xexpired", "user-a"); !errors.Is(err, ErrPendingUserOpExpired) {
+		t.Fatalf("Get: got %v, want ErrPendingUserOpExpired", err)
+	}
+	// Another user must not learn that the op exists but expired
+	if _, err := store.Get(ctx, "0xexpired", "user-b"); !errors.Is(err, ErrPendingUserOpNotOwner) {
+		t.Fatalf("Get as user B: got %v, want ErrPendingUserOpNotOwner", err)
+	}
+
+	deleted, err := store.DeleteExpired(ctx)
+	if err != nil || deleted != 1 {
+		t.Fatalf("DeleteExpired = %d, %v; want 1, nil", deleted, err)
+	}
+	if _, err := store.Get(ctx, "0xexpired", "user-a"); !errors.Is(err, ErrPendingUserOpNotFound) {
+		t.Fatalf("Get after sweep: got %v, want ErrPendingUserOpNotFound", err)
+	}
+}
+
+// testPendingUserOpTakeOnce checks that racing Takes of one op hand it to exactly one caller
+func testPendingUserOpTakeOnce(t *testing.T, store PendingUserOpStore) {
+	ctx := context.Background()
+	if err := store.Save(ctx, "user-a", "0xtake", fixtureUserOp(), time.Minute); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := store.Take(ctx, "0xtake", "user-b"); !errors.Is(err, ErrPendingUserOpNotOwner) {
+		t.Fatalf("Take as user B: got %v, want ErrPendingUserOpNotOwner", err)
+	}
+
+	const attempts = 8
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		taken    int
+		notFound int
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.Take(ctx, "0xtake", "user-a")
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				taken++
+			case errors.Is(err, ErrPendingUserOpNotFound):
+				notFound++
+			default:
+				t.Errorf("Take: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if taken != 1 || notFound != attempts-1 {
+		t.Fatalf("taken %d, not found %d; want 1 and %d", taken, notFound, attempts-1)
+	}
+
+	if err := store.Save(ctx, "user-a", "0xtake-expired", fixtureUserOp(), -time.Second); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := store.Take(ctx, "0xtake-expired", "user-a"); !errors.Is(err, ErrPendingUserOpExpired) {
+		t.Fatalf("Take(expired): got %v, want ErrPendingUserOpExpired", err)
+	}
+	// Rejecting the expired op also removed it
+	if _, err := store.Take(ctx, "0xtake-expired", "user-a"); !errors.Is(err, ErrPendingUserOpNotFound) {
+		t.Fatalf("Take(expired) again: got %v, want ErrPendingUserOpNotFound", err)
+	}
+}
+
+func TestMemoryPendingUserOpStoreTake(t *testing.T) {
+	testPendingUserOpTakeOnce(t, NewMemoryPendingUserOpStore())
+}
+
+func TestGormPendingUserOpStoreTake(t *testing.T) {
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&models.PendingUserOp{}); err != nil {
+		t.Fatal(err)
+	}
+	testPendingUserOpTakeOnce(t, NewGormPendingUserOpStore(db))
+}
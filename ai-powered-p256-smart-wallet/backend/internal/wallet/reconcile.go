@@ -0,0 +1,139 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Defaults for the is_deployed reconciler
+const (
+	DefaultReconcileInterval  = 10 * time.Minute
+	DefaultReconcileBatchSize = 100
+)
+
+// ReconcileConfig controls RunDeployedReconciler
+type ReconcileConfig struct {
+	Interval  time.Duration // time between runs; zero or negative disables the reconciler
+	BatchSize int           // addresses per eth_getCode batch
+	All       bool          // also check wallets already flagged deployed
+}
+
+// ReconcileConfigFromEnv reads DEPLOYED_RECONCILE_INTERVAL (Go duration, "0" disables),
+// DEPLOYED_RECONCILE_BATCH_SIZE and DEPLOYED_RECONCILE_ALL; unset values keep the defaults
+func ReconcileConfigFromEnv() ReconcileConfig {
+	cfg := ReconcileConfig{Interval: DefaultReconcileInterval, BatchSize: DefaultReconcileBatchSize}
+	if interval, err := time.ParseDuration(os.Getenv("DEPLOYED_RECONCILE_INTERVAL")); err == nil {
+		cfg.Interval = interval
+	}
+	if batchSize, err := strconv.Atoi(os.Getenv("DEPLOYED_RECONCILE_BATCH_SIZE")); err == nil && batchSize > 0 {
+		cfg.BatchSize = batchSize
+	}
+	if all, err := strconv.ParseBool(os.Getenv("DEPLOYED_RECONCILE_ALL")); err == nil {
+		cfg.All = all
+	}
+	return cfg
+}
+
+// ReconcileResult summarizes a ReconcileDeployed run
+type ReconcileResult struct {
+	Checked    int // wallets whose code was fetched
+	Deployed   int // rows corrected to is_deployed = true
+	Undeployed int // rows corrected to is_deployed = false (e.g. a reset testnet)
+}
+
+// ReconcileDeployed syncs is_deployed/deployed_at of the wallets on the manager's chain
+// with the chain, fetching their code batchSize addresses at a time. Only wallets flagged
+// undeployed are checked unless all is set; the flag is never assumed, only read from code.
+func (m *Manager) ReconcileDeployed(ctx context.Context, batchSize int, all bool) (ReconcileResult, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultReconcileBatchSize
+	}
+
+	var result ReconcileResult
+	lastID := ""
+	for {
+		// Keyset pagination: rows corrected in a batch leave the undeployed filter
+		// without shifting the next page
+		query := m.db.WithContext(ctx).Where("chain_id = ? AND id > ?", m.chainID, lastID)
+		if !all {
+			query = query.Where("is_deployed = ?", false)
+		}
+		var wallets []models.Wallet
+		if err := query.Order("id").Limit(batchSize).Find(&wallets).Error; err != nil {
+			return result, fmt.Errorf("failed to load wallets: %w", err)
+		}
+		if len(wallets) == 0 {
+			return result, nil
+		}
+
+		if err := m.reconcileBatch(ctx, wallets, &result); err != nil {
+			return result, err
+		}
+		if len(wallets) < batchSize {
+			return result, nil
+		}
+		lastID = wallets[len(wallets)-1].ID
+	}
+}
+
+// reconcileBatch fetches the code of wallets in one batch and corrects rows whose flag disagrees
+func (m *Manager) reconcileBatch(ctx context.Context, wallets []models.Wallet, result *ReconcileResult) error {
+	addresses := make([]common.Address, len(wallets))
+	for i := range wallets {
+		addresses[i] = common.HexToAddress(wallets[i].Address)
+	}
+	codes, err := m.ethClient.CodesAt(ctx, addresses)
+	if err != nil {
+		return fmt.Errorf("failed to get wallet code: %w", err)
+	}
+
+	for i := range wallets {
+		result.Checked++
+		deployed := len(codes[i]) > 0
+		if deployed == wallets[i].IsDeployed {
+			continue
+		}
+		if err := m.syncDeployedFlag(ctx, wallets[i].Address, deployed); err != nil {
+			return fmt.Errorf("failed to sync is_deployed for %s: %w", wallets[i].Address, err)
+		}
+		if deployed {
+			result.Deployed++
+		} else {
+			result.Undeployed++
+		}
+	}
+	return nil
+}
+
+// RunDeployedReconciler periodically runs ReconcileDeployed until ctx is cancelled
+func RunDeployedReconciler(ctx context.Context, m *Manager, cfg ReconcileConfig) {
+	if cfg.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := m.ReconcileDeployed(ctx, cfg.BatchSize, cfg.All)
+			if err != nil {
+				log.Printf("⚠️  Failed to reconcile wallet deployments: %v", err)
+				continue
+			}
+			if result.Deployed+result.Undeployed > 0 {
+				log.Printf("🔄 Reconciled is_deployed of %d wallets (%d now deployed, %d now undeployed)",
+					result.Deployed+result.Undeployed, result.Deployed, result.Undeployed)
+			}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// codeNode answers eth_getCode, single or batched, with code for the addresses in
+// deployed and records the size of each request
+type codeNode struct {
+	mu       sync.Mutex
+	deployed map[string]bool // lower-case address
+	batches  []int
+}
+
+type codeRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+func (n *codeNode) reply(req codeRequest) map[string]interface{} {
+	var address string
+	json.Unmarshal(req.Params[0], &address)
+	code := "0x"
+	if n.deployed[strings.ToLower(address)] {
+		code = "0x6080"
+	}
+	return map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": code}
+}
+
+func (n *codeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var body json.RawMessage
+	json.NewDecoder(r.Body).Decode(&body)
+	if strings.HasPrefix(strings.TrimSpace(string(body)), "[") {
+		var reqs []codeRequest
+		json.Unmarshal(body, &reqs)
+		n.batches = append(n.batches, len(reqs))
+		replies := make([]map[string]interface{}, len(reqs))
+		for i, req := range reqs {
+			replies[i] = n.reply(req)
+		}
+		json.NewEncoder(w).Encode(replies)
+		return
+	}
+	var req codeRequest
+	json.Unmarshal(body, &req)
+	n.batches = append(n.batches, 1)
+	json.NewEncoder(w).Encode(n.reply(req))
+}
+
+func newCodeManager(t *testing.T, node *codeNode) *Manager {
+	t.Helper()
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+	client, err := ethclient.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return &Manager{db: dbtest.DryRun(t), ethClient: NewRPCClient(client), chainID: 133}
+}
+
+func TestReconcileBatchCorrectsDriftedFlags(t *testing.T) {
+	node := &codeNode{deployed: map[string]bool{
+		"0x1111111111111111111111111111111111111111": true,
+		"0x3333333333333333333333333333333333333333": true,
+	}}
+	m := newCodeManager(t, node)
+	updates := recordUpdates(t, m.db)
+
+	wallets := []models.Wallet{
+		{ID: "w1", Address: "0x1111111111111111111111111111111111111111"},                   // deployed, flagged false
+		{ID: "w2", Address: "0x2222222222222222222222222222222222222222"},                   // undeployed, flagged false
+		{ID: "w3", Address: "0x3333333333333333333333333333333333333333", IsDeployed: true}, // in sync
+		{ID: "w4", Address: "0x4444444444444444444444444444444444444444", IsDeployed: true}, // no code, flagged true
+	}
+	var result ReconcileResult
+	if err := m.reconcileBatch(t.Context(), wallets, &result); err != nil {
+		t.Fatalf("reconcileBatch: %v", err)
+	}
+
+	if result != (ReconcileResult{Checked: 4, Deployed: 1, Undeployed: 1}) {
+		t.Fatalf("result = %+v", result)
+	}
+	if len(node.batches) != 1 || node.batches[0] != 4 {
+		t.Fatalf("eth_getCode requests = %v, want one batch of 4", node.batches)
+	}
+	if len(*updates) != 2 || !strings.Contains((*updates)[0], "COALESCE(deployed_at") || !strings.Contains((*updates)[1], `"deployed_at"=$`) {
+		t.Fatalf("update statements = %v", *updates)
+	}
+}
+
+func TestReconcileDeployedPagesThroughWallets(t *testing.T) {
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&models.Wallet{}); err != nil {
+		t.Fatal(err)
+	}
+	node := &codeNode{deployed: map[string]bool{
+		"0x1111111111111111111111111111111111111111": true,
+		"0x3333333333333333333333333333333333333333": true,
+		"0x5555555555555555555555555555555555555555": true,
+	}}
+	m := newCodeManager(t, node)
+	m.db = db
+
+	for _, w := range []models.Wallet{
+		{ID: "w1", UserID: "u1", Address: "0x1111111111111111111111111111111111111111", ChainID: 133},
+		{ID: "w2", UserID: "u2", Address: "0x2222222222222222222222222222222222222222", ChainID: 133},
+		{ID: "w3", UserID: "u3", Address: "0x3333333333333333333333333333333333333333", ChainID: 133},
+		{ID: "w4", UserID: "u4", Address: "0x4444444444444444444444444444444444444444", ChainID: 133, IsDeployed: true},
+		{ID: "w5", UserID: "u5", Address: "0x5555555555555555555555555555555555555555", ChainID: 11155111}, // other chain
+	} {
+		if err := db.Create(&w).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Undeployed only: w1-w3 in batches of 2; w4 is not checked
+	result, err := m.ReconcileDeployed(t.Context(), 2, false)
+	if err != nil {
+		t.Fatalf("ReconcileDeployed: %v", err)
+	}
+	if result != (ReconcileResult{Checked: 3, Deployed: 2}) {
+		t.Fatalf("result = %+v", result)
+	}
+	if len(node.batches) != 2 || node.batches[0] != 2 || node.batches[1] != 1 {
+		t.Fatalf("eth_getCode requests = %v, want batches of 2 and 1", node.batches)
+	}
+
+	var rows []models.Wallet
+	db.Order("id").Find(&rows)
+	for _, row := range rows {
+		want := row.ID == "w1" || row.ID == "w3" || row.ID == "w4"
+		if row.IsDeployed != want || (want && row.DeployedAt == nil) {
+			t.Errorf("%s: is_deployed=%v deployed_at=%v, want deployed=%v", row.ID, row.IsDeployed, row.DeployedAt, want)
+		}
+	}
+
+	// All: w4 has no code and is reset
+	result, err = m.ReconcileDeployed(t.Context(), 10, true)
+	if err != nil {
+		t.Fatalf("ReconcileDeployed(all): %v", err)
+	}
+	if result != (ReconcileResult{Checked: 4, Undeployed: 1}) {
+		t.Fatalf("result(all) = %+v", result)
+	}
+}
+
+func TestReconcileConfigFromEnv(t *testing.T) {
+	cfg := ReconcileConfigFromEnv()
+	if cfg != (ReconcileConfig{Interval: DefaultReconcileInterval, BatchSize: DefaultReconcileBatchSize}) {
+		t.Fatalf("defaults = %+v", cfg)
+	}
+
+	t.Setenv("DEPLOYED_RECONCILE_INTERVAL", "0")
+	t.Setenv("DEPLOYED_RECONCILE_BATCH_SIZE", "25")
+	t.Setenv("DEPLOYED_RECONCILE_ALL", "true")
+	if cfg := ReconcileConfigFromEnv(); cfg != (ReconcileConfig{BatchSize: 25, All: true}) {
+		t.Fatalf("from env = %+v", cfg)
+	}
+
+	t.Setenv("DEPLOYED_RECONCILE_INTERVAL", "90s")
+	t.Setenv("DEPLOYED_RECONCILE_BATCH_SIZE", "-1")
+	if cfg := ReconcileConfigFromEnv(); cfg.Interval != 90*time.Second || cfg.BatchSize != DefaultReconcileBatchSize {
+		t.Fatalf("from env = %+v", cfg)
+	}
+}
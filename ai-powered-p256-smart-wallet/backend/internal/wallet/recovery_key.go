@@ -0,0 +1,25 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/models"
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// recoveryPublicKeyXSelector is P256Account.recoveryPublicKeyX(), only present on
+// implementations that support a recovery passkey
+var recoveryPublicKeyXSelector = crypto.Keccak256([]byte("recoveryPublicKeyX()"))[:4]
+
+// SupportsRecoveryKey reports whether w's implementation has setRecoveryKey and
+// rotatePublicKey. Wallets are proxies that cannot be upgraded, so wallets deployed with an
+// older implementation never will; an undeployed wallet is checked through the
+// implementation it will be deployed with.
+func (m *Manager) SupportsRecoveryKey(ctx context.Context, w *models.Wallet) (bool, error) {
+	result, reverted, found, err := m.callImplementation(ctx, w, recoveryPublicKeyXSelector)
+	if err != nil {
+		return false, fmt.Errorf("failed to check recovery key support of %s: %w", w.Address, err)
+	}
+	return found && !reverted && len(result) == 32, nil
+}
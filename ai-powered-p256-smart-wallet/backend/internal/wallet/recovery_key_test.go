@@ -0,0 +1,35 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/models"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestSupportsRecoveryKey(t *testing.T) {
+	wallet := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	implementation := common.HexToAddress("0x6666666666666666666666666666666666666666")
+	unset := hexutil.Encode(make([]byte, 32))
+
+	tests := []struct {
+		name string
+		node *callNode
+		want bool
+	}{
+		{"implementation with a recovery key slot", &callNode{results: map[common.Address]string{wallet: unset}}, true},
+		{"older implementation", &callNode{reverts: map[common.Address]bool{wallet: true}}, false},
+		{"undeployed wallet checks its implementation", &callNode{results: map[common.Address]string{implementation: unset}}, true},
+		{"nothing deployed", &callNode{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newCallManager(t, tt.node)
+			w := &models.Wallet{ID: "w1", Address: wallet.Hex(), ImplementationAddress: implementation.Hex()}
+			if supported, err := m.SupportsRecoveryKey(t.Context(), w); err != nil || supported != tt.want {
+				t.Fatalf("SupportsRecoveryKey = %v, %v; want %v", supported, err, tt.want)
+			}
+		})
+	}
+}
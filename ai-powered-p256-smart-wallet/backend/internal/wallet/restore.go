@@ -0,0 +1,67 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/models"
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ErrWalletNotDeleted is returned by RestoreWallet for a wallet that is still live
+var ErrWalletNotDeleted = errors.New("wallet is not deleted")
+
+// DeleteWallet soft-deletes one of the user's wallets. The row stays in the
+// database (with its history) but is hidden from every normal query until
+// RestoreWallet brings it back.
+func (m *Manager) DeleteWallet(ctx context.Context, userID, address string) error {
+	result := m.db.WithContext(ctx).
+		Where("user_id = ? AND address = ?", userID, address).
+		Delete(&models.Wallet{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete wallet: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("wallet %s: %w", address, gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// RestoreWallet undoes a soft delete of one of the user's wallets
+func (m *Manager) RestoreWallet(ctx context.Context, userID, address string) (*models.Wallet, error) {
+	return Restore(ctx, m.db, userID, address)
+}
+
+// Restore clears deleted_at on a soft-deleted wallet. It needs only the database,
+// so maintenance tools can use it without an RPC connection.
+func Restore(ctx context.Context, db *gorm.DB, userID, address string) (*models.Wallet, error) {
+	var wallet models.Wallet
+	if err := db.WithContext(ctx).Unscoped().
+		Where("user_id = ? AND address = ?", userID, address).
+		First(&wallet).Error; err != nil {
+		return nil, fmt.Errorf("wallet %s: %w", address, err)
+	}
+	if !wallet.DeletedAt.Valid {
+		return nil, fmt.Errorf("wallet %s: %w", address, ErrWalletNotDeleted)
+	}
+
+	if err := db.WithContext(ctx).Unscoped().Model(&wallet).Update("deleted_at", nil).Error; err != nil {
+		return nil, fmt.Errorf("failed to restore wallet: %w", err)
+	}
+	wallet.DeletedAt = gorm.DeletedAt{}
+	return &wallet, nil
+}
+
+// findDeletedWallet looks up a soft-deleted wallet at address, which still holds
+// the address and (user, chain, index) unique keys
+func (m *Manager) findDeletedWallet(ctx context.Context, address string) (*models.Wallet, error) {
+	var wallet models.Wallet
+	err := m.db.WithContext(ctx).Unscoped().
+		Where("address = ? AND deleted_at IS NOT NULL", address).
+		First(&wallet).Error
+	if err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
@@ -0,0 +1,84 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestWalletQueriesExcludeSoftDeleted(t *testing.T) {
+	db := dbtest.DryRun(t)
+
+	sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		var wallet models.Wallet
+		return tx.Where("address = ?", "0xabc").First(&wallet)
+	})
+	if !strings.Contains(sql, `"wallets"."deleted_at" IS NULL`) {
+		t.Errorf("wallet lookup does not filter soft-deleted rows: %s", sql)
+	}
+
+	sql = db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("user_id = ? AND address = ?", "u1", "0xabc").Delete(&models.Wallet{})
+	})
+	if !strings.HasPrefix(sql, `UPDATE "wallets" SET "deleted_at"=`) {
+		t.Errorf("wallet delete is not a soft delete: %s", sql)
+	}
+}
+
+func TestDeleteAndRestoreWallet(t *testing.T) {
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&models.User{}, &models.PasskeyCredential{}, &models.Wallet{}); err != nil {
+		t.Fatal(err)
+	}
+	m := &Manager{db: db, chainID: 133}
+	ctx := t.Context()
+
+	created := models.Wallet{ID: "w1", UserID: "u1", Address: "0xabc", ChainID: 133, CreatedAt: time.Now(), CreatedBy: "u1"}
+	if err := db.Create(&created).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.DeleteWallet(ctx, "u1", "0xabc"); err != nil {
+		t.Fatalf("DeleteWallet: %v", err)
+	}
+	if err := m.DeleteWallet(ctx, "u1", "0xabc"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("deleting twice: %v", err)
+	}
+
+	// Hidden from normal queries...
+	if _, err := m.GetWalletByAddress("0xabc"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetWalletByAddress after delete: %v", err)
+	}
+	if wallets, err := m.GetWalletsByUserID("u1"); err != nil || len(wallets) != 0 {
+		t.Fatalf("GetWalletsByUserID after delete = %v, %v", wallets, err)
+	}
+
+	// ...but still stored
+	var count int64
+	db.Unscoped().Model(&models.Wallet{}).Where("address = ?", "0xabc").Count(&count)
+	if count != 1 {
+		t.Fatalf("soft-deleted row count = %d", count)
+	}
+
+	if _, err := m.RestoreWallet(ctx, "u2", "0xabc"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("restoring another user's wallet: %v", err)
+	}
+	restored, err := m.RestoreWallet(ctx, "u1", "0xabc")
+	if err != nil {
+		t.Fatalf("RestoreWallet: %v", err)
+	}
+	if restored.ID != "w1" || restored.DeletedAt.Valid {
+		t.Fatalf("restored = %+v", restored)
+	}
+	if wallet, err := m.GetWalletByUserID("u1"); err != nil || wallet.ID != "w1" || wallet.CreatedBy != "u1" {
+		t.Fatalf("GetWalletByUserID after restore = %+v, %v", wallet, err)
+	}
+	if _, err := m.RestoreWallet(ctx, "u1", "0xabc"); !errors.Is(err, ErrWalletNotDeleted) {
+		t.Fatalf("restoring a live wallet: %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrRPCUnavailable is returned when an RPC read still fails with a transient error
+// (timeout, connection reset, rate limit, 5xx) after all retries
+var ErrRPCUnavailable = errors.New("RPC node unavailable")
+
+// Default retry policy for RPC reads
+const (
+	DefaultRPCAttempts  = 3
+	DefaultRPCBaseDelay = 200 * time.Millisecond
+	DefaultRPCMaxDelay  = 2 * time.Second
+)
+
+// limitExceededCode is the JSON-RPC error code (EIP-1474) nodes use for rate limiting
+const limitExceededCode = -32005
+
+// RPCClient is an ethclient.Client whose idempotent reads are retried with exponential
+// backoff on transient errors. Other errors (reverts, not found, bad requests) and
+// the caller's context ending are returned at once. SendTransaction is not retried:
+// a send that timed out may still have reached the node.
+type RPCClient struct {
+	*ethclient.Client
+
+	attempts  int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+}
+
+// NewRPCClient wraps client with the default retry policy
+func NewRPCClient(client *ethclient.Client) *RPCClient {
+	return &RPCClient{
+		Client:    client,
+		attempts:  DefaultRPCAttempts,
+		baseDelay: DefaultRPCBaseDelay,
+		maxDelay:  DefaultRPCMaxDelay,
+	}
+}
+
+// DialRPC connects to rpcURL and wraps the client with the default retry policy
+func DialRPC(rpcURL string) (*RPCClient, error) {
+	client, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, err
+	}
+	return NewRPCClient(client), nil
+}
+
+// SetRetryPolicy sets how many times a read is attempted and the backoff between attempts
+func (c *RPCClient) SetRetryPolicy(attempts int, baseDelay, maxDelay time.Duration) {
+	c.attempts = max(attempts, 1)
+	c.baseDelay = baseDelay
+	c.maxDelay = maxDelay
+}
+
+// retryRead runs read until it succeeds, fails with a non-transient error or the
+// attempts are used up; the last transient error is wrapped in ErrRPCUnavailable
+func retryRead[T any](ctx context.Context, c *RPCClient, method string, read func() (T, error)) (T, error) {
+	delay := c.baseDelay
+	for attempt := 1; ; attempt++ {
+		result, err := read()
+		if err == nil || !isTransientRPCError(err) || ctx.Err() != nil {
+			return result, err
+		}
+		if attempt >= c.attempts {
+			return result, fmt.Errorf("%w: %s failed after %d attempts: %v", ErrRPCUnavailable, method, attempt, err)
+		}
+
+		log.Printf("⚠️  %s failed (attempt %d/%d), retrying in %s: %v", method, attempt, c.attempts, delay, err)
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, c.maxDelay)
+	}
+}
+
+// isTransientRPCError reports whether err is worth retrying: network failures,
+// timeouts, rate limiting and server-side HTTP errors
+func isTransientRPCError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= http.StatusInternalServerError
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		message := strings.ToLower(rpcErr.Error())
+		return rpcErr.ErrorCode() == limitExceededCode || strings.Contains(message, "rate limit") || strings.Contains(message, "timeout")
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// ChainID retries eth_chainId
+func (c *RPCClient) ChainID(ctx context.Context) (*big.Int, error) {
+	return retryRead(ctx, c, "eth_chainId", func() (*big.Int, error) {
+		return c.Client.ChainID(ctx)
+	})
+}
+
+// BalanceAt retries eth_getBalance
+func (c *RPCClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return retryRead(ctx, c, "eth_getBalance", func() (*big.Int, error) {
+		return c.Client.BalanceAt(ctx, account, blockNumber)
+	})
+}
+
+// CodeAt retries eth_getCode
+func (c *RPCClient) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	return retryRead(ctx, c, "eth_getCode", func() ([]byte, error) {
+		return c.Client.CodeAt(ctx, account, blockNumber)
+	})
+}
+
+// CodesAt fetches the latest code at each address in one JSON-RPC batch; the whole
+// batch is retried on transient errors
+func (c *RPCClient) CodesAt(ctx context.Context, addresses []common.Address) ([][]byte, error) {
+	return retryRead(ctx, c, "eth_getCode", func() ([][]byte, error) {
+		codes := make([]hexutil.Bytes, len(addresses))
+		batch := make([]rpc.BatchElem, len(addresses))
+		for i, address := range addresses {
+			batch[i] = rpc.BatchElem{Method: "eth_getCode", Args: []interface{}{address, "latest"}, Result: &codes[i]}
+		}
+		if err := c.Client.Client().BatchCallContext(ctx, batch); err != nil {
+			return nil, err
+		}
+		result := make([][]byte, len(addresses))
+		for i := range batch {
+			if batch[i].Error != nil {
+				return nil, fmt.Errorf("eth_getCode %s: %w", addresses[i].Hex(), batch[i].Error)
+			}
+			result[i] = codes[i]
+		}
+		return result, nil
+	})
+}
+
+// CallContract retries eth_call
+func (c *RPCClient) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return retryRead(ctx, c, "eth_call", func() ([]byte, error) {
+		return c.Client.CallContract(ctx, msg, blockNumber)
+	})
+}
+
+// SuggestGasPrice retries eth_gasPrice
+func (c *RPCClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return retryRead(ctx, c, "eth_gasPrice", func() (*big.Int, error) {
+		return c.Client.SuggestGasPrice(ctx)
+	})
+}
+
+// EstimateGas retries eth_estimateGas
+func (c *RPCClient) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	return retryRead(ctx, c, "eth_estimateGas", func() (uint64, error) {
+		return c.Client.EstimateGas(ctx, msg)
+	})
+}
+
+// PendingNonceAt retries eth_getTransactionCount
+func (c *RPCClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return retryRead(ctx, c, "eth_getTransactionCount", func() (uint64, error) {
+		return c.Client.PendingNonceAt(ctx, account)
+	})
+}
+
+// HeaderByNumber retries eth_getBlockByNumber
+func (c *RPCClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return retryRead(ctx, c, "eth_getBlockByNumber", func() (*types.Header, error) {
+		return c.Client.HeaderByNumber(ctx, number)
+	})
+}
+
+// TransactionReceipt retries eth_getTransactionReceipt; ethereum.NotFound (not mined
+// yet) is returned at once so pollers keep their own schedule
+func (c *RPCClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return retryRead(ctx, c, "eth_getTransactionReceipt", func() (*types.Receipt, error) {
+		return c.Client.TransactionReceipt(ctx, txHash)
+	})
+}
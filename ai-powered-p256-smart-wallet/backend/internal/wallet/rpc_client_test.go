@@ -0,0 +1,181 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// flakyNode fails the first failures requests the way fail describes, then answers
+// eth_gasPrice and eth_call like a healthy node
+type flakyNode struct {
+	mu       sync.Mutex
+	failures int
+	fail     func(w http.ResponseWriter, id json.RawMessage)
+	requests map[string]int
+	callData string // eth_call result
+}
+
+func (n *flakyNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.requests == nil {
+		n.requests = make(map[string]int)
+	}
+	n.requests[req.Method]++
+	if n.failures > 0 {
+		n.failures--
+		n.fail(w, req.ID)
+		return
+	}
+
+	reply := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	switch req.Method {
+	case "eth_gasPrice":
+		reply["result"] = "0x3b9aca00"
+	case "eth_call":
+		reply["result"] = n.callData
+	default:
+		reply["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+	}
+	json.NewEncoder(w).Encode(reply)
+}
+
+func (n *flakyNode) count(method string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.requests[method]
+}
+
+func unavailable(w http.ResponseWriter, _ json.RawMessage) {
+	http.Error(w, "upstream down", http.StatusServiceUnavailable)
+}
+
+func rateLimited(w http.ResponseWriter, id json.RawMessage) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jsonrpc": "2.0", "id": id,
+		"error": map[string]interface{}{"code": -32005, "message": "limit exceeded"},
+	})
+}
+
+func reverted(w http.ResponseWriter, id json.RawMessage) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jsonrpc": "2.0", "id": id,
+		"error": map[string]interface{}{"code": 3, "message": "execution reverted", "data": "0x"},
+	})
+}
+
+func newFlakyClient(t *testing.T, node *flakyNode) *RPCClient {
+	t.Helper()
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+	client, err := ethclient.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	rpcClient := NewRPCClient(client)
+	rpcClient.SetRetryPolicy(3, time.Millisecond, 2*time.Millisecond)
+	return rpcClient
+}
+
+func TestRPCClientRetriesTransientErrors(t *testing.T) {
+	for name, fail := range map[string]func(http.ResponseWriter, json.RawMessage){
+		"503":          unavailable,
+		"rate limited": rateLimited,
+	} {
+		node := &flakyNode{failures: 2, fail: fail}
+		client := newFlakyClient(t, node)
+
+		gasPrice, err := client.SuggestGasPrice(t.Context())
+		if err != nil || gasPrice.Int64() != 1_000_000_000 {
+			t.Errorf("%s: SuggestGasPrice = %v, %v", name, gasPrice, err)
+		}
+		if got := node.count("eth_gasPrice"); got != 3 {
+			t.Errorf("%s: %d requests, want 3", name, got)
+		}
+	}
+}
+
+func TestRPCClientGivesUp(t *testing.T) {
+	node := &flakyNode{failures: 10, fail: unavailable}
+	client := newFlakyClient(t, node)
+
+	if _, err := client.SuggestGasPrice(t.Context()); !errors.Is(err, ErrRPCUnavailable) {
+		t.Fatalf("err = %v, want ErrRPCUnavailable", err)
+	}
+	if got := node.count("eth_gasPrice"); got != 3 {
+		t.Fatalf("%d requests, want 3", got)
+	}
+}
+
+func TestRPCClientDoesNotRetryHardErrors(t *testing.T) {
+	// A revert is an answer, not an outage
+	node := &flakyNode{failures: 1, fail: reverted}
+	client := newFlakyClient(t, node)
+	if _, err := client.CallContract(t.Context(), ethereum.CallMsg{}, nil); err == nil || errors.Is(err, ErrRPCUnavailable) {
+		t.Fatalf("revert: err = %v", err)
+	}
+	if got := node.count("eth_call"); got != 1 {
+		t.Fatalf("revert retried: %d requests", got)
+	}
+
+	// Writes are never retried
+	node = &flakyNode{failures: 1, fail: unavailable}
+	client = newFlakyClient(t, node)
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil)
+	if err := client.SendTransaction(t.Context(), tx); err == nil {
+		t.Fatal("SendTransaction succeeded against a failing node")
+	}
+	if got := node.count("eth_sendRawTransaction"); got != 1 {
+		t.Fatalf("send retried: %d requests", got)
+	}
+
+	// The caller's deadline ends the retries
+	node = &flakyNode{failures: 10, fail: unavailable}
+	client = newFlakyClient(t, node)
+	client.SetRetryPolicy(10, time.Hour, time.Hour)
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.SuggestGasPrice(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("cancelled: err = %v", err)
+	}
+}
+
+func TestGetWalletNonceFailsInsteadOfDefaulting(t *testing.T) {
+	node := &flakyNode{failures: 10, fail: unavailable}
+	m := &Manager{ethClient: newFlakyClient(t, node), entryPointAddr: DefaultEntryPointAddress}
+	if nonce, err := m.GetWalletNonce(t.Context(), "0x4444444444444444444444444444444444444444"); !errors.Is(err, ErrRPCUnavailable) {
+		t.Fatalf("unavailable node: nonce %v, err %v", nonce, err)
+	}
+
+	// No EntryPoint at the configured address: eth_call returns no data
+	node = &flakyNode{callData: "0x"}
+	m.ethClient = newFlakyClient(t, node)
+	if nonce, err := m.GetWalletNonce(t.Context(), "0x4444444444444444444444444444444444444444"); err == nil {
+		t.Fatalf("missing EntryPoint: nonce %v", nonce)
+	}
+
+	node = &flakyNode{callData: "0x0000000000000000000000000000000000000000000000000000000000000007"}
+	m.ethClient = newFlakyClient(t, node)
+	if nonce, err := m.GetWalletNonce(t.Context(), "0x4444444444444444444444444444444444444444"); err != nil || nonce.Int64() != 7 {
+		t.Fatalf("nonce = %v, %v", nonce, err)
+	}
+}
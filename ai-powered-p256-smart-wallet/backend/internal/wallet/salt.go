@@ -0,0 +1,56 @@
+package wallet
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Salt strategies for counterfactual wallet addresses
+const (
+	// SaltStrategyUser derives salt = keccak256(userID || uint256(index)), so every
+	// user gets distinct, reproducible addresses even if they share a signer key
+	SaltStrategyUser = "user"
+	// SaltStrategyIndex uses salt = index (the legacy behaviour: first wallet has salt 0)
+	SaltStrategyIndex = "index"
+)
+
+// maxUint256 bounds user-supplied salts
+var maxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// NormalizeSaltStrategy maps a config value to a supported salt strategy (default "user")
+func NormalizeSaltStrategy(strategy string) string {
+	if strings.EqualFold(strings.TrimSpace(strategy), SaltStrategyIndex) {
+		return SaltStrategyIndex
+	}
+	return SaltStrategyUser
+}
+
+// DeriveWalletSalt returns the deterministic CREATE2 salt for a user's index-th wallet
+func DeriveWalletSalt(strategy, userID string, index uint64) *big.Int {
+	if NormalizeSaltStrategy(strategy) == SaltStrategyIndex {
+		return new(big.Int).SetUint64(index)
+	}
+
+	indexWord := common.LeftPadBytes(new(big.Int).SetUint64(index).Bytes(), 32)
+	return new(big.Int).SetBytes(crypto.Keccak256([]byte(userID), indexWord))
+}
+
+// ParseSalt parses a decimal or 0x-prefixed hex salt that must fit in a uint256
+func ParseSalt(value string) (*big.Int, error) {
+	value = strings.TrimSpace(value)
+
+	salt, ok := new(big.Int), false
+	if strings.HasPrefix(value, "0x") || strings.HasPrefix(value, "0X") {
+		_, ok = salt.SetString(value[2:], 16)
+	} else {
+		_, ok = salt.SetString(value, 10)
+	}
+	if !ok || salt.Sign() < 0 || salt.Cmp(maxUint256) > 0 {
+		return nil, fmt.Errorf("invalid salt %q: must be a uint256", value)
+	}
+	return salt, nil
+}
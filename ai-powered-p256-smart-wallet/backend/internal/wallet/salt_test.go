@@ -0,0 +1,47 @@
+package wallet
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestDeriveWalletSaltUserStrategy(t *testing.T) {
+	a0 := DeriveWalletSalt(SaltStrategyUser, "user-a", 0)
+	if a0.Cmp(DeriveWalletSalt(SaltStrategyUser, "user-a", 0)) != 0 {
+		t.Fatal("salt is not deterministic")
+	}
+	if a0.Cmp(DeriveWalletSalt(SaltStrategyUser, "user-a", 1)) == 0 {
+		t.Fatal("different indexes produced the same salt")
+	}
+	if a0.Cmp(DeriveWalletSalt(SaltStrategyUser, "user-b", 0)) == 0 {
+		t.Fatal("different users produced the same salt")
+	}
+	if a0.Sign() == 0 {
+		t.Fatal("user salt should not be zero")
+	}
+}
+
+func TestDeriveWalletSaltIndexStrategy(t *testing.T) {
+	if got := DeriveWalletSalt(SaltStrategyIndex, "user-a", 0); got.Sign() != 0 {
+		t.Fatalf("legacy first wallet salt = %s, want 0", got)
+	}
+	if got := DeriveWalletSalt("INDEX", "user-a", 3); got.Cmp(big.NewInt(3)) != 0 {
+		t.Fatalf("salt = %s, want 3", got)
+	}
+}
+
+func TestParseSalt(t *testing.T) {
+	for input, want := range map[string]int64{"0": 0, "42": 42, "0x2a": 42} {
+		got, err := ParseSalt(input)
+		if err != nil || got.Cmp(big.NewInt(want)) != 0 {
+			t.Fatalf("ParseSalt(%q) = %v, %v; want %d", input, got, err, want)
+		}
+	}
+
+	tooBig := "0x10000000000000000000000000000000000000000000000000000000000000000"
+	for _, bad := range []string{"", "-1", "abc", "0xzz", tooBig} {
+		if _, err := ParseSalt(bad); err == nil {
+			t.Fatalf("ParseSalt(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/models"
+	"ai-wallet-backend/internal/webauthnp256"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// signatureFormatV1Selector is P256Account.SIGNATURE_FORMAT_V1(), only present on
+// implementations that read the version byte
+var signatureFormatV1Selector = crypto.Keccak256([]byte("SIGNATURE_FORMAT_V1()"))[:4]
+
+// SignatureFormat returns the UserOp signature layout (webauthnp256.SignatureFormat*) that
+// w's implementation decodes. Wallets are proxies that cannot be upgraded, so the answer is
+// probed once with SIGNATURE_FORMAT_V1() and kept on the wallet row: older implementations
+// revert and read the legacy layout. An undeployed wallet is probed through the
+// implementation it will be deployed with.
+func (m *Manager) SignatureFormat(ctx context.Context, w *models.Wallet) (byte, error) {
+	if w.SignatureFormat != nil {
+		return *w.SignatureFormat, nil
+	}
+
+	result, reverted, found, err := m.callImplementation(ctx, w, signatureFormatV1Selector)
+	if err != nil {
+		return 0, fmt.Errorf("failed to probe signature format of %s: %w", w.Address, err)
+	}
+	if !found {
+		// No code at the wallet or its implementation: nothing to learn yet, so nothing is stored
+		return webauthnp256.SignatureFormatLegacy, nil
+	}
+
+	format := webauthnp256.SignatureFormatLegacy
+	if !reverted && len(result) == 32 && new(big.Int).SetBytes(result).Cmp(big.NewInt(int64(webauthnp256.SignatureFormatV1))) == 0 {
+		format = webauthnp256.SignatureFormatV1
+	}
+	if err := m.db.WithContext(ctx).Model(&models.Wallet{}).Where("id = ?", w.ID).Update("signature_format", format).Error; err != nil {
+		log.Printf("⚠️  Failed to store signature format of wallet %s: %v", w.Address, err)
+	}
+	w.SignatureFormat = &format
+	return format, nil
+}
+
+// callImplementation eth_calls a no-argument getter on w, or on the implementation it will
+// be deployed with while it has no code. found is false when neither has code; reverted
+// means the implementation does not have the getter.
+func (m *Manager) callImplementation(ctx context.Context, w *models.Wallet, selector []byte) (result []byte, reverted, found bool, err error) {
+	targets := []string{w.Address}
+	if w.ImplementationAddress != "" {
+		targets = append(targets, w.ImplementationAddress)
+	}
+	for _, target := range targets {
+		to := common.HexToAddress(target)
+		result, err := m.ethClient.CallContract(ctx, ethereum.CallMsg{To: &to, Data: selector}, nil)
+		switch {
+		case isExecutionReverted(err):
+			return nil, true, true, nil
+		case err != nil:
+			return nil, false, false, err
+		case len(result) > 0:
+			return result, false, true, nil
+		}
+	}
+	return nil, false, false, nil
+}
+
+// isExecutionReverted reports whether err is the node answering that an eth_call reverted,
+// as opposed to the call not reaching the node
+func isExecutionReverted(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	return rpcErr.ErrorCode() == 3 || strings.Contains(strings.ToLower(rpcErr.Error()), "revert")
+}
@@ -0,0 +1,115 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"ai-wallet-backend/internal/webauthnp256"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// callNode answers eth_call per target: results holds the returned data, reverts the
+// targets whose call reverts, and any other target has no code
+type callNode struct {
+	mu      sync.Mutex
+	results map[common.Address]string
+	reverts map[common.Address]bool
+	calls   int
+}
+
+func (n *callNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	reply := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if req.Method != "eth_call" {
+		reply["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+		json.NewEncoder(w).Encode(reply)
+		return
+	}
+	n.calls++
+	var call struct {
+		To common.Address `json:"to"`
+	}
+	json.Unmarshal(req.Params[0], &call)
+	switch {
+	case n.reverts[call.To]:
+		reply["error"] = map[string]interface{}{"code": 3, "message": "execution reverted"}
+	case n.results[call.To] != "":
+		reply["result"] = n.results[call.To]
+	default:
+		reply["result"] = "0x"
+	}
+	json.NewEncoder(w).Encode(reply)
+}
+
+func newCallManager(t *testing.T, node *callNode) *Manager {
+	t.Helper()
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+	client, err := ethclient.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return &Manager{db: dbtest.DryRun(t), ethClient: NewRPCClient(client), chainID: 133}
+}
+
+func TestSignatureFormat(t *testing.T) {
+	wallet := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	implementation := common.HexToAddress("0x6666666666666666666666666666666666666666")
+	v1 := hexutil.Encode(common.LeftPadBytes([]byte{webauthnp256.SignatureFormatV1}, 32))
+
+	tests := []struct {
+		name   string
+		node   *callNode
+		want   byte
+		cached bool
+	}{
+		{"versioned implementation", &callNode{results: map[common.Address]string{wallet: v1}}, webauthnp256.SignatureFormatV1, true},
+		{"implementation without the getter", &callNode{reverts: map[common.Address]bool{wallet: true}}, webauthnp256.SignatureFormatLegacy, true},
+		{"undeployed wallet probes its implementation", &callNode{results: map[common.Address]string{implementation: v1}}, webauthnp256.SignatureFormatV1, true},
+		{"nothing deployed", &callNode{}, webauthnp256.SignatureFormatLegacy, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newCallManager(t, tt.node)
+			updates := recordUpdates(t, m.db)
+			w := &models.Wallet{ID: "w1", Address: wallet.Hex(), ImplementationAddress: implementation.Hex()}
+
+			format, err := m.SignatureFormat(t.Context(), w)
+			if err != nil || format != tt.want {
+				t.Fatalf("SignatureFormat = %d, %v; want %d", format, err, tt.want)
+			}
+			if !tt.cached {
+				if len(*updates) != 0 || w.SignatureFormat != nil {
+					t.Fatalf("format was stored without any code to probe: %v", *updates)
+				}
+				return
+			}
+			if len(*updates) != 1 || !strings.Contains((*updates)[0], `"signature_format"=$`) {
+				t.Fatalf("update statements = %v", *updates)
+			}
+
+			// The stored format is used without probing again
+			calls := tt.node.calls
+			if format, err := m.SignatureFormat(t.Context(), w); err != nil || format != tt.want || tt.node.calls != calls {
+				t.Fatalf("second SignatureFormat = %d, %v after %d more calls", format, err, tt.node.calls-calls)
+			}
+		})
+	}
+}
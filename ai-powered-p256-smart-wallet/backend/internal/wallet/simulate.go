@@ -0,0 +1,191 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrInsufficientBalance is matched by *InsufficientBalanceError
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+// InsufficientBalanceError reports that the wallet cannot cover a transfer and its gas
+type InsufficientBalanceError struct {
+	Token     string // NativeToken or the ERC-20 contract address
+	Symbol    string
+	Decimals  uint8
+	Required  *big.Int
+	Available *big.Int
+}
+
+func (e *InsufficientBalanceError) Error() string {
+	return fmt.Sprintf("insufficient %s balance: need %s, have %s",
+		e.Symbol, FormatUnits(e.Required, e.Decimals), FormatUnits(e.Available, e.Decimals))
+}
+
+// Is makes errors.Is(err, ErrInsufficientBalance) match
+func (e *InsufficientBalanceError) Is(target error) bool {
+	return target == ErrInsufficientBalance
+}
+
+// BalanceChange is the expected effect of a transfer on one account's balance of one asset
+type BalanceChange struct {
+	Address   string `json:"address"`
+	Token     string `json:"token"` // NativeToken or the ERC-20 contract address
+	Symbol    string `json:"symbol"`
+	Decimals  uint8  `json:"decimals"`
+	Before    string `json:"before"` // base units
+	After     string `json:"after"`
+	Delta     string `json:"delta"`     // signed, base units
+	Formatted string `json:"formatted"` // signed, e.g. "-0.5"
+}
+
+// TransferSimulation is the dry-run result of a transfer UserOp
+type TransferSimulation struct {
+	Success        bool
+	RevertReason   string
+	GasLimit       *big.Int // callGasLimit + verificationGasLimit + preVerificationGas
+	MaxFeePerGas   *big.Int
+	MaxGasCost     *big.Int // upper bound charged in the native token
+	Sponsored      bool     // a paymaster pays the gas, so MaxGasCost is not charged to the wallet
+	BalanceChanges []BalanceChange
+}
+
+// SimulateTransfer dry-runs a transfer UserOp built by the backend without submitting it:
+// it checks the wallet can pay the amount plus the maximum gas cost, then eth_calls the
+// transfer from the wallet address to surface a revert reason. Gas is charged at the
+// UserOp's limits and maxFeePerGas, so the returned cost is an upper bound; it is not
+// charged to the wallet when the UserOp carries paymasterAndData.
+// A wallet that cannot cover the transfer returns an *InsufficientBalanceError.
+func (m *Manager) SimulateTransfer(ctx context.Context, userOp map[string]interface{}, nativeSymbol string) (*TransferSimulation, error) {
+	sender, _ := userOp["sender"].(string)
+	transfer, ok := DecodeTransferCallData(hexToBytes(userOp["callData"]))
+	if !common.IsHexAddress(sender) || !ok {
+		return nil, fmt.Errorf("UserOp is not a transfer built by the backend")
+	}
+	amount, _ := new(big.Int).SetString(transfer.Amount, 10)
+
+	gasLimit := new(big.Int).Add(hexToBigInt(userOp["callGasLimit"]), hexToBigInt(userOp["verificationGasLimit"]))
+	gasLimit.Add(gasLimit, hexToBigInt(userOp["preVerificationGas"]))
+	maxFeePerGas := hexToBigInt(userOp["maxFeePerGas"])
+	sim := &TransferSimulation{
+		Success:      true,
+		GasLimit:     gasLimit,
+		MaxFeePerGas: maxFeePerGas,
+		MaxGasCost:   new(big.Int).Mul(gasLimit, maxFeePerGas),
+		Sponsored:    len(hexToBytes(userOp["paymasterAndData"])) > 0,
+	}
+
+	nativeBefore, err := m.GetBalance(ctx, sender)
+	if err != nil {
+		return nil, err
+	}
+	nativeSpent := new(big.Int)
+	if !sim.Sponsored {
+		nativeSpent.Set(sim.MaxGasCost)
+	}
+	if transfer.Token == "" {
+		nativeSpent.Add(nativeSpent, amount)
+	}
+	if nativeBefore.Cmp(nativeSpent) < 0 {
+		return nil, &InsufficientBalanceError{Token: NativeToken, Symbol: nativeSymbol, Decimals: 18, Required: nativeSpent, Available: nativeBefore}
+	}
+	if nativeSpent.Sign() > 0 {
+		sim.BalanceChanges = append(sim.BalanceChanges, newBalanceChange(sender, NativeToken, nativeSymbol, 18, nativeBefore, new(big.Int).Neg(nativeSpent)))
+	}
+
+	call := ethereum.CallMsg{From: common.HexToAddress(sender)}
+	if transfer.Token == "" {
+		recipientBefore, err := m.GetBalance(ctx, transfer.Recipient)
+		if err != nil {
+			return nil, err
+		}
+		sim.BalanceChanges = append(sim.BalanceChanges, newBalanceChange(transfer.Recipient, NativeToken, nativeSymbol, 18, recipientBefore, amount))
+
+		to := common.HexToAddress(transfer.Recipient)
+		call.To, call.Value = &to, amount
+	} else {
+		senderToken, err := m.getERC20Balance(ctx, transfer.Token, sender)
+		if err != nil {
+			return nil, err
+		}
+		tokenBefore, _ := new(big.Int).SetString(senderToken.Raw, 10)
+		if tokenBefore.Cmp(amount) < 0 {
+			return nil, &InsufficientBalanceError{Token: transfer.Token, Symbol: senderToken.Symbol, Decimals: senderToken.Decimals, Required: amount, Available: tokenBefore}
+		}
+		recipientToken, err := m.getERC20Balance(ctx, transfer.Token, transfer.Recipient)
+		if err != nil {
+			return nil, err
+		}
+		recipientBefore, _ := new(big.Int).SetString(recipientToken.Raw, 10)
+		sim.BalanceChanges = append(sim.BalanceChanges,
+			newBalanceChange(sender, transfer.Token, senderToken.Symbol, senderToken.Decimals, tokenBefore, new(big.Int).Neg(amount)),
+			newBalanceChange(transfer.Recipient, transfer.Token, senderToken.Symbol, senderToken.Decimals, recipientBefore, amount),
+		)
+
+		args, err := transferArguments.Pack(common.HexToAddress(transfer.Recipient), amount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode transfer: %w", err)
+		}
+		to := common.HexToAddress(transfer.Token)
+		call.To = &to
+		call.Data = append(append([]byte{}, transferSelector...), args...)
+	}
+
+	// The wallet's execute() forwards this call, so its revert is the UserOp's revert
+	if _, err := m.ethClient.CallContract(ctx, call, nil); err != nil {
+		reason, reverted := revertReason(err)
+		if !reverted {
+			return nil, fmt.Errorf("failed to simulate transfer: %w", err)
+		}
+		sim.Success = false
+		sim.RevertReason = reason
+	}
+
+	return sim, nil
+}
+
+// newBalanceChange describes before → before+delta for one account and asset
+func newBalanceChange(address, token, symbol string, decimals uint8, before, delta *big.Int) BalanceChange {
+	formatted := FormatUnits(delta, decimals)
+	if delta.Sign() > 0 {
+		formatted = "+" + formatted
+	}
+	return BalanceChange{
+		Address:   address,
+		Token:     token,
+		Symbol:    symbol,
+		Decimals:  decimals,
+		Before:    before.String(),
+		After:     new(big.Int).Add(before, delta).String(),
+		Delta:     delta.String(),
+		Formatted: formatted,
+	}
+}
+
+// revertReason extracts the reason from an eth_call error; reverted is false for
+// errors that are not reverts (e.g. the RPC being unreachable)
+func revertReason(err error) (reason string, reverted bool) {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if data, ok := dataErr.ErrorData().(string); ok {
+			if raw, decodeErr := hexutil.Decode(data); decodeErr == nil {
+				if reason, unpackErr := abi.UnpackRevert(raw); unpackErr == nil {
+					return reason, true
+				}
+			}
+		}
+	}
+	if strings.Contains(err.Error(), "revert") {
+		return err.Error(), true
+	}
+	return "", false
+}
@@ -0,0 +1,192 @@
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	simSender    = "0x1111111111111111111111111111111111111111"
+	simRecipient = "0x2222222222222222222222222222222222222222"
+	simToken     = "0x3333333333333333333333333333333333333333"
+)
+
+// fakeNode answers the JSON-RPC calls SimulateTransfer makes
+type fakeNode struct {
+	balances     map[string]*big.Int // lower-case address → native balance
+	tokenBalance *big.Int            // balanceOf for any holder
+	revert       string              // revert reason for the simulated transfer, if any
+}
+
+func (n *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	reply := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+
+	switch req.Method {
+	case "eth_getBalance":
+		var address string
+		json.Unmarshal(req.Params[0], &address)
+		balance := n.balances[strings.ToLower(address)]
+		if balance == nil {
+			balance = new(big.Int)
+		}
+		reply["result"] = hexutil.EncodeBig(balance)
+	case "eth_call":
+		var call struct {
+			Input hexutil.Bytes `json:"input"`
+			Data  hexutil.Bytes `json:"data"`
+		}
+		json.Unmarshal(req.Params[0], &call)
+		data := call.Input
+		if len(data) == 0 {
+			data = call.Data
+		}
+		uint256Type, _ := abi.NewType("uint256", "", nil)
+		stringType, _ := abi.NewType("string", "", nil)
+		switch {
+		case len(data) >= 4 && hexutil.Encode(data[:4]) == "0x70a08231": // balanceOf
+			out, _ := abi.Arguments{{Type: uint256Type}}.Pack(n.tokenBalance)
+			reply["result"] = hexutil.Encode(out)
+		case len(data) >= 4 && hexutil.Encode(data[:4]) == "0x313ce567": // decimals
+			out, _ := abi.Arguments{{Type: uint256Type}}.Pack(big.NewInt(6))
+			reply["result"] = hexutil.Encode(out)
+		case len(data) >= 4 && hexutil.Encode(data[:4]) == "0x95d89b41": // symbol
+			out, _ := abi.Arguments{{Type: stringType}}.Pack("USDT")
+			reply["result"] = hexutil.Encode(out)
+		case n.revert != "":
+			out, _ := abi.Arguments{{Type: stringType}}.Pack(n.revert)
+			reply["error"] = map[string]interface{}{
+				"code":    3,
+				"message": "execution reverted: " + n.revert,
+				"data":    hexutil.Encode(append(common.FromHex("0x08c379a0"), out...)),
+			}
+		default:
+			reply["result"] = "0x"
+		}
+	default:
+		reply["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+	}
+	json.NewEncoder(w).Encode(reply)
+}
+
+func newSimulationManager(t *testing.T, node *fakeNode) *Manager {
+	t.Helper()
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+	client, err := ethclient.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return &Manager{ethClient: NewRPCClient(client)}
+}
+
+// transferUserOp returns a UserOp whose gas limits total 100000 at 1 gwei (max cost 0.0001)
+func transferUserOp(callData []byte) map[string]interface{} {
+	return map[string]interface{}{
+		"sender":               simSender,
+		"callData":             hexutil.Encode(callData),
+		"callGasLimit":         "0x7530", // 30000
+		"verificationGasLimit": "0x9c40", // 40000
+		"preVerificationGas":   "0x7530", // 30000
+		"maxFeePerGas":         "0x3b9aca00",
+	}
+}
+
+func nativeTransferCallData(t *testing.T, amount *big.Int) []byte {
+	t.Helper()
+	args, err := executeArguments.Pack(common.HexToAddress(simRecipient), amount, []byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(append([]byte{}, executeSelector...), args...)
+}
+
+func ether(s string) *big.Int {
+	v, _ := new(big.Float).SetString(s)
+	wei, _ := new(big.Float).Mul(v, big.NewFloat(1e18)).Int(nil)
+	return wei
+}
+
+func TestSimulateNativeTransfer(t *testing.T) {
+	m := newSimulationManager(t, &fakeNode{balances: map[string]*big.Int{
+		simSender:    ether("1"),
+		simRecipient: ether("2"),
+	}})
+
+	sim, err := m.SimulateTransfer(t.Context(), transferUserOp(nativeTransferCallData(t, ether("0.5"))), "HSK")
+	if err != nil {
+		t.Fatalf("SimulateTransfer: %v", err)
+	}
+	if !sim.Success || sim.RevertReason != "" {
+		t.Fatalf("expected success, got revert %q", sim.RevertReason)
+	}
+	if sim.GasLimit.Int64() != 100000 || sim.MaxGasCost.Cmp(ether("0.0001")) != 0 {
+		t.Fatalf("gas limit %s, cost %s", sim.GasLimit, sim.MaxGasCost)
+	}
+	if len(sim.BalanceChanges) != 2 {
+		t.Fatalf("expected sender and recipient changes, got %+v", sim.BalanceChanges)
+	}
+	if got := sim.BalanceChanges[0]; got.Formatted != "-0.5001" || got.After != ether("0.4999").String() {
+		t.Errorf("sender change = %+v", got)
+	}
+	if got := sim.BalanceChanges[1]; got.Formatted != "+0.5" || got.After != ether("2.5").String() {
+		t.Errorf("recipient change = %+v", got)
+	}
+}
+
+func TestSimulateTransferInsufficientBalance(t *testing.T) {
+	m := newSimulationManager(t, &fakeNode{balances: map[string]*big.Int{simSender: ether("0.5")}})
+
+	// The amount fits, the amount plus gas does not
+	_, err := m.SimulateTransfer(t.Context(), transferUserOp(nativeTransferCallData(t, ether("0.5"))), "HSK")
+	var insufficient *InsufficientBalanceError
+	if !errors.Is(err, ErrInsufficientBalance) || !errors.As(err, &insufficient) {
+		t.Fatalf("expected InsufficientBalanceError, got %v", err)
+	}
+	if insufficient.Token != NativeToken || insufficient.Required.Cmp(ether("0.5001")) != 0 || insufficient.Available.Cmp(ether("0.5")) != 0 {
+		t.Fatalf("unexpected error details: %+v", insufficient)
+	}
+
+	// ERC-20 transfers need enough tokens; gas is still paid in the native token
+	m = newSimulationManager(t, &fakeNode{balances: map[string]*big.Int{simSender: ether("1")}, tokenBalance: big.NewInt(1_000_000)})
+	inner, _ := transferArguments.Pack(common.HexToAddress(simRecipient), big.NewInt(2_000_000))
+	args, _ := executeArguments.Pack(common.HexToAddress(simToken), new(big.Int), append(append([]byte{}, transferSelector...), inner...))
+	_, err = m.SimulateTransfer(t.Context(), transferUserOp(append(append([]byte{}, executeSelector...), args...)), "HSK")
+	if !errors.As(err, &insufficient) || insufficient.Symbol != "USDT" || insufficient.Decimals != 6 {
+		t.Fatalf("expected USDT InsufficientBalanceError, got %v", err)
+	}
+	if want := "insufficient USDT balance: need 2, have 1"; err.Error() != want {
+		t.Fatalf("error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestSimulateTransferRevertReason(t *testing.T) {
+	m := newSimulationManager(t, &fakeNode{
+		balances: map[string]*big.Int{simSender: ether("1")},
+		revert:   "recipient rejects HSK",
+	})
+
+	sim, err := m.SimulateTransfer(t.Context(), transferUserOp(nativeTransferCallData(t, ether("0.5"))), "HSK")
+	if err != nil {
+		t.Fatalf("SimulateTransfer: %v", err)
+	}
+	if sim.Success || sim.RevertReason != "recipient rejects HSK" {
+		t.Fatalf("expected revert reason, got success=%v reason=%q", sim.Success, sim.RevertReason)
+	}
+}
@@ -1,6 +1,7 @@
 package wallet
 
 import (
+	"ai-wallet-backend/internal/chains"
 	"context"
 	"encoding/hex"
 	"fmt"
@@ -47,8 +48,13 @@ const EntryPointHandleOpsABI = `[
 ]`
 
 // SubmitUserOperation submits a UserOperation to the EntryPoint contract
-// This backend acts as a "Bundler" by paying for gas
+// In direct mode this backend acts as a "Bundler" by paying for gas;
+// in bundler mode the UserOp is relayed to the configured bundler and the key is unused
 func (m *Manager) SubmitUserOperation(ctx context.Context, userOpData map[string]interface{}, bundlerPrivateKeyHex string) (string, error) {
+	if m.submitMode == SubmitModeBundler {
+		return m.submitViaBundler(ctx, userOpData)
+	}
+
 	log.Printf("🚀 Submitting UserOperation to chain...")
 
 	// Parse bundler private key
@@ -60,31 +66,10 @@ func (m *Manager) SubmitUserOperation(ctx context.Context, userOpData map[string
 	bundlerAddress := crypto.PubkeyToAddress(privateKey.PublicKey)
 	log.Printf("📌 Bundler address: %s", bundlerAddress.Hex())
 
-	// Parse EntryPoint ABI
-	parsedABI, err := abi.JSON(strings.NewReader(EntryPointHandleOpsABI))
-	if err != nil {
-		return "", fmt.Errorf("failed to parse ABI: %w", err)
-	}
-
-	// Convert UserOperation from map to struct
-	userOp, err := m.parseUserOperationFromMap(userOpData)
+	// Pack handleOps call for the EntryPoint version the UserOp was built for
+	data, err := m.packHandleOps(userOpData, bundlerAddress)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse UserOperation: %w", err)
-	}
-
-	// Log UserOperation details
-	log.Printf("📝 UserOperation details:")
-	log.Printf("   Sender: %s", userOp.Sender.Hex())
-	log.Printf("   Nonce: %s", userOp.Nonce.String())
-	log.Printf("   CallData length: %d", len(userOp.CallData))
-	log.Printf("   Signature length: %d", len(userOp.Signature))
-
-	// Pack handleOps call: handleOps(UserOperation[], address)
-	// go-ethereum ABI library requires actual struct slice for tuple[] types
-	userOpsArray := []UserOperation{*userOp}
-	data, err := parsedABI.Pack("handleOps", userOpsArray, bundlerAddress)
-	if err != nil {
-		return "", fmt.Errorf("failed to pack handleOps: %w", err)
+		return "", err
 	}
 
 	// Get chain ID
@@ -109,8 +94,8 @@ func (m *Manager) SubmitUserOperation(ctx context.Context, userOpData map[string
 	gasPrice = new(big.Int).Mul(gasPrice, big.NewInt(120))
 	gasPrice = new(big.Int).Div(gasPrice, big.NewInt(100))
 
-	// Use the EntryPoint address (defined in aa_wallet.go)
-	entryPointAddr := common.HexToAddress(DefaultEntryPointAddress)
+	// Use the configured EntryPoint address
+	entryPointAddr := m.EntryPointAddress()
 
 	// Estimate gas limit
 	gasLimit := uint64(1000000) // 1M gas limit for handleOps
@@ -144,7 +129,9 @@ func (m *Manager) SubmitUserOperation(ctx context.Context, userOpData map[string
 
 	txHash := signedTx.Hash().Hex()
 	log.Printf("✅ Transaction sent! Hash: %s", txHash)
-	log.Printf("🔗 Explorer: https://testnet-explorer.hsk.xyz/tx/%s", txHash)
+	if explorerURL := chains.Default.TxURL(int64(m.chainID), txHash); explorerURL != "" {
+		log.Printf("🔗 Explorer: %s", explorerURL)
+	}
 
 	// Wait for confirmation (optional, can be done async)
 	go m.waitForTransaction(ctx, signedTx.Hash())
@@ -152,6 +139,61 @@ func (m *Manager) SubmitUserOperation(ctx context.Context, userOpData map[string
 	return txHash, nil
 }
 
+// packHandleOps encodes handleOps calldata for either a v0.6 UserOperation
+// or a v0.7 PackedUserOperation, depending on the layout of the UserOp map
+func (m *Manager) packHandleOps(userOpData map[string]interface{}, beneficiary common.Address) ([]byte, error) {
+	if IsPackedUserOp(userOpData) {
+		parsedABI, err := abi.JSON(strings.NewReader(EntryPointV07HandleOpsABI))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ABI: %w", err)
+		}
+
+		userOp, err := parsePackedUserOperationFromMap(userOpData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse PackedUserOperation: %w", err)
+		}
+
+		log.Printf("📝 PackedUserOperation (v0.7) details:")
+		log.Printf("   Sender: %s", userOp.Sender.Hex())
+		log.Printf("   Nonce: %s", userOp.Nonce.String())
+		log.Printf("   CallData length: %d", len(userOp.CallData))
+		log.Printf("   Signature length: %d", len(userOp.Signature))
+
+		data, err := parsedABI.Pack("handleOps", []PackedUserOperation{*userOp}, beneficiary)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack handleOps: %w", err)
+		}
+		return data, nil
+	}
+
+	// Parse EntryPoint ABI
+	parsedABI, err := abi.JSON(strings.NewReader(EntryPointHandleOpsABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ABI: %w", err)
+	}
+
+	// Convert UserOperation from map to struct
+	userOp, err := m.parseUserOperationFromMap(userOpData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse UserOperation: %w", err)
+	}
+
+	// Log UserOperation details
+	log.Printf("📝 UserOperation details:")
+	log.Printf("   Sender: %s", userOp.Sender.Hex())
+	log.Printf("   Nonce: %s", userOp.Nonce.String())
+	log.Printf("   CallData length: %d", len(userOp.CallData))
+	log.Printf("   Signature length: %d", len(userOp.Signature))
+
+	// Pack handleOps call: handleOps(UserOperation[], address)
+	// go-ethereum ABI library requires actual struct slice for tuple[] types
+	data, err := parsedABI.Pack("handleOps", []UserOperation{*userOp}, beneficiary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack handleOps: %w", err)
+	}
+	return data, nil
+}
+
 // parseUserOperationFromMap converts map to UserOperation struct
 func (m *Manager) parseUserOperationFromMap(data map[string]interface{}) (*UserOperation, error) {
 	userOp := &UserOperation{}
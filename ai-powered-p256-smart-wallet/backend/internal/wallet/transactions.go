@@ -0,0 +1,309 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/models"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Transaction history page sizes
+const (
+	DefaultTransactionPageSize = 20
+	MaxTransactionPageSize     = 100
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// userOperationEventTopic is keccak256 of the EntryPoint UserOperationEvent signature (v0.6 and v0.7)
+var userOperationEventTopic = crypto.Keccak256Hash([]byte("UserOperationEvent(bytes32,address,address,uint256,bool,uint256,uint256)"))
+
+// Selectors of the calls built by the transfer flow
+var (
+	executeSelector  = common.FromHex("0xb61d27f6") // execute(address,uint256,bytes)
+	transferSelector = common.FromHex("0xa9059cbb") // transfer(address,uint256)
+)
+
+var (
+	executeArguments = func() abi.Arguments {
+		addressType, _ := abi.NewType("address", "", nil)
+		uint256Type, _ := abi.NewType("uint256", "", nil)
+		bytesType, _ := abi.NewType("bytes", "", nil)
+		return abi.Arguments{{Type: addressType}, {Type: uint256Type}, {Type: bytesType}}
+	}()
+	transferArguments = func() abi.Arguments {
+		addressType, _ := abi.NewType("address", "", nil)
+		uint256Type, _ := abi.NewType("uint256", "", nil)
+		return abi.Arguments{{Type: addressType}, {Type: uint256Type}}
+	}()
+	// Non-indexed fields of UserOperationEvent: nonce, success, actualGasCost, actualGasUsed
+	userOperationEventData = func() abi.Arguments {
+		uint256Type, _ := abi.NewType("uint256", "", nil)
+		boolType, _ := abi.NewType("bool", "", nil)
+		return abi.Arguments{{Type: uint256Type}, {Type: boolType}, {Type: uint256Type}, {Type: uint256Type}}
+	}()
+)
+
+// TransferDetails describes the transfer encoded in a UserOp's execute() callData
+type TransferDetails struct {
+	Recipient string
+	Amount    string // base units
+	Token     string // empty for native transfers
+}
+
+// DecodeTransferCallData extracts recipient/amount/token from execute() callData
+// Native transfers are execute(recipient, amount, ""); ERC-20 transfers are
+// execute(token, 0, transfer(recipient, amount)). Other calls return ok=false.
+func DecodeTransferCallData(callData []byte) (*TransferDetails, bool) {
+	if len(callData) < 4 || !bytes.Equal(callData[:4], executeSelector) {
+		return nil, false
+	}
+
+	values, err := executeArguments.Unpack(callData[4:])
+	if err != nil || len(values) != 3 {
+		return nil, false
+	}
+	dest := values[0].(common.Address)
+	value := values[1].(*big.Int)
+	inner := values[2].([]byte)
+
+	if len(inner) == 0 {
+		return &TransferDetails{Recipient: dest.Hex(), Amount: value.String()}, true
+	}
+
+	if len(inner) < 4 || !bytes.Equal(inner[:4], transferSelector) {
+		return nil, false
+	}
+	args, err := transferArguments.Unpack(inner[4:])
+	if err != nil || len(args) != 2 {
+		return nil, false
+	}
+	return &TransferDetails{
+		Recipient: args[0].(common.Address).Hex(),
+		Amount:    args[1].(*big.Int).String(),
+		Token:     dest.Hex(),
+	}, true
+}
+
+// RecordTransaction inserts a submitted UserOp into the transaction history as pending
+func (m *Manager) RecordTransaction(ctx context.Context, tx *models.Transaction) error {
+	if tx.ID == "" {
+		tx.ID = uuid.New().String()
+	}
+	if tx.Status == "" {
+		tx.Status = models.TxStatusPending
+	}
+	if tx.CreatedAt.IsZero() {
+		tx.CreatedAt = time.Now()
+	}
+
+	if err := m.db.WithContext(ctx).Create(tx).Error; err != nil {
+		return fmt.Errorf("failed to record transaction: %w", err)
+	}
+	return nil
+}
+
+// ListTransactions returns a page of userID's transactions, newest first
+// cursor is the opaque value returned as nextCursor by the previous page ("" for the first page)
+func (m *Manager) ListTransactions(ctx context.Context, userID string, limit int, cursor string) ([]models.Transaction, string, error) {
+	if limit <= 0 {
+		limit = DefaultTransactionPageSize
+	}
+	if limit > MaxTransactionPageSize {
+		limit = MaxTransactionPageSize
+	}
+
+	query := m.db.WithContext(ctx).Where("user_id = ?", userID)
+	if cursor != "" {
+		createdAt, id, err := decodePageCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query = query.Where("(created_at < ?) OR (created_at = ? AND id < ?)", createdAt, createdAt, id)
+	}
+
+	// Fetch one extra row to know whether there is a next page
+	var txs []models.Transaction
+	if err := query.Order("created_at DESC, id DESC").Limit(limit + 1).Find(&txs).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to list transactions: %w", err)
+	}
+
+	nextCursor := ""
+	if len(txs) > limit {
+		txs = txs[:limit]
+		last := txs[len(txs)-1]
+		nextCursor = encodePageCursor(last.CreatedAt, last.ID)
+	}
+	return txs, nextCursor, nil
+}
+
+// encodePageCursor encodes a (created_at, id) keyset position as base64url("<unix nanos>:<id>")
+func encodePageCursor(createdAt time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", createdAt.UnixNano(), id)))
+}
+
+func decodePageCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	return time.Unix(0, nanos), parts[1], nil
+}
+
+// GetUserOperationReceipt returns the UserOp receipt, or nil if it is not yet included
+// The bundler is asked when configured; otherwise the UserOperationEvent is read
+// from the handleOps transaction receipt.
+func (m *Manager) GetUserOperationReceipt(ctx context.Context, userOpHash, txHash string) (*UserOperationReceipt, error) {
+	if m.bundler != nil {
+		return m.bundler.GetUserOperationReceipt(ctx, userOpHash)
+	}
+	if txHash == "" {
+		return nil, nil
+	}
+
+	receipt, err := m.ethClient.TransactionReceipt(ctx, common.HexToHash(txHash))
+	if err != nil {
+		// Not yet mined (or unknown to the node); try again on the next poll
+		return nil, nil
+	}
+
+	result := &UserOperationReceipt{UserOpHash: userOpHash}
+	result.Receipt.TransactionHash = txHash
+	result.Receipt.BlockNumber = hexutil.EncodeBig(receipt.BlockNumber)
+	result.Receipt.Status = hexutil.EncodeUint64(receipt.Status)
+
+	target := common.HexToHash(userOpHash)
+	for _, l := range receipt.Logs {
+		if len(l.Topics) < 2 || l.Topics[0] != userOperationEventTopic || l.Topics[1] != target {
+			continue
+		}
+		values, err := userOperationEventData.Unpack(l.Data)
+		if err != nil || len(values) != 4 {
+			return nil, fmt.Errorf("failed to decode UserOperationEvent: %v", err)
+		}
+		result.Success = values[1].(bool)
+		result.ActualGasCost = hexutil.EncodeBig(values[2].(*big.Int))
+		result.ActualGasUsed = hexutil.EncodeBig(values[3].(*big.Int))
+		return result, nil
+	}
+
+	// handleOps was mined without an event for this UserOp (e.g. the whole bundle reverted)
+	result.Success = false
+	result.Reason = "UserOperationEvent not found in transaction"
+	return result, nil
+}
+
+// RefreshPendingTransactions updates pending transactions whose UserOp receipt is available
+// and applies the wallet key changes that succeeded, oldest first
+func (m *Manager) RefreshPendingTransactions(ctx context.Context) (int, error) {
+	var pending []models.Transaction
+	if err := m.db.WithContext(ctx).Where("status = ?", models.TxStatusPending).Order("created_at ASC").Find(&pending).Error; err != nil {
+		return 0, fmt.Errorf("failed to load pending transactions: %w", err)
+	}
+
+	updated := 0
+	for _, tx := range pending {
+		receipt, err := m.GetUserOperationReceipt(ctx, tx.UserOpHash, tx.TxHash)
+		if err != nil {
+			log.Printf("⚠️  Failed to get receipt for UserOp %s: %v", tx.UserOpHash, err)
+			continue
+		}
+		if receipt == nil {
+			continue
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status":       models.TxStatusConfirmed,
+			"confirmed_at": &now,
+		}
+		if receipt.Receipt.TransactionHash != "" {
+			updates["tx_hash"] = receipt.Receipt.TransactionHash
+		}
+		if gasUsed, err := hexutil.DecodeBig(receipt.ActualGasUsed); err == nil {
+			updates["gas_used"] = gasUsed.String()
+		}
+		if !receipt.Success {
+			updates["status"] = models.TxStatusFailed
+			reason := receipt.Reason
+			if reason == "" {
+				reason = "user operation reverted"
+			}
+			updates["error_message"] = reason
+		}
+
+		err = m.db.WithContext(ctx).Transaction(func(db *gorm.DB) error {
+			if err := db.Model(&models.Transaction{}).Where("id = ?", tx.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+			// A key change only reaches the wallet once the chain has accepted it
+			if columns := walletKeyColumns(&tx); columns != nil && receipt.Success {
+				return db.Model(&models.Wallet{}).Where("id = ?", tx.WalletID).Updates(columns).Error
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("⚠️  Failed to update transaction %s: %v", tx.ID, err)
+			continue
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// walletKeyColumns returns the wallet columns a key change transaction sets, or nil for
+// other transactions
+func walletKeyColumns(tx *models.Transaction) map[string]interface{} {
+	switch tx.Action {
+	case models.TxActionRotateKey:
+		return map[string]interface{}{"public_key_x": tx.PublicKeyX, "public_key_y": tx.PublicKeyY}
+	case models.TxActionSetRecoveryKey:
+		return map[string]interface{}{"recovery_public_key_x": tx.PublicKeyX, "recovery_public_key_y": tx.PublicKeyY}
+	}
+	return nil
+}
+
+// RunTransactionStatusPoller periodically resolves pending transactions until ctx is cancelled
+func RunTransactionStatusPoller(ctx context.Context, m *Manager, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			updated, err := m.RefreshPendingTransactions(ctx)
+			if err != nil {
+				log.Printf("⚠️  Failed to refresh pending transactions: %v", err)
+				continue
+			}
+			if updated > 0 {
+				log.Printf("🔄 Updated status of %d transactions", updated)
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package wallet
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestDecodeTransferCallDataNative(t *testing.T) {
+	details, ok := DecodeTransferCallData(hexToBytes(fixtureUserOp()["callData"]))
+	if !ok {
+		t.Fatal("expected native transfer callData to decode")
+	}
+	if details.Recipient != common.HexToAddress("0xdead").Hex() {
+		t.Fatalf("recipient = %s", details.Recipient)
+	}
+	if details.Amount != "10000000000000000" {
+		t.Fatalf("amount = %s, want 10000000000000000", details.Amount)
+	}
+	if details.Token != "" {
+		t.Fatalf("token = %s, want empty", details.Token)
+	}
+}
+
+func TestDecodeTransferCallDataERC20(t *testing.T) {
+	token := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	recipient := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	inner, err := transferArguments.Pack(recipient, big.NewInt(1500000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outer, err := executeArguments.Pack(token, big.NewInt(0), append(append([]byte{}, transferSelector...), inner...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	details, ok := DecodeTransferCallData(append(append([]byte{}, executeSelector...), outer...))
+	if !ok {
+		t.Fatal("expected ERC-20 transfer callData to decode")
+	}
+	if details.Token != token.Hex() || details.Recipient != recipient.Hex() || details.Amount != "1500000" {
+		t.Fatalf("unexpected details: %+v", details)
+	}
+}
+
+func TestDecodeTransferCallDataRejectsOtherCalls(t *testing.T) {
+	if _, ok := DecodeTransferCallData([]byte{0xde, 0xad, 0xbe, 0xef}); ok {
+		t.Fatal("unexpected decode of unknown selector")
+	}
+}
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	createdAt := time.Unix(1700000000, 123456789)
+	cursor := encodePageCursor(createdAt, "tx-id")
+
+	gotTime, gotID, err := decodePageCursor(cursor)
+	if err != nil {
+		t.Fatalf("decodePageCursor: %v", err)
+	}
+	if !gotTime.Equal(createdAt) || gotID != "tx-id" {
+		t.Fatalf("got (%v, %s), want (%v, tx-id)", gotTime, gotID, createdAt)
+	}
+
+	if _, _, err := decodePageCursor("not a cursor!"); err != ErrInvalidCursor {
+		t.Fatalf("err = %v, want ErrInvalidCursor", err)
+	}
+}
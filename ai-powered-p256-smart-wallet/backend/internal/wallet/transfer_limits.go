@@ -0,0 +1,232 @@
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Transfer limit kinds reported by TransferLimitError
+const (
+	TransferLimitMin   = "min"
+	TransferLimitMax   = "max"
+	TransferLimitDaily = "daily"
+)
+
+// ErrTransferLimit is wrapped by every TransferLimitError
+var ErrTransferLimit = errors.New("transfer limit exceeded")
+
+// TransferLimit bounds the transfers of one token, in base units. A nil bound is not enforced.
+type TransferLimit struct {
+	Min      *big.Int // smallest single transfer
+	Max      *big.Int // largest single transfer
+	Daily    *big.Int // total a user may transfer per UTC day
+	Symbol   string   // lets AI operation cards that name the asset be checked
+	Decimals uint8    // for rendering amounts (18 for the native coin)
+}
+
+// TransferLimits maps NativeToken or a lowercase ERC-20 address to its limit.
+// Tokens without an entry are not limited.
+type TransferLimits map[string]TransferLimit
+
+// transferLimitJSON is one TRANSFER_LIMITS entry; amounts are base-unit decimal strings
+// so they survive JSON without losing precision
+type transferLimitJSON struct {
+	Min      string `json:"min"`
+	Max      string `json:"max"`
+	Daily    string `json:"daily"`
+	Symbol   string `json:"symbol"`
+	Decimals *uint8 `json:"decimals"`
+}
+
+// TokenKey returns the TransferLimits key of token ("" is the native coin)
+func TokenKey(token string) string {
+	if token == "" || token == NativeToken {
+		return NativeToken
+	}
+	return strings.ToLower(token)
+}
+
+// TransferLimitsFromEnv reads TRANSFER_LIMITS: inline JSON or a path to a JSON file.
+// Unset means transfers are not limited.
+func TransferLimitsFromEnv() (TransferLimits, error) {
+	value := strings.TrimSpace(os.Getenv("TRANSFER_LIMITS"))
+	if value == "" {
+		return nil, nil
+	}
+	data := []byte(value)
+	if !strings.HasPrefix(value, "{") {
+		var err error
+		if data, err = os.ReadFile(value); err != nil {
+			return nil, fmt.Errorf("failed to read TRANSFER_LIMITS: %w", err)
+		}
+	}
+	return ParseTransferLimits(data)
+}
+
+// ParseTransferLimits parses a JSON object keyed by "native" or an ERC-20 address, e.g.
+// {"native":{"min":"1000000000000000","max":"5000000000000000000","daily":"20000000000000000000"}}
+func ParseTransferLimits(data []byte) (TransferLimits, error) {
+	var raw map[string]transferLimitJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid transfer limits: %w", err)
+	}
+
+	limits := make(TransferLimits, len(raw))
+	for token, entry := range raw {
+		if token != NativeToken && !common.IsHexAddress(token) {
+			return nil, fmt.Errorf("invalid transfer limits: %q is neither %q nor a token address", token, NativeToken)
+		}
+		limit := TransferLimit{Symbol: entry.Symbol, Decimals: 18}
+		if entry.Decimals != nil {
+			limit.Decimals = *entry.Decimals
+		}
+		for _, bound := range []struct {
+			name  string
+			value string
+			dst   **big.Int
+		}{
+			{TransferLimitMin, entry.Min, &limit.Min},
+			{TransferLimitMax, entry.Max, &limit.Max},
+			{TransferLimitDaily, entry.Daily, &limit.Daily},
+		} {
+			if bound.value == "" {
+				continue
+			}
+			amount, ok := new(big.Int).SetString(bound.value, 10)
+			if !ok || amount.Sign() <= 0 {
+				return nil, fmt.Errorf("invalid transfer limits: %s %s must be a positive base-unit integer", token, bound.name)
+			}
+			*bound.dst = amount
+		}
+		if limit.Min != nil && limit.Max != nil && limit.Min.Cmp(limit.Max) > 0 {
+			return nil, fmt.Errorf("invalid transfer limits: %s min is above max", token)
+		}
+		limits[TokenKey(token)] = limit
+	}
+	return limits, nil
+}
+
+// BySymbol returns the key and limit of the token whose symbol is symbol (case-insensitive)
+func (l TransferLimits) BySymbol(symbol string) (string, TransferLimit, bool) {
+	for token, limit := range l {
+		if limit.Symbol != "" && strings.EqualFold(limit.Symbol, symbol) {
+			return token, limit, true
+		}
+	}
+	return "", TransferLimit{}, false
+}
+
+// CheckTransfer checks a single transfer of amount against the token's min and max
+func (l TransferLimits) CheckTransfer(token string, amount *big.Int) error {
+	token = TokenKey(token)
+	limit, ok := l[token]
+	if !ok {
+		return nil
+	}
+	if limit.Min != nil && amount.Cmp(limit.Min) < 0 {
+		return &TransferLimitError{Token: token, Kind: TransferLimitMin, Amount: amount, Limit: limit.Min, decimals: limit.Decimals, symbol: limit.Symbol}
+	}
+	if limit.Max != nil && amount.Cmp(limit.Max) > 0 {
+		return &TransferLimitError{Token: token, Kind: TransferLimitMax, Amount: amount, Limit: limit.Max, decimals: limit.Decimals, symbol: limit.Symbol}
+	}
+	return nil
+}
+
+// CheckDaily checks that adding amounts (token key -> total) to what the user already
+// transferred today (used) stays within each token's daily cap
+func (l TransferLimits) CheckDaily(used, amounts map[string]*big.Int) error {
+	for token, amount := range amounts {
+		limit, ok := l[token]
+		if !ok || limit.Daily == nil {
+			continue
+		}
+		spent := used[token]
+		if spent == nil {
+			spent = new(big.Int)
+		}
+		if new(big.Int).Add(spent, amount).Cmp(limit.Daily) > 0 {
+			return &TransferLimitError{
+				Token:     token,
+				Kind:      TransferLimitDaily,
+				Amount:    amount,
+				Limit:     limit.Daily,
+				Remaining: Remaining(limit.Daily, spent),
+				decimals:  limit.Decimals,
+				symbol:    limit.Symbol,
+			}
+		}
+	}
+	return nil
+}
+
+// Remaining returns how much of limit is left after used, never below zero
+func Remaining(limit, used *big.Int) *big.Int {
+	remaining := new(big.Int).Sub(limit, used)
+	if remaining.Sign() < 0 {
+		return new(big.Int)
+	}
+	return remaining
+}
+
+// DailyWindow returns the UTC day containing now: daily caps count transfers since
+// start and reset at end
+func DailyWindow(now time.Time) (start, end time.Time) {
+	start = now.UTC().Truncate(24 * time.Hour)
+	return start, start.Add(24 * time.Hour)
+}
+
+// TransferLimitError reports a transfer outside a token's limits
+type TransferLimitError struct {
+	Token     string   // NativeToken or lowercase ERC-20 address
+	Kind      string   // TransferLimitMin, TransferLimitMax or TransferLimitDaily
+	Amount    *big.Int // the transfer (or, for the daily cap, the UserOp's total of the token)
+	Limit     *big.Int
+	Remaining *big.Int // daily cap only: what the user may still transfer today
+	decimals  uint8
+	symbol    string
+}
+
+// format renders a base-unit amount of the token for the error message
+func (e *TransferLimitError) format(amount *big.Int) string {
+	if e.symbol == "" {
+		return FormatUnits(amount, e.decimals)
+	}
+	return FormatUnits(amount, e.decimals) + " " + e.symbol
+}
+
+func (e *TransferLimitError) Error() string {
+	amount, limit := e.format(e.Amount), e.format(e.Limit)
+	switch e.Kind {
+	case TransferLimitMin:
+		return fmt.Sprintf("transfer of %s is below the minimum of %s", amount, limit)
+	case TransferLimitMax:
+		return fmt.Sprintf("transfer of %s exceeds the maximum of %s per transfer", amount, limit)
+	default:
+		return fmt.Sprintf("transfer of %s exceeds the daily limit of %s (%s remaining today)", amount, limit, e.format(e.Remaining))
+	}
+}
+
+func (e *TransferLimitError) Unwrap() error {
+	return ErrTransferLimit
+}
+
+// Details returns the client-visible fields of the error, with amounts as base-unit strings
+func (e *TransferLimitError) Details() map[string]interface{} {
+	details := map[string]interface{}{
+		"token":       e.Token,
+		"limit":       e.Kind,
+		"amount":      e.Amount.String(),
+		"limitAmount": e.Limit.String(),
+	}
+	if e.Remaining != nil {
+		details["remaining"] = e.Remaining.String()
+	}
+	return details
+}
@@ -0,0 +1,195 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/database/dbtest"
+	"ai-wallet-backend/internal/models"
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+const limitTestToken = "0x5555555555555555555555555555555555555555"
+
+// testTransferLimits: native 0.01-5 HSK per transfer, 10 HSK per day; token 100 units per day
+func testTransferLimits(t *testing.T) TransferLimits {
+	t.Helper()
+	limits, err := ParseTransferLimits([]byte(`{
+		"native": {"min": "10000000000000000", "max": "5000000000000000000", "daily": "10000000000000000000", "symbol": "HSK"},
+		"0x5555555555555555555555555555555555555555": {"daily": "100", "symbol": "USDT", "decimals": 6}
+	}`))
+	if err != nil {
+		t.Fatalf("ParseTransferLimits: %v", err)
+	}
+	return limits
+}
+
+func TestParseTransferLimits(t *testing.T) {
+	limits := testTransferLimits(t)
+	native := limits[NativeToken]
+	if native.Min.String() != "10000000000000000" || native.Max.Cmp(ether("5")) != 0 || native.Daily.Cmp(ether("10")) != 0 || native.Decimals != 18 {
+		t.Fatalf("native limit = %+v", native)
+	}
+	token, limit, ok := limits.BySymbol("usdt")
+	if !ok || token != limitTestToken || limit.Decimals != 6 || limit.Min != nil || limit.Max != nil {
+		t.Fatalf("BySymbol(usdt) = %s, %+v, %v", token, limit, ok)
+	}
+
+	for name, config := range map[string]string{
+		"not JSON":        `native`,
+		"unknown key":     `{"hsk": {"max": "1"}}`,
+		"decimal amount":  `{"native": {"max": "1.5"}}`,
+		"negative amount": `{"native": {"daily": "-1"}}`,
+		"min above max":   `{"native": {"min": "2", "max": "1"}}`,
+	} {
+		if _, err := ParseTransferLimits([]byte(config)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestTransferLimitsCheckTransfer(t *testing.T) {
+	limits := testTransferLimits(t)
+
+	var limitErr *TransferLimitError
+	if err := limits.CheckTransfer("", big.NewInt(1)); !errors.As(err, &limitErr) || limitErr.Kind != TransferLimitMin {
+		t.Fatalf("below min: %v", err)
+	}
+	err := limits.CheckTransfer("", ether("6"))
+	if !errors.As(err, &limitErr) || limitErr.Kind != TransferLimitMax || !errors.Is(err, ErrTransferLimit) {
+		t.Fatalf("above max: %v", err)
+	}
+	if want := "transfer of 6 HSK exceeds the maximum of 5 HSK per transfer"; err.Error() != want {
+		t.Fatalf("message = %q, want %q", err.Error(), want)
+	}
+	if err := limits.CheckTransfer(NativeToken, ether("5")); err != nil {
+		t.Fatalf("at max: %v", err)
+	}
+	// Tokens without a min/max, and unknown tokens, are not bounded per transfer
+	if err := limits.CheckTransfer(limitTestToken, big.NewInt(1000)); err != nil {
+		t.Fatalf("token without max: %v", err)
+	}
+	if err := limits.CheckTransfer("0x6666666666666666666666666666666666666666", ether("100")); err != nil {
+		t.Fatalf("unlimited token: %v", err)
+	}
+}
+
+// testTransferUsage runs the cumulative cap behaviour both stores share
+func testTransferUsage(t *testing.T, store TransferUsageStore) {
+	ctx := context.Background()
+	limits := testTransferLimits(t)
+	native := func(n string) map[string]*big.Int { return map[string]*big.Int{NativeToken: ether(n)} }
+
+	// 4 + 4 HSK fit under the 10 HSK cap, another 4 does not
+	if err := store.Reserve(ctx, "user-1", "0xop1", native("4"), limits, time.Minute); err != nil {
+		t.Fatalf("Reserve op1: %v", err)
+	}
+	if err := store.Reserve(ctx, "user-1", "0xop2", native("4"), limits, time.Minute); err != nil {
+		t.Fatalf("Reserve op2: %v", err)
+	}
+	err := store.Reserve(ctx, "user-1", "0xop3", native("4"), limits, time.Minute)
+	var limitErr *TransferLimitError
+	if !errors.As(err, &limitErr) || limitErr.Kind != TransferLimitDaily || limitErr.Remaining.Cmp(ether("2")) != 0 {
+		t.Fatalf("Reserve over the cap = %v, want daily limit with 2 HSK remaining", err)
+	}
+	// The cap is per user
+	if err := store.Reserve(ctx, "user-2", "0xop4", native("9"), limits, time.Minute); err != nil {
+		t.Fatalf("Reserve for another user: %v", err)
+	}
+
+	// A submitted UserOp keeps counting; a released one frees its allowance
+	if err := store.Confirm(ctx, "0xop1"); err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	if err := store.Release(ctx, "0xop2"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	used, err := store.UsedToday(ctx, "user-1")
+	if err != nil || used[NativeToken].Cmp(ether("4")) != 0 {
+		t.Fatalf("UsedToday = %v, %v; want 4 HSK", used, err)
+	}
+	if err := store.Reserve(ctx, "user-1", "0xop3", native("6"), limits, time.Minute); err != nil {
+		t.Fatalf("Reserve up to the cap: %v", err)
+	}
+
+	// Tokens are capped separately; unlimited tokens are recorded but never rejected
+	mixed := map[string]*big.Int{limitTestToken: big.NewInt(100), "0x6666666666666666666666666666666666666666": big.NewInt(1)}
+	if err := store.Reserve(ctx, "user-1", "0xop5", mixed, limits, time.Minute); err != nil {
+		t.Fatalf("Reserve token: %v", err)
+	}
+	if err := store.Reserve(ctx, "user-1", "0xop6", map[string]*big.Int{limitTestToken: big.NewInt(1)}, limits, time.Minute); !errors.Is(err, ErrTransferLimit) {
+		t.Fatalf("Reserve token over the cap = %v", err)
+	}
+}
+
+// testTransferUsageExpiry checks a prepared UserOp that expires unsigned stops counting
+func testTransferUsageExpiry(t *testing.T, store TransferUsageStore) {
+	ctx := context.Background()
+	limits := testTransferLimits(t)
+
+	if err := store.Reserve(ctx, "user-1", "0xabandoned", map[string]*big.Int{NativeToken: ether("10")}, limits, -time.Second); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if err := store.Reserve(ctx, "user-1", "0xnext", map[string]*big.Int{NativeToken: ether("10")}, limits, time.Minute); err != nil {
+		t.Fatalf("Reserve after expiry: %v", err)
+	}
+	deleted, err := store.DeleteExpired(ctx)
+	if err != nil || deleted != 1 {
+		t.Fatalf("DeleteExpired = %d, %v; want 1", deleted, err)
+	}
+}
+
+func TestMemoryTransferUsageStore(t *testing.T) {
+	testTransferUsage(t, NewMemoryTransferUsageStore())
+	testTransferUsageExpiry(t, NewMemoryTransferUsageStore())
+}
+
+func TestMemoryTransferUsageConcurrentReserves(t *testing.T) {
+	store := NewMemoryTransferUsageStore()
+	limits := testTransferLimits(t)
+
+	// Ten concurrent 4 HSK prepares: only two fit under the 10 HSK cap
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	accepted := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := store.Reserve(context.Background(), "user-1", "0xop"+string(rune('a'+i)), map[string]*big.Int{NativeToken: ether("4")}, limits, time.Minute)
+			if err == nil {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if accepted != 2 {
+		t.Fatalf("accepted %d concurrent reserves, want 2", accepted)
+	}
+}
+
+func TestGormTransferUsageStore(t *testing.T) {
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&models.TransferUsage{}); err != nil {
+		t.Fatal(err)
+	}
+	testTransferUsage(t, NewGormTransferUsageStore(db))
+
+	db = dbtest.Open(t)
+	if err := db.AutoMigrate(&models.TransferUsage{}); err != nil {
+		t.Fatal(err)
+	}
+	testTransferUsageExpiry(t, NewGormTransferUsageStore(db))
+}
+
+func TestDailyWindow(t *testing.T) {
+	now := time.Date(2026, 3, 14, 15, 9, 26, 0, time.FixedZone("UTC+8", 8*60*60))
+	start, end := DailyWindow(now)
+	if !start.Equal(time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("DailyWindow = %s, %s", start, end)
+	}
+}
@@ -0,0 +1,255 @@
+package wallet
+
+import (
+	"ai-wallet-backend/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// transferUsageRetention is how long submitted usage is kept; daily caps only read today's
+const transferUsageRetention = 48 * time.Hour
+
+// TransferUsageStore tracks how much of each token a user's UserOps transfer, so daily
+// transfer caps hold across requests. Usage is reserved when a UserOp is prepared, kept
+// once it is submitted, and stops counting when the prepared UserOp expires unsigned.
+type TransferUsageStore interface {
+	// Reserve records amounts (token key -> total) for userOpHash until ttl passes, unless
+	// they would take userID past a daily cap of limits: then it returns a *TransferLimitError
+	// and records nothing
+	Reserve(ctx context.Context, userID, userOpHash string, amounts map[string]*big.Int, limits TransferLimits, ttl time.Duration) error
+	// Confirm keeps the usage of a submitted UserOp for the rest of the day
+	Confirm(ctx context.Context, userOpHash string) error
+	// Release drops the usage of a UserOp that will not be submitted
+	Release(ctx context.Context, userOpHash string) error
+	// UsedToday returns what userID has transferred (or has prepared) today, by token key
+	UsedToday(ctx context.Context, userID string) (map[string]*big.Int, error)
+	// DeleteExpired removes expired reservations and old usage and returns how many were deleted
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// sumUsage totals the records that still count towards today's caps, by token key
+func sumUsage(records []models.TransferUsage, since time.Time) map[string]*big.Int {
+	used := make(map[string]*big.Int)
+	for _, record := range records {
+		if record.CreatedAt.Before(since) || !record.Counts() {
+			continue
+		}
+		amount, ok := new(big.Int).SetString(record.Amount, 10)
+		if !ok {
+			continue
+		}
+		if used[record.Token] == nil {
+			used[record.Token] = new(big.Int)
+		}
+		used[record.Token].Add(used[record.Token], amount)
+	}
+	return used
+}
+
+// usageRecords builds the records reserving amounts for userOpHash
+func usageRecords(userID, userOpHash string, amounts map[string]*big.Int, now time.Time, ttl time.Duration) []models.TransferUsage {
+	expiresAt := now.Add(ttl)
+	records := make([]models.TransferUsage, 0, len(amounts))
+	for token, amount := range amounts {
+		records = append(records, models.TransferUsage{
+			UserOpHash: userOpHash,
+			Token:      TokenKey(token),
+			UserID:     userID,
+			Amount:     amount.String(),
+			ExpiresAt:  &expiresAt,
+			CreatedAt:  now,
+		})
+	}
+	return records
+}
+
+// GormTransferUsageStore stores transfer usage in Postgres, so caps survive restarts and
+// hold across replicas
+type GormTransferUsageStore struct {
+	db *gorm.DB
+}
+
+// NewGormTransferUsageStore creates a Postgres-backed transfer usage store
+func NewGormTransferUsageStore(db *gorm.DB) *GormTransferUsageStore {
+	return &GormTransferUsageStore{db: db}
+}
+
+// Reserve checks and records usage in one transaction. A per-user advisory lock
+// serialises concurrent prepares, so two of them cannot both fit under the cap.
+func (s *GormTransferUsageStore) Reserve(ctx context.Context, userID, userOpHash string, amounts map[string]*big.Int, limits TransferLimits, ttl time.Duration) error {
+	if len(amounts) == 0 {
+		return nil
+	}
+	now := time.Now()
+	since, _ := DailyWindow(now)
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "transfer_usage:"+userID).Error; err != nil {
+			return fmt.Errorf("failed to lock transfer usage: %w", err)
+		}
+		var records []models.TransferUsage
+		if err := tx.Where("user_id = ? AND created_at >= ?", userID, since).Find(&records).Error; err != nil {
+			return fmt.Errorf("failed to load transfer usage: %w", err)
+		}
+		if err := limits.CheckDaily(sumUsage(records, since), amounts); err != nil {
+			return err
+		}
+		if err := tx.Create(usageRecords(userID, userOpHash, amounts, now, ttl)).Error; err != nil {
+			return fmt.Errorf("failed to record transfer usage: %w", err)
+		}
+		return nil
+	})
+}
+
+// Confirm clears the expiry of a submitted UserOp's usage
+func (s *GormTransferUsageStore) Confirm(ctx context.Context, userOpHash string) error {
+	return s.db.WithContext(ctx).Model(&models.TransferUsage{}).
+		Where("user_op_hash = ?", userOpHash).
+		Update("expires_at", nil).Error
+}
+
+// Release deletes a UserOp's usage
+func (s *GormTransferUsageStore) Release(ctx context.Context, userOpHash string) error {
+	return s.db.WithContext(ctx).Where("user_op_hash = ?", userOpHash).Delete(&models.TransferUsage{}).Error
+}
+
+// UsedToday sums the user's usage since the start of the UTC day
+func (s *GormTransferUsageStore) UsedToday(ctx context.Context, userID string) (map[string]*big.Int, error) {
+	since, _ := DailyWindow(time.Now())
+	var records []models.TransferUsage
+	if err := s.db.WithContext(ctx).Where("user_id = ? AND created_at >= ?", userID, since).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return sumUsage(records, since), nil
+}
+
+// DeleteExpired removes expired reservations and usage older than transferUsageRetention
+func (s *GormTransferUsageStore) DeleteExpired(ctx context.Context) (int64, error) {
+	now := time.Now()
+	result := s.db.WithContext(ctx).
+		Where("expires_at < ? OR created_at < ?", now, now.Add(-transferUsageRetention)).
+		Delete(&models.TransferUsage{})
+	return result.RowsAffected, result.Error
+}
+
+// MemoryTransferUsageStore keeps transfer usage in process memory (tests and local development)
+type MemoryTransferUsageStore struct {
+	mu      sync.Mutex
+	records map[string][]models.TransferUsage // UserOp hash -> usage per token
+}
+
+// NewMemoryTransferUsageStore creates an in-memory transfer usage store
+func NewMemoryTransferUsageStore() *MemoryTransferUsageStore {
+	return &MemoryTransferUsageStore{records: make(map[string][]models.TransferUsage)}
+}
+
+// userRecords returns all records of userID; the caller holds s.mu
+func (s *MemoryTransferUsageStore) userRecords(userID string) []models.TransferUsage {
+	var records []models.TransferUsage
+	for _, usage := range s.records {
+		for _, record := range usage {
+			if record.UserID == userID {
+				records = append(records, record)
+			}
+		}
+	}
+	return records
+}
+
+// Reserve checks and records usage
+func (s *MemoryTransferUsageStore) Reserve(ctx context.Context, userID, userOpHash string, amounts map[string]*big.Int, limits TransferLimits, ttl time.Duration) error {
+	if len(amounts) == 0 {
+		return nil
+	}
+	now := time.Now()
+	since, _ := DailyWindow(now)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := limits.CheckDaily(sumUsage(s.userRecords(userID), since), amounts); err != nil {
+		return err
+	}
+	s.records[userOpHash] = usageRecords(userID, userOpHash, amounts, now, ttl)
+	return nil
+}
+
+// Confirm clears the expiry of a submitted UserOp's usage
+func (s *MemoryTransferUsageStore) Confirm(ctx context.Context, userOpHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.records[userOpHash] {
+		s.records[userOpHash][i].ExpiresAt = nil
+	}
+	return nil
+}
+
+// Release deletes a UserOp's usage
+func (s *MemoryTransferUsageStore) Release(ctx context.Context, userOpHash string) error {
+	s.mu.Lock()
+	delete(s.records, userOpHash)
+	s.mu.Unlock()
+	return nil
+}
+
+// UsedToday sums the user's usage since the start of the UTC day
+func (s *MemoryTransferUsageStore) UsedToday(ctx context.Context, userID string) (map[string]*big.Int, error) {
+	since, _ := DailyWindow(time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sumUsage(s.userRecords(userID), since), nil
+}
+
+// DeleteExpired removes expired reservations and usage older than transferUsageRetention
+func (s *MemoryTransferUsageStore) DeleteExpired(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().Add(-transferUsageRetention)
+	var deleted int64
+	for userOpHash, usage := range s.records {
+		kept := usage[:0]
+		for _, record := range usage {
+			if !record.Counts() || record.CreatedAt.Before(cutoff) {
+				deleted++
+				continue
+			}
+			kept = append(kept, record)
+		}
+		if len(kept) == 0 {
+			delete(s.records, userOpHash)
+		} else {
+			s.records[userOpHash] = kept
+		}
+	}
+	return deleted, nil
+}
+
+// RunTransferUsageSweeper periodically deletes expired transfer usage until ctx is cancelled
+func RunTransferUsageSweeper(ctx context.Context, store TransferUsageStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := store.DeleteExpired(ctx)
+			if err != nil {
+				log.Printf("⚠️  Failed to sweep expired transfer usage: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("🧹 Swept %d expired transfer usage records", deleted)
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package wallet
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// userOpV06Arguments matches the abi.encode call in EntryPoint v0.6 UserOperationLib.pack:
+// (address,uint256,bytes32,bytes32,uint256,uint256,uint256,uint256,uint256,bytes32)
+var userOpV06Arguments = func() abi.Arguments {
+	addressType, _ := abi.NewType("address", "", nil)
+	uint256Type, _ := abi.NewType("uint256", "", nil)
+	bytes32Type, _ := abi.NewType("bytes32", "", nil)
+
+	return abi.Arguments{
+		{Type: addressType}, // sender
+		{Type: uint256Type}, // nonce
+		{Type: bytes32Type}, // keccak256(initCode)
+		{Type: bytes32Type}, // keccak256(callData)
+		{Type: uint256Type}, // callGasLimit
+		{Type: uint256Type}, // verificationGasLimit
+		{Type: uint256Type}, // preVerificationGas
+		{Type: uint256Type}, // maxFeePerGas
+		{Type: uint256Type}, // maxPriorityFeePerGas
+		{Type: bytes32Type}, // keccak256(paymasterAndData)
+	}
+}()
+
+// userOpHashArguments matches EntryPoint.getUserOpHash: abi.encode(bytes32,address,uint256)
+var userOpHashArguments = func() abi.Arguments {
+	addressType, _ := abi.NewType("address", "", nil)
+	uint256Type, _ := abi.NewType("uint256", "", nil)
+	bytes32Type, _ := abi.NewType("bytes32", "", nil)
+
+	return abi.Arguments{
+		{Type: bytes32Type},
+		{Type: addressType},
+		{Type: uint256Type},
+	}
+}()
+
+// UserOpHashV06 computes the EntryPoint v0.6 getUserOpHash for a UserOperation
+// userOpHash = keccak256(abi.encode(keccak256(abi.encode(fields...)), entryPoint, chainId))
+func UserOpHashV06(userOp *UserOperation, entryPoint common.Address, chainID *big.Int) (common.Hash, error) {
+	packed, err := userOpV06Arguments.Pack(
+		userOp.Sender,
+		userOp.Nonce,
+		crypto.Keccak256Hash(userOp.InitCode),
+		crypto.Keccak256Hash(userOp.CallData),
+		userOp.CallGasLimit,
+		userOp.VerificationGasLimit,
+		userOp.PreVerificationGas,
+		userOp.MaxFeePerGas,
+		userOp.MaxPriorityFeePerGas,
+		crypto.Keccak256Hash(userOp.PaymasterAndData),
+	)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to pack user operation: %w", err)
+	}
+
+	encoded, err := userOpHashArguments.Pack(crypto.Keccak256Hash(packed), entryPoint, chainID)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to pack user operation hash: %w", err)
+	}
+
+	return crypto.Keccak256Hash(encoded), nil
+}
+
+// GetUserOpHashV06 computes the EntryPoint v0.6 getUserOpHash for a UserOp map
+func GetUserOpHashV06(userOp map[string]interface{}, entryPoint common.Address, chainID *big.Int) (common.Hash, error) {
+	senderStr, ok := userOp["sender"].(string)
+	if !ok {
+		return common.Hash{}, fmt.Errorf("invalid sender")
+	}
+
+	return UserOpHashV06(&UserOperation{
+		Sender:               common.HexToAddress(senderStr),
+		Nonce:                hexToBigInt(userOp["nonce"]),
+		InitCode:             hexToBytes(userOp["initCode"]),
+		CallData:             hexToBytes(userOp["callData"]),
+		CallGasLimit:         hexToBigInt(userOp["callGasLimit"]),
+		VerificationGasLimit: hexToBigInt(userOp["verificationGasLimit"]),
+		PreVerificationGas:   hexToBigInt(userOp["preVerificationGas"]),
+		MaxFeePerGas:         hexToBigInt(userOp["maxFeePerGas"]),
+		MaxPriorityFeePerGas: hexToBigInt(userOp["maxPriorityFeePerGas"]),
+		PaymasterAndData:     hexToBytes(userOp["paymasterAndData"]),
+	}, entryPoint, chainID)
+}
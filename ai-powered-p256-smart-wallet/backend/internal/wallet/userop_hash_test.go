@@ -0,0 +1,196 @@
+package wallet
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// fixtureUserOp mirrors the map produced by buildTransferUserOpP256 for a
+// deployed wallet sending 0.01 HSK
+func fixtureUserOp() map[string]interface{} {
+	return map[string]interface{}{
+		"sender":               "0x6a1E4D4e5cF5b3b2f3F0c53C2B8c8D2Ad5e8B0a1",
+		"nonce":                "0x3",
+		"initCode":             "0x",
+		"callData":             "0xb61d27f6000000000000000000000000000000000000000000000000000000000000dead000000000000000000000000000000000000000000000000002386f26fc1000000000000000000000000000000000000000000000000000000000000000000600000000000000000000000000000000000000000000000000000000000000000",
+		"callGasLimit":         "0x186a0",
+		"verificationGasLimit": "0x30d40",
+		"preVerificationGas":   "0x186a0",
+		"maxFeePerGas":         "0x3b9aca00",
+		"maxPriorityFeePerGas": "0x3b9aca00",
+		"paymasterAndData":     "0x",
+		"signature":            "0x",
+	}
+}
+
+// word left-pads b to a 32-byte ABI word
+func word(b []byte) []byte {
+	return common.LeftPadBytes(b, 32)
+}
+
+func TestGetUserOpHashV06MatchesABIEncode(t *testing.T) {
+	op := fixtureUserOp()
+	entryPoint := common.HexToAddress(DefaultEntryPointAddress)
+	chainID := big.NewInt(133)
+
+	got, err := GetUserOpHashV06(op, entryPoint, chainID)
+	if err != nil {
+		t.Fatalf("GetUserOpHashV06: %v", err)
+	}
+
+	// Build the abi.encode preimage word by word, exactly as the EntryPoint does
+	var inner []byte
+	inner = append(inner, word(common.HexToAddress(op["sender"].(string)).Bytes())...)
+	inner = append(inner, word(hexToBigInt(op["nonce"]).Bytes())...)
+	inner = append(inner, crypto.Keccak256(hexToBytes(op["initCode"]))...)
+	inner = append(inner, crypto.Keccak256(hexToBytes(op["callData"]))...)
+	for _, field := range []string{"callGasLimit", "verificationGasLimit", "preVerificationGas", "maxFeePerGas", "maxPriorityFeePerGas"} {
+		inner = append(inner, word(hexToBigInt(op[field]).Bytes())...)
+	}
+	inner = append(inner, crypto.Keccak256(hexToBytes(op["paymasterAndData"]))...)
+
+	var outer []byte
+	outer = append(outer, crypto.Keccak256(inner)...)
+	outer = append(outer, word(entryPoint.Bytes())...)
+	outer = append(outer, word(chainID.Bytes())...)
+	want := crypto.Keccak256Hash(outer)
+
+	if got != want {
+		t.Fatalf("hash mismatch: got %s, want %s", got.Hex(), want.Hex())
+	}
+}
+
+const entryPointV06GetUserOpHashABI = `[{"inputs":[{"components":[
+	{"name":"sender","type":"address"},
+	{"name":"nonce","type":"uint256"},
+	{"name":"initCode","type":"bytes"},
+	{"name":"callData","type":"bytes"},
+	{"name":"callGasLimit","type":"uint256"},
+	{"name":"verificationGasLimit","type":"uint256"},
+	{"name":"preVerificationGas","type":"uint256"},
+	{"name":"maxFeePerGas","type":"uint256"},
+	{"name":"maxPriorityFeePerGas","type":"uint256"},
+	{"name":"paymasterAndData","type":"bytes"},
+	{"name":"signature","type":"bytes"}
+],"name":"userOp","type":"tuple"}],"name":"getUserOpHash","outputs":[{"name":"","type":"bytes32"}],"stateMutability":"view","type":"function"}]`
+
+// TestGetUserOpHashV06MatchesEntryPoint compares against getUserOpHash on the
+// canonical EntryPoint v0.6 deployment, reached through TEST_ENTRYPOINT_V06_RPC_URL
+// (any chain where 0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789 is deployed, e.g.
+// Sepolia or HashKey testnet). Skipped when the variable is not set.
+func TestGetUserOpHashV06MatchesEntryPoint(t *testing.T) {
+	url := os.Getenv("TEST_ENTRYPOINT_V06_RPC_URL")
+	if url == "" {
+		t.Skip("TEST_ENTRYPOINT_V06_RPC_URL not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client, err := ethclient.DialContext(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := abi.JSON(strings.NewReader(entryPointV06GetUserOpHashABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entryPoint := common.HexToAddress(DefaultEntryPointAddress)
+
+	deploy := fixtureUserOp()
+	deploy["nonce"] = "0x0"
+	deploy["initCode"] = "0x9406Cc6185a346906296840746125a0E449764545fbfb9cf000000000000000000000000000000000000000000000000000000000000002a"
+	deploy["paymasterAndData"] = "0x1111111111111111111111111111111111111111deadbeef"
+	for name, op := range map[string]map[string]interface{}{"transfer": fixtureUserOp(), "deploy with paymaster": deploy} {
+		input, err := parsed.Pack("getUserOpHash", UserOperation{
+			Sender:               common.HexToAddress(op["sender"].(string)),
+			Nonce:                hexToBigInt(op["nonce"]),
+			InitCode:             hexToBytes(op["initCode"]),
+			CallData:             hexToBytes(op["callData"]),
+			CallGasLimit:         hexToBigInt(op["callGasLimit"]),
+			VerificationGasLimit: hexToBigInt(op["verificationGasLimit"]),
+			PreVerificationGas:   hexToBigInt(op["preVerificationGas"]),
+			MaxFeePerGas:         hexToBigInt(op["maxFeePerGas"]),
+			MaxPriorityFeePerGas: hexToBigInt(op["maxPriorityFeePerGas"]),
+			PaymasterAndData:     hexToBytes(op["paymasterAndData"]),
+			Signature:            hexToBytes(op["signature"]),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret, err := client.CallContract(ctx, ethereum.CallMsg{To: &entryPoint, Data: input}, nil)
+		if err != nil {
+			t.Fatalf("%s: getUserOpHash: %v", name, err)
+		}
+
+		got, err := GetUserOpHashV06(op, entryPoint, chainID)
+		if err != nil {
+			t.Fatalf("%s: GetUserOpHashV06: %v", name, err)
+		}
+		if want := common.BytesToHash(ret); got != want {
+			t.Errorf("%s: hash = %s, EntryPoint.getUserOpHash = %s (chain %s)", name, got.Hex(), want.Hex(), chainID)
+		}
+	}
+}
+
+func TestGetUserOpHashV06SenderIsPadded(t *testing.T) {
+	// The legacy implementation appended the raw 20-byte sender, which can never
+	// match the EntryPoint; make sure the hash depends on the padded encoding
+	op := fixtureUserOp()
+	entryPoint := common.HexToAddress(DefaultEntryPointAddress)
+	chainID := big.NewInt(133)
+
+	got, err := GetUserOpHashV06(op, entryPoint, chainID)
+	if err != nil {
+		t.Fatalf("GetUserOpHashV06: %v", err)
+	}
+
+	var legacy []byte
+	legacy = append(legacy, common.HexToAddress(op["sender"].(string)).Bytes()...)
+	legacy = append(legacy, common.BigToHash(hexToBigInt(op["nonce"])).Bytes()...)
+	legacy = append(legacy, crypto.Keccak256(hexToBytes(op["initCode"]))...)
+	legacy = append(legacy, crypto.Keccak256(hexToBytes(op["callData"]))...)
+	for _, field := range []string{"callGasLimit", "verificationGasLimit", "preVerificationGas", "maxFeePerGas", "maxPriorityFeePerGas"} {
+		legacy = append(legacy, common.BigToHash(hexToBigInt(op[field])).Bytes()...)
+	}
+	legacy = append(legacy, crypto.Keccak256(hexToBytes(op["paymasterAndData"]))...)
+	legacyHash := crypto.Keccak256Hash(append(append(crypto.Keccak256(legacy), entryPoint.Bytes()...), common.BigToHash(chainID).Bytes()...))
+
+	if got == legacyHash {
+		t.Fatal("hash unexpectedly matches the unpadded legacy preimage")
+	}
+}
+
+func TestGetUserOpHashV07PacksGasFields(t *testing.T) {
+	op := BuildUserOpV07(fixtureUserOp())
+
+	accountGasLimits := hexToBytes(op["accountGasLimits"])
+	if len(accountGasLimits) != 32 {
+		t.Fatalf("accountGasLimits length = %d, want 32", len(accountGasLimits))
+	}
+	if v := new(big.Int).SetBytes(accountGasLimits[:16]); v.Cmp(big.NewInt(0x30d40)) != 0 {
+		t.Fatalf("verificationGasLimit = %s, want %d", v, 0x30d40)
+	}
+	if v := new(big.Int).SetBytes(accountGasLimits[16:]); v.Cmp(big.NewInt(0x186a0)) != 0 {
+		t.Fatalf("callGasLimit = %s, want %d", v, 0x186a0)
+	}
+
+	if _, err := GetUserOpHashV07(op, common.HexToAddress(EntryPointV07Address), big.NewInt(133)); err != nil {
+		t.Fatalf("GetUserOpHashV07: %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package wallet
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Supported EntryPoint versions
+const (
+	EntryPointV06 = "0.6"
+	EntryPointV07 = "0.7"
+
+	// Canonical EntryPoint v0.7 deployment (same address on every chain)
+	EntryPointV07Address = "0x0000000071727De22E5E9d8BAf0edAc6f37da032"
+)
+
+// EntryPoint v0.7 ABI for handleOps(PackedUserOperation[], address)
+const EntryPointV07HandleOpsABI = `[
+	{
+		"inputs": [
+			{
+				"components": [
+					{"internalType": "address", "name": "sender", "type": "address"},
+					{"internalType": "uint256", "name": "nonce", "type": "uint256"},
+					{"internalType": "bytes", "name": "initCode", "type": "bytes"},
+					{"internalType": "bytes", "name": "callData", "type": "bytes"},
+					{"internalType": "bytes32", "name": "accountGasLimits", "type": "bytes32"},
+					{"internalType": "uint256", "name": "preVerificationGas", "type": "uint256"},
+					{"internalType": "bytes32", "name": "gasFees", "type": "bytes32"},
+					{"internalType": "bytes", "name": "paymasterAndData", "type": "bytes"},
+					{"internalType": "bytes", "name": "signature", "type": "bytes"}
+				],
+				"internalType": "struct PackedUserOperation[]",
+				"name": "ops",
+				"type": "tuple[]"
+			},
+			{"internalType": "address payable", "name": "beneficiary", "type": "address"}
+		],
+		"name": "handleOps",
+		"outputs": [],
+		"stateMutability": "nonpayable",
+		"type": "function"
+	}
+]`
+
+// PackedUserOperation represents an ERC-4337 v0.7 user operation
+// Gas limits and fees are packed into two bytes32 words:
+// - accountGasLimits = verificationGasLimit (16 bytes) || callGasLimit (16 bytes)
+// - gasFees          = maxPriorityFeePerGas (16 bytes) || maxFeePerGas (16 bytes)
+type PackedUserOperation struct {
+	Sender             common.Address `json:"sender"`
+	Nonce              *big.Int       `json:"nonce"`
+	InitCode           []byte         `json:"initCode"`
+	CallData           []byte         `json:"callData"`
+	AccountGasLimits   [32]byte       `json:"accountGasLimits"`
+	PreVerificationGas *big.Int       `json:"preVerificationGas"`
+	GasFees            [32]byte       `json:"gasFees"`
+	PaymasterAndData   []byte         `json:"paymasterAndData"`
+	Signature          []byte         `json:"signature"`
+}
+
+// NormalizeEntryPointVersion maps a config value to a supported EntryPoint version
+// Anything other than v0.7 falls back to v0.6 so existing wallets keep working
+func NormalizeEntryPointVersion(version string) string {
+	switch strings.TrimPrefix(strings.TrimSpace(version), "v") {
+	case "0.7", "07", "7":
+		return EntryPointV07
+	default:
+		return EntryPointV06
+	}
+}
+
+// IsPackedUserOp reports whether a UserOp map uses the v0.7 packed layout
+func IsPackedUserOp(userOp map[string]interface{}) bool {
+	_, ok := userOp["accountGasLimits"]
+	return ok
+}
+
+// PackUint128Pair packs two uint128 values into a single bytes32 (high || low)
+func PackUint128Pair(high, low *big.Int) [32]byte {
+	var packed [32]byte
+	copy(packed[:16], common.LeftPadBytes(high.Bytes(), 16))
+	copy(packed[16:], common.LeftPadBytes(low.Bytes(), 16))
+	return packed
+}
+
+// BuildUserOpV07 converts a v0.6-shaped UserOp map into the v0.7 packed layout
+// The gas fields are packed into accountGasLimits and gasFees; everything else is carried over
+func BuildUserOpV07(userOp map[string]interface{}) map[string]interface{} {
+	accountGasLimits := PackUint128Pair(
+		hexToBigInt(userOp["verificationGasLimit"]),
+		hexToBigInt(userOp["callGasLimit"]),
+	)
+	gasFees := PackUint128Pair(
+		hexToBigInt(userOp["maxPriorityFeePerGas"]),
+		hexToBigInt(userOp["maxFeePerGas"]),
+	)
+
+	return map[string]interface{}{
+		"sender":             userOp["sender"],
+		"nonce":              userOp["nonce"],
+		"initCode":           userOp["initCode"],
+		"callData":           userOp["callData"],
+		"accountGasLimits":   hexutil.Encode(accountGasLimits[:]),
+		"preVerificationGas": userOp["preVerificationGas"],
+		"gasFees":            hexutil.Encode(gasFees[:]),
+		"paymasterAndData":   userOp["paymasterAndData"],
+		"signature":          userOp["signature"],
+	}
+}
+
+// parsePackedUserOperationFromMap converts a v0.7 UserOp map to a PackedUserOperation struct
+func parsePackedUserOperationFromMap(data map[string]interface{}) (*PackedUserOperation, error) {
+	senderStr, ok := data["sender"].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid sender")
+	}
+
+	userOp := &PackedUserOperation{
+		Sender:             common.HexToAddress(senderStr),
+		Nonce:              hexToBigInt(data["nonce"]),
+		InitCode:           hexToBytes(data["initCode"]),
+		CallData:           hexToBytes(data["callData"]),
+		PreVerificationGas: hexToBigInt(data["preVerificationGas"]),
+		PaymasterAndData:   hexToBytes(data["paymasterAndData"]),
+		Signature:          hexToBytes(data["signature"]),
+	}
+
+	accountGasLimits := hexToBytes(data["accountGasLimits"])
+	gasFees := hexToBytes(data["gasFees"])
+	if len(accountGasLimits) != 32 || len(gasFees) != 32 {
+		return nil, fmt.Errorf("accountGasLimits and gasFees must be 32 bytes")
+	}
+	copy(userOp.AccountGasLimits[:], accountGasLimits)
+	copy(userOp.GasFees[:], gasFees)
+
+	return userOp, nil
+}
+
+// GetUserOpHashV07 computes the v0.7 UserOperation hash for a packed UserOp map
+// userOpHash = keccak256(abi.encode(keccak256(abi.encode(packed fields)), entryPoint, chainId))
+func GetUserOpHashV07(userOp map[string]interface{}, entryPoint common.Address, chainID *big.Int) (common.Hash, error) {
+	op, err := parsePackedUserOperationFromMap(userOp)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	addressType, _ := abi.NewType("address", "", nil)
+	uint256Type, _ := abi.NewType("uint256", "", nil)
+	bytes32Type, _ := abi.NewType("bytes32", "", nil)
+
+	inner := abi.Arguments{
+		{Type: addressType}, // sender
+		{Type: uint256Type}, // nonce
+		{Type: bytes32Type}, // keccak256(initCode)
+		{Type: bytes32Type}, // keccak256(callData)
+		{Type: bytes32Type}, // accountGasLimits
+		{Type: uint256Type}, // preVerificationGas
+		{Type: bytes32Type}, // gasFees
+		{Type: bytes32Type}, // keccak256(paymasterAndData)
+	}
+
+	packed, err := inner.Pack(
+		op.Sender,
+		op.Nonce,
+		crypto.Keccak256Hash(op.InitCode),
+		crypto.Keccak256Hash(op.CallData),
+		op.AccountGasLimits,
+		op.PreVerificationGas,
+		op.GasFees,
+		crypto.Keccak256Hash(op.PaymasterAndData),
+	)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to pack user operation: %w", err)
+	}
+
+	encoded, err := userOpHashArguments.Pack(crypto.Keccak256Hash(packed), entryPoint, chainID)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to pack user operation hash: %w", err)
+	}
+
+	return crypto.Keccak256Hash(encoded), nil
+}
@@ -0,0 +1,82 @@
+package wallet
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// fixtureDeployUserOpV07 is a counterfactual deployment sponsored by a
+// paymaster, so every hashed field of the packed UserOp is non-empty
+func fixtureDeployUserOpV07() map[string]interface{} {
+	op := BuildUserOpV07(fixtureUserOp())
+	op["nonce"] = "0x0"
+	op["initCode"] = "0x9406Cc6185a346906296840746125a0E449764545fbfb9cf000000000000000000000000000000000000000000000000000000000000002a"
+	op["paymasterAndData"] = "0x1111111111111111111111111111111111111111000000000000000000000000000186a000000000000000000000000000007530deadbeef"
+	op["signature"] = "0x01"
+	return op
+}
+
+func TestGetUserOpHashV07MatchesEntryPoint(t *testing.T) {
+	// Expected values are EntryPoint.getUserOpHash(op) from the deployed
+	// EntryPoint v0.7 runtime code (eth_getCode of 0x0000000071727De22E5E9d8BAf0edAc6f37da032
+	// on Ethereum mainnet, as snapshotted in OpenZeppelin's test/bin/EntryPoint070.bytecode),
+	// executed in go-ethereum's EVM at that address with chain id 133.
+	for _, tc := range []struct {
+		name string
+		op   map[string]interface{}
+		want string
+	}{
+		{"transfer", BuildUserOpV07(fixtureUserOp()), "0xf433c0274f88729422fe05634e9f092bda8b9889949682c33fd7dd01cf4b0fb6"},
+		{"deploy with paymaster", fixtureDeployUserOpV07(), "0x55376d99497685a84a9ae03277e80acf1580b62b0b93f561c54059125a9ad622"},
+	} {
+		got, err := GetUserOpHashV07(tc.op, common.HexToAddress(EntryPointV07Address), big.NewInt(133))
+		if err != nil {
+			t.Fatalf("%s: GetUserOpHashV07: %v", tc.name, err)
+		}
+		if got.Hex() != tc.want {
+			t.Errorf("%s: hash = %s, want %s", tc.name, got.Hex(), tc.want)
+		}
+	}
+}
+
+func TestGetUserOpHashV07IgnoresSignatureButNotChain(t *testing.T) {
+	entryPoint := common.HexToAddress(EntryPointV07Address)
+	op := BuildUserOpV07(fixtureUserOp())
+	unsigned, err := GetUserOpHashV07(op, entryPoint, big.NewInt(133))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	op["signature"] = "0x" + strings.Repeat("ab", 65)
+	signed, err := GetUserOpHashV07(op, entryPoint, big.NewInt(133))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed != unsigned {
+		t.Fatalf("signature changed the hash: %s != %s", signed.Hex(), unsigned.Hex())
+	}
+
+	// Same op on mainnet, also from the deployed EntryPoint v0.7
+	other, err := GetUserOpHashV07(op, entryPoint, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Hex() != "0x4cd1d6b573d5529ba5194005cc5a253049d1956571858f37a1d84b5104a95b1e" {
+		t.Fatalf("chain 1 hash = %s", other.Hex())
+	}
+}
+
+func TestGetUserOpHashV07RejectsMalformedPackedFields(t *testing.T) {
+	op := BuildUserOpV07(fixtureUserOp())
+	op["gasFees"] = "0x1234"
+	if _, err := GetUserOpHashV07(op, common.HexToAddress(EntryPointV07Address), big.NewInt(133)); err == nil {
+		t.Fatal("expected an error for a short gasFees word")
+	}
+	delete(op, "sender")
+	if _, err := GetUserOpHashV07(op, common.HexToAddress(EntryPointV07Address), big.NewInt(133)); err == nil {
+		t.Fatal("expected an error for a missing sender")
+	}
+}
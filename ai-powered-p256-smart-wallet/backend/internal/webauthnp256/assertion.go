@@ -0,0 +1,129 @@
+// Package webauthnp256 parses and verifies the WebAuthn P-256 signature blobs
+// the frontend sends for UserOperations. It mirrors the wallet contract's
+// verification so a bad signature is caught before it costs a transaction.
+package webauthnp256
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// minAuthDataLength is rpIdHash (32) || flags (1) || signCount (4)
+const minAuthDataLength = 37
+
+// ClientData is the subset of clientDataJSON the backend inspects
+type ClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// Assertion is a parsed WebAuthn signature blob:
+// signature (r||s or DER) || authDataLength (2, big-endian) || authenticatorData || clientDataJSON
+type Assertion struct {
+	R, S              *big.Int // S is normalized to low-S
+	AuthenticatorData []byte
+	ClientDataJSON    []byte
+	ClientData        ClientData
+}
+
+// ParseAssertion splits a signature blob into its parts without checking the signature
+func ParseAssertion(blob []byte) (*Assertion, error) {
+	sig, rest, err := splitSignature(blob)
+	if err != nil {
+		return nil, err
+	}
+	r, s, err := normalizeP256Signature(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) < 2 {
+		return nil, fmt.Errorf("missing authenticator data length")
+	}
+	authDataLength := int(rest[0])<<8 | int(rest[1])
+	if authDataLength < minAuthDataLength {
+		return nil, fmt.Errorf("authenticator data too short: %d bytes", authDataLength)
+	}
+	if len(rest) < 2+authDataLength {
+		return nil, fmt.Errorf("truncated authenticator data: have %d bytes, need %d", len(rest)-2, authDataLength)
+	}
+
+	assertion := &Assertion{
+		R:                 r,
+		S:                 s,
+		AuthenticatorData: rest[2 : 2+authDataLength],
+		ClientDataJSON:    rest[2+authDataLength:],
+	}
+	if err := json.Unmarshal(assertion.ClientDataJSON, &assertion.ClientData); err != nil {
+		return nil, fmt.Errorf("invalid clientDataJSON: %w", err)
+	}
+	if assertion.ClientData.Type != "webauthn.get" {
+		return nil, fmt.Errorf("unexpected client data type %q", assertion.ClientData.Type)
+	}
+	return assertion, nil
+}
+
+// UserVerified reports whether the authenticator set the UV flag (bit 2 of the flags byte)
+func (a *Assertion) UserVerified() bool {
+	return a.AuthenticatorData[32]&0x04 != 0
+}
+
+// MessageHash is the digest the authenticator signed and the contract verifies:
+// SHA256(authenticatorData || SHA256(clientDataJSON))
+func (a *Assertion) MessageHash() [32]byte {
+	clientDataHash := sha256.Sum256(a.ClientDataJSON)
+	signed := make([]byte, 0, len(a.AuthenticatorData)+len(clientDataHash))
+	signed = append(signed, a.AuthenticatorData...)
+	signed = append(signed, clientDataHash[:]...)
+	return sha256.Sum256(signed)
+}
+
+// PublicKey builds a P-256 public key from its 32-byte coordinates
+func PublicKey(pubKeyX, pubKeyY []byte) (*ecdsa.PublicKey, error) {
+	if len(pubKeyX) != 32 || len(pubKeyY) != 32 {
+		return nil, fmt.Errorf("public key coordinates must be 32 bytes, got %d and %d", len(pubKeyX), len(pubKeyY))
+	}
+	pubKey := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(pubKeyX),
+		Y:     new(big.Int).SetBytes(pubKeyY),
+	}
+	if !pubKey.Curve.IsOnCurve(pubKey.X, pubKey.Y) {
+		return nil, fmt.Errorf("public key is not on the P-256 curve")
+	}
+	return pubKey, nil
+}
+
+// VerifyDigest checks a bare P-256 signature (r||s or DER) over a 32-byte digest
+func VerifyDigest(pubKeyX, pubKeyY, digest, signature []byte) (bool, error) {
+	pubKey, err := PublicKey(pubKeyX, pubKeyY)
+	if err != nil {
+		return false, err
+	}
+	r, s, err := normalizeP256Signature(signature)
+	if err != nil {
+		return false, err
+	}
+	return ecdsa.Verify(pubKey, digest, r, s), nil
+}
+
+// VerifyAssertion checks a WebAuthn signature blob against the passkey's public key
+// It returns an error when the key or blob is malformed and false when the
+// signature does not match the signed data.
+func VerifyAssertion(pubKeyX, pubKeyY, signatureBlob []byte) (bool, error) {
+	pubKey, err := PublicKey(pubKeyX, pubKeyY)
+	if err != nil {
+		return false, err
+	}
+	assertion, err := ParseAssertion(signatureBlob)
+	if err != nil {
+		return false, err
+	}
+	hash := assertion.MessageHash()
+	return ecdsa.Verify(pubKey, hash[:], assertion.R, assertion.S), nil
+}
@@ -0,0 +1,187 @@
+package webauthnp256
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// Assertion captured from a passkey on http://localhost:3000 (see cmd/verify_webauthn):
+	// r || s || authDataLength || authenticatorData || clientDataJSON
+	capturedKeyX = "f81f8ea92c0cf33bc5df8b48884531f0d0f68f01e75c588c098673176034f1c5"
+	capturedKeyY = "cc430dcb14c1855bb9f55319bb3f7972201e72696baf00721fbb58bc51b6d8f9"
+	capturedBlob = "050eb3a1048e6a1dde9bd2d384478cca1eec7e5171222c52d1f25eff7e214c5f7ceeccdcee9fa28ee5f0253a1fa6bee9770cc3dbd12913eee514db79313ee983002549960de5880e8c687434170f6476605b8fe4aeb9a28632c7995cf3ba831d97631d000000007b2274797065223a22776562617574686e2e676574222c226368616c6c656e6765223a22626e44436f48456c75306b736d51414147592d36444d4a6c6b6a6b69734e6a5362435a74395a49564e6463222c226f726967696e223a22687474703a2f2f6c6f63616c686f73743a33303030222c2263726f73734f726967696e223a66616c73657d"
+	capturedHash = "06b5160647d63df126b3e09fc2d9d010a0582743a465b8c85086a718bf411340"
+
+	// Bare signature from a failed transaction (see cmd/verify_signature); it does not sign the hash
+	failedDigest = "7ba5b4b553355a03ec90070bbb20b195508cb8914b0a045ee85d372aa6fb7bf9"
+	failedSig    = "bf61928d8d028987587722b3779d011066f8a61311aae35e2b0c8e7efbbe1339e921bb41af551af8e2e2dc743b4bfb60881feb7d1b5629b1a450bbee6a0aca8c"
+	failedKeyX   = "0c5eb37395ea6da0b462730cc55d1d646ae9fd81186636eb3d81aaac2cca2c66"
+	failedKeyY   = "3d00c3f46d19d9e9aaae3532e60339a88816be0c87a540e981ebd0c4197d741d"
+
+	// DER-encoded assertion (as returned by authenticators) signed with the key
+	// derived from SHA256("webauthnp256 test key")
+	derKeyX      = "0f513e2866d8557cb2ed96a4a87af81b1a0239d66b3769647fbfe6f6ceaf0402"
+	derKeyY      = "8ba71043bd3695ec875be0def2ed7eed73fb001a656ca492a0f0f9c94ec68f0f"
+	derSignature = "30440220338438c7b83e052532a21f82c2c4c3d3d9b498888adcbf2e0999a83c925dad1902205661b6398978d88dfbc52ad676839360fc0472bd83646c3ac3b3db763c1100f7"
+	derAuthData  = "49960de5880e8c687434170f6476605b8fe4aeb9a28632c7995cf3ba831d97630500000001"
+	derClient    = `{"type":"webauthn.get","challenge":"OoXkI-WWUWJrBMZMtzMOwaORsTfJhZ8RRhsQLEV59xE","origin":"http://localhost:3000","crossOrigin":false}`
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("hex.DecodeString: %v", err)
+	}
+	return b
+}
+
+// capturedVector returns a copy of the captured blob with fn applied
+func capturedVector(t *testing.T, fn func(blob []byte) []byte) []byte {
+	t.Helper()
+	blob := mustHex(t, capturedBlob)
+	if fn == nil {
+		return blob
+	}
+	return fn(blob)
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	keyX, keyY := mustHex(t, capturedKeyX), mustHex(t, capturedKeyY)
+	n := elliptic.P256().Params().N
+
+	derBlob := mustHex(t, derSignature)
+	derBlob = append(derBlob, 0x00, byte(len(derAuthData)/2))
+	derBlob = append(derBlob, mustHex(t, derAuthData)...)
+	derBlob = append(derBlob, derClient...)
+
+	tests := []struct {
+		name       string
+		keyX, keyY []byte
+		blob       []byte
+		want       bool
+		wantErr    bool
+	}{
+		{name: "valid", keyX: keyX, keyY: keyY, blob: capturedVector(t, nil), want: true},
+		{name: "valid DER", keyX: mustHex(t, derKeyX), keyY: mustHex(t, derKeyY), blob: derBlob, want: true},
+		{
+			// The flipped s is an equally valid signature; it is normalized before verifying
+			name: "high-S", keyX: keyX, keyY: keyY, want: true,
+			blob: capturedVector(t, func(blob []byte) []byte {
+				s := new(big.Int).Sub(n, new(big.Int).SetBytes(blob[32:64]))
+				copy(blob[32:64], common.LeftPadBytes(s.Bytes(), 32))
+				return blob
+			}),
+		},
+		{
+			// Same length as the signed origin so only the content differs
+			name: "wrong origin", keyX: keyX, keyY: keyY, want: false,
+			blob: capturedVector(t, func(blob []byte) []byte {
+				return bytes.Replace(blob, []byte("http://localhost:3000"), []byte("http://attacker.io:80"), 1)
+			}),
+		},
+		{
+			name: "tampered authData", keyX: keyX, keyY: keyY, want: false,
+			blob: capturedVector(t, func(blob []byte) []byte {
+				blob[66+32] ^= 0x04 // flags byte
+				return blob
+			}),
+		},
+		{name: "wrong key", keyX: mustHex(t, derKeyX), keyY: mustHex(t, derKeyY), blob: capturedVector(t, nil), want: false},
+		{
+			name: "truncated authData", keyX: keyX, keyY: keyY, wantErr: true,
+			blob: capturedVector(t, func(blob []byte) []byte { return blob[:66+20] }),
+		},
+		{
+			name: "authData shorter than its header", keyX: keyX, keyY: keyY, wantErr: true,
+			blob: capturedVector(t, func(blob []byte) []byte {
+				blob[65] = 20
+				return blob
+			}),
+		},
+		{
+			name: "missing authData length", keyX: keyX, keyY: keyY, wantErr: true,
+			blob: capturedVector(t, func(blob []byte) []byte { return blob[:64] }),
+		},
+		{
+			name: "not a get assertion", keyX: keyX, keyY: keyY, wantErr: true,
+			blob: capturedVector(t, func(blob []byte) []byte {
+				return bytes.Replace(blob, []byte("webauthn.get"), []byte("webauthn.new"), 1)
+			}),
+		},
+		{name: "key not on curve", keyX: keyX, keyY: keyX, blob: capturedVector(t, nil), wantErr: true},
+		{name: "short key", keyX: keyX[1:], keyY: keyY, blob: capturedVector(t, nil), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyAssertion(tt.keyX, tt.keyY, tt.blob)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyAssertion: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("VerifyAssertion = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAssertion(t *testing.T) {
+	assertion, err := ParseAssertion(mustHex(t, capturedBlob))
+	if err != nil {
+		t.Fatalf("ParseAssertion: %v", err)
+	}
+	if len(assertion.AuthenticatorData) != 37 {
+		t.Fatalf("authenticatorData is %d bytes, want 37", len(assertion.AuthenticatorData))
+	}
+	if assertion.ClientData.Origin != "http://localhost:3000" {
+		t.Fatalf("origin = %q", assertion.ClientData.Origin)
+	}
+	if assertion.ClientData.Challenge != "bnDCoHElu0ksmQAAGY-6DMJlkjkisNjSbCZt9ZIVNdc" {
+		t.Fatalf("challenge = %q", assertion.ClientData.Challenge)
+	}
+	if !assertion.UserVerified() {
+		t.Fatal("captured assertion has the UV flag set")
+	}
+	if hash := assertion.MessageHash(); hex.EncodeToString(hash[:]) != capturedHash {
+		t.Fatalf("messageHash = %x, want %s", hash, capturedHash)
+	}
+}
+
+func TestVerifyDigest(t *testing.T) {
+	sig := mustHex(t, capturedBlob)[:64]
+
+	tests := []struct {
+		name               string
+		keyX, keyY, digest []byte
+		sig                []byte
+		want               bool
+	}{
+		{"captured assertion", mustHex(t, capturedKeyX), mustHex(t, capturedKeyY), mustHex(t, capturedHash), sig, true},
+		{"failed transaction", mustHex(t, failedKeyX), mustHex(t, failedKeyY), mustHex(t, failedDigest), mustHex(t, failedSig), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyDigest(tt.keyX, tt.keyY, tt.digest, tt.sig)
+			if err != nil {
+				t.Fatalf("VerifyDigest: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("VerifyDigest = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
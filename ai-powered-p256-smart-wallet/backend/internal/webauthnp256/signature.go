@@ -0,0 +1,164 @@
+package webauthnp256
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// p256HalfOrder is n/2 for the P-256 curve; s values above it are "high-S"
+var p256HalfOrder = new(big.Int).Rsh(elliptic.P256().Params().N, 1)
+
+// normalizeP256Signature parses a P-256 ECDSA signature and returns low-S (r, s)
+// raw may be a fixed 64-byte r||s encoding or an ASN.1 DER SEQUENCE{r, s}
+// as returned by WebAuthn authenticators. High-S values are flipped to n-s,
+// which is an equally valid signature that the on-chain verifier accepts.
+func normalizeP256Signature(raw []byte) (r, s *big.Int, err error) {
+	switch {
+	case len(raw) == 64:
+		r = new(big.Int).SetBytes(raw[:32])
+		s = new(big.Int).SetBytes(raw[32:])
+	case len(raw) > 0 && raw[0] == 0x30:
+		var der struct {
+			R, S *big.Int
+		}
+		rest, err := asn1.Unmarshal(raw, &der)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid DER signature: %w", err)
+		}
+		if len(rest) != 0 {
+			return nil, nil, fmt.Errorf("invalid DER signature: %d trailing bytes", len(rest))
+		}
+		r, s = der.R, der.S
+	default:
+		return nil, nil, fmt.Errorf("unsupported signature encoding (%d bytes)", len(raw))
+	}
+
+	n := elliptic.P256().Params().N
+	if r.Sign() <= 0 || r.Cmp(n) >= 0 || s.Sign() <= 0 || s.Cmp(n) >= 0 {
+		return nil, nil, fmt.Errorf("signature values out of range")
+	}
+
+	if s.Cmp(p256HalfOrder) > 0 {
+		s = new(big.Int).Sub(n, s)
+	}
+
+	return r, s, nil
+}
+
+// splitSignature separates the ECDSA signature from the rest of the
+// assertion blob (authDataLength || authenticatorData || clientDataJSON)
+func splitSignature(blob []byte) (sig, rest []byte, err error) {
+	// DER: 0x30 <len> ... (short-form length; P-256 signatures are at most 72 bytes)
+	if len(blob) >= 2 && blob[0] == 0x30 && blob[1] < 0x80 {
+		derLen := int(blob[1]) + 2
+		if len(blob) >= derLen {
+			var der struct {
+				R, S *big.Int
+			}
+			if rest, err := asn1.Unmarshal(blob[:derLen], &der); err == nil && len(rest) == 0 {
+				return blob[:derLen], blob[derLen:], nil
+			}
+		}
+	}
+
+	if len(blob) < 64 {
+		return nil, nil, fmt.Errorf("signature too short: %d bytes", len(blob))
+	}
+	return blob[:64], blob[64:], nil
+}
+
+// Signature format versions: the first byte of a packed UserOp.signature
+const (
+	// SignatureFormatLegacy is the unversioned layout read by wallets deployed before
+	// the version byte: r (32) || s (32) || authDataLength (2) || authData || clientDataJSON
+	SignatureFormatLegacy byte = 0x00
+	// SignatureFormatV1 prefixes the legacy layout with the version byte (see SignatureLayoutV1)
+	SignatureFormatV1 byte = 0x01
+)
+
+// SignatureLayoutV1 is the packed UserOp.signature layout P256Account._validateSignature
+// decodes; lengths in bytes, authDataLength big-endian
+const SignatureLayoutV1 = "version (1) = 0x01 || r (32) || s (32, low-S) || authDataLength (2) || authenticatorData || clientDataJSON"
+
+// ErrUnknownSignatureFormat is returned for a packed signature with an unsupported version byte
+var ErrUnknownSignatureFormat = errors.New("unknown signature format version")
+
+// PackSignature normalizes a WebAuthn signature blob from the frontend and packs it for
+// UserOp.signature in the given format version
+func PackSignature(blob []byte, version byte) ([]byte, error) {
+	normalized, err := NormalizeSignature(blob)
+	if err != nil {
+		return nil, err
+	}
+	switch version {
+	case SignatureFormatLegacy:
+		return normalized, nil
+	case SignatureFormatV1:
+		return append([]byte{SignatureFormatV1}, normalized...), nil
+	default:
+		return nil, fmt.Errorf("%w: 0x%02x", ErrUnknownSignatureFormat, version)
+	}
+}
+
+// DummySignature returns a packed UserOp.signature in the given format version with the
+// layout and size of a real passkey assertion, but which does not verify. Bundlers
+// simulate validation when estimating gas, and an empty signature makes the wallet revert
+// while decoding it; a failed check on a well-formed one does not.
+func DummySignature(version byte) ([]byte, error) {
+	authData := make([]byte, minAuthDataLength)
+	authData[32] = 0x05 // user present, user verified
+
+	// A base64url SHA-256 challenge is 43 characters, like the UserOp hash the frontend signs
+	clientDataJSON := `{"type":"webauthn.get","challenge":"` + strings.Repeat("A", 43) +
+		`","origin":"https://localhost:3000","crossOrigin":false}`
+
+	blob := make([]byte, 0, 64+2+len(authData)+len(clientDataJSON))
+	blob = append(blob, bytes.Repeat([]byte{0x11}, 32)...) // r
+	blob = append(blob, bytes.Repeat([]byte{0x22}, 32)...) // s, below n/2
+	blob = append(blob, byte(len(authData)>>8), byte(len(authData)))
+	blob = append(blob, authData...)
+	blob = append(blob, clientDataJSON...)
+	return PackSignature(blob, version)
+}
+
+// UnpackSignature decodes a packed UserOp.signature by its version byte. The legacy
+// layout has no version byte and cannot be told apart; decode it with ParseAssertion.
+func UnpackSignature(packed []byte) (version byte, assertion *Assertion, err error) {
+	if len(packed) == 0 {
+		return 0, nil, fmt.Errorf("empty signature")
+	}
+	switch version = packed[0]; version {
+	case SignatureFormatV1:
+		assertion, err = ParseAssertion(packed[1:])
+		return version, assertion, err
+	default:
+		return version, nil, fmt.Errorf("%w: 0x%02x", ErrUnknownSignatureFormat, version)
+	}
+}
+
+// NormalizeSignature rewrites a WebAuthn signature blob into the legacy layout:
+// r (32) || s (32, low-S) || authDataLength || authData || clientDataJSON
+func NormalizeSignature(blob []byte) ([]byte, error) {
+	sig, rest, err := splitSignature(blob)
+	if err != nil {
+		return nil, err
+	}
+
+	r, s, err := normalizeP256Signature(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := make([]byte, 0, 64+len(rest))
+	normalized = append(normalized, common.LeftPadBytes(r.Bytes(), 32)...)
+	normalized = append(normalized, common.LeftPadBytes(s.Bytes(), 32)...)
+	normalized = append(normalized, rest...)
+	return normalized, nil
+}
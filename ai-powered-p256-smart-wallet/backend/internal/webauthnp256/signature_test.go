@@ -0,0 +1,218 @@
+package webauthnp256
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func signP256(t *testing.T) (*ecdsa.PrivateKey, []byte, *big.Int, *big.Int) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	digest := sha256.Sum256([]byte("userOpHash"))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	return key, digest[:], r, s
+}
+
+func rawSig(r, s *big.Int) []byte {
+	return append(common.LeftPadBytes(r.Bytes(), 32), common.LeftPadBytes(s.Bytes(), 32)...)
+}
+
+func derSig(t *testing.T, r, s *big.Int) []byte {
+	t.Helper()
+	der, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	if err != nil {
+		t.Fatalf("asn1.Marshal: %v", err)
+	}
+	return der
+}
+
+func highS(s *big.Int) *big.Int {
+	if s.Cmp(p256HalfOrder) > 0 {
+		return s
+	}
+	return new(big.Int).Sub(elliptic.P256().Params().N, s)
+}
+
+func TestNormalizeP256Signature(t *testing.T) {
+	key, digest, r, s := signP256(t)
+
+	tests := []struct {
+		name string
+		raw  []byte
+	}{
+		{"raw low-S", rawSig(r, new(big.Int).Sub(elliptic.P256().Params().N, highS(s)))},
+		{"raw high-S", rawSig(r, highS(s))},
+		{"DER low-S", derSig(t, r, new(big.Int).Sub(elliptic.P256().Params().N, highS(s)))},
+		{"DER high-S", derSig(t, r, highS(s))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotR, gotS, err := normalizeP256Signature(tt.raw)
+			if err != nil {
+				t.Fatalf("normalizeP256Signature: %v", err)
+			}
+			if gotR.Cmp(r) != 0 {
+				t.Fatalf("r = %x, want %x", gotR, r)
+			}
+			if gotS.Cmp(p256HalfOrder) > 0 {
+				t.Fatalf("s = %x is still high-S", gotS)
+			}
+			if !ecdsa.Verify(&key.PublicKey, digest, gotR, gotS) {
+				t.Fatal("normalized signature does not verify")
+			}
+		})
+	}
+}
+
+func TestNormalizeP256SignatureRejectsInvalid(t *testing.T) {
+	n := elliptic.P256().Params().N
+
+	tests := []struct {
+		name string
+		raw  []byte
+	}{
+		{"empty", nil},
+		{"truncated raw", make([]byte, 63)},
+		{"zero r", rawSig(big.NewInt(0), big.NewInt(1))},
+		{"s not below n", rawSig(big.NewInt(1), n)},
+		{"malformed DER", []byte{0x30, 0x05, 0x02, 0x01}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := normalizeP256Signature(tt.raw); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestNormalizeSignatureKeepsAssertionData(t *testing.T) {
+	_, _, r, s := signP256(t)
+
+	authData := bytes.Repeat([]byte{0xaa}, 37)
+	clientDataJSON := []byte(`{"type":"webauthn.get","challenge":"abc","origin":"http://localhost:3000"}`)
+	rest := append([]byte{0x00, byte(len(authData))}, authData...)
+	rest = append(rest, clientDataJSON...)
+
+	for name, sig := range map[string][]byte{
+		"raw": rawSig(r, highS(s)),
+		"DER": derSig(t, r, highS(s)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			normalized, err := NormalizeSignature(append(append([]byte{}, sig...), rest...))
+			if err != nil {
+				t.Fatalf("NormalizeSignature: %v", err)
+			}
+			if !bytes.Equal(normalized[64:], rest) {
+				t.Fatal("authData/clientDataJSON were not preserved")
+			}
+			if new(big.Int).SetBytes(normalized[32:64]).Cmp(p256HalfOrder) > 0 {
+				t.Fatal("s was not normalized to low-S")
+			}
+		})
+	}
+}
+
+func TestPackUnpackSignatureRoundTrip(t *testing.T) {
+	keyX, keyY := mustHex(t, capturedKeyX), mustHex(t, capturedKeyY)
+	der := mustHex(t, derSignature)
+	der = append(der, 0x00, byte(len(derAuthData)/2))
+	der = append(der, mustHex(t, derAuthData)...)
+	der = append(der, derClient...)
+
+	for name, blob := range map[string][]byte{"raw": capturedVector(t, nil), "DER": der} {
+		t.Run(name, func(t *testing.T) {
+			want, err := ParseAssertion(blob)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			packed, err := PackSignature(blob, SignatureFormatV1)
+			if err != nil {
+				t.Fatalf("PackSignature: %v", err)
+			}
+			// v1 is the legacy layout behind the version byte, so the contract's offsets shift by one
+			legacy, err := PackSignature(blob, SignatureFormatLegacy)
+			if err != nil {
+				t.Fatalf("PackSignature(legacy): %v", err)
+			}
+			if packed[0] != SignatureFormatV1 || !bytes.Equal(packed[1:], legacy) || len(packed) != len(legacy)+1 {
+				t.Fatalf("v1 = %x, legacy = %x", packed, legacy)
+			}
+
+			version, got, err := UnpackSignature(packed)
+			if err != nil || version != SignatureFormatV1 {
+				t.Fatalf("UnpackSignature = %d, %v", version, err)
+			}
+			if got.R.Cmp(want.R) != 0 || got.S.Cmp(want.S) != 0 ||
+				!bytes.Equal(got.AuthenticatorData, want.AuthenticatorData) ||
+				!bytes.Equal(got.ClientDataJSON, want.ClientDataJSON) {
+				t.Fatalf("round trip changed the assertion: %+v, want %+v", got, want)
+			}
+			if name == "raw" {
+				if ok, err := VerifyAssertion(keyX, keyY, packed[1:]); err != nil || !ok {
+					t.Fatalf("unpacked v1 signature does not verify: %v, %v", ok, err)
+				}
+			}
+		})
+	}
+}
+
+func TestUnpackSignatureRejectsUnknownVersion(t *testing.T) {
+	packed, err := PackSignature(capturedVector(t, nil), SignatureFormatV1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, version := range []byte{SignatureFormatLegacy, 0x02, 0xff} {
+		packed[0] = version
+		if _, _, err := UnpackSignature(packed); !errors.Is(err, ErrUnknownSignatureFormat) {
+			t.Errorf("UnpackSignature(version 0x%02x) = %v, want ErrUnknownSignatureFormat", version, err)
+		}
+	}
+	if _, _, err := UnpackSignature(nil); err == nil {
+		t.Error("UnpackSignature(nil) succeeded")
+	}
+	if _, err := PackSignature(capturedVector(t, nil), 0x02); !errors.Is(err, ErrUnknownSignatureFormat) {
+		t.Errorf("PackSignature(version 2) = %v, want ErrUnknownSignatureFormat", err)
+	}
+}
+
+func TestDummySignatureParses(t *testing.T) {
+	packed, err := DummySignature(SignatureFormatV1)
+	if err != nil {
+		t.Fatalf("DummySignature: %v", err)
+	}
+	version, assertion, err := UnpackSignature(packed)
+	if err != nil || version != SignatureFormatV1 {
+		t.Fatalf("UnpackSignature = %d, %v", version, err)
+	}
+	if !assertion.UserVerified() || len(assertion.ClientData.Challenge) != 43 {
+		t.Fatalf("assertion = %+v", assertion.ClientData)
+	}
+
+	legacy, err := DummySignature(SignatureFormatLegacy)
+	if err != nil {
+		t.Fatalf("DummySignature(legacy): %v", err)
+	}
+	if !bytes.Equal(legacy, packed[1:]) {
+		t.Fatal("legacy dummy signature differs from the v1 one without its version byte")
+	}
+}
@@ -13,7 +13,7 @@ CREATE TABLE IF NOT EXISTS users (
     last_active_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
-CREATE INDEX idx_users_username ON users(username);
+CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
 
 -- Passkey credentials table
 -- Stores WebAuthn credentials with P-256 public keys
@@ -29,8 +29,8 @@ CREATE TABLE IF NOT EXISTS passkey_credentials (
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
 
-CREATE INDEX idx_passkey_user_id ON passkey_credentials(user_id);
-CREATE INDEX idx_passkey_credential_id ON passkey_credentials(credential_id);
+CREATE INDEX IF NOT EXISTS idx_passkey_user_id ON passkey_credentials(user_id);
+CREATE INDEX IF NOT EXISTS idx_passkey_credential_id ON passkey_credentials(credential_id);
 
 -- Sessions table
 CREATE TABLE IF NOT EXISTS sessions (
@@ -42,9 +42,9 @@ CREATE TABLE IF NOT EXISTS sessions (
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
 
-CREATE INDEX idx_sessions_token ON sessions(token);
-CREATE INDEX idx_sessions_user_id ON sessions(user_id);
-CREATE INDEX idx_sessions_expires_at ON sessions(expires_at);
+CREATE INDEX IF NOT EXISTS idx_sessions_token ON sessions(token);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions(expires_at);
 
 -- Wallets table (P256 Non-Custodial Architecture)
 -- Private keys NEVER stored - only P-256 public key coordinates
@@ -70,10 +70,10 @@ CREATE TABLE IF NOT EXISTS wallets (
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
 
-CREATE INDEX idx_wallets_user_id ON wallets(user_id);
-CREATE INDEX idx_wallets_address ON wallets(address);
-CREATE INDEX idx_wallets_public_keys ON wallets(public_key_x, public_key_y);
-CREATE UNIQUE INDEX idx_wallets_user_id_chain_id ON wallets(user_id, chain_id);
+CREATE INDEX IF NOT EXISTS idx_wallets_user_id ON wallets(user_id);
+CREATE INDEX IF NOT EXISTS idx_wallets_address ON wallets(address);
+CREATE INDEX IF NOT EXISTS idx_wallets_public_keys ON wallets(public_key_x, public_key_y);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wallets_user_id_chain_id ON wallets(user_id, chain_id);
 
 -- Transactions table
 CREATE TABLE IF NOT EXISTS transactions (
@@ -93,11 +93,11 @@ CREATE TABLE IF NOT EXISTS transactions (
     FOREIGN KEY (wallet_id) REFERENCES wallets(id) ON DELETE CASCADE
 );
 
-CREATE INDEX idx_transactions_wallet_id ON transactions(wallet_id);
-CREATE INDEX idx_transactions_status ON transactions(status);
-CREATE INDEX idx_transactions_created_at ON transactions(created_at);
-CREATE INDEX idx_transactions_tx_hash ON transactions(tx_hash);
-CREATE INDEX idx_transactions_user_op_hash ON transactions(user_op_hash);
+CREATE INDEX IF NOT EXISTS idx_transactions_wallet_id ON transactions(wallet_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_status ON transactions(status);
+CREATE INDEX IF NOT EXISTS idx_transactions_created_at ON transactions(created_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_tx_hash ON transactions(tx_hash);
+CREATE INDEX IF NOT EXISTS idx_transactions_user_op_hash ON transactions(user_op_hash);
 
 -- Balances cache table (optional, for performance)
 CREATE TABLE IF NOT EXISTS balances (
@@ -118,8 +118,8 @@ CREATE TABLE IF NOT EXISTS webauthn_sessions (
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
-CREATE INDEX idx_webauthn_sessions_expires_at ON webauthn_sessions(expires_at);
-CREATE INDEX idx_webauthn_sessions_user_id ON webauthn_sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_webauthn_sessions_expires_at ON webauthn_sessions(expires_at);
+CREATE INDEX IF NOT EXISTS idx_webauthn_sessions_user_id ON webauthn_sessions(user_id);
 
 -- Create enum types for transaction status
 DO $$ BEGIN
@@ -129,7 +129,10 @@ EXCEPTION
 END $$;
 
 -- Comments for documentation
-COMMENT ON DATABASE ai_wallet IS 'AI-Powered P256 Smart Wallet - Non-custodial architecture using WebAuthn Passkeys';
+DO $$ BEGIN
+    EXECUTE format('COMMENT ON DATABASE %I IS %L', current_database(),
+        'AI-Powered P256 Smart Wallet - Non-custodial architecture using WebAuthn Passkeys');
+END $$;
 
 COMMENT ON TABLE users IS 'User accounts with Passkey authentication';
 COMMENT ON TABLE passkey_credentials IS 'WebAuthn credentials for biometric authentication (Face ID/Fingerprint)';
@@ -0,0 +1,16 @@
+-- Pending UserOperations
+-- Prepared (unsigned) UserOps waiting for the user's passkey signature.
+-- Rows past expires_at are swept by the backend and rejected on submit.
+CREATE TABLE IF NOT EXISTS pending_user_ops (
+    user_op_hash VARCHAR(66) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    user_op JSONB NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_user_ops_user_id ON pending_user_ops(user_id);
+CREATE INDEX IF NOT EXISTS idx_pending_user_ops_expires_at ON pending_user_ops(expires_at);
+
+COMMENT ON TABLE pending_user_ops IS 'Prepared UserOperations awaiting a passkey signature (expire after PENDING_USEROP_TTL)';
@@ -0,0 +1,11 @@
+-- Transaction history
+-- Rows are inserted after a UserOp is submitted and moved from pending to
+-- confirmed/failed by the backend's receipt poller.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS user_id VARCHAR(36);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS wallet_address VARCHAR(42);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS token VARCHAR(42);
+
+-- Keyset pagination for GET /api/transactions (newest first)
+CREATE INDEX IF NOT EXISTS idx_transactions_user_created ON transactions(user_id, created_at DESC, id DESC);
+
+COMMENT ON COLUMN transactions.token IS 'ERC-20 contract address, NULL/empty for native transfers';
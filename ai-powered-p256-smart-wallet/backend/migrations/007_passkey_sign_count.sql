@@ -0,0 +1,11 @@
+-- Passkey signature counter
+-- Authenticators report a monotonically increasing signCount in authenticatorData.
+-- The backend rejects logins whose counter does not increase (cloned authenticator),
+-- unless the authenticator reports 0 (no counter support).
+ALTER TABLE passkey_credentials ADD COLUMN IF NOT EXISTS sign_count BIGINT DEFAULT 0;
+
+-- signCount is a uint32; INTEGER overflows above 2^31-1
+ALTER TABLE passkey_credentials ALTER COLUMN sign_count TYPE BIGINT;
+UPDATE passkey_credentials SET sign_count = 0 WHERE sign_count IS NULL;
+
+COMMENT ON COLUMN passkey_credentials.sign_count IS 'Last WebAuthn signCount seen for this credential (0 = authenticator has no counter)';
@@ -0,0 +1,5 @@
+-- Passkey nicknames
+-- Users can register several passkeys; a nickname helps tell them apart
+-- in GET /api/passkeys before revoking one.
+ALTER TABLE passkey_credentials ADD COLUMN IF NOT EXISTS nickname VARCHAR(100) DEFAULT 'Passkey';
+UPDATE passkey_credentials SET nickname = 'Passkey' WHERE nickname IS NULL;
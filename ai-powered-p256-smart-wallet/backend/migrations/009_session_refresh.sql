@@ -0,0 +1,7 @@
+-- Session refresh
+-- POST /api/session/refresh rotates the token and slides expires_at forward,
+-- but never past authenticated_at + SESSION_MAX_LIFETIME.
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS authenticated_at TIMESTAMP;
+UPDATE sessions SET authenticated_at = created_at WHERE authenticated_at IS NULL;
+
+COMMENT ON COLUMN sessions.authenticated_at IS 'Time of the passkey login this session descends from (kept across refreshes)';
@@ -0,0 +1,15 @@
+-- Wallet salts
+-- Wallet addresses are CREATE2(factory, salt); the salt is stored so initCode
+-- can be rebuilt and so users can hold several wallets (one per wallet_index).
+-- Existing wallets were all created with salt 0.
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS salt VARCHAR(78) DEFAULT '0';
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS wallet_index BIGINT DEFAULT 0;
+UPDATE wallets SET salt = '0' WHERE salt IS NULL;
+UPDATE wallets SET wallet_index = 0 WHERE wallet_index IS NULL;
+
+-- One wallet per (user, chain, index) instead of one per (user, chain)
+DROP INDEX IF EXISTS idx_wallets_user_id_chain_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wallets_user_chain_index ON wallets(user_id, chain_id, wallet_index);
+
+COMMENT ON COLUMN wallets.salt IS 'CREATE2 salt used by the factory (decimal uint256)';
+COMMENT ON COLUMN wallets.wallet_index IS '0 for the primary wallet, incremented for additional wallets';
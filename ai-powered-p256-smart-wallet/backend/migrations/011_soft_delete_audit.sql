@@ -0,0 +1,25 @@
+-- Soft delete and audit columns
+-- Users, wallets and passkeys are no longer hard-deleted: deleted_at hides a row
+-- from normal queries while keeping its history, and clearing it restores the row.
+-- created_by records who created the row (a user ID, or the tool that inserted it).
+ALTER TABLE users ADD COLUMN IF NOT EXISTS created_by VARCHAR(255) DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+UPDATE users SET updated_at = created_at WHERE updated_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users(deleted_at);
+
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS created_by VARCHAR(255) DEFAULT '';
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP;
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+UPDATE wallets SET updated_at = created_at WHERE updated_at IS NULL;
+UPDATE wallets SET created_by = user_id WHERE created_by IS NULL OR created_by = '';
+CREATE INDEX IF NOT EXISTS idx_wallets_deleted_at ON wallets(deleted_at);
+
+ALTER TABLE passkey_credentials ADD COLUMN IF NOT EXISTS created_by VARCHAR(255) DEFAULT '';
+ALTER TABLE passkey_credentials ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP;
+ALTER TABLE passkey_credentials ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+UPDATE passkey_credentials SET updated_at = created_at WHERE updated_at IS NULL;
+UPDATE passkey_credentials SET created_by = user_id WHERE created_by IS NULL OR created_by = '';
+CREATE INDEX IF NOT EXISTS idx_passkey_credentials_deleted_at ON passkey_credentials(deleted_at);
+
+COMMENT ON COLUMN wallets.deleted_at IS 'Soft delete; restore by setting back to NULL';
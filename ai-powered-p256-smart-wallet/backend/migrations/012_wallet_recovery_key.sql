@@ -0,0 +1,12 @@
+-- Wallet recovery keys
+-- A wallet may register a second passkey that can rotate its signing key after the
+-- signing device is lost (P256Account.setRecoveryKey / rotatePublicKey). The key is
+-- mirrored here so the backend knows which passkeys may sign a rotation.
+-- Empty means no recovery key is registered.
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS recovery_public_key_x VARCHAR(66) DEFAULT '';
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS recovery_public_key_y VARCHAR(66) DEFAULT '';
+UPDATE wallets SET recovery_public_key_x = '' WHERE recovery_public_key_x IS NULL;
+UPDATE wallets SET recovery_public_key_y = '' WHERE recovery_public_key_y IS NULL;
+
+COMMENT ON COLUMN wallets.recovery_public_key_x IS 'Recovery passkey P-256 X coordinate (hex); empty when none is registered';
+COMMENT ON COLUMN wallets.recovery_public_key_y IS 'Recovery passkey P-256 Y coordinate (hex)';
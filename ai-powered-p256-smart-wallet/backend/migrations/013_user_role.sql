@@ -0,0 +1,8 @@
+-- User roles
+-- role gates operator-only endpoints such as GET /api/admin/wallets. Every account
+-- starts as 'user'; grant admin by hand:
+--   UPDATE users SET role = 'admin' WHERE username = '<name>';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(32) DEFAULT 'user';
+UPDATE users SET role = 'user' WHERE role IS NULL OR role = '';
+
+COMMENT ON COLUMN users.role IS 'user or admin';
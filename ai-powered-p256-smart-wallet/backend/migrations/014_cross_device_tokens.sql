@@ -0,0 +1,17 @@
+-- Cross-device signing tokens
+-- One-time tokens handed out by /transfer/prepare-crossdevice so another device can sign
+-- and submit a pending UserOp. Only the SHA-256 of each token is stored; a token is
+-- deleted when redeemed, and rows past expires_at are swept by the backend.
+CREATE TABLE IF NOT EXISTS cross_device_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    user_op_hash VARCHAR(66) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_cross_device_tokens_user_id ON cross_device_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_cross_device_tokens_expires_at ON cross_device_tokens(expires_at);
+
+COMMENT ON TABLE cross_device_tokens IS 'Single-use tokens for signing a prepared UserOp on another device (expire after CROSSDEVICE_TOKEN_TTL)';
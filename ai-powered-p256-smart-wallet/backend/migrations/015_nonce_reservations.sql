@@ -0,0 +1,15 @@
+-- Nonce reservations
+-- Each prepared UserOp holds its EntryPoint nonce here until it lands on-chain, so two
+-- transfers prepared back-to-back get distinct nonces. A reservation is released when
+-- submission fails, dropped once the on-chain nonce passes it, and swept after expires_at.
+CREATE TABLE IF NOT EXISTS nonce_reservations (
+    wallet_address VARCHAR(42) NOT NULL,
+    nonce VARCHAR(78) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (wallet_address, nonce)
+);
+
+CREATE INDEX IF NOT EXISTS idx_nonce_reservations_expires_at ON nonce_reservations(expires_at);
+
+COMMENT ON TABLE nonce_reservations IS 'EntryPoint nonces held by prepared UserOps (expire after PENDING_USEROP_TTL)';
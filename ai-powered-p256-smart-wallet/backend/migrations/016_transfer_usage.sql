@@ -0,0 +1,19 @@
+-- Transfer usage
+-- Each prepared UserOp records the amount of every token it transfers, so the daily caps
+-- of TRANSFER_LIMITS count a user's transfers across requests. A prepared UserOp's rows
+-- expire with it (expires_at); submission clears expires_at so the transfer keeps counting.
+CREATE TABLE IF NOT EXISTS transfer_usage (
+    user_op_hash VARCHAR(66) NOT NULL,
+    token VARCHAR(42) NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    amount NUMERIC(78, 0) NOT NULL,
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_op_hash, token),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfer_usage_user_day ON transfer_usage(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_transfer_usage_expires_at ON transfer_usage(expires_at);
+
+COMMENT ON TABLE transfer_usage IS 'Token amounts of prepared and submitted UserOps, counted against daily transfer caps';
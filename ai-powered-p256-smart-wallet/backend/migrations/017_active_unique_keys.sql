@@ -0,0 +1,11 @@
+-- Unique keys only among live rows
+-- Soft-deleted users and passkeys keep their rows (see 011), so a plain UNIQUE on
+-- username or credential_id stops the same name or authenticator from registering
+-- again. The partial indexes below replace those constraints; restoring a deleted row
+-- fails if a live row has taken its key in the meantime.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_username_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_active ON users(username) WHERE deleted_at IS NULL;
+
+ALTER TABLE passkey_credentials DROP CONSTRAINT IF EXISTS passkey_credentials_credential_id_key;
+DROP INDEX IF EXISTS idx_passkey_credentials_credential_id; -- created by AutoMigrate
+CREATE UNIQUE INDEX IF NOT EXISTS idx_passkey_credentials_credential_id_active ON passkey_credentials(credential_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,9 @@
+-- Pending wallet key changes
+-- A submitted setRecoveryKey or rotatePublicKey UserOp is recorded with the key it sets.
+-- The receipt poller copies the key to the wallet only once the UserOp succeeds, so a
+-- reverted change leaves the wallet's keys as they were.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS public_key_x VARCHAR(66) DEFAULT '';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS public_key_y VARCHAR(66) DEFAULT '';
+
+COMMENT ON COLUMN transactions.public_key_x IS 'New P-256 X coordinate (hex) of a set_recovery_key or rotate_key transaction';
+COMMENT ON COLUMN transactions.public_key_y IS 'New P-256 Y coordinate (hex) of a set_recovery_key or rotate_key transaction';
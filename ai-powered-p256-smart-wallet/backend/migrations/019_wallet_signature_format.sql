@@ -0,0 +1,8 @@
+-- Wallet signature formats
+-- Wallets are proxies that cannot be upgraded, so the UserOp signature layout each one
+-- decodes is fixed by the implementation it was deployed with. The backend probes
+-- SIGNATURE_FORMAT_V1() once per wallet and stores the answer here.
+-- NULL means not probed yet.
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS signature_format SMALLINT;
+
+COMMENT ON COLUMN wallets.signature_format IS 'UserOp signature layout: 0 = legacy (no version byte), 1 = v1; NULL until probed';
@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL schema migrations applied by database.Migrate
+package migrations
+
+import "embed"
+
+// FS holds the NNN_name.sql files, applied in filename order
+//
+//go:embed *.sql
+var FS embed.FS
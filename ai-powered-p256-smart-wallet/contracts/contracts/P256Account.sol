@@ -28,10 +28,20 @@ contract P256Account is BaseAccount {
     
     /// @notice P-256 public key Y coordinate
     uint256 public publicKeyY;
+
+    /// @notice Recovery passkey X coordinate (0 if no recovery key is set)
+    uint256 public recoveryPublicKeyX;
+
+    /// @notice Recovery passkey Y coordinate
+    uint256 public recoveryPublicKeyY;
     
     /// @notice ERC-4337 EntryPoint contract
     IEntryPoint private immutable _entryPoint;
     
+    /// @notice Version byte that starts a v1 signature
+    /// @dev v1 layout: version (1) = 0x01 || r (32) || s (32) || authDataLength (2) || authenticatorData || clientDataJSON
+    uint8 public constant SIGNATURE_FORMAT_V1 = 0x01;
+
     /// @notice RIP-7212 P-256 signature verification precompile address
     /// @dev Sepolia now supports this natively!
     address private constant P256_VERIFIER = address(0x0000000000000000000000000000000000000100);
@@ -40,6 +50,8 @@ contract P256Account is BaseAccount {
     
     event P256AccountInitialized(address indexed account, uint256 publicKeyX, uint256 publicKeyY);
     event TransactionExecuted(address indexed target, uint256 value, bytes data);
+    event RecoveryKeySet(address indexed account, uint256 recoveryPublicKeyX, uint256 recoveryPublicKeyY);
+    event PublicKeyRotated(address indexed account, uint256 publicKeyX, uint256 publicKeyY);
     
     // ==================== Modifiers ====================
     
@@ -89,8 +101,10 @@ contract P256Account is BaseAccount {
      * @notice Validate UserOperation signature using P-256 WebAuthn verification
      * @dev Called by EntryPoint to validate UserOperation before execution
      * 
-     * WebAuthn Signature Format:
-     * The signature contains: r (32 bytes) || s (32 bytes) || authenticatorData || clientDataJSON
+     * WebAuthn Signature Format (v1):
+     * The signature contains: version (1 byte) || r (32 bytes) || s (32 bytes) ||
+     * authDataLength (2 bytes) || authenticatorData || clientDataJSON
+     * Any other version byte fails validation, so a future layout is never misread as v1
      * 
      * WebAuthn signs: authenticatorData || SHA256(clientDataJSON)
      * The clientDataJSON contains the challenge (userOpHash) in base64url format
@@ -103,25 +117,26 @@ contract P256Account is BaseAccount {
         PackedUserOperation calldata userOp,
         bytes32 userOpHash
     ) internal virtual override returns (uint256 validationData) {
-        // Minimum length: 64 bytes (r+s) + at least 37 bytes authenticatorData + minimal clientDataJSON
-        if (userOp.signature.length < 200) {
+        // Minimum length: 1 byte version + 64 bytes (r+s) + at least 37 bytes authenticatorData + minimal clientDataJSON
+        if (userOp.signature.length < 201 || uint8(userOp.signature[0]) != SIGNATURE_FORMAT_V1) {
             return SIG_VALIDATION_FAILED;
         }
+        bytes calldata signature = userOp.signature[1:];
         
-        // Extract r and s (first 64 bytes)
-        bytes32 r = bytes32(userOp.signature[0:32]);
-        bytes32 s = bytes32(userOp.signature[32:64]);
+        // Extract r and s (first 64 bytes after the version byte)
+        bytes32 r = bytes32(signature[0:32]);
+        bytes32 s = bytes32(signature[32:64]);
         
         // The rest is authenticatorData followed by clientDataJSON
         // Format after r||s: authenticatorDataLength (2 bytes) || authenticatorData || clientDataJSON
-        uint16 authDataLength = uint16(uint8(userOp.signature[64])) << 8 | uint16(uint8(userOp.signature[65]));
+        uint16 authDataLength = uint16(uint8(signature[64])) << 8 | uint16(uint8(signature[65]));
         
-        if (userOp.signature.length < 66 + authDataLength) {
+        if (signature.length < 66 + authDataLength) {
             return SIG_VALIDATION_FAILED;
         }
         
-        bytes memory authenticatorData = userOp.signature[66:66 + authDataLength];
-        bytes memory clientDataJSON = userOp.signature[66 + authDataLength:];
+        bytes memory authenticatorData = signature[66:66 + authDataLength];
+        bytes memory clientDataJSON = signature[66 + authDataLength:];
         
         // Verify the clientDataJSON contains our challenge (userOpHash)
         // In clientDataJSON, the challenge is base64url encoded
@@ -142,9 +157,30 @@ contract P256Account is BaseAccount {
             publicKeyX,
             publicKeyY
         );
+
+        // The recovery key may only sign a direct rotatePublicKey call
+        if (!isValid && _isRecoveryCall(userOp.callData)) {
+            isValid = _verifyP256Signature(
+                messageHash,
+                r,
+                s,
+                recoveryPublicKeyX,
+                recoveryPublicKeyY
+            );
+        }
         
         return isValid ? 0 : SIG_VALIDATION_FAILED;
     }
+
+    /**
+     * @dev Whether callData is a direct call to rotatePublicKey and a recovery key is set
+     */
+    function _isRecoveryCall(bytes calldata callData) internal view returns (bool) {
+        if (recoveryPublicKeyX == 0 || recoveryPublicKeyY == 0 || callData.length < 4) {
+            return false;
+        }
+        return bytes4(callData[0:4]) == this.rotatePublicKey.selector;
+    }
     
     /**
      * @notice Verify P-256 (secp256r1) signature using RIP-7212 precompile
@@ -231,6 +267,40 @@ contract P256Account is BaseAccount {
         }
     }
     
+    // ==================== Key Recovery ====================
+
+    /**
+     * @notice Register a backup passkey that can rotate the signing key after device loss
+     * @dev Must be signed by the current key. Setting (0, 0) removes the recovery key.
+     * @param _recoveryPublicKeyX X coordinate of the recovery passkey
+     * @param _recoveryPublicKeyY Y coordinate of the recovery passkey
+     */
+    function setRecoveryKey(
+        uint256 _recoveryPublicKeyX,
+        uint256 _recoveryPublicKeyY
+    ) external onlyEntryPointOrSelf {
+        recoveryPublicKeyX = _recoveryPublicKeyX;
+        recoveryPublicKeyY = _recoveryPublicKeyY;
+        emit RecoveryKeySet(address(this), _recoveryPublicKeyX, _recoveryPublicKeyY);
+    }
+
+    /**
+     * @notice Replace the P-256 signing key
+     * @dev Signed by the current key, or by the recovery key when the UserOp calls this
+     *      function directly (see _validateSignature)
+     * @param _publicKeyX X coordinate of the new P-256 public key
+     * @param _publicKeyY Y coordinate of the new P-256 public key
+     */
+    function rotatePublicKey(
+        uint256 _publicKeyX,
+        uint256 _publicKeyY
+    ) external onlyEntryPointOrSelf {
+        require(_publicKeyX != 0 && _publicKeyY != 0, "P256Account: invalid public key");
+        publicKeyX = _publicKeyX;
+        publicKeyY = _publicKeyY;
+        emit PublicKeyRotated(address(this), _publicKeyX, _publicKeyY);
+    }
+    
     // ==================== Deposit Management ====================
     
     /**
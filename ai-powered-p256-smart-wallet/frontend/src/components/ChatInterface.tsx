@@ -19,7 +19,7 @@ import { ChatMessage, AIResponse, Operation } from '@/types';
 import MessageBubble from './MessageBubble';
 import WalletHeader from './WalletHeader';
 import { useAuth } from '@/context/AuthContext';
-import { P256WalletService } from '@/services/p256Wallet';
+import { P256WalletService, toWei } from '@/services/p256Wallet';
 
 const ChatInterface: React.FC = () => {
   const { session, user, wallet } = useAuth();
@@ -232,8 +232,7 @@ const ChatInterface: React.FC = () => {
         }
 
         // Convert amount to wei (assuming amount is in HSK)
-        // For native token, 1 HSK = 10^18 wei
-        const amountInWei = BigInt(Math.floor(operation.amount * 1e18)).toString();
+        const amountInWei = toWei(operation.amount);
 
         // Use P256 Wallet Service with real signing
         console.log('🔐 Initiating transfer with P256 signature...');
@@ -250,7 +249,8 @@ const ChatInterface: React.FC = () => {
         const successMessage: ChatMessage = {
           id: (Date.now() + 1).toString(),
           role: 'assistant',
-          content: `✓ TRANSACTION SUCCESS\n\nTransaction hash: ${result.txHash}\n\nView on explorer: ${result.explorerUrl}`,
+          content: `✓ TRANSACTION SUCCESS\n\nTransaction hash: ${result.txHash}` +
+            (result.explorerUrl ? `\n\nView on explorer: ${result.explorerUrl}` : ''),
           timestamp: new Date(),
         };
         setMessages((prev) => [...prev, successMessage]);
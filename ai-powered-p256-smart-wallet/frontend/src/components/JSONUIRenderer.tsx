@@ -26,6 +26,8 @@ import {
   CheckCircle,
 } from '@mui/icons-material';
 import { AIResponse, Operation, JSONUIComponentProps } from '@/types';
+import { useAuth } from '@/context/AuthContext';
+import { P256WalletService, TransferPreview, toWei } from '@/services/p256Wallet';
 
 const JSONUIRenderer: React.FC<JSONUIComponentProps> = ({
   data,
@@ -36,6 +38,32 @@ const JSONUIRenderer: React.FC<JSONUIComponentProps> = ({
 }) => {
   const { problem, operation, supplement, form } = data;
   const [formData, setFormData] = useState<Record<string, any>>({});
+  const { session } = useAuth();
+  const [preview, setPreview] = useState<TransferPreview | null>(null);
+  const [previewError, setPreviewError] = useState<string | null>(null);
+
+  // Back the transfer confirmation card with a backend dry-run so the user sees real numbers
+  useEffect(() => {
+    if (isProcessed || !session?.token || operation?.action !== 'transfer' || !operation.recipient || !operation.amount) {
+      return;
+    }
+    let cancelled = false;
+    setPreview(null);
+    setPreviewError(null);
+    P256WalletService.simulateTransfer(
+      { recipient: operation.recipient, amount: toWei(operation.amount) },
+      session.token
+    )
+      .then(result => !cancelled && setPreview(result))
+      .catch(error => !cancelled && setPreviewError(error.message));
+    return () => {
+      cancelled = true;
+    };
+  }, [operation?.action, operation?.recipient, operation?.amount, session?.token, isProcessed]);
+
+  const simulation = preview?.simulation;
+  const insufficientBalance = preview?.insufficientBalance;
+  const simulationBlocksConfirm = !!insufficientBalance || simulation?.success === false;
 
   // Initialize form defaults
   useEffect(() => {
@@ -277,12 +305,59 @@ const JSONUIRenderer: React.FC<JSONUIComponentProps> = ({
                 </Grid>
               )}
 
-              {operation.gasEstimate && (
+              {(simulation || operation.gasEstimate) && (
                 <Grid item xs={6}>
                   <Typography variant="caption" color="text.secondary" sx={{ textTransform: 'uppercase', fontWeight: 600 }}>
                     预计费用
                   </Typography>
-                  <Typography variant="body1" sx={{ fontWeight: 600, mt: 0.5 }}>{operation.gasEstimate}</Typography>
+                  <Typography variant="body1" sx={{ fontWeight: 600, mt: 0.5 }}>
+                    {simulation
+                      ? simulation.sponsored
+                        ? '0（Gas 由平台代付）'
+                        : `≤ ${simulation.estimatedGasFee}`
+                      : operation.gasEstimate}
+                  </Typography>
+                </Grid>
+              )}
+
+              {operation.action === 'transfer' && !isProcessed && session?.token && (
+                <Grid item xs={12}>
+                  <Typography variant="caption" color="text.secondary" sx={{ textTransform: 'uppercase', fontWeight: 600 }}>
+                    模拟结果
+                  </Typography>
+                  {!preview && !previewError && (
+                    <Typography variant="body2" color="text.secondary" sx={{ mt: 0.5 }}>正在模拟交易...</Typography>
+                  )}
+                  {previewError && (
+                    <Alert severity="warning" sx={{ mt: 1 }}>无法模拟交易：{previewError}</Alert>
+                  )}
+                  {insufficientBalance && (
+                    <Alert severity="error" sx={{ mt: 1 }}>
+                      {insufficientBalance.symbol} 余额不足，还差 {insufficientBalance.shortfall} {insufficientBalance.symbol}（含 Gas 费用）
+                    </Alert>
+                  )}
+                  {simulation && !simulation.success && (
+                    <Alert severity="error" sx={{ mt: 1 }}>
+                      交易预计会失败：{simulation.revertReason || '未知原因'}
+                    </Alert>
+                  )}
+                  {simulation?.success && (
+                    <Box sx={{ mt: 1 }}>
+                      {simulation.balanceChanges.map(change => (
+                        <Box key={`${change.address}-${change.token}`} sx={{ display: 'flex', justifyContent: 'space-between', fontSize: '0.85rem', mb: 0.5 }}>
+                          <Typography variant="body2" sx={{ fontFamily: 'monospace' }}>
+                            {change.address.slice(0, 6)}...{change.address.slice(-4)}
+                          </Typography>
+                          <Typography
+                            variant="body2"
+                            sx={{ fontWeight: 600, color: change.delta.startsWith('-') ? 'error.main' : 'success.main' }}
+                          >
+                            {change.formatted} {change.symbol}
+                          </Typography>
+                        </Box>
+                      ))}
+                    </Box>
+                  )}
                 </Grid>
               )}
 
@@ -335,6 +410,7 @@ const JSONUIRenderer: React.FC<JSONUIComponentProps> = ({
                   variant="contained"
                   color="primary"
                   onClick={() => onConfirm(operation)}
+                  disabled={simulationBlocksConfirm}
                   size="large"
                   sx={{ flex: 1 }}
                 >
@@ -2,6 +2,7 @@
 // This service handles building UserOperations and signing with Passkey
 
 import { base64url } from './passkey';
+import { ApiError, InsufficientBalance, TransferLimits, TransferSimulation } from '@/types';
 
 const API_BASE_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080/api';
 
@@ -24,6 +25,18 @@ export interface TransferParams {
   amount: string; // in wei
 }
 
+export interface TransferPreview {
+  simulation?: TransferSimulation;
+  insufficientBalance?: InsufficientBalance;
+}
+
+/**
+ * Convert an HSK amount to wei (1 HSK = 10^18 wei)
+ */
+export function toWei(amount: number): string {
+  return BigInt(Math.floor(amount * 1e18)).toString();
+}
+
 /**
  * Parse DER-encoded ECDSA signature to extract r and s
  * WebAuthn returns signatures in DER format, but we need raw r,s for the contract
@@ -97,11 +110,13 @@ export class P256WalletService {
   /**
    * Sign a message using WebAuthn and return raw r,s signature
    * This is the core P-256 signing function that uses the device's Secure Enclave
+   * The browser lets the user pick any of the allowed passkeys; the ID of the one
+   * that signed is returned so the backend can look it up
    */
   static async signMessageWithPasskey(
     message: Uint8Array,
-    credentialId: string
-  ): Promise<string> {
+    credentialIds: string[]
+  ): Promise<{ signature: string; credentialId: string }> {
     try {
       // Create authentication challenge
       // Convert to ArrayBuffer for WebAuthn API
@@ -111,11 +126,11 @@ export class P256WalletService {
       const credential = await navigator.credentials.get({
         publicKey: {
           challenge: challenge,
-          allowCredentials: [{
-            id: base64url.decode(credentialId),
-            type: 'public-key',
-            transports: ['internal', 'hybrid'],
-          }],
+          allowCredentials: credentialIds.map((id) => ({
+            id: base64url.decode(id),
+            type: 'public-key' as const,
+            transports: ['internal', 'hybrid'] as AuthenticatorTransport[],
+          })),
           timeout: 60000,
           userVerification: 'required',
         },
@@ -183,13 +198,59 @@ export class P256WalletService {
       console.log('  - authenticatorData:', authenticatorData.length, 'bytes');
       console.log('  - clientDataJSON:', clientDataJSON.length, 'bytes');
       
-      return uint8ArrayToHex(fullSignature);
+      return {
+        signature: uint8ArrayToHex(fullSignature),
+        credentialId: base64url.encode(credential.rawId),
+      };
     } catch (error: any) {
       console.error('WebAuthn signing error:', error);
       throw new Error(`Failed to sign with Passkey: ${error.message}`);
     }
   }
 
+  /**
+   * Dry-run a transfer: backend builds the same UserOp as /transfer/prepare and
+   * returns expected balance changes, gas cost and any revert reason without submitting
+   */
+  static async simulateTransfer(
+    params: TransferParams,
+    sessionToken: string
+  ): Promise<TransferPreview> {
+    const response = await fetch(`${API_BASE_URL}/transfer/simulate`, {
+      method: 'POST',
+      headers: {
+        'Content-Type': 'application/json',
+        'X-Session-Token': sessionToken,
+      },
+      body: JSON.stringify(params),
+    });
+
+    const data = await response.json();
+    if (!response.ok) {
+      const error = data as ApiError;
+      if (error.code === 'insufficient_balance') {
+        return { insufficientBalance: error.details as InsufficientBalance };
+      }
+      throw new Error(error.message || 'Failed to simulate transaction');
+    }
+    return { simulation: data };
+  }
+
+  /**
+   * Transfer limits and how much the user can still send today
+   */
+  static async getTransferLimits(sessionToken: string): Promise<TransferLimits> {
+    const response = await fetch(`${API_BASE_URL}/transfer/limits`, {
+      headers: { 'X-Session-Token': sessionToken },
+    });
+
+    const data = await response.json();
+    if (!response.ok) {
+      throw new Error((data as ApiError).message || 'Failed to load transfer limits');
+    }
+    return data;
+  }
+
   /**
    * Simplified transfer that sends parameters to backend
    * Backend builds UserOp, frontend signs it, backend submits
@@ -197,7 +258,7 @@ export class P256WalletService {
   static async executeTransfer(
     params: TransferParams,
     sessionToken: string
-  ): Promise<{ txHash: string; explorerUrl: string }> {
+  ): Promise<{ txHash: string; explorerUrl: string | null }> {
     try {
       // Step 1: Request backend to prepare UserOp
       console.log('Step 1: Preparing UserOp...');
@@ -211,16 +272,17 @@ export class P256WalletService {
       });
 
       if (!prepareResponse.ok) {
-        const error = await prepareResponse.json();
-        throw new Error(error.error || 'Failed to prepare transaction');
+        const error: ApiError = await prepareResponse.json();
+        throw new Error(error.message || 'Failed to prepare transaction');
       }
 
       const prepareData = await prepareResponse.json();
       console.log('Prepare response:', prepareData);
       
-      const { userOpHash, credentialId } = prepareData;
+      const { userOpHash, credentialId, allowCredentials } = prepareData;
+      const credentialIds: string[] = allowCredentials?.length ? allowCredentials : [credentialId];
       
-      if (!userOpHash || !credentialId) {
+      if (!userOpHash || !credentialIds[0]) {
         throw new Error(`Missing data from prepare response: userOpHash=${userOpHash}, credentialId=${credentialId}`);
       }
       
@@ -236,7 +298,7 @@ export class P256WalletService {
         userOpHash.slice(2).match(/.{1,2}/g)!.map((byte: string) => parseInt(byte, 16))
       );
       
-      const signature = await this.signMessageWithPasskey(hashBytes, credentialId);
+      const { signature, credentialId: usedCredentialId } = await this.signMessageWithPasskey(hashBytes, credentialIds);
       
       console.log('✅ Signature created successfully');
       console.log('Signature includes r, s, authenticatorData, and clientDataJSON');
@@ -252,12 +314,14 @@ export class P256WalletService {
         body: JSON.stringify({
           signature,
           userOpHash, // Include hash so backend can match it
+          credentialId: usedCredentialId, // Passkey that produced the assertion
         }),
       });
 
       if (!submitResponse.ok) {
-        const error = await submitResponse.json();
-        throw new Error(error.error || 'Failed to submit transaction');
+        const error: ApiError = await submitResponse.json();
+        const details = typeof error.details === 'string' ? `: ${error.details}` : '';
+        throw new Error((error.message || 'Failed to submit transaction') + details);
       }
 
       return await submitResponse.json();
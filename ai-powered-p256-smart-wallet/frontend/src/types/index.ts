@@ -73,6 +73,7 @@ export interface ChainConfig {
   symbol: string;
   rpcUrl: string;
   explorerUrl: string;
+  txUrlTemplate?: string;
   isTestnet: boolean;
 }
 
@@ -93,6 +94,71 @@ export interface GasEstimate {
   chainSymbol: string;
 }
 
+// Transfer simulation (POST /transfer/simulate)
+export interface BalanceChange {
+  address: string;
+  token: string; // "native" or ERC-20 contract address
+  symbol: string;
+  decimals: number;
+  before: string; // base units
+  after: string;
+  delta: string;
+  formatted: string; // signed, e.g. "-0.5"
+}
+
+export interface TransferSimulation {
+  success: boolean;
+  revertReason?: string;
+  gasLimit: string;
+  maxFeePerGas: string;
+  estimatedGasCost: string; // upper bound in wei
+  estimatedGasFee: string; // e.g. "0.00042 HSK"
+  gasSource: 'bundler' | 'default';
+  sponsored: boolean; // gas paid by the paymaster
+  balanceChanges: BalanceChange[];
+}
+
+// Error body of the transfer endpoints; code is stable, message is for display
+export interface ApiError {
+  code: string; // e.g. 'validation_error', 'insufficient_balance', 'transfer_limit', 'bundler_revert'
+  message: string;
+  details?: unknown;
+}
+
+// details of the 'insufficient_balance' error
+export interface InsufficientBalance {
+  token: string;
+  symbol: string;
+  decimals: number;
+  required: string;
+  available: string;
+  shortfall: string;
+}
+
+// details of the 'transfer_limit' error; amounts in base units
+export interface TransferLimitExceeded {
+  token: string; // 'native' or ERC-20 address
+  limit: 'min' | 'max' | 'daily';
+  amount: string;
+  limitAmount: string;
+  remaining?: string; // daily limit only: what can still be sent today
+}
+
+// GET /transfer/limits: configured limits and the remaining daily allowance
+export interface TransferLimits {
+  limits: {
+    token: string;
+    symbol?: string;
+    decimals: number;
+    min?: string;
+    max?: string;
+    daily?: string;
+    usedToday: string;
+    remainingToday?: string;
+  }[];
+  resetsAt: string; // when daily limits reset (00:00 UTC)
+}
+
 export interface TransferResult {
   txHash: string;
   chainId: number;
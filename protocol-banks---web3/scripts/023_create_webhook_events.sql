@@ -0,0 +1,25 @@
+-- Create webhook_events table: durable audit log of provider webhooks (Rain, Transak)
+-- Redis only caches dedup keys; this table survives Redis eviction and is the source of truth
+
+CREATE TABLE IF NOT EXISTS webhook_events (
+  id BIGSERIAL PRIMARY KEY,
+  provider TEXT NOT NULL,          -- 'rain', 'transak'
+  event_type TEXT NOT NULL,
+  external_id TEXT NOT NULL,       -- provider event ID (idempotency key)
+  payload JSONB NOT NULL,
+  received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  processed_at TIMESTAMPTZ,
+  UNIQUE (provider, external_id)
+);
+
+-- Index for finding unprocessed events
+CREATE INDEX IF NOT EXISTS idx_webhook_events_unprocessed
+  ON webhook_events(provider, received_at)
+  WHERE processed_at IS NULL;
+
+-- Index for browsing by event type
+CREATE INDEX IF NOT EXISTS idx_webhook_events_type
+  ON webhook_events(provider, event_type, received_at DESC);
+
+-- Append-only: events are never deleted, only processed_at is set
+REVOKE DELETE ON webhook_events FROM PUBLIC;
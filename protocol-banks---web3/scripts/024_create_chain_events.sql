@@ -0,0 +1,27 @@
+-- Create chain_events table: logs indexed by the event-indexer, served by its GetEvents RPC
+-- Rows are upserted when first seen, marked confirmed at confirmation depth and deleted when reorged out
+
+CREATE TABLE IF NOT EXISTS chain_events (
+  chain_id BIGINT NOT NULL,
+  block_hash TEXT NOT NULL,
+  log_index INTEGER NOT NULL,
+  block_number BIGINT NOT NULL,
+  tx_hash TEXT NOT NULL,
+  tx_index INTEGER NOT NULL,
+  contract TEXT NOT NULL,          -- lowercase hex
+  event_name TEXT NOT NULL,        -- ABI event name, e.g. 'Transfer'
+  topics TEXT[] NOT NULL,          -- lowercase hex, topics[1] is the event signature
+  data TEXT NOT NULL,
+  fields JSONB NOT NULL DEFAULT '[]', -- decoded event parameters
+  confirmed BOOLEAN NOT NULL DEFAULT FALSE,
+  indexed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (chain_id, block_hash, log_index)
+);
+
+-- Index for GetEvents range queries
+CREATE INDEX IF NOT EXISTS idx_chain_events_contract_block
+  ON chain_events(chain_id, contract, event_name, block_number, log_index);
+
+-- Index for filtering by indexed topics (e.g. Transfer from/to)
+CREATE INDEX IF NOT EXISTS idx_chain_events_topic1 ON chain_events(chain_id, (topics[2]));
+CREATE INDEX IF NOT EXISTS idx_chain_events_topic2 ON chain_events(chain_id, (topics[3]));
@@ -2,13 +2,19 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/protocol-bank/event-indexer/internal/config"
+	"github.com/protocol-bank/event-indexer/internal/handler"
+	"github.com/protocol-bank/event-indexer/internal/store"
 	"github.com/protocol-bank/event-indexer/internal/watcher"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -21,26 +27,61 @@ func main() {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 
+	reset := flag.Bool("reset", false, "ignore saved checkpoints and re-index from the configured start block")
+	backfill := flag.Bool("backfill", false, "index confirmed historical blocks with ranged log queries before following the chain head")
+	flag.Parse()
+
 	// 加载配置
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to load config")
 	}
+	if *reset {
+		cfg.Reset = true
+	}
+	if *backfill {
+		cfg.Backfill.Enabled = true
+	}
 
 	log.Info().Str("env", cfg.Environment).Msg("Starting Event Indexer")
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// 每条链 + 合约的同步进度
+	checkpoints, err := watcher.NewRedisCheckpointStore(ctx, cfg.Redis)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize checkpoint store")
+	}
+	defer checkpoints.Close()
+
 	// 创建多链监听器
-	multiChainWatcher, err := watcher.NewMultiChainWatcher(ctx, cfg)
+	multiChainWatcher, err := watcher.NewMultiChainWatcher(ctx, cfg, checkpoints)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to create multi-chain watcher")
 	}
 
+	// 已索引事件存储：未配置数据库时使用内存存储
+	var eventStore store.EventStore
+	if cfg.Database.URL != "" {
+		pgStore, err := store.NewPostgresEventStore(ctx, cfg.Database.URL)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to initialize event store")
+		}
+		defer pgStore.Close()
+		eventStore = pgStore
+	} else {
+		log.Warn().Msg("DATABASE_URL not set, indexed events are kept in memory")
+		eventStore = store.NewMemoryEventStore()
+	}
+	multiChainWatcher.AddHandler(store.Recorder(ctx, eventStore))
+
 	// 启动监听
 	go multiChainWatcher.Start(ctx)
 
+	// Prometheus 指标
+	metricsServer := startMetricsServer(cfg.MetricsPort)
+
 	// 启动 gRPC 服务器
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
 	if err != nil {
@@ -48,6 +89,7 @@ func main() {
 	}
 
 	grpcServer := grpc.NewServer()
+	handler.RegisterEventQueryServer(grpcServer, handler.NewEventQueryServer(eventStore))
 	reflection.Register(grpcServer)
 
 	go func() {
@@ -64,6 +106,31 @@ func main() {
 
 	log.Info().Msg("Shutting down...")
 	grpcServer.GracefulStop()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Metrics server shutdown error")
+	}
 	cancel()
 	log.Info().Msg("Event Indexer stopped")
 }
+
+// startMetricsServer 在独立端口暴露 Prometheus /metrics
+func startMetricsServer(port int) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		log.Info().Int("port", port).Msg("Metrics server listening")
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatal().Err(err).Msg("Metrics server error")
+		}
+	}()
+	return server
+}
@@ -1,14 +1,25 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
+)
+
+// 新块来源
+const (
+	IndexModeWS   = "ws"   // WebSocket 订阅新块，连接失效时回退为轮询
+	IndexModePoll = "poll" // 按固定间隔轮询 eth_getLogs，用于不支持 WebSocket 的节点
 )
 
 type Config struct {
 	Environment string
 	GRPCPort    int
+	MetricsPort int // Prometheus /metrics 端口
 
 	// Database
 	Database DatabaseConfig
@@ -21,6 +32,23 @@ type Config struct {
 
 	// Watched addresses (comma-separated in env)
 	WatchedAddresses []string
+
+	// Reset 忽略已保存的进度，从配置的起始块重新索引
+	Reset bool
+
+	// ContractABIs 按 ABI 解码事件的合约（INDEXER_CONTRACTS_FILE）
+	ContractABIs []ContractABIConfig
+
+	// Backfill 历史区块回填
+	Backfill BackfillConfig
+}
+
+// BackfillConfig 回填：启动时以范围查询索引已达确认深度的历史区块，之后转为逐块同步
+type BackfillConfig struct {
+	Enabled     bool
+	Window      uint64  // 每次 eth_getLogs 查询的块数，节点返回结果过多时减半
+	Concurrency int     // 同时进行的查询数
+	RateLimit   float64 // 每个 RPC 节点每秒最多查询次数，0 表示不限
 }
 
 type DatabaseConfig struct {
@@ -40,22 +68,35 @@ type ChainConfig struct {
 	WSURL         string // WebSocket URL for subscriptions
 	ExplorerURL   string
 	StartBlock    uint64
-	Confirmations uint64
+	Confirmations uint64        // 确认深度：块高 ≤ 链头 - Confirmations 的块视为不可回滚
+	Contracts     []string      // 只索引这些合约（逗号分隔），为空时索引全部 ERC20 合约
+	IndexMode     string        // 新块来源：ws 或 poll；未配置 WSURL 时总是轮询
+	PollInterval  time.Duration // 轮询间隔（轮询模式与 WebSocket 回退期间）
+	HeadTimeout   time.Duration // WebSocket 超过该时间未收到新块视为连接已失效
+}
+
+// ContractABIConfig 注册的合约：按 ABI 解码其关注的事件，与监听地址无关
+type ContractABIConfig struct {
+	ChainID uint64   `json:"chain_id"`
+	Address string   `json:"address"`
+	Name    string   `json:"name"`
+	ABI     string   `json:"-"`        // ABI JSON，取自 abi 或 abi_file
+	ABIFile string   `json:"abi_file"` // 相对路径相对于注册文件所在目录
+	Events  []string `json:"events"`   // 关注的事件名，为空时为 ABI 中的全部事件
 }
 
 func Load() (*Config, error) {
 	port, _ := strconv.Atoi(getEnv("GRPC_PORT", "50052"))
+	metricsPort, _ := strconv.Atoi(getEnv("METRICS_PORT", "9090"))
 	redisDB, _ := strconv.Atoi(getEnv("REDIS_DB", "0"))
 
 	// Parse watched addresses
-	watchedAddrs := []string{}
-	if addrs := getEnv("WATCHED_ADDRESSES", ""); addrs != "" {
-		watchedAddrs = strings.Split(addrs, ",")
-	}
+	watchedAddrs := getList("WATCHED_ADDRESSES")
 
 	cfg := &Config{
 		Environment: getEnv("ENVIRONMENT", "development"),
 		GRPCPort:    port,
+		MetricsPort: metricsPort,
 		Database: DatabaseConfig{
 			URL: getEnv("DATABASE_URL", ""),
 		},
@@ -65,6 +106,13 @@ func Load() (*Config, error) {
 			DB:       redisDB,
 		},
 		WatchedAddresses: watchedAddrs,
+		Reset:            getEnv("INDEXER_RESET", "false") == "true",
+		Backfill: BackfillConfig{
+			Enabled:     getEnv("INDEXER_BACKFILL", "false") == "true",
+			Window:      max(getUint("INDEXER_BACKFILL_WINDOW", 2000), 1),
+			Concurrency: int(max(getUint("INDEXER_BACKFILL_CONCURRENCY", 4), 1)),
+			RateLimit:   getFloat("INDEXER_BACKFILL_RATE_LIMIT", 10),
+		},
 		Chains: map[uint64]ChainConfig{
 			1: {
 				ChainID:       1,
@@ -72,8 +120,12 @@ func Load() (*Config, error) {
 				RPCURL:        getEnv("ETH_RPC_URL", "https://eth.llamarpc.com"),
 				WSURL:         getEnv("ETH_WS_URL", "wss://eth.llamarpc.com"),
 				ExplorerURL:   "https://etherscan.io",
-				StartBlock:    0, // 0 = latest
-				Confirmations: 12,
+				StartBlock:    getUint("ETH_START_BLOCK", 0), // 0 = latest
+				Confirmations: getUint("ETH_CONFIRMATIONS", 12),
+				Contracts:     getList("ETH_CONTRACTS"),
+				IndexMode:     getEnv("ETH_INDEX_MODE", IndexModeWS),
+				PollInterval:  getDuration("ETH_POLL_INTERVAL", 12*time.Second),
+				HeadTimeout:   getDuration("ETH_HEAD_TIMEOUT", 60*time.Second),
 			},
 			137: {
 				ChainID:       137,
@@ -81,8 +133,12 @@ func Load() (*Config, error) {
 				RPCURL:        getEnv("POLYGON_RPC_URL", "https://polygon-rpc.com"),
 				WSURL:         getEnv("POLYGON_WS_URL", "wss://polygon-rpc.com"),
 				ExplorerURL:   "https://polygonscan.com",
-				StartBlock:    0,
-				Confirmations: 128,
+				StartBlock:    getUint("POLYGON_START_BLOCK", 0),
+				Confirmations: getUint("POLYGON_CONFIRMATIONS", 128),
+				Contracts:     getList("POLYGON_CONTRACTS"),
+				IndexMode:     getEnv("POLYGON_INDEX_MODE", IndexModeWS),
+				PollInterval:  getDuration("POLYGON_POLL_INTERVAL", 12*time.Second),
+				HeadTimeout:   getDuration("POLYGON_HEAD_TIMEOUT", 30*time.Second),
 			},
 			8453: {
 				ChainID:       8453,
@@ -90,8 +146,12 @@ func Load() (*Config, error) {
 				RPCURL:        getEnv("BASE_RPC_URL", "https://mainnet.base.org"),
 				WSURL:         getEnv("BASE_WS_URL", "wss://mainnet.base.org"),
 				ExplorerURL:   "https://basescan.org",
-				StartBlock:    0,
-				Confirmations: 12,
+				StartBlock:    getUint("BASE_START_BLOCK", 0),
+				Confirmations: getUint("BASE_CONFIRMATIONS", 12),
+				Contracts:     getList("BASE_CONTRACTS"),
+				IndexMode:     getEnv("BASE_INDEX_MODE", IndexModeWS),
+				PollInterval:  getDuration("BASE_POLL_INTERVAL", 12*time.Second),
+				HeadTimeout:   getDuration("BASE_HEAD_TIMEOUT", 30*time.Second),
 			},
 			42161: {
 				ChainID:       42161,
@@ -99,18 +159,105 @@ func Load() (*Config, error) {
 				RPCURL:        getEnv("ARBITRUM_RPC_URL", "https://arb1.arbitrum.io/rpc"),
 				WSURL:         getEnv("ARBITRUM_WS_URL", "wss://arb1.arbitrum.io/rpc"),
 				ExplorerURL:   "https://arbiscan.io",
-				StartBlock:    0,
-				Confirmations: 12,
+				StartBlock:    getUint("ARBITRUM_START_BLOCK", 0),
+				Confirmations: getUint("ARBITRUM_CONFIRMATIONS", 12),
+				Contracts:     getList("ARBITRUM_CONTRACTS"),
+				IndexMode:     getEnv("ARBITRUM_INDEX_MODE", IndexModeWS),
+				PollInterval:  getDuration("ARBITRUM_POLL_INTERVAL", 12*time.Second),
+				HeadTimeout:   getDuration("ARBITRUM_HEAD_TIMEOUT", 30*time.Second),
 			},
 		},
 	}
 
+	contracts, err := loadContractABIs(getEnv("INDEXER_CONTRACTS_FILE", ""))
+	if err != nil {
+		return nil, err
+	}
+	cfg.ContractABIs = contracts
+
 	return cfg, nil
 }
 
+// loadContractABIs 读取合约注册文件（JSON 数组），每项的 ABI 内联在 abi 中或由 abi_file 指定
+func loadContractABIs(path string) ([]ContractABIConfig, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read contracts file: %w", err)
+	}
+
+	var entries []struct {
+		ContractABIConfig
+		ABI json.RawMessage `json:"abi"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid contracts file %s: %w", path, err)
+	}
+
+	contracts := make([]ContractABIConfig, len(entries))
+	for i, entry := range entries {
+		contract := entry.ContractABIConfig
+		switch {
+		case len(entry.ABI) > 0:
+			contract.ABI = string(entry.ABI)
+		case contract.ABIFile != "":
+			abiPath := contract.ABIFile
+			if !filepath.IsAbs(abiPath) {
+				abiPath = filepath.Join(filepath.Dir(path), abiPath)
+			}
+			abiJSON, err := os.ReadFile(abiPath)
+			if err != nil {
+				return nil, fmt.Errorf("contract %s: failed to read ABI: %w", contract.Address, err)
+			}
+			contract.ABI = string(abiJSON)
+		default:
+			return nil, fmt.Errorf("contract %s: abi or abi_file is required", contract.Address)
+		}
+		contracts[i] = contract
+	}
+	return contracts, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getUint(key string, defaultValue uint64) uint64 {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+func getFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil && f >= 0 {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+func getDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getList(key string) []string {
+	list := []string{}
+	if value := getEnv(key, ""); value != "" {
+		list = strings.Split(value, ",")
+	}
+	return list
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/protocol-bank/event-indexer/internal/pb"
+	"github.com/protocol-bank/event-indexer/internal/store"
+	"github.com/protocol-bank/event-indexer/internal/watcher"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// defaultPageSize 未指定 limit 时的分页大小
+	defaultPageSize = 100
+	// maxPageSize 单页最大事件数
+	maxPageSize = 1000
+	// maxTopicPosition 事件最多 3 个 indexed 参数
+	maxTopicPosition = 3
+)
+
+// EventQueryServer 已索引事件查询服务
+type EventQueryServer struct {
+	pb.UnimplementedEventQueryServiceServer
+	store store.EventStore
+}
+
+// NewEventQueryServer 创建事件查询服务
+func NewEventQueryServer(s store.EventStore) *EventQueryServer {
+	return &EventQueryServer{store: s}
+}
+
+// RegisterEventQueryServer 注册 gRPC 服务
+func RegisterEventQueryServer(s *grpc.Server, srv *EventQueryServer) {
+	pb.RegisterEventQueryServiceServer(s, srv)
+	log.Info().Msg("Event query gRPC server registered")
+}
+
+// GetEvents 按链、合约、事件名、区块范围与 indexed 参数查询事件
+func (s *EventQueryServer) GetEvents(ctx context.Context, req *pb.GetEventsRequest) (*pb.GetEventsResponse, error) {
+	filter, err := buildFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	events, total, err := s.store.QueryEvents(ctx, filter)
+	if err != nil {
+		log.Error().Err(err).Uint64("chain_id", req.ChainId).Msg("Failed to query events")
+		return nil, status.Error(codes.Internal, "failed to query events")
+	}
+
+	resp := &pb.GetEventsResponse{
+		Events:     make([]*pb.IndexedEvent, 0, len(events)),
+		TotalCount: int32(total),
+		HasMore:    filter.Offset+len(events) < total,
+	}
+	for _, event := range events {
+		resp.Events = append(resp.Events, toIndexedEvent(event))
+	}
+	return resp, nil
+}
+
+// buildFilter 校验请求并转换为存储查询条件
+func buildFilter(req *pb.GetEventsRequest) (store.Filter, error) {
+	if req.ChainId == 0 {
+		return store.Filter{}, status.Error(codes.InvalidArgument, "chain_id is required")
+	}
+	if req.ToBlock != 0 && req.ToBlock < req.FromBlock {
+		return store.Filter{}, status.Error(codes.InvalidArgument, "to_block must not be less than from_block")
+	}
+	if req.Limit < 0 || req.Offset < 0 {
+		return store.Filter{}, status.Error(codes.InvalidArgument, "limit and offset must not be negative")
+	}
+
+	filter := store.Filter{
+		ChainID:       req.ChainId,
+		EventName:     req.EventName,
+		FromBlock:     req.FromBlock,
+		ToBlock:       req.ToBlock,
+		ConfirmedOnly: req.ConfirmedOnly,
+		Limit:         int(req.Limit),
+		Offset:        int(req.Offset),
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageSize
+	}
+	filter.Limit = min(filter.Limit, maxPageSize)
+
+	if req.Contract != "" {
+		if !common.IsHexAddress(req.Contract) {
+			return store.Filter{}, status.Errorf(codes.InvalidArgument, "invalid contract address %q", req.Contract)
+		}
+		filter.Contract = strings.ToLower(common.HexToAddress(req.Contract).Hex())
+	}
+
+	for _, topic := range req.Topics {
+		if topic.Position > maxTopicPosition {
+			return store.Filter{}, status.Errorf(codes.InvalidArgument, "topic position must be 0-%d", maxTopicPosition)
+		}
+		if len(topic.Values) == 0 {
+			return store.Filter{}, status.Errorf(codes.InvalidArgument, "topic %d has no values", topic.Position)
+		}
+
+		values := make([]string, 0, len(topic.Values))
+		for _, value := range topic.Values {
+			normalized, ok := normalizeTopic(value)
+			if !ok {
+				return store.Filter{}, status.Errorf(codes.InvalidArgument, "invalid topic value %q", value)
+			}
+			values = append(values, normalized)
+		}
+		filter.Topics = append(filter.Topics, store.TopicFilter{Position: int(topic.Position), Values: values})
+	}
+
+	return filter, nil
+}
+
+// normalizeTopic topic 值统一为小写 32 字节 hex；20 字节地址左侧补零
+func normalizeTopic(value string) (string, bool) {
+	b, err := hexutil.Decode(value)
+	if err != nil {
+		return "", false
+	}
+
+	switch len(b) {
+	case common.AddressLength:
+		return strings.ToLower(common.BytesToHash(b).Hex()), true
+	case common.HashLength:
+		return strings.ToLower(value), true
+	default:
+		return "", false
+	}
+}
+
+// toIndexedEvent 存储事件 → gRPC 消息
+func toIndexedEvent(event *watcher.ChainEvent) *pb.IndexedEvent {
+	fields := make([]*pb.EventField, 0, len(event.Fields))
+	for _, field := range event.Fields {
+		fields = append(fields, &pb.EventField{
+			Name:    field.Name,
+			Type:    field.Type,
+			Value:   field.Value,
+			Indexed: field.Indexed,
+		})
+	}
+
+	return &pb.IndexedEvent{
+		ChainId:     event.ChainID,
+		Contract:    event.TokenAddress,
+		EventName:   event.EventName,
+		Fields:      fields,
+		TxHash:      event.TxHash,
+		TxIndex:     uint32(event.TxIndex),
+		LogIndex:    uint32(event.LogIndex),
+		BlockNumber: event.BlockNumber,
+		BlockHash:   event.BlockHash,
+		Topics:      event.Topics,
+		Data:        event.Data,
+		Confirmed:   event.Confirmed,
+		Timestamp:   event.Timestamp.Unix(),
+	}
+}
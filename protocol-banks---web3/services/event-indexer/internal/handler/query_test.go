@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"context"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/protocol-bank/event-indexer/internal/pb"
+	"github.com/protocol-bank/event-indexer/internal/store"
+	"github.com/protocol-bank/event-indexer/internal/watcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var (
+	usdc  = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	usdt  = common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	alice = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	bob   = common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	transferSig = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+)
+
+// startQueryServer 启动进程内 gRPC 服务器，使用已写入事件的内存存储
+func startQueryServer(t *testing.T, events ...*watcher.ChainEvent) pb.EventQueryServiceClient {
+	t.Helper()
+
+	s := store.NewMemoryEventStore()
+	for _, event := range events {
+		require.NoError(t, s.SaveEvent(context.Background(), event))
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	RegisterEventQueryServer(server, NewEventQueryServer(s))
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewEventQueryServiceClient(conn)
+}
+
+// transfer 构造 Transfer 事件
+func transfer(chainID uint64, token common.Address, from, to common.Address, value string, block uint64, logIndex uint, confirmed bool) *watcher.ChainEvent {
+	return &watcher.ChainEvent{
+		ChainID:   chainID,
+		EventType: "transfer",
+		EventName: "Transfer",
+		Fields: []watcher.EventField{
+			{Name: "from", Type: "address", Value: from.Hex(), Indexed: true},
+			{Name: "to", Type: "address", Value: to.Hex(), Indexed: true},
+			{Name: "value", Type: "uint256", Value: value},
+		},
+		TxHash:       common.BigToHash(common.Big1).Hex(),
+		LogIndex:     logIndex,
+		BlockNumber:  block,
+		BlockHash:    common.BigToHash(new(big.Int).SetUint64(block)).Hex(),
+		Topics:       []string{transferSig, common.BytesToHash(from.Bytes()).Hex(), common.BytesToHash(to.Bytes()).Hex()},
+		Data:         "0x",
+		FromAddress:  from.Hex(),
+		ToAddress:    to.Hex(),
+		Value:        value,
+		TokenAddress: token.Hex(),
+		Timestamp:    time.Unix(1700000000, 0),
+		Confirmed:    confirmed,
+	}
+}
+
+func seedEvents() []*watcher.ChainEvent {
+	return []*watcher.ChainEvent{
+		transfer(1, usdc, alice, bob, "100", 10, 0, true),
+		transfer(1, usdc, bob, alice, "50", 11, 2, true),
+		transfer(1, usdt, alice, bob, "7", 11, 3, true),
+		transfer(1, usdc, alice, bob, "25", 12, 0, false),
+		transfer(137, usdc, alice, bob, "1", 10, 0, true),
+	}
+}
+
+func TestGetEvents_FiltersByContractAndBlockRange(t *testing.T) {
+	client := startQueryServer(t, seedEvents()...)
+
+	resp, err := client.GetEvents(context.Background(), &pb.GetEventsRequest{
+		ChainId:   1,
+		Contract:  usdc.Hex(),
+		EventName: "Transfer",
+		FromBlock: 11,
+		ToBlock:   12,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Events, 2)
+	assert.Equal(t, int32(2), resp.TotalCount)
+	assert.False(t, resp.HasMore)
+
+	event := resp.Events[0]
+	assert.Equal(t, uint64(11), event.BlockNumber)
+	assert.Equal(t, uint32(2), event.LogIndex)
+	assert.Equal(t, strings.ToLower(usdc.Hex()), event.Contract)
+	assert.Equal(t, "Transfer", event.EventName)
+	assert.True(t, event.Confirmed)
+	require.Len(t, event.Fields, 3)
+	assert.Equal(t, "from", event.Fields[0].Name)
+	assert.Equal(t, bob.Hex(), event.Fields[0].Value)
+	assert.True(t, event.Fields[0].Indexed)
+	assert.Equal(t, "50", event.Fields[2].Value)
+	assert.Len(t, event.Topics, 3)
+
+	assert.Equal(t, uint64(12), resp.Events[1].BlockNumber)
+}
+
+func TestGetEvents_FiltersByIndexedTopics(t *testing.T) {
+	client := startQueryServer(t, seedEvents()...)
+
+	// from = alice, as a plain address
+	resp, err := client.GetEvents(context.Background(), &pb.GetEventsRequest{
+		ChainId: 1,
+		Topics:  []*pb.TopicFilter{{Position: 1, Values: []string{alice.Hex()}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), resp.TotalCount)
+	for _, event := range resp.Events {
+		assert.Equal(t, alice.Hex(), event.Fields[0].Value)
+	}
+
+	// to = alice, as a 32-byte topic; combined with the event signature
+	resp, err = client.GetEvents(context.Background(), &pb.GetEventsRequest{
+		ChainId: 1,
+		Topics: []*pb.TopicFilter{
+			{Position: 0, Values: []string{transferSig}},
+			{Position: 2, Values: []string{common.BytesToHash(alice.Bytes()).Hex()}},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Events, 1)
+	assert.Equal(t, "50", resp.Events[0].Fields[2].Value)
+
+	// Any of several values
+	resp, err = client.GetEvents(context.Background(), &pb.GetEventsRequest{
+		ChainId: 1,
+		Topics:  []*pb.TopicFilter{{Position: 2, Values: []string{alice.Hex(), bob.Hex()}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), resp.TotalCount)
+}
+
+func TestGetEvents_PaginatesWithTotalCount(t *testing.T) {
+	client := startQueryServer(t, seedEvents()...)
+
+	resp, err := client.GetEvents(context.Background(), &pb.GetEventsRequest{ChainId: 1, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, resp.Events, 2)
+	assert.Equal(t, int32(4), resp.TotalCount)
+	assert.True(t, resp.HasMore)
+	assert.Equal(t, uint64(10), resp.Events[0].BlockNumber)
+
+	resp, err = client.GetEvents(context.Background(), &pb.GetEventsRequest{ChainId: 1, Limit: 2, Offset: 2})
+	require.NoError(t, err)
+	require.Len(t, resp.Events, 2)
+	assert.Equal(t, int32(4), resp.TotalCount)
+	assert.False(t, resp.HasMore)
+	assert.Equal(t, uint32(3), resp.Events[0].LogIndex)
+
+	resp, err = client.GetEvents(context.Background(), &pb.GetEventsRequest{ChainId: 1, ConfirmedOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), resp.TotalCount)
+}
+
+func TestGetEvents_InvalidArguments(t *testing.T) {
+	client := startQueryServer(t)
+
+	tests := []struct {
+		name string
+		req  *pb.GetEventsRequest
+	}{
+		{"missing chain", &pb.GetEventsRequest{}},
+		{"inverted range", &pb.GetEventsRequest{ChainId: 1, FromBlock: 10, ToBlock: 5}},
+		{"bad contract", &pb.GetEventsRequest{ChainId: 1, Contract: "usdc"}},
+		{"bad topic position", &pb.GetEventsRequest{ChainId: 1, Topics: []*pb.TopicFilter{{Position: 4, Values: []string{alice.Hex()}}}}},
+		{"bad topic value", &pb.GetEventsRequest{ChainId: 1, Topics: []*pb.TopicFilter{{Position: 1, Values: []string{"0x1234"}}}}},
+		{"empty topic values", &pb.GetEventsRequest{ChainId: 1, Topics: []*pb.TopicFilter{{Position: 1}}}},
+		{"negative offset", &pb.GetEventsRequest{ChainId: 1, Offset: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.GetEvents(context.Background(), tt.req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
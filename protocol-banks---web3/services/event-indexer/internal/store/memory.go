@@ -0,0 +1,119 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/protocol-bank/event-indexer/internal/watcher"
+)
+
+// eventKey 事件唯一标识
+type eventKey struct {
+	chainID   uint64
+	blockHash string
+	logIndex  uint
+}
+
+// MemoryEventStore 内存事件存储，未配置数据库时使用（重启后丢失）
+type MemoryEventStore struct {
+	mu     sync.RWMutex
+	events map[eventKey]*watcher.ChainEvent
+}
+
+// NewMemoryEventStore 创建内存存储
+func NewMemoryEventStore() *MemoryEventStore {
+	return &MemoryEventStore{events: make(map[eventKey]*watcher.ChainEvent)}
+}
+
+// SaveEvent 写入事件；已存在时只更新确认状态
+func (s *MemoryEventStore) SaveEvent(ctx context.Context, event *watcher.ChainEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := eventKey{event.ChainID, strings.ToLower(event.BlockHash), event.LogIndex}
+	if existing, ok := s.events[key]; ok {
+		existing.Confirmed = existing.Confirmed || event.Confirmed
+		return nil
+	}
+
+	e := *event
+	e.TokenAddress = strings.ToLower(e.TokenAddress)
+	e.Topics = make([]string, len(event.Topics))
+	for i, topic := range event.Topics {
+		e.Topics[i] = strings.ToLower(topic)
+	}
+	s.events[key] = &e
+	return nil
+}
+
+// DeleteEvent 删除被重组移除的事件
+func (s *MemoryEventStore) DeleteEvent(ctx context.Context, chainID uint64, blockHash string, logIndex uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.events, eventKey{chainID, strings.ToLower(blockHash), logIndex})
+	return nil
+}
+
+// QueryEvents 分页查询事件
+func (s *MemoryEventStore) QueryEvents(ctx context.Context, filter Filter) ([]*watcher.ChainEvent, int, error) {
+	s.mu.RLock()
+	matched := []*watcher.ChainEvent{}
+	for _, event := range s.events {
+		if matches(event, filter) {
+			e := *event
+			matched = append(matched, &e)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].BlockNumber != matched[j].BlockNumber {
+			return matched[i].BlockNumber < matched[j].BlockNumber
+		}
+		return matched[i].LogIndex < matched[j].LogIndex
+	})
+
+	total := len(matched)
+	start := min(filter.Offset, total)
+	end := min(start+filter.Limit, total)
+	return matched[start:end], total, nil
+}
+
+// matches 事件是否满足查询条件
+func matches(event *watcher.ChainEvent, filter Filter) bool {
+	if event.ChainID != filter.ChainID {
+		return false
+	}
+	if filter.Contract != "" && event.TokenAddress != filter.Contract {
+		return false
+	}
+	if filter.EventName != "" && event.EventName != filter.EventName {
+		return false
+	}
+	if event.BlockNumber < filter.FromBlock {
+		return false
+	}
+	if filter.ToBlock > 0 && event.BlockNumber > filter.ToBlock {
+		return false
+	}
+	if filter.ConfirmedOnly && !event.Confirmed {
+		return false
+	}
+	for _, topic := range filter.Topics {
+		if topic.Position >= len(event.Topics) || !contains(topic.Values, event.Topics[topic.Position]) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
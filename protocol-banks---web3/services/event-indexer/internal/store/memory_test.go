@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/protocol-bank/event-indexer/internal/watcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder_TracksConfirmationAndReorg(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryEventStore()
+	record := Recorder(ctx, s)
+
+	event := &watcher.ChainEvent{
+		ChainID:      1,
+		EventName:    "Transfer",
+		TxHash:       "0xAA",
+		BlockNumber:  10,
+		BlockHash:    "0xB10",
+		LogIndex:     1,
+		TokenAddress: "0xToken",
+		Topics:       []string{"0xSig"},
+	}
+	record(event)
+
+	events, total, err := s.QueryEvents(ctx, Filter{ChainID: 1, Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.False(t, events[0].Confirmed)
+	assert.Equal(t, "0xtoken", events[0].TokenAddress)
+	assert.Equal(t, []string{"0xsig"}, events[0].Topics)
+
+	// Confirmation updates the stored row instead of duplicating it
+	confirmed := *event
+	confirmed.Confirmed = true
+	record(&confirmed)
+
+	events, total, err = s.QueryEvents(ctx, Filter{ChainID: 1, ConfirmedOnly: true, Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.True(t, events[0].Confirmed)
+
+	// A late unconfirmed copy does not downgrade the row
+	record(event)
+	_, total, err = s.QueryEvents(ctx, Filter{ChainID: 1, ConfirmedOnly: true, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	// Reorged out
+	removed := *event
+	removed.Removed = true
+	record(&removed)
+
+	_, total, err = s.QueryEvents(ctx, Filter{ChainID: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+}
+
+func TestBuildWhere(t *testing.T) {
+	where, args := buildWhere(Filter{
+		ChainID:       1,
+		Contract:      "0xabc",
+		FromBlock:     5,
+		Topics:        []TopicFilter{{Position: 1, Values: []string{"0x01"}}},
+		ConfirmedOnly: true,
+	})
+
+	assert.Equal(t, "chain_id = $1 AND contract = $2 AND block_number >= $3 AND topics[2] = ANY($4) AND confirmed", where)
+	assert.Len(t, args, 4)
+}
@@ -0,0 +1,162 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/protocol-bank/event-indexer/internal/watcher"
+)
+
+// PostgresEventStore 基于 Postgres chain_events 表的事件存储
+type PostgresEventStore struct {
+	db *sql.DB
+}
+
+// NewPostgresEventStore 创建存储
+func NewPostgresEventStore(ctx context.Context, dbURL string) (*PostgresEventStore, error) {
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return &PostgresEventStore{db: db}, nil
+}
+
+// SaveEvent 写入事件；已存在时只更新确认状态
+func (s *PostgresEventStore) SaveEvent(ctx context.Context, event *watcher.ChainEvent) error {
+	fields, err := json.Marshal(event.Fields)
+	if err != nil {
+		return fmt.Errorf("failed to encode event fields: %w", err)
+	}
+
+	topics := make([]string, len(event.Topics))
+	for i, topic := range event.Topics {
+		topics[i] = strings.ToLower(topic)
+	}
+
+	query := `
+		INSERT INTO chain_events (
+			chain_id, block_hash, log_index, block_number, tx_hash, tx_index,
+			contract, event_name, topics, data, fields, confirmed, indexed_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11::jsonb, $12, $13)
+		ON CONFLICT (chain_id, block_hash, log_index)
+		DO UPDATE SET confirmed = chain_events.confirmed OR EXCLUDED.confirmed
+	`
+	_, err = s.db.ExecContext(ctx, query,
+		int64(event.ChainID), strings.ToLower(event.BlockHash), int64(event.LogIndex), int64(event.BlockNumber),
+		strings.ToLower(event.TxHash), int64(event.TxIndex), strings.ToLower(event.TokenAddress), event.EventName,
+		pq.Array(topics), event.Data, string(fields), event.Confirmed, event.Timestamp,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save event: %w", err)
+	}
+	return nil
+}
+
+// DeleteEvent 删除被重组移除的事件
+func (s *PostgresEventStore) DeleteEvent(ctx context.Context, chainID uint64, blockHash string, logIndex uint) error {
+	query := `DELETE FROM chain_events WHERE chain_id = $1 AND block_hash = $2 AND log_index = $3`
+	if _, err := s.db.ExecContext(ctx, query, int64(chainID), strings.ToLower(blockHash), int64(logIndex)); err != nil {
+		return fmt.Errorf("failed to delete event: %w", err)
+	}
+	return nil
+}
+
+// QueryEvents 分页查询事件
+func (s *PostgresEventStore) QueryEvents(ctx context.Context, filter Filter) ([]*watcher.ChainEvent, int, error) {
+	where, args := buildWhere(filter)
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM chain_events WHERE ` + where
+	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count events: %w", err)
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT chain_id, block_hash, log_index, block_number, tx_hash, tx_index,
+			contract, event_name, topics, data, fields, confirmed, indexed_at
+		FROM chain_events
+		WHERE %s
+		ORDER BY block_number, log_index
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*watcher.ChainEvent{}
+	for rows.Next() {
+		var event watcher.ChainEvent
+		var chainID, logIndex, blockNumber, txIndex int64
+		var fields []byte
+		if err := rows.Scan(
+			&chainID, &event.BlockHash, &logIndex, &blockNumber, &event.TxHash, &txIndex,
+			&event.TokenAddress, &event.EventName, pq.Array(&event.Topics), &event.Data, &fields, &event.Confirmed, &event.Timestamp,
+		); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan event: %w", err)
+		}
+		if err := json.Unmarshal(fields, &event.Fields); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode event fields: %w", err)
+		}
+		event.ChainID = uint64(chainID)
+		event.LogIndex = uint(logIndex)
+		event.BlockNumber = uint64(blockNumber)
+		event.TxIndex = uint(txIndex)
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to query events: %w", err)
+	}
+
+	return events, total, nil
+}
+
+// Close 关闭连接
+func (s *PostgresEventStore) Close() error {
+	return s.db.Close()
+}
+
+// buildWhere 构造查询条件与参数
+func buildWhere(filter Filter) (string, []interface{}) {
+	conditions := []string{"chain_id = $1"}
+	args := []interface{}{int64(filter.ChainID)}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Contract != "" {
+		add("contract = $%d", filter.Contract)
+	}
+	if filter.EventName != "" {
+		add("event_name = $%d", filter.EventName)
+	}
+	if filter.FromBlock > 0 {
+		add("block_number >= $%d", int64(filter.FromBlock))
+	}
+	if filter.ToBlock > 0 {
+		add("block_number <= $%d", int64(filter.ToBlock))
+	}
+	for _, topic := range filter.Topics {
+		// Postgres 数组下标从 1 开始
+		add(fmt.Sprintf("topics[%d] = ANY($%%d)", topic.Position+1), pq.Array(topic.Values))
+	}
+	if filter.ConfirmedOnly {
+		conditions = append(conditions, "confirmed")
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+
+	"github.com/protocol-bank/event-indexer/internal/watcher"
+	"github.com/rs/zerolog/log"
+)
+
+// TopicFilter 指定位置的 topic 匹配任一值（值为小写 32 字节 hex）
+type TopicFilter struct {
+	Position int
+	Values   []string
+}
+
+// Filter 事件查询条件，零值字段表示不限
+type Filter struct {
+	ChainID       uint64
+	Contract      string // 小写 hex
+	EventName     string
+	FromBlock     uint64
+	ToBlock       uint64
+	Topics        []TopicFilter
+	ConfirmedOnly bool
+	Limit         int
+	Offset        int
+}
+
+// EventStore 已索引事件存储
+// 事件以 (chain_id, block_hash, log_index) 唯一标识，同一交易被重组到另一个块时视为新事件。
+type EventStore interface {
+	// SaveEvent 写入事件；已存在时只更新确认状态
+	SaveEvent(ctx context.Context, event *watcher.ChainEvent) error
+	// DeleteEvent 删除被重组移除的事件
+	DeleteEvent(ctx context.Context, chainID uint64, blockHash string, logIndex uint) error
+	// QueryEvents 按块高、日志序号升序分页查询，同时返回满足条件的总数
+	QueryEvents(ctx context.Context, filter Filter) ([]*watcher.ChainEvent, int, error)
+}
+
+// Recorder 将监听器推送的事件写入存储
+// 新事件写入、确认事件标记为已确认、重组移除的事件删除。
+func Recorder(ctx context.Context, s EventStore) watcher.EventHandler {
+	return func(event *watcher.ChainEvent) {
+		var err error
+		if event.Removed {
+			err = s.DeleteEvent(ctx, event.ChainID, event.BlockHash, event.LogIndex)
+		} else {
+			err = s.SaveEvent(ctx, event)
+		}
+		if err != nil {
+			log.Error().Err(err).
+				Uint64("chain_id", event.ChainID).
+				Str("tx", event.TxHash).
+				Uint("log_index", event.LogIndex).
+				Msg("Failed to record event")
+		}
+	}
+}
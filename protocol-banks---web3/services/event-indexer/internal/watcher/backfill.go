@@ -0,0 +1,189 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+)
+
+// rangeTooLargeErrors 节点拒绝范围查询的错误信息：结果过多或范围过大，缩小窗口后可以成功
+var rangeTooLargeErrors = []string{
+	"query returned more than",
+	"response size exceeded",
+	"response size should not greater than",
+	"block range is too large",
+	"block range too large",
+	"exceed maximum block range",
+	"range too large",
+	"too many results",
+}
+
+// isRangeTooLarge 是否为可以通过缩小窗口解决的错误
+func isRangeTooLarge(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, s := range rangeTooLargeErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// newRateLimiter 每秒最多 perSecond 次请求，0 表示不限（返回 nil）
+func newRateLimiter(perSecond float64) *rate.Limiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(perSecond), 1)
+}
+
+// blockWindow 闭区间 [from, to]
+type blockWindow struct {
+	from, to uint64
+}
+
+// windowResult 一个窗口的查询结果
+type windowResult struct {
+	window blockWindow
+	events []*ChainEvent
+	err    error
+}
+
+// backfill 以范围查询索引已达确认深度的历史区块，直到确认深度以内
+// 窗口并发查询、按顺序提交：事件按块高顺序以已确认状态推送，每提交一个窗口保存一次进度，
+// 中断后从最后提交的窗口之后继续。确认深度以内的块仍由 sync 逐块索引。
+func (w *ChainWatcher) backfill(ctx context.Context) error {
+	w.syncMu.Lock()
+	defer w.syncMu.Unlock()
+
+	head, err := w.client.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get block number: %w", err)
+	}
+	if head < w.cfg.Confirmations {
+		return nil
+	}
+	target := head - w.cfg.Confirmations
+
+	// 进度中未确认的块此时都已达确认深度
+	if err := w.checkTip(ctx); err != nil {
+		return err
+	}
+	w.confirm(head)
+	if target <= w.lastIndexed {
+		return w.saveCheckpoint(ctx)
+	}
+
+	log.Info().
+		Str("chain", w.chainName).
+		Str("contract", w.scope()).
+		Uint64("from", w.lastIndexed+1).
+		Uint64("to", target).
+		Uint64("window", w.backfillCfg.Window).
+		Int("concurrency", w.backfillCfg.Concurrency).
+		Msg("Backfilling historical blocks")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var size atomic.Uint64
+	size.Store(max(w.backfillCfg.Window, 1))
+	concurrency := max(w.backfillCfg.Concurrency, 1)
+
+	// 按顺序排队的进行中窗口，队首完成后才提交，保证事件与进度有序
+	inflight := make([]chan windowResult, 0, concurrency)
+	next := w.lastIndexed + 1
+	for next <= target || len(inflight) > 0 {
+		for len(inflight) < concurrency && next <= target {
+			win := blockWindow{from: next, to: min(next+size.Load()-1, target)}
+			results := make(chan windowResult, 1)
+			go func() {
+				events, err := w.fetchWindow(ctx, win, &size)
+				results <- windowResult{window: win, events: events, err: err}
+			}()
+			inflight = append(inflight, results)
+			next = win.to + 1
+		}
+
+		result := <-inflight[0]
+		inflight = inflight[1:]
+		if result.err != nil {
+			return fmt.Errorf("failed to backfill blocks %d-%d: %w", result.window.from, result.window.to, result.err)
+		}
+		if err := w.commitWindow(ctx, result, head); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Str("chain", w.chainName).Str("contract", w.scope()).Uint64("block", target).Msg("Backfill complete")
+	return nil
+}
+
+// fetchWindow 查询窗口内的事件；节点返回结果过多时把窗口减半分别查询，并缩小之后的窗口
+func (w *ChainWatcher) fetchWindow(ctx context.Context, win blockWindow, size *atomic.Uint64) ([]*ChainEvent, error) {
+	query, addresses, ok := w.logQuery()
+	if !ok {
+		return nil, nil
+	}
+	query.FromBlock = new(big.Int).SetUint64(win.from)
+	query.ToBlock = new(big.Int).SetUint64(win.to)
+
+	if w.limiter != nil {
+		if err := w.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	logs, err := w.client.FilterLogs(ctx, query)
+	if err == nil {
+		return w.processLogs(logs, addresses), nil
+	}
+	if !isRangeTooLarge(err) || win.from == win.to {
+		return nil, fmt.Errorf("failed to filter logs: %w", err)
+	}
+
+	half := (win.to - win.from + 1) / 2
+	for current := size.Load(); half < current; current = size.Load() {
+		if size.CompareAndSwap(current, half) {
+			break
+		}
+	}
+	log.Debug().
+		Str("chain", w.chainName).
+		Uint64("from", win.from).
+		Uint64("to", win.to).
+		Uint64("window", half).
+		Msg("Log query too large, halving window")
+
+	first, err := w.fetchWindow(ctx, blockWindow{from: win.from, to: win.from + half - 1}, size)
+	if err != nil {
+		return nil, err
+	}
+	second, err := w.fetchWindow(ctx, blockWindow{from: win.from + half, to: win.to}, size)
+	if err != nil {
+		return nil, err
+	}
+	return append(first, second...), nil
+}
+
+// commitWindow 推送窗口内的已确认事件并保存进度
+func (w *ChainWatcher) commitWindow(ctx context.Context, result windowResult, head uint64) error {
+	for _, event := range result.events {
+		event.Confirmed = true
+	}
+	w.dispatch(result.events)
+
+	w.lastIndexed = result.window.to
+	w.lastSafe = result.window.to
+	w.safeHash = common.Hash{}
+	w.dirty = true
+	BlocksProcessedTotal.WithLabelValues(strconv.FormatUint(w.chainID, 10), w.scope()).Add(float64(result.window.to - result.window.from + 1))
+	w.reportLag(head)
+	return w.saveCheckpoint(ctx)
+}
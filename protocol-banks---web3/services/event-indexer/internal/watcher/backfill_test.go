@@ -0,0 +1,137 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/protocol-bank/event-indexer/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rangeLimitedChain 模拟限制范围查询结果数的节点
+type rangeLimitedChain struct {
+	*fakeChain
+	maxRange uint64 // 超过该块数的范围查询返回结果过多
+
+	mu      sync.Mutex
+	queries []blockWindow
+	failAt  uint64 // 非 0 时包含该块的查询返回网络错误
+}
+
+func (c *rangeLimitedChain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	if q.BlockHash != nil {
+		return c.fakeChain.FilterLogs(ctx, q)
+	}
+
+	win := blockWindow{from: q.FromBlock.Uint64(), to: q.ToBlock.Uint64()}
+	c.mu.Lock()
+	c.queries = append(c.queries, win)
+	failAt := c.failAt
+	c.mu.Unlock()
+
+	if failAt != 0 && win.from <= failAt && failAt <= win.to {
+		return nil, errors.New("connection reset by peer")
+	}
+	if win.to-win.from+1 > c.maxRange {
+		return nil, fmt.Errorf("query returned more than 10000 results")
+	}
+	return c.fakeChain.FilterLogs(ctx, q)
+}
+
+// mineHistory 出 n 个块，每个块一笔与监听地址相关的转账，返回各块的交易
+func mineHistory(chain *fakeChain, n int) []common.Hash {
+	txs := make([]common.Hash, n)
+	for i := range txs {
+		txs[i] = common.BigToHash(big.NewInt(int64(1000 + i)))
+		chain.mine(0, txs[i])
+	}
+	return txs
+}
+
+func newBackfillWatcher(t *testing.T, chain *rangeLimitedChain, checkpoints CheckpointStore) (*ChainWatcher, *eventRecorder) {
+	t.Helper()
+	w, recorder := newTestWatcher(t, chain.fakeChain, checkpoints, config.ChainConfig{Confirmations: 5, StartBlock: 1}, common.Address{})
+	w.client = chain
+	w.backfillCfg = config.BackfillConfig{Enabled: true, Window: 32, Concurrency: 3}
+	require.NoError(t, w.loadCheckpoint(context.Background()))
+	return w, recorder
+}
+
+func TestBackfill_HalvesWindowOnTooManyResults(t *testing.T) {
+	ctx := context.Background()
+	chain := &rangeLimitedChain{fakeChain: newFakeChain(0), maxRange: 10}
+	txs := mineHistory(chain.fakeChain, 100)
+	checkpoints := newMemoryCheckpointStore()
+
+	w, recorder := newBackfillWatcher(t, chain, checkpoints)
+	require.NoError(t, w.backfill(ctx))
+
+	// 确认深度之外的 95 个块按顺序以已确认状态推送，每块一次
+	events := recorder.take()
+	require.Len(t, events, 95)
+	for i, event := range events {
+		assert.Equal(t, txs[i].Hex(), event.TxHash)
+		assert.Equal(t, uint64(i+1), event.BlockNumber)
+		assert.True(t, event.Confirmed)
+	}
+	assert.Equal(t, uint64(95), w.lastIndexed)
+	assert.Equal(t, uint64(95), checkpoints.safeBlock(t, "all"))
+
+	// 32 → 16 → 8：成功的查询都不超过节点限制，窗口缩小后不再反复失败
+	var failed int
+	for _, q := range chain.queries {
+		if q.to-q.from+1 > chain.maxRange {
+			failed++
+		}
+	}
+	assert.Less(t, failed, 10)
+	last := chain.queries[len(chain.queries)-1]
+	assert.LessOrEqual(t, last.to-last.from+1, uint64(8))
+
+	// 确认深度以内的块由逐块同步继续索引
+	require.NoError(t, w.sync(ctx))
+	assert.Equal(t, uint64(100), w.lastIndexed)
+	assert.Len(t, recorder.take(), 5)
+}
+
+func TestBackfill_ResumesFromCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	chain := &rangeLimitedChain{fakeChain: newFakeChain(0), maxRange: 1000, failAt: 70}
+	txs := mineHistory(chain.fakeChain, 100)
+	checkpoints := newMemoryCheckpointStore()
+
+	// 第一次回填在包含块 70 的窗口失败，之前的窗口已提交
+	first, before := newBackfillWatcher(t, chain, checkpoints)
+	require.Error(t, first.backfill(ctx))
+	assert.Equal(t, uint64(64), checkpoints.safeBlock(t, "all"))
+
+	// 重启后从块 65 继续，不重复也不遗漏
+	chain.mu.Lock()
+	chain.failAt = 0
+	chain.mu.Unlock()
+	second, after := newBackfillWatcher(t, chain, checkpoints)
+	assert.Equal(t, uint64(64), second.lastIndexed)
+	require.NoError(t, second.backfill(ctx))
+	assert.Equal(t, uint64(95), checkpoints.safeBlock(t, "all"))
+
+	expected := make(map[eventKey]int)
+	for _, tx := range txs[:95] {
+		expected[eventKey{tx: tx.Hex(), confirmed: true}] = 1
+	}
+	assert.Equal(t, expected, countEvents(before.take(), after.take()))
+}
+
+func TestIsRangeTooLarge(t *testing.T) {
+	assert.True(t, isRangeTooLarge(errors.New("query returned more than 10000 results")))
+	assert.True(t, isRangeTooLarge(errors.New("Log response size exceeded. You can make eth_getLogs requests with up to a 2K block range")))
+	assert.True(t, isRangeTooLarge(errors.New("exceed maximum block range: 50000")))
+	assert.False(t, isRangeTooLarge(errors.New("connection reset by peer")))
+}
@@ -0,0 +1,194 @@
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-redis/redis/v8"
+	"github.com/protocol-bank/event-indexer/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// checkpointKeyPrefix 每条链 + 合约同步进度的 Redis key 前缀
+const checkpointKeyPrefix = "indexer:checkpoint:"
+
+// Checkpoint 同步进度
+// 除最后处理块外还保存未达确认深度的块，重启后可继续检测重组并推送确认事件，不重复推送也不遗漏。
+type Checkpoint struct {
+	LastBlock uint64         `json:"last_block"` // 最后已处理块
+	SafeBlock uint64         `json:"safe_block"` // 最后安全块（已达确认深度）
+	SafeHash  string         `json:"safe_hash,omitempty"`
+	Pending   []PendingBlock `json:"pending,omitempty"` // 已处理、未达确认深度的块
+}
+
+// PendingBlock 未达确认深度的块及其已推送的事件
+type PendingBlock struct {
+	Number uint64        `json:"number"`
+	Hash   string        `json:"hash"`
+	Events []*ChainEvent `json:"events,omitempty"`
+}
+
+// CheckpointStore 同步进度持久化，按链 + 合约区分
+type CheckpointStore interface {
+	// LoadCheckpoint 读取进度，未记录时返回 nil
+	LoadCheckpoint(ctx context.Context, chainID uint64, contract string) (*Checkpoint, error)
+	// SaveCheckpoint 保存进度
+	SaveCheckpoint(ctx context.Context, chainID uint64, contract string, cp *Checkpoint) error
+	// DeleteCheckpoint 删除进度（强制从头重新索引）
+	DeleteCheckpoint(ctx context.Context, chainID uint64, contract string) error
+}
+
+// RedisCheckpointStore 基于 Redis 的同步进度存储
+type RedisCheckpointStore struct {
+	redis *redis.Client
+}
+
+// NewRedisCheckpointStore 创建同步进度存储
+func NewRedisCheckpointStore(ctx context.Context, cfg config.RedisConfig) (*RedisCheckpointStore, error) {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     cfg.URL,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("redis connection failed: %w", err)
+	}
+
+	return &RedisCheckpointStore{redis: rdb}, nil
+}
+
+// LoadCheckpoint 读取进度
+func (s *RedisCheckpointStore) LoadCheckpoint(ctx context.Context, chainID uint64, contract string) (*Checkpoint, error) {
+	data, err := s.redis.Get(ctx, checkpointKey(chainID, contract)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint: %w", err)
+	}
+	return &cp, nil
+}
+
+// SaveCheckpoint 保存进度
+func (s *RedisCheckpointStore) SaveCheckpoint(ctx context.Context, chainID uint64, contract string, cp *Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	return s.redis.Set(ctx, checkpointKey(chainID, contract), data, 0).Err()
+}
+
+// DeleteCheckpoint 删除进度
+func (s *RedisCheckpointStore) DeleteCheckpoint(ctx context.Context, chainID uint64, contract string) error {
+	return s.redis.Del(ctx, checkpointKey(chainID, contract)).Err()
+}
+
+// Close 关闭连接
+func (s *RedisCheckpointStore) Close() error {
+	return s.redis.Close()
+}
+
+func checkpointKey(chainID uint64, contract string) string {
+	return fmt.Sprintf("%s%d:%s", checkpointKeyPrefix, chainID, contract)
+}
+
+// loadCheckpoint 从保存的进度恢复同步状态
+// 无进度（或要求重置）时从配置的起始块开始，StartBlock 为 0 时从当前链头开始。
+func (w *ChainWatcher) loadCheckpoint(ctx context.Context) error {
+	w.syncMu.Lock()
+	defer w.syncMu.Unlock()
+
+	scope := w.scope()
+	if w.reset {
+		if err := w.checkpoints.DeleteCheckpoint(ctx, w.chainID, scope); err != nil {
+			return fmt.Errorf("failed to reset checkpoint: %w", err)
+		}
+		log.Warn().Str("chain", w.chainName).Str("contract", scope).Msg("Checkpoint reset, re-indexing from start block")
+	}
+
+	cp, err := w.checkpoints.LoadCheckpoint(ctx, w.chainID, scope)
+	if err != nil {
+		return fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+
+	w.unsafe = make(map[uint64]*indexedBlock)
+	w.safeHash = common.Hash{}
+	w.dirty = false
+
+	if cp == nil {
+		start, err := w.startBlock(ctx)
+		if err != nil {
+			return err
+		}
+		w.lastSafe = start
+		w.lastIndexed = start
+		log.Info().Str("chain", w.chainName).Str("contract", scope).Uint64("block", start).Msg("No checkpoint, indexing from start block")
+		return nil
+	}
+
+	w.lastSafe = cp.SafeBlock
+	w.lastIndexed = cp.LastBlock
+	if cp.SafeHash != "" {
+		w.safeHash = common.HexToHash(cp.SafeHash)
+	}
+	for _, block := range cp.Pending {
+		w.unsafe[block.Number] = &indexedBlock{hash: common.HexToHash(block.Hash), events: block.Events}
+	}
+
+	log.Info().
+		Str("chain", w.chainName).
+		Str("contract", scope).
+		Uint64("last_block", cp.LastBlock).
+		Uint64("safe_block", cp.SafeBlock).
+		Int("pending", len(cp.Pending)).
+		Msg("Resuming from checkpoint")
+	return nil
+}
+
+// startBlock 无进度时的起点（该块本身不索引）
+func (w *ChainWatcher) startBlock(ctx context.Context) (uint64, error) {
+	if w.cfg.StartBlock > 0 {
+		return w.cfg.StartBlock - 1, nil
+	}
+
+	head, err := w.client.BlockNumber(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get block number: %w", err)
+	}
+	return head, nil
+}
+
+// saveCheckpoint 保存当前同步状态，状态无变化时跳过
+func (w *ChainWatcher) saveCheckpoint(ctx context.Context) error {
+	if !w.dirty {
+		return nil
+	}
+
+	cp := &Checkpoint{
+		LastBlock: w.lastIndexed,
+		SafeBlock: w.lastSafe,
+		Pending:   make([]PendingBlock, 0, len(w.unsafe)),
+	}
+	if w.safeHash != (common.Hash{}) {
+		cp.SafeHash = w.safeHash.Hex()
+	}
+	for number, block := range w.unsafe {
+		cp.Pending = append(cp.Pending, PendingBlock{Number: number, Hash: block.hash.Hex(), Events: block.events})
+	}
+	sort.Slice(cp.Pending, func(i, j int) bool { return cp.Pending[i].Number < cp.Pending[j].Number })
+
+	if err := w.checkpoints.SaveCheckpoint(ctx, w.chainID, w.scope(), cp); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	w.dirty = false
+	return nil
+}
@@ -0,0 +1,160 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/protocol-bank/event-indexer/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventKey 事件在推送流中的唯一标识
+type eventKey struct {
+	tx        string
+	confirmed bool
+	removed   bool
+}
+
+func countEvents(events ...[]ChainEvent) map[eventKey]int {
+	counts := make(map[eventKey]int)
+	for _, list := range events {
+		for _, e := range list {
+			counts[eventKey{e.TxHash, e.Confirmed, e.Removed}]++
+		}
+	}
+	return counts
+}
+
+func TestCheckpoint_ResumeWithoutGapsOrDuplicates(t *testing.T) {
+	ctx := context.Background()
+	chain := newFakeChain(10)
+	checkpoints := newMemoryCheckpointStore()
+	cfg := config.ChainConfig{Confirmations: 2, StartBlock: 11}
+
+	first, before := newTestWatcher(t, chain, checkpoints, cfg, common.Address{})
+	require.NoError(t, first.loadCheckpoint(ctx))
+
+	chain.mine(0, txHash(1)) // 11
+	chain.mine(0)            // 12
+	chain.mine(0, txHash(2)) // 13
+	require.NoError(t, first.sync(ctx))
+
+	// Restart: resumes after block 13 with blocks 12-13 still unconfirmed
+	second, after := newTestWatcher(t, chain, checkpoints, cfg, common.Address{})
+	require.NoError(t, second.loadCheckpoint(ctx))
+	assert.Equal(t, uint64(13), second.lastIndexed)
+	assert.Equal(t, uint64(11), second.lastSafe)
+
+	chain.mine(0, txHash(3)) // 14
+	chain.mine(0)            // 15
+	chain.mine(0)            // 16
+	require.NoError(t, second.sync(ctx))
+
+	assert.Equal(t, map[eventKey]int{
+		{txHash(1).Hex(), false, false}: 1,
+		{txHash(1).Hex(), true, false}:  1,
+		{txHash(2).Hex(), false, false}: 1,
+		{txHash(2).Hex(), true, false}:  1,
+		{txHash(3).Hex(), false, false}: 1,
+		{txHash(3).Hex(), true, false}:  1,
+	}, countEvents(before.take(), after.take()))
+
+	cp, err := checkpoints.LoadCheckpoint(ctx, 1, "all")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(16), cp.LastBlock)
+	assert.Equal(t, uint64(14), cp.SafeBlock)
+	require.Len(t, cp.Pending, 2)
+	assert.Equal(t, uint64(15), cp.Pending[0].Number)
+}
+
+func TestCheckpoint_ResumeDetectsReorgWhileStopped(t *testing.T) {
+	ctx := context.Background()
+	chain := newFakeChain(10)
+	checkpoints := newMemoryCheckpointStore()
+	cfg := config.ChainConfig{Confirmations: 3, StartBlock: 11}
+
+	first, before := newTestWatcher(t, chain, checkpoints, cfg, common.Address{})
+	require.NoError(t, first.loadCheckpoint(ctx))
+	chain.mine(0, txHash(1)) // 11
+	require.NoError(t, first.sync(ctx))
+	require.Len(t, before.take(), 1)
+
+	// Block 11 is replaced while the indexer is down
+	chain.rewind(10)
+	chain.mine(1, txHash(2))
+	chain.mine(1)
+
+	second, after := newTestWatcher(t, chain, checkpoints, cfg, common.Address{})
+	require.NoError(t, second.loadCheckpoint(ctx))
+	require.NoError(t, second.sync(ctx))
+
+	events := after.take()
+	require.Len(t, events, 2)
+	assert.Equal(t, txHash(1).Hex(), events[0].TxHash)
+	assert.True(t, events[0].Removed)
+	assert.Equal(t, txHash(2).Hex(), events[1].TxHash)
+	assert.False(t, events[1].Removed)
+}
+
+func TestCheckpoint_Reset(t *testing.T) {
+	ctx := context.Background()
+	chain := newFakeChain(10)
+	checkpoints := newMemoryCheckpointStore()
+	cfg := config.ChainConfig{Confirmations: 1, StartBlock: 11}
+
+	first, _ := newTestWatcher(t, chain, checkpoints, cfg, common.Address{})
+	require.NoError(t, first.loadCheckpoint(ctx))
+	chain.mine(0, txHash(1))
+	chain.mine(0)
+	require.NoError(t, first.sync(ctx))
+
+	second, recorder := newTestWatcher(t, chain, checkpoints, cfg, common.Address{})
+	second.reset = true
+	require.NoError(t, second.loadCheckpoint(ctx))
+	assert.Equal(t, uint64(10), second.lastIndexed)
+
+	require.NoError(t, second.sync(ctx))
+	assert.Equal(t, map[eventKey]int{
+		{txHash(1).Hex(), false, false}: 1,
+		{txHash(1).Hex(), true, false}:  1,
+	}, countEvents(recorder.take()))
+}
+
+func TestCheckpoint_PerContract(t *testing.T) {
+	ctx := context.Background()
+	chain := newFakeChain(10)
+	checkpoints := newMemoryCheckpointStore()
+	cfg := config.ChainConfig{Confirmations: 1, StartBlock: 11}
+
+	token, tokenEvents := newTestWatcher(t, chain, checkpoints, cfg, tokenAddr)
+	require.NoError(t, token.loadCheckpoint(ctx))
+	chain.mine(0, txHash(1))
+	chain.mine(0)
+	require.NoError(t, token.sync(ctx))
+	assert.NotEmpty(t, tokenEvents.take())
+
+	// A contract added later has its own progress and starts from the configured block
+	other, otherEvents := newTestWatcher(t, chain, checkpoints, cfg, otherAddr)
+	require.NoError(t, other.loadCheckpoint(ctx))
+	assert.Equal(t, uint64(10), other.lastIndexed)
+	require.NoError(t, other.sync(ctx))
+	assert.Empty(t, otherEvents.take())
+
+	assert.Equal(t, uint64(11), checkpoints.safeBlock(t, token.scope()))
+	assert.Equal(t, uint64(11), checkpoints.safeBlock(t, other.scope()))
+	assert.NotEqual(t, token.scope(), other.scope())
+}
+
+func TestLoadCheckpoint_StartsFromConfig(t *testing.T) {
+	chain := newFakeChain(20)
+
+	w, _ := setupTestWatcher(t, chain, newMemoryCheckpointStore(), 2)
+	assert.Equal(t, uint64(20), w.lastIndexed, "0 = latest")
+
+	w, _ = newTestWatcher(t, chain, newMemoryCheckpointStore(), config.ChainConfig{StartBlock: 5}, common.Address{})
+	require.NoError(t, w.loadCheckpoint(context.Background()))
+	assert.Equal(t, uint64(4), w.lastSafe)
+	assert.Equal(t, uint64(4), w.lastIndexed)
+}
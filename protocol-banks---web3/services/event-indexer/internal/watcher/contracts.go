@@ -0,0 +1,91 @@
+package watcher
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/protocol-bank/event-indexer/internal/config"
+)
+
+// ContractABI 注册合约的 ABI，只解码关注的事件
+type ContractABI struct {
+	ChainID  uint64
+	Name     string
+	Address  common.Address
+	contract *bind.BoundContract       // 与 abigen 生成的绑定相同，用于 UnpackLogIntoMap
+	events   map[common.Hash]abi.Event // topic0 → 关注的事件
+}
+
+// NewContractABI 解析注册合约的 ABI，关注的事件不在 ABI 中时返回错误
+func NewContractABI(cfg config.ContractABIConfig) (*ContractABI, error) {
+	if !common.IsHexAddress(cfg.Address) {
+		return nil, fmt.Errorf("invalid contract address %q", cfg.Address)
+	}
+	address := common.HexToAddress(cfg.Address)
+
+	meta := &bind.MetaData{ABI: cfg.ABI}
+	parsed, err := meta.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("contract %s: failed to parse ABI: %w", address.Hex(), err)
+	}
+
+	events := make(map[common.Hash]abi.Event)
+	if len(cfg.Events) == 0 {
+		for _, event := range parsed.Events {
+			if !event.Anonymous {
+				events[event.ID] = event
+			}
+		}
+	}
+	for _, name := range cfg.Events {
+		event, ok := parsed.Events[name]
+		if !ok {
+			return nil, fmt.Errorf("contract %s: event %s not in ABI", address.Hex(), name)
+		}
+		if event.Anonymous {
+			return nil, fmt.Errorf("contract %s: anonymous event %s cannot be matched", address.Hex(), name)
+		}
+		events[event.ID] = event
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("contract %s: no events to index", address.Hex())
+	}
+
+	return &ContractABI{
+		ChainID:  cfg.ChainID,
+		Name:     cfg.Name,
+		Address:  address,
+		contract: bind.NewBoundContract(address, *parsed, nil, nil, nil),
+		events:   events,
+	}, nil
+}
+
+// topics 关注事件的 topic0，用于 eth_getLogs 过滤
+func (c *ContractABI) topics() []common.Hash {
+	topics := make([]common.Hash, 0, len(c.events))
+	for id := range c.events {
+		topics = append(topics, id)
+	}
+	return topics
+}
+
+// Event 日志对应的关注事件
+func (c *ContractABI) Event(vLog types.Log) (abi.Event, bool) {
+	if vLog.Address != c.Address || len(vLog.Topics) == 0 {
+		return abi.Event{}, false
+	}
+	event, ok := c.events[vLog.Topics[0]]
+	return event, ok
+}
+
+// Decode 解码关注事件的参数：indexed 参数取自 topics，其余取自 data
+func (c *ContractABI) Decode(event abi.Event, vLog types.Log) ([]EventField, error) {
+	values := make(map[string]interface{})
+	if err := c.contract.UnpackLogIntoMap(values, event.Name, vLog); err != nil {
+		return nil, fmt.Errorf("failed to unpack %s: %w", event.Name, err)
+	}
+	return eventFields(event, values), nil
+}
@@ -0,0 +1,141 @@
+package watcher
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/protocol-bank/event-indexer/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// oracleManagerABI OracleManager 的部分 ABI
+const oracleManagerABI = `[
+	{"type":"event","name":"PricesSubmitted","inputs":[
+		{"name":"batchId","type":"uint256","indexed":false},
+		{"name":"aggregatedPrice","type":"uint256","indexed":false},
+		{"name":"nodeCount","type":"uint256","indexed":false},
+		{"name":"signatoryRecordHash","type":"bytes32","indexed":false}],"anonymous":false},
+	{"type":"event","name":"OperatorRegistered","inputs":[
+		{"name":"operator","type":"address","indexed":true},
+		{"name":"nodeUrl","type":"string","indexed":false}],"anonymous":false}
+]`
+
+var oracleManagerAddr = common.HexToAddress("0x4444444444444444444444444444444444444444")
+
+func newOracleManagerABI(t *testing.T, events ...string) *ContractABI {
+	t.Helper()
+	contractABI, err := NewContractABI(config.ContractABIConfig{
+		ChainID: 1,
+		Address: oracleManagerAddr.Hex(),
+		Name:    "OracleManager",
+		ABI:     oracleManagerABI,
+		Events:  events,
+	})
+	require.NoError(t, err)
+	return contractABI
+}
+
+// oracleManagerLog 按 ABI 编码事件日志
+func oracleManagerLog(t *testing.T, name string, blockHash common.Hash, topics []common.Hash, args ...interface{}) types.Log {
+	t.Helper()
+	parsed, err := abi.JSON(strings.NewReader(oracleManagerABI))
+	require.NoError(t, err)
+	event := parsed.Events[name]
+	data, err := event.Inputs.NonIndexed().Pack(args...)
+	require.NoError(t, err)
+
+	return types.Log{
+		Address:     oracleManagerAddr,
+		Topics:      append([]common.Hash{event.ID}, topics...),
+		Data:        data,
+		BlockNumber: 1,
+		BlockHash:   blockHash,
+		TxHash:      txHash(9),
+	}
+}
+
+func TestContractABI_DecodesPricesSubmitted(t *testing.T) {
+	contractABI := newOracleManagerABI(t, "PricesSubmitted")
+	recordHash := common.HexToHash("0xabababababababababababababababababababababababababababababababab")
+	vLog := oracleManagerLog(t, "PricesSubmitted", common.Hash{}, nil,
+		big.NewInt(7), big.NewInt(200_000_000_000), big.NewInt(5), [32]byte(recordHash))
+
+	event, ok := contractABI.Event(vLog)
+	require.True(t, ok)
+	assert.Equal(t, "PricesSubmitted", event.Name)
+
+	fields, err := contractABI.Decode(event, vLog)
+	require.NoError(t, err)
+	assert.Equal(t, []EventField{
+		{Name: "batchId", Type: "uint256", Value: "7"},
+		{Name: "aggregatedPrice", Type: "uint256", Value: "200000000000"},
+		{Name: "nodeCount", Type: "uint256", Value: "5"},
+		{Name: "signatoryRecordHash", Type: "bytes32", Value: recordHash.Hex()},
+	}, fields)
+
+	// 未关注的事件与其他合约的日志不匹配
+	operator := oracleManagerLog(t, "OperatorRegistered", common.Hash{}, []common.Hash{common.BytesToHash(watchedAddr.Bytes())}, "https://node")
+	_, ok = contractABI.Event(operator)
+	assert.False(t, ok)
+	vLog.Address = tokenAddr
+	_, ok = contractABI.Event(vLog)
+	assert.False(t, ok)
+}
+
+func TestNewContractABI_Validates(t *testing.T) {
+	// 未指定事件时关注全部事件
+	contractABI := newOracleManagerABI(t)
+	assert.Len(t, contractABI.topics(), 2)
+
+	for name, cfg := range map[string]config.ContractABIConfig{
+		"unknown event":   {Address: oracleManagerAddr.Hex(), ABI: oracleManagerABI, Events: []string{"Missing"}},
+		"invalid address": {Address: "oracle", ABI: oracleManagerABI},
+		"invalid abi":     {Address: oracleManagerAddr.Hex(), ABI: "{"},
+		"no events":       {Address: oracleManagerAddr.Hex(), ABI: "[]"},
+	} {
+		_, err := NewContractABI(cfg)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestBlockEvents_DecodesRegisteredContract(t *testing.T) {
+	chain := newFakeChain(0)
+	block := chain.mine(0)
+	operator := common.BytesToHash(otherAddr.Bytes())
+	chain.logs[block] = append(chain.logs[block],
+		oracleManagerLog(t, "PricesSubmitted", block, nil, big.NewInt(7), big.NewInt(100), big.NewInt(3), [32]byte{}),
+		oracleManagerLog(t, "OperatorRegistered", block, []common.Hash{operator}, "https://node"),
+	)
+
+	// 注册合约的事件与监听地址无关
+	w, _ := newTestWatcher(t, chain, newMemoryCheckpointStore(), config.ChainConfig{}, oracleManagerAddr)
+	w.RemoveAddress(watchedAddr)
+	w.transfers = false
+	w.contractABI = newOracleManagerABI(t, "PricesSubmitted", "OperatorRegistered")
+
+	events, err := w.blockEvents(context.Background(), block)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	prices := events[0]
+	assert.Equal(t, "PricesSubmitted", prices.EventName)
+	assert.Equal(t, "contract", prices.EventType)
+	assert.Equal(t, oracleManagerAddr.Hex(), prices.TokenAddress)
+	assert.Equal(t, "7", prices.Fields[0].Value)
+	assert.Equal(t, hexutil.Encode(chain.logs[block][0].Data), prices.Data)
+
+	registered := events[1]
+	assert.Equal(t, "OperatorRegistered", registered.EventName)
+	assert.Equal(t, []EventField{
+		{Name: "operator", Type: "address", Value: otherAddr.Hex(), Indexed: true},
+		{Name: "nodeUrl", Type: "string", Value: "https://node"},
+	}, registered.Fields)
+	assert.Equal(t, operator.Hex(), registered.Topics[1])
+}
@@ -0,0 +1,178 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// defaultPollInterval 未配置轮询间隔时使用
+	defaultPollInterval = 12 * time.Second
+	// defaultHeadTimeout 未配置新块超时时使用
+	defaultHeadTimeout = 60 * time.Second
+	// maxReconnectDelay WebSocket 重连的最大退避间隔
+	maxReconnectDelay = 5 * time.Minute
+)
+
+// errHeadTimeout 订阅仍在但超时未收到新块（部分节点会静默丢弃订阅）
+var errHeadTimeout = errors.New("no new block within head timeout")
+
+// HeadSubscriber WebSocket 新块订阅（*ethclient.Client 实现该接口）
+type HeadSubscriber interface {
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+	Close()
+}
+
+// HeadDialer 建立 WebSocket 连接
+type HeadDialer func(ctx context.Context) (HeadSubscriber, error)
+
+// wsDialer 连接 WebSocket 节点
+func wsDialer(url string) HeadDialer {
+	return func(ctx context.Context) (HeadSubscriber, error) {
+		client, err := ethclient.DialContext(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	}
+}
+
+// watchHeads 通过 WebSocket 订阅新块触发同步
+// 连接断开或超时未收到新块时回退为轮询，并按指数退避重连。同步总是从已索引进度继续，
+// 不依赖收到的块头，因此切换前后不会漏块或重复推送。
+func (w *ChainWatcher) watchHeads(ctx context.Context) {
+	delay := w.pollInterval()
+	for {
+		healthy, err := w.subscribeHeads(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if healthy {
+			delay = w.pollInterval()
+		}
+		w.enterFallback(err, delay)
+
+		// 回退期间轮询，到期后重连
+		if !w.pollFor(ctx, delay) {
+			return
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+// subscribeHeads 建立订阅并在每个新块时同步，直到订阅失效
+// healthy 表示本次订阅期间收到过新块，用于重置重连退避。
+func (w *ChainWatcher) subscribeHeads(ctx context.Context) (healthy bool, err error) {
+	client, err := w.dialHeads(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to WebSocket: %w", err)
+	}
+	defer client.Close()
+
+	headers := make(chan *types.Header)
+	sub, err := client.SubscribeNewHead(ctx, headers)
+	if err != nil {
+		return false, fmt.Errorf("failed to subscribe to new blocks: %w", err)
+	}
+	defer sub.Unsubscribe()
+
+	w.leaveFallback()
+
+	// 先追上断线或回退期间产生的块
+	w.syncOrLog(ctx)
+
+	timeout := w.cfg.HeadTimeout
+	if timeout <= 0 {
+		timeout = defaultHeadTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return healthy, nil
+		case err := <-sub.Err():
+			if err == nil {
+				err = errors.New("subscription closed")
+			}
+			return healthy, fmt.Errorf("subscription error: %w", err)
+		case <-timer.C:
+			return healthy, errHeadTimeout
+		case <-headers:
+			healthy = true
+			w.syncOrLog(ctx)
+
+			// 同步耗时不计入新块超时
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(timeout)
+		}
+	}
+}
+
+// pollFor 在 d 时间内轮询同步，ctx 取消时返回 false
+func (w *ChainWatcher) pollFor(ctx context.Context, d time.Duration) bool {
+	deadline := time.NewTimer(d)
+	defer deadline.Stop()
+	ticker := time.NewTicker(w.pollInterval())
+	defer ticker.Stop()
+
+	w.syncOrLog(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-deadline.C:
+			return true
+		case <-ticker.C:
+			w.syncOrLog(ctx)
+		}
+	}
+}
+
+// enterFallback 订阅失效，切换为轮询
+func (w *ChainWatcher) enterFallback(err error, retryIn time.Duration) {
+	logger := log.With().Str("chain", w.chainName).Str("contract", w.scope()).Err(err).Dur("retry_in", retryIn).Logger()
+	if w.fallback {
+		logger.Debug().Msg("WebSocket reconnect failed, still polling")
+		return
+	}
+
+	reason := "error"
+	if errors.Is(err, errHeadTimeout) {
+		reason = "timeout"
+	}
+	chainID := strconv.FormatUint(w.chainID, 10)
+	HeadFallbackTotal.WithLabelValues(chainID, w.scope(), reason).Inc()
+	PollingFallback.WithLabelValues(chainID, w.scope()).Set(1)
+	w.fallback = true
+
+	logger.Warn().Str("reason", reason).Msg("WebSocket head subscription unhealthy, falling back to polling")
+}
+
+// leaveFallback 订阅恢复，停止回退轮询
+func (w *ChainWatcher) leaveFallback() {
+	if !w.fallback {
+		return
+	}
+
+	chainID := strconv.FormatUint(w.chainID, 10)
+	HeadReconnectTotal.WithLabelValues(chainID, w.scope()).Inc()
+	PollingFallback.WithLabelValues(chainID, w.scope()).Set(0)
+	w.fallback = false
+
+	log.Info().Str("chain", w.chainName).Str("contract", w.scope()).Msg("WebSocket head subscription restored")
+}
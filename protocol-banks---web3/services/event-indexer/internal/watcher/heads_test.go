@@ -0,0 +1,191 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/protocol-bank/event-indexer/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNode 模拟 WebSocket 节点：可停止推送新块（静默失效）、断开订阅或拒绝连接
+type fakeNode struct {
+	mu    sync.Mutex
+	down  bool
+	dials int
+	sub   *fakeSubscription
+}
+
+type fakeSubscription struct {
+	headers chan<- *types.Header
+	errc    chan error
+	once    sync.Once
+}
+
+func (s *fakeSubscription) Err() <-chan error { return s.errc }
+
+func (s *fakeSubscription) Unsubscribe() { s.once.Do(func() { close(s.errc) }) }
+
+func (n *fakeNode) dial(ctx context.Context) (HeadSubscriber, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.dials++
+	if n.down {
+		return nil, errors.New("connection refused")
+	}
+	return n, nil
+}
+
+func (n *fakeNode) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sub = &fakeSubscription{headers: ch, errc: make(chan error, 1)}
+	return n.sub, nil
+}
+
+func (n *fakeNode) Close() {}
+
+func (n *fakeNode) setDown(down bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.down = down
+}
+
+func (n *fakeNode) dialCount() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.dials
+}
+
+// announce 向当前订阅推送新块
+func (n *fakeNode) announce(t *testing.T) {
+	t.Helper()
+	n.mu.Lock()
+	sub := n.sub
+	n.mu.Unlock()
+	require.NotNil(t, sub)
+
+	select {
+	case sub.headers <- &types.Header{}:
+	case <-time.After(time.Second):
+		t.Fatal("subscription is not being read")
+	}
+}
+
+// drop 断开当前订阅
+func (n *fakeNode) drop() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sub.errc <- errors.New("websocket: close 1006")
+}
+
+// startHeadWatcher 以很短的超时启动 WebSocket 监听
+func startHeadWatcher(t *testing.T, chain *fakeChain, node *fakeNode) *eventRecorder {
+	t.Helper()
+
+	cfg := config.ChainConfig{Confirmations: 100, PollInterval: 10 * time.Millisecond, HeadTimeout: 100 * time.Millisecond}
+	w, recorder := newTestWatcher(t, chain, newMemoryCheckpointStore(), cfg, common.Address{})
+	require.NoError(t, w.loadCheckpoint(context.Background()))
+	w.dialHeads = node.dial
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.watchHeads(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	require.Eventually(t, func() bool { return node.dialCount() > 0 }, time.Second, 5*time.Millisecond)
+	return recorder
+}
+
+// waitForTx 等待交易被推送，返回期间收到的全部事件
+func waitForTx(t *testing.T, recorder *eventRecorder, tx common.Hash) []ChainEvent {
+	t.Helper()
+
+	var events []ChainEvent
+	require.Eventually(t, func() bool {
+		events = append(events, recorder.take()...)
+		for _, e := range events {
+			if e.TxHash == tx.Hex() {
+				return true
+			}
+		}
+		return false
+	}, 2*time.Second, 5*time.Millisecond)
+	return events
+}
+
+func TestWatchHeads_FallsBackOnSilentStallWithoutGaps(t *testing.T) {
+	chain := newFakeChain(10)
+	node := &fakeNode{}
+	recorder := startHeadWatcher(t, chain, node)
+
+	fallbacks := testutil.ToFloat64(HeadFallbackTotal.WithLabelValues("1", "all", "timeout"))
+	reconnects := testutil.ToFloat64(HeadReconnectTotal.WithLabelValues("1", "all"))
+
+	chain.mine(0, txHash(1))
+	node.announce(t)
+	events := waitForTx(t, recorder, txHash(1))
+
+	// 节点停止推送新块且无法重连：超时后轮询
+	node.setDown(true)
+	chain.mine(0, txHash(2))
+	chain.mine(0)
+	events = append(events, waitForTx(t, recorder, txHash(2))...)
+	assert.Equal(t, fallbacks+1, testutil.ToFloat64(HeadFallbackTotal.WithLabelValues("1", "all", "timeout")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(PollingFallback.WithLabelValues("1", "all")))
+
+	// 回退期间出的块在恢复订阅前已索引
+	chain.mine(0, txHash(3))
+	events = append(events, waitForTx(t, recorder, txHash(3))...)
+
+	node.setDown(false)
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(PollingFallback.WithLabelValues("1", "all")) == 0
+	}, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, reconnects+1, testutil.ToFloat64(HeadReconnectTotal.WithLabelValues("1", "all")))
+
+	chain.mine(0, txHash(4))
+	node.announce(t)
+	events = append(events, waitForTx(t, recorder, txHash(4))...)
+
+	counts := countEvents(events)
+	for i := byte(1); i <= 4; i++ {
+		assert.Equal(t, 1, counts[eventKey{txHash(i).Hex(), false, false}], "tx %d", i)
+	}
+	assert.Len(t, counts, 4)
+}
+
+func TestWatchHeads_FallsBackWhenSubscriptionDrops(t *testing.T) {
+	chain := newFakeChain(10)
+	node := &fakeNode{}
+	recorder := startHeadWatcher(t, chain, node)
+
+	fallbacks := testutil.ToFloat64(HeadFallbackTotal.WithLabelValues("1", "all", "error"))
+
+	node.setDown(true)
+	node.drop()
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(HeadFallbackTotal.WithLabelValues("1", "all", "error")) == fallbacks+1
+	}, time.Second, 5*time.Millisecond)
+
+	// 不等新块超时，立即开始轮询
+	chain.mine(0, txHash(1))
+	waitForTx(t, recorder, txHash(1))
+
+	// 按退避间隔重连
+	assert.Eventually(t, func() bool { return node.dialCount() > 2 }, time.Second, 5*time.Millisecond)
+}
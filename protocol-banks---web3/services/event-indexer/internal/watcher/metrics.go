@@ -0,0 +1,38 @@
+package watcher
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// HeadFallbackTotal WebSocket 新块订阅失效、回退为轮询的次数
+	HeadFallbackTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "indexer_head_fallback_total",
+		Help: "Times the WebSocket head subscription failed and the watcher fell back to polling",
+	}, []string{"chain_id", "contract", "reason"})
+
+	// HeadReconnectTotal WebSocket 新块订阅恢复的次数
+	HeadReconnectTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "indexer_head_reconnect_total",
+		Help: "Times the WebSocket head subscription was restored after a fallback",
+	}, []string{"chain_id", "contract"})
+
+	// PollingFallback 当前是否处于轮询回退状态（1 = 回退中）
+	PollingFallback = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "indexer_polling_fallback",
+		Help: "Whether the watcher is polling because its WebSocket head subscription is unhealthy",
+	}, []string{"chain_id", "contract"})
+
+	// BlocksProcessedTotal 已索引的区块数（重组后重新索引的块会重复计数）
+	BlocksProcessedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "indexer_blocks_processed_total",
+		Help: "Total number of blocks indexed",
+	}, []string{"chain_id", "contract"})
+
+	// BlockLag 链头与最新已索引块的差距
+	BlockLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "indexer_block_lag",
+		Help: "Number of blocks the indexer is behind the chain head",
+	}, []string{"chain_id", "contract"})
+)
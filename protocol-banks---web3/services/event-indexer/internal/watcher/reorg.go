@@ -0,0 +1,228 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+)
+
+// checkpointEvery 追块时每索引多少个块保存一次进度
+const checkpointEvery = 100
+
+// errReorg 新块的父哈希与已索引的上一块不一致
+var errReorg = errors.New("chain reorganization detected")
+
+// indexedBlock 已索引、尚未达到确认深度的块
+type indexedBlock struct {
+	hash   common.Hash
+	events []*ChainEvent
+}
+
+// sync 同步到链头：检测重组并回滚，索引新块，推进已达确认深度的安全块
+// 每批（追块时每 checkpointEvery 个块，以及同步结束时）保存一次进度。
+func (w *ChainWatcher) sync(ctx context.Context) error {
+	w.syncMu.Lock()
+	defer w.syncMu.Unlock()
+
+	head, err := w.client.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get block number: %w", err)
+	}
+
+	if err := w.checkTip(ctx); err != nil {
+		return err
+	}
+	defer w.reportLag(head)
+
+	batch := 0
+	for n := w.lastIndexed + 1; n <= head; n++ {
+		err := w.indexBlock(ctx, n)
+		if errors.Is(err, errReorg) {
+			indexed := w.lastIndexed
+			if err := w.rollback(ctx); err != nil {
+				return err
+			}
+			// 节点数据尚不一致，等下一轮再同步
+			if w.lastIndexed == indexed {
+				return fmt.Errorf("block %d: %w", n, err)
+			}
+			// 从共同祖先之后重新索引
+			n = w.lastIndexed
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if batch++; batch == checkpointEvery {
+			batch = 0
+			w.reportLag(head)
+			w.confirm(head)
+			if err := w.saveCheckpoint(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	w.confirm(head)
+	return w.saveCheckpoint(ctx)
+}
+
+// checkTip 最新已索引块的哈希变化（或块已不存在）说明发生了重组
+func (w *ChainWatcher) checkTip(ctx context.Context) error {
+	tip, ok := w.unsafe[w.lastIndexed]
+	if !ok {
+		return nil
+	}
+
+	header, err := w.client.HeaderByNumber(ctx, new(big.Int).SetUint64(w.lastIndexed))
+	if err != nil && !errors.Is(err, ethereum.NotFound) {
+		return fmt.Errorf("failed to get block %d: %w", w.lastIndexed, err)
+	}
+	if err == nil && header.Hash() == tip.hash {
+		return nil
+	}
+
+	return w.rollback(ctx)
+}
+
+// indexBlock 索引单个区块并推送未确认事件
+func (w *ChainWatcher) indexBlock(ctx context.Context, number uint64) error {
+	header, err := w.client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return fmt.Errorf("failed to get block %d: %w", number, err)
+	}
+
+	if parent, ok := w.unsafe[number-1]; ok && header.ParentHash != parent.hash {
+		return errReorg
+	}
+
+	hash := header.Hash()
+	events, err := w.blockEvents(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("failed to index block %d: %w", number, err)
+	}
+
+	w.unsafe[number] = &indexedBlock{hash: hash, events: events}
+	w.lastIndexed = number
+	w.dirty = true
+	BlocksProcessedTotal.WithLabelValues(strconv.FormatUint(w.chainID, 10), w.scope()).Inc()
+
+	w.dispatch(events)
+	return nil
+}
+
+// reportLag 上报与链头的差距
+func (w *ChainWatcher) reportLag(head uint64) {
+	var lag uint64
+	if head > w.lastIndexed {
+		lag = head - w.lastIndexed
+	}
+	BlockLag.WithLabelValues(strconv.FormatUint(w.chainID, 10), w.scope()).Set(float64(lag))
+}
+
+// rollback 从最新已索引块向前查找与链上一致的共同祖先，撤销其后的块
+// 被撤销块内已推送的事件以 Removed 重新推送，随后由 sync 从祖先之后重新索引。
+func (w *ChainWatcher) rollback(ctx context.Context) error {
+	ancestor := w.lastSafe
+	for n := w.lastIndexed; n > w.lastSafe; n-- {
+		block, ok := w.unsafe[n]
+		if !ok {
+			continue
+		}
+
+		header, err := w.client.HeaderByNumber(ctx, new(big.Int).SetUint64(n))
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return fmt.Errorf("failed to get block %d: %w", n, err)
+		}
+		if err == nil && header.Hash() == block.hash {
+			ancestor = n
+			break
+		}
+	}
+
+	if ancestor == w.lastSafe {
+		w.checkSafeBlock(ctx)
+	}
+
+	log.Warn().
+		Str("chain", w.chainName).
+		Uint64("from", ancestor+1).
+		Uint64("to", w.lastIndexed).
+		Msg("Chain reorganization detected, rolling back")
+
+	for n := w.lastIndexed; n > ancestor; n-- {
+		block, ok := w.unsafe[n]
+		if !ok {
+			continue
+		}
+		delete(w.unsafe, n)
+
+		removed := make([]*ChainEvent, 0, len(block.events))
+		for _, event := range block.events {
+			e := *event
+			e.Removed = true
+			removed = append(removed, &e)
+		}
+		w.dispatch(removed)
+	}
+
+	w.lastIndexed = ancestor
+	w.dirty = true
+	return nil
+}
+
+// checkSafeBlock 重组越过确认深度时无法回滚已确认事件，只能告警
+func (w *ChainWatcher) checkSafeBlock(ctx context.Context) {
+	if w.safeHash == (common.Hash{}) {
+		return
+	}
+
+	header, err := w.client.HeaderByNumber(ctx, new(big.Int).SetUint64(w.lastSafe))
+	if err == nil && header.Hash() == w.safeHash {
+		return
+	}
+
+	log.Error().
+		Str("chain", w.chainName).
+		Uint64("safe_block", w.lastSafe).
+		Uint64("confirmations", w.cfg.Confirmations).
+		Msg("Reorg deeper than confirmation depth, confirmed events may be invalid")
+}
+
+// confirm 推进达到确认深度的块：推送已确认事件，移出未确认窗口
+func (w *ChainWatcher) confirm(head uint64) {
+	if head < w.cfg.Confirmations {
+		return
+	}
+	safe := min(head-w.cfg.Confirmations, w.lastIndexed)
+	if safe <= w.lastSafe {
+		return
+	}
+
+	for n := w.lastSafe + 1; n <= safe; n++ {
+		block, ok := w.unsafe[n]
+		if !ok {
+			continue
+		}
+		delete(w.unsafe, n)
+
+		confirmed := make([]*ChainEvent, 0, len(block.events))
+		for _, event := range block.events {
+			e := *event
+			e.Confirmed = true
+			confirmed = append(confirmed, &e)
+		}
+		w.dispatch(confirmed)
+		w.safeHash = block.hash
+	}
+
+	w.lastSafe = safe
+	w.dirty = true
+}
@@ -0,0 +1,316 @@
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/protocol-bank/event-indexer/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	watchedAddr = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	otherAddr   = common.HexToAddress("0x2222222222222222222222222222222222222222")
+	tokenAddr   = common.HexToAddress("0x3333333333333333333333333333333333333333")
+)
+
+// fakeChain 内存链，支持截断后在另一分叉上出块以模拟重组
+type fakeChain struct {
+	mu      sync.Mutex
+	headers []*types.Header // 下标即块高
+	logs    map[common.Hash][]types.Log
+}
+
+func newFakeChain(height uint64) *fakeChain {
+	c := &fakeChain{logs: make(map[common.Hash][]types.Log)}
+	c.headers = []*types.Header{{Number: big.NewInt(0)}}
+	for i := uint64(0); i < height; i++ {
+		c.mine(0)
+	}
+	return c
+}
+
+// mine 在当前链头之后出一个块，fork 区分不同分叉上同一高度的块
+func (c *fakeChain) mine(fork byte, txs ...common.Hash) common.Hash {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	parent := c.headers[len(c.headers)-1]
+	header := &types.Header{
+		Number:     big.NewInt(int64(len(c.headers))),
+		ParentHash: parent.Hash(),
+		Extra:      []byte{fork},
+	}
+	c.headers = append(c.headers, header)
+
+	hash := header.Hash()
+	for _, tx := range txs {
+		c.logs[hash] = append(c.logs[hash], types.Log{
+			Address:     tokenAddr,
+			Topics:      []common.Hash{transferEventSig, common.BytesToHash(otherAddr.Bytes()), common.BytesToHash(watchedAddr.Bytes())},
+			Data:        common.LeftPadBytes(big.NewInt(100).Bytes(), 32),
+			BlockNumber: header.Number.Uint64(),
+			BlockHash:   hash,
+			TxHash:      tx,
+		})
+	}
+	return hash
+}
+
+// rewind 丢弃 height 之后的块
+func (c *fakeChain) rewind(height uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.headers = c.headers[:height+1]
+}
+
+func (c *fakeChain) BlockNumber(ctx context.Context) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return uint64(len(c.headers) - 1), nil
+}
+
+func (c *fakeChain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if number.Uint64() >= uint64(len(c.headers)) {
+		return nil, ethereum.NotFound
+	}
+	return types.CopyHeader(c.headers[number.Uint64()]), nil
+}
+
+func (c *fakeChain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 按区块哈希或块高范围查询
+	var hashes []common.Hash
+	if q.BlockHash != nil {
+		hashes = append(hashes, *q.BlockHash)
+	} else {
+		for n := q.FromBlock.Uint64(); n <= q.ToBlock.Uint64() && n < uint64(len(c.headers)); n++ {
+			hashes = append(hashes, c.headers[n].Hash())
+		}
+	}
+
+	var logs []types.Log
+	for _, hash := range hashes {
+		for _, l := range c.logs[hash] {
+			if len(q.Addresses) == 0 || l.Address == q.Addresses[0] {
+				logs = append(logs, l)
+			}
+		}
+	}
+	return logs, nil
+}
+
+// memoryCheckpointStore 内存进度存储，经 JSON 往返以模拟持久化
+type memoryCheckpointStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func newMemoryCheckpointStore() *memoryCheckpointStore {
+	return &memoryCheckpointStore{data: make(map[string][]byte)}
+}
+
+func (s *memoryCheckpointStore) LoadCheckpoint(ctx context.Context, chainID uint64, contract string) (*Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.data[checkpointKey(chainID, contract)]
+	if !ok {
+		return nil, nil
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+func (s *memoryCheckpointStore) SaveCheckpoint(ctx context.Context, chainID uint64, contract string, cp *Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[checkpointKey(chainID, contract)] = data
+	return nil
+}
+
+func (s *memoryCheckpointStore) DeleteCheckpoint(ctx context.Context, chainID uint64, contract string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, checkpointKey(chainID, contract))
+	return nil
+}
+
+// safeBlock 已保存的最后安全块
+func (s *memoryCheckpointStore) safeBlock(t *testing.T, contract string) uint64 {
+	t.Helper()
+	cp, err := s.LoadCheckpoint(context.Background(), 1, contract)
+	require.NoError(t, err)
+	require.NotNil(t, cp)
+	return cp.SafeBlock
+}
+
+// eventRecorder 按顺序记录推送的事件
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []ChainEvent
+}
+
+func (r *eventRecorder) handle(event *ChainEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, *event)
+}
+
+func (r *eventRecorder) take() []ChainEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := r.events
+	r.events = nil
+	return events
+}
+
+// newTestWatcher 创建监听器（尚未加载进度）
+func newTestWatcher(t *testing.T, chain *fakeChain, checkpoints CheckpointStore, cfg config.ChainConfig, contract common.Address) (*ChainWatcher, *eventRecorder) {
+	t.Helper()
+
+	parsedABI, err := abi.JSON(strings.NewReader(erc20ABI))
+	require.NoError(t, err)
+
+	cfg.ChainID, cfg.Name = 1, "Ethereum"
+	w := newChainWatcherWithClient(cfg, chain, contract, parsedABI, checkpoints)
+	w.AddAddress(watchedAddr)
+
+	recorder := &eventRecorder{}
+	w.handlers = append(w.handlers, recorder.handle)
+	return w, recorder
+}
+
+func setupTestWatcher(t *testing.T, chain *fakeChain, checkpoints CheckpointStore, confirmations uint64) (*ChainWatcher, *eventRecorder) {
+	t.Helper()
+
+	w, recorder := newTestWatcher(t, chain, checkpoints, config.ChainConfig{Confirmations: confirmations}, common.Address{})
+	require.NoError(t, w.loadCheckpoint(context.Background()))
+	return w, recorder
+}
+
+func txHash(n byte) common.Hash {
+	return common.BytesToHash([]byte{n})
+}
+
+func TestSync_ConfirmsAfterDepth(t *testing.T) {
+	ctx := context.Background()
+	chain := newFakeChain(10)
+	checkpoints := newMemoryCheckpointStore()
+	w, recorder := setupTestWatcher(t, chain, checkpoints, 3)
+
+	chain.mine(0, txHash(1)) // 11
+	require.NoError(t, w.sync(ctx))
+
+	events := recorder.take()
+	require.Len(t, events, 1)
+	assert.Equal(t, txHash(1).Hex(), events[0].TxHash)
+	assert.Equal(t, uint64(11), events[0].BlockNumber)
+	assert.False(t, events[0].Confirmed)
+	assert.Equal(t, "Transfer", events[0].EventName)
+	assert.Equal(t, []EventField{
+		{Name: "from", Type: "address", Value: otherAddr.Hex(), Indexed: true},
+		{Name: "to", Type: "address", Value: watchedAddr.Hex(), Indexed: true},
+		{Name: "value", Type: "uint256", Value: "100"},
+	}, events[0].Fields)
+
+	// Not yet deep enough
+	chain.mine(0)
+	chain.mine(0) // 13
+	require.NoError(t, w.sync(ctx))
+	assert.Empty(t, recorder.take())
+
+	chain.mine(0) // 14: block 11 has 3 confirmations
+	require.NoError(t, w.sync(ctx))
+	events = recorder.take()
+	require.Len(t, events, 1)
+	assert.True(t, events[0].Confirmed)
+	assert.Equal(t, txHash(1).Hex(), events[0].TxHash)
+
+	assert.Equal(t, uint64(11), checkpoints.safeBlock(t, "all"))
+	assert.Equal(t, float64(0), testutil.ToFloat64(BlockLag.WithLabelValues("1", "all")))
+}
+
+func TestSync_RollsBackAndReindexesOnReorg(t *testing.T) {
+	ctx := context.Background()
+	chain := newFakeChain(10)
+	checkpoints := newMemoryCheckpointStore()
+	w, recorder := setupTestWatcher(t, chain, checkpoints, 3)
+
+	chain.mine(0)            // 11
+	chain.mine(0, txHash(1)) // 12: orphaned below
+	chain.mine(0)            // 13
+	require.NoError(t, w.sync(ctx))
+	require.Len(t, recorder.take(), 1)
+
+	// Reorg: blocks 12-13 replaced, tx 2 lands in the new block 13
+	chain.rewind(11)
+	chain.mine(1)
+	chain.mine(1, txHash(2))
+	chain.mine(1) // 14
+	require.NoError(t, w.sync(ctx))
+
+	events := recorder.take()
+	require.Len(t, events, 2)
+	assert.Equal(t, txHash(1).Hex(), events[0].TxHash)
+	assert.True(t, events[0].Removed)
+	assert.False(t, events[0].Confirmed)
+
+	assert.Equal(t, txHash(2).Hex(), events[1].TxHash)
+	assert.Equal(t, uint64(13), events[1].BlockNumber)
+	assert.False(t, events[1].Removed)
+	assert.False(t, events[1].Confirmed)
+
+	// Block 11 reached depth and was kept
+	assert.Equal(t, uint64(11), w.lastSafe)
+}
+
+func TestSync_OrphanedEventIsNeverConfirmed(t *testing.T) {
+	ctx := context.Background()
+	chain := newFakeChain(10)
+	checkpoints := newMemoryCheckpointStore()
+	w, recorder := setupTestWatcher(t, chain, checkpoints, 2)
+
+	chain.mine(0, txHash(1)) // 11
+	require.NoError(t, w.sync(ctx))
+
+	// Shorter competing chain replaces block 11 before it is confirmed
+	chain.rewind(10)
+	chain.mine(1, txHash(2)) // 11'
+	require.NoError(t, w.sync(ctx))
+
+	for i := 0; i < 3; i++ {
+		chain.mine(1)
+		require.NoError(t, w.sync(ctx))
+	}
+
+	var confirmed []string
+	for _, event := range recorder.take() {
+		if event.Confirmed {
+			confirmed = append(confirmed, event.TxHash)
+		}
+	}
+	assert.Equal(t, []string{txHash(2).Hex()}, confirmed)
+
+	assert.Equal(t, uint64(12), checkpoints.safeBlock(t, "all"))
+}
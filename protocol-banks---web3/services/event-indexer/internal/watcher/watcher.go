@@ -11,10 +11,12 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/protocol-bank/event-indexer/internal/config"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
 )
 
 // ERC20 Transfer event signature
@@ -28,43 +30,82 @@ type ChainEvent struct {
 	ChainID      uint64
 	ChainName    string
 	EventType    string
+	EventName    string       // ABI 事件名，如 Transfer
+	Fields       []EventField // 解码后的事件参数
 	TxHash       string
+	TxIndex      uint
+	LogIndex     uint
 	BlockNumber  uint64
+	BlockHash    string
+	Topics       []string
+	Data         string
 	FromAddress  string
 	ToAddress    string
 	Value        string
 	TokenAddress string
 	TokenSymbol  string
 	Timestamp    time.Time
-	Confirmed    bool
+	Confirmed    bool // 所在块已达到确认深度
+	Removed      bool // 所在块已被重组移除，撤销此前推送的未确认事件
+}
+
+// EventField 解码后的事件参数
+type EventField struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Value   string `json:"value"`
+	Indexed bool   `json:"indexed"`
 }
 
 // EventHandler 事件处理回调
 type EventHandler func(event *ChainEvent)
 
+// ChainClient 监听所需的链上查询（*ethclient.Client 实现该接口）
+type ChainClient interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+}
+
 // ChainWatcher 单链监听器
 type ChainWatcher struct {
-	chainID   uint64
-	chainName string
-	client    *ethclient.Client
-	wsClient  *ethclient.Client
-	cfg       config.ChainConfig
-	addresses map[common.Address]bool
-	handlers  []EventHandler
-	erc20ABI  abi.ABI
-	mu        sync.RWMutex
+	chainID     uint64
+	chainName   string
+	client      ChainClient
+	dialHeads   HeadDialer // 建立 WebSocket 新块订阅，nil 表示只轮询
+	fallback    bool       // WebSocket 已失效，正在回退轮询（仅由 watchHeads 访问）
+	cfg         config.ChainConfig
+	addresses   map[common.Address]bool
+	handlers    []EventHandler
+	erc20ABI    abi.ABI
+	contract    common.Address // 只索引该合约的事件，零地址表示全部合约
+	transfers   bool           // 索引与监听地址相关的 ERC20 Transfer
+	contractABI *ContractABI   // 注册合约的 ABI，非 nil 时解码其关注的全部事件
+	checkpoints CheckpointStore
+	reset       bool // 忽略已保存的进度，从配置的起始块重新索引
+	backfillCfg config.BackfillConfig
+	limiter     *rate.Limiter // 回填查询限速，同一链的监听器共用，nil 表示不限
+	mu          sync.RWMutex
+
+	// 同步状态，由 syncMu 保护（轮询与 WebSocket 订阅都会触发同步）
+	syncMu      sync.Mutex
+	lastSafe    uint64                   // 最后安全块
+	safeHash    common.Hash              // 最后安全块哈希，用于发现超过确认深度的重组
+	lastIndexed uint64                   // 最后已索引块
+	unsafe      map[uint64]*indexedBlock // 已索引、未达确认深度的块
+	dirty       bool                     // 同步状态有变化，尚未保存进度
 }
 
-// MultiChainWatcher 多链监听器
+// MultiChainWatcher 多链监听器，每条链按配置的合约各一个监听器
 type MultiChainWatcher struct {
-	watchers map[uint64]*ChainWatcher
+	watchers map[uint64][]*ChainWatcher
 	handlers []EventHandler
 }
 
 // NewMultiChainWatcher 创建多链监听器
-func NewMultiChainWatcher(ctx context.Context, cfg *config.Config) (*MultiChainWatcher, error) {
+func NewMultiChainWatcher(ctx context.Context, cfg *config.Config, checkpoints CheckpointStore) (*MultiChainWatcher, error) {
 	mcw := &MultiChainWatcher{
-		watchers: make(map[uint64]*ChainWatcher),
+		watchers: make(map[uint64][]*ChainWatcher),
 		handlers: []EventHandler{},
 	}
 
@@ -74,53 +115,109 @@ func NewMultiChainWatcher(ctx context.Context, cfg *config.Config) (*MultiChainW
 		return nil, fmt.Errorf("failed to parse ERC20 ABI: %w", err)
 	}
 
+	// 解析注册合约的 ABI
+	registered := make(map[uint64]map[common.Address]*ContractABI)
+	for _, contractCfg := range cfg.ContractABIs {
+		contractABI, err := NewContractABI(contractCfg)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := cfg.Chains[contractABI.ChainID]; !ok {
+			return nil, fmt.Errorf("contract %s: unknown chain %d", contractABI.Address.Hex(), contractABI.ChainID)
+		}
+		if registered[contractABI.ChainID] == nil {
+			registered[contractABI.ChainID] = make(map[common.Address]*ContractABI)
+		}
+		registered[contractABI.ChainID][contractABI.Address] = contractABI
+	}
+
 	// 为每条链创建监听器
 	for chainID, chainCfg := range cfg.Chains {
-		watcher, err := newChainWatcher(ctx, chainCfg, parsedABI)
+		client, err := dialChain(chainCfg)
 		if err != nil {
 			log.Warn().Err(err).Uint64("chain_id", chainID).Msg("Failed to create watcher, skipping")
 			continue
 		}
 
-		// 添加监听地址
-		for _, addr := range cfg.WatchedAddresses {
-			watcher.AddAddress(common.HexToAddress(addr))
+		// 未配置合约时索引全部 ERC20 合约
+		contracts := []common.Address{{}}
+		if len(chainCfg.Contracts) > 0 {
+			contracts = contracts[:0]
+			for _, contract := range chainCfg.Contracts {
+				contracts = append(contracts, common.HexToAddress(contract))
+			}
+		}
+
+		// 注册合约：已在合约列表中的与 Transfer 共用监听器，其余单独监听且只解码注册的事件
+		transfers := make(map[common.Address]bool, len(contracts))
+		for _, contract := range contracts {
+			transfers[contract] = true
+		}
+		for address := range registered[chainID] {
+			if !transfers[address] {
+				contracts = append(contracts, address)
+			}
 		}
 
-		mcw.watchers[chainID] = watcher
-		log.Info().Uint64("chain_id", chainID).Str("name", chainCfg.Name).Msg("Chain watcher created")
+		// 同一链的监听器共用一个 RPC 节点，回填限速也共用
+		limiter := newRateLimiter(cfg.Backfill.RateLimit)
+
+		for _, contract := range contracts {
+			watcher := newChainWatcherWithClient(chainCfg, client, contract, parsedABI, checkpoints)
+			watcher.backfillCfg = cfg.Backfill
+			watcher.limiter = limiter
+			watcher.transfers = transfers[contract]
+			watcher.contractABI = registered[chainID][contract]
+			if chainCfg.WSURL != "" && chainCfg.IndexMode != config.IndexModePoll {
+				watcher.dialHeads = wsDialer(chainCfg.WSURL)
+			}
+			watcher.reset = cfg.Reset
+
+			// 添加监听地址
+			for _, addr := range cfg.WatchedAddresses {
+				watcher.AddAddress(common.HexToAddress(addr))
+			}
+
+			mcw.watchers[chainID] = append(mcw.watchers[chainID], watcher)
+			log.Info().Uint64("chain_id", chainID).Str("name", chainCfg.Name).Str("contract", watcher.scope()).Msg("Chain watcher created")
+		}
 	}
 
 	return mcw, nil
 }
 
-// newChainWatcher 创建单链监听器
-func newChainWatcher(ctx context.Context, cfg config.ChainConfig, parsedABI abi.ABI) (*ChainWatcher, error) {
-	// HTTP 客户端
+// dialChain 连接链 RPC
+func dialChain(cfg config.ChainConfig) (*ethclient.Client, error) {
 	client, err := ethclient.Dial(cfg.RPCURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to RPC: %w", err)
 	}
+	return client, nil
+}
 
-	// WebSocket 客户端 (可选)
-	var wsClient *ethclient.Client
-	if cfg.WSURL != "" {
-		wsClient, err = ethclient.Dial(cfg.WSURL)
-		if err != nil {
-			log.Warn().Err(err).Str("chain", cfg.Name).Msg("Failed to connect to WebSocket, using polling")
-		}
+// newChainWatcherWithClient 使用已有链客户端创建单链监听器
+func newChainWatcherWithClient(cfg config.ChainConfig, client ChainClient, contract common.Address, parsedABI abi.ABI, checkpoints CheckpointStore) *ChainWatcher {
+	return &ChainWatcher{
+		chainID:     cfg.ChainID,
+		chainName:   cfg.Name,
+		client:      client,
+		cfg:         cfg,
+		addresses:   make(map[common.Address]bool),
+		handlers:    []EventHandler{},
+		erc20ABI:    parsedABI,
+		contract:    contract,
+		transfers:   true,
+		checkpoints: checkpoints,
+		unsafe:      make(map[uint64]*indexedBlock),
 	}
+}
 
-	return &ChainWatcher{
-		chainID:   cfg.ChainID,
-		chainName: cfg.Name,
-		client:    client,
-		wsClient:  wsClient,
-		cfg:       cfg,
-		addresses: make(map[common.Address]bool),
-		handlers:  []EventHandler{},
-		erc20ABI:  parsedABI,
-	}, nil
+// scope 进度的合约维度：合约地址，或 "all" 表示全部合约
+func (w *ChainWatcher) scope() string {
+	if w.contract == (common.Address{}) {
+		return "all"
+	}
+	return strings.ToLower(w.contract.Hex())
 }
 
 // AddAddress 添加监听地址
@@ -142,12 +239,14 @@ func (w *ChainWatcher) RemoveAddress(addr common.Address) {
 func (mcw *MultiChainWatcher) Start(ctx context.Context) {
 	var wg sync.WaitGroup
 
-	for chainID, watcher := range mcw.watchers {
-		wg.Add(1)
-		go func(cID uint64, w *ChainWatcher) {
-			defer wg.Done()
-			w.Start(ctx)
-		}(chainID, watcher)
+	for _, watchers := range mcw.watchers {
+		for _, watcher := range watchers {
+			wg.Add(1)
+			go func(w *ChainWatcher) {
+				defer wg.Done()
+				w.Start(ctx)
+			}(watcher)
+		}
 	}
 
 	wg.Wait()
@@ -156,83 +255,88 @@ func (mcw *MultiChainWatcher) Start(ctx context.Context) {
 // AddHandler 添加事件处理器
 func (mcw *MultiChainWatcher) AddHandler(handler EventHandler) {
 	mcw.handlers = append(mcw.handlers, handler)
-	for _, watcher := range mcw.watchers {
-		watcher.handlers = append(watcher.handlers, handler)
+	for _, watchers := range mcw.watchers {
+		for _, watcher := range watchers {
+			watcher.handlers = append(watcher.handlers, handler)
+		}
 	}
 }
 
 // Start 启动单链监听
 func (w *ChainWatcher) Start(ctx context.Context) {
-	log.Info().Str("chain", w.chainName).Msg("Starting chain watcher")
+	log.Info().Str("chain", w.chainName).Str("contract", w.scope()).Msg("Starting chain watcher")
 
-	// 优先使用 WebSocket 订阅
-	if w.wsClient != nil {
-		go w.subscribeNewBlocks(ctx)
+	if err := w.loadCheckpoint(ctx); err != nil {
+		log.Error().Err(err).Str("chain", w.chainName).Str("contract", w.scope()).Msg("Failed to load checkpoint, watcher not started")
+		return
 	}
 
-	// 同时使用轮询作为备份
-	go w.pollBlocks(ctx)
-}
+	// 回填失败时已提交的窗口不会丢失，剩余区块由逐块同步继续索引
+	if w.backfillCfg.Enabled {
+		if err := w.backfill(ctx); err != nil && ctx.Err() == nil {
+			log.Error().Err(err).Str("chain", w.chainName).Str("contract", w.scope()).Msg("Backfill failed, continuing block by block")
+		}
+	}
 
-// subscribeNewBlocks WebSocket 订阅新块
-func (w *ChainWatcher) subscribeNewBlocks(ctx context.Context) {
-	headers := make(chan *types.Header)
-	sub, err := w.wsClient.SubscribeNewHead(ctx, headers)
-	if err != nil {
-		log.Error().Err(err).Str("chain", w.chainName).Msg("Failed to subscribe to new blocks")
+	// 优先使用 WebSocket 订阅，连接失效时回退为轮询
+	if w.dialHeads != nil {
+		go w.watchHeads(ctx)
 		return
 	}
-	defer sub.Unsubscribe()
 
-	log.Info().Str("chain", w.chainName).Msg("WebSocket subscription started")
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case err := <-sub.Err():
-			log.Error().Err(err).Str("chain", w.chainName).Msg("WebSocket subscription error")
-			return
-		case header := <-headers:
-			w.processBlock(ctx, header.Number.Uint64())
-		}
-	}
+	log.Info().Str("chain", w.chainName).Str("contract", w.scope()).Dur("interval", w.pollInterval()).Msg("Polling for new blocks")
+	go w.pollBlocks(ctx)
 }
 
 // pollBlocks 轮询新块
 func (w *ChainWatcher) pollBlocks(ctx context.Context) {
-	ticker := time.NewTicker(12 * time.Second) // 每 12 秒检查一次
+	ticker := time.NewTicker(w.pollInterval())
 	defer ticker.Stop()
 
-	var lastBlock uint64
-
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			currentBlock, err := w.client.BlockNumber(ctx)
-			if err != nil {
-				log.Error().Err(err).Str("chain", w.chainName).Msg("Failed to get block number")
-				continue
-			}
+			w.syncOrLog(ctx)
+		}
+	}
+}
 
-			if lastBlock == 0 {
-				lastBlock = currentBlock
-				continue
-			}
+// syncOrLog 同步新块，失败时记录日志，由下一次触发重试
+func (w *ChainWatcher) syncOrLog(ctx context.Context) {
+	if err := w.sync(ctx); err != nil && ctx.Err() == nil {
+		log.Error().Err(err).Str("chain", w.chainName).Str("contract", w.scope()).Msg("Failed to sync blocks")
+	}
+}
 
-			// 处理新块
-			for block := lastBlock + 1; block <= currentBlock; block++ {
-				w.processBlock(ctx, block)
-			}
-			lastBlock = currentBlock
-		}
+// pollInterval 轮询间隔，未配置时为 12 秒
+func (w *ChainWatcher) pollInterval() time.Duration {
+	if w.cfg.PollInterval > 0 {
+		return w.cfg.PollInterval
+	}
+	return defaultPollInterval
+}
+
+// blockEvents 查询区块内与监听地址相关的事件
+// 按区块哈希查询，保证日志与已记录的块哈希属于同一分叉。
+func (w *ChainWatcher) blockEvents(ctx context.Context, blockHash common.Hash) ([]*ChainEvent, error) {
+	query, addresses, ok := w.logQuery()
+	if !ok {
+		return nil, nil
+	}
+	query.BlockHash = &blockHash
+
+	logs, err := w.client.FilterLogs(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter logs: %w", err)
 	}
+	return w.processLogs(logs, addresses), nil
 }
 
-// processBlock 处理单个区块
-func (w *ChainWatcher) processBlock(ctx context.Context, blockNumber uint64) {
+// logQuery 日志查询条件（不含区块范围）与当前监听地址，无需查询时返回 false
+// Transfer 只在有监听地址时查询，注册合约的事件总是查询。
+func (w *ChainWatcher) logQuery() (ethereum.FilterQuery, []common.Address, bool) {
 	w.mu.RLock()
 	addresses := make([]common.Address, 0, len(w.addresses))
 	for addr := range w.addresses {
@@ -240,34 +344,49 @@ func (w *ChainWatcher) processBlock(ctx context.Context, blockNumber uint64) {
 	}
 	w.mu.RUnlock()
 
-	if len(addresses) == 0 {
-		return
+	var topics []common.Hash
+	if w.transfers && len(addresses) > 0 {
+		topics = append(topics, transferEventSig)
+	}
+	if w.contractABI != nil {
+		topics = append(topics, w.contractABI.topics()...)
+	}
+	if len(topics) == 0 {
+		return ethereum.FilterQuery{}, nil, false
 	}
 
-	// 查询与监听地址相关的日志
 	query := ethereum.FilterQuery{
-		FromBlock: big.NewInt(int64(blockNumber)),
-		ToBlock:   big.NewInt(int64(blockNumber)),
-		Topics:    [][]common.Hash{{transferEventSig}},
+		Topics: [][]common.Hash{topics},
 	}
-
-	logs, err := w.client.FilterLogs(ctx, query)
-	if err != nil {
-		log.Error().Err(err).Uint64("block", blockNumber).Str("chain", w.chainName).Msg("Failed to filter logs")
-		return
+	if w.contract != (common.Address{}) {
+		query.Addresses = []common.Address{w.contract}
 	}
+	return query, addresses, true
+}
 
-	// 处理每个日志
+// processLogs 处理每个日志，保持日志顺序
+func (w *ChainWatcher) processLogs(logs []types.Log, addresses []common.Address) []*ChainEvent {
+	events := []*ChainEvent{}
 	for _, vLog := range logs {
-		w.processLog(ctx, vLog, addresses, blockNumber)
+		if event := w.processLog(vLog, addresses); event != nil {
+			events = append(events, event)
+		}
 	}
+	return events
 }
 
-// processLog 处理单个日志
-func (w *ChainWatcher) processLog(ctx context.Context, vLog types.Log, addresses []common.Address, currentBlock uint64) {
+// processLog 处理单个日志，与监听地址无关时返回 nil
+func (w *ChainWatcher) processLog(vLog types.Log, addresses []common.Address) *ChainEvent {
+	// 注册合约的事件
+	if w.contractABI != nil {
+		if event, ok := w.contractABI.Event(vLog); ok {
+			return w.processContractLog(event, vLog)
+		}
+	}
+
 	// 解析 Transfer 事件
-	if len(vLog.Topics) < 3 {
-		return
+	if !w.transfers || len(vLog.Topics) < 3 || vLog.Topics[0] != transferEventSig {
+		return nil
 	}
 
 	from := common.HexToAddress(vLog.Topics[1].Hex())
@@ -282,41 +401,154 @@ func (w *ChainWatcher) processLog(ctx context.Context, vLog types.Log, addresses
 		}
 	}
 	if !isRelevant {
-		return
+		return nil
 	}
 
 	// 解析金额
 	value := new(big.Int).SetBytes(vLog.Data)
 
-	// 检查确认数
-	confirmations := currentBlock - vLog.BlockNumber
-	confirmed := confirmations >= w.cfg.Confirmations
+	fields, err := w.decodeFields("Transfer", vLog)
+	if err != nil {
+		log.Warn().Err(err).Str("chain", w.chainName).Str("tx", vLog.TxHash.Hex()).Msg("Failed to decode event fields")
+	}
 
-	event := &ChainEvent{
+	log.Info().
+		Str("chain", w.chainName).
+		Str("tx", vLog.TxHash.Hex()).
+		Str("from", from.Hex()).
+		Str("to", to.Hex()).
+		Str("value", value.String()).
+		Uint64("block", vLog.BlockNumber).
+		Msg("Transfer event detected")
+
+	return &ChainEvent{
 		ChainID:      w.chainID,
 		ChainName:    w.chainName,
 		EventType:    "transfer",
+		EventName:    "Transfer",
+		Fields:       fields,
 		TxHash:       vLog.TxHash.Hex(),
+		TxIndex:      vLog.TxIndex,
+		LogIndex:     vLog.Index,
 		BlockNumber:  vLog.BlockNumber,
+		BlockHash:    vLog.BlockHash.Hex(),
+		Topics:       logTopics(vLog),
+		Data:         hexutil.Encode(vLog.Data),
 		FromAddress:  from.Hex(),
 		ToAddress:    to.Hex(),
 		Value:        value.String(),
 		TokenAddress: vLog.Address.Hex(),
 		Timestamp:    time.Now(),
-		Confirmed:    confirmed,
+	}
+}
+
+// processContractLog 按注册的 ABI 解码事件；解码失败时仍保留原始 topics 与 data
+func (w *ChainWatcher) processContractLog(event abi.Event, vLog types.Log) *ChainEvent {
+	fields, err := w.contractABI.Decode(event, vLog)
+	if err != nil {
+		log.Warn().Err(err).Str("chain", w.chainName).Str("tx", vLog.TxHash.Hex()).Msg("Failed to decode event fields")
 	}
 
 	log.Info().
 		Str("chain", w.chainName).
+		Str("contract", w.contractABI.Name).
+		Str("event", event.Name).
 		Str("tx", vLog.TxHash.Hex()).
-		Str("from", from.Hex()).
-		Str("to", to.Hex()).
-		Str("value", value.String()).
-		Bool("confirmed", confirmed).
-		Msg("Transfer event detected")
+		Uint64("block", vLog.BlockNumber).
+		Msg("Contract event detected")
+
+	return &ChainEvent{
+		ChainID:      w.chainID,
+		ChainName:    w.chainName,
+		EventType:    "contract",
+		EventName:    event.Name,
+		Fields:       fields,
+		TxHash:       vLog.TxHash.Hex(),
+		TxIndex:      vLog.TxIndex,
+		LogIndex:     vLog.Index,
+		BlockNumber:  vLog.BlockNumber,
+		BlockHash:    vLog.BlockHash.Hex(),
+		Topics:       logTopics(vLog),
+		Data:         hexutil.Encode(vLog.Data),
+		TokenAddress: vLog.Address.Hex(),
+		Timestamp:    time.Now(),
+	}
+}
+
+// decodeFields 按 ABI 解码事件参数：indexed 参数取自 topics，其余取自 data
+func (w *ChainWatcher) decodeFields(eventName string, vLog types.Log) ([]EventField, error) {
+	event, ok := w.erc20ABI.Events[eventName]
+	if !ok {
+		return nil, fmt.Errorf("event %s not in ABI", eventName)
+	}
+
+	values := make(map[string]interface{})
+	if err := event.Inputs.UnpackIntoMap(values, vLog.Data); err != nil {
+		return nil, fmt.Errorf("failed to unpack data: %w", err)
+	}
+	var indexed abi.Arguments
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if len(vLog.Topics) < len(indexed)+1 {
+		return nil, fmt.Errorf("expected %d topics, got %d", len(indexed)+1, len(vLog.Topics))
+	}
+	if err := abi.ParseTopicsIntoMap(values, indexed, vLog.Topics[1:]); err != nil {
+		return nil, fmt.Errorf("failed to parse topics: %w", err)
+	}
+
+	return eventFields(event, values), nil
+}
+
+// eventFields 按 ABI 参数顺序输出解码后的参数
+func eventFields(event abi.Event, values map[string]interface{}) []EventField {
+	fields := make([]EventField, 0, len(event.Inputs))
+	for _, input := range event.Inputs {
+		fields = append(fields, EventField{
+			Name:    input.Name,
+			Type:    input.Type.String(),
+			Value:   formatValue(values[input.Name]),
+			Indexed: input.Indexed,
+		})
+	}
+	return fields
+}
 
-	// 调用处理器
-	for _, handler := range w.handlers {
-		go handler(event)
+// logTopics topics 转为 hex 字符串
+func logTopics(vLog types.Log) []string {
+	topics := make([]string, len(vLog.Topics))
+	for i, topic := range vLog.Topics {
+		topics[i] = topic.Hex()
+	}
+	return topics
+}
+
+// formatValue 参数值转为字符串：地址为校验和格式，整数为十进制
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case common.Address:
+		return val.Hex()
+	case common.Hash:
+		return val.Hex()
+	case [32]byte:
+		return hexutil.Encode(val[:])
+	case *big.Int:
+		return val.String()
+	case []byte:
+		return hexutil.Encode(val)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// dispatch 按顺序调用处理器
+// 同步调用以保证同一笔交易的撤销/确认事件在原事件之后送达。
+func (w *ChainWatcher) dispatch(events []*ChainEvent) {
+	for _, event := range events {
+		for _, handler := range w.handlers {
+			handler(event)
+		}
 	}
 }
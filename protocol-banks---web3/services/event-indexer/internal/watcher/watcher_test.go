@@ -1,7 +1,6 @@
 package watcher
 
 import (
-	"context"
 	"math/big"
 	"testing"
 	"time"
@@ -4,18 +4,24 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/protocol-bank/payout-engine/internal/config"
 	"github.com/protocol-bank/payout-engine/internal/handler"
 	"github.com/protocol-bank/payout-engine/internal/nonce"
 	"github.com/protocol-bank/payout-engine/internal/queue"
 	"github.com/protocol-bank/payout-engine/internal/service"
+	"github.com/protocol-bank/payout-engine/internal/signer"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
@@ -42,20 +48,71 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to initialize nonce manager")
 	}
 
-	// 队列消费者
-	queueConsumer, err := queue.NewConsumer(ctx, cfg.Redis)
+	// 队列消费者：Redis 或 NATS JetStream（PAYOUT_QUEUE_BACKEND）
+	queueConsumer, err := queue.NewJobSource(ctx, cfg.Queue, cfg.Redis)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to initialize queue consumer")
+		log.Fatal().Err(err).Str("backend", string(cfg.Queue.Backend)).Msg("Failed to initialize queue consumer")
 	}
+	defer queueConsumer.Close()
+
+	// 支付 ID → 交易记录（幂等）
+	txRecords, err := service.NewRedisTxRecordStore(ctx, cfg.Redis)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize tx record store")
+	}
+	defer txRecords.Close()
 
 	// 支付服务
-	payoutService, err := service.NewPayoutService(ctx, cfg, nonceManager, queueConsumer)
+	payoutService, err := service.NewPayoutService(ctx, cfg, nonceManager, queueConsumer, txRecords)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to initialize payout service")
 	}
 
+	// 签名地址池：多个地址各自分配 Nonce，任务轮流使用；签名由本地私钥或外部签名服务完成
+	if signer.Configured(cfg.Signer) {
+		signers, err := signer.NewPool(ctx, cfg.Signer)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to load signer pool")
+		}
+		payoutService.SetSignerPool(signers)
+		log.Info().Int("signers", signers.Size()).Bool("external", cfg.Signer.Endpoint != "").Msg("Signer pool loaded")
+	}
+
+	// 支付状态事件：写入 Redis 并广播，各实例转发到本地订阅者
+	eventPublisher, err := service.NewRedisEventPublisher(ctx, cfg.Redis)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize payout event publisher")
+	}
+	defer eventPublisher.Close()
+	statusBroker := service.NewStatusBroker()
+	go eventPublisher.Relay(ctx, statusBroker)
+
+	// 支付终态回调：只由发布事件的实例投递，避免多实例重复通知
+	var events service.EventPublisher = eventPublisher
+	var notifier *service.WebhookNotifier
+	if cfg.Webhook.URL != "" {
+		notifier = service.NewWebhookNotifier(cfg.Webhook)
+		notifier.SetDeadLetterHandler(eventPublisher.RecordWebhookDeadLetter)
+		go notifier.Run(ctx)
+		events = service.MultiPublisher{eventPublisher, notifier}
+	}
+	payoutService.SetEventPublisher(events)
+	queueConsumer.SetDeadLetterHandler(payoutService.HandleDeadLetter)
+
+	// 卡住交易加价重发
+	txWatcher := service.NewTxWatcher(payoutService, events, cfg.Bump)
+	go txWatcher.Run(ctx)
+
+	// Nonce 缺口对账：超时先重新广播已记录的交易
+	nonceManager.SetResubmitter(payoutService)
+	go nonceManager.RunReconciler(ctx, cfg.Nonce.ReconcileInterval, cfg.Nonce.GapTimeout)
+
 	// 启动队列消费者
 	go queueConsumer.Start(ctx, payoutService.ProcessJob)
+	go queue.RunDepthReporter(ctx, queueConsumer, 15*time.Second)
+
+	// Prometheus 指标
+	metricsServer := startMetricsServer(cfg.MetricsPort)
 
 	// 启动 gRPC 服务器
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
@@ -69,8 +126,19 @@ func main() {
 	)
 
 	handler.RegisterPayoutServer(grpcServer, payoutService)
+	handler.RegisterPayoutStatusServer(grpcServer, handler.NewPayoutStatusServer(statusBroker, eventPublisher))
+	handler.RegisterPayoutControlServer(grpcServer, handler.NewPayoutControlServer(payoutService))
 	reflection.Register(grpcServer)
 
+	// 健康检查：Redis 与链客户端均可达时为 SERVING
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	healthReporter := handler.NewHealthReporter(healthServer, map[string]handler.Probe{
+		"redis": nonceManager.Ping,
+		"chain": payoutService.CheckChains,
+	})
+	go healthReporter.Run(ctx, 10*time.Second)
+
 	go func() {
 		log.Info().Int("port", cfg.GRPCPort).Msg("gRPC server listening")
 		if err := grpcServer.Serve(lis); err != nil {
@@ -84,7 +152,51 @@ func main() {
 	<-quit
 
 	log.Info().Msg("Shutting down...")
-	grpcServer.GracefulStop()
+	healthServer.Shutdown()
+	// 状态订阅流可能长期存在，超时后强制关闭
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		grpcServer.Stop()
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Metrics server shutdown error")
+	}
 	cancel()
+	// 未投递完的回调写入死信后再关闭 Redis
+	if notifier != nil {
+		select {
+		case <-notifier.Done():
+		case <-time.After(10 * time.Second):
+			log.Warn().Msg("Timed out waiting for webhook notifier to stop")
+		}
+	}
 	log.Info().Msg("Payout Engine stopped")
 }
+
+// startMetricsServer 在独立端口暴露 Prometheus /metrics
+func startMetricsServer(port int) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		log.Info().Int("port", port).Msg("Metrics server listening")
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatal().Err(err).Msg("Metrics server error")
+		}
+	}()
+	return server
+}
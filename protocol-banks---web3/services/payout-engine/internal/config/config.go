@@ -1,13 +1,17 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	Environment string
 	GRPCPort    int
+	MetricsPort int // Prometheus /metrics 端口
 	APISecret   string
 
 	// Database
@@ -16,8 +20,23 @@ type Config struct {
 	// Redis
 	Redis RedisConfig
 
+	// 支付任务队列
+	Queue QueueConfig
+
 	// Blockchain
 	Chains map[uint64]ChainConfig
+
+	// Nonce
+	Nonce NonceConfig
+
+	// 卡住交易加价重发
+	Bump BumpConfig
+
+	// 支付终态回调
+	Webhook WebhookConfig
+
+	// 签名地址池
+	Signer SignerConfig
 }
 
 type DatabaseConfig struct {
@@ -30,6 +49,93 @@ type RedisConfig struct {
 	DB       int
 }
 
+// QueueBackend 支付任务队列后端
+type QueueBackend string
+
+const (
+	// QueueBackendRedis Redis 列表（默认）
+	QueueBackendRedis QueueBackend = "redis"
+	// QueueBackendNATS NATS JetStream，任务持久化并可重放
+	QueueBackendNATS QueueBackend = "nats"
+)
+
+// QueueConfig 支付任务队列，Backend 为 redis 时使用 RedisConfig
+type QueueConfig struct {
+	Backend QueueBackend
+	NATS    NATSConfig
+}
+
+// NATSConfig NATS JetStream 队列
+type NATSConfig struct {
+	URL string
+	// Stream 存放待处理任务的流（WorkQueue 保留策略），任务发布到 Subject.<任务 ID>
+	Stream  string
+	Subject string
+	// DeadLetterStream 超过重试次数的任务写入 DeadLetterSubject.<任务 ID>，与 Subject 不能重叠
+	DeadLetterStream  string
+	DeadLetterSubject string
+	// Consumer 持久化拉取消费者名称，多个实例共享同一消费者分摊任务
+	Consumer string
+	// AckWait 任务投递后多久未确认由服务端重新投递，应大于单个任务的处理时间
+	AckWait time.Duration
+}
+
+type NonceConfig struct {
+	// ReconcileInterval Redis 与链上 Nonce 的对账周期
+	ReconcileInterval time.Duration
+	// GapTimeout 缺口持续多久后重新广播或重置
+	GapTimeout time.Duration
+}
+
+type BumpConfig struct {
+	// Interval 检查待确认交易的周期
+	Interval time.Duration
+	// StuckAfter 广播后多久未确认视为卡住
+	StuckAfter time.Duration
+	// MaxAttempts 最多加价重发次数，超过后放弃并发出失败事件
+	MaxAttempts int
+	// BumpPercent 每次加价的百分比，节点替换规则要求至少 10%
+	BumpPercent int
+}
+
+// WebhookConfig 支付终态回调，URL 为空时不启用
+type WebhookConfig struct {
+	URL string
+	// Secret HMAC-SHA256 签名密钥，与接收方共享
+	Secret string
+	// MaxAttempts 每个事件最多投递次数，用尽后写入死信
+	MaxAttempts int
+	// RetryDelay 首次重试前的等待，之后每次翻倍
+	RetryDelay time.Duration
+	// MaxRetryDelay 重试等待上限
+	MaxRetryDelay time.Duration
+	// Timeout 单次请求超时
+	Timeout time.Duration
+	// Workers 并行投递的协程数，一个慢请求或长时间退避不会阻塞其余事件
+	Workers int
+}
+
+// SignerConfig 签名地址池，私钥列表、助记词与外部签名服务三选一
+// 每个地址有独立的 Nonce 序列，多个地址可以并行广播。
+type SignerConfig struct {
+	// PrivateKeys 十六进制私钥列表
+	PrivateKeys []string
+	// Mnemonic BIP-39 助记词，从 DerivationPath 开始依次派生 PoolSize 个地址
+	Mnemonic string
+	// Passphrase 助记词密码（可选）
+	Passphrase string
+	// DerivationPath 第一个地址的派生路径，之后递增最后一级
+	DerivationPath string
+	// PoolSize 签名地址数量；0 表示使用全部私钥或外部签名服务的全部地址，助记词模式下为 1
+	PoolSize int
+	// Endpoint 外部签名服务地址（clef 兼容 JSON-RPC，可由 HSM/KMS 签名代理提供），设置后私钥不进入本进程
+	Endpoint string
+	// Addresses 使用外部签名服务中的哪些地址，为空时使用服务管理的全部地址
+	Addresses []string
+	// Timeout 外部签名请求超时；签名在 Nonce 锁内进行，应小于锁的有效期（30s）
+	Timeout time.Duration
+}
+
 type ChainConfig struct {
 	ChainID     uint64
 	Name        string
@@ -37,15 +143,57 @@ type ChainConfig struct {
 	ExplorerURL string
 	NativeToken string
 	Decimals    int
+	Fee         FeeStrategy
+	// Confirmations 交易所在区块之上需要多少个区块（含自身）才视为最终，最小为 1
+	Confirmations uint64
+}
+
+// FeeMode 交易计价方式
+type FeeMode string
+
+const (
+	// FeeModeAuto 优先使用 EIP-1559，链不支持时回退到 legacy
+	FeeModeAuto FeeMode = "auto"
+	// FeeModeEIP1559 只使用 EIP-1559
+	FeeModeEIP1559 FeeMode = "eip1559"
+	// FeeModeLegacy 只使用 legacy gasPrice
+	FeeModeLegacy FeeMode = "legacy"
+)
+
+// FeeStrategy 每条链的 Gas 费用策略，上限为 0 表示不设上限
+type FeeStrategy struct {
+	Mode FeeMode
+	// BaseFeeMultiplier maxFeePerGas = baseFee * BaseFeeMultiplier + maxPriorityFeePerGas
+	BaseFeeMultiplier float64
+	// PriorityFeeMultiplier 对 eth_feeHistory 建议小费的放大倍数
+	PriorityFeeMultiplier float64
+	// GasPriceMultiplier legacy 模式下对 eth_gasPrice 的放大倍数
+	GasPriceMultiplier float64
+	// MaxFeePerGasGwei maxFeePerGas（legacy 模式为 gasPrice）上限
+	MaxFeePerGasGwei float64
+	// MaxPriorityFeePerGasGwei maxPriorityFeePerGas 上限
+	MaxPriorityFeePerGasGwei float64
+}
+
+// DefaultFeeStrategy 默认费用策略
+func DefaultFeeStrategy() FeeStrategy {
+	return FeeStrategy{
+		Mode:                  FeeModeAuto,
+		BaseFeeMultiplier:     2,
+		PriorityFeeMultiplier: 1.2,
+		GasPriceMultiplier:    1.2,
+	}
 }
 
 func Load() (*Config, error) {
 	port, _ := strconv.Atoi(getEnv("GRPC_PORT", "50051"))
+	metricsPort, _ := strconv.Atoi(getEnv("METRICS_PORT", "9090"))
 	redisDB, _ := strconv.Atoi(getEnv("REDIS_DB", "0"))
 
 	cfg := &Config{
 		Environment: getEnv("ENVIRONMENT", "development"),
 		GRPCPort:    port,
+		MetricsPort: metricsPort,
 		APISecret:   getEnv("API_SECRET", ""),
 		Database: DatabaseConfig{
 			URL: getEnv("DATABASE_URL", ""),
@@ -55,56 +203,174 @@ func Load() (*Config, error) {
 			Password: getEnv("REDIS_PASSWORD", ""),
 			DB:       redisDB,
 		},
+		Queue: QueueConfig{
+			Backend: QueueBackend(getEnv("PAYOUT_QUEUE_BACKEND", string(QueueBackendRedis))),
+			NATS: NATSConfig{
+				URL:               getEnv("NATS_URL", "nats://localhost:4222"),
+				Stream:            getEnv("PAYOUT_NATS_STREAM", "PAYOUT_JOBS"),
+				Subject:           getEnv("PAYOUT_NATS_SUBJECT", "payout.jobs"),
+				DeadLetterStream:  getEnv("PAYOUT_NATS_DEADLETTER_STREAM", "PAYOUT_DEADLETTER"),
+				DeadLetterSubject: getEnv("PAYOUT_NATS_DEADLETTER_SUBJECT", "payout.deadletter"),
+				Consumer:          getEnv("PAYOUT_NATS_CONSUMER", "payout-engine"),
+				AckWait:           getDuration("PAYOUT_NATS_ACK_WAIT", 5*time.Minute),
+			},
+		},
+		Nonce: NonceConfig{
+			ReconcileInterval: getDuration("NONCE_RECONCILE_INTERVAL", 30*time.Second),
+			GapTimeout:        getDuration("NONCE_GAP_TIMEOUT", 2*time.Minute),
+		},
+		Bump: BumpConfig{
+			Interval:    getDuration("PAYOUT_BUMP_INTERVAL", 15*time.Second),
+			StuckAfter:  getDuration("PAYOUT_STUCK_AFTER", 3*time.Minute),
+			MaxAttempts: getInt("PAYOUT_MAX_BUMPS", 5),
+			BumpPercent: max(getInt("PAYOUT_BUMP_PERCENT", 10), 10),
+		},
+		Webhook: WebhookConfig{
+			URL:           getEnv("PAYOUT_WEBHOOK_URL", ""),
+			Secret:        getEnv("PAYOUT_WEBHOOK_SECRET", ""),
+			MaxAttempts:   max(getInt("PAYOUT_WEBHOOK_MAX_ATTEMPTS", 6), 1),
+			RetryDelay:    getDuration("PAYOUT_WEBHOOK_RETRY_DELAY", 2*time.Second),
+			MaxRetryDelay: getDuration("PAYOUT_WEBHOOK_MAX_RETRY_DELAY", time.Minute),
+			Timeout:       getDuration("PAYOUT_WEBHOOK_TIMEOUT", 10*time.Second),
+			Workers:       max(getInt("PAYOUT_WEBHOOK_WORKERS", 4), 1),
+		},
+		Signer: SignerConfig{
+			PrivateKeys:    getList("PAYOUT_SIGNER_KEYS"),
+			Mnemonic:       getEnv("PAYOUT_SIGNER_MNEMONIC", ""),
+			Passphrase:     getEnv("PAYOUT_SIGNER_PASSPHRASE", ""),
+			DerivationPath: getEnv("PAYOUT_SIGNER_DERIVATION_PATH", "m/44'/60'/0'/0/0"),
+			PoolSize:       getInt("PAYOUT_SIGNER_POOL_SIZE", 0),
+			Endpoint:       getEnv("PAYOUT_SIGNER_ENDPOINT", ""),
+			Addresses:      getList("PAYOUT_SIGNER_ADDRESSES"),
+			Timeout:        getDuration("PAYOUT_SIGNER_TIMEOUT", 10*time.Second),
+		},
 		Chains: map[uint64]ChainConfig{
 			1: {
-				ChainID:     1,
-				Name:        "Ethereum",
-				RPCURL:      getEnv("ETH_RPC_URL", "https://eth.llamarpc.com"),
-				ExplorerURL: "https://etherscan.io",
-				NativeToken: "ETH",
-				Decimals:    18,
+				ChainID:       1,
+				Name:          "Ethereum",
+				RPCURL:        getEnv("ETH_RPC_URL", "https://eth.llamarpc.com"),
+				ExplorerURL:   "https://etherscan.io",
+				NativeToken:   "ETH",
+				Decimals:      18,
+				Fee:           getFeeStrategy("ETH"),
+				Confirmations: getConfirmations("ETH", 12),
 			},
 			137: {
-				ChainID:     137,
-				Name:        "Polygon",
-				RPCURL:      getEnv("POLYGON_RPC_URL", "https://polygon-rpc.com"),
-				ExplorerURL: "https://polygonscan.com",
-				NativeToken: "MATIC",
-				Decimals:    18,
+				ChainID:       137,
+				Name:          "Polygon",
+				RPCURL:        getEnv("POLYGON_RPC_URL", "https://polygon-rpc.com"),
+				ExplorerURL:   "https://polygonscan.com",
+				NativeToken:   "MATIC",
+				Decimals:      18,
+				Fee:           getFeeStrategy("POLYGON"),
+				Confirmations: getConfirmations("POLYGON", 64),
 			},
 			42161: {
-				ChainID:     42161,
-				Name:        "Arbitrum",
-				RPCURL:      getEnv("ARBITRUM_RPC_URL", "https://arb1.arbitrum.io/rpc"),
-				ExplorerURL: "https://arbiscan.io",
-				NativeToken: "ETH",
-				Decimals:    18,
+				ChainID:       42161,
+				Name:          "Arbitrum",
+				RPCURL:        getEnv("ARBITRUM_RPC_URL", "https://arb1.arbitrum.io/rpc"),
+				ExplorerURL:   "https://arbiscan.io",
+				NativeToken:   "ETH",
+				Decimals:      18,
+				Fee:           getFeeStrategy("ARBITRUM"),
+				Confirmations: getConfirmations("ARBITRUM", 20),
 			},
 			8453: {
-				ChainID:     8453,
-				Name:        "Base",
-				RPCURL:      getEnv("BASE_RPC_URL", "https://mainnet.base.org"),
-				ExplorerURL: "https://basescan.org",
-				NativeToken: "ETH",
-				Decimals:    18,
+				ChainID:       8453,
+				Name:          "Base",
+				RPCURL:        getEnv("BASE_RPC_URL", "https://mainnet.base.org"),
+				ExplorerURL:   "https://basescan.org",
+				NativeToken:   "ETH",
+				Decimals:      18,
+				Fee:           getFeeStrategy("BASE"),
+				Confirmations: getConfirmations("BASE", 10),
 			},
 			10: {
-				ChainID:     10,
-				Name:        "Optimism",
-				RPCURL:      getEnv("OPTIMISM_RPC_URL", "https://mainnet.optimism.io"),
-				ExplorerURL: "https://optimistic.etherscan.io",
-				NativeToken: "ETH",
-				Decimals:    18,
+				ChainID:       10,
+				Name:          "Optimism",
+				RPCURL:        getEnv("OPTIMISM_RPC_URL", "https://mainnet.optimism.io"),
+				ExplorerURL:   "https://optimistic.etherscan.io",
+				NativeToken:   "ETH",
+				Decimals:      18,
+				Fee:           getFeeStrategy("OPTIMISM"),
+				Confirmations: getConfirmations("OPTIMISM", 10),
 			},
 		},
 	}
 
+	switch cfg.Queue.Backend {
+	case QueueBackendRedis, QueueBackendNATS:
+	default:
+		return nil, fmt.Errorf("PAYOUT_QUEUE_BACKEND must be %q or %q, got %q", QueueBackendRedis, QueueBackendNATS, cfg.Queue.Backend)
+	}
+	if cfg.Webhook.URL != "" && cfg.Webhook.Secret == "" {
+		return nil, fmt.Errorf("PAYOUT_WEBHOOK_SECRET is required when PAYOUT_WEBHOOK_URL is set")
+	}
+	if len(cfg.Signer.PrivateKeys) > 0 && cfg.Signer.Mnemonic != "" {
+		return nil, fmt.Errorf("PAYOUT_SIGNER_KEYS and PAYOUT_SIGNER_MNEMONIC are mutually exclusive")
+	}
+	if cfg.Signer.Endpoint != "" && (len(cfg.Signer.PrivateKeys) > 0 || cfg.Signer.Mnemonic != "") {
+		return nil, fmt.Errorf("PAYOUT_SIGNER_ENDPOINT excludes PAYOUT_SIGNER_KEYS and PAYOUT_SIGNER_MNEMONIC")
+	}
+
 	return cfg, nil
 }
 
+// getConfirmations 读取 <prefix>_CONFIRMATIONS，未设置时使用链的默认确认深度
+func getConfirmations(prefix string, defaultValue int) uint64 {
+	return uint64(max(getInt(prefix+"_CONFIRMATIONS", defaultValue), 1))
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getInt(key string, defaultValue int) int {
+	if i, err := strconv.Atoi(os.Getenv(key)); err == nil && i >= 0 {
+		return i
+	}
+	return defaultValue
+}
+
+// getList 读取逗号分隔的列表，忽略空项
+func getList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getFloat(key string, defaultValue float64) float64 {
+	if f, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil && f >= 0 {
+		return f
+	}
+	return defaultValue
+}
+
+// getFeeStrategy 读取链的费用策略，环境变量以链前缀开头，如 ETH_FEE_MODE、POLYGON_MAX_FEE_GWEI
+func getFeeStrategy(prefix string) FeeStrategy {
+	fee := DefaultFeeStrategy()
+	switch mode := FeeMode(os.Getenv(prefix + "_FEE_MODE")); mode {
+	case FeeModeAuto, FeeModeEIP1559, FeeModeLegacy:
+		fee.Mode = mode
+	}
+	fee.BaseFeeMultiplier = getFloat(prefix+"_BASE_FEE_MULTIPLIER", fee.BaseFeeMultiplier)
+	fee.PriorityFeeMultiplier = getFloat(prefix+"_PRIORITY_FEE_MULTIPLIER", fee.PriorityFeeMultiplier)
+	fee.GasPriceMultiplier = getFloat(prefix+"_GAS_PRICE_MULTIPLIER", fee.GasPriceMultiplier)
+	fee.MaxFeePerGasGwei = getFloat(prefix+"_MAX_FEE_GWEI", 0)
+	fee.MaxPriorityFeePerGasGwei = getFloat(prefix+"_MAX_PRIORITY_FEE_GWEI", 0)
+	return fee
+}
+
+func getDuration(key string, defaultValue time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d > 0 {
+		return d
+	}
+	return defaultValue
+}
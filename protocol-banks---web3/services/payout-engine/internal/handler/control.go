@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/protocol-bank/payout-engine/internal/pb"
+	"github.com/protocol-bank/payout-engine/internal/service"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PayoutCanceller 支付取消，*service.PayoutService 满足该接口
+type PayoutCanceller interface {
+	CancelPayout(ctx context.Context, jobID, reason string, replace bool) (*service.CancelResult, error)
+}
+
+// PayoutControlServer 单笔支付运维操作服务
+type PayoutControlServer struct {
+	pb.UnimplementedPayoutControlServiceServer
+	canceller PayoutCanceller
+}
+
+// NewPayoutControlServer 创建运维操作服务
+func NewPayoutControlServer(canceller PayoutCanceller) *PayoutControlServer {
+	return &PayoutControlServer{canceller: canceller}
+}
+
+// RegisterPayoutControlServer 注册 gRPC 服务
+func RegisterPayoutControlServer(s *grpc.Server, srv *PayoutControlServer) {
+	pb.RegisterPayoutControlServiceServer(s, srv)
+	log.Info().Msg("Payout control gRPC server registered")
+}
+
+// CancelPayout 取消支付；已广播且未要求替换时返回 FailedPrecondition
+func (s *PayoutControlServer) CancelPayout(ctx context.Context, req *pb.CancelPayoutRequest) (*pb.CancelPayoutResponse, error) {
+	if req.PayoutId == "" {
+		return nil, status.Error(codes.InvalidArgument, "payout_id is required")
+	}
+
+	result, err := s.canceller.CancelPayout(ctx, req.PayoutId, req.Reason, req.ReplaceIfBroadcast)
+	switch {
+	case errors.Is(err, service.ErrPayoutNotFound):
+		return nil, status.Errorf(codes.NotFound, "payout %s not found", req.PayoutId)
+	case errors.Is(err, service.ErrAlreadyBroadcast):
+		return nil, status.Errorf(codes.FailedPrecondition, "payout %s already broadcast, too late to cancel", req.PayoutId)
+	case errors.Is(err, service.ErrPayoutFinished):
+		return nil, status.Errorf(codes.FailedPrecondition, "payout %s already finished", req.PayoutId)
+	case errors.Is(err, service.ErrCancelFeeCap):
+		return nil, status.Errorf(codes.FailedPrecondition, "payout %s cannot be replaced within the fee cap", req.PayoutId)
+	case err != nil:
+		log.Error().Err(err).Str("payout_id", req.PayoutId).Msg("Failed to cancel payout")
+		return nil, status.Error(codes.Internal, "failed to cancel payout")
+	}
+
+	log.Info().
+		Str("payout_id", req.PayoutId).
+		Str("reason", req.Reason).
+		Bool("removed_from_queue", result.RemovedFromQueue).
+		Str("replacement_tx_hash", result.ReplacementTxHash).
+		Msg("Payout cancel requested")
+
+	resp := &pb.CancelPayoutResponse{
+		PayoutId:          req.PayoutId,
+		Outcome:           pb.CancelOutcome_CANCEL_OUTCOME_CANCELLED,
+		RemovedFromQueue:  result.RemovedFromQueue,
+		ReplacementTxHash: result.ReplacementTxHash,
+	}
+	if result.ReplacementTxHash != "" {
+		resp.Outcome = pb.CancelOutcome_CANCEL_OUTCOME_REPLACEMENT_SENT
+	}
+	return resp, nil
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/protocol-bank/payout-engine/internal/pb"
+	"github.com/protocol-bank/payout-engine/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeCanceller 模拟支付状态：queued 仍在队列，broadcast 已广播，missing 不存在
+type fakeCanceller struct {
+	states map[string]string
+	calls  int
+}
+
+func (c *fakeCanceller) CancelPayout(ctx context.Context, jobID, reason string, replace bool) (*service.CancelResult, error) {
+	c.calls++
+	switch c.states[jobID] {
+	case "queued":
+		return &service.CancelResult{JobID: jobID, RemovedFromQueue: true}, nil
+	case "broadcast":
+		if !replace {
+			return nil, service.ErrAlreadyBroadcast
+		}
+		return &service.CancelResult{JobID: jobID, ReplacementTxHash: "0xreplacement"}, nil
+	case "confirmed":
+		return nil, service.ErrPayoutFinished
+	case "missing":
+		return nil, service.ErrPayoutNotFound
+	default:
+		return nil, errors.New("redis: connection refused")
+	}
+}
+
+func startControlServer(t *testing.T) (pb.PayoutControlServiceClient, *fakeCanceller) {
+	canceller := &fakeCanceller{states: map[string]string{
+		"payout-queued":    "queued",
+		"payout-broadcast": "broadcast",
+		"payout-confirmed": "confirmed",
+		"payout-missing":   "missing",
+	}}
+	conn := startTestServer(t, func(s *grpc.Server) {
+		RegisterPayoutControlServer(s, NewPayoutControlServer(canceller))
+	})
+	return pb.NewPayoutControlServiceClient(conn), canceller
+}
+
+func TestCancelPayout_BeforeBroadcast(t *testing.T) {
+	client, _ := startControlServer(t)
+
+	resp, err := client.CancelPayout(authContext(), &pb.CancelPayoutRequest{PayoutId: "payout-queued", Reason: "fraud flag"})
+	require.NoError(t, err)
+	assert.Equal(t, pb.CancelOutcome_CANCEL_OUTCOME_CANCELLED, resp.Outcome)
+	assert.True(t, resp.RemovedFromQueue)
+	assert.Empty(t, resp.ReplacementTxHash)
+}
+
+func TestCancelPayout_AfterBroadcast(t *testing.T) {
+	client, _ := startControlServer(t)
+
+	_, err := client.CancelPayout(authContext(), &pb.CancelPayoutRequest{PayoutId: "payout-broadcast"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "too late")
+
+	resp, err := client.CancelPayout(authContext(), &pb.CancelPayoutRequest{PayoutId: "payout-broadcast", ReplaceIfBroadcast: true})
+	require.NoError(t, err)
+	assert.Equal(t, pb.CancelOutcome_CANCEL_OUTCOME_REPLACEMENT_SENT, resp.Outcome)
+	assert.Equal(t, "0xreplacement", resp.ReplacementTxHash)
+
+	_, err = client.CancelPayout(authContext(), &pb.CancelPayoutRequest{PayoutId: "payout-confirmed", ReplaceIfBroadcast: true})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestCancelPayout_Errors(t *testing.T) {
+	client, canceller := startControlServer(t)
+
+	// Requires the API key
+	_, err := client.CancelPayout(context.Background(), &pb.CancelPayoutRequest{PayoutId: "payout-queued"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Zero(t, canceller.calls)
+
+	_, err = client.CancelPayout(authContext(), &pb.CancelPayoutRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.CancelPayout(authContext(), &pb.CancelPayoutRequest{PayoutId: "payout-missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// Internal errors are not leaked
+	_, err = client.CancelPayout(authContext(), &pb.CancelPayoutRequest{PayoutId: "payout-unknown"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.NotContains(t, status.Convert(err).Message(), "redis")
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"strings"
 
 	"github.com/protocol-bank/payout-engine/internal/service"
 	"github.com/rs/zerolog/log"
@@ -23,6 +24,23 @@ func RegisterPayoutServer(s *grpc.Server, svc *service.PayoutService) {
 	log.Info().Msg("Payout gRPC server registered")
 }
 
+// authExemptServices 免认证的服务：负载均衡健康探测与调试用反射
+var authExemptServices = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.v1.ServerReflection/",
+	"/grpc.reflection.v1alpha.ServerReflection/",
+}
+
+// isAuthExempt 方法是否免认证
+func isAuthExempt(fullMethod string) bool {
+	for _, prefix := range authExemptServices {
+		if strings.HasPrefix(fullMethod, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // AuthInterceptor 认证拦截器
 func AuthInterceptor(apiSecret string) grpc.UnaryServerInterceptor {
 	return func(
@@ -31,8 +49,8 @@ func AuthInterceptor(apiSecret string) grpc.UnaryServerInterceptor {
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
-		// 跳过健康检查
-		if info.FullMethod == "/grpc.health.v1.Health/Check" {
+		// 跳过健康检查与反射
+		if isAuthExempt(info.FullMethod) {
 			return handler(ctx, req)
 		}
 
@@ -60,6 +78,11 @@ func StreamAuthInterceptor(apiSecret string) grpc.StreamServerInterceptor {
 		info *grpc.StreamServerInfo,
 		handler grpc.StreamHandler,
 	) error {
+		// 跳过健康检查（Watch）与反射
+		if isAuthExempt(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
 		// 验证 API Key
 		md, ok := metadata.FromIncomingContext(ss.Context())
 		if !ok {
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// PayoutServiceName 健康检查中的服务名
+const PayoutServiceName = "payout.PayoutService"
+
+// probeTimeout 单次依赖探测的超时
+const probeTimeout = 3 * time.Second
+
+// Probe 依赖探测，返回 nil 表示可用
+type Probe func(ctx context.Context) error
+
+// HealthReporter 周期性探测依赖并更新 grpc.health.v1 状态
+// 所有依赖可达时为 SERVING，否则为 NOT_SERVING。
+type HealthReporter struct {
+	server *health.Server
+	probes map[string]Probe
+}
+
+// NewHealthReporter 创建健康状态上报器，初始状态为 NOT_SERVING
+func NewHealthReporter(server *health.Server, probes map[string]Probe) *HealthReporter {
+	h := &HealthReporter{server: server, probes: probes}
+	h.setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+	return h
+}
+
+// Run 立即探测一次，之后按 interval 周期探测，直到 ctx 取消
+func (h *HealthReporter) Run(ctx context.Context, interval time.Duration) {
+	h.Check(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// 关闭时通知探测方摘除流量
+			h.server.Shutdown()
+			return
+		case <-ticker.C:
+			h.Check(ctx)
+		}
+	}
+}
+
+// Check 探测所有依赖并更新状态
+func (h *HealthReporter) Check(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	status := healthpb.HealthCheckResponse_SERVING
+	for name, probe := range h.probes {
+		probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+		err := probe(probeCtx)
+		cancel()
+		if err != nil {
+			log.Warn().Err(err).Str("dependency", name).Msg("Health probe failed")
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+	}
+	h.setStatus(status)
+	return status
+}
+
+// setStatus 同时更新整体状态（空服务名）与支付服务状态
+func (h *HealthReporter) setStatus(status healthpb.HealthCheckResponse_ServingStatus) {
+	h.server.SetServingStatus("", status)
+	h.server.SetServingStatus(PayoutServiceName, status)
+}
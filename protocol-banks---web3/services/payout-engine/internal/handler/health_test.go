@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testAPISecret = "test-secret"
+
+// startTestServer 启动带认证拦截器的进程内 gRPC 服务器
+func startTestServer(t *testing.T, register func(s *grpc.Server)) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(AuthInterceptor(testAPISecret)),
+		grpc.StreamInterceptor(StreamAuthInterceptor(testAPISecret)),
+	)
+	register(server)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestHealth_ReportsDependencyStatus(t *testing.T) {
+	healthServer := health.NewServer()
+	conn := startTestServer(t, func(s *grpc.Server) {
+		healthpb.RegisterHealthServer(s, healthServer)
+	})
+
+	var redisDown atomic.Bool
+	reporter := NewHealthReporter(healthServer, map[string]Probe{
+		"redis": func(ctx context.Context) error {
+			if redisDown.Load() {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+		"chain": func(ctx context.Context) error { return nil },
+	})
+
+	client := healthpb.NewHealthClient(conn)
+	ctx := context.Background()
+
+	// Not serving until the first probe succeeds; no API key needed
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	reporter.Check(ctx)
+	resp, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: PayoutServiceName})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	redisDown.Store(true)
+	reporter.Check(ctx)
+	resp, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	// Streaming Watch is exempt from StreamAuthInterceptor too
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	update, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, update.Status)
+}
+
+func TestReflection_ExemptFromAuth(t *testing.T) {
+	conn := startTestServer(t, func(s *grpc.Server) {
+		healthpb.RegisterHealthServer(s, health.NewServer())
+		reflection.Register(s)
+	})
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.GetListServicesResponse().GetService())
+}
+
+func TestAuthInterceptor_RequiresAPIKey(t *testing.T) {
+	interceptor := AuthInterceptor(testAPISecret)
+	info := &grpc.UnaryServerInfo{FullMethod: "/payout.PayoutService/SubmitBatchPayout"}
+	called := false
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	}
+
+	_, err := interceptor(context.Background(), nil, info, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.False(t, called)
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/protocol-bank/payout-engine/internal/pb"
+	"github.com/protocol-bank/payout-engine/internal/service"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StatusSubscriber 支付状态订阅（进程内发布/订阅）
+type StatusSubscriber interface {
+	Subscribe(jobID string) (<-chan *service.PayoutEvent, func())
+}
+
+// StatusStore 支付最新状态查询
+type StatusStore interface {
+	LatestEvent(ctx context.Context, jobID string) (*service.PayoutEvent, error)
+}
+
+// PayoutStatusServer 支付状态流式订阅服务
+type PayoutStatusServer struct {
+	pb.UnimplementedPayoutStatusServiceServer
+	subscriber StatusSubscriber
+	store      StatusStore
+}
+
+// NewPayoutStatusServer 创建支付状态服务
+func NewPayoutStatusServer(subscriber StatusSubscriber, store StatusStore) *PayoutStatusServer {
+	return &PayoutStatusServer{subscriber: subscriber, store: store}
+}
+
+// RegisterPayoutStatusServer 注册 gRPC 服务
+func RegisterPayoutStatusServer(s *grpc.Server, srv *PayoutStatusServer) {
+	pb.RegisterPayoutStatusServiceServer(s, srv)
+	log.Info().Msg("Payout status gRPC server registered")
+}
+
+// WatchPayout 先推送当前状态，再推送后续状态变化，到达终态（已确认/失败/取消）后结束
+func (s *PayoutStatusServer) WatchPayout(req *pb.WatchPayoutRequest, stream pb.PayoutStatusService_WatchPayoutServer) error {
+	if req.PayoutId == "" {
+		return status.Error(codes.InvalidArgument, "payout_id is required")
+	}
+	ctx := stream.Context()
+
+	// 先订阅再查询当前状态，避免两者之间的事件丢失
+	events, unsubscribe := s.subscriber.Subscribe(req.PayoutId)
+	defer unsubscribe()
+
+	latest, err := s.store.LatestEvent(ctx, req.PayoutId)
+	if err != nil {
+		log.Error().Err(err).Str("payout_id", req.PayoutId).Msg("Failed to get payout status")
+		return status.Error(codes.Internal, "failed to get payout status")
+	}
+	if latest == nil {
+		return status.Errorf(codes.NotFound, "payout %s not found", req.PayoutId)
+	}
+
+	if err := stream.Send(toStatusUpdate(latest)); err != nil {
+		return err
+	}
+	if latest.Type.Terminal() {
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case event := <-events:
+			// 跳过查询当前状态之前已发生的事件
+			if !event.Timestamp.After(latest.Timestamp) {
+				continue
+			}
+			latest = event
+
+			if err := stream.Send(toStatusUpdate(event)); err != nil {
+				return err
+			}
+			if event.Type.Terminal() {
+				return nil
+			}
+		}
+	}
+}
+
+// toStatusUpdate 将内部事件转换为 gRPC 消息
+func toStatusUpdate(event *service.PayoutEvent) *pb.PayoutStatusUpdate {
+	return &pb.PayoutStatusUpdate{
+		PayoutId:      event.JobID,
+		BatchId:       event.BatchID,
+		Stage:         toPayoutStage(event.Type),
+		TxHash:        event.TxHash,
+		Confirmations: event.Confirmations,
+		Attempts:      int32(event.Attempts),
+		ErrorMessage:  event.Error,
+		Timestamp:     event.Timestamp.Unix(),
+	}
+}
+
+// toPayoutStage 事件类型 → 状态阶段
+func toPayoutStage(eventType service.PayoutEventType) pb.PayoutStage {
+	switch eventType {
+	case service.PayoutEventQueued:
+		return pb.PayoutStage_PAYOUT_STAGE_QUEUED
+	case service.PayoutEventBroadcast, service.PayoutEventBumped:
+		return pb.PayoutStage_PAYOUT_STAGE_BROADCAST
+	case service.PayoutEventConfirmed:
+		return pb.PayoutStage_PAYOUT_STAGE_CONFIRMED
+	case service.PayoutEventReverted, service.PayoutEventFailed:
+		return pb.PayoutStage_PAYOUT_STAGE_FAILED
+	case service.PayoutEventCancelled:
+		return pb.PayoutStage_PAYOUT_STAGE_CANCELLED
+	default:
+		return pb.PayoutStage_PAYOUT_STAGE_UNSPECIFIED
+	}
+}
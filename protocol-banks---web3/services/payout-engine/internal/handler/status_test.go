@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/protocol-bank/payout-engine/internal/pb"
+	"github.com/protocol-bank/payout-engine/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// memoryStatusStore 保存最新状态，同时转发给 broker，模拟 RedisEventPublisher + Relay
+type memoryStatusStore struct {
+	mu     sync.Mutex
+	latest map[string]*service.PayoutEvent
+	broker *service.StatusBroker
+}
+
+func newMemoryStatusStore(broker *service.StatusBroker) *memoryStatusStore {
+	return &memoryStatusStore{latest: make(map[string]*service.PayoutEvent), broker: broker}
+}
+
+func (s *memoryStatusStore) Publish(ctx context.Context, event *service.PayoutEvent) error {
+	s.mu.Lock()
+	s.latest[event.JobID] = event
+	s.mu.Unlock()
+	return s.broker.Publish(ctx, event)
+}
+
+func (s *memoryStatusStore) LatestEvent(ctx context.Context, jobID string) (*service.PayoutEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.latest[jobID], nil
+}
+
+func startStatusServer(t *testing.T) (pb.PayoutStatusServiceClient, *memoryStatusStore) {
+	broker := service.NewStatusBroker()
+	store := newMemoryStatusStore(broker)
+	conn := startTestServer(t, func(s *grpc.Server) {
+		RegisterPayoutStatusServer(s, NewPayoutStatusServer(broker, store))
+	})
+	return pb.NewPayoutStatusServiceClient(conn), store
+}
+
+func authContext() context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", testAPISecret)
+}
+
+func TestWatchPayout_StreamsTransitionsUntilConfirmed(t *testing.T) {
+	client, store := startStatusServer(t)
+	ctx := context.Background()
+	start := time.Now()
+
+	require.NoError(t, store.Publish(ctx, &service.PayoutEvent{
+		Type: service.PayoutEventQueued, JobID: "payout-1", BatchID: "batch-1", Timestamp: start,
+	}))
+
+	stream, err := client.WatchPayout(authContext(), &pb.WatchPayoutRequest{PayoutId: "payout-1"})
+	require.NoError(t, err)
+
+	// Current state first; once received the server is subscribed
+	update, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, pb.PayoutStage_PAYOUT_STAGE_QUEUED, update.Stage)
+	assert.Equal(t, "batch-1", update.BatchId)
+
+	require.NoError(t, store.Publish(ctx, &service.PayoutEvent{
+		Type: service.PayoutEventBroadcast, JobID: "payout-1", TxHash: "0xaaa", Timestamp: start.Add(time.Second),
+	}))
+	require.NoError(t, store.Publish(ctx, &service.PayoutEvent{
+		Type: service.PayoutEventBumped, JobID: "payout-1", TxHash: "0xbbb", Attempts: 1, Timestamp: start.Add(2 * time.Second),
+	}))
+	require.NoError(t, store.Publish(ctx, &service.PayoutEvent{
+		Type: service.PayoutEventConfirmed, JobID: "payout-1", TxHash: "0xbbb", Attempts: 1, Confirmations: 1, Timestamp: start.Add(3 * time.Second),
+	}))
+	// Events for other payouts are not delivered
+	require.NoError(t, store.Publish(ctx, &service.PayoutEvent{
+		Type: service.PayoutEventFailed, JobID: "payout-2", Timestamp: start.Add(3 * time.Second),
+	}))
+
+	update, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, pb.PayoutStage_PAYOUT_STAGE_BROADCAST, update.Stage)
+	assert.Equal(t, "0xaaa", update.TxHash)
+
+	update, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, pb.PayoutStage_PAYOUT_STAGE_BROADCAST, update.Stage)
+	assert.Equal(t, "0xbbb", update.TxHash)
+	assert.Equal(t, int32(1), update.Attempts)
+
+	update, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, pb.PayoutStage_PAYOUT_STAGE_CONFIRMED, update.Stage)
+	assert.Equal(t, uint64(1), update.Confirmations)
+
+	// Terminal state closes the stream
+	_, err = stream.Recv()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestWatchPayout_TerminalStateEndsImmediately(t *testing.T) {
+	client, store := startStatusServer(t)
+	require.NoError(t, store.Publish(context.Background(), &service.PayoutEvent{
+		Type: service.PayoutEventFailed, JobID: "payout-1", Error: "max retries", Timestamp: time.Now(),
+	}))
+
+	stream, err := client.WatchPayout(authContext(), &pb.WatchPayoutRequest{PayoutId: "payout-1"})
+	require.NoError(t, err)
+
+	update, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, pb.PayoutStage_PAYOUT_STAGE_FAILED, update.Stage)
+	assert.Equal(t, "max retries", update.ErrorMessage)
+
+	_, err = stream.Recv()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestWatchPayout_Errors(t *testing.T) {
+	client, _ := startStatusServer(t)
+
+	// Protected by StreamAuthInterceptor
+	stream, err := client.WatchPayout(context.Background(), &pb.WatchPayoutRequest{PayoutId: "payout-1"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	stream, err = client.WatchPayout(authContext(), &pb.WatchPayoutRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	stream, err = client.WatchPayout(authContext(), &pb.WatchPayoutRequest{PayoutId: "unknown"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -7,19 +7,27 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/go-redis/redis/v8"
 	"github.com/protocol-bank/payout-engine/internal/config"
 	"github.com/rs/zerolog/log"
 )
 
+// ChainClient 查询链上 Nonce 的客户端，*ethclient.Client 满足该接口
+type ChainClient interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
 // Manager 管理多链多地址的 Nonce
 type Manager struct {
 	redis       *redis.Client
-	clients     map[uint64]*ethclient.Client
+	clients     map[uint64]ChainClient
 	localNonces map[string]uint64 // key: chainID:address
 	mu          sync.RWMutex
 	lockTTL     time.Duration
+
+	// 缺口修复
+	resubmitter Resubmitter
+	now         func() time.Time
 }
 
 // NewManager 创建 Nonce 管理器
@@ -34,16 +42,31 @@ func NewManager(ctx context.Context, cfg config.RedisConfig) (*Manager, error) {
 		return nil, fmt.Errorf("redis connection failed: %w", err)
 	}
 
+	return newManager(rdb), nil
+}
+
+func newManager(rdb *redis.Client) *Manager {
 	return &Manager{
 		redis:       rdb,
-		clients:     make(map[uint64]*ethclient.Client),
+		clients:     make(map[uint64]ChainClient),
 		localNonces: make(map[string]uint64),
 		lockTTL:     30 * time.Second,
-	}, nil
+		now:         time.Now,
+	}
+}
+
+// nonceKey Redis 中保存下一个可用 Nonce 的键
+func nonceKey(chainID uint64, address common.Address) string {
+	return fmt.Sprintf("nonce:%d:%s", chainID, address.Hex())
+}
+
+// Ping 检查 Redis 连接，用于健康检查
+func (m *Manager) Ping(ctx context.Context) error {
+	return m.redis.Ping(ctx).Err()
 }
 
 // AddChainClient 添加链客户端
-func (m *Manager) AddChainClient(chainID uint64, client *ethclient.Client) {
+func (m *Manager) AddChainClient(chainID uint64, client ChainClient) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.clients[chainID] = client
@@ -51,7 +74,7 @@ func (m *Manager) AddChainClient(chainID uint64, client *ethclient.Client) {
 
 // GetNonce 获取下一个可用的 Nonce（带分布式锁）
 func (m *Manager) GetNonce(ctx context.Context, chainID uint64, address common.Address) (uint64, func(), error) {
-	key := fmt.Sprintf("nonce:%d:%s", chainID, address.Hex())
+	key := nonceKey(chainID, address)
 	lockKey := fmt.Sprintf("lock:%s", key)
 
 	// 获取分布式锁
@@ -77,6 +100,9 @@ func (m *Manager) GetNonce(ctx context.Context, chainID uint64, address common.A
 	// 预增加 Nonce
 	m.incrementNonce(ctx, key)
 
+	// 记录发送地址，供缺口检测遍历
+	m.trackAddress(ctx, chainID, address)
+
 	return nonce, releaseFn, nil
 }
 
@@ -115,8 +141,7 @@ func (m *Manager) incrementNonce(ctx context.Context, key string) {
 
 // ResetNonce 重置 Nonce（交易失败时使用）
 func (m *Manager) ResetNonce(ctx context.Context, chainID uint64, address common.Address) error {
-	key := fmt.Sprintf("nonce:%d:%s", chainID, address.Hex())
-	return m.redis.Del(ctx, key).Err()
+	return m.redis.Del(ctx, nonceKey(chainID, address)).Err()
 }
 
 // acquireLock 获取分布式锁
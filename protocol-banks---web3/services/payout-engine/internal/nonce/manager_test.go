@@ -2,178 +2,340 @@ package nonce
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
-	"github.com/redis/go-redis/v9"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func setupTestRedis(t *testing.T) (*redis.Client, func()) {
-	mr, err := miniredis.Run()
-	require.NoError(t, err)
+// mockChainClient 可控的链上 pending Nonce
+type mockChainClient struct {
+	mu      sync.Mutex
+	pending map[common.Address]uint64
+}
 
-	client := redis.NewClient(&redis.Options{
-		Addr: mr.Addr(),
-	})
+func newMockChainClient() *mockChainClient {
+	return &mockChainClient{pending: make(map[common.Address]uint64)}
+}
 
-	return client, func() {
-		client.Close()
-		mr.Close()
-	}
+func (c *mockChainClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pending[account], nil
 }
 
-func TestNonceManager_GetAndIncrement(t *testing.T) {
-	rdb, cleanup := setupTestRedis(t)
-	defer cleanup()
+func (c *mockChainClient) setPending(account common.Address, nonce uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending[account] = nonce
+}
 
-	nm := NewManager(rdb)
-	ctx := context.Background()
-	address := "0x1234567890123456789012345678901234567890"
-	chainID := int64(1)
+// mockResubmitter 记录重新广播的 Nonce；tracked 表示仍有跟踪中的交易占用缺口之后的 Nonce
+type mockResubmitter struct {
+	err     error
+	nonces  []uint64
+	tracked bool
+}
 
-	// First call should initialize nonce to 0
-	nonce1, err := nm.GetAndIncrement(ctx, address, chainID)
-	require.NoError(t, err)
-	assert.Equal(t, uint64(0), nonce1)
+func (r *mockResubmitter) ResubmitNonce(ctx context.Context, chainID uint64, address common.Address, nonce uint64) error {
+	r.nonces = append(r.nonces, nonce)
+	return r.err
+}
 
-	// Second call should return 1
-	nonce2, err := nm.GetAndIncrement(ctx, address, chainID)
+func (r *mockResubmitter) HasTrackedNonce(ctx context.Context, chainID uint64, address common.Address, nonce uint64) (bool, error) {
+	return r.tracked, nil
+}
+
+func setupTestManager(t *testing.T) (*Manager, *mockChainClient, *miniredis.Miniredis) {
+	mr, err := miniredis.Run()
 	require.NoError(t, err)
-	assert.Equal(t, uint64(1), nonce2)
 
-	// Third call should return 2
-	nonce3, err := nm.GetAndIncrement(ctx, address, chainID)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		rdb.Close()
+		mr.Close()
+	})
+
+	nm := newManager(rdb)
+	client := newMockChainClient()
+	nm.AddChainClient(1, client)
+	nm.AddChainClient(137, client)
+	return nm, client, mr
+}
+
+func getNonce(t *testing.T, nm *Manager, chainID uint64, address common.Address) uint64 {
+	t.Helper()
+	nonce, release, err := nm.GetNonce(context.Background(), chainID, address)
 	require.NoError(t, err)
-	assert.Equal(t, uint64(2), nonce3)
+	release()
+	return nonce
 }
 
-func TestNonceManager_SetNonce(t *testing.T) {
-	rdb, cleanup := setupTestRedis(t)
-	defer cleanup()
+func TestNonceManager_GetAndIncrement(t *testing.T) {
+	nm, _, _ := setupTestManager(t)
+	address := common.HexToAddress("0x1234567890123456789012345678901234567890")
 
-	nm := NewManager(rdb)
-	ctx := context.Background()
-	address := "0x1234567890123456789012345678901234567890"
-	chainID := int64(1)
+	// First call should initialize nonce from the chain (0)
+	assert.Equal(t, uint64(0), getNonce(t, nm, 1, address))
 
-	// Set nonce to specific value
-	err := nm.SetNonce(ctx, address, chainID, 100)
-	require.NoError(t, err)
+	// Subsequent calls increment
+	assert.Equal(t, uint64(1), getNonce(t, nm, 1, address))
+	assert.Equal(t, uint64(2), getNonce(t, nm, 1, address))
+}
 
-	// Get should return 100
-	nonce, err := nm.GetAndIncrement(ctx, address, chainID)
-	require.NoError(t, err)
-	assert.Equal(t, uint64(100), nonce)
+func TestNonceManager_InitializesFromChain(t *testing.T) {
+	nm, client, _ := setupTestManager(t)
+	address := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	client.setPending(address, 100)
+
+	assert.Equal(t, uint64(100), getNonce(t, nm, 1, address))
+	assert.Equal(t, uint64(101), getNonce(t, nm, 1, address))
 }
 
 func TestNonceManager_Reset(t *testing.T) {
-	rdb, cleanup := setupTestRedis(t)
-	defer cleanup()
+	nm, _, _ := setupTestManager(t)
+	address := common.HexToAddress("0x1234567890123456789012345678901234567890")
 
-	nm := NewManager(rdb)
-	ctx := context.Background()
-	address := "0x1234567890123456789012345678901234567890"
-	chainID := int64(1)
+	getNonce(t, nm, 1, address)
+	getNonce(t, nm, 1, address)
+	getNonce(t, nm, 1, address)
 
-	// Increment a few times
-	nm.GetAndIncrement(ctx, address, chainID)
-	nm.GetAndIncrement(ctx, address, chainID)
-	nm.GetAndIncrement(ctx, address, chainID)
+	require.NoError(t, nm.ResetNonce(context.Background(), 1, address))
 
-	// Reset
-	err := nm.Reset(ctx, address, chainID)
-	require.NoError(t, err)
-
-	// Should start from 0 again
-	nonce, err := nm.GetAndIncrement(ctx, address, chainID)
-	require.NoError(t, err)
-	assert.Equal(t, uint64(0), nonce)
+	// Should start from the chain value (0) again
+	assert.Equal(t, uint64(0), getNonce(t, nm, 1, address))
 }
 
 func TestNonceManager_ConcurrentAccess(t *testing.T) {
-	rdb, cleanup := setupTestRedis(t)
-	defer cleanup()
+	nm, _, _ := setupTestManager(t)
+	address := common.HexToAddress("0x1234567890123456789012345678901234567890")
 
-	nm := NewManager(rdb)
-	ctx := context.Background()
-	address := "0x1234567890123456789012345678901234567890"
-	chainID := int64(1)
-
-	// Run 100 concurrent goroutines
-	numGoroutines := 100
+	numGoroutines := 20
 	results := make(chan uint64, numGoroutines)
 
 	for i := 0; i < numGoroutines; i++ {
 		go func() {
-			nonce, err := nm.GetAndIncrement(ctx, address, chainID)
-			if err != nil {
-				t.Error(err)
-				return
+			// 锁忙时重试，与支付任务重新入队的行为一致
+			for {
+				nonce, release, err := nm.GetNonce(context.Background(), 1, address)
+				if err == nil {
+					release()
+					results <- nonce
+					return
+				}
 			}
-			results <- nonce
 		}()
 	}
 
-	// Collect results
 	seen := make(map[uint64]bool)
 	for i := 0; i < numGoroutines; i++ {
 		select {
 		case nonce := <-results:
-			// Each nonce should be unique
 			assert.False(t, seen[nonce], "Duplicate nonce detected: %d", nonce)
 			seen[nonce] = true
-		case <-time.After(5 * time.Second):
+		case <-time.After(10 * time.Second):
 			t.Fatal("Timeout waiting for goroutines")
 		}
 	}
 
-	// Should have exactly numGoroutines unique nonces
 	assert.Equal(t, numGoroutines, len(seen))
 }
 
 func TestNonceManager_MultipleAddresses(t *testing.T) {
-	rdb, cleanup := setupTestRedis(t)
-	defer cleanup()
-
-	nm := NewManager(rdb)
-	ctx := context.Background()
-	address1 := "0x1111111111111111111111111111111111111111"
-	address2 := "0x2222222222222222222222222222222222222222"
-	chainID := int64(1)
+	nm, _, _ := setupTestManager(t)
+	address1 := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	address2 := common.HexToAddress("0x2222222222222222222222222222222222222222")
 
-	// Increment address1
-	nonce1a, _ := nm.GetAndIncrement(ctx, address1, chainID)
-	nonce1b, _ := nm.GetAndIncrement(ctx, address1, chainID)
+	nonce1a := getNonce(t, nm, 1, address1)
+	nonce1b := getNonce(t, nm, 1, address1)
+	nonce2a := getNonce(t, nm, 1, address2)
 
-	// Increment address2
-	nonce2a, _ := nm.GetAndIncrement(ctx, address2, chainID)
-
-	// Address1 should be at 0, 1
 	assert.Equal(t, uint64(0), nonce1a)
 	assert.Equal(t, uint64(1), nonce1b)
 
-	// Address2 should be at 0 (independent counter)
+	// Address2 has an independent counter
 	assert.Equal(t, uint64(0), nonce2a)
 }
 
 func TestNonceManager_MultipleChains(t *testing.T) {
-	rdb, cleanup := setupTestRedis(t)
-	defer cleanup()
+	nm, _, _ := setupTestManager(t)
+	address := common.HexToAddress("0x1234567890123456789012345678901234567890")
+
+	nonceEth := getNonce(t, nm, 1, address)
+	noncePoly := getNonce(t, nm, 137, address)
 
-	nm := NewManager(rdb)
+	// Each chain has an independent counter
+	assert.Equal(t, uint64(0), nonceEth)
+	assert.Equal(t, uint64(0), noncePoly)
+}
+
+func TestReconcile_NoGap(t *testing.T) {
+	nm, client, _ := setupTestManager(t)
+	address := common.HexToAddress("0x1234567890123456789012345678901234567890")
+
+	getNonce(t, nm, 1, address) // nonce 0 broadcast
+	client.setPending(address, 1)
+
+	action, err := nm.ReconcileAddress(context.Background(), 1, address, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, GapActionNone, action)
+}
+
+func TestReconcile_SyncsWhenCacheBehindChain(t *testing.T) {
+	nm, client, _ := setupTestManager(t)
+	address := common.HexToAddress("0x1234567890123456789012345678901234567890")
+
+	getNonce(t, nm, 1, address)
+	client.setPending(address, 5) // another sender advanced the account
+
+	action, err := nm.ReconcileAddress(context.Background(), 1, address, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, GapActionSynced, action)
+	assert.Equal(t, uint64(5), getNonce(t, nm, 1, address))
+}
+
+func TestReconcile_ResetsGapAfterTimeout(t *testing.T) {
+	nm, client, _ := setupTestManager(t)
+	address := common.HexToAddress("0x1234567890123456789012345678901234567890")
 	ctx := context.Background()
-	address := "0x1234567890123456789012345678901234567890"
 
-	// Increment on Ethereum mainnet
-	nonceEth, _ := nm.GetAndIncrement(ctx, address, 1)
+	now := time.Unix(1_700_000_000, 0)
+	nm.now = func() time.Time { return now }
 
-	// Increment on Polygon
-	noncePoly, _ := nm.GetAndIncrement(ctx, address, 137)
+	// Nonces 0..2 handed out, but nonce 1 was dropped: chain only has 0 pending
+	getNonce(t, nm, 1, address)
+	getNonce(t, nm, 1, address)
+	getNonce(t, nm, 1, address)
+	client.setPending(address, 1)
 
-	// Each chain should have independent counters
-	assert.Equal(t, uint64(0), nonceEth)
-	assert.Equal(t, uint64(0), noncePoly)
+	action, err := nm.ReconcileAddress(ctx, 1, address, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, GapActionDetected, action)
+
+	now = now.Add(30 * time.Second)
+	action, err = nm.ReconcileAddress(ctx, 1, address, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, GapActionWaiting, action)
+
+	now = now.Add(time.Minute)
+	action, err = nm.ReconcileAddress(ctx, 1, address, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, GapActionReset, action)
+
+	// Next payout fills the gap
+	assert.Equal(t, uint64(1), getNonce(t, nm, 1, address))
+}
+
+func TestReconcile_ResubmitsBeforeReset(t *testing.T) {
+	nm, client, _ := setupTestManager(t)
+	address := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	ctx := context.Background()
+
+	resubmitter := &mockResubmitter{}
+	nm.SetResubmitter(resubmitter)
+
+	now := time.Unix(1_700_000_000, 0)
+	nm.now = func() time.Time { return now }
+
+	getNonce(t, nm, 1, address)
+	getNonce(t, nm, 1, address)
+	client.setPending(address, 0)
+
+	_, err := nm.ReconcileAddress(ctx, 1, address, time.Minute)
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Minute)
+	action, err := nm.ReconcileAddress(ctx, 1, address, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, GapActionResubmit, action)
+	assert.Equal(t, []uint64{0}, resubmitter.nonces)
+
+	// Resubmission did not help: reset on the next timeout
+	now = now.Add(2 * time.Minute)
+	action, err = nm.ReconcileAddress(ctx, 1, address, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, GapActionReset, action)
+	assert.Equal(t, []uint64{0}, resubmitter.nonces, "resubmit only once per gap")
+}
+
+func TestReconcile_ResetsWhenResubmitFails(t *testing.T) {
+	nm, client, _ := setupTestManager(t)
+	address := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	ctx := context.Background()
+
+	nm.SetResubmitter(&mockResubmitter{err: errors.New("signed tx not found")})
+	now := time.Unix(1_700_000_000, 0)
+	nm.now = func() time.Time { return now }
+
+	getNonce(t, nm, 1, address)
+	client.setPending(address, 0)
+
+	_, err := nm.ReconcileAddress(ctx, 1, address, time.Minute)
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Minute)
+	action, err := nm.ReconcileAddress(ctx, 1, address, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, GapActionReset, action)
+}
+
+func TestReconcile_HoldsGapWhileNoncesAreTracked(t *testing.T) {
+	nm, client, _ := setupTestManager(t)
+	address := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	ctx := context.Background()
+
+	// Nonce 1 is still awaiting confirmation: resetting would hand it to a new payout
+	resubmitter := &mockResubmitter{err: errors.New("signed tx not found"), tracked: true}
+	nm.SetResubmitter(resubmitter)
+	now := time.Unix(1_700_000_000, 0)
+	nm.now = func() time.Time { return now }
+
+	getNonce(t, nm, 1, address)
+	getNonce(t, nm, 1, address)
+	client.setPending(address, 0)
+
+	_, err := nm.ReconcileAddress(ctx, 1, address, time.Minute)
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Minute)
+	action, err := nm.ReconcileAddress(ctx, 1, address, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, GapActionHeld, action)
+
+	// The gap waits out another timeout, then the missing nonce is resubmitted again
+	now = now.Add(30 * time.Second)
+	action, err = nm.ReconcileAddress(ctx, 1, address, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, GapActionWaiting, action)
+
+	now = now.Add(time.Minute)
+	action, err = nm.ReconcileAddress(ctx, 1, address, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, GapActionHeld, action)
+	assert.Equal(t, []uint64{0, 0}, resubmitter.nonces)
+
+	// Once nothing is tracked the gap is reset as before
+	resubmitter.tracked = false
+	now = now.Add(2 * time.Minute)
+	action, err = nm.ReconcileAddress(ctx, 1, address, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, GapActionReset, action)
+	assert.Equal(t, uint64(0), getNonce(t, nm, 1, address))
+}
+
+func TestReconcile_VisitsTrackedAddresses(t *testing.T) {
+	nm, client, _ := setupTestManager(t)
+	address := common.HexToAddress("0x1234567890123456789012345678901234567890")
+
+	getNonce(t, nm, 1, address)
+	client.setPending(address, 3)
+
+	require.NoError(t, nm.Reconcile(context.Background(), time.Minute))
+	assert.Equal(t, uint64(3), getNonce(t, nm, 1, address))
 }
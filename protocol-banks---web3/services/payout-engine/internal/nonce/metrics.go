@@ -0,0 +1,36 @@
+package nonce
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Nonce 缺口指标
+var (
+	// 检测到的缺口数
+	NonceGapDetectedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nonce_gap_detected_total",
+			Help: "Total number of nonce gaps detected between Redis and the chain",
+		},
+		[]string{"chain_id"},
+	)
+
+	// 缺口修复次数（resubmit / reset）
+	NonceGapResolvedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nonce_gap_resolved_total",
+			Help: "Total number of nonce gaps resolved, by action",
+		},
+		[]string{"chain_id", "action"},
+	)
+
+	// 当前缺口大小
+	NonceGapSize = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nonce_gap_size",
+			Help: "Number of nonces handed out but missing from the chain's pending pool",
+		},
+		[]string{"chain_id", "address"},
+	)
+)
@@ -0,0 +1,252 @@
+package nonce
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-redis/redis/v8"
+	"github.com/rs/zerolog/log"
+)
+
+// addressesKey 记录所有发送过交易的 chainID:address
+const addressesKey = "nonce:addresses"
+
+// GapAction 一次对账的结果
+type GapAction string
+
+const (
+	GapActionNone      GapAction = "none"       // Redis 与链上一致
+	GapActionSynced    GapAction = "synced"     // Redis 落后于链上，已前移
+	GapActionDetected  GapAction = "detected"   // 发现缺口，等待超时
+	GapActionWaiting   GapAction = "waiting"    // 缺口仍在等待超时
+	GapActionResubmit  GapAction = "resubmit"   // 已重新广播缺失的 Nonce
+	GapActionHeld      GapAction = "held"       // 缺口之后的 Nonce 仍被跟踪中的交易占用，不重置
+	GapActionReset     GapAction = "reset"      // 已将 Redis 重置为链上值
+	GapActionLockBusy  GapAction = "lock_busy"  // Nonce 正在被分配，下一轮再检查
+	GapActionNotCached GapAction = "not_cached" // Redis 中没有缓存
+)
+
+// Resubmitter 重新广播某个 Nonce 上的交易（交易被内存池丢弃时使用）
+type Resubmitter interface {
+	ResubmitNonce(ctx context.Context, chainID uint64, address common.Address, nonce uint64) error
+	// HasTrackedNonce 是否仍有等待确认的交易占用不小于 nonce 的 Nonce
+	HasTrackedNonce(ctx context.Context, chainID uint64, address common.Address, nonce uint64) (bool, error)
+}
+
+// SetResubmitter 设置缺口修复时使用的重新广播器；未设置时缺口超时后直接重置 Redis
+func (m *Manager) SetResubmitter(r Resubmitter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resubmitter = r
+}
+
+// trackAddress 记录发送地址
+func (m *Manager) trackAddress(ctx context.Context, chainID uint64, address common.Address) {
+	member := fmt.Sprintf("%d:%s", chainID, address.Hex())
+	if err := m.redis.SAdd(ctx, addressesKey, member).Err(); err != nil {
+		log.Warn().Err(err).Str("member", member).Msg("Failed to track nonce address")
+	}
+}
+
+// RunReconciler 周期性对账 Redis Nonce 与链上 pending Nonce，直到 ctx 取消
+func (m *Manager) RunReconciler(ctx context.Context, interval, gapTimeout time.Duration) {
+	log.Info().Dur("interval", interval).Dur("gap_timeout", gapTimeout).Msg("Starting nonce reconciler")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Reconcile(ctx, gapTimeout); err != nil {
+				log.Error().Err(err).Msg("Nonce reconciliation failed")
+			}
+		}
+	}
+}
+
+// Reconcile 对所有发送地址执行一次对账
+func (m *Manager) Reconcile(ctx context.Context, gapTimeout time.Duration) error {
+	members, err := m.redis.SMembers(ctx, addressesKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list nonce addresses: %w", err)
+	}
+
+	for _, member := range members {
+		parts := strings.SplitN(member, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		chainID, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		address := common.HexToAddress(parts[1])
+
+		if _, err := m.ReconcileAddress(ctx, chainID, address, gapTimeout); err != nil {
+			log.Error().
+				Err(err).
+				Uint64("chain_id", chainID).
+				Str("address", address.Hex()).
+				Msg("Failed to reconcile nonce")
+		}
+	}
+	return nil
+}
+
+// ReconcileAddress 对账单个地址
+// Redis 中的值是下一个要分配的 Nonce；若大于链上 pending Nonce，说明中间的交易已被内存池丢弃，
+// 后续交易会卡在缺口之后。缺口持续超过 gapTimeout 时先尝试重新广播缺失的 Nonce，
+// 重新广播后仍未恢复（或没有重新广播器）则把 Redis 重置为链上值，由后续支付填补缺口；
+// 缺口之后仍有等待确认的交易时不重置，以免新支付复用其 Nonce。
+func (m *Manager) ReconcileAddress(ctx context.Context, chainID uint64, address common.Address, gapTimeout time.Duration) (GapAction, error) {
+	m.mu.RLock()
+	client, ok := m.clients[chainID]
+	resubmitter := m.resubmitter
+	m.mu.RUnlock()
+	if !ok {
+		return GapActionNone, fmt.Errorf("no client for chain %d", chainID)
+	}
+
+	key := nonceKey(chainID, address)
+	gapKey := fmt.Sprintf("nonce:gap:%d:%s", chainID, address.Hex())
+	chainLabel := strconv.FormatUint(chainID, 10)
+
+	// 与 GetNonce 互斥，只尝试一次：正在分配时跳过本轮
+	lockKey := fmt.Sprintf("lock:%s", key)
+	acquired, err := m.redis.SetNX(ctx, lockKey, "1", m.lockTTL).Result()
+	if err != nil {
+		return GapActionNone, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	if !acquired {
+		return GapActionLockBusy, nil
+	}
+	defer m.releaseLock(ctx, lockKey)
+
+	cached, err := m.redis.Get(ctx, key).Uint64()
+	if err == redis.Nil {
+		m.redis.Del(ctx, gapKey)
+		NonceGapSize.WithLabelValues(chainLabel, address.Hex()).Set(0)
+		return GapActionNotCached, nil
+	}
+	if err != nil {
+		return GapActionNone, fmt.Errorf("failed to read cached nonce: %w", err)
+	}
+
+	pending, err := client.PendingNonceAt(ctx, address)
+	if err != nil {
+		return GapActionNone, fmt.Errorf("failed to get pending nonce: %w", err)
+	}
+
+	switch {
+	case cached == pending:
+		m.redis.Del(ctx, gapKey)
+		NonceGapSize.WithLabelValues(chainLabel, address.Hex()).Set(0)
+		return GapActionNone, nil
+
+	case cached < pending:
+		// 其他发送方推进了链上 Nonce，直接前移
+		if err := m.redis.Set(ctx, key, pending, 10*time.Minute).Err(); err != nil {
+			return GapActionNone, fmt.Errorf("failed to sync nonce: %w", err)
+		}
+		m.redis.Del(ctx, gapKey)
+		NonceGapSize.WithLabelValues(chainLabel, address.Hex()).Set(0)
+		log.Info().
+			Uint64("chain_id", chainID).
+			Str("address", address.Hex()).
+			Uint64("cached", cached).
+			Uint64("pending", pending).
+			Msg("Nonce cache behind chain, synced")
+		return GapActionSynced, nil
+	}
+
+	// cached > pending：存在缺口
+	NonceGapSize.WithLabelValues(chainLabel, address.Hex()).Set(float64(cached - pending))
+	now := m.now()
+
+	state, err := m.redis.HGetAll(ctx, gapKey).Result()
+	if err != nil {
+		return GapActionNone, fmt.Errorf("failed to read gap state: %w", err)
+	}
+	since, _ := strconv.ParseInt(state["since"], 10, 64)
+	gapPending, _ := strconv.ParseUint(state["pending"], 10, 64)
+
+	// 新缺口，或链上有进展（缺口被部分填补）时重新计时
+	if since == 0 || gapPending != pending {
+		if err := m.redis.HSet(ctx, gapKey, "since", now.Unix(), "pending", pending, "resubmitted", 0).Err(); err != nil {
+			return GapActionNone, fmt.Errorf("failed to record gap: %w", err)
+		}
+		m.redis.Expire(ctx, gapKey, 24*time.Hour)
+		NonceGapDetectedTotal.WithLabelValues(chainLabel).Inc()
+		log.Warn().
+			Uint64("chain_id", chainID).
+			Str("address", address.Hex()).
+			Uint64("cached", cached).
+			Uint64("pending", pending).
+			Msg("Nonce gap detected")
+		return GapActionDetected, nil
+	}
+
+	if now.Sub(time.Unix(since, 0)) < gapTimeout {
+		return GapActionWaiting, nil
+	}
+
+	// 超时：先尝试重新广播一次
+	if resubmitter != nil && state["resubmitted"] != "1" {
+		err := resubmitter.ResubmitNonce(ctx, chainID, address, pending)
+		if err == nil {
+			m.redis.HSet(ctx, gapKey, "since", now.Unix(), "resubmitted", 1)
+			NonceGapResolvedTotal.WithLabelValues(chainLabel, string(GapActionResubmit)).Inc()
+			log.Info().
+				Uint64("chain_id", chainID).
+				Str("address", address.Hex()).
+				Uint64("nonce", pending).
+				Msg("Resubmitted missing nonce")
+			return GapActionResubmit, nil
+		}
+		log.Warn().
+			Err(err).
+			Uint64("chain_id", chainID).
+			Str("address", address.Hex()).
+			Uint64("nonce", pending).
+			Msg("Failed to resubmit missing nonce, resetting")
+	}
+
+	// 重置会让新支付复用仍被跟踪交易占用的 Nonce 并替换掉这些支付：保留缺口，下次超时再重新广播
+	if resubmitter != nil {
+		held, err := resubmitter.HasTrackedNonce(ctx, chainID, address, pending)
+		if err != nil {
+			return GapActionNone, fmt.Errorf("failed to check tracked nonces: %w", err)
+		}
+		if held {
+			m.redis.HSet(ctx, gapKey, "since", now.Unix(), "resubmitted", 0)
+			log.Warn().
+				Uint64("chain_id", chainID).
+				Str("address", address.Hex()).
+				Uint64("cached", cached).
+				Uint64("pending", pending).
+				Msg("Nonce gap held by tracked transactions, not resetting")
+			return GapActionHeld, nil
+		}
+	}
+
+	if err := m.redis.Set(ctx, key, pending, 10*time.Minute).Err(); err != nil {
+		return GapActionNone, fmt.Errorf("failed to reset nonce: %w", err)
+	}
+	m.redis.Del(ctx, gapKey)
+	NonceGapSize.WithLabelValues(chainLabel, address.Hex()).Set(0)
+	NonceGapResolvedTotal.WithLabelValues(chainLabel, string(GapActionReset)).Inc()
+	log.Warn().
+		Uint64("chain_id", chainID).
+		Str("address", address.Hex()).
+		Uint64("cached", cached).
+		Uint64("pending", pending).
+		Msg("Nonce gap timed out, reset to chain value")
+	return GapActionReset, nil
+}
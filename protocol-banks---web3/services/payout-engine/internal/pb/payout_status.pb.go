@@ -0,0 +1,363 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.33.0
+// 	protoc        (unknown)
+// source: payout_status.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// 支付状态阶段
+type PayoutStage int32
+
+const (
+	PayoutStage_PAYOUT_STAGE_UNSPECIFIED PayoutStage = 0
+	PayoutStage_PAYOUT_STAGE_QUEUED      PayoutStage = 1 // 已入队
+	PayoutStage_PAYOUT_STAGE_BROADCAST   PayoutStage = 2 // 交易已广播 (含加价重发)
+	PayoutStage_PAYOUT_STAGE_CONFIRMED   PayoutStage = 3 // 已确认
+	PayoutStage_PAYOUT_STAGE_FAILED      PayoutStage = 4 // 失败 (回滚、放弃重发或进入死信队列)
+	PayoutStage_PAYOUT_STAGE_CANCELLED   PayoutStage = 5 // 已取消 (广播前取消或替换交易被打包)
+)
+
+// Enum value maps for PayoutStage.
+var (
+	PayoutStage_name = map[int32]string{
+		0: "PAYOUT_STAGE_UNSPECIFIED",
+		1: "PAYOUT_STAGE_QUEUED",
+		2: "PAYOUT_STAGE_BROADCAST",
+		3: "PAYOUT_STAGE_CONFIRMED",
+		4: "PAYOUT_STAGE_FAILED",
+		5: "PAYOUT_STAGE_CANCELLED",
+	}
+	PayoutStage_value = map[string]int32{
+		"PAYOUT_STAGE_UNSPECIFIED": 0,
+		"PAYOUT_STAGE_QUEUED":      1,
+		"PAYOUT_STAGE_BROADCAST":   2,
+		"PAYOUT_STAGE_CONFIRMED":   3,
+		"PAYOUT_STAGE_FAILED":      4,
+		"PAYOUT_STAGE_CANCELLED":   5,
+	}
+)
+
+func (x PayoutStage) Enum() *PayoutStage {
+	p := new(PayoutStage)
+	*p = x
+	return p
+}
+
+func (x PayoutStage) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PayoutStage) Descriptor() protoreflect.EnumDescriptor {
+	return file_payout_status_proto_enumTypes[0].Descriptor()
+}
+
+func (PayoutStage) Type() protoreflect.EnumType {
+	return &file_payout_status_proto_enumTypes[0]
+}
+
+func (x PayoutStage) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PayoutStage.Descriptor instead.
+func (PayoutStage) EnumDescriptor() ([]byte, []int) {
+	return file_payout_status_proto_rawDescGZIP(), []int{0}
+}
+
+// 订阅请求
+type WatchPayoutRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PayoutId string `protobuf:"bytes,1,opt,name=payout_id,json=payoutId,proto3" json:"payout_id,omitempty"` // 支付项 ID
+}
+
+func (x *WatchPayoutRequest) Reset() {
+	*x = WatchPayoutRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_payout_status_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchPayoutRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchPayoutRequest) ProtoMessage() {}
+
+func (x *WatchPayoutRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payout_status_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchPayoutRequest.ProtoReflect.Descriptor instead.
+func (*WatchPayoutRequest) Descriptor() ([]byte, []int) {
+	return file_payout_status_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *WatchPayoutRequest) GetPayoutId() string {
+	if x != nil {
+		return x.PayoutId
+	}
+	return ""
+}
+
+// 状态变化
+type PayoutStatusUpdate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PayoutId      string      `protobuf:"bytes,1,opt,name=payout_id,json=payoutId,proto3" json:"payout_id,omitempty"`
+	BatchId       string      `protobuf:"bytes,2,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	Stage         PayoutStage `protobuf:"varint,3,opt,name=stage,proto3,enum=payout.PayoutStage" json:"stage,omitempty"`
+	TxHash        string      `protobuf:"bytes,4,opt,name=tx_hash,json=txHash,proto3" json:"tx_hash,omitempty"`                   // 交易哈希
+	Confirmations uint64      `protobuf:"varint,5,opt,name=confirmations,proto3" json:"confirmations,omitempty"`                  // 确认数
+	Attempts      int32       `protobuf:"varint,6,opt,name=attempts,proto3" json:"attempts,omitempty"`                            // 加价重发次数
+	ErrorMessage  string      `protobuf:"bytes,7,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"` // 错误信息
+	Timestamp     int64       `protobuf:"varint,8,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                          // 状态时间 (Unix timestamp)
+}
+
+func (x *PayoutStatusUpdate) Reset() {
+	*x = PayoutStatusUpdate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_payout_status_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PayoutStatusUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PayoutStatusUpdate) ProtoMessage() {}
+
+func (x *PayoutStatusUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_payout_status_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PayoutStatusUpdate.ProtoReflect.Descriptor instead.
+func (*PayoutStatusUpdate) Descriptor() ([]byte, []int) {
+	return file_payout_status_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PayoutStatusUpdate) GetPayoutId() string {
+	if x != nil {
+		return x.PayoutId
+	}
+	return ""
+}
+
+func (x *PayoutStatusUpdate) GetBatchId() string {
+	if x != nil {
+		return x.BatchId
+	}
+	return ""
+}
+
+func (x *PayoutStatusUpdate) GetStage() PayoutStage {
+	if x != nil {
+		return x.Stage
+	}
+	return PayoutStage_PAYOUT_STAGE_UNSPECIFIED
+}
+
+func (x *PayoutStatusUpdate) GetTxHash() string {
+	if x != nil {
+		return x.TxHash
+	}
+	return ""
+}
+
+func (x *PayoutStatusUpdate) GetConfirmations() uint64 {
+	if x != nil {
+		return x.Confirmations
+	}
+	return 0
+}
+
+func (x *PayoutStatusUpdate) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *PayoutStatusUpdate) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *PayoutStatusUpdate) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+var File_payout_status_proto protoreflect.FileDescriptor
+
+var file_payout_status_proto_rawDesc = []byte{
+	0x0a, 0x13, 0x70, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x06, 0x70, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x22, 0x31, 0x0a,
+	0x12, 0x57, 0x61, 0x74, 0x63, 0x68, 0x50, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x5f, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x49, 0x64,
+	0x22, 0x95, 0x02, 0x0a, 0x12, 0x50, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x61, 0x79, 0x6f, 0x75,
+	0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x61, 0x79, 0x6f,
+	0x75, 0x74, 0x49, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x62, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x69, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x62, 0x61, 0x74, 0x63, 0x68, 0x49, 0x64, 0x12,
+	0x29, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13,
+	0x2e, 0x70, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x2e, 0x50, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x53, 0x74,
+	0x61, 0x67, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61, 0x67, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x74, 0x78,
+	0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x74, 0x78, 0x48,
+	0x61, 0x73, 0x68, 0x12, 0x24, 0x0a, 0x0d, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d, 0x63, 0x6f, 0x6e, 0x66,
+	0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x61, 0x74, 0x74,
+	0x65, 0x6d, 0x70, 0x74, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x61, 0x74, 0x74,
+	0x65, 0x6d, 0x70, 0x74, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x5f, 0x6d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2a, 0xb1, 0x01, 0x0a, 0x0b, 0x50, 0x61, 0x79,
+	0x6f, 0x75, 0x74, 0x53, 0x74, 0x61, 0x67, 0x65, 0x12, 0x1c, 0x0a, 0x18, 0x50, 0x41, 0x59, 0x4f,
+	0x55, 0x54, 0x5f, 0x53, 0x54, 0x41, 0x47, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49,
+	0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x17, 0x0a, 0x13, 0x50, 0x41, 0x59, 0x4f, 0x55, 0x54,
+	0x5f, 0x53, 0x54, 0x41, 0x47, 0x45, 0x5f, 0x51, 0x55, 0x45, 0x55, 0x45, 0x44, 0x10, 0x01, 0x12,
+	0x1a, 0x0a, 0x16, 0x50, 0x41, 0x59, 0x4f, 0x55, 0x54, 0x5f, 0x53, 0x54, 0x41, 0x47, 0x45, 0x5f,
+	0x42, 0x52, 0x4f, 0x41, 0x44, 0x43, 0x41, 0x53, 0x54, 0x10, 0x02, 0x12, 0x1a, 0x0a, 0x16, 0x50,
+	0x41, 0x59, 0x4f, 0x55, 0x54, 0x5f, 0x53, 0x54, 0x41, 0x47, 0x45, 0x5f, 0x43, 0x4f, 0x4e, 0x46,
+	0x49, 0x52, 0x4d, 0x45, 0x44, 0x10, 0x03, 0x12, 0x17, 0x0a, 0x13, 0x50, 0x41, 0x59, 0x4f, 0x55,
+	0x54, 0x5f, 0x53, 0x54, 0x41, 0x47, 0x45, 0x5f, 0x46, 0x41, 0x49, 0x4c, 0x45, 0x44, 0x10, 0x04,
+	0x12, 0x1a, 0x0a, 0x16, 0x50, 0x41, 0x59, 0x4f, 0x55, 0x54, 0x5f, 0x53, 0x54, 0x41, 0x47, 0x45,
+	0x5f, 0x43, 0x41, 0x4e, 0x43, 0x45, 0x4c, 0x4c, 0x45, 0x44, 0x10, 0x05, 0x32, 0x5e, 0x0a, 0x13,
+	0x50, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x53, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x12, 0x47, 0x0a, 0x0b, 0x57, 0x61, 0x74, 0x63, 0x68, 0x50, 0x61, 0x79, 0x6f,
+	0x75, 0x74, 0x12, 0x1a, 0x2e, 0x70, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x2e, 0x57, 0x61, 0x74, 0x63,
+	0x68, 0x50, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a,
+	0x2e, 0x70, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x2e, 0x50, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x30, 0x01, 0x42, 0x34, 0x5a, 0x32,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x63, 0x6f, 0x6c, 0x2d, 0x62, 0x61, 0x6e, 0x6b, 0x2f, 0x70, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x2d,
+	0x65, 0x6e, 0x67, 0x69, 0x6e, 0x65, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f,
+	0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_payout_status_proto_rawDescOnce sync.Once
+	file_payout_status_proto_rawDescData = file_payout_status_proto_rawDesc
+)
+
+func file_payout_status_proto_rawDescGZIP() []byte {
+	file_payout_status_proto_rawDescOnce.Do(func() {
+		file_payout_status_proto_rawDescData = protoimpl.X.CompressGZIP(file_payout_status_proto_rawDescData)
+	})
+	return file_payout_status_proto_rawDescData
+}
+
+var file_payout_status_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_payout_status_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_payout_status_proto_goTypes = []interface{}{
+	(PayoutStage)(0),           // 0: payout.PayoutStage
+	(*WatchPayoutRequest)(nil), // 1: payout.WatchPayoutRequest
+	(*PayoutStatusUpdate)(nil), // 2: payout.PayoutStatusUpdate
+}
+var file_payout_status_proto_depIdxs = []int32{
+	0, // 0: payout.PayoutStatusUpdate.stage:type_name -> payout.PayoutStage
+	1, // 1: payout.PayoutStatusService.WatchPayout:input_type -> payout.WatchPayoutRequest
+	2, // 2: payout.PayoutStatusService.WatchPayout:output_type -> payout.PayoutStatusUpdate
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_payout_status_proto_init() }
+func file_payout_status_proto_init() {
+	if File_payout_status_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_payout_status_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchPayoutRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_payout_status_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PayoutStatusUpdate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_payout_status_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_payout_status_proto_goTypes,
+		DependencyIndexes: file_payout_status_proto_depIdxs,
+		EnumInfos:         file_payout_status_proto_enumTypes,
+		MessageInfos:      file_payout_status_proto_msgTypes,
+	}.Build()
+	File_payout_status_proto = out.File
+	file_payout_status_proto_rawDesc = nil
+	file_payout_status_proto_goTypes = nil
+	file_payout_status_proto_depIdxs = nil
+}
@@ -4,18 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
-	"github.com/go-redis/redis/v8"
 	"github.com/protocol-bank/payout-engine/internal/config"
 	"github.com/rs/zerolog/log"
 )
 
 const (
-	PayoutQueueKey       = "payout:queue"
-	PayoutProcessingKey  = "payout:processing"
-	PayoutDeadLetterKey  = "payout:deadletter"
-	MaxRetries           = 3
+	MaxRetries = 3
+	// DefaultRetryDelay 失败任务第 n 次重试前等待 n 倍该时长
+	DefaultRetryDelay = 5 * time.Second
+	// defaultWorkerPool 并发工作线程数
+	defaultWorkerPool = 10
 )
 
 // Job 支付任务
@@ -37,133 +38,109 @@ type Job struct {
 
 // JobResult 任务结果
 type JobResult struct {
-	JobID   string
-	Success bool
-	TxHash  string
-	Error   error
+	JobID     string
+	Success   bool
+	Cancelled bool // 广播前已被取消，不再重试
+	TxHash    string
+	Error     error
 }
 
 // ProcessFunc 任务处理函数
 type ProcessFunc func(ctx context.Context, job *Job) (*JobResult, error)
 
-// Consumer 队列消费者
-type Consumer struct {
-	redis      *redis.Client
-	workerPool int
-}
-
-// NewConsumer 创建队列消费者
-func NewConsumer(ctx context.Context, cfg config.RedisConfig) (*Consumer, error) {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     cfg.URL,
-		Password: cfg.Password,
-		DB:       cfg.DB,
-	})
-
-	if err := rdb.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("redis connection failed: %w", err)
-	}
+// DeadLetterFunc 任务进入死信队列时的回调
+type DeadLetterFunc func(ctx context.Context, job *Job, err error)
 
-	return &Consumer{
-		redis:      rdb,
-		workerPool: 10, // 并发工作线程数
-	}, nil
+// Depth 各队列中的任务数
+type Depth struct {
+	Pending    int64 // 等待处理
+	Processing int64 // 已取出、尚未确认
+	DeadLetter int64 // 超过重试次数
 }
 
-// Push 添加任务到队列
-func (c *Consumer) Push(ctx context.Context, job *Job) error {
-	data, err := json.Marshal(job)
-	if err != nil {
-		return fmt.Errorf("failed to marshal job: %w", err)
-	}
-
-	return c.redis.LPush(ctx, PayoutQueueKey, data).Err()
+// JobSource 支付任务队列，由 config.QueueConfig 选择 Redis 或 NATS JetStream 实现
+// 投递至少一次：任务处理结束前一直保留在队列后端，成功或取消后确认（ack）删除，
+// 失败后延迟重新投递（nack），超过 MaxRetries 次移入死信。同一任务可能被处理多次，
+// 处理函数需保证幂等（支付以任务 ID 裁决广播）。
+type JobSource interface {
+	// Push 添加任务到队列
+	Push(ctx context.Context, job *Job) error
+	// PushBatch 批量添加任务
+	PushBatch(ctx context.Context, jobs []*Job) error
+	// Start 启动工作协程处理任务，直到 ctx 取消
+	Start(ctx context.Context, processFn ProcessFunc)
+	// Remove 从待处理队列中移除指定任务，返回被移除的任务，不在队列中时返回 nil
+	// 已被工作协程取走的任务不在队列中，需由处理函数自行放弃。
+	Remove(ctx context.Context, jobID string) (*Job, error)
+	// Contains 任务是否仍在队列后端：待处理、处理中或等待重试；确认或移入死信后返回 false
+	Contains(ctx context.Context, jobID string) (bool, error)
+	// Depth 获取各队列中的任务数
+	Depth(ctx context.Context) (Depth, error)
+	// SetDeadLetterHandler 设置死信回调
+	SetDeadLetterHandler(fn DeadLetterFunc)
+	// Close 释放连接
+	Close() error
 }
 
-// PushBatch 批量添加任务
-func (c *Consumer) PushBatch(ctx context.Context, jobs []*Job) error {
-	pipe := c.redis.Pipeline()
-	for _, job := range jobs {
-		data, err := json.Marshal(job)
-		if err != nil {
-			return fmt.Errorf("failed to marshal job: %w", err)
-		}
-		pipe.LPush(ctx, PayoutQueueKey, data)
-	}
-	_, err := pipe.Exec(ctx)
-	return err
-}
-
-// Start 启动消费者
-func (c *Consumer) Start(ctx context.Context, processFn ProcessFunc) {
-	log.Info().Int("workers", c.workerPool).Msg("Starting queue consumer")
-
-	// 启动多个工作协程
-	for i := 0; i < c.workerPool; i++ {
-		go c.worker(ctx, i, processFn)
+// NewJobSource 按配置创建任务队列
+func NewJobSource(ctx context.Context, cfg config.QueueConfig, redisCfg config.RedisConfig) (JobSource, error) {
+	switch cfg.Backend {
+	case config.QueueBackendRedis, "":
+		return NewRedisConsumer(ctx, redisCfg)
+	case config.QueueBackendNATS:
+		return NewNATSConsumer(ctx, cfg.NATS)
+	default:
+		return nil, fmt.Errorf("unknown queue backend %q", cfg.Backend)
 	}
 }
 
-// worker 工作协程
-func (c *Consumer) worker(ctx context.Context, id int, processFn ProcessFunc) {
-	log.Info().Int("worker_id", id).Msg("Worker started")
-
-	for {
-		select {
-		case <-ctx.Done():
-			log.Info().Int("worker_id", id).Msg("Worker stopped")
-			return
-		default:
-			// 从队列获取任务（阻塞等待 5 秒）
-			result, err := c.redis.BRPopLPush(ctx, PayoutQueueKey, PayoutProcessingKey, 5*time.Second).Result()
-			if err == redis.Nil {
-				continue // 超时，继续等待
-			}
-			if err != nil {
-				log.Error().Err(err).Int("worker_id", id).Msg("Failed to pop from queue")
-				continue
-			}
-
-			// 解析任务
-			var job Job
-			if err := json.Unmarshal([]byte(result), &job); err != nil {
-				log.Error().Err(err).Str("data", result).Msg("Failed to unmarshal job")
-				c.removeFromProcessing(ctx, result)
-				continue
-			}
-
-			log.Info().
-				Str("job_id", job.ID).
-				Str("batch_id", job.BatchID).
-				Int("worker_id", id).
-				Msg("Processing job")
-
-			// 处理任务
-			jobResult, err := processFn(ctx, &job)
-			if err != nil {
-				c.handleFailure(ctx, &job, result, err)
-			} else if !jobResult.Success {
-				c.handleFailure(ctx, &job, result, jobResult.Error)
-			} else {
-				c.handleSuccess(ctx, &job, result, jobResult.TxHash)
-			}
-		}
-	}
+// delivery 一次任务投递，处理结束后调用其中一个方法确认
+type delivery interface {
+	// ack 处理完成（成功或取消），从队列删除
+	ack(ctx context.Context) error
+	// nack 处理失败，delay 后重新投递 job（RetryCount 已递增）
+	nack(ctx context.Context, job *Job, delay time.Duration) error
+	// deadLetter 超过重试次数，移入死信
+	deadLetter(ctx context.Context, job *Job) error
 }
 
-// handleSuccess 处理成功
-func (c *Consumer) handleSuccess(ctx context.Context, job *Job, rawData string, txHash string) {
+// handleDelivery 处理一次投递并按结果确认
+func handleDelivery(ctx context.Context, workerID int, job *Job, d delivery, processFn ProcessFunc, onDeadLetter DeadLetterFunc, retryDelay time.Duration) {
 	log.Info().
 		Str("job_id", job.ID).
-		Str("tx_hash", txHash).
-		Msg("Job completed successfully")
-
-	c.removeFromProcessing(ctx, rawData)
+		Str("batch_id", job.BatchID).
+		Int("worker_id", workerID).
+		Msg("Processing job")
+
+	// 处理任务
+	jobResult, err := processFn(ctx, job)
+	chainID := strconv.FormatUint(job.ChainID, 10)
+	switch {
+	case err != nil:
+		handleFailure(ctx, job, d, err, onDeadLetter, retryDelay)
+
+	case jobResult.Cancelled:
+		log.Info().Str("job_id", job.ID).Msg("Job cancelled before broadcast")
+		JobsProcessedTotal.WithLabelValues(chainID, "cancelled").Inc()
+		logAckError(job, "ack", d.ack(ctx))
+
+	case !jobResult.Success:
+		handleFailure(ctx, job, d, jobResult.Error, onDeadLetter, retryDelay)
+
+	default:
+		log.Info().
+			Str("job_id", job.ID).
+			Str("tx_hash", jobResult.TxHash).
+			Msg("Job completed successfully")
+		JobsProcessedTotal.WithLabelValues(chainID, "success").Inc()
+		logAckError(job, "ack", d.ack(ctx))
+	}
 }
 
-// handleFailure 处理失败
-func (c *Consumer) handleFailure(ctx context.Context, job *Job, rawData string, err error) {
+// handleFailure 处理失败：未超过重试次数时延迟重新投递，否则移入死信
+func handleFailure(ctx context.Context, job *Job, d delivery, err error, onDeadLetter DeadLetterFunc, retryDelay time.Duration) {
 	job.RetryCount++
+	chainID := strconv.FormatUint(job.ChainID, 10)
 
 	if job.RetryCount >= MaxRetries {
 		log.Error().
@@ -172,10 +149,11 @@ func (c *Consumer) handleFailure(ctx context.Context, job *Job, rawData string,
 			Err(err).
 			Msg("Job exceeded max retries, moving to dead letter queue")
 
-		// 移到死信队列
-		data, _ := json.Marshal(job)
-		c.redis.LPush(ctx, PayoutDeadLetterKey, data)
-		c.removeFromProcessing(ctx, rawData)
+		JobsProcessedTotal.WithLabelValues(chainID, "dead_letter").Inc()
+		logAckError(job, "dead letter", d.deadLetter(ctx, job))
+		if onDeadLetter != nil {
+			onDeadLetter(ctx, job, err)
+		}
 		return
 	}
 
@@ -185,29 +163,12 @@ func (c *Consumer) handleFailure(ctx context.Context, job *Job, rawData string,
 		Err(err).
 		Msg("Job failed, requeueing")
 
-	// 重新入队（延迟重试）
-	time.Sleep(time.Duration(job.RetryCount) * 5 * time.Second)
-	data, _ := json.Marshal(job)
-	c.redis.LPush(ctx, PayoutQueueKey, data)
-	c.removeFromProcessing(ctx, rawData)
-}
-
-// removeFromProcessing 从处理中列表移除
-func (c *Consumer) removeFromProcessing(ctx context.Context, rawData string) {
-	c.redis.LRem(ctx, PayoutProcessingKey, 1, rawData)
+	JobsProcessedTotal.WithLabelValues(chainID, "retry").Inc()
+	logAckError(job, "nack", d.nack(ctx, job, time.Duration(job.RetryCount)*retryDelay))
 }
 
-// GetQueueLength 获取队列长度
-func (c *Consumer) GetQueueLength(ctx context.Context) (int64, error) {
-	return c.redis.LLen(ctx, PayoutQueueKey).Result()
-}
-
-// GetProcessingCount 获取处理中数量
-func (c *Consumer) GetProcessingCount(ctx context.Context) (int64, error) {
-	return c.redis.LLen(ctx, PayoutProcessingKey).Result()
-}
-
-// GetDeadLetterCount 获取死信队列数量
-func (c *Consumer) GetDeadLetterCount(ctx context.Context) (int64, error) {
-	return c.redis.LLen(ctx, PayoutDeadLetterKey).Result()
+func logAckError(job *Job, op string, err error) {
+	if err != nil {
+		log.Error().Err(err).Str("job_id", job.ID).Str("op", op).Msg("Failed to acknowledge job")
+	}
 }
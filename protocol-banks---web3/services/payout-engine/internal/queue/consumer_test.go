@@ -0,0 +1,135 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testJob(id string) *Job {
+	return &Job{
+		ID:        id,
+		BatchID:   "batch-1",
+		ToAddress: "0x000000000000000000000000000000000000dEaD",
+		Amount:    "1",
+		ChainID:   1,
+		CreatedAt: time.Now(),
+	}
+}
+
+func requireDepth(t *testing.T, source JobSource, want Depth) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		depth, err := source.Depth(context.Background())
+		return err == nil && depth == want
+	}, 5*time.Second, 10*time.Millisecond, "depth never reached %+v", want)
+}
+
+// testJobSource 各队列后端共同的投递语义：移除、确认、失败重试与死信
+func testJobSource(t *testing.T, source JobSource) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	require.NoError(t, source.PushBatch(ctx, []*Job{testJob("ok"), testJob("flaky"), testJob("cancelled")}))
+	require.NoError(t, source.Push(ctx, testJob("doomed")))
+	requireDepth(t, source, Depth{Pending: 4})
+
+	// 尚未取出的任务可以移除
+	removed, err := source.Remove(ctx, "cancelled")
+	require.NoError(t, err)
+	require.NotNil(t, removed)
+	assert.Equal(t, "batch-1", removed.BatchID)
+	removed, err = source.Remove(ctx, "missing")
+	require.NoError(t, err)
+	assert.Nil(t, removed)
+	for id, want := range map[string]bool{"ok": true, "cancelled": false, "missing": false} {
+		found, err := source.Contains(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, want, found, id)
+	}
+
+	var mu sync.Mutex
+	attempts := make(map[string][]int) // 任务 ID -> 每次处理时的 RetryCount
+	var deadLettered []*Job
+	source.SetDeadLetterHandler(func(ctx context.Context, job *Job, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		deadLettered = append(deadLettered, job)
+	})
+	source.Start(ctx, func(ctx context.Context, job *Job) (*JobResult, error) {
+		mu.Lock()
+		attempts[job.ID] = append(attempts[job.ID], job.RetryCount)
+		n := len(attempts[job.ID])
+		mu.Unlock()
+
+		switch {
+		case job.ID == "doomed":
+			return nil, errors.New("rpc unavailable")
+		case job.ID == "flaky" && n == 1:
+			return &JobResult{JobID: job.ID, Error: errors.New("replacement underpriced")}, nil
+		}
+		return &JobResult{JobID: job.ID, Success: true, TxHash: "0xabc"}, nil
+	})
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(deadLettered) == 1 && len(attempts["flaky"]) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	requireDepth(t, source, Depth{DeadLetter: 1})
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []int{0}, attempts["ok"])
+	assert.Equal(t, []int{0, 1}, attempts["flaky"])
+	assert.Equal(t, []int{0, 1, 2}, attempts["doomed"])
+	assert.NotContains(t, attempts, "cancelled")
+	assert.Equal(t, "doomed", deadLettered[0].ID)
+	assert.Equal(t, MaxRetries, deadLettered[0].RetryCount)
+
+	// 确认或移入死信的任务不再在队列中
+	for _, id := range []string{"ok", "flaky", "doomed"} {
+		found, err := source.Contains(ctx, id)
+		require.NoError(t, err)
+		assert.False(t, found, id)
+	}
+}
+
+// testJobSourceHoldsUnackedJob 处理结束前任务留在队列后端，不能再被移除
+func testJobSourceHoldsUnackedJob(t *testing.T, source JobSource) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	source.Start(ctx, func(ctx context.Context, job *Job) (*JobResult, error) {
+		close(started)
+		<-release
+		return &JobResult{JobID: job.ID, Success: true}, nil
+	})
+	require.NoError(t, source.Push(ctx, testJob("slow")))
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was never delivered")
+	}
+	requireDepth(t, source, Depth{Processing: 1})
+	removed, err := source.Remove(ctx, "slow")
+	require.NoError(t, err)
+	assert.Nil(t, removed, "an in-flight job must not be removed")
+	found, err := source.Contains(ctx, "slow")
+	require.NoError(t, err)
+	assert.True(t, found, "an in-flight job is still in the queue")
+
+	close(release)
+	requireDepth(t, source, Depth{})
+	found, err = source.Contains(ctx, "slow")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
@@ -0,0 +1,59 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+// 队列指标
+var (
+	// 处理完成的任务：success / retry（失败后重新入队）/ dead_letter（超过重试次数）/ cancelled（广播前取消）
+	JobsProcessedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payout_jobs_processed_total",
+			Help: "Total number of payout jobs processed, by outcome",
+		},
+		[]string{"chain_id", "status"},
+	)
+
+	// 各队列长度：pending / processing / deadletter
+	QueueDepth = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "payout_queue_depth",
+			Help: "Number of payout jobs in each queue",
+		},
+		[]string{"queue"},
+	)
+)
+
+// RunDepthReporter 定期上报各队列长度，直到 ctx 取消
+func RunDepthReporter(ctx context.Context, source JobSource, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		reportDepth(ctx, source)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func reportDepth(ctx context.Context, source JobSource) {
+	depth, err := source.Depth(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Warn().Err(err).Msg("Failed to read queue depth")
+		}
+		return
+	}
+	QueueDepth.WithLabelValues("pending").Set(float64(depth.Pending))
+	QueueDepth.WithLabelValues("processing").Set(float64(depth.Processing))
+	QueueDepth.WithLabelValues("deadletter").Set(float64(depth.DeadLetter))
+}
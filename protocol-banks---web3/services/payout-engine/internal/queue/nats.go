@@ -0,0 +1,282 @@
+package queue
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/protocol-bank/payout-engine/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// natsFetchWait 工作协程单次拉取的最长等待
+const natsFetchWait = 5 * time.Second
+
+// NATSConsumer 基于 NATS JetStream 的任务队列
+// 任务发布到 <Subject>.<任务 ID>，存入 WorkQueue 保留策略的流，由多个实例共享的持久化拉取消费者分发；
+// 消息确认后才从流中删除，失败时由服务端延迟重新投递，进程退出或超过 AckWait 未确认的任务也会重新投递。
+type NATSConsumer struct {
+	conn         *nats.Conn
+	js           jetstream.JetStream
+	stream       jetstream.Stream
+	deadLetters  jetstream.Stream
+	consumer     jetstream.Consumer
+	cfg          config.NATSConfig
+	workerPool   int
+	retryDelay   time.Duration
+	onDeadLetter DeadLetterFunc
+}
+
+// NewNATSConsumer 连接 NATS 并创建（或更新）任务流、死信流与持久化消费者
+func NewNATSConsumer(ctx context.Context, cfg config.NATSConfig) (*NATSConsumer, error) {
+	nc, err := nats.Connect(cfg.URL, nats.Name("payout-engine"))
+	if err != nil {
+		return nil, fmt.Errorf("nats connection failed: %w", err)
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("jetstream init failed: %w", err)
+	}
+
+	c, err := newNATSConsumer(ctx, js, cfg)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	c.conn = nc
+	return c, nil
+}
+
+func newNATSConsumer(ctx context.Context, js jetstream.JetStream, cfg config.NATSConfig) (*NATSConsumer, error) {
+	stream, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      cfg.Stream,
+		Subjects:  []string{cfg.Subject + ".>"},
+		Retention: jetstream.WorkQueuePolicy,
+		Storage:   jetstream.FileStorage,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stream %s: %w", cfg.Stream, err)
+	}
+
+	deadLetters, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     cfg.DeadLetterStream,
+		Subjects: []string{cfg.DeadLetterSubject + ".>"},
+		Storage:  jetstream.FileStorage,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stream %s: %w", cfg.DeadLetterStream, err)
+	}
+
+	consumer, err := js.CreateOrUpdateConsumer(ctx, cfg.Stream, jetstream.ConsumerConfig{
+		Durable:       cfg.Consumer,
+		FilterSubject: cfg.Subject + ".>",
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       cfg.AckWait,
+		MaxDeliver:    -1, // 重试次数由 handleFailure 控制，超过后移入死信
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer %s: %w", cfg.Consumer, err)
+	}
+
+	return &NATSConsumer{
+		js:          js,
+		stream:      stream,
+		deadLetters: deadLetters,
+		consumer:    consumer,
+		cfg:         cfg,
+		workerPool:  defaultWorkerPool,
+		retryDelay:  DefaultRetryDelay,
+	}, nil
+}
+
+// SetDeadLetterHandler 设置死信回调
+func (c *NATSConsumer) SetDeadLetterHandler(fn DeadLetterFunc) {
+	c.onDeadLetter = fn
+}
+
+// SetRetryDelay 设置失败重试的基础等待
+func (c *NATSConsumer) SetRetryDelay(d time.Duration) {
+	c.retryDelay = d
+}
+
+// subject 任务的主题，任务 ID 编码后作为最后一级，避免 "." 等字符破坏主题层级
+func subject(prefix, jobID string) string {
+	return prefix + "." + base64.RawURLEncoding.EncodeToString([]byte(jobID))
+}
+
+// Push 添加任务到队列，以任务 ID 去重，重复提交的批次不会产生重复任务
+func (c *NATSConsumer) Push(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	if _, err := c.js.Publish(ctx, subject(c.cfg.Subject, job.ID), data, jetstream.WithMsgID(job.ID)); err != nil {
+		return fmt.Errorf("failed to publish job: %w", err)
+	}
+	return nil
+}
+
+// PushBatch 批量添加任务，每个任务由服务端确认后才返回
+func (c *NATSConsumer) PushBatch(ctx context.Context, jobs []*Job) error {
+	for _, job := range jobs {
+		if err := c.Push(ctx, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start 启动消费者
+func (c *NATSConsumer) Start(ctx context.Context, processFn ProcessFunc) {
+	log.Info().Int("workers", c.workerPool).Str("backend", "nats").Msg("Starting queue consumer")
+
+	for i := 0; i < c.workerPool; i++ {
+		go c.worker(ctx, i, processFn)
+	}
+}
+
+// worker 工作协程
+func (c *NATSConsumer) worker(ctx context.Context, id int, processFn ProcessFunc) {
+	log.Info().Int("worker_id", id).Msg("Worker started")
+
+	for {
+		if ctx.Err() != nil {
+			log.Info().Int("worker_id", id).Msg("Worker stopped")
+			return
+		}
+
+		msg, err := c.consumer.Next(jetstream.FetchMaxWait(natsFetchWait))
+		if errors.Is(err, nats.ErrTimeout) {
+			continue // 超时，继续等待
+		}
+		if err != nil {
+			log.Error().Err(err).Int("worker_id", id).Msg("Failed to fetch from queue")
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		// 解析任务，无法解析的消息不再投递
+		var job Job
+		if err := json.Unmarshal(msg.Data(), &job); err != nil {
+			log.Error().Err(err).Str("subject", msg.Subject()).Msg("Failed to unmarshal job")
+			if err := msg.Term(); err != nil {
+				log.Error().Err(err).Str("subject", msg.Subject()).Msg("Failed to terminate message")
+			}
+			continue
+		}
+		// 服务端的重新投递（nack 或 AckWait 超时）都计入重试次数
+		if meta, err := msg.Metadata(); err == nil {
+			job.RetryCount = max(job.RetryCount, int(meta.NumDelivered)-1)
+		}
+
+		handleDelivery(ctx, id, &job, &natsDelivery{consumer: c, msg: msg}, processFn, c.onDeadLetter, c.retryDelay)
+	}
+}
+
+// natsDelivery 一条尚未确认的 JetStream 消息
+type natsDelivery struct {
+	consumer *NATSConsumer
+	msg      jetstream.Msg
+}
+
+// ack 等待服务端确认，确认失败的任务会被重新投递
+func (d *natsDelivery) ack(ctx context.Context) error {
+	return d.msg.DoubleAck(context.WithoutCancel(ctx))
+}
+
+// nack 由服务端在 delay 后重新投递，重试次数取自投递次数
+func (d *natsDelivery) nack(ctx context.Context, job *Job, delay time.Duration) error {
+	return d.msg.NakWithDelay(delay)
+}
+
+// deadLetter 先写入死信流再确认，写入失败时任务留在队列中等待重新投递
+func (d *natsDelivery) deadLetter(ctx context.Context, job *Job) error {
+	ctx = context.WithoutCancel(ctx)
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	if _, err := d.consumer.js.Publish(ctx, subject(d.consumer.cfg.DeadLetterSubject, job.ID), data); err != nil {
+		return fmt.Errorf("failed to publish dead letter: %w", err)
+	}
+	return d.msg.DoubleAck(ctx)
+}
+
+// Remove 从待处理队列中移除指定任务，返回被移除的任务，不在队列中时返回 nil
+// 已投递给工作协程（处理中或等待重试）的任务不删除，需由处理函数自行放弃。
+func (c *NATSConsumer) Remove(ctx context.Context, jobID string) (*Job, error) {
+	raw, err := c.stream.GetLastMsgForSubject(ctx, subject(c.cfg.Subject, jobID))
+	if errors.Is(err, jetstream.ErrMsgNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up job: %w", err)
+	}
+
+	// 消费者按序列号顺序投递，不大于已投递序列号的消息已被取走
+	info, err := c.consumer.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read consumer info: %w", err)
+	}
+	if raw.Sequence <= info.Delivered.Stream {
+		return nil, nil
+	}
+
+	var job Job
+	if err := json.Unmarshal(raw.Data, &job); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+	if err := c.stream.DeleteMsg(ctx, raw.Sequence); err != nil {
+		if errors.Is(err, jetstream.ErrMsgNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to remove job: %w", err)
+	}
+	return &job, nil
+}
+
+// Contains WorkQueue 流在确认后删除消息，流中仍有该任务的消息即尚未处理结束
+func (c *NATSConsumer) Contains(ctx context.Context, jobID string) (bool, error) {
+	_, err := c.stream.GetLastMsgForSubject(ctx, subject(c.cfg.Subject, jobID))
+	if errors.Is(err, jetstream.ErrMsgNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up job: %w", err)
+	}
+	return true, nil
+}
+
+// Depth 获取各队列中的任务数
+func (c *NATSConsumer) Depth(ctx context.Context) (Depth, error) {
+	info, err := c.consumer.Info(ctx)
+	if err != nil {
+		return Depth{}, fmt.Errorf("failed to read consumer info: %w", err)
+	}
+	deadLetters, err := c.deadLetters.Info(ctx)
+	if err != nil {
+		return Depth{}, fmt.Errorf("failed to read dead letter stream info: %w", err)
+	}
+	return Depth{
+		Pending:    int64(info.NumPending),
+		Processing: int64(info.NumAckPending),
+		DeadLetter: int64(deadLetters.State.Msgs),
+	}, nil
+}
+
+// Close 关闭 NATS 连接
+func (c *NATSConsumer) Close() error {
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package queue
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/protocol-bank/payout-engine/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func testNATSConfig(url string) config.NATSConfig {
+	return config.NATSConfig{
+		URL:               url,
+		Stream:            "PAYOUT_JOBS",
+		Subject:           "payout.jobs",
+		DeadLetterStream:  "PAYOUT_DEADLETTER",
+		DeadLetterSubject: "payout.deadletter",
+		Consumer:          "payout-engine",
+		AckWait:           time.Minute,
+	}
+}
+
+// newTestNATSConsumer 连接进程内启动的 JetStream 服务
+func newTestNATSConsumer(t *testing.T) *NATSConsumer {
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+	go ns.Start()
+	t.Cleanup(func() {
+		ns.Shutdown()
+		ns.WaitForShutdown()
+	})
+	require.True(t, ns.ReadyForConnections(5*time.Second), "nats server did not start")
+
+	c, err := NewNATSConsumer(context.Background(), testNATSConfig(ns.ClientURL()))
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	c.SetRetryDelay(10 * time.Millisecond)
+	return c
+}
+
+func TestNATSConsumer(t *testing.T) {
+	testJobSource(t, newTestNATSConsumer(t))
+}
+
+func TestNATSConsumer_HoldsUnackedJob(t *testing.T) {
+	testJobSourceHoldsUnackedJob(t, newTestNATSConsumer(t))
+}
+
+func TestNATSConsumer_SubjectEncodesJobID(t *testing.T) {
+	// 任务 ID 中的 "." 与通配符不能成为主题层级
+	got := subject("payout.jobs", "batch.1/*>")
+	require.Len(t, strings.Split(got, "."), 3)
+	require.NotContains(t, got, "*")
+	require.NotContains(t, got, ">")
+}
@@ -0,0 +1,246 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/protocol-bank/payout-engine/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	PayoutQueueKey      = "payout:queue"
+	PayoutProcessingKey = "payout:processing"
+	PayoutDeadLetterKey = "payout:deadletter"
+)
+
+// RedisConsumer 基于 Redis 列表的任务队列
+// 工作协程以 BRPOPLPUSH 把任务移到处理中列表，确认后删除，失败时重新入队。
+type RedisConsumer struct {
+	redis        *redis.Client
+	workerPool   int
+	retryDelay   time.Duration
+	onDeadLetter DeadLetterFunc
+}
+
+// NewRedisConsumer 创建 Redis 队列消费者
+func NewRedisConsumer(ctx context.Context, cfg config.RedisConfig) (*RedisConsumer, error) {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     cfg.URL,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+		// 每个工作协程阻塞在 BRPOPLPUSH 上占用一个连接，另留连接给确认、入队与监控
+		PoolSize: defaultWorkerPool * 2,
+	})
+
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("redis connection failed: %w", err)
+	}
+
+	return &RedisConsumer{
+		redis:      rdb,
+		workerPool: defaultWorkerPool,
+		retryDelay: DefaultRetryDelay,
+	}, nil
+}
+
+// SetDeadLetterHandler 设置死信回调
+func (c *RedisConsumer) SetDeadLetterHandler(fn DeadLetterFunc) {
+	c.onDeadLetter = fn
+}
+
+// SetRetryDelay 设置失败重试的基础等待
+func (c *RedisConsumer) SetRetryDelay(d time.Duration) {
+	c.retryDelay = d
+}
+
+// Push 添加任务到队列
+func (c *RedisConsumer) Push(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	return c.redis.LPush(ctx, PayoutQueueKey, data).Err()
+}
+
+// PushBatch 批量添加任务
+func (c *RedisConsumer) PushBatch(ctx context.Context, jobs []*Job) error {
+	pipe := c.redis.Pipeline()
+	for _, job := range jobs {
+		data, err := json.Marshal(job)
+		if err != nil {
+			return fmt.Errorf("failed to marshal job: %w", err)
+		}
+		pipe.LPush(ctx, PayoutQueueKey, data)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Start 启动消费者
+func (c *RedisConsumer) Start(ctx context.Context, processFn ProcessFunc) {
+	log.Info().Int("workers", c.workerPool).Str("backend", "redis").Msg("Starting queue consumer")
+
+	// 启动多个工作协程
+	for i := 0; i < c.workerPool; i++ {
+		go c.worker(ctx, i, processFn)
+	}
+}
+
+// worker 工作协程
+func (c *RedisConsumer) worker(ctx context.Context, id int, processFn ProcessFunc) {
+	log.Info().Int("worker_id", id).Msg("Worker started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Int("worker_id", id).Msg("Worker stopped")
+			return
+		default:
+			// 从队列获取任务（阻塞等待 5 秒）
+			result, err := c.redis.BRPopLPush(ctx, PayoutQueueKey, PayoutProcessingKey, 5*time.Second).Result()
+			if err == redis.Nil {
+				continue // 超时，继续等待
+			}
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Error().Err(err).Int("worker_id", id).Msg("Failed to pop from queue")
+				}
+				continue
+			}
+
+			// 解析任务
+			var job Job
+			if err := json.Unmarshal([]byte(result), &job); err != nil {
+				log.Error().Err(err).Str("data", result).Msg("Failed to unmarshal job")
+				c.removeFromProcessing(ctx, result)
+				continue
+			}
+
+			handleDelivery(ctx, id, &job, &redisDelivery{consumer: c, rawData: result}, processFn, c.onDeadLetter, c.retryDelay)
+		}
+	}
+}
+
+// redisDelivery 处理中列表里的一个任务，rawData 为出队时的原始内容
+type redisDelivery struct {
+	consumer *RedisConsumer
+	rawData  string
+}
+
+func (d *redisDelivery) ack(ctx context.Context) error {
+	return d.consumer.removeFromProcessing(ctx, d.rawData)
+}
+
+// nack 等待 delay 后重新入队；关闭时立即重新入队，不把任务留在处理中列表
+func (d *redisDelivery) nack(ctx context.Context, job *Job, delay time.Duration) error {
+	select {
+	case <-ctx.Done():
+	case <-time.After(delay):
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	if err := d.consumer.redis.LPush(ctx, PayoutQueueKey, data).Err(); err != nil {
+		return err
+	}
+	return d.consumer.removeFromProcessing(ctx, d.rawData)
+}
+
+func (d *redisDelivery) deadLetter(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	if err := d.consumer.redis.LPush(ctx, PayoutDeadLetterKey, data).Err(); err != nil {
+		return err
+	}
+	return d.consumer.removeFromProcessing(ctx, d.rawData)
+}
+
+// Remove 从待处理队列中移除指定任务，返回被移除的任务，不在队列中时返回 nil
+// 已被工作协程取走的任务不在队列中，需由处理函数自行放弃。
+func (c *RedisConsumer) Remove(ctx context.Context, jobID string) (*Job, error) {
+	entries, err := c.redis.LRange(ctx, PayoutQueueKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queue: %w", err)
+	}
+
+	for _, entry := range entries {
+		var job Job
+		if err := json.Unmarshal([]byte(entry), &job); err != nil || job.ID != jobID {
+			continue
+		}
+		removed, err := c.redis.LRem(ctx, PayoutQueueKey, 1, entry).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove job: %w", err)
+		}
+		if removed > 0 {
+			return &job, nil
+		}
+	}
+	return nil, nil
+}
+
+// Contains 在待处理与处理中列表里查找任务，等待重试的任务留在处理中列表
+func (c *RedisConsumer) Contains(ctx context.Context, jobID string) (bool, error) {
+	for _, key := range []string{PayoutQueueKey, PayoutProcessingKey} {
+		entries, err := c.redis.LRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return false, fmt.Errorf("failed to list %s: %w", key, err)
+		}
+		for _, entry := range entries {
+			var job Job
+			if err := json.Unmarshal([]byte(entry), &job); err == nil && job.ID == jobID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// removeFromProcessing 从处理中列表移除
+func (c *RedisConsumer) removeFromProcessing(ctx context.Context, rawData string) error {
+	return c.redis.LRem(ctx, PayoutProcessingKey, 1, rawData).Err()
+}
+
+// GetQueueLength 获取队列长度
+func (c *RedisConsumer) GetQueueLength(ctx context.Context) (int64, error) {
+	return c.redis.LLen(ctx, PayoutQueueKey).Result()
+}
+
+// GetProcessingCount 获取处理中数量
+func (c *RedisConsumer) GetProcessingCount(ctx context.Context) (int64, error) {
+	return c.redis.LLen(ctx, PayoutProcessingKey).Result()
+}
+
+// GetDeadLetterCount 获取死信队列数量
+func (c *RedisConsumer) GetDeadLetterCount(ctx context.Context) (int64, error) {
+	return c.redis.LLen(ctx, PayoutDeadLetterKey).Result()
+}
+
+// Depth 获取各队列长度
+func (c *RedisConsumer) Depth(ctx context.Context) (Depth, error) {
+	var depth Depth
+	var err error
+	if depth.Pending, err = c.GetQueueLength(ctx); err != nil {
+		return depth, err
+	}
+	if depth.Processing, err = c.GetProcessingCount(ctx); err != nil {
+		return depth, err
+	}
+	depth.DeadLetter, err = c.GetDeadLetterCount(ctx)
+	return depth, err
+}
+
+// Close 关闭 Redis 连接
+func (c *RedisConsumer) Close() error {
+	return c.redis.Close()
+}
@@ -0,0 +1,31 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/protocol-bank/payout-engine/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedisConsumer(t *testing.T) *RedisConsumer {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	c, err := NewRedisConsumer(context.Background(), config.RedisConfig{URL: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	c.SetRetryDelay(time.Millisecond)
+	return c
+}
+
+func TestRedisConsumer(t *testing.T) {
+	testJobSource(t, newTestRedisConsumer(t))
+}
+
+func TestRedisConsumer_HoldsUnackedJob(t *testing.T) {
+	testJobSourceHoldsUnackedJob(t, newTestRedisConsumer(t))
+}
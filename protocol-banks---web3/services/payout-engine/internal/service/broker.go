@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// subscriberBuffer 每个订阅者的事件缓冲
+const subscriberBuffer = 16
+
+// StatusBroker 进程内支付状态发布/订阅，按支付 ID 分发事件
+type StatusBroker struct {
+	mu   sync.RWMutex
+	subs map[string]map[chan *PayoutEvent]struct{}
+}
+
+// NewStatusBroker 创建状态分发器
+func NewStatusBroker() *StatusBroker {
+	return &StatusBroker{subs: make(map[string]map[chan *PayoutEvent]struct{})}
+}
+
+// Publish 分发事件给订阅了该支付的订阅者
+// 不阻塞发布方：订阅者缓冲已满时丢弃事件。
+func (b *StatusBroker) Publish(ctx context.Context, event *PayoutEvent) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subs[event.JobID] {
+		select {
+		case ch <- event:
+		default:
+			log.Warn().Str("job_id", event.JobID).Str("type", string(event.Type)).Msg("Status subscriber is slow, dropping event")
+		}
+	}
+	return nil
+}
+
+// Subscribe 订阅支付状态，返回事件通道与取消函数
+func (b *StatusBroker) Subscribe(jobID string) (<-chan *PayoutEvent, func()) {
+	ch := make(chan *PayoutEvent, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subs[jobID] == nil {
+		b.subs[jobID] = make(map[chan *PayoutEvent]struct{})
+	}
+	b.subs[jobID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subs[jobID], ch)
+			if len(b.subs[jobID]) == 0 {
+				delete(b.subs, jobID)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrPayoutNotFound 队列与交易记录中都没有该支付
+	ErrPayoutNotFound = errors.New("payout not found")
+	// ErrAlreadyBroadcast 支付已广播，只能以替换交易取消
+	ErrAlreadyBroadcast = errors.New("payout already broadcast")
+	// ErrPayoutFinished 支付已确认、失败或记录已过期，无法取消
+	ErrPayoutFinished = errors.New("payout already finished")
+	// ErrCancelFeeCap 替换交易的费用超过链的费用上限
+	ErrCancelFeeCap = errors.New("fee cap reached while replacing payout")
+)
+
+// CancelResult 取消结果
+type CancelResult struct {
+	JobID string
+	// RemovedFromQueue 任务仍在队列中并已移除；为 false 时可能正被处理，处理前会放弃
+	RemovedFromQueue bool
+	// ReplacementTxHash 已广播时发送的 0 值替换交易
+	ReplacementTxHash string
+}
+
+// CancelPayout 取消支付
+// 广播与取消通过同一裁决键竞争：取消先生效时任务从队列移除，已被取走的任务在广播前放弃；
+// 广播先生效时返回 ErrAlreadyBroadcast，replace 为 true 则以相同 Nonce 发送 0 值自转账替换，
+// 原交易仍可能先被打包，最终结果以状态事件为准。
+// 不存在的支付返回 ErrPayoutNotFound，不写入裁决，之后以该 ID 提交的支付不受影响。
+func (s *PayoutService) CancelPayout(ctx context.Context, jobID, reason string, replace bool) (*CancelResult, error) {
+	known, err := s.payoutKnown(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if !known {
+		return nil, ErrPayoutNotFound
+	}
+
+	claim, err := s.txRecords.ClaimPayout(ctx, jobID, ClaimCancelled)
+	if err != nil {
+		return nil, err
+	}
+
+	if claim == ClaimCancelled {
+		job, err := s.queue.Remove(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		result := &CancelResult{JobID: jobID, RemovedFromQueue: job != nil}
+		if job != nil {
+			log.Info().Str("job_id", jobID).Str("reason", reason).Msg("Removed cancelled payout from queue")
+			publishEvent(ctx, s.events, &PayoutEvent{
+				Type:    PayoutEventCancelled,
+				JobID:   job.ID,
+				BatchID: job.BatchID,
+				ChainID: job.ChainID,
+				Error:   cancelReason(reason),
+			})
+		}
+		return result, nil
+	}
+
+	if !replace {
+		return nil, ErrAlreadyBroadcast
+	}
+	return s.replaceWithCancel(ctx, jobID, reason)
+}
+
+// payoutKnown 支付是否存在：已有裁决（重复取消）、已有交易记录，或仍在队列后端（待处理、处理中或等待重试）
+func (s *PayoutService) payoutKnown(ctx context.Context, jobID string) (bool, error) {
+	claim, err := s.txRecords.GetPayoutClaim(ctx, jobID)
+	if err != nil || claim != "" {
+		return claim != "", err
+	}
+	record, err := s.txRecords.GetTxRecord(ctx, jobID)
+	if err != nil || record != nil {
+		return record != nil, err
+	}
+	found, err := s.queue.Contains(ctx, jobID)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up queued payout: %w", err)
+	}
+	return found, nil
+}
+
+// replaceWithCancel 以相同 Nonce、更高费用发送 0 值自转账，之后由 TxWatcher 跟踪
+func (s *PayoutService) replaceWithCancel(ctx context.Context, jobID, reason string) (*CancelResult, error) {
+	record, err := s.txRecords.GetTxRecord(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, ErrPayoutFinished
+	}
+	pending, err := s.txRecords.IsPending(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check pending payout: %w", err)
+	}
+	if !pending {
+		return nil, ErrPayoutFinished
+	}
+	if record.Cancelled {
+		// 重复请求，替换交易已发送
+		return &CancelResult{JobID: jobID, ReplacementTxHash: record.TxHash}, nil
+	}
+
+	client, ok := s.clients[record.ChainID]
+	if !ok {
+		return nil, fmt.Errorf("unsupported chain: %d", record.ChainID)
+	}
+	prev, err := record.Transaction()
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.suggestFees(ctx, client, record.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	fees := bumpFees(prev, current, s.bumpPercent())
+	if exceedsCap(fees.maxFee(), s.feeStrategy(record.ChainID).MaxFeePerGasGwei) {
+		return nil, ErrCancelFeeCap
+	}
+
+	from := common.HexToAddress(record.From)
+	tx := newTx(record.ChainID, prev.Nonce(), fees, params.TxGas, &from, new(big.Int), nil)
+	replaced, err := s.replaceTx(ctx, client, record, tx, func(r *TxRecord) {
+		r.Cancelled = true
+	})
+	if errors.Is(err, errNonceUsed) {
+		// 原交易已被打包
+		return nil, ErrAlreadyBroadcast
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("job_id", jobID).
+		Str("prev_tx_hash", record.TxHash).
+		Str("tx_hash", replaced.TxHash).
+		Uint64("nonce", prev.Nonce()).
+		Str("reason", reason).
+		Msg("Sent zero-value replacement to cancel payout")
+	return &CancelResult{JobID: jobID, ReplacementTxHash: replaced.TxHash}, nil
+}
+
+// bumpPercent 替换交易的加价百分比，节点替换规则要求至少 10%
+func (s *PayoutService) bumpPercent() int {
+	if s.cfg != nil {
+		return max(s.cfg.Bump.BumpPercent, 10)
+	}
+	return 10
+}
+
+// cancelReason 取消事件中的说明
+func cancelReason(reason string) string {
+	if reason == "" {
+		return "cancelled by operator"
+	}
+	return "cancelled by operator: " + reason
+}
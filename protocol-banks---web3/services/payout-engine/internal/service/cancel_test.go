@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/protocol-bank/payout-engine/internal/queue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelPayout_RemovesQueuedJob(t *testing.T) {
+	s, client, _ := setupTestPayoutService(t)
+	events := &recordingPublisher{}
+	s.SetEventPublisher(events)
+	ctx := context.Background()
+
+	require.NoError(t, s.queue.Push(ctx, testPayoutJob()))
+
+	result, err := s.CancelPayout(ctx, "payout-1", "fraud flag", false)
+	require.NoError(t, err)
+	assert.True(t, result.RemovedFromQueue)
+	assert.Empty(t, result.ReplacementTxHash)
+
+	depth, err := s.queue.Depth(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, depth.Pending)
+	require.Equal(t, []PayoutEventType{PayoutEventCancelled}, events.types())
+	assert.Equal(t, "batch-1", events.events[0].BatchID)
+	assert.Contains(t, events.events[0].Error, "fraud flag")
+
+	// Cancelling again is a no-op
+	result, err = s.CancelPayout(ctx, "payout-1", "fraud flag", false)
+	require.NoError(t, err)
+	assert.False(t, result.RemovedFromQueue)
+	assert.Empty(t, client.sent)
+}
+
+// startTestQueue 以 processFn 消费测试服务的 Redis 队列，失败的任务等待 retryDelay 后重新入队
+func startTestQueue(t *testing.T, s *PayoutService, retryDelay time.Duration, processFn queue.ProcessFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	consumer := s.queue.(*queue.RedisConsumer)
+	consumer.SetRetryDelay(retryDelay)
+	consumer.Start(ctx, processFn)
+}
+
+func TestCancelPayout_InFlightJobIsNotBroadcast(t *testing.T) {
+	s, client, _ := setupTestPayoutService(t)
+	events := &recordingPublisher{}
+	s.SetEventPublisher(events)
+	ctx := context.Background()
+
+	// A worker has taken the job off the queue but not yet reached the broadcast
+	taken := make(chan struct{})
+	cancelled := make(chan struct{})
+	results := make(chan *queue.JobResult, 1)
+	startTestQueue(t, s, time.Hour, func(ctx context.Context, job *queue.Job) (*queue.JobResult, error) {
+		close(taken)
+		<-cancelled
+		result, err := s.ProcessJob(ctx, job)
+		results <- result
+		return result, err
+	})
+	require.NoError(t, s.queue.Push(ctx, testPayoutJob()))
+	<-taken
+
+	result, err := s.CancelPayout(ctx, "payout-1", "", false)
+	require.NoError(t, err)
+	assert.False(t, result.RemovedFromQueue)
+	close(cancelled)
+
+	select {
+	case jobResult := <-results:
+		assert.True(t, jobResult.Cancelled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was never processed")
+	}
+	assert.Empty(t, client.sent)
+
+	record, err := s.txRecords.GetTxRecord(ctx, "payout-1")
+	require.NoError(t, err)
+	assert.Nil(t, record)
+	assert.Equal(t, []PayoutEventType{PayoutEventCancelled}, events.types())
+}
+
+func TestCancelPayout_RejectedSendCanStillBeCancelled(t *testing.T) {
+	s, client, _ := setupTestPayoutService(t)
+	ctx := context.Background()
+
+	// The node rejected the only send, so nothing is in the mempool; the job waits for a retry
+	client.sendErr = &rpcError{msg: "insufficient funds for gas * price + value"}
+	attempted := make(chan *queue.JobResult, 1)
+	startTestQueue(t, s, time.Hour, func(ctx context.Context, job *queue.Job) (*queue.JobResult, error) {
+		result, err := s.ProcessJob(ctx, job)
+		attempted <- result
+		return result, err
+	})
+	require.NoError(t, s.queue.Push(ctx, testPayoutJob()))
+	select {
+	case jobResult := <-attempted:
+		require.False(t, jobResult.Success)
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was never processed")
+	}
+
+	result, err := s.CancelPayout(ctx, "payout-1", "", false)
+	require.NoError(t, err)
+	assert.False(t, result.RemovedFromQueue)
+
+	// The retry gives up instead of sending
+	jobResult, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	assert.True(t, jobResult.Cancelled)
+	assert.Empty(t, client.sent)
+}
+
+func TestCancelPayout_UnknownPayout(t *testing.T) {
+	s, client, _ := setupTestPayoutService(t)
+	events := &recordingPublisher{}
+	s.SetEventPublisher(events)
+	ctx := context.Background()
+
+	_, err := s.CancelPayout(ctx, "payout-1", "typo", false)
+	assert.ErrorIs(t, err, ErrPayoutNotFound)
+	assert.Empty(t, events.types())
+
+	// No claim is left behind: a payout later submitted with the same ID is sent
+	claim, err := s.txRecords.GetPayoutClaim(ctx, "payout-1")
+	require.NoError(t, err)
+	assert.Empty(t, claim)
+	jobResult, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	assert.True(t, jobResult.Success, "%v", jobResult.Error)
+	assert.Len(t, client.sent, 1)
+}
+
+func TestCancelPayout_AfterBroadcast(t *testing.T) {
+	w, s, client, events, _ := setupTestWatcher(t, 3)
+	s.SetEventPublisher(events)
+	ctx := context.Background()
+
+	jobResult, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	require.True(t, jobResult.Success, "%v", jobResult.Error)
+	_, original := recordedTx(t, s, "payout-1")
+
+	// Too late without a replacement
+	_, err = s.CancelPayout(ctx, "payout-1", "fraud flag", false)
+	assert.ErrorIs(t, err, ErrAlreadyBroadcast)
+	require.Len(t, client.sent, 1)
+
+	// Replace with a zero-value self transfer at the same nonce
+	result, err := s.CancelPayout(ctx, "payout-1", "fraud flag", true)
+	require.NoError(t, err)
+	require.NotEmpty(t, result.ReplacementTxHash)
+	require.Len(t, client.sent, 2)
+
+	record, replacement := recordedTx(t, s, "payout-1")
+	assert.Equal(t, result.ReplacementTxHash, replacement.Hash().Hex())
+	assert.Equal(t, original.Nonce(), replacement.Nonce())
+	assert.Equal(t, common.HexToAddress(testPayoutJob().FromAddress), *replacement.To())
+	assert.Zero(t, replacement.Value().Sign())
+	assert.Empty(t, replacement.Data())
+	assert.GreaterOrEqual(t, replacement.GasFeeCap().Cmp(bumpWei(original.GasFeeCap(), 10)), 0)
+	assert.True(t, record.Cancelled)
+	assert.Equal(t, []string{original.Hash().Hex()}, record.PrevHashes)
+
+	// Repeating the request returns the same replacement
+	again, err := s.CancelPayout(ctx, "payout-1", "fraud flag", true)
+	require.NoError(t, err)
+	assert.Equal(t, result.ReplacementTxHash, again.ReplacementTxHash)
+	require.Len(t, client.sent, 2)
+
+	// The replacement is mined: the payout ends as cancelled, not confirmed
+	client.mine(replacement.Hash())
+	action, err := w.CheckPayout(ctx, "payout-1")
+	require.NoError(t, err)
+	assert.Equal(t, WatchActionCancelled, action)
+	assert.Equal(t, []PayoutEventType{PayoutEventBroadcast, PayoutEventCancelled}, events.types())
+
+	// Once finished it can no longer be cancelled
+	_, err = s.CancelPayout(ctx, "payout-1", "", true)
+	assert.ErrorIs(t, err, ErrPayoutFinished)
+}
+
+func TestCancelPayout_OriginalMinedFirst(t *testing.T) {
+	w, s, client, events, _ := setupTestWatcher(t, 3)
+	ctx := context.Background()
+
+	jobResult, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	require.True(t, jobResult.Success, "%v", jobResult.Error)
+
+	_, err = s.CancelPayout(ctx, "payout-1", "", true)
+	require.NoError(t, err)
+
+	// The original transaction wins the race
+	client.mine(common.HexToHash(jobResult.TxHash))
+	action, err := w.CheckPayout(ctx, "payout-1")
+	require.NoError(t, err)
+	assert.Equal(t, WatchActionConfirmed, action)
+	assert.Equal(t, []PayoutEventType{PayoutEventConfirmed}, events.types())
+	assert.Equal(t, jobResult.TxHash, events.events[0].TxHash)
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/protocol-bank/payout-engine/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// PayoutEventsChannel 支付状态事件的 Redis 发布频道
+const PayoutEventsChannel = "payout:events"
+
+// WebhookDeadLetterKey 最终投递失败的回调事件列表
+const WebhookDeadLetterKey = "payout:webhook:deadletter"
+
+// payoutStatusTTL 最新状态的保留时间
+const payoutStatusTTL = 7 * 24 * time.Hour
+
+// PayoutEventType 支付事件类型
+type PayoutEventType string
+
+const (
+	PayoutEventQueued    PayoutEventType = "payout.queued"    // 已入队
+	PayoutEventBroadcast PayoutEventType = "payout.broadcast" // 交易已广播
+	PayoutEventConfirmed PayoutEventType = "payout.confirmed" // 交易达到确认深度且执行成功
+	PayoutEventReverted  PayoutEventType = "payout.reverted"  // 交易达到确认深度但执行失败
+	PayoutEventBumped    PayoutEventType = "payout.bumped"    // 已加价重发
+	PayoutEventFailed    PayoutEventType = "payout.failed"    // 放弃跟踪，需人工处理
+	PayoutEventCancelled PayoutEventType = "payout.cancelled" // 广播前取消，或取消用的替换交易已打包
+)
+
+// Terminal 是否为终态，终态之后不会再有事件
+func (t PayoutEventType) Terminal() bool {
+	return t == PayoutEventConfirmed || t == PayoutEventReverted || t == PayoutEventFailed || t == PayoutEventCancelled
+}
+
+// PayoutEvent 支付状态事件
+type PayoutEvent struct {
+	Type          PayoutEventType `json:"type"`
+	JobID         string          `json:"job_id"`
+	BatchID       string          `json:"batch_id"`
+	ChainID       uint64          `json:"chain_id"`
+	TxHash        string          `json:"tx_hash"`
+	Confirmations uint64          `json:"confirmations,omitempty"`
+	Attempts      int             `json:"attempts"`
+	Error         string          `json:"error,omitempty"`
+	Timestamp     time.Time       `json:"timestamp"`
+}
+
+// EventPublisher 支付事件发布器
+type EventPublisher interface {
+	Publish(ctx context.Context, event *PayoutEvent) error
+}
+
+// MultiPublisher 依次发布给多个发布器，返回所有错误
+type MultiPublisher []EventPublisher
+
+// Publish 发布给每个发布器，某个失败不影响其余
+func (m MultiPublisher) Publish(ctx context.Context, event *PayoutEvent) error {
+	var errs []error
+	for _, publisher := range m {
+		if err := publisher.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// publishEvent 发布事件，publisher 为 nil 时忽略，失败只记录日志
+func publishEvent(ctx context.Context, publisher EventPublisher, event *PayoutEvent) {
+	if publisher == nil {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if err := publisher.Publish(ctx, event); err != nil {
+		log.Error().Err(err).Str("job_id", event.JobID).Str("type", string(event.Type)).Msg("Failed to publish payout event")
+	}
+}
+
+// RedisEventPublisher 通过 Redis Pub/Sub 发布支付事件，并保存每笔支付的最新状态
+type RedisEventPublisher struct {
+	redis *redis.Client
+}
+
+// NewRedisEventPublisher 创建事件发布器
+func NewRedisEventPublisher(ctx context.Context, cfg config.RedisConfig) (*RedisEventPublisher, error) {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     cfg.URL,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("redis connection failed: %w", err)
+	}
+
+	return &RedisEventPublisher{redis: rdb}, nil
+}
+
+// payoutStatusKey 支付最新状态的 Redis 键
+func payoutStatusKey(jobID string) string {
+	return fmt.Sprintf("payout:status:%s", jobID)
+}
+
+// Publish 保存最新状态并发布事件
+func (p *RedisEventPublisher) Publish(ctx context.Context, event *PayoutEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payout event: %w", err)
+	}
+
+	pipe := p.redis.TxPipeline()
+	pipe.Set(ctx, payoutStatusKey(event.JobID), data, payoutStatusTTL)
+	pipe.Publish(ctx, PayoutEventsChannel, data)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// LatestEvent 返回支付的最新状态，不存在时返回 nil
+func (p *RedisEventPublisher) LatestEvent(ctx context.Context, jobID string) (*PayoutEvent, error) {
+	data, err := p.redis.Get(ctx, payoutStatusKey(jobID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payout status: %w", err)
+	}
+
+	var event PayoutEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payout status: %w", err)
+	}
+	return &event, nil
+}
+
+// Relay 订阅 Redis 频道并把事件转发给 dst，直到 ctx 取消
+// 多实例部署时任一实例发布的事件都会转发到本实例的订阅者。
+func (p *RedisEventPublisher) Relay(ctx context.Context, dst EventPublisher) {
+	sub := p.redis.Subscribe(ctx, PayoutEventsChannel)
+	defer sub.Close()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var event PayoutEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				log.Error().Err(err).Msg("Failed to unmarshal payout event")
+				continue
+			}
+			if err := dst.Publish(ctx, &event); err != nil {
+				log.Error().Err(err).Str("job_id", event.JobID).Msg("Failed to relay payout event")
+			}
+		}
+	}
+}
+
+// RecordWebhookDeadLetter 保存最终投递失败的回调事件，供人工排查与重放
+func (p *RedisEventPublisher) RecordWebhookDeadLetter(ctx context.Context, event *PayoutEvent, err error) {
+	data, marshalErr := json.Marshal(map[string]interface{}{
+		"event":     event,
+		"error":     err.Error(),
+		"failed_at": time.Now(),
+	})
+	if marshalErr != nil {
+		log.Error().Err(marshalErr).Str("job_id", event.JobID).Msg("Failed to marshal webhook dead letter")
+		return
+	}
+	if pushErr := p.redis.LPush(ctx, WebhookDeadLetterKey, data).Err(); pushErr != nil {
+		log.Error().Err(pushErr).Str("job_id", event.JobID).Msg("Failed to record webhook dead letter")
+	}
+}
+
+// Close 关闭连接
+func (p *RedisEventPublisher) Close() error {
+	return p.redis.Close()
+}
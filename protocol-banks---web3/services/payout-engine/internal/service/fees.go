@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/protocol-bank/payout-engine/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// feeHistoryBlocks eth_feeHistory 查询的区块数
+	feeHistoryBlocks = 5
+	// feeHistoryPercentile 小费取样的百分位
+	feeHistoryPercentile = 50
+)
+
+// TxFees 交易费用；GasPrice 非 nil 时为 legacy 交易，否则为 EIP-1559 交易
+type TxFees struct {
+	GasPrice  *big.Int
+	GasTipCap *big.Int // maxPriorityFeePerGas
+	GasFeeCap *big.Int // maxFeePerGas
+}
+
+// Dynamic 是否为 EIP-1559 交易
+func (f *TxFees) Dynamic() bool {
+	return f.GasPrice == nil
+}
+
+// maxFee 每单位 Gas 最多支付的费用
+func (f *TxFees) maxFee() *big.Int {
+	if f.Dynamic() {
+		return f.GasFeeCap
+	}
+	return f.GasPrice
+}
+
+// tip 每单位 Gas 的小费
+func (f *TxFees) tip() *big.Int {
+	if f.Dynamic() {
+		return f.GasTipCap
+	}
+	return f.GasPrice
+}
+
+// feeStrategy 返回链的费用策略，未配置的字段使用默认值
+func (s *PayoutService) feeStrategy(chainID uint64) config.FeeStrategy {
+	var strategy config.FeeStrategy
+	if s.cfg != nil {
+		strategy = s.cfg.Chains[chainID].Fee
+	}
+
+	defaults := config.DefaultFeeStrategy()
+	if strategy.Mode == "" {
+		strategy.Mode = defaults.Mode
+	}
+	if strategy.BaseFeeMultiplier == 0 {
+		strategy.BaseFeeMultiplier = defaults.BaseFeeMultiplier
+	}
+	if strategy.PriorityFeeMultiplier == 0 {
+		strategy.PriorityFeeMultiplier = defaults.PriorityFeeMultiplier
+	}
+	if strategy.GasPriceMultiplier == 0 {
+		strategy.GasPriceMultiplier = defaults.GasPriceMultiplier
+	}
+	return strategy
+}
+
+// suggestFees 按链的费用策略计算交易费用
+// EIP-1559：baseFee 取 eth_feeHistory 返回的下一个区块基础费用，小费取近几个区块的中位数；
+// auto 模式下节点不支持 eth_feeHistory 或链上没有 baseFee 时回退到 legacy gasPrice。
+func (s *PayoutService) suggestFees(ctx context.Context, client ChainClient, chainID uint64) (*TxFees, error) {
+	strategy := s.feeStrategy(chainID)
+
+	if strategy.Mode == config.FeeModeLegacy {
+		return suggestLegacyFees(ctx, client, strategy)
+	}
+
+	fees, err := suggestDynamicFees(ctx, client, strategy)
+	if err == nil {
+		return fees, nil
+	}
+	if strategy.Mode == config.FeeModeEIP1559 {
+		return nil, err
+	}
+
+	log.Debug().Err(err).Uint64("chain_id", chainID).Msg("EIP-1559 fees unavailable, falling back to legacy gas price")
+	return suggestLegacyFees(ctx, client, strategy)
+}
+
+// suggestDynamicFees 计算 EIP-1559 费用
+func suggestDynamicFees(ctx context.Context, client ChainClient, strategy config.FeeStrategy) (*TxFees, error) {
+	history, err := client.FeeHistory(ctx, feeHistoryBlocks, nil, []float64{feeHistoryPercentile})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee history: %w", err)
+	}
+	if len(history.BaseFee) == 0 {
+		return nil, fmt.Errorf("chain does not report base fee")
+	}
+	// BaseFee 比查询的区块多一项，最后一项为下一个区块的基础费用
+	baseFee := history.BaseFee[len(history.BaseFee)-1]
+	if baseFee == nil {
+		return nil, fmt.Errorf("chain does not report base fee")
+	}
+
+	tip := medianReward(history.Reward)
+	if tip == nil {
+		if tip, err = client.SuggestGasTipCap(ctx); err != nil {
+			return nil, fmt.Errorf("failed to get gas tip cap: %w", err)
+		}
+	}
+	tip = capWei(mulFloat(tip, strategy.PriorityFeeMultiplier), strategy.MaxPriorityFeePerGasGwei)
+
+	feeCap := new(big.Int).Add(mulFloat(baseFee, strategy.BaseFeeMultiplier), tip)
+	feeCap = capWei(feeCap, strategy.MaxFeePerGasGwei)
+
+	// 小费不能超过 maxFeePerGas
+	if tip.Cmp(feeCap) > 0 {
+		tip = new(big.Int).Set(feeCap)
+	}
+	if feeCap.Cmp(baseFee) < 0 {
+		log.Warn().
+			Str("base_fee", baseFee.String()).
+			Str("max_fee", feeCap.String()).
+			Msg("Max fee cap below current base fee, transaction will wait for base fee to drop")
+	}
+
+	return &TxFees{GasTipCap: tip, GasFeeCap: feeCap}, nil
+}
+
+// suggestLegacyFees 计算 legacy gasPrice
+func suggestLegacyFees(ctx context.Context, client ChainClient, strategy config.FeeStrategy) (*TxFees, error) {
+	gasPrice, err := client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gas price: %w", err)
+	}
+	gasPrice = capWei(mulFloat(gasPrice, strategy.GasPriceMultiplier), strategy.MaxFeePerGasGwei)
+	return &TxFees{GasPrice: gasPrice}, nil
+}
+
+// medianReward 取各区块小费的中位数，没有数据时返回 nil
+func medianReward(rewards [][]*big.Int) *big.Int {
+	var values []*big.Int
+	for _, reward := range rewards {
+		if len(reward) > 0 && reward[0] != nil && reward[0].Sign() > 0 {
+			values = append(values, reward[0])
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Cmp(values[j]) < 0 })
+	return new(big.Int).Set(values[len(values)/2])
+}
+
+// mulFloat 按倍数放大，精确到千分之一
+func mulFloat(x *big.Int, multiplier float64) *big.Int {
+	result := new(big.Int).Mul(x, big.NewInt(int64(multiplier*1000)))
+	return result.Div(result, big.NewInt(1000))
+}
+
+// capWei 将 wei 数值限制在 gwei 上限内，上限为 0 时不限制
+func capWei(x *big.Int, capGwei float64) *big.Int {
+	if capGwei <= 0 {
+		return x
+	}
+	limit := mulFloat(big.NewInt(1_000_000_000), capGwei)
+	if x.Cmp(limit) > 0 {
+		return limit
+	}
+	return x
+}
+
+// newTx 按费用类型构建交易
+func newTx(chainID uint64, nonceVal uint64, fees *TxFees, gasLimit uint64, to *common.Address, value *big.Int, data []byte) *types.Transaction {
+	if !fees.Dynamic() {
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    nonceVal,
+			GasPrice: fees.GasPrice,
+			Gas:      gasLimit,
+			To:       to,
+			Value:    value,
+			Data:     data,
+		})
+	}
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   new(big.Int).SetUint64(chainID),
+		Nonce:     nonceVal,
+		GasTipCap: fees.GasTipCap,
+		GasFeeCap: fees.GasFeeCap,
+		Gas:       gasLimit,
+		To:        to,
+		Value:     value,
+		Data:      data,
+	})
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/protocol-bank/payout-engine/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gwei(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1_000_000_000))
+}
+
+func feeTestService(strategy config.FeeStrategy) *PayoutService {
+	return &PayoutService{
+		cfg: &config.Config{
+			Chains: map[uint64]config.ChainConfig{1: {ChainID: 1, Fee: strategy}},
+		},
+	}
+}
+
+func eip1559Client() *fakeChainClient {
+	client := newFakeChainClient()
+	client.feeHistory = &ethereum.FeeHistory{
+		BaseFee: []*big.Int{gwei(8), gwei(9), gwei(10)},
+		Reward:  [][]*big.Int{{gwei(1)}, {gwei(3)}},
+	}
+	return client
+}
+
+func TestSuggestFees_EIP1559(t *testing.T) {
+	s := feeTestService(config.DefaultFeeStrategy())
+
+	fees, err := s.suggestFees(context.Background(), eip1559Client(), 1)
+	require.NoError(t, err)
+	require.True(t, fees.Dynamic())
+	assert.Nil(t, fees.GasPrice)
+
+	// tip = median(1, 3) * 1.2 = 3.6 gwei; maxFee = nextBaseFee(10) * 2 + tip
+	assert.Equal(t, big.NewInt(3_600_000_000), fees.GasTipCap)
+	assert.Equal(t, big.NewInt(23_600_000_000), fees.GasFeeCap)
+}
+
+func TestSuggestFees_FallsBackToSuggestedTip(t *testing.T) {
+	s := feeTestService(config.DefaultFeeStrategy())
+	client := eip1559Client()
+	client.feeHistory.Reward = nil
+	client.tipCap = gwei(2)
+
+	fees, err := s.suggestFees(context.Background(), client, 1)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(2_400_000_000), fees.GasTipCap)
+}
+
+func TestSuggestFees_Caps(t *testing.T) {
+	strategy := config.DefaultFeeStrategy()
+	strategy.MaxPriorityFeePerGasGwei = 2
+	strategy.MaxFeePerGasGwei = 15
+	s := feeTestService(strategy)
+
+	fees, err := s.suggestFees(context.Background(), eip1559Client(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, gwei(2), fees.GasTipCap)
+	assert.Equal(t, gwei(15), fees.GasFeeCap)
+}
+
+func TestSuggestFees_TipNeverExceedsMaxFee(t *testing.T) {
+	strategy := config.DefaultFeeStrategy()
+	strategy.MaxFeePerGasGwei = 3
+	s := feeTestService(strategy)
+
+	fees, err := s.suggestFees(context.Background(), eip1559Client(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, gwei(3), fees.GasFeeCap)
+	assert.Equal(t, gwei(3), fees.GasTipCap)
+}
+
+func TestSuggestFees_AutoFallsBackToLegacy(t *testing.T) {
+	s := feeTestService(config.DefaultFeeStrategy())
+	client := newFakeChainClient()
+	client.feeHistory = nil
+	client.gasPrice = gwei(5)
+
+	fees, err := s.suggestFees(context.Background(), client, 1)
+	require.NoError(t, err)
+	require.False(t, fees.Dynamic())
+	assert.Equal(t, gwei(6), fees.GasPrice)
+	assert.Nil(t, fees.GasTipCap)
+	assert.Nil(t, fees.GasFeeCap)
+}
+
+func TestSuggestFees_LegacyModeCapped(t *testing.T) {
+	strategy := config.DefaultFeeStrategy()
+	strategy.Mode = config.FeeModeLegacy
+	strategy.MaxFeePerGasGwei = 4
+	s := feeTestService(strategy)
+	client := eip1559Client()
+	client.gasPrice = gwei(5)
+
+	fees, err := s.suggestFees(context.Background(), client, 1)
+	require.NoError(t, err)
+	require.False(t, fees.Dynamic())
+	assert.Equal(t, gwei(4), fees.GasPrice)
+}
+
+func TestSuggestFees_EIP1559ModeRequiresSupport(t *testing.T) {
+	strategy := config.DefaultFeeStrategy()
+	strategy.Mode = config.FeeModeEIP1559
+	s := feeTestService(strategy)
+	client := newFakeChainClient()
+	client.feeHistory = nil
+
+	_, err := s.suggestFees(context.Background(), client, 1)
+	assert.Error(t, err)
+}
+
+func TestNewTx_FeeFields(t *testing.T) {
+	dynamic := newTx(1, 7, &TxFees{GasTipCap: gwei(1), GasFeeCap: gwei(20)}, 21000, nil, big.NewInt(1), nil)
+	assert.Equal(t, uint8(types.DynamicFeeTxType), dynamic.Type())
+	assert.Equal(t, gwei(1), dynamic.GasTipCap())
+	assert.Equal(t, gwei(20), dynamic.GasFeeCap())
+	assert.Equal(t, uint64(7), dynamic.Nonce())
+
+	legacy := newTx(1, 7, &TxFees{GasPrice: gwei(6)}, 21000, nil, big.NewInt(1), nil)
+	assert.Equal(t, uint8(types.LegacyTxType), legacy.Type())
+	assert.Equal(t, gwei(6), legacy.GasPrice())
+}
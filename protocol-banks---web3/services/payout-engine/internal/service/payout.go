@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
@@ -10,25 +11,73 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/protocol-bank/payout-engine/internal/config"
 	"github.com/protocol-bank/payout-engine/internal/nonce"
 	"github.com/protocol-bank/payout-engine/internal/queue"
+	"github.com/protocol-bank/payout-engine/internal/signer"
 	"github.com/rs/zerolog/log"
 )
 
 // ERC20 ABI (只需要 transfer 函数)
 const erc20ABI = `[{"constant":false,"inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"name":"transfer","outputs":[{"name":"","type":"bool"}],"type":"function"}]`
 
+// ChainClient 支付服务使用的链客户端，*ethclient.Client 满足该接口
+type ChainClient interface {
+	nonce.ChainClient
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error)
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// ErrChainIDMismatch RPC 节点所在链与配置的链 ID 不一致，按配置签名的交易都会被拒绝
+var ErrChainIDMismatch = errors.New("rpc chain id does not match configured chain id")
+
+// chainIDCheckTimeout 启动时查询 eth_chainId 的超时
+const chainIDCheckTimeout = 10 * time.Second
+
+// chainIDReader 查询 eth_chainId，*ethclient.Client 满足该接口
+type chainIDReader interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+// checkChainID 校验 RPC 节点的 eth_chainId 与配置一致
+func checkChainID(ctx context.Context, client chainIDReader, chainID uint64) error {
+	ctx, cancel := context.WithTimeout(ctx, chainIDCheckTimeout)
+	defer cancel()
+
+	actual, err := client.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get chain id: %w", err)
+	}
+	if !actual.IsUint64() || actual.Uint64() != chainID {
+		return fmt.Errorf("%w: configured %d, rpc returned %s", ErrChainIDMismatch, chainID, actual)
+	}
+	return nil
+}
+
+// signFunc 交易签名函数，from 为任务分配的签名地址
+type signFunc func(ctx context.Context, tx *types.Transaction, chainID uint64, from common.Address) (*types.Transaction, error)
+
 // PayoutService 支付服务
 type PayoutService struct {
 	cfg          *config.Config
 	nonceManager *nonce.Manager
-	queue        *queue.Consumer
-	clients      map[uint64]*ethclient.Client
+	queue        queue.JobSource
+	txRecords    TxRecordStore
+	clients      map[uint64]ChainClient
 	erc20ABI     abi.ABI
+	sign         signFunc
+	signers      *signer.Pool
+	events       EventPublisher
 }
 
 // NewPayoutService 创建支付服务
@@ -36,7 +85,8 @@ func NewPayoutService(
 	ctx context.Context,
 	cfg *config.Config,
 	nonceManager *nonce.Manager,
-	queueConsumer *queue.Consumer,
+	queueConsumer queue.JobSource,
+	txRecords TxRecordStore,
 ) (*PayoutService, error) {
 	// 解析 ERC20 ABI
 	parsedABI, err := abi.JSON(strings.NewReader(erc20ABI))
@@ -45,25 +95,59 @@ func NewPayoutService(
 	}
 
 	// 初始化链客户端
-	clients := make(map[uint64]*ethclient.Client)
+	clients := make(map[uint64]ChainClient)
 	for chainID, chainCfg := range cfg.Chains {
 		client, err := ethclient.Dial(chainCfg.RPCURL)
 		if err != nil {
 			log.Warn().Err(err).Uint64("chain_id", chainID).Msg("Failed to connect to chain")
 			continue
 		}
+		if err := checkChainID(ctx, client, chainCfg.ChainID); err != nil {
+			if errors.Is(err, ErrChainIDMismatch) {
+				client.Close()
+				return nil, fmt.Errorf("chain %s: %w", chainCfg.Name, err)
+			}
+			// 节点暂时不可达不阻止启动，健康检查会报告
+			log.Warn().Err(err).Uint64("chain_id", chainID).Msg("Failed to verify chain ID")
+		}
 		clients[chainID] = client
 		nonceManager.AddChainClient(chainID, client)
 		log.Info().Uint64("chain_id", chainID).Str("name", chainCfg.Name).Msg("Connected to chain")
 	}
 
-	return &PayoutService{
+	s := &PayoutService{
 		cfg:          cfg,
 		nonceManager: nonceManager,
 		queue:        queueConsumer,
+		txRecords:    txRecords,
 		clients:      clients,
 		erc20ABI:     parsedABI,
-	}, nil
+	}
+	s.sign = s.signTransaction
+	return s, nil
+}
+
+// SetEventPublisher 设置支付状态事件发布器（入队、广播、失败）
+func (s *PayoutService) SetEventPublisher(events EventPublisher) {
+	s.events = events
+}
+
+// SetSignerPool 设置签名地址池，任务提交时轮流分配签名地址
+func (s *PayoutService) SetSignerPool(signers *signer.Pool) {
+	s.signers = signers
+}
+
+// CheckChains 检查所有链客户端是否可达，用于健康检查
+func (s *PayoutService) CheckChains(ctx context.Context) error {
+	if len(s.clients) == 0 {
+		return fmt.Errorf("no chain clients connected")
+	}
+	for chainID, client := range s.clients {
+		if _, err := client.BlockNumber(ctx); err != nil {
+			return fmt.Errorf("chain %d unreachable: %w", chainID, err)
+		}
+	}
+	return nil
 }
 
 // SubmitBatchPayout 提交批量支付
@@ -86,7 +170,7 @@ func (s *PayoutService) SubmitBatchPayout(ctx context.Context, req *BatchPayoutR
 			ID:            item.ID,
 			BatchID:       req.BatchID,
 			UserID:        req.UserID,
-			FromAddress:   req.FromAddress,
+			FromAddress:   s.assignSigner(req.FromAddress),
 			ToAddress:     item.RecipientAddress,
 			Amount:        item.Amount,
 			TokenAddress:  item.TokenAddress,
@@ -102,6 +186,14 @@ func (s *PayoutService) SubmitBatchPayout(ctx context.Context, req *BatchPayoutR
 	if err := s.queue.PushBatch(ctx, jobs); err != nil {
 		return nil, fmt.Errorf("failed to queue jobs: %w", err)
 	}
+	for _, job := range jobs {
+		publishEvent(ctx, s.events, &PayoutEvent{
+			Type:    PayoutEventQueued,
+			JobID:   job.ID,
+			BatchID: job.BatchID,
+			ChainID: job.ChainID,
+		})
+	}
 
 	return &BatchPayoutResponse{
 		BatchID: req.BatchID,
@@ -128,6 +220,20 @@ func (s *PayoutService) ProcessJob(ctx context.Context, job *queue.Job) (*queue.
 		}, nil
 	}
 
+	// 幂等检查：消费者在广播后、确认前崩溃会导致任务被重投递，
+	// 此时返回已记录的交易哈希并重新广播同一笔交易，避免重复支付
+	record, err := s.txRecords.GetTxRecord(ctx, job.ID)
+	if err != nil {
+		return &queue.JobResult{
+			JobID:   job.ID,
+			Success: false,
+			Error:   fmt.Errorf("failed to check tx record: %w", err),
+		}, nil
+	}
+	if record != nil {
+		return s.rebroadcast(ctx, client, job, record), nil
+	}
+
 	// 获取 Nonce
 	fromAddr := common.HexToAddress(job.FromAddress)
 	nonceVal, releaseFn, err := s.nonceManager.GetNonce(ctx, job.ChainID, fromAddr)
@@ -157,14 +263,10 @@ func (s *PayoutService) ProcessJob(ctx context.Context, job *queue.Job) (*queue.
 		}, nil
 	}
 
-	// 签名交易 (这里需要从安全存储获取私钥)
-	// 注意：生产环境应使用 HSM 或 KMS
-	signedTx, err := s.signTransaction(ctx, tx, job.ChainID)
+	// 签名交易：签名后端只对已分配 Nonce 的交易签名，不参与 Nonce 管理；
+	// 签名失败时本次分配的 Nonce 未被使用，由对账任务修复缺口
+	signedTx, err := s.sign(ctx, tx, job.ChainID, fromAddr)
 	if err != nil {
-		// Nonce 错误时重置
-		if strings.Contains(err.Error(), "nonce") {
-			s.nonceManager.ResetNonce(ctx, job.ChainID, fromAddr)
-		}
 		return &queue.JobResult{
 			JobID:   job.ID,
 			Success: false,
@@ -172,8 +274,65 @@ func (s *PayoutService) ProcessJob(ctx context.Context, job *queue.Job) (*queue.
 		}, nil
 	}
 
+	// 广播前记录支付 ID → 交易哈希
+	rawTx, err := signedTx.MarshalBinary()
+	if err != nil {
+		return &queue.JobResult{
+			JobID:   job.ID,
+			Success: false,
+			Error:   fmt.Errorf("failed to encode transaction: %w", err),
+		}, nil
+	}
+	now := time.Now()
+	recorded, existing, err := s.txRecords.RecordTx(ctx, &TxRecord{
+		JobID:       job.ID,
+		BatchID:     job.BatchID,
+		ChainID:     job.ChainID,
+		From:        fromAddr.Hex(),
+		Nonce:       nonceVal,
+		TxHash:      signedTx.Hash().Hex(),
+		RawTx:       hexutil.Encode(rawTx),
+		CreatedAt:   now,
+		SubmittedAt: now,
+	})
+	if err != nil {
+		return &queue.JobResult{
+			JobID:   job.ID,
+			Success: false,
+			Error:   fmt.Errorf("failed to record transaction: %w", err),
+		}, nil
+	}
+	if !recorded {
+		// 另一个消费者已为该任务签发交易，本次分配的 Nonce 由对账任务修复
+		log.Warn().
+			Str("job_id", job.ID).
+			Str("tx_hash", existing.TxHash).
+			Msg("Payout already recorded by another worker")
+		return &queue.JobResult{
+			JobID:   job.ID,
+			Success: true,
+			TxHash:  existing.TxHash,
+		}, nil
+	}
+
+	// 广播前裁决，取消请求已先生效时放弃
+	if result := s.claimBroadcast(ctx, job); result != nil {
+		return result, nil
+	}
+
 	// 发送交易
 	if err := client.SendTransaction(ctx, signedTx); err != nil {
+		// 节点明确拒绝（JSON-RPC 错误）时交易未进入内存池，删除记录并撤销裁决以便重试时重新构建；
+		// 网络错误时交易可能已广播，保留记录，重试时重新广播同一笔交易
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) {
+			if delErr := s.txRecords.DeleteTxRecord(ctx, job.ID); delErr != nil {
+				log.Error().Err(delErr).Str("job_id", job.ID).Msg("Failed to delete tx record")
+			}
+			if relErr := s.txRecords.ReleaseBroadcastClaim(ctx, job.ID); relErr != nil {
+				log.Error().Err(relErr).Str("job_id", job.ID).Msg("Failed to release broadcast claim")
+			}
+		}
 		// Nonce 错误时重置
 		if strings.Contains(err.Error(), "nonce") {
 			s.nonceManager.ResetNonce(ctx, job.ChainID, fromAddr)
@@ -186,6 +345,14 @@ func (s *PayoutService) ProcessJob(ctx context.Context, job *queue.Job) (*queue.
 	}
 
 	txHash := signedTx.Hash().Hex()
+	s.trackPending(ctx, job.ID)
+	publishEvent(ctx, s.events, &PayoutEvent{
+		Type:    PayoutEventBroadcast,
+		JobID:   job.ID,
+		BatchID: job.BatchID,
+		ChainID: job.ChainID,
+		TxHash:  txHash,
+	})
 	log.Info().
 		Str("job_id", job.ID).
 		Str("tx_hash", txHash).
@@ -198,10 +365,120 @@ func (s *PayoutService) ProcessJob(ctx context.Context, job *queue.Job) (*queue.
 	}, nil
 }
 
+// rebroadcast 重投递的任务：重新广播已记录的交易并返回其哈希
+// 交易哈希不变，链上最多执行一次；节点已知该交易或 Nonce 已被使用时视为成功。
+func (s *PayoutService) rebroadcast(ctx context.Context, client ChainClient, job *queue.Job, record *TxRecord) *queue.JobResult {
+	log.Info().
+		Str("job_id", job.ID).
+		Str("tx_hash", record.TxHash).
+		Msg("Payout already has a transaction, rebroadcasting")
+
+	if result := s.claimBroadcast(ctx, job); result != nil {
+		return result
+	}
+
+	rawTx, err := hexutil.Decode(record.RawTx)
+	if err != nil {
+		return &queue.JobResult{
+			JobID:   job.ID,
+			Success: false,
+			TxHash:  record.TxHash,
+			Error:   fmt.Errorf("invalid recorded transaction: %w", err),
+		}
+	}
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(rawTx); err != nil {
+		return &queue.JobResult{
+			JobID:   job.ID,
+			Success: false,
+			TxHash:  record.TxHash,
+			Error:   fmt.Errorf("invalid recorded transaction: %w", err),
+		}
+	}
+
+	if err := client.SendTransaction(ctx, &tx); err != nil && !isAlreadyBroadcast(err) {
+		return &queue.JobResult{
+			JobID:   job.ID,
+			Success: false,
+			TxHash:  record.TxHash,
+			Error:   fmt.Errorf("failed to rebroadcast transaction: %w", err),
+		}
+	}
+	s.trackPending(ctx, job.ID)
+
+	return &queue.JobResult{
+		JobID:   job.ID,
+		Success: true,
+		TxHash:  record.TxHash,
+	}
+}
+
+// claimBroadcast 广播前写入裁决；取消已先生效时删除交易记录并返回取消结果，可以广播时返回 nil
+// 本次分配的 Nonce 不会被使用，由对账任务修复缺口。
+func (s *PayoutService) claimBroadcast(ctx context.Context, job *queue.Job) *queue.JobResult {
+	claim, err := s.txRecords.ClaimPayout(ctx, job.ID, ClaimBroadcast)
+	if err != nil {
+		return &queue.JobResult{
+			JobID:   job.ID,
+			Success: false,
+			Error:   err,
+		}
+	}
+	if claim != ClaimCancelled {
+		return nil
+	}
+
+	if err := s.txRecords.DeleteTxRecord(ctx, job.ID); err != nil {
+		log.Error().Err(err).Str("job_id", job.ID).Msg("Failed to delete tx record")
+	}
+	log.Info().Str("job_id", job.ID).Msg("Payout cancelled before broadcast")
+	publishEvent(ctx, s.events, &PayoutEvent{
+		Type:    PayoutEventCancelled,
+		JobID:   job.ID,
+		BatchID: job.BatchID,
+		ChainID: job.ChainID,
+		Error:   "cancelled before broadcast",
+	})
+	return &queue.JobResult{JobID: job.ID, Cancelled: true}
+}
+
+// HandleDeadLetter 任务超过重试次数进入死信队列时发出失败事件
+func (s *PayoutService) HandleDeadLetter(ctx context.Context, job *queue.Job, err error) {
+	event := &PayoutEvent{
+		Type:     PayoutEventFailed,
+		JobID:    job.ID,
+		BatchID:  job.BatchID,
+		ChainID:  job.ChainID,
+		Attempts: job.RetryCount,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	publishEvent(ctx, s.events, event)
+}
+
+// trackPending 交由 TxWatcher 跟踪确认；失败只记录日志，支付本身已广播
+func (s *PayoutService) trackPending(ctx context.Context, jobID string) {
+	if err := s.txRecords.AddPending(ctx, jobID); err != nil {
+		log.Error().Err(err).Str("job_id", jobID).Msg("Failed to track pending payout")
+	}
+}
+
+// isAlreadyBroadcast 节点返回的错误表示交易已在内存池或已上链
+func isAlreadyBroadcast(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already known") || strings.Contains(msg, "nonce too low")
+}
+
+// isNonceTooLow 节点返回的错误表示该 Nonce 已上链
+func isNonceTooLow(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "nonce too low")
+}
+
 // buildNativeTransfer 构建原生代币转账交易
 func (s *PayoutService) buildNativeTransfer(
 	ctx context.Context,
-	client *ethclient.Client,
+	client ChainClient,
 	job *queue.Job,
 	nonceVal uint64,
 ) (*types.Transaction, error) {
@@ -211,16 +488,12 @@ func (s *PayoutService) buildNativeTransfer(
 		return nil, fmt.Errorf("invalid amount: %s", job.Amount)
 	}
 
-	// 获取 Gas 价格
-	gasPrice, err := client.SuggestGasPrice(ctx)
+	// 按链的费用策略计算 Gas 费用
+	fees, err := s.suggestFees(ctx, client, job.ChainID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get gas price: %w", err)
+		return nil, err
 	}
 
-	// 增加 20% Gas 价格以加快确认
-	gasPrice = new(big.Int).Mul(gasPrice, big.NewInt(120))
-	gasPrice = new(big.Int).Div(gasPrice, big.NewInt(100))
-
 	// 估算 Gas
 	msg := ethereum.CallMsg{
 		From:  common.HexToAddress(job.FromAddress),
@@ -235,24 +508,13 @@ func (s *PayoutService) buildNativeTransfer(
 	// 增加 20% Gas Limit
 	gasLimit = gasLimit * 120 / 100
 
-	chainID := new(big.Int).SetUint64(job.ChainID)
-	tx := types.NewTx(&types.DynamicFeeTx{
-		ChainID:   chainID,
-		Nonce:     nonceVal,
-		GasTipCap: gasPrice,
-		GasFeeCap: new(big.Int).Mul(gasPrice, big.NewInt(2)),
-		Gas:       gasLimit,
-		To:        &toAddr,
-		Value:     value,
-	})
-
-	return tx, nil
+	return newTx(job.ChainID, nonceVal, fees, gasLimit, &toAddr, value, nil), nil
 }
 
 // buildERC20Transfer 构建 ERC20 转账交易
 func (s *PayoutService) buildERC20Transfer(
 	ctx context.Context,
-	client *ethclient.Client,
+	client ChainClient,
 	job *queue.Job,
 	nonceVal uint64,
 ) (*types.Transaction, error) {
@@ -269,16 +531,12 @@ func (s *PayoutService) buildERC20Transfer(
 		return nil, fmt.Errorf("failed to pack transfer data: %w", err)
 	}
 
-	// 获取 Gas 价格
-	gasPrice, err := client.SuggestGasPrice(ctx)
+	// 按链的费用策略计算 Gas 费用
+	fees, err := s.suggestFees(ctx, client, job.ChainID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get gas price: %w", err)
+		return nil, err
 	}
 
-	// 增加 20% Gas 价格
-	gasPrice = new(big.Int).Mul(gasPrice, big.NewInt(120))
-	gasPrice = new(big.Int).Div(gasPrice, big.NewInt(100))
-
 	// 估算 Gas
 	msg := ethereum.CallMsg{
 		From: common.HexToAddress(job.FromAddress),
@@ -293,44 +551,24 @@ func (s *PayoutService) buildERC20Transfer(
 	// 增加 20% Gas Limit
 	gasLimit = gasLimit * 120 / 100
 
-	chainID := new(big.Int).SetUint64(job.ChainID)
-	tx := types.NewTx(&types.DynamicFeeTx{
-		ChainID:   chainID,
-		Nonce:     nonceVal,
-		GasTipCap: gasPrice,
-		GasFeeCap: new(big.Int).Mul(gasPrice, big.NewInt(2)),
-		Gas:       gasLimit,
-		To:        &tokenAddr,
-		Value:     big.NewInt(0),
-		Data:      data,
-	})
-
-	return tx, nil
+	return newTx(job.ChainID, nonceVal, fees, gasLimit, &tokenAddr, big.NewInt(0), data), nil
 }
 
-// signTransaction 签名交易
-// 注意：生产环境应使用 HSM/KMS，这里只是示例
-func (s *PayoutService) signTransaction(ctx context.Context, tx *types.Transaction, chainID uint64) (*types.Transaction, error) {
-	// TODO: 从安全存储获取私钥
-	// 生产环境应使用 AWS KMS, GCP KMS, 或 HashiCorp Vault
-	privateKeyHex := "" // 从环境变量或密钥管理服务获取
-
-	if privateKeyHex == "" {
-		return nil, fmt.Errorf("private key not configured")
-	}
-
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
-	if err != nil {
-		return nil, fmt.Errorf("invalid private key: %w", err)
+// signTransaction 用签名地址池的签名后端（本地私钥或外部签名服务）签名交易
+func (s *PayoutService) signTransaction(ctx context.Context, tx *types.Transaction, chainID uint64, from common.Address) (*types.Transaction, error) {
+	if s.signers == nil {
+		return nil, fmt.Errorf("signer not configured")
 	}
+	return s.signers.SignTx(ctx, tx, chainID, from)
+}
 
-	signer := types.LatestSignerForChainID(new(big.Int).SetUint64(chainID))
-	signedTx, err := types.SignTx(tx, signer, privateKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to sign: %w", err)
+// assignSigner 任务的签名地址：请求指定了地址时固定使用该地址，否则从签名地址池轮流分配
+// 每个地址有独立的 Nonce 序列，分散到多个地址的任务可以并行广播。
+func (s *PayoutService) assignSigner(requested string) string {
+	if requested != "" || s.signers == nil {
+		return requested
 	}
-
-	return signedTx, nil
+	return s.signers.Next().Hex()
 }
 
 // validateRequest 验证请求
@@ -341,9 +579,12 @@ func (s *PayoutService) validateRequest(req *BatchPayoutRequest) error {
 	if req.UserID == "" {
 		return fmt.Errorf("user_id is required")
 	}
-	if req.FromAddress == "" {
+	if s.signers == nil && req.FromAddress == "" {
 		return fmt.Errorf("from_address is required")
 	}
+	if s.signers != nil && req.FromAddress != "" && !s.signers.Contains(common.HexToAddress(req.FromAddress)) {
+		return fmt.Errorf("from_address is not a configured signer")
+	}
 	if len(req.Items) == 0 {
 		return fmt.Errorf("at least one item is required")
 	}
@@ -2,11 +2,26 @@ package service
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/protocol-bank/payout-engine/internal/config"
+	"github.com/protocol-bank/payout-engine/internal/nonce"
+	"github.com/protocol-bank/payout-engine/internal/queue"
+	"github.com/protocol-bank/payout-engine/internal/signer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // Mock Ethereum client
@@ -120,7 +135,202 @@ func TestBatchSizeOptimization(t *testing.T) {
 	}
 }
 
+func TestSubmitBatchPayout_SpreadsJobsAcrossSigners(t *testing.T) {
+	ctx := context.Background()
+	s, client, _ := setupTestPayoutService(t)
+	signers, err := signer.NewPool(ctx, config.SignerConfig{
+		Mnemonic:       "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		DerivationPath: "m/44'/60'/0'/0/0",
+		PoolSize:       3,
+	})
+	require.NoError(t, err)
+	s.SetSignerPool(signers)
+	s.sign = s.signTransaction
+
+	req := &BatchPayoutRequest{BatchID: "batch-1", UserID: "user-1", ChainID: 1}
+	for i := 0; i < 6; i++ {
+		req.Items = append(req.Items, PayoutItem{
+			ID:               fmt.Sprintf("payout-%d", i),
+			RecipientAddress: "0x2222222222222222222222222222222222222222",
+			Amount:           "1000",
+		})
+	}
+	_, err = s.SubmitBatchPayout(ctx, req)
+	require.NoError(t, err)
+
+	for _, item := range req.Items {
+		job, err := s.queue.Remove(ctx, item.ID)
+		require.NoError(t, err)
+		require.NotNil(t, job)
+		result, err := s.ProcessJob(ctx, job)
+		require.NoError(t, err)
+		require.True(t, result.Success, "%v", result.Error)
+	}
+
+	// 任务轮流分配到 3 个地址，每个地址的 Nonce 独立从 0 开始
+	require.Len(t, client.sent, 6)
+	nonces := make(map[common.Address][]uint64)
+	for _, tx := range client.sent {
+		from, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1)), tx)
+		require.NoError(t, err)
+		nonces[from] = append(nonces[from], tx.Nonce())
+	}
+	for _, address := range signers.Addresses() {
+		assert.Equal(t, []uint64{0, 1}, nonces[address], address.Hex())
+	}
+}
+
+func TestSubmitBatchPayout_PinnedSigner(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := setupTestPayoutService(t)
+	signers, err := signer.NewPool(ctx, config.SignerConfig{
+		PrivateKeys: []string{
+			"4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318",
+			"4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362319",
+		},
+	})
+	require.NoError(t, err)
+	s.SetSignerPool(signers)
+
+	item := PayoutItem{ID: "payout-1", RecipientAddress: "0x2222222222222222222222222222222222222222", Amount: "1000"}
+	pinned := signers.Addresses()[1].Hex()
+	_, err = s.SubmitBatchPayout(ctx, &BatchPayoutRequest{
+		BatchID: "batch-1", UserID: "user-1", ChainID: 1, FromAddress: pinned, Items: []PayoutItem{item},
+	})
+	require.NoError(t, err)
+	job, err := s.queue.Remove(ctx, item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, pinned, job.FromAddress)
+
+	// 不在签名地址池中的地址无法签名
+	_, err = s.SubmitBatchPayout(ctx, &BatchPayoutRequest{
+		BatchID: "batch-2", UserID: "user-1", ChainID: 1, FromAddress: "0x1111111111111111111111111111111111111111", Items: []PayoutItem{item},
+	})
+	assert.Error(t, err)
+}
+
+// stubSigner 签名后端桩：记录收到的 Nonce，err 非 nil 时签名失败
+type stubSigner struct {
+	key    *ecdsa.PrivateKey
+	nonces []uint64
+	err    error
+}
+
+func (s *stubSigner) Addresses() []common.Address {
+	return []common.Address{crypto.PubkeyToAddress(s.key.PublicKey)}
+}
+
+func (s *stubSigner) SignTx(_ context.Context, tx *types.Transaction, chainID uint64, _ common.Address) (*types.Transaction, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.nonces = append(s.nonces, tx.Nonce())
+	return types.SignTx(tx, types.LatestSignerForChainID(new(big.Int).SetUint64(chainID)), s.key)
+}
+
+func TestProcessJob_SignerDecoupledFromNonce(t *testing.T) {
+	ctx := context.Background()
+	s, client, _ := setupTestPayoutService(t)
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	stub := &stubSigner{key: key}
+	signers, err := signer.NewPoolWithSigner(stub)
+	require.NoError(t, err)
+	s.SetSignerPool(signers)
+	s.sign = s.signTransaction
+
+	from := signers.Addresses()[0].Hex()
+	job := func(id string) *queue.Job {
+		j := testPayoutJob()
+		j.ID = id
+		j.FromAddress = from
+		return j
+	}
+
+	result, err := s.ProcessJob(ctx, job("payout-1"))
+	require.NoError(t, err)
+	require.True(t, result.Success, "%v", result.Error)
+
+	// 签名失败不重置 Nonce，即使错误信息提到 nonce
+	stub.err = errors.New("kms: nonce service unavailable")
+	result, err = s.ProcessJob(ctx, job("payout-2"))
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+
+	stub.err = nil
+	result, err = s.ProcessJob(ctx, job("payout-3"))
+	require.NoError(t, err)
+	require.True(t, result.Success, "%v", result.Error)
+
+	// 签名后端只签 nonce.Manager 分配的 Nonce；失败任务的 Nonce 1 留给对账任务修复
+	assert.Equal(t, []uint64{0, 2}, stub.nonces)
+	require.Len(t, client.sent, 2)
+	assert.Equal(t, uint64(2), client.sent[1].Nonce())
+
+	// 不属于签名地址池的地址无法签名
+	other := job("payout-4")
+	other.FromAddress = "0x1111111111111111111111111111111111111111"
+	result, err = s.ProcessJob(ctx, other)
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.ErrorIs(t, result.Error, signer.ErrUnknownSigner)
+}
+
 // Helper functions for tests
+// fakeChainIDClient 返回固定的 eth_chainId
+type fakeChainIDClient struct {
+	id  *big.Int
+	err error
+}
+
+func (c fakeChainIDClient) ChainID(context.Context) (*big.Int, error) {
+	return c.id, c.err
+}
+
+func TestCheckChainID(t *testing.T) {
+	ctx := context.Background()
+	assert.NoError(t, checkChainID(ctx, fakeChainIDClient{id: big.NewInt(137)}, 137))
+
+	err := checkChainID(ctx, fakeChainIDClient{id: big.NewInt(80002)}, 137)
+	assert.ErrorIs(t, err, ErrChainIDMismatch)
+	assert.ErrorContains(t, err, "configured 137, rpc returned 80002")
+
+	err = checkChainID(ctx, fakeChainIDClient{err: errors.New("connection refused")}, 137)
+	assert.ErrorContains(t, err, "connection refused")
+	assert.NotErrorIs(t, err, ErrChainIDMismatch)
+}
+
+func TestNewPayoutService_ChainIDMismatch(t *testing.T) {
+	// RPC 指向 Polygon Amoy 测试网，配置却是 Polygon 主网
+	rpcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": "0x13882"})
+	}))
+	t.Cleanup(rpcServer.Close)
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	nonceManager, err := nonce.NewManager(context.Background(), config.RedisConfig{URL: mr.Addr()})
+	require.NoError(t, err)
+
+	cfg := &config.Config{Chains: map[uint64]config.ChainConfig{
+		137: {ChainID: 137, Name: "Polygon", RPCURL: rpcServer.URL},
+	}}
+	_, err = NewPayoutService(context.Background(), cfg, nonceManager, nil, nil)
+	assert.ErrorIs(t, err, ErrChainIDMismatch)
+
+	cfg.Chains = map[uint64]config.ChainConfig{
+		80002: {ChainID: 80002, Name: "Polygon Amoy", RPCURL: rpcServer.URL},
+	}
+	s, err := NewPayoutService(context.Background(), cfg, nonceManager, nil, nil)
+	require.NoError(t, err)
+	assert.Contains(t, s.clients, uint64(80002))
+}
+
 func isValidAddress(address string) bool {
 	if len(address) != 42 {
 		return false
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+)
+
+// ResubmitNonce 重新广播某个地址在该 Nonce 上已记录的交易，供 Nonce 对账修复缺口
+// 交易被内存池丢弃后，重新广播同一笔已签名交易即可填补缺口，不会重复支付。
+func (s *PayoutService) ResubmitNonce(ctx context.Context, chainID uint64, address common.Address, nonce uint64) error {
+	client, ok := s.clients[chainID]
+	if !ok {
+		return fmt.Errorf("unsupported chain: %d", chainID)
+	}
+
+	records, err := s.trackedRecords(ctx, chainID, address)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if record.Nonce != nonce {
+			continue
+		}
+		tx, err := record.Transaction()
+		if err != nil {
+			return err
+		}
+		if err := client.SendTransaction(ctx, tx); err != nil && !isAlreadyBroadcast(err) {
+			return fmt.Errorf("failed to rebroadcast transaction: %w", err)
+		}
+		log.Info().
+			Str("job_id", record.JobID).
+			Str("tx_hash", record.TxHash).
+			Uint64("nonce", nonce).
+			Msg("Rebroadcast transaction for missing nonce")
+		return nil
+	}
+	return fmt.Errorf("no tracked transaction for nonce %d of %s", nonce, address.Hex())
+}
+
+// HasTrackedNonce 是否仍有等待确认的交易占用该地址不小于 nonce 的 Nonce
+// 此时不能把 Nonce 重置到链上值，否则新支付会复用这些 Nonce 并替换掉已广播的支付。
+func (s *PayoutService) HasTrackedNonce(ctx context.Context, chainID uint64, address common.Address, nonce uint64) (bool, error) {
+	records, err := s.trackedRecords(ctx, chainID, address)
+	if err != nil {
+		return false, err
+	}
+	for _, record := range records {
+		if record.Nonce >= nonce {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// trackedRecords 列出该地址等待确认的交易记录
+func (s *PayoutService) trackedRecords(ctx context.Context, chainID uint64, address common.Address) ([]*TxRecord, error) {
+	jobIDs, err := s.txRecords.ListPending(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending payouts: %w", err)
+	}
+
+	var records []*TxRecord
+	for _, jobID := range jobIDs {
+		record, err := s.txRecords.GetTxRecord(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		if record == nil || record.ChainID != chainID || !strings.EqualFold(record.From, address.Hex()) {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
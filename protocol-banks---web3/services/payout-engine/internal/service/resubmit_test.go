@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResubmitNonce_RebroadcastsRecordedTx(t *testing.T) {
+	s, client, _ := setupTestPayoutService(t)
+	ctx := context.Background()
+	from := common.HexToAddress(testPayoutJob().FromAddress)
+
+	result, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	require.True(t, result.Success, "%v", result.Error)
+	_, original := recordedTx(t, s, "payout-1")
+
+	// The mempool dropped the transaction: the same signed tx is sent again
+	delete(client.known, original.Hash())
+	require.NoError(t, s.ResubmitNonce(ctx, 1, from, original.Nonce()))
+	require.Len(t, client.sent, 2)
+	assert.Equal(t, original.Hash(), client.sent[1].Hash())
+
+	// Still in the mempool counts as resubmitted
+	require.NoError(t, s.ResubmitNonce(ctx, 1, from, original.Nonce()))
+
+	// No recorded tx for another nonce or address
+	assert.Error(t, s.ResubmitNonce(ctx, 1, from, original.Nonce()+1))
+	assert.Error(t, s.ResubmitNonce(ctx, 1, common.HexToAddress("0x3333333333333333333333333333333333333333"), original.Nonce()))
+}
+
+func TestHasTrackedNonce(t *testing.T) {
+	s, _, _ := setupTestPayoutService(t)
+	ctx := context.Background()
+	from := common.HexToAddress(testPayoutJob().FromAddress)
+
+	result, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	require.True(t, result.Success, "%v", result.Error)
+	record, _ := recordedTx(t, s, "payout-1")
+
+	held, err := s.HasTrackedNonce(ctx, 1, from, record.Nonce)
+	require.NoError(t, err)
+	assert.True(t, held)
+
+	held, err = s.HasTrackedNonce(ctx, 1, from, record.Nonce+1)
+	require.NoError(t, err)
+	assert.False(t, held)
+
+	// Confirmed payouts no longer hold their nonce
+	require.NoError(t, s.txRecords.RemovePending(ctx, "payout-1"))
+	held, err = s.HasTrackedNonce(ctx, 1, from, record.Nonce)
+	require.NoError(t, err)
+	assert.False(t, held)
+}
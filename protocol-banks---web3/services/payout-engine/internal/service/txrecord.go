@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-redis/redis/v8"
+	"github.com/protocol-bank/payout-engine/internal/config"
+)
+
+const (
+	// txRecordTTL 支付 ID → 交易记录的保留时间，需覆盖队列重投递的最长窗口
+	txRecordTTL = 7 * 24 * time.Hour
+	// pendingTxKey 已广播、等待确认的支付 ID 集合
+	pendingTxKey = "payout:pending"
+)
+
+// PayoutClaim 支付的广播/取消裁决，先写入者胜出
+type PayoutClaim string
+
+const (
+	ClaimBroadcast PayoutClaim = "broadcast" // 即将广播，之后只能以替换交易取消
+	ClaimCancelled PayoutClaim = "cancelled" // 已取消，不得广播
+)
+
+// releaseBroadcastClaim 仅在裁决仍为 broadcast 时删除，避免误删取消标记
+var releaseBroadcastClaim = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// TxRecord 支付任务已签名交易的记录
+// 广播前写入，重投递时据此返回已有交易哈希并重新广播同一笔交易，而不是再签一笔新交易。
+type TxRecord struct {
+	JobID     string    `json:"job_id"`
+	BatchID   string    `json:"batch_id"`
+	ChainID   uint64    `json:"chain_id"`
+	From      string    `json:"from"`
+	Nonce     uint64    `json:"nonce"`
+	TxHash    string    `json:"tx_hash"`
+	RawTx     string    `json:"raw_tx"` // 0x 前缀的已签名交易
+	CreatedAt time.Time `json:"created_at"`
+
+	// 加价重发：同一 Nonce 的历史交易哈希都可能被打包
+	SubmittedAt time.Time `json:"submitted_at"`
+	Attempts    int       `json:"attempts"`
+	PrevHashes  []string  `json:"prev_hashes,omitempty"`
+
+	// 取消：已发送相同 Nonce 的 0 值自转账，这些哈希被打包表示支付已取消
+	Cancelled    bool     `json:"cancelled,omitempty"`
+	CancelHashes []string `json:"cancel_hashes,omitempty"`
+}
+
+// Hashes 当前及历史交易哈希
+func (r *TxRecord) Hashes() []string {
+	return append([]string{r.TxHash}, r.PrevHashes...)
+}
+
+// Transaction 解码当前已签名交易
+func (r *TxRecord) Transaction() (*types.Transaction, error) {
+	raw, err := hexutil.Decode(r.RawTx)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recorded transaction: %w", err)
+	}
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("invalid recorded transaction: %w", err)
+	}
+	return &tx, nil
+}
+
+// IsCancelTx 该哈希是否为取消用的替换交易
+func (r *TxRecord) IsCancelTx(hash string) bool {
+	for _, h := range r.CancelHashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// TxRecordStore 支付 ID → 交易记录存储
+type TxRecordStore interface {
+	// GetTxRecord 查询记录，不存在时返回 nil
+	GetTxRecord(ctx context.Context, jobID string) (*TxRecord, error)
+	// RecordTx 原子写入记录；已存在时返回 false 与已有记录
+	RecordTx(ctx context.Context, record *TxRecord) (bool, *TxRecord, error)
+	// UpdateTxRecord 覆盖已有记录（加价重发后更新当前交易）
+	UpdateTxRecord(ctx context.Context, record *TxRecord) error
+	// DeleteTxRecord 删除记录（交易被节点明确拒绝、从未广播时使用）
+	DeleteTxRecord(ctx context.Context, jobID string) error
+
+	// AddPending 标记支付已广播、等待确认
+	AddPending(ctx context.Context, jobID string) error
+	// ListPending 列出等待确认的支付 ID
+	ListPending(ctx context.Context) ([]string, error)
+	// RemovePending 支付已确认或放弃后移出等待集合
+	RemovePending(ctx context.Context, jobID string) error
+	// IsPending 支付是否仍在等待确认
+	IsPending(ctx context.Context, jobID string) (bool, error)
+
+	// ClaimPayout 原子写入广播/取消裁决，已存在时不覆盖；返回最终生效的裁决
+	ClaimPayout(ctx context.Context, jobID string, claim PayoutClaim) (PayoutClaim, error)
+	// GetPayoutClaim 读取已生效的裁决，尚未写入或已过期时返回空
+	GetPayoutClaim(ctx context.Context, jobID string) (PayoutClaim, error)
+	// ReleaseBroadcastClaim 交易被节点拒绝、从未广播时撤销 broadcast 裁决，取消标记保持不变
+	ReleaseBroadcastClaim(ctx context.Context, jobID string) error
+}
+
+// RedisTxRecordStore 基于 Redis 的交易记录存储
+type RedisTxRecordStore struct {
+	redis *redis.Client
+}
+
+// NewRedisTxRecordStore 创建交易记录存储
+func NewRedisTxRecordStore(ctx context.Context, cfg config.RedisConfig) (*RedisTxRecordStore, error) {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     cfg.URL,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("redis connection failed: %w", err)
+	}
+
+	return &RedisTxRecordStore{redis: rdb}, nil
+}
+
+// payoutClaimKey 支付广播/取消裁决的 Redis 键
+func payoutClaimKey(jobID string) string {
+	return fmt.Sprintf("payout:claim:%s", jobID)
+}
+
+// txRecordKey 支付 ID 对应的 Redis 键
+func txRecordKey(jobID string) string {
+	return fmt.Sprintf("payout:tx:%s", jobID)
+}
+
+// GetTxRecord 查询记录
+func (s *RedisTxRecordStore) GetTxRecord(ctx context.Context, jobID string) (*TxRecord, error) {
+	data, err := s.redis.Get(ctx, txRecordKey(jobID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx record: %w", err)
+	}
+
+	var record TxRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tx record: %w", err)
+	}
+	return &record, nil
+}
+
+// RecordTx 通过 SETNX 写入记录，保证同一支付 ID 只对应一笔交易
+func (s *RedisTxRecordStore) RecordTx(ctx context.Context, record *TxRecord) (bool, *TxRecord, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to marshal tx record: %w", err)
+	}
+
+	ok, err := s.redis.SetNX(ctx, txRecordKey(record.JobID), data, txRecordTTL).Result()
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to record tx: %w", err)
+	}
+	if ok {
+		return true, record, nil
+	}
+
+	existing, err := s.GetTxRecord(ctx, record.JobID)
+	if err != nil {
+		return false, nil, err
+	}
+	if existing == nil {
+		// 记录恰好过期，视为写入失败，由队列重试
+		return false, nil, fmt.Errorf("tx record for job %s disappeared", record.JobID)
+	}
+	return false, existing, nil
+}
+
+// UpdateTxRecord 覆盖已有记录，记录不存在时返回错误
+func (s *RedisTxRecordStore) UpdateTxRecord(ctx context.Context, record *TxRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tx record: %w", err)
+	}
+
+	ok, err := s.redis.SetXX(ctx, txRecordKey(record.JobID), data, txRecordTTL).Result()
+	if err != nil {
+		return fmt.Errorf("failed to update tx record: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("tx record for job %s not found", record.JobID)
+	}
+	return nil
+}
+
+// DeleteTxRecord 删除记录
+func (s *RedisTxRecordStore) DeleteTxRecord(ctx context.Context, jobID string) error {
+	return s.redis.Del(ctx, txRecordKey(jobID)).Err()
+}
+
+// AddPending 标记支付等待确认
+func (s *RedisTxRecordStore) AddPending(ctx context.Context, jobID string) error {
+	return s.redis.SAdd(ctx, pendingTxKey, jobID).Err()
+}
+
+// ListPending 列出等待确认的支付 ID
+func (s *RedisTxRecordStore) ListPending(ctx context.Context) ([]string, error) {
+	return s.redis.SMembers(ctx, pendingTxKey).Result()
+}
+
+// RemovePending 移出等待集合
+func (s *RedisTxRecordStore) RemovePending(ctx context.Context, jobID string) error {
+	return s.redis.SRem(ctx, pendingTxKey, jobID).Err()
+}
+
+// IsPending 支付是否仍在等待确认
+func (s *RedisTxRecordStore) IsPending(ctx context.Context, jobID string) (bool, error) {
+	return s.redis.SIsMember(ctx, pendingTxKey, jobID).Result()
+}
+
+// ClaimPayout 通过 SETNX 写入裁决，广播与取消并发时只有一方生效
+func (s *RedisTxRecordStore) ClaimPayout(ctx context.Context, jobID string, claim PayoutClaim) (PayoutClaim, error) {
+	key := payoutClaimKey(jobID)
+	ok, err := s.redis.SetNX(ctx, key, string(claim), txRecordTTL).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to claim payout: %w", err)
+	}
+	if ok {
+		return claim, nil
+	}
+
+	existing, err := s.redis.Get(ctx, key).Result()
+	if err == redis.Nil {
+		// 裁决恰好过期，由调用方重试
+		return "", fmt.Errorf("claim for payout %s disappeared", jobID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get payout claim: %w", err)
+	}
+	return PayoutClaim(existing), nil
+}
+
+// GetPayoutClaim 读取裁决
+func (s *RedisTxRecordStore) GetPayoutClaim(ctx context.Context, jobID string) (PayoutClaim, error) {
+	claim, err := s.redis.Get(ctx, payoutClaimKey(jobID)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get payout claim: %w", err)
+	}
+	return PayoutClaim(claim), nil
+}
+
+// ReleaseBroadcastClaim 撤销 broadcast 裁决
+func (s *RedisTxRecordStore) ReleaseBroadcastClaim(ctx context.Context, jobID string) error {
+	return releaseBroadcastClaim.Run(ctx, s.redis, []string{payoutClaimKey(jobID)}, string(ClaimBroadcast)).Err()
+}
+
+// Close 关闭连接
+func (s *RedisTxRecordStore) Close() error {
+	return s.redis.Close()
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/protocol-bank/payout-engine/internal/config"
+	"github.com/protocol-bank/payout-engine/internal/nonce"
+	"github.com/protocol-bank/payout-engine/internal/queue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rpcError 模拟节点返回的 JSON-RPC 错误
+type rpcError struct{ msg string }
+
+func (e *rpcError) Error() string  { return e.msg }
+func (e *rpcError) ErrorCode() int { return -32000 }
+
+// fakeChainClient 记录广播的交易，重复广播同一笔交易时返回 "already known"
+type fakeChainClient struct {
+	mu      sync.Mutex
+	sent    []*types.Transaction
+	known   map[common.Hash]bool
+	sendErr error // 非 nil 时下一次广播返回该错误
+
+	// 打包模拟：maxFee 不低于 mineFeeCap 的交易被打包进当前区块，nil 表示都不打包
+	mineFeeCap *big.Int
+	mined      map[common.Hash]*types.Receipt
+
+	// 区块模拟：head 为最新高度，fork 变化后 fork 之前打包的回执不再在主链上
+	head uint64
+	fork uint64
+
+	// 费用数据；feeHistory 为 nil 时模拟不支持 EIP-1559 的链
+	gasPrice   *big.Int
+	tipCap     *big.Int
+	feeHistory *ethereum.FeeHistory
+}
+
+func newFakeChainClient() *fakeChainClient {
+	return &fakeChainClient{
+		known:    make(map[common.Hash]bool),
+		mined:    make(map[common.Hash]*types.Receipt),
+		head:     1,
+		gasPrice: big.NewInt(1_000_000_000),
+		tipCap:   big.NewInt(100_000_000),
+		feeHistory: &ethereum.FeeHistory{
+			BaseFee: []*big.Int{big.NewInt(1_000_000_000)},
+		},
+	}
+}
+
+func (c *fakeChainClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return 0, nil
+}
+
+func (c *fakeChainClient) BlockNumber(ctx context.Context) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.head, nil
+}
+
+func (c *fakeChainClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if number.Uint64() > c.head {
+		return nil, ethereum.NotFound
+	}
+	return c.header(number.Uint64()), nil
+}
+
+// header 当前主链上的区块头，哈希随 fork 变化
+func (c *fakeChainClient) header(number uint64) *types.Header {
+	return &types.Header{Number: new(big.Int).SetUint64(number), Extra: big.NewInt(int64(c.fork)).Bytes()}
+}
+
+// advance 出 n 个新区块
+func (c *fakeChainClient) advance(n uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.head += n
+}
+
+// reorg 从 number 开始换成另一条链；stale 为 true 时节点仍返回旧回执，否则交易退回内存池
+func (c *fakeChainClient) reorg(number uint64, stale bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fork++
+	if stale {
+		return
+	}
+	for hash, receipt := range c.mined {
+		if receipt.BlockNumber.Uint64() >= number {
+			delete(c.mined, hash)
+		}
+	}
+}
+
+// mine 把交易打包进当前区块
+func (c *fakeChainClient) mine(hash common.Hash) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.include(hash)
+}
+
+// include 生成当前区块中的成功回执，调用方持有锁
+func (c *fakeChainClient) include(hash common.Hash) {
+	header := c.header(c.head)
+	c.mined[hash] = &types.Receipt{
+		Status:      types.ReceiptStatusSuccessful,
+		TxHash:      hash,
+		BlockNumber: header.Number,
+		BlockHash:   header.Hash(),
+	}
+}
+
+func (c *fakeChainClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return c.gasPrice, nil
+}
+
+func (c *fakeChainClient) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return c.tipCap, nil
+}
+
+func (c *fakeChainClient) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	if c.feeHistory == nil {
+		return nil, &rpcError{msg: "the method eth_feeHistory does not exist/is not available"}
+	}
+	return c.feeHistory, nil
+}
+
+func (c *fakeChainClient) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	return 21000, nil
+}
+
+func (c *fakeChainClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sendErr != nil {
+		err := c.sendErr
+		c.sendErr = nil
+		return err
+	}
+	c.sent = append(c.sent, tx)
+	if c.known[tx.Hash()] {
+		return &rpcError{msg: "already known"}
+	}
+	c.known[tx.Hash()] = true
+	if c.mineFeeCap != nil && tx.GasFeeCap().Cmp(c.mineFeeCap) >= 0 {
+		c.include(tx.Hash())
+	}
+	return nil
+}
+
+func (c *fakeChainClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	receipt, ok := c.mined[txHash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+func setupTestPayoutService(t *testing.T) (*PayoutService, *fakeChainClient, *nonce.Manager) {
+	ctx := context.Background()
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	redisCfg := config.RedisConfig{URL: mr.Addr()}
+
+	nonceManager, err := nonce.NewManager(ctx, redisCfg)
+	require.NoError(t, err)
+	txRecords, err := NewRedisTxRecordStore(ctx, redisCfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { txRecords.Close() })
+
+	queueConsumer, err := queue.NewRedisConsumer(ctx, redisCfg)
+	require.NoError(t, err)
+
+	client := newFakeChainClient()
+	nonceManager.AddChainClient(1, client)
+
+	parsedABI, err := abi.JSON(strings.NewReader(erc20ABI))
+	require.NoError(t, err)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	s := &PayoutService{
+		nonceManager: nonceManager,
+		queue:        queueConsumer,
+		txRecords:    txRecords,
+		clients:      map[uint64]ChainClient{1: client},
+		erc20ABI:     parsedABI,
+		sign: func(ctx context.Context, tx *types.Transaction, chainID uint64, from common.Address) (*types.Transaction, error) {
+			signer := types.LatestSignerForChainID(new(big.Int).SetUint64(chainID))
+			return types.SignTx(tx, signer, key)
+		},
+	}
+	return s, client, nonceManager
+}
+
+func testPayoutJob() *queue.Job {
+	return &queue.Job{
+		ID:          "payout-1",
+		BatchID:     "batch-1",
+		UserID:      "user-1",
+		FromAddress: "0x1111111111111111111111111111111111111111",
+		ToAddress:   "0x2222222222222222222222222222222222222222",
+		Amount:      "1000000000000000000",
+		ChainID:     1,
+		CreatedAt:   time.Now(),
+	}
+}
+
+func TestProcessJob_RedeliveryReturnsExistingTx(t *testing.T) {
+	s, client, nonceManager := setupTestPayoutService(t)
+	ctx := context.Background()
+
+	first, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	require.True(t, first.Success, "first delivery: %v", first.Error)
+
+	// Consumer crashed before acking: the same job is delivered again
+	second, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	require.True(t, second.Success, "redelivery: %v", second.Error)
+
+	assert.Equal(t, first.TxHash, second.TxHash)
+
+	// Only one transaction was ever signed; the redelivery rebroadcast it
+	require.Len(t, client.sent, 2)
+	assert.Equal(t, client.sent[0].Hash(), client.sent[1].Hash())
+
+	// Redelivery did not consume another nonce
+	from := common.HexToAddress(testPayoutJob().FromAddress)
+	next, release, err := nonceManager.GetNonce(ctx, 1, from)
+	require.NoError(t, err)
+	release()
+	assert.Equal(t, uint64(1), next)
+}
+
+func TestProcessJob_RejectedSendIsRebuiltOnRetry(t *testing.T) {
+	s, client, _ := setupTestPayoutService(t)
+	ctx := context.Background()
+
+	client.sendErr = &rpcError{msg: "insufficient funds for gas * price + value"}
+	first, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	assert.False(t, first.Success)
+
+	// The node rejected the transaction, so the record is dropped and a new one is built
+	record, err := s.txRecords.GetTxRecord(ctx, "payout-1")
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	second, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	require.True(t, second.Success, "retry: %v", second.Error)
+	require.Len(t, client.sent, 1)
+	assert.Equal(t, client.sent[0].Hash().Hex(), second.TxHash)
+}
+
+func TestProcessJob_NetworkErrorKeepsRecord(t *testing.T) {
+	s, client, _ := setupTestPayoutService(t)
+	ctx := context.Background()
+
+	client.sendErr = errors.New("context deadline exceeded")
+	first, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	assert.False(t, first.Success)
+
+	// The transaction may have reached the node: keep the record and rebroadcast it
+	record, err := s.txRecords.GetTxRecord(ctx, "payout-1")
+	require.NoError(t, err)
+	require.NotNil(t, record)
+
+	second, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	require.True(t, second.Success, "retry: %v", second.Error)
+	assert.Equal(t, record.TxHash, second.TxHash)
+	require.Len(t, client.sent, 1)
+	assert.Equal(t, record.TxHash, client.sent[0].Hash().Hex())
+}
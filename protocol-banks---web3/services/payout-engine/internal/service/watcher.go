@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/protocol-bank/payout-engine/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// WatchAction 一次检查的结果
+type WatchAction string
+
+const (
+	WatchActionConfirmed  WatchAction = "confirmed"  // 达到确认深度且执行成功
+	WatchActionReverted   WatchAction = "reverted"   // 达到确认深度但执行失败
+	WatchActionCancelled  WatchAction = "cancelled"  // 取消用的替换交易达到确认深度
+	WatchActionWaiting    WatchAction = "waiting"    // 未超时，继续等待
+	WatchActionConfirming WatchAction = "confirming" // 已打包，等待达到确认深度
+	WatchActionBumped     WatchAction = "bumped"     // 已加价重发
+	WatchActionFailed     WatchAction = "failed"     // 放弃跟踪并发出失败事件
+	WatchActionGone       WatchAction = "gone"       // 记录已过期
+)
+
+// TxWatcher 跟踪已广播的支付交易，超时未确认时以相同 Nonce 加价重发
+type TxWatcher struct {
+	service *PayoutService
+	events  EventPublisher
+	cfg     config.BumpConfig
+	now     func() time.Time
+}
+
+// NewTxWatcher 创建交易跟踪器
+func NewTxWatcher(s *PayoutService, events EventPublisher, cfg config.BumpConfig) *TxWatcher {
+	return &TxWatcher{
+		service: s,
+		events:  events,
+		cfg:     cfg,
+		now:     time.Now,
+	}
+}
+
+// Run 周期性检查待确认交易，直到 ctx 取消
+func (w *TxWatcher) Run(ctx context.Context) {
+	log.Info().
+		Dur("interval", w.cfg.Interval).
+		Dur("stuck_after", w.cfg.StuckAfter).
+		Int("max_attempts", w.cfg.MaxAttempts).
+		Msg("Starting payout tx watcher")
+
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.Check(ctx); err != nil {
+				log.Error().Err(err).Msg("Payout tx watch failed")
+			}
+		}
+	}
+}
+
+// Check 检查所有待确认交易一次
+func (w *TxWatcher) Check(ctx context.Context) error {
+	jobIDs, err := w.service.txRecords.ListPending(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list pending payouts: %w", err)
+	}
+
+	for _, jobID := range jobIDs {
+		if _, err := w.CheckPayout(ctx, jobID); err != nil {
+			log.Error().Err(err).Str("job_id", jobID).Msg("Failed to check pending payout")
+		}
+	}
+	return nil
+}
+
+// CheckPayout 检查单笔支付
+// 任一历史交易被打包并达到确认深度即视为完成；超过 StuckAfter 未确认时以相同 Nonce 加价重发，
+// 加价次数达到 MaxAttempts 或超过费用上限时放弃并发出失败事件。
+func (w *TxWatcher) CheckPayout(ctx context.Context, jobID string) (WatchAction, error) {
+	records := w.service.txRecords
+
+	record, err := records.GetTxRecord(ctx, jobID)
+	if err != nil {
+		return "", err
+	}
+	if record == nil {
+		return WatchActionGone, records.RemovePending(ctx, jobID)
+	}
+
+	client, ok := w.service.clients[record.ChainID]
+	if !ok {
+		return "", fmt.Errorf("unsupported chain: %d", record.ChainID)
+	}
+
+	// 检查当前及历史交易是否已打包
+	// 回执所在区块达到确认深度才视为最终；每次检查都核对区块仍在主链上，被重组出去的交易按未打包处理。
+	required := w.service.confirmations(record.ChainID)
+	for _, hash := range record.Hashes() {
+		receipt, err := client.TransactionReceipt(ctx, common.HexToHash(hash))
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to get receipt for %s: %w", hash, err)
+		}
+
+		confirmations, err := inclusionDepth(ctx, client, receipt)
+		if err != nil {
+			return "", fmt.Errorf("failed to check inclusion of %s: %w", hash, err)
+		}
+		if confirmations == 0 {
+			log.Warn().
+				Str("job_id", record.JobID).
+				Str("tx_hash", hash).
+				Str("block_hash", receipt.BlockHash.Hex()).
+				Msg("Payout tx receipt is not on the canonical chain, treating as unmined")
+			continue
+		}
+		if confirmations < required {
+			return WatchActionConfirming, nil
+		}
+
+		if record.IsCancelTx(hash) {
+			w.finish(ctx, record, hash, confirmations, PayoutEventCancelled, "replaced by a zero-value cancellation")
+			return WatchActionCancelled, nil
+		}
+		if receipt.Status == types.ReceiptStatusSuccessful {
+			w.finish(ctx, record, hash, confirmations, PayoutEventConfirmed, "")
+			return WatchActionConfirmed, nil
+		}
+		w.finish(ctx, record, hash, confirmations, PayoutEventReverted, "transaction reverted")
+		return WatchActionReverted, nil
+	}
+
+	if w.now().Sub(record.SubmittedAt) < w.cfg.StuckAfter {
+		return WatchActionWaiting, nil
+	}
+
+	if record.Attempts >= w.cfg.MaxAttempts {
+		w.finish(ctx, record, record.TxHash, 0, PayoutEventFailed,
+			fmt.Sprintf("not confirmed after %d fee bumps", record.Attempts))
+		return WatchActionFailed, nil
+	}
+
+	return w.bump(ctx, client, record)
+}
+
+// confirmations 链配置的确认深度，未配置时为 1
+func (s *PayoutService) confirmations(chainID uint64) uint64 {
+	if s.cfg != nil {
+		return max(s.cfg.Chains[chainID].Confirmations, 1)
+	}
+	return 1
+}
+
+// inclusionDepth 回执所在区块的确认数（含自身）
+// 该高度的主链区块哈希与回执不一致（已被重组）时返回 0。
+func inclusionDepth(ctx context.Context, client ChainClient, receipt *types.Receipt) (uint64, error) {
+	if receipt.BlockNumber == nil {
+		return 0, nil
+	}
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get block number: %w", err)
+	}
+	number := receipt.BlockNumber.Uint64()
+	if head < number {
+		// 负载均衡后的节点进度不一致，下一轮再检查
+		return 0, fmt.Errorf("chain head %d is behind receipt block %d", head, number)
+	}
+
+	header, err := client.HeaderByNumber(ctx, receipt.BlockNumber)
+	if errors.Is(err, ethereum.NotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get block %d: %w", number, err)
+	}
+	if header.Hash() != receipt.BlockHash {
+		return 0, nil
+	}
+	return head - number + 1, nil
+}
+
+// bump 以相同 Nonce、更高费用重新签名并广播
+func (w *TxWatcher) bump(ctx context.Context, client ChainClient, record *TxRecord) (WatchAction, error) {
+	prev, err := record.Transaction()
+	if err != nil {
+		return "", err
+	}
+
+	current, err := w.service.suggestFees(ctx, client, record.ChainID)
+	if err != nil {
+		return "", err
+	}
+	fees := bumpFees(prev, current, w.cfg.BumpPercent)
+
+	strategy := w.service.feeStrategy(record.ChainID)
+	if exceedsCap(fees.maxFee(), strategy.MaxFeePerGasGwei) {
+		w.finish(ctx, record, record.TxHash, 0, PayoutEventFailed, "fee cap reached while bumping")
+		return WatchActionFailed, nil
+	}
+
+	tx := newTx(record.ChainID, prev.Nonce(), fees, prev.Gas(), prev.To(), prev.Value(), prev.Data())
+	bumped, err := w.service.replaceTx(ctx, client, record, tx, func(r *TxRecord) {
+		r.Attempts = record.Attempts + 1
+		r.SubmittedAt = w.now()
+	})
+	if errors.Is(err, errNonceConsumed) {
+		// 该 Nonce 已被打包，而本轮检查时跟踪的哈希都没有回执：再查一次，排除刚刚打包的情况，
+		// 仍没有回执说明 Nonce 被其他交易占用，支付不会再上链
+		included, err := w.anyIncluded(ctx, client, record)
+		if err != nil {
+			return "", err
+		}
+		if included {
+			return WatchActionConfirming, nil
+		}
+		w.finish(ctx, record, record.TxHash, 0, PayoutEventFailed, "nonce consumed by another transaction")
+		return WatchActionFailed, nil
+	}
+	if errors.Is(err, errNonceUsed) {
+		// 节点已知该交易，等待回执
+		return WatchActionWaiting, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	log.Info().
+		Str("job_id", record.JobID).
+		Str("prev_tx_hash", record.TxHash).
+		Str("tx_hash", bumped.TxHash).
+		Uint64("nonce", prev.Nonce()).
+		Int("attempt", bumped.Attempts).
+		Msg("Bumped stuck payout transaction")
+	w.publish(ctx, bumped, bumped.TxHash, 0, PayoutEventBumped, "")
+	return WatchActionBumped, nil
+}
+
+// anyIncluded 当前及历史交易是否有任一已有回执
+func (w *TxWatcher) anyIncluded(ctx context.Context, client ChainClient, record *TxRecord) (bool, error) {
+	for _, hash := range record.Hashes() {
+		_, err := client.TransactionReceipt(ctx, common.HexToHash(hash))
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to get receipt for %s: %w", hash, err)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+var (
+	// errNonceUsed 替换交易被拒绝：该 Nonce 已被打包或节点已知
+	errNonceUsed = errors.New("nonce already used")
+	// errNonceConsumed 节点返回 nonce too low：该 Nonce 已被打包
+	errNonceConsumed = fmt.Errorf("%w: nonce too low", errNonceUsed)
+)
+
+// replaceTx 签名并以相同 Nonce 广播替换交易，返回更新后的记录
+// 先记录再广播，广播结果不确定时新哈希也在跟踪范围内；节点拒绝时恢复原记录。
+// edit 在写入前调整记录；已取消的记录的后续替换交易仍是取消交易。
+func (s *PayoutService) replaceTx(ctx context.Context, client ChainClient, record *TxRecord, tx *types.Transaction, edit func(*TxRecord)) (*TxRecord, error) {
+	signedTx, err := s.sign(ctx, tx, record.ChainID, common.HexToAddress(record.From))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign replacement transaction: %w", err)
+	}
+	encoded, err := signedTx.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode replacement transaction: %w", err)
+	}
+
+	replaced := *record
+	replaced.PrevHashes = append(append([]string{}, record.PrevHashes...), record.TxHash)
+	replaced.TxHash = signedTx.Hash().Hex()
+	replaced.RawTx = hexutil.Encode(encoded)
+	replaced.SubmittedAt = time.Now()
+	edit(&replaced)
+	if replaced.Cancelled {
+		replaced.CancelHashes = append(append([]string{}, record.CancelHashes...), replaced.TxHash)
+	}
+	if err := s.txRecords.UpdateTxRecord(ctx, &replaced); err != nil {
+		return nil, err
+	}
+
+	if err := client.SendTransaction(ctx, signedTx); err != nil {
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) || isAlreadyBroadcast(err) {
+			// 节点拒绝了替换交易，恢复原记录，下一轮再检查
+			if restoreErr := s.txRecords.UpdateTxRecord(ctx, record); restoreErr != nil {
+				log.Error().Err(restoreErr).Str("job_id", record.JobID).Msg("Failed to restore tx record")
+			}
+		}
+		if isNonceTooLow(err) {
+			return nil, errNonceConsumed
+		}
+		if isAlreadyBroadcast(err) {
+			return nil, errNonceUsed
+		}
+		return nil, fmt.Errorf("failed to send replacement transaction: %w", err)
+	}
+	return &replaced, nil
+}
+
+// finish 停止跟踪并发出事件
+func (w *TxWatcher) finish(ctx context.Context, record *TxRecord, txHash string, confirmations uint64, eventType PayoutEventType, reason string) {
+	if err := w.service.txRecords.RemovePending(ctx, record.JobID); err != nil {
+		log.Error().Err(err).Str("job_id", record.JobID).Msg("Failed to remove pending payout")
+	}
+	if eventType == PayoutEventFailed {
+		log.Error().
+			Str("job_id", record.JobID).
+			Str("tx_hash", txHash).
+			Int("attempts", record.Attempts).
+			Str("reason", reason).
+			Msg("Giving up on stuck payout transaction")
+	}
+	w.publish(ctx, record, txHash, confirmations, eventType, reason)
+}
+
+// publish 发布支付事件，失败只记录日志
+func (w *TxWatcher) publish(ctx context.Context, record *TxRecord, txHash string, confirmations uint64, eventType PayoutEventType, reason string) {
+	publishEvent(ctx, w.events, &PayoutEvent{
+		Type:          eventType,
+		JobID:         record.JobID,
+		BatchID:       record.BatchID,
+		ChainID:       record.ChainID,
+		TxHash:        txHash,
+		Confirmations: confirmations,
+		Attempts:      record.Attempts,
+		Error:         reason,
+		Timestamp:     w.now(),
+	})
+}
+
+// bumpFees 计算替换交易的费用：不低于原费用加价 percent%，也不低于当前建议费用
+// 交易类型与原交易保持一致。
+func bumpFees(prev *types.Transaction, current *TxFees, percent int) *TxFees {
+	if prev.Type() == types.LegacyTxType {
+		return &TxFees{GasPrice: maxWei(bumpWei(prev.GasPrice(), percent), current.maxFee())}
+	}
+
+	tip := maxWei(bumpWei(prev.GasTipCap(), percent), current.tip())
+	feeCap := maxWei(bumpWei(prev.GasFeeCap(), percent), current.maxFee())
+	if tip.Cmp(feeCap) > 0 {
+		feeCap = new(big.Int).Set(tip)
+	}
+	return &TxFees{GasTipCap: tip, GasFeeCap: feeCap}
+}
+
+// bumpWei 加价 percent%，向上取整
+func bumpWei(x *big.Int, percent int) *big.Int {
+	result := new(big.Int).Mul(x, big.NewInt(int64(100+percent)))
+	result.Add(result, big.NewInt(99))
+	return result.Div(result, big.NewInt(100))
+}
+
+func maxWei(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+// exceedsCap 是否超过 gwei 上限，上限为 0 时不限制
+func exceedsCap(x *big.Int, capGwei float64) bool {
+	return capGwei > 0 && x.Cmp(capWei(x, capGwei)) > 0
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/protocol-bank/payout-engine/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingPublisher 记录发布的事件
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []*PayoutEvent
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event *PayoutEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *recordingPublisher) types() []PayoutEventType {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var result []PayoutEventType
+	for _, event := range p.events {
+		result = append(result, event.Type)
+	}
+	return result
+}
+
+func setupTestWatcher(t *testing.T, maxAttempts int) (*TxWatcher, *PayoutService, *fakeChainClient, *recordingPublisher, *time.Time) {
+	s, client, _ := setupTestPayoutService(t)
+	events := &recordingPublisher{}
+	w := NewTxWatcher(s, events, config.BumpConfig{
+		Interval:    time.Second,
+		StuckAfter:  time.Minute,
+		MaxAttempts: maxAttempts,
+		BumpPercent: 10,
+	})
+	now := time.Now()
+	w.now = func() time.Time { return now }
+	return w, s, client, events, &now
+}
+
+func recordedTx(t *testing.T, s *PayoutService, jobID string) (*TxRecord, *types.Transaction) {
+	t.Helper()
+	record, err := s.txRecords.GetTxRecord(context.Background(), jobID)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	raw, err := hexutil.Decode(record.RawTx)
+	require.NoError(t, err)
+	var tx types.Transaction
+	require.NoError(t, tx.UnmarshalBinary(raw))
+	return record, &tx
+}
+
+func TestTxWatcher_BumpsUntilConfirmed(t *testing.T) {
+	w, s, client, events, now := setupTestWatcher(t, 3)
+	ctx := context.Background()
+
+	// The initial fee (2.12 gwei) is underpriced; only a bumped tx gets mined
+	client.mineFeeCap = big.NewInt(2_300_000_000)
+
+	result, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	require.True(t, result.Success, "%v", result.Error)
+	_, original := recordedTx(t, s, "payout-1")
+
+	action, err := w.CheckPayout(ctx, "payout-1")
+	require.NoError(t, err)
+	assert.Equal(t, WatchActionWaiting, action)
+
+	*now = now.Add(2 * time.Minute)
+	action, err = w.CheckPayout(ctx, "payout-1")
+	require.NoError(t, err)
+	assert.Equal(t, WatchActionBumped, action)
+
+	record, bumped := recordedTx(t, s, "payout-1")
+	assert.Equal(t, original.Nonce(), bumped.Nonce())
+	assert.NotEqual(t, original.Hash(), bumped.Hash())
+	assert.Equal(t, []string{original.Hash().Hex()}, record.PrevHashes)
+	assert.Equal(t, 1, record.Attempts)
+	assert.GreaterOrEqual(t, bumped.GasFeeCap().Cmp(bumpWei(original.GasFeeCap(), 10)), 0)
+	assert.GreaterOrEqual(t, bumped.GasTipCap().Cmp(bumpWei(original.GasTipCap(), 10)), 0)
+
+	action, err = w.CheckPayout(ctx, "payout-1")
+	require.NoError(t, err)
+	assert.Equal(t, WatchActionConfirmed, action)
+
+	assert.Equal(t, []PayoutEventType{PayoutEventBumped, PayoutEventConfirmed}, events.types())
+	pending, err := s.txRecords.ListPending(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestTxWatcher_GivesUpAfterMaxAttempts(t *testing.T) {
+	w, s, _, events, now := setupTestWatcher(t, 2)
+	ctx := context.Background()
+
+	result, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	require.True(t, result.Success, "%v", result.Error)
+
+	var prevFeeCap *big.Int
+	for i := 0; i < 2; i++ {
+		_, tx := recordedTx(t, s, "payout-1")
+		prevFeeCap = tx.GasFeeCap()
+
+		*now = now.Add(2 * time.Minute)
+		action, err := w.CheckPayout(ctx, "payout-1")
+		require.NoError(t, err)
+		require.Equal(t, WatchActionBumped, action)
+
+		_, bumped := recordedTx(t, s, "payout-1")
+		assert.GreaterOrEqual(t, bumped.GasFeeCap().Cmp(bumpWei(prevFeeCap, 10)), 0)
+	}
+
+	*now = now.Add(2 * time.Minute)
+	action, err := w.CheckPayout(ctx, "payout-1")
+	require.NoError(t, err)
+	assert.Equal(t, WatchActionFailed, action)
+
+	assert.Equal(t, []PayoutEventType{PayoutEventBumped, PayoutEventBumped, PayoutEventFailed}, events.types())
+	assert.Equal(t, 2, events.events[2].Attempts)
+	assert.NotEmpty(t, events.events[2].Error)
+
+	pending, err := s.txRecords.ListPending(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestTxWatcher_StopsAtFeeCap(t *testing.T) {
+	w, s, _, events, now := setupTestWatcher(t, 5)
+	ctx := context.Background()
+
+	result, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	require.True(t, result.Success, "%v", result.Error)
+
+	// The first transaction is just under the cap, so no bump is possible
+	strategy := config.DefaultFeeStrategy()
+	strategy.MaxFeePerGasGwei = 2.2
+	s.cfg = &config.Config{Chains: map[uint64]config.ChainConfig{1: {ChainID: 1, Fee: strategy}}}
+
+	*now = now.Add(2 * time.Minute)
+	action, err := w.CheckPayout(ctx, "payout-1")
+	require.NoError(t, err)
+	assert.Equal(t, WatchActionFailed, action)
+	assert.Equal(t, []PayoutEventType{PayoutEventFailed}, events.types())
+}
+
+func TestTxWatcher_FailsWhenNonceConsumedElsewhere(t *testing.T) {
+	w, s, client, events, now := setupTestWatcher(t, 5)
+	ctx := context.Background()
+
+	result, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	require.True(t, result.Success, "%v", result.Error)
+
+	// Another transaction from the same address was mined with this nonce
+	client.sendErr = &rpcError{msg: "nonce too low"}
+	*now = now.Add(2 * time.Minute)
+	action, err := w.CheckPayout(ctx, "payout-1")
+	require.NoError(t, err)
+	assert.Equal(t, WatchActionFailed, action)
+
+	assert.Equal(t, []PayoutEventType{PayoutEventFailed}, events.types())
+	assert.Equal(t, "nonce consumed by another transaction", events.events[0].Error)
+	pending, err := s.txRecords.ListPending(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestTxWatcher_WaitsWhenReplacementAlreadyKnown(t *testing.T) {
+	w, s, client, events, now := setupTestWatcher(t, 5)
+	ctx := context.Background()
+
+	result, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	require.True(t, result.Success, "%v", result.Error)
+
+	client.sendErr = &rpcError{msg: "already known"}
+	*now = now.Add(2 * time.Minute)
+	action, err := w.CheckPayout(ctx, "payout-1")
+	require.NoError(t, err)
+	assert.Equal(t, WatchActionWaiting, action)
+	assert.Empty(t, events.types())
+}
+
+// withConfirmations 要求链 1 的交易达到 depth 个确认
+func withConfirmations(s *PayoutService, depth uint64) {
+	s.cfg = &config.Config{Chains: map[uint64]config.ChainConfig{1: {ChainID: 1, Confirmations: depth}}}
+}
+
+func TestTxWatcher_WaitsForConfirmationDepth(t *testing.T) {
+	w, s, client, events, _ := setupTestWatcher(t, 3)
+	withConfirmations(s, 3)
+	ctx := context.Background()
+
+	client.mineFeeCap = big.NewInt(0)
+	result, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	require.True(t, result.Success, "%v", result.Error)
+
+	for i := 0; i < 2; i++ {
+		action, err := w.CheckPayout(ctx, "payout-1")
+		require.NoError(t, err)
+		assert.Equal(t, WatchActionConfirming, action, "depth %d", i+1)
+		client.advance(1)
+	}
+
+	action, err := w.CheckPayout(ctx, "payout-1")
+	require.NoError(t, err)
+	assert.Equal(t, WatchActionConfirmed, action)
+	require.Equal(t, []PayoutEventType{PayoutEventConfirmed}, events.types())
+	assert.Equal(t, uint64(3), events.events[0].Confirmations)
+	assert.Equal(t, result.TxHash, events.events[0].TxHash)
+}
+
+func TestTxWatcher_ReorgedOutTxIsTrackedAgain(t *testing.T) {
+	w, s, client, events, _ := setupTestWatcher(t, 3)
+	withConfirmations(s, 3)
+	ctx := context.Background()
+
+	client.mineFeeCap = big.NewInt(0)
+	result, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	require.True(t, result.Success, "%v", result.Error)
+
+	client.advance(1)
+	action, err := w.CheckPayout(ctx, "payout-1")
+	require.NoError(t, err)
+	assert.Equal(t, WatchActionConfirming, action)
+
+	// The block is reorged out and the tx returns to the mempool
+	client.reorg(1, false)
+	action, err = w.CheckPayout(ctx, "payout-1")
+	require.NoError(t, err)
+	assert.Equal(t, WatchActionWaiting, action)
+
+	// It is mined again on the new chain and counts depth from its new block
+	client.mine(common.HexToHash(result.TxHash))
+	client.advance(1)
+	action, err = w.CheckPayout(ctx, "payout-1")
+	require.NoError(t, err)
+	assert.Equal(t, WatchActionConfirming, action)
+
+	client.advance(1)
+	action, err = w.CheckPayout(ctx, "payout-1")
+	require.NoError(t, err)
+	assert.Equal(t, WatchActionConfirmed, action)
+	require.Equal(t, []PayoutEventType{PayoutEventConfirmed}, events.types())
+	assert.Equal(t, uint64(3), events.events[0].Confirmations)
+}
+
+func TestTxWatcher_StaleReceiptIsNotFinal(t *testing.T) {
+	w, s, client, events, _ := setupTestWatcher(t, 3)
+	withConfirmations(s, 2)
+	ctx := context.Background()
+
+	client.mineFeeCap = big.NewInt(0)
+	result, err := s.ProcessJob(ctx, testPayoutJob())
+	require.NoError(t, err)
+	require.True(t, result.Success, "%v", result.Error)
+
+	// The node still serves the receipt from the orphaned block
+	client.reorg(1, true)
+	client.advance(5)
+	action, err := w.CheckPayout(ctx, "payout-1")
+	require.NoError(t, err)
+	assert.Equal(t, WatchActionWaiting, action)
+	assert.Empty(t, events.types())
+
+	pending, err := s.txRecords.ListPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"payout-1"}, pending)
+}
+
+func TestBumpFees(t *testing.T) {
+	dynamic := types.NewTx(&types.DynamicFeeTx{GasTipCap: gwei(1), GasFeeCap: gwei(20)})
+
+	// Current market below the previous fee: bump by 10%
+	fees := bumpFees(dynamic, &TxFees{GasTipCap: gwei(1), GasFeeCap: gwei(10)}, 10)
+	assert.Equal(t, big.NewInt(1_100_000_000), fees.GasTipCap)
+	assert.Equal(t, gwei(22), fees.GasFeeCap)
+
+	// Current market above the bump: follow the market
+	fees = bumpFees(dynamic, &TxFees{GasTipCap: gwei(2), GasFeeCap: gwei(30)}, 10)
+	assert.Equal(t, gwei(2), fees.GasTipCap)
+	assert.Equal(t, gwei(30), fees.GasFeeCap)
+
+	// Legacy transactions stay legacy
+	legacy := types.NewTx(&types.LegacyTx{GasPrice: gwei(10)})
+	fees = bumpFees(legacy, &TxFees{GasPrice: gwei(5)}, 10)
+	require.False(t, fees.Dynamic())
+	assert.Equal(t, gwei(11), fees.GasPrice)
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/protocol-bank/payout-engine/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// 回调请求头
+const (
+	WebhookSignatureHeader = "X-Payout-Signature" // sha256=<hex(HMAC-SHA256(secret, timestamp + "." + body))>
+	WebhookTimestampHeader = "X-Payout-Timestamp" // 签名时的 Unix 秒，接收方应拒绝过旧的请求以防重放
+	WebhookEventHeader     = "X-Payout-Event"     // 事件类型
+	WebhookDeliveryHeader  = "X-Payout-Delivery"  // 投递 ID，重试时不变，接收方可据此去重
+)
+
+// webhookQueueSize 待投递事件缓冲
+const webhookQueueSize = 256
+
+// errWebhookStopped 通知器停止时仍未投递的事件写入死信的原因
+var errWebhookStopped = errors.New("webhook notifier stopped before delivery")
+
+// SignWebhook 计算回调签名：HMAC-SHA256(secret, timestamp + "." + body)
+// 时间戳参与签名，接收方校验签名后还应检查时间戳是否在允许窗口内。
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookDeadLetterFunc 事件最终投递失败时的回调
+type WebhookDeadLetterFunc func(ctx context.Context, event *PayoutEvent, err error)
+
+// webhookError 一次投递的失败，retry 表示是否值得重试
+type webhookError struct {
+	err   error
+	retry bool
+}
+
+func (e *webhookError) Error() string { return e.err.Error() }
+func (e *webhookError) Unwrap() error { return e.err }
+
+// WebhookNotifier 把支付终态以签名的 HTTP POST 通知外部系统
+// Publish 只入队不阻塞发布方；Run 以 cfg.Workers 个协程并行投递，网络错误、429 与 5xx 按指数退避重试，
+// 其他 4xx 或重试用尽时写入死信。停止时未投递完的事件同样写入死信，可从死信重放。
+type WebhookNotifier struct {
+	cfg        config.WebhookConfig
+	client     *http.Client
+	queue      chan *PayoutEvent
+	deadLetter WebhookDeadLetterFunc
+	now        func() time.Time
+	sleep      func(ctx context.Context, d time.Duration) error
+	done       chan struct{} // Run 返回时关闭
+}
+
+// NewWebhookNotifier 创建回调通知器
+func NewWebhookNotifier(cfg config.WebhookConfig) *WebhookNotifier {
+	return &WebhookNotifier{
+		cfg:        cfg,
+		client:     &http.Client{Timeout: cfg.Timeout},
+		queue:      make(chan *PayoutEvent, webhookQueueSize),
+		deadLetter: logWebhookDeadLetter,
+		now:        time.Now,
+		sleep:      sleepContext,
+		done:       make(chan struct{}),
+	}
+}
+
+// SetDeadLetterHandler 设置死信回调，默认只记录日志
+// 停止时 ctx 已取消，回调收到的 ctx 不随之取消，死信仍能写入
+func (n *WebhookNotifier) SetDeadLetterHandler(fn WebhookDeadLetterFunc) {
+	n.deadLetter = func(ctx context.Context, event *PayoutEvent, err error) {
+		logWebhookDeadLetter(ctx, event, err)
+		fn(context.WithoutCancel(ctx), event, err)
+	}
+}
+
+// Publish 终态事件入队，其他事件忽略；队列已满或已停止时直接写入死信
+func (n *WebhookNotifier) Publish(ctx context.Context, event *PayoutEvent) error {
+	if !event.Type.Terminal() {
+		return nil
+	}
+	select {
+	case <-n.done:
+		n.deadLetter(ctx, event, errWebhookStopped)
+		return nil
+	default:
+	}
+	select {
+	case n.queue <- event:
+	default:
+		n.deadLetter(ctx, event, fmt.Errorf("webhook queue full"))
+	}
+	return nil
+}
+
+// Run 并行投递队列中的事件，直到 ctx 取消；取消后等待进行中的投递结束（中断的写入死信），
+// 再把队列中剩余的事件写入死信后返回
+func (n *WebhookNotifier) Run(ctx context.Context) {
+	defer close(n.done)
+	workers := max(n.cfg.Workers, 1)
+	log.Info().Str("url", n.cfg.URL).Int("max_attempts", n.cfg.MaxAttempts).Int("workers", workers).Msg("Starting payout webhook notifier")
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case event := <-n.queue:
+					n.Deliver(ctx, event)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for {
+		select {
+		case event := <-n.queue:
+			n.deadLetter(ctx, event, errWebhookStopped)
+		default:
+			return
+		}
+	}
+}
+
+// Done Run 返回（队列已清空）时关闭
+func (n *WebhookNotifier) Done() <-chan struct{} {
+	return n.done
+}
+
+// Deliver 投递单个事件，失败时按退避重试，最终失败写入死信
+func (n *WebhookNotifier) Deliver(ctx context.Context, event *PayoutEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		err = fmt.Errorf("failed to marshal payout event: %w", err)
+		n.deadLetter(ctx, event, err)
+		return err
+	}
+
+	delay := n.cfg.RetryDelay
+	for attempt := 1; ; attempt++ {
+		err := n.post(ctx, event, body)
+		if err == nil {
+			log.Info().Str("job_id", event.JobID).Str("type", string(event.Type)).Int("attempt", attempt).Msg("Delivered payout webhook")
+			return nil
+		}
+
+		var whErr *webhookError
+		retry := ctx.Err() == nil && errors.As(err, &whErr) && whErr.retry
+		if !retry || attempt >= n.cfg.MaxAttempts {
+			err = fmt.Errorf("webhook delivery failed after %d attempts: %w", attempt, err)
+			n.deadLetter(ctx, event, err)
+			return err
+		}
+
+		log.Warn().Err(err).
+			Str("job_id", event.JobID).
+			Int("attempt", attempt).
+			Dur("retry_in", delay).
+			Msg("Payout webhook delivery failed, retrying")
+		if err := n.sleep(ctx, delay); err != nil {
+			n.deadLetter(ctx, event, err)
+			return err
+		}
+		delay = min(delay*2, n.cfg.MaxRetryDelay)
+	}
+}
+
+// post 签名并发送一次，每次重试使用新的时间戳
+func (n *WebhookNotifier) post(ctx context.Context, event *PayoutEvent, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return &webhookError{err: err}
+	}
+	timestamp := n.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(n.cfg.Secret, timestamp, body))
+	req.Header.Set(WebhookEventHeader, string(event.Type))
+	req.Header.Set(WebhookDeliveryHeader, event.JobID+":"+string(event.Type))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return &webhookError{err: err, retry: true}
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	return &webhookError{
+		err:   fmt.Errorf("webhook endpoint returned %d", resp.StatusCode),
+		retry: resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500,
+	}
+}
+
+// logWebhookDeadLetter 死信日志，包含重新投递所需的全部字段
+func logWebhookDeadLetter(ctx context.Context, event *PayoutEvent, err error) {
+	log.Error().Err(err).
+		Str("job_id", event.JobID).
+		Str("batch_id", event.BatchID).
+		Str("type", string(event.Type)).
+		Uint64("chain_id", event.ChainID).
+		Str("tx_hash", event.TxHash).
+		Msg("Payout webhook dead-lettered")
+}
+
+// sleepContext 等待 d 或 ctx 取消
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
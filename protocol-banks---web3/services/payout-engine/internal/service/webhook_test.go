@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/protocol-bank/payout-engine/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWebhookSecret = "whsec_test"
+
+// webhookRequest 回调端收到的请求
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// fakeWebhookEndpoint 依次返回 statuses 中的状态码，用完后返回 200
+type fakeWebhookEndpoint struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []webhookRequest
+}
+
+func (e *fakeWebhookEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.requests = append(e.requests, webhookRequest{header: r.Header.Clone(), body: body})
+	status := http.StatusOK
+	if len(e.statuses) > 0 {
+		status, e.statuses = e.statuses[0], e.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (e *fakeWebhookEndpoint) received() []webhookRequest {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]webhookRequest(nil), e.requests...)
+}
+
+// deadLetters 记录写入死信的事件及写入时 ctx 的状态
+type deadLetters struct {
+	mu      sync.Mutex
+	events  []*PayoutEvent
+	errs    []error
+	ctxErrs []error
+}
+
+func (d *deadLetters) record(ctx context.Context, event *PayoutEvent, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.events = append(d.events, event)
+	d.errs = append(d.errs, err)
+	d.ctxErrs = append(d.ctxErrs, ctx.Err())
+}
+
+func (d *deadLetters) jobIDs() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var ids []string
+	for _, event := range d.events {
+		ids = append(ids, event.JobID)
+	}
+	return ids
+}
+
+func setupTestNotifier(t *testing.T, endpoint *fakeWebhookEndpoint) (*WebhookNotifier, *deadLetters, *[]time.Duration) {
+	server := httptest.NewServer(endpoint)
+	t.Cleanup(server.Close)
+
+	n := NewWebhookNotifier(config.WebhookConfig{
+		URL:           server.URL,
+		Secret:        testWebhookSecret,
+		MaxAttempts:   3,
+		RetryDelay:    time.Second,
+		MaxRetryDelay: 90 * time.Second,
+		Timeout:       time.Second,
+	})
+	dead := &deadLetters{}
+	n.SetDeadLetterHandler(dead.record)
+
+	var sleeps []time.Duration
+	n.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	return n, dead, &sleeps
+}
+
+func confirmedEvent() *PayoutEvent {
+	return &PayoutEvent{
+		Type:          PayoutEventConfirmed,
+		JobID:         "payout-1",
+		BatchID:       "batch-1",
+		ChainID:       8453,
+		TxHash:        "0xabc",
+		Confirmations: 2,
+		Attempts:      1,
+		Timestamp:     time.Unix(1_700_000_000, 0).UTC(),
+	}
+}
+
+func TestWebhookNotifier_SignatureMatchesBody(t *testing.T) {
+	endpoint := &fakeWebhookEndpoint{}
+	n, dead, _ := setupTestNotifier(t, endpoint)
+	n.now = func() time.Time { return time.Unix(1_700_000_123, 0) }
+
+	require.NoError(t, n.Deliver(context.Background(), confirmedEvent()))
+
+	requests := endpoint.received()
+	require.Len(t, requests, 1)
+	req := requests[0]
+
+	// 接收方的校验方式：用时间戳与原始请求体重新计算签名
+	timestamp, err := strconv.ParseInt(req.header.Get(WebhookTimestampHeader), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1_700_000_123), timestamp)
+	signature := req.header.Get(WebhookSignatureHeader)
+	assert.True(t, hmac.Equal([]byte(signature), []byte(SignWebhook(testWebhookSecret, timestamp, req.body))))
+
+	// 篡改请求体、时间戳或使用其他密钥都无法通过校验
+	assert.NotEqual(t, signature, SignWebhook(testWebhookSecret, timestamp, append(req.body, ' ')))
+	assert.NotEqual(t, signature, SignWebhook(testWebhookSecret, timestamp+1, req.body))
+	assert.NotEqual(t, signature, SignWebhook("other", timestamp, req.body))
+
+	var got PayoutEvent
+	require.NoError(t, json.Unmarshal(req.body, &got))
+	assert.Equal(t, *confirmedEvent(), got)
+	assert.Equal(t, "payout.confirmed", req.header.Get(WebhookEventHeader))
+	assert.Equal(t, "payout-1:payout.confirmed", req.header.Get(WebhookDeliveryHeader))
+	assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+	assert.Empty(t, dead.events)
+}
+
+func TestWebhookNotifier_RetriesWithBackoff(t *testing.T) {
+	endpoint := &fakeWebhookEndpoint{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	n, dead, sleeps := setupTestNotifier(t, endpoint)
+	now := time.Unix(1_700_000_000, 0)
+	n.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	require.NoError(t, n.Deliver(context.Background(), confirmedEvent()))
+
+	requests := endpoint.received()
+	require.Len(t, requests, 3)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, *sleeps)
+	assert.Empty(t, dead.events)
+
+	// 每次重试重新签名，投递 ID 不变
+	assert.NotEqual(t, requests[0].header.Get(WebhookTimestampHeader), requests[2].header.Get(WebhookTimestampHeader))
+	assert.Equal(t, requests[0].header.Get(WebhookDeliveryHeader), requests[2].header.Get(WebhookDeliveryHeader))
+}
+
+func TestWebhookNotifier_DeadLetters(t *testing.T) {
+	// 重试用尽
+	endpoint := &fakeWebhookEndpoint{statuses: []int{500, 502, 503, 504}}
+	n, dead, _ := setupTestNotifier(t, endpoint)
+	require.Error(t, n.Deliver(context.Background(), confirmedEvent()))
+	assert.Len(t, endpoint.received(), 3)
+	require.Len(t, dead.events, 1)
+	assert.Equal(t, "payout-1", dead.events[0].JobID)
+
+	// 4xx 不重试
+	endpoint = &fakeWebhookEndpoint{statuses: []int{http.StatusBadRequest}}
+	n, dead, _ = setupTestNotifier(t, endpoint)
+	require.Error(t, n.Deliver(context.Background(), confirmedEvent()))
+	assert.Len(t, endpoint.received(), 1)
+	assert.Len(t, dead.events, 1)
+}
+
+func TestWebhookNotifier_PublishesOnlyTerminalEvents(t *testing.T) {
+	n := NewWebhookNotifier(config.WebhookConfig{})
+	ctx := context.Background()
+
+	for _, eventType := range []PayoutEventType{PayoutEventQueued, PayoutEventBroadcast, PayoutEventBumped} {
+		require.NoError(t, n.Publish(ctx, &PayoutEvent{Type: eventType, JobID: "payout-1"}))
+	}
+	for _, eventType := range []PayoutEventType{PayoutEventConfirmed, PayoutEventReverted, PayoutEventFailed} {
+		require.NoError(t, n.Publish(ctx, &PayoutEvent{Type: eventType, JobID: "payout-1"}))
+	}
+	assert.Len(t, n.queue, 3)
+}
+
+// blockingWebhookEndpoint 对 slowJob 的请求挂起直到 release 关闭，其他请求立即返回 200
+type blockingWebhookEndpoint struct {
+	slowJob   string
+	release   chan struct{}
+	mu        sync.Mutex
+	delivered []string
+}
+
+func (e *blockingWebhookEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var event PayoutEvent
+	json.NewDecoder(r.Body).Decode(&event)
+	if event.JobID == e.slowJob {
+		select {
+		case <-e.release:
+		case <-r.Context().Done():
+			return
+		}
+	}
+	e.mu.Lock()
+	e.delivered = append(e.delivered, event.JobID)
+	e.mu.Unlock()
+}
+
+func (e *blockingWebhookEndpoint) deliveredJobs() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.delivered...)
+}
+
+func setupBlockingNotifier(t *testing.T, slowJob string, workers int) (*WebhookNotifier, *blockingWebhookEndpoint, *deadLetters) {
+	endpoint := &blockingWebhookEndpoint{slowJob: slowJob, release: make(chan struct{})}
+	server := httptest.NewServer(endpoint)
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(endpoint.release) })
+
+	n := NewWebhookNotifier(config.WebhookConfig{
+		URL:         server.URL,
+		Secret:      testWebhookSecret,
+		MaxAttempts: 1,
+		Timeout:     10 * time.Second,
+		Workers:     workers,
+	})
+	dead := &deadLetters{}
+	n.SetDeadLetterHandler(dead.record)
+	return n, endpoint, dead
+}
+
+func TestWebhookNotifier_SlowDeliveryDoesNotBlockOthers(t *testing.T) {
+	n, endpoint, dead := setupBlockingNotifier(t, "slow", 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		<-n.Done()
+	}()
+	go n.Run(ctx)
+
+	require.NoError(t, n.Publish(ctx, &PayoutEvent{Type: PayoutEventConfirmed, JobID: "slow"}))
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, n.Publish(ctx, &PayoutEvent{Type: PayoutEventConfirmed, JobID: id}))
+	}
+
+	require.Eventually(t, func() bool {
+		return len(endpoint.deliveredJobs()) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, endpoint.deliveredJobs())
+	assert.Empty(t, dead.jobIDs())
+}
+
+func TestWebhookNotifier_StopDeadLettersPendingEvents(t *testing.T) {
+	n, endpoint, dead := setupBlockingNotifier(t, "slow", 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	go n.Run(ctx)
+
+	// 唯一的投递协程卡在 slow 上，其余事件留在队列中
+	require.NoError(t, n.Publish(ctx, &PayoutEvent{Type: PayoutEventConfirmed, JobID: "slow"}))
+	require.Eventually(t, func() bool { return len(n.queue) == 0 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, n.Publish(ctx, &PayoutEvent{Type: PayoutEventFailed, JobID: "queued-1"}))
+	require.NoError(t, n.Publish(ctx, &PayoutEvent{Type: PayoutEventReverted, JobID: "queued-2"}))
+
+	cancel()
+	select {
+	case <-n.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("notifier did not stop")
+	}
+
+	// 中断的投递与队列中的事件都写入死信，写入时 ctx 未被取消
+	assert.ElementsMatch(t, []string{"slow", "queued-1", "queued-2"}, dead.jobIDs())
+	for _, err := range dead.ctxErrs {
+		assert.NoError(t, err)
+	}
+	assert.Empty(t, endpoint.deliveredJobs())
+
+	// 停止后发布的事件直接写入死信
+	require.NoError(t, n.Publish(context.Background(), &PayoutEvent{Type: PayoutEventConfirmed, JobID: "late"}))
+	assert.Contains(t, dead.jobIDs(), "late")
+	assert.Empty(t, n.queue)
+}
@@ -0,0 +1,52 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// LocalSigner 用进程内的私钥签名，私钥来自配置的私钥列表或助记词
+// 注意：私钥常驻内存，生产环境应使用 RemoteSigner 对接 HSM/KMS
+type LocalSigner struct {
+	addresses []common.Address
+	keys      map[common.Address]*ecdsa.PrivateKey
+}
+
+// NewLocalSigner 创建本地私钥签名后端，地址按私钥顺序排列
+func NewLocalSigner(keys []*ecdsa.PrivateKey) (*LocalSigner, error) {
+	s := &LocalSigner{keys: make(map[common.Address]*ecdsa.PrivateKey, len(keys))}
+	for _, key := range keys {
+		address := crypto.PubkeyToAddress(key.PublicKey)
+		if _, ok := s.keys[address]; ok {
+			return nil, fmt.Errorf("duplicate signer %s", address.Hex())
+		}
+		s.keys[address] = key
+		s.addresses = append(s.addresses, address)
+	}
+	return s, nil
+}
+
+// Addresses 按私钥顺序返回地址
+func (s *LocalSigner) Addresses() []common.Address {
+	return append([]common.Address(nil), s.addresses...)
+}
+
+// SignTx 用 from 对应的私钥签名交易
+func (s *LocalSigner) SignTx(_ context.Context, tx *types.Transaction, chainID uint64, from common.Address) (*types.Transaction, error) {
+	key, ok := s.keys[from]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSigner, from.Hex())
+	}
+	signer := types.LatestSignerForChainID(new(big.Int).SetUint64(chainID))
+	signedTx, err := types.SignTx(tx, signer, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+	return signedTx, nil
+}
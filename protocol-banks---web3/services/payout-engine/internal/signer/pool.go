@@ -0,0 +1,177 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/protocol-bank/payout-engine/internal/config"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// Pool 签名地址池
+// 每个地址的 Nonce 由 nonce.Manager 按 chainID:address 独立分配，
+// 任务轮流分配到不同地址，并行的支付不会在同一个 Nonce 序列上排队。
+// 签名交给 TxSigner 后端：本地私钥或外部签名服务。
+type Pool struct {
+	addresses []common.Address
+	members   map[common.Address]bool
+	signer    TxSigner
+	next      atomic.Uint64
+}
+
+// Configured 是否配置了签名私钥、助记词或外部签名服务
+func Configured(cfg config.SignerConfig) bool {
+	return len(cfg.PrivateKeys) > 0 || cfg.Mnemonic != "" || cfg.Endpoint != ""
+}
+
+// NewPool 按配置选择签名后端并创建签名地址池
+func NewPool(ctx context.Context, cfg config.SignerConfig) (*Pool, error) {
+	if cfg.Endpoint != "" {
+		if len(cfg.PrivateKeys) > 0 || cfg.Mnemonic != "" {
+			return nil, fmt.Errorf("external signer endpoint excludes private keys and mnemonic")
+		}
+		addresses, err := parseAddresses(cfg.Addresses)
+		if err != nil {
+			return nil, err
+		}
+		remote, err := NewRemoteSigner(ctx, cfg.Endpoint, addresses, cfg.PoolSize, cfg.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		return NewPoolWithSigner(remote)
+	}
+
+	var keys []*ecdsa.PrivateKey
+	var err error
+	switch {
+	case len(cfg.PrivateKeys) > 0 && cfg.Mnemonic != "":
+		return nil, fmt.Errorf("private keys and mnemonic are mutually exclusive")
+	case len(cfg.PrivateKeys) > 0:
+		keys, err = parseKeys(cfg.PrivateKeys, cfg.PoolSize)
+	case cfg.Mnemonic != "":
+		keys, err = deriveKeys(cfg.Mnemonic, cfg.Passphrase, cfg.DerivationPath, max(cfg.PoolSize, 1))
+	default:
+		return nil, fmt.Errorf("no signer keys configured")
+	}
+	if err != nil {
+		return nil, err
+	}
+	local, err := NewLocalSigner(keys)
+	if err != nil {
+		return nil, err
+	}
+	return NewPoolWithSigner(local)
+}
+
+// NewPoolWithSigner 用签名后端的全部地址创建签名地址池
+func NewPoolWithSigner(signer TxSigner) (*Pool, error) {
+	addresses := signer.Addresses()
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("signer has no addresses")
+	}
+	p := &Pool{members: make(map[common.Address]bool, len(addresses)), signer: signer}
+	for _, address := range addresses {
+		if p.members[address] {
+			return nil, fmt.Errorf("duplicate signer %s", address.Hex())
+		}
+		p.members[address] = true
+		p.addresses = append(p.addresses, address)
+	}
+	return p, nil
+}
+
+// parseAddresses 解析外部签名服务的地址列表
+func parseAddresses(hexAddresses []string) ([]common.Address, error) {
+	addresses := make([]common.Address, len(hexAddresses))
+	for i, hexAddress := range hexAddresses {
+		if !common.IsHexAddress(hexAddress) {
+			return nil, fmt.Errorf("invalid signer address #%d: %s", i, hexAddress)
+		}
+		addresses[i] = common.HexToAddress(hexAddress)
+	}
+	return addresses, nil
+}
+
+// parseKeys 解析十六进制私钥，size 为 0 时使用全部
+func parseKeys(hexKeys []string, size int) ([]*ecdsa.PrivateKey, error) {
+	if size == 0 {
+		size = len(hexKeys)
+	}
+	if size > len(hexKeys) {
+		return nil, fmt.Errorf("pool size %d exceeds %d configured keys", size, len(hexKeys))
+	}
+
+	keys := make([]*ecdsa.PrivateKey, size)
+	for i, hexKey := range hexKeys[:size] {
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(hexKey, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key #%d: %w", i, err)
+		}
+		keys[i] = key
+	}
+	return keys, nil
+}
+
+// deriveKeys 从助记词派生 size 个私钥，路径从 path 开始递增最后一级
+func deriveKeys(mnemonic, passphrase, path string, size int) ([]*ecdsa.PrivateKey, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+	base, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid derivation path: %w", err)
+	}
+	master, err := newMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	next := accounts.DefaultIterator(base)
+	keys := make([]*ecdsa.PrivateKey, size)
+	for i := range keys {
+		path := next()
+		key, err := master.derive(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive %s: %w", path, err)
+		}
+		keys[i] = key
+	}
+	return keys, nil
+}
+
+// Size 签名地址数量
+func (p *Pool) Size() int {
+	return len(p.addresses)
+}
+
+// Addresses 按配置顺序返回全部签名地址
+func (p *Pool) Addresses() []common.Address {
+	return append([]common.Address(nil), p.addresses...)
+}
+
+// Contains 地址是否属于签名地址池
+func (p *Pool) Contains(address common.Address) bool {
+	return p.members[address]
+}
+
+// Next 轮流返回下一个签名地址，并发安全
+func (p *Pool) Next() common.Address {
+	i := p.next.Add(1) - 1
+	return p.addresses[i%uint64(len(p.addresses))]
+}
+
+// SignTx 用签名后端签名交易，from 必须属于签名地址池
+func (p *Pool) SignTx(ctx context.Context, tx *types.Transaction, chainID uint64, from common.Address) (*types.Transaction, error) {
+	if !p.members[from] {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSigner, from.Hex())
+	}
+	return p.signer.SignTx(ctx, tx, chainID, from)
+}
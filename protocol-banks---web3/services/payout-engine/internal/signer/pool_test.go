@@ -0,0 +1,92 @@
+package signer
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/protocol-bank/payout-engine/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestNewPool_DerivesFromMnemonic(t *testing.T) {
+	pool, err := NewPool(context.Background(), config.SignerConfig{
+		Mnemonic:       testMnemonic,
+		DerivationPath: "m/44'/60'/0'/0/0",
+		PoolSize:       3,
+	})
+	require.NoError(t, err)
+
+	// 与常见钱包对同一助记词的派生结果一致
+	addresses := pool.Addresses()
+	require.Len(t, addresses, 3)
+	assert.Equal(t, common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"), addresses[0])
+	assert.Equal(t, common.HexToAddress("0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0"), addresses[1])
+	assert.NotEqual(t, addresses[1], addresses[2])
+}
+
+func TestNewPool_PrivateKeys(t *testing.T) {
+	keys := []string{
+		"0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318",
+		"4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362319",
+	}
+
+	pool, err := NewPool(context.Background(), config.SignerConfig{PrivateKeys: keys})
+	require.NoError(t, err)
+	assert.Equal(t, 2, pool.Size())
+	assert.Equal(t, common.HexToAddress("0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"), pool.Addresses()[0])
+
+	pool, err = NewPool(context.Background(), config.SignerConfig{PrivateKeys: keys, PoolSize: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, pool.Size())
+
+	_, err = NewPool(context.Background(), config.SignerConfig{PrivateKeys: keys, PoolSize: 3})
+	assert.Error(t, err)
+	_, err = NewPool(context.Background(), config.SignerConfig{PrivateKeys: []string{keys[0], keys[0]}})
+	assert.Error(t, err)
+	_, err = NewPool(context.Background(), config.SignerConfig{Mnemonic: "abandon abandon"})
+	assert.Error(t, err)
+	_, err = NewPool(context.Background(), config.SignerConfig{})
+	assert.Error(t, err)
+}
+
+func TestPool_NextRoundRobin(t *testing.T) {
+	pool, err := NewPool(context.Background(), config.SignerConfig{Mnemonic: testMnemonic, DerivationPath: "m/44'/60'/0'/0/0", PoolSize: 3})
+	require.NoError(t, err)
+	addresses := pool.Addresses()
+
+	for i := 0; i < 7; i++ {
+		assert.Equal(t, addresses[i%3], pool.Next())
+	}
+}
+
+func TestPool_SignTx(t *testing.T) {
+	pool, err := NewPool(context.Background(), config.SignerConfig{Mnemonic: testMnemonic, DerivationPath: "m/44'/60'/0'/0/0", PoolSize: 2})
+	require.NoError(t, err)
+
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		Gas:       21000,
+		GasFeeCap: big.NewInt(1),
+		GasTipCap: big.NewInt(1),
+		To:        &to,
+		Value:     big.NewInt(1),
+	})
+
+	for _, from := range pool.Addresses() {
+		signed, err := pool.SignTx(context.Background(), tx, 1, from)
+		require.NoError(t, err)
+		sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1)), signed)
+		require.NoError(t, err)
+		assert.Equal(t, from, sender)
+	}
+
+	_, err = pool.SignTx(context.Background(), tx, 1, to)
+	assert.Error(t, err)
+}
@@ -0,0 +1,146 @@
+package signer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// RemoteSigner 通过外部签名服务签名，私钥不进入本进程
+// 协议为 clef 兼容的 account_list / account_signTransaction JSON-RPC，
+// HSM 或 AWS/GCP KMS 通过实现该协议的签名代理接入。
+type RemoteSigner struct {
+	client    *rpc.Client
+	addresses []common.Address
+	timeout   time.Duration
+}
+
+// sendTxArgs account_signTransaction 的请求参数
+type sendTxArgs struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas,omitempty"`
+	Value                hexutil.Big     `json:"value"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Input                hexutil.Bytes   `json:"input"`
+	ChainID              *hexutil.Big    `json:"chainId,omitempty"`
+}
+
+// signTxResult account_signTransaction 的返回，raw 为签名后交易的编码
+type signTxResult struct {
+	Raw hexutil.Bytes `json:"raw"`
+}
+
+// NewRemoteSigner 连接外部签名服务
+// addresses 为空时使用服务管理的全部地址，size 大于 0 时只取前 size 个；
+// 指定的地址必须由服务管理。timeout 限制每次请求，签名在 Nonce 锁内进行，应小于锁的有效期。
+func NewRemoteSigner(ctx context.Context, endpoint string, addresses []common.Address, size int, timeout time.Duration) (*RemoteSigner, error) {
+	client, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to external signer: %w", err)
+	}
+	s := &RemoteSigner{client: client, timeout: timeout}
+
+	managed, err := s.list(ctx)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	if len(addresses) == 0 {
+		addresses = managed
+	} else {
+		known := make(map[common.Address]bool, len(managed))
+		for _, address := range managed {
+			known[address] = true
+		}
+		for _, address := range addresses {
+			if !known[address] {
+				client.Close()
+				return nil, fmt.Errorf("external signer does not manage %s", address.Hex())
+			}
+		}
+	}
+	if size > 0 && size < len(addresses) {
+		addresses = addresses[:size]
+	}
+	if len(addresses) == 0 {
+		client.Close()
+		return nil, fmt.Errorf("external signer has no accounts")
+	}
+	s.addresses = append([]common.Address(nil), addresses...)
+	return s, nil
+}
+
+// list 查询签名服务管理的地址
+func (s *RemoteSigner) list(ctx context.Context) ([]common.Address, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	var addresses []common.Address
+	if err := s.client.CallContext(ctx, &addresses, "account_list"); err != nil {
+		return nil, fmt.Errorf("failed to list external signer accounts: %w", err)
+	}
+	return addresses, nil
+}
+
+// Addresses 使用的签名地址
+func (s *RemoteSigner) Addresses() []common.Address {
+	return append([]common.Address(nil), s.addresses...)
+}
+
+// SignTx 请求签名服务签名交易，并检查返回的交易与请求一致
+func (s *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID uint64, from common.Address) (*types.Transaction, error) {
+	args := sendTxArgs{
+		From:    from,
+		To:      tx.To(),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Input:   tx.Data(),
+		ChainID: (*hexutil.Big)(new(big.Int).SetUint64(chainID)),
+	}
+	switch tx.Type() {
+	case types.LegacyTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	case types.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	default:
+		return nil, fmt.Errorf("unsupported tx type %d", tx.Type())
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	var res signTxResult
+	if err := s.client.CallContext(ctx, &res, "account_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("external signer: %w", err)
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(res.Raw); err != nil {
+		return nil, fmt.Errorf("invalid transaction from external signer: %w", err)
+	}
+	if err := verifySigned(tx, signed, chainID, from); err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
+
+// Close 关闭与签名服务的连接
+func (s *RemoteSigner) Close() {
+	s.client.Close()
+}
+
+func (s *RemoteSigner) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.timeout)
+}
@@ -0,0 +1,164 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/protocol-bank/payout-engine/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSignerService 外部签名服务，tamper 非空时签名前修改交易
+type fakeSignerService struct {
+	keys   map[common.Address]*ecdsa.PrivateKey
+	order  []common.Address
+	tamper func(*types.DynamicFeeTx)
+	signed []sendTxArgs
+}
+
+func newFakeSignerService(t *testing.T, n int) *fakeSignerService {
+	t.Helper()
+	svc := &fakeSignerService{keys: make(map[common.Address]*ecdsa.PrivateKey)}
+	for i := 0; i < n; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		address := crypto.PubkeyToAddress(key.PublicKey)
+		svc.keys[address] = key
+		svc.order = append(svc.order, address)
+	}
+	return svc
+}
+
+func (f *fakeSignerService) List() []common.Address {
+	return f.order
+}
+
+func (f *fakeSignerService) SignTransaction(args sendTxArgs) (*signTxResult, error) {
+	f.signed = append(f.signed, args)
+	inner := &types.DynamicFeeTx{
+		ChainID:   args.ChainID.ToInt(),
+		Nonce:     uint64(args.Nonce),
+		GasTipCap: args.MaxPriorityFeePerGas.ToInt(),
+		GasFeeCap: args.MaxFeePerGas.ToInt(),
+		Gas:       uint64(args.Gas),
+		To:        args.To,
+		Value:     args.Value.ToInt(),
+		Data:      args.Input,
+	}
+	if f.tamper != nil {
+		f.tamper(inner)
+	}
+	signed, err := types.SignTx(types.NewTx(inner), types.LatestSignerForChainID(inner.ChainID), f.keys[args.From])
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &signTxResult{Raw: hexutil.Bytes(raw)}, nil
+}
+
+func startFakeSignerService(t *testing.T, svc *fakeSignerService) string {
+	t.Helper()
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("account", svc))
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+	return httpServer.URL
+}
+
+func testDynamicTx(nonce uint64) *types.Transaction {
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		Nonce:     nonce,
+		Gas:       21000,
+		GasFeeCap: big.NewInt(30),
+		GasTipCap: big.NewInt(2),
+		To:        &to,
+		Value:     big.NewInt(1000),
+	})
+}
+
+func TestNewPool_ExternalSigner(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeSignerService(t, 3)
+	endpoint := startFakeSignerService(t, svc)
+
+	pool, err := NewPool(ctx, config.SignerConfig{Endpoint: endpoint, Timeout: time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, svc.order, pool.Addresses())
+
+	pool, err = NewPool(ctx, config.SignerConfig{Endpoint: endpoint, PoolSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, svc.order[:2], pool.Addresses())
+
+	pool, err = NewPool(ctx, config.SignerConfig{Endpoint: endpoint, Addresses: []string{svc.order[2].Hex()}})
+	require.NoError(t, err)
+	assert.Equal(t, svc.order[2:], pool.Addresses())
+
+	// 指定的地址不由签名服务管理
+	_, err = NewPool(ctx, config.SignerConfig{Endpoint: endpoint, Addresses: []string{"0x1111111111111111111111111111111111111111"}})
+	assert.Error(t, err)
+	_, err = NewPool(ctx, config.SignerConfig{Endpoint: endpoint, Mnemonic: testMnemonic})
+	assert.Error(t, err)
+}
+
+func TestRemoteSigner_SignTx(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeSignerService(t, 2)
+	endpoint := startFakeSignerService(t, svc)
+	pool, err := NewPool(ctx, config.SignerConfig{Endpoint: endpoint})
+	require.NoError(t, err)
+
+	from := pool.Addresses()[1]
+	tx := testDynamicTx(7)
+	signed, err := pool.SignTx(ctx, tx, 1, from)
+	require.NoError(t, err)
+
+	sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1)), signed)
+	require.NoError(t, err)
+	assert.Equal(t, from, sender)
+	assert.Equal(t, uint64(7), signed.Nonce(), "signer signs the nonce assigned by the nonce manager")
+	require.Len(t, svc.signed, 1)
+	assert.Equal(t, from, svc.signed[0].From)
+	assert.Equal(t, hexutil.Uint64(7), svc.signed[0].Nonce)
+
+	// 不属于签名地址池的地址不会发往签名服务
+	_, err = pool.SignTx(ctx, tx, 1, common.HexToAddress("0x1111111111111111111111111111111111111111"))
+	assert.ErrorIs(t, err, ErrUnknownSigner)
+	assert.Len(t, svc.signed, 1)
+}
+
+func TestRemoteSigner_RejectsModifiedTx(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeSignerService(t, 1)
+	endpoint := startFakeSignerService(t, svc)
+	pool, err := NewPool(ctx, config.SignerConfig{Endpoint: endpoint})
+	require.NoError(t, err)
+	from := pool.Addresses()[0]
+
+	// 签名服务自行选择 Nonce
+	svc.tamper = func(tx *types.DynamicFeeTx) { tx.Nonce++ }
+	_, err = pool.SignTx(ctx, testDynamicTx(7), 1, from)
+	assert.ErrorIs(t, err, ErrTxModified)
+
+	// 签名服务修改费用
+	svc.tamper = func(tx *types.DynamicFeeTx) { tx.GasFeeCap = big.NewInt(3000) }
+	_, err = pool.SignTx(ctx, testDynamicTx(7), 1, from)
+	assert.ErrorIs(t, err, ErrTxModified)
+}
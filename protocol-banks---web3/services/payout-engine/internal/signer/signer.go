@@ -0,0 +1,46 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	// ErrUnknownSigner 签名后端没有该地址的私钥
+	ErrUnknownSigner = errors.New("unknown signer address")
+	// ErrTxModified 签名后端返回的交易与请求不一致（Nonce、费用、接收方等被修改）
+	ErrTxModified = errors.New("signed transaction differs from the request")
+	// ErrSenderMismatch 签名后的交易发送方不是请求的地址
+	ErrSenderMismatch = errors.New("signed transaction sender mismatch")
+)
+
+// TxSigner 交易签名后端
+// 只负责签名：Nonce 由 nonce.Manager 分配并写入交易，后端必须原样签名，
+// 因此本地私钥与远程签名服务可以互换，不影响 Nonce 序列。
+type TxSigner interface {
+	// Addresses 可签名的地址
+	Addresses() []common.Address
+	// SignTx 用 from 的私钥签名交易
+	SignTx(ctx context.Context, tx *types.Transaction, chainID uint64, from common.Address) (*types.Transaction, error)
+}
+
+// verifySigned 检查签名结果：签名哈希与请求的交易一致，且发送方为 from
+func verifySigned(tx, signed *types.Transaction, chainID uint64, from common.Address) error {
+	signer := types.LatestSignerForChainID(new(big.Int).SetUint64(chainID))
+	if signed.Type() != tx.Type() || signer.Hash(signed) != signer.Hash(tx) {
+		return fmt.Errorf("%w: nonce %d, got nonce %d", ErrTxModified, tx.Nonce(), signed.Nonce())
+	}
+	sender, err := types.Sender(signer, signed)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	if sender != from {
+		return fmt.Errorf("%w: want %s, got %s", ErrSenderMismatch, from.Hex(), sender.Hex())
+	}
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/protocol-bank/webhook-handler/internal/config"
 	"github.com/protocol-bank/webhook-handler/internal/handler"
 	"github.com/protocol-bank/webhook-handler/internal/store"
@@ -30,6 +31,12 @@ func main() {
 	}
 
 	log.Info().Str("env", cfg.Environment).Msg("Starting Webhook Handler")
+	if cfg.Rain.WebhookSecret == "" {
+		log.Warn().Msg("RAIN_WEBHOOK_SECRET not set, all Rain webhooks will be rejected")
+	}
+	if cfg.Admin.APISecret == "" {
+		log.Warn().Msg("ADMIN_API_SECRET not set, webhook replay is disabled")
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -40,9 +47,16 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to initialize store")
 	}
 
-	// 创建处理器
-	rainHandler := handler.NewRainHandler(cfg.Rain, webhookStore)
-	transakHandler := handler.NewTransakHandler(cfg.Transak, webhookStore)
+	// 注册 Webhook 提供方，新增提供方只需在此注册
+	providers := handler.NewRegistry()
+	for _, provider := range []handler.WebhookProvider{
+		handler.NewRainHandler(cfg.Rain, webhookStore),
+		handler.NewTransakHandler(cfg.Transak, webhookStore),
+	} {
+		if err := providers.Register(provider); err != nil {
+			log.Fatal().Err(err).Msg("Failed to register webhook provider")
+		}
+	}
 
 	// 设置路由
 	r := chi.NewRouter()
@@ -61,10 +75,18 @@ func main() {
 	})
 
 	// Webhook 路由
+	// 跟踪处理中的 Webhook，关闭时排空
+	inflight := handler.NewInflight(ctx)
 	r.Route("/webhooks", func(r chi.Router) {
-		r.Post("/rain", rainHandler.HandleWebhook)
-		r.Post("/rain/auth", rainHandler.HandleAuthorizationRequest)
-		r.Post("/transak", transakHandler.HandleWebhook)
+		r.Use(inflight.Middleware)
+		providers.Mount(r)
+	})
+
+	// 管理端点：重放 webhook_events 中的历史事件
+	replay := handler.NewReplayHandler(providers, webhookStore, cfg.Admin.APISecret)
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(inflight.Middleware)
+		replay.Routes(r)
 	})
 
 	// 启动 HTTP 服务器
@@ -83,6 +105,9 @@ func main() {
 		}
 	}()
 
+	// Prometheus 指标
+	metricsServer := startMetricsServer(cfg.MetricsPort)
+
 	// 优雅关闭
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -97,6 +122,34 @@ func main() {
 		log.Error().Err(err).Msg("HTTP server shutdown error")
 	}
 
+	// 等待处理中的 Webhook 完成后再取消根上下文；超时后取消，未完成的处理会回滚而不是被标记为已处理
+	if !inflight.Wait(cfg.DrainTimeout) {
+		log.Warn().Dur("timeout", cfg.DrainTimeout).Msg("Timed out draining in-flight webhooks")
+	}
+
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Metrics server shutdown error")
+	}
+
 	cancel()
 	log.Info().Msg("Webhook Handler stopped")
 }
+
+// startMetricsServer 在独立端口暴露 Prometheus /metrics
+func startMetricsServer(port int) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		log.Info().Int("port", port).Msg("Metrics server listening")
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatal().Err(err).Msg("Metrics server error")
+		}
+	}()
+	return server
+}
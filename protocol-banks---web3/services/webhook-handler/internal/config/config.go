@@ -3,16 +3,21 @@ package config
 import (
 	"os"
 	"strconv"
+	"time"
 )
 
 type Config struct {
 	Environment string
 	HTTPPort    int
+	MetricsPort int // Prometheus /metrics 端口
+	// DrainTimeout 关闭时等待处理中 Webhook 完成的最长时间
+	DrainTimeout time.Duration
 
 	Database DatabaseConfig
 	Redis    RedisConfig
 	Rain     RainConfig
 	Transak  TransakConfig
+	Admin    AdminConfig
 }
 
 type DatabaseConfig struct {
@@ -31,6 +36,8 @@ type RainConfig struct {
 	APISecret        string
 	BaseURL          string
 	AuthorizationURL string
+	// MaxTimestampSkew 签名时间戳允许的最大偏差，超出即视为过期/重放
+	MaxTimestampSkew time.Duration
 }
 
 type TransakConfig struct {
@@ -39,13 +46,30 @@ type TransakConfig struct {
 	BaseURL       string
 }
 
+type AdminConfig struct {
+	// APISecret 管理端点（事件回放）的 X-Admin-Secret；为空时管理端点拒绝所有请求
+	APISecret string
+}
+
 func Load() (*Config, error) {
 	port, _ := strconv.Atoi(getEnv("HTTP_PORT", "8080"))
+	metricsPort, _ := strconv.Atoi(getEnv("METRICS_PORT", "9090"))
 	redisDB, _ := strconv.Atoi(getEnv("REDIS_DB", "0"))
+	rainSkew, err := time.ParseDuration(getEnv("RAIN_TIMESTAMP_SKEW", "5m"))
+	if err != nil || rainSkew <= 0 {
+		rainSkew = 5 * time.Minute
+	}
+
+	drainTimeout, err := time.ParseDuration(getEnv("DRAIN_TIMEOUT", "20s"))
+	if err != nil || drainTimeout <= 0 {
+		drainTimeout = 20 * time.Second
+	}
 
 	cfg := &Config{
-		Environment: getEnv("ENVIRONMENT", "development"),
-		HTTPPort:    port,
+		Environment:  getEnv("ENVIRONMENT", "development"),
+		HTTPPort:     port,
+		MetricsPort:  metricsPort,
+		DrainTimeout: drainTimeout,
 		Database: DatabaseConfig{
 			URL: getEnv("DATABASE_URL", ""),
 		},
@@ -60,12 +84,16 @@ func Load() (*Config, error) {
 			APISecret:        getEnv("RAIN_API_SECRET", ""),
 			BaseURL:          getEnv("RAIN_BASE_URL", "https://api.rain.com"),
 			AuthorizationURL: getEnv("RAIN_AUTHORIZATION_URL", ""),
+			MaxTimestampSkew: rainSkew,
 		},
 		Transak: TransakConfig{
 			WebhookSecret: getEnv("TRANSAK_WEBHOOK_SECRET", ""),
 			APIKey:        getEnv("TRANSAK_API_KEY", ""),
 			BaseURL:       getEnv("TRANSAK_BASE_URL", "https://api.transak.com"),
 		},
+		Admin: AdminConfig{
+			APISecret: getEnv("ADMIN_API_SECRET", ""),
+		},
 	}
 
 	return cfg, nil
@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/protocol-bank/webhook-handler/internal/store"
+)
+
+// EventStore 事件日志与幂等键存储，由 store.WebhookStore 实现
+type EventStore interface {
+	StoreEvent(ctx context.Context, provider, eventType, externalID string, payload []byte) (*store.WebhookEvent, error)
+	ClaimEvent(ctx context.Context, provider, externalID string) (store.ClaimStatus, error)
+	MarkProcessed(ctx context.Context, provider, externalID string) error
+	ReleaseEvent(ctx context.Context, provider, externalID string) error
+}
+
+// generateIdempotencyKey 生成幂等键 "<来源>:<事件 ID>"
+func generateIdempotencyKey(source, eventID string) string {
+	return source + ":" + eventID
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/protocol-bank/webhook-handler/internal/store"
+)
+
+// memoryEventStore 内存版事件日志与幂等键存储，行为与 store.WebhookStore 一致：
+// 未持久化的事件不能被标记为已处理
+type memoryEventStore struct {
+	mu     sync.Mutex
+	events map[string]*store.WebhookEvent
+	claims map[string]bool
+}
+
+func newMemoryEventStore() *memoryEventStore {
+	return &memoryEventStore{
+		events: make(map[string]*store.WebhookEvent),
+		claims: make(map[string]bool),
+	}
+}
+
+func (s *memoryEventStore) StoreEvent(ctx context.Context, provider, eventType, externalID string, payload []byte) (*store.WebhookEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := generateIdempotencyKey(provider, externalID)
+	if event, ok := s.events[key]; ok {
+		return event, nil
+	}
+	event := &store.WebhookEvent{
+		ID:         int64(len(s.events) + 1),
+		Provider:   provider,
+		EventType:  eventType,
+		ExternalID: externalID,
+		Payload:    json.RawMessage(payload),
+		ReceivedAt: time.Now(),
+	}
+	s.events[key] = event
+	return event, nil
+}
+
+func (s *memoryEventStore) ClaimEvent(ctx context.Context, provider, externalID string) (store.ClaimStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := generateIdempotencyKey(provider, externalID)
+	if event, ok := s.events[key]; ok && event.ProcessedAt != nil {
+		return store.ClaimDuplicate, nil
+	}
+	if s.claims[key] {
+		return store.ClaimInFlight, nil
+	}
+	s.claims[key] = true
+	return store.ClaimAcquired, nil
+}
+
+func (s *memoryEventStore) MarkProcessed(ctx context.Context, provider, externalID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := generateIdempotencyKey(provider, externalID)
+	event, ok := s.events[key]
+	if !ok {
+		return store.ErrEventNotFound
+	}
+	now := time.Now()
+	event.ProcessedAt = &now
+	delete(s.claims, key)
+	return nil
+}
+
+func (s *memoryEventStore) ReleaseEvent(ctx context.Context, provider, externalID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.claims, generateIdempotencyKey(provider, externalID))
+	return nil
+}
+
+func (s *memoryEventStore) ListEvents(ctx context.Context, filter store.EventFilter) ([]store.WebhookEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []store.WebhookEvent
+	for _, event := range s.events {
+		if event.Provider != filter.Provider ||
+			(!filter.From.IsZero() && event.ReceivedAt.Before(filter.From)) ||
+			(!filter.To.IsZero() && !event.ReceivedAt.Before(filter.To)) ||
+			(filter.FromID > 0 && event.ID < filter.FromID) ||
+			(filter.ToID > 0 && event.ID > filter.ToID) {
+			continue
+		}
+		events = append(events, *event)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	if len(events) > filter.Limit {
+		events = events[:filter.Limit]
+	}
+	return events, nil
+}
+
+func (s *memoryEventStore) event(provider, externalID string) *store.WebhookEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.events[generateIdempotencyKey(provider, externalID)]
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Inflight 跟踪处理中的 Webhook（包括请求处理与其派生的异步任务），用于优雅关闭
+// 请求上下文与根上下文合并：根上下文在排空超时后被取消，处理中的任务在安全点发现取消并回滚，而不会被标记为已处理。
+type Inflight struct {
+	ctx context.Context
+	wg  sync.WaitGroup
+}
+
+// NewInflight 创建跟踪器，ctx 为服务的根上下文
+func NewInflight(ctx context.Context) *Inflight {
+	return &Inflight{ctx: ctx}
+}
+
+// Middleware 跟踪每个请求，并在根上下文取消时取消请求上下文
+func (f *Inflight) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.wg.Add(1)
+		defer f.wg.Done()
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		stop := context.AfterFunc(f.ctx, cancel)
+		defer stop()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Go 在根上下文上运行异步任务，关闭时会等待其完成
+func (f *Inflight) Go(fn func(ctx context.Context)) {
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		fn(f.ctx)
+	}()
+}
+
+// Wait 等待所有处理中的任务完成，超时返回 false
+func (f *Inflight) Wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
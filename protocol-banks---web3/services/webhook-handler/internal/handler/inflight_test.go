@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/protocol-bank/webhook-handler/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInflightWaitsForAsyncWork(t *testing.T) {
+	inflight := NewInflight(context.Background())
+
+	release := make(chan struct{})
+	inflight.Go(func(ctx context.Context) { <-release })
+
+	assert.False(t, inflight.Wait(20*time.Millisecond), "wait must time out while work is running")
+
+	close(release)
+	assert.True(t, inflight.Wait(time.Second))
+}
+
+func TestInflightMiddlewareCancelsOnRootCancel(t *testing.T) {
+	root, cancel := context.WithCancel(context.Background())
+	inflight := NewInflight(root)
+
+	started := make(chan struct{})
+	var requestErr error
+	handler := inflight.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		requestErr = r.Context().Err()
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/webhooks/transak", nil))
+		close(done)
+	}()
+
+	<-started
+	assert.False(t, inflight.Wait(20*time.Millisecond))
+	cancel()
+	<-done
+
+	assert.ErrorIs(t, requestErr, context.Canceled)
+	assert.True(t, inflight.Wait(time.Second))
+}
+
+func TestCancelledWebhookIsNotMarkedProcessed(t *testing.T) {
+	events := newMemoryEventStore()
+	h := NewTransakHandler(config.TransakConfig{WebhookSecret: transakTestSecret}, events)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	h.orderHandlers["ORDER_COMPLETED"] = func(ctx context.Context, order TransakOrder) error {
+		calls++
+		// 模拟处理过程中服务关闭：回滚并返回取消错误
+		cancel()
+		return ctx.Err()
+	}
+
+	body := transakBody("wh_5", "ORDER_COMPLETED", "order_5")
+	req := signedTransakRequest(body).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	h.HandleWebhook(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	event := events.event(transakSource, "wh_5")
+	require.NotNil(t, event, "event must still be persisted")
+	assert.Nil(t, event.ProcessedAt, "cancelled work must not be marked processed")
+
+	// 重投递时再次处理
+	h.orderHandlers["ORDER_COMPLETED"] = func(ctx context.Context, order TransakOrder) error {
+		calls++
+		return nil
+	}
+	assert.Equal(t, http.StatusOK, deliverTransakWebhook(h, body))
+	assert.Equal(t, 2, calls)
+}
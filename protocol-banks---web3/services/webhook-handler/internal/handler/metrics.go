@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Webhook 指标
+var (
+	// 通过签名校验的 Webhook，按提供方与事件类型
+	WebhookReceived = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "webhook_received_total",
+			Help: "Total number of verified webhooks received",
+		},
+		[]string{"provider", "event_type"},
+	)
+
+	// 签名校验结果：valid / invalid（签名错误或过期）/ malformed（负载无法解析）
+	WebhookSignatureVerification = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "webhook_signature_verification_total",
+			Help: "Webhook signature verification results",
+		},
+		[]string{"provider", "result"},
+	)
+
+	// 处理结果：processed / duplicate / in_flight / failed，管理端回放为 replayed / replay_failed
+	WebhookProcessed = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "webhook_processed_total",
+			Help: "Total number of verified webhooks by processing outcome",
+		},
+		[]string{"provider", "event_type", "outcome"},
+	)
+
+	// 处理时间（验证通过到响应）
+	WebhookProcessingDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "webhook_processing_duration_seconds",
+			Help:    "Time taken to process webhooks",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		},
+		[]string{"provider", "event_type"},
+	)
+)
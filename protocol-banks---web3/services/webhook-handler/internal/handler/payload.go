@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// PayloadError 负载格式错误、缺少必填字段或字段类型不符，Verify 返回时响应 400 并指明字段
+type PayloadError struct {
+	Provider string
+	Field    string // 出错字段的 JSON 路径，如 data.id；整体无法解析时为空
+	Reason   string
+}
+
+func (e *PayloadError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("invalid %s payload: %s", e.Provider, e.Reason)
+	}
+	return fmt.Sprintf("invalid %s payload: %s %s", e.Provider, e.Field, e.Reason)
+}
+
+// decodePayload 解析 JSON 负载；截断的 JSON 与类型不符的字段转换为 PayloadError
+// prefix 为 v 在整个负载中的路径（如 "data."），用于报告嵌套字段
+func decodePayload(provider, prefix string, data []byte, v interface{}) error {
+	err := json.Unmarshal(data, v)
+	if err == nil {
+		return nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		return &PayloadError{Provider: provider, Field: strings.TrimSuffix(prefix, "."), Reason: "malformed JSON"}
+	}
+	if typeErr.Field == "" {
+		// 负载本身（或 prefix 指向的字段）不是对象
+		return &PayloadError{Provider: provider, Field: strings.TrimSuffix(prefix, "."), Reason: "must be an object"}
+	}
+	return &PayloadError{Provider: provider, Field: prefix + typeErr.Field, Reason: "must be " + jsonTypeName(typeErr.Type)}
+}
+
+// jsonTypeName 字段期望的 JSON 类型
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// fieldChecker 按顺序收集第一个缺失或非法的必填字段
+type fieldChecker struct {
+	provider string
+	err      *PayloadError
+}
+
+// fail 记录第一个错误
+func (c *fieldChecker) fail(field, reason string) {
+	if c.err == nil {
+		c.err = &PayloadError{Provider: c.provider, Field: field, Reason: reason}
+	}
+}
+
+// requireString 字段必须存在且非空
+func (c *fieldChecker) requireString(field string, value *string) {
+	if value == nil {
+		c.fail(field, "is missing")
+	} else if strings.TrimSpace(*value) == "" {
+		c.fail(field, "must not be empty")
+	}
+}
+
+// requireAmount 金额必须存在；positive 为 true 时还必须大于 0
+func (c *fieldChecker) requireAmount(field string, value *float64, positive bool) {
+	if value == nil {
+		c.fail(field, "is missing")
+	} else if positive && *value <= 0 {
+		c.fail(field, "must be positive")
+	}
+}
+
+// Err 返回第一个错误，全部通过时为 nil
+func (c *fieldChecker) Err() error {
+	if c.err == nil {
+		return nil
+	}
+	return c.err
+}
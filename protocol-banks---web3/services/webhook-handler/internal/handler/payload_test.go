@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/protocol-bank/webhook-handler/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransakWebhookRejectsInvalidPayloads(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		message string
+	}{
+		{"truncated JSON", `{"webhookId":"wh_v1","eventType":"ORDER_COMPLETED","data":{"id":"ord`, "invalid transak payload: malformed JSON"},
+		{"not an object", `[]`, "invalid transak payload: must be an object"},
+		{"missing event type", `{"webhookId":"wh_v2","data":{"id":"order_v","status":"COMPLETED","fiatCurrency":"USD","fiatAmount":100}}`, "invalid transak payload: eventType is missing"},
+		{"missing data", `{"webhookId":"wh_v3","eventType":"ORDER_COMPLETED"}`, "invalid transak payload: data is missing"},
+		{"data not an object", `{"webhookId":"wh_v4","eventType":"ORDER_COMPLETED","data":"order_v"}`, "invalid transak payload: data must be an object"},
+		{"missing order id", `{"webhookId":"wh_v5","eventType":"ORDER_COMPLETED","data":{"status":"COMPLETED","fiatCurrency":"USD","fiatAmount":100}}`, "invalid transak payload: data.id is missing"},
+		{"empty status", `{"webhookId":"wh_v6","eventType":"ORDER_COMPLETED","data":{"id":"order_v","status":"","fiatCurrency":"USD","fiatAmount":100}}`, "invalid transak payload: data.status must not be empty"},
+		{"amount as string", `{"webhookId":"wh_v7","eventType":"ORDER_COMPLETED","data":{"id":"order_v","status":"COMPLETED","fiatCurrency":"USD","fiatAmount":"100"}}`, "invalid transak payload: data.fiatAmount must be a number"},
+		{"zero amount", `{"webhookId":"wh_v8","eventType":"ORDER_COMPLETED","data":{"id":"order_v","status":"COMPLETED","fiatCurrency":"USD","fiatAmount":0}}`, "invalid transak payload: data.fiatAmount must be positive"},
+		{"currency as number", `{"webhookId":"wh_v9","eventType":"ORDER_COMPLETED","data":{"id":"order_v","status":"COMPLETED","fiatCurrency":840,"fiatAmount":100}}`, "invalid transak payload: data.fiatCurrency must be a string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := newMemoryEventStore()
+			h := NewTransakHandler(config.TransakConfig{WebhookSecret: transakTestSecret}, events)
+
+			rec := httptest.NewRecorder()
+			h.HandleWebhook(rec, signedTransakRequest(tt.body))
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, tt.message+"\n", rec.Body.String())
+			assert.Empty(t, events.events, "invalid payloads must not be persisted")
+		})
+	}
+}
+
+func TestRainWebhookRejectsInvalidPayloads(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		message string
+	}{
+		{"truncated JSON", `{"event_id":"evt_v1","event_type":"card.trans`, "invalid rain payload: malformed JSON"},
+		{"missing event id", `{"event_type":"card.created"}`, "invalid rain payload: event_id is missing"},
+		{"event id as number", `{"event_id":42,"event_type":"card.created"}`, "invalid rain payload: event_id must be a string"},
+		{"missing event type", `{"event_id":"evt_v2"}`, "invalid rain payload: event_type is missing"},
+		{"timestamp as string", `{"event_id":"evt_v3","event_type":"card.created","timestamp":"now"}`, "invalid rain payload: timestamp must be a number"},
+		{"transaction without data", `{"event_id":"evt_v4","event_type":"card.transaction"}`, "invalid rain payload: data is missing"},
+		{"transaction data not an object", `{"event_id":"evt_v5","event_type":"card.transaction","data":[]}`, "invalid rain payload: data must be an object"},
+		{"missing transaction id", `{"event_id":"evt_v6","event_type":"card.transaction","data":{"status":"settled","amount":10,"currency":"USD"}}`, "invalid rain payload: data.transaction_id is missing"},
+		{"missing status", `{"event_id":"evt_v7","event_type":"card.transaction","data":{"transaction_id":"tx_v","amount":10,"currency":"USD"}}`, "invalid rain payload: data.status is missing"},
+		{"amount as string", `{"event_id":"evt_v8","event_type":"card.transaction","data":{"transaction_id":"tx_v","status":"settled","amount":"10","currency":"USD"}}`, "invalid rain payload: data.amount must be a number"},
+		{"missing currency", `{"event_id":"evt_v9","event_type":"card.transaction","data":{"transaction_id":"tx_v","status":"settled","amount":10}}`, "invalid rain payload: data.currency is missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := newMemoryRainStore()
+			h := NewRainHandler(config.RainConfig{WebhookSecret: rainTestSecret}, events)
+
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/rain", bytes.NewBufferString(tt.body))
+			req.Header.Set(rainSignatureHeader, signRain(rainTestSecret, timestamp, tt.body))
+			req.Header.Set(rainTimestampHeader, timestamp)
+			rec := httptest.NewRecorder()
+			h.HandleWebhook(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, tt.message+"\n", rec.Body.String())
+			assert.Empty(t, events.events, "invalid payloads must not be persisted")
+		})
+	}
+}
+
+func TestRainWebhookAcceptsCompleteTransaction(t *testing.T) {
+	events := newMemoryRainStore()
+	h := NewRainHandler(config.RainConfig{WebhookSecret: rainTestSecret}, events)
+	body := `{"event_id":"evt_v10","event_type":"card.transaction","data":{"transaction_id":"tx_v","status":"settled","amount":0,"currency":"USD"}}`
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/rain", bytes.NewBufferString(body))
+	req.Header.Set(rainSignatureHeader, signRain(rainTestSecret, timestamp, body))
+	req.Header.Set(rainTimestampHeader, timestamp)
+	rec := httptest.NewRecorder()
+	h.HandleWebhook(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/protocol-bank/webhook-handler/internal/store"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrUnauthorized 签名缺失、错误或已过期，Verify 返回时包装此错误，响应 401
+var ErrUnauthorized = errors.New("unauthorized webhook")
+
+// Event 已验证并解析的 Webhook 事件
+type Event struct {
+	Provider   string
+	Type       string
+	ExternalID string          // 提供方事件 ID，作为幂等键
+	Body       []byte          // 原始请求体，持久化到 webhook_events
+	Payload    json.RawMessage // 提供方负载，Handle 自行解析
+}
+
+// WebhookProvider 出入金 Webhook 提供方
+// 新增提供方只需实现该接口并在 main 中注册，公共流程（持久化、去重、标记处理完成）由 ServeWebhook 负责。
+type WebhookProvider interface {
+	// Name 提供方名称，同时作为事件来源与幂等键前缀
+	Name() string
+	// Routes 在 /webhooks 下挂载路由
+	Routes(r chi.Router)
+	// Verify 校验签名并解析事件；body 为已读取的原始请求体
+	Verify(r *http.Request, body []byte) (*Event, error)
+	// Handle 执行业务处理；返回错误时释放幂等键，提供方重投递时再次处理
+	// 实现应在副作用之间检查 ctx.Err()，被取消时回滚已做的部分并返回错误
+	Handle(ctx context.Context, event *Event) error
+}
+
+// bookkeepingTimeout 释放/标记幂等键的超时；这些写入不随请求取消，保证处理结果被如实记录
+const bookkeepingTimeout = 5 * time.Second
+
+// bookkeepingContext 返回不随请求取消的上下文
+func bookkeepingContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), bookkeepingTimeout)
+}
+
+// releaseEvent 释放幂等键，让提供方重投递时再次处理
+func releaseEvent(ctx context.Context, events EventStore, event *Event) {
+	ctx, cancel := bookkeepingContext(ctx)
+	defer cancel()
+	if err := events.ReleaseEvent(ctx, event.Provider, event.ExternalID); err != nil {
+		log.Error().Err(err).Msg("Failed to release idempotency key")
+	}
+}
+
+// ServeWebhook 处理一次 Webhook 投递：验证 → 持久化 → 抢占幂等键 → 处理 → 标记完成
+func ServeWebhook(w http.ResponseWriter, r *http.Request, provider WebhookProvider, events EventStore) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to read request body")
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	// 验证签名（基于原始请求体，必须在 JSON 解析之前）
+	event, err := provider.Verify(r, body)
+	if errors.Is(err, ErrUnauthorized) {
+		WebhookSignatureVerification.WithLabelValues(provider.Name(), "invalid").Inc()
+		log.Warn().Err(err).Str("provider", provider.Name()).Msg("Rejected webhook")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		WebhookSignatureVerification.WithLabelValues(provider.Name(), "malformed").Inc()
+		log.Error().Err(err).Str("provider", provider.Name()).Msg("Failed to parse webhook payload")
+		// 负载校验失败时告知提供方具体字段
+		var payloadErr *PayloadError
+		if errors.As(err, &payloadErr) {
+			http.Error(w, payloadErr.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	WebhookSignatureVerification.WithLabelValues(provider.Name(), "valid").Inc()
+	WebhookReceived.WithLabelValues(provider.Name(), event.Type).Inc()
+
+	outcome := "failed"
+	start := time.Now()
+	defer func() {
+		WebhookProcessed.WithLabelValues(provider.Name(), event.Type, outcome).Inc()
+		WebhookProcessingDuration.WithLabelValues(provider.Name(), event.Type).Observe(time.Since(start).Seconds())
+	}()
+
+	key := generateIdempotencyKey(event.Provider, event.ExternalID)
+
+	// 先持久化到事件日志，再抢占幂等键，重复投递直接返回 200
+	if _, err := events.StoreEvent(r.Context(), event.Provider, event.Type, event.ExternalID, event.Body); err != nil {
+		log.Error().Err(err).Str("idempotency_key", key).Msg("Failed to store webhook event")
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	status, err := events.ClaimEvent(r.Context(), event.Provider, event.ExternalID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to check duplicate")
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	switch status {
+	case store.ClaimDuplicate:
+		outcome = "duplicate"
+		log.Info().Str("idempotency_key", key).Msg("Duplicate webhook, skipping")
+		w.WriteHeader(http.StatusOK)
+		return
+	case store.ClaimInFlight:
+		outcome = "in_flight"
+		// 另一个请求正在处理，返回 409 让提供方稍后重试
+		log.Info().Str("idempotency_key", key).Msg("Webhook already being processed")
+		http.Error(w, "Already processing", http.StatusConflict)
+		return
+	}
+
+	// 安全点：服务正在关闭时不开始处理
+	if err := r.Context().Err(); err != nil {
+		log.Warn().Err(err).Str("idempotency_key", key).Msg("Shutting down, webhook not processed")
+		releaseEvent(r.Context(), events, event)
+		http.Error(w, "Service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	log.Info().
+		Str("provider", event.Provider).
+		Str("idempotency_key", key).
+		Str("event_type", event.Type).
+		Msg("Processing webhook")
+
+	if err := provider.Handle(r.Context(), event); err != nil {
+		// 处理失败或被取消：释放幂等键而不是标记完成，提供方重投递时再次处理
+		log.Error().Err(err).Str("idempotency_key", key).Msg("Failed to process webhook")
+		releaseEvent(r.Context(), events, event)
+		if r.Context().Err() != nil {
+			http.Error(w, "Service unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	// 处理已完成，即使请求随后被取消也要记录结果
+	markCtx, cancel := bookkeepingContext(r.Context())
+	defer cancel()
+	if err := events.MarkProcessed(markCtx, event.Provider, event.ExternalID); err != nil {
+		// 处理中标记保留到过期，之后的重投递会再次处理
+		log.Error().Err(err).Str("idempotency_key", key).Msg("Failed to mark as processed")
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	outcome = "processed"
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Registry Webhook 提供方注册表
+type Registry struct {
+	providers []WebhookProvider
+	names     map[string]bool
+}
+
+// NewRegistry 创建注册表
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// Register 注册提供方，名称重复时返回错误
+func (reg *Registry) Register(provider WebhookProvider) error {
+	name := provider.Name()
+	if reg.names[name] {
+		return fmt.Errorf("webhook provider %q already registered", name)
+	}
+	reg.names[name] = true
+	reg.providers = append(reg.providers, provider)
+	return nil
+}
+
+// Providers 按注册顺序返回提供方
+func (reg *Registry) Providers() []WebhookProvider {
+	return reg.providers
+}
+
+// Provider 按名称查找提供方
+func (reg *Registry) Provider(name string) (WebhookProvider, bool) {
+	for _, provider := range reg.providers {
+		if provider.Name() == name {
+			return provider, true
+		}
+	}
+	return nil, false
+}
+
+// Mount 挂载所有提供方的路由
+func (reg *Registry) Mount(r chi.Router) {
+	for _, provider := range reg.providers {
+		provider.Routes(r)
+	}
+}
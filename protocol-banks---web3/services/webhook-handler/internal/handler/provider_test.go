@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/protocol-bank/webhook-handler/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryMountsProviderRoutes(t *testing.T) {
+	registry := NewRegistry()
+	require.NoError(t, registry.Register(NewRainHandler(config.RainConfig{}, newMemoryRainStore())))
+	require.NoError(t, registry.Register(NewTransakHandler(config.TransakConfig{}, newMemoryEventStore())))
+
+	r := chi.NewRouter()
+	r.Route("/webhooks", registry.Mount)
+
+	var routes []string
+	err := chi.Walk(r, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		routes = append(routes, method+" "+route)
+		return nil
+	})
+	require.NoError(t, err)
+	sort.Strings(routes)
+
+	// 外部 URL 保持不变
+	assert.Equal(t, []string{
+		"POST /webhooks/rain",
+		"POST /webhooks/rain/auth",
+		"POST /webhooks/transak",
+	}, routes)
+}
+
+func TestRegistryRejectsDuplicateProvider(t *testing.T) {
+	registry := NewRegistry()
+	require.NoError(t, registry.Register(NewTransakHandler(config.TransakConfig{}, newMemoryEventStore())))
+	assert.Error(t, registry.Register(NewTransakHandler(config.TransakConfig{}, newMemoryEventStore())))
+	assert.Len(t, registry.Providers(), 1)
+}
+
+func TestServeWebhookRecordsMetrics(t *testing.T) {
+	h := NewTransakHandler(config.TransakConfig{WebhookSecret: transakTestSecret}, newMemoryEventStore())
+	h.orderHandlers["ORDER_COMPLETED"] = func(ctx context.Context, order TransakOrder) error { return nil }
+
+	received := testutil.ToFloat64(WebhookReceived.WithLabelValues("transak", "ORDER_COMPLETED"))
+	valid := testutil.ToFloat64(WebhookSignatureVerification.WithLabelValues("transak", "valid"))
+	invalid := testutil.ToFloat64(WebhookSignatureVerification.WithLabelValues("transak", "invalid"))
+	duplicates := testutil.ToFloat64(WebhookProcessed.WithLabelValues("transak", "ORDER_COMPLETED", "duplicate"))
+
+	body := transakBody("wh_metrics", "ORDER_COMPLETED", "order_m")
+	require.Equal(t, http.StatusOK, deliverTransakWebhook(h, body))
+	require.Equal(t, http.StatusOK, deliverTransakWebhook(h, body))
+
+	req := signedTransakRequest(body)
+	req.Header.Set("X-Transak-Signature", "bad")
+	rec := httptest.NewRecorder()
+	h.HandleWebhook(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	assert.Equal(t, received+2, testutil.ToFloat64(WebhookReceived.WithLabelValues("transak", "ORDER_COMPLETED")))
+	assert.Equal(t, valid+2, testutil.ToFloat64(WebhookSignatureVerification.WithLabelValues("transak", "valid")))
+	assert.Equal(t, invalid+1, testutil.ToFloat64(WebhookSignatureVerification.WithLabelValues("transak", "invalid")))
+	assert.Equal(t, duplicates+1, testutil.ToFloat64(WebhookProcessed.WithLabelValues("transak", "ORDER_COMPLETED", "duplicate")))
+}
@@ -1,17 +1,19 @@
 package handler
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/protocol-bank/webhook-handler/internal/config"
-	"github.com/protocol-bank/webhook-handler/internal/store"
 	"github.com/rs/zerolog/log"
 )
 
@@ -36,6 +38,51 @@ type RainTransaction struct {
 	CreatedAt        string  `json:"created_at"`
 }
 
+// rainRequiredFields Rain 负载的必填字段，指针区分缺失与零值
+type rainRequiredFields struct {
+	EventID   *string         `json:"event_id"`
+	EventType *string         `json:"event_type"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// rainTransactionFields card.transaction 事件 data 的必填字段
+type rainTransactionFields struct {
+	TransactionID *string  `json:"transaction_id"`
+	Status        *string  `json:"status"`
+	Amount        *float64 `json:"amount"`
+	Currency      *string  `json:"currency"`
+}
+
+// validateRainPayload 校验必填字段：event_id、event_type，交易事件还需交易 ID、状态、金额与币种
+func validateRainPayload(body []byte) error {
+	var fields rainRequiredFields
+	if err := decodePayload(rainSource, "", body, &fields); err != nil {
+		return err
+	}
+	check := fieldChecker{provider: rainSource}
+	check.requireString("event_id", fields.EventID)
+	check.requireString("event_type", fields.EventType)
+	if err := check.Err(); err != nil {
+		return err
+	}
+
+	if *fields.EventType != "card.transaction" {
+		return nil
+	}
+	if len(fields.Data) == 0 || string(fields.Data) == "null" {
+		return &PayloadError{Provider: rainSource, Field: "data", Reason: "is missing"}
+	}
+	var tx rainTransactionFields
+	if err := decodePayload(rainSource, "data.", fields.Data, &tx); err != nil {
+		return err
+	}
+	check.requireString("data.transaction_id", tx.TransactionID)
+	check.requireString("data.status", tx.Status)
+	check.requireAmount("data.amount", tx.Amount, false)
+	check.requireString("data.currency", tx.Currency)
+	return check.Err()
+}
+
 // RainAuthorizationRequest Rain 授权请求
 type RainAuthorizationRequest struct {
 	AuthorizationID string  `json:"authorization_id"`
@@ -46,66 +93,101 @@ type RainAuthorizationRequest struct {
 	Currency        string  `json:"currency"`
 }
 
+// Rain 签名请求头
+const (
+	rainSignatureHeader = "X-Rain-Signature"
+	rainTimestampHeader = "X-Rain-Timestamp"
+)
+
+// defaultRainTimestampSkew 未配置时允许的签名时间戳偏差
+const defaultRainTimestampSkew = 5 * time.Minute
+
+// 授权拒绝原因
+const (
+	declineStaleRequest     = "stale_request"
+	declineDuplicateRequest = "duplicate_request"
+	declineInvalidRequest   = "invalid_request"
+	declineUnavailable      = "temporarily_unavailable"
+)
+
+// rainSource Rain 提供方名称
+const rainSource = "rain"
+
+// RainStore Rain 处理器使用的存储，由 store.WebhookStore 实现
+type RainStore interface {
+	EventStore
+	MarkAuthorizationSeen(ctx context.Context, authorizationID string, ttl time.Duration) (bool, error)
+}
+
 // RainHandler Rain Webhook 处理器
 type RainHandler struct {
 	cfg   config.RainConfig
-	store *store.WebhookStore
+	store RainStore
 }
 
 // NewRainHandler 创建 Rain 处理器
-func NewRainHandler(cfg config.RainConfig, store *store.WebhookStore) *RainHandler {
+func NewRainHandler(cfg config.RainConfig, store RainStore) *RainHandler {
 	return &RainHandler{
 		cfg:   cfg,
 		store: store,
 	}
 }
 
+// Name 提供方名称
+func (h *RainHandler) Name() string {
+	return rainSource
+}
+
+// Routes 挂载 Rain 路由
+func (h *RainHandler) Routes(r chi.Router) {
+	r.Post("/rain", h.HandleWebhook)
+	r.Post("/rain/auth", h.HandleAuthorizationRequest)
+}
+
 // HandleWebhook 处理 Rain Webhook
 func (h *RainHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
-	// 读取请求体
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to read request body")
-		http.Error(w, "Bad request", http.StatusBadRequest)
-		return
-	}
+	ServeWebhook(w, r, h, h.store)
+}
 
-	// 验证签名
-	signature := r.Header.Get("X-Rain-Signature")
-	timestamp := r.Header.Get("X-Rain-Timestamp")
+// Verify 验证签名、时间戳并解析 Rain 事件
+func (h *RainHandler) Verify(r *http.Request, body []byte) (*Event, error) {
+	signature := r.Header.Get(rainSignatureHeader)
+	timestamp := r.Header.Get(rainTimestampHeader)
+	if signature == "" {
+		return nil, fmt.Errorf("%w: missing Rain signature", ErrUnauthorized)
+	}
 	if !h.verifySignature(body, signature, timestamp) {
-		log.Warn().Str("signature", signature).Msg("Invalid webhook signature")
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
+		return nil, fmt.Errorf("%w: invalid Rain signature", ErrUnauthorized)
 	}
 
 	// 防重放攻击检查
-	ts, _ := strconv.ParseInt(timestamp, 10, 64)
-	if time.Now().Unix()-ts > 300 { // 5 分钟过期
-		log.Warn().Int64("timestamp", ts).Msg("Webhook timestamp expired")
-		http.Error(w, "Request expired", http.StatusUnauthorized)
-		return
+	if !h.timestampFresh(timestamp, time.Now()) {
+		return nil, fmt.Errorf("%w: Rain timestamp %q expired", ErrUnauthorized, timestamp)
 	}
 
-	// 解析负载
+	// 校验必填字段，残缺或类型不符的负载不进入处理流程
+	if err := validateRainPayload(body); err != nil {
+		return nil, err
+	}
 	var payload RainWebhookPayload
-	if err := json.Unmarshal(body, &payload); err != nil {
-		log.Error().Err(err).Msg("Failed to parse webhook payload")
-		http.Error(w, "Bad request", http.StatusBadRequest)
-		return
+	if err := decodePayload(rainSource, "", body, &payload); err != nil {
+		return nil, err
 	}
 
-	// 检查重复处理
-	processed, err := h.store.IsProcessed(r.Context(), payload.EventID)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to check duplicate")
-		http.Error(w, "Internal error", http.StatusInternalServerError)
-		return
-	}
-	if processed {
-		log.Info().Str("event_id", payload.EventID).Msg("Duplicate webhook, skipping")
-		w.WriteHeader(http.StatusOK)
-		return
+	return &Event{
+		Provider:   rainSource,
+		Type:       payload.EventType,
+		ExternalID: payload.EventID,
+		Body:       body,
+		Payload:    body,
+	}, nil
+}
+
+// Handle 根据事件类型处理
+func (h *RainHandler) Handle(ctx context.Context, event *Event) error {
+	var payload RainWebhookPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid Rain payload: %w", err)
 	}
 
 	log.Info().
@@ -113,27 +195,24 @@ func (h *RainHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		Str("event_type", payload.EventType).
 		Msg("Processing Rain webhook")
 
-	// 根据事件类型处理
+	// 安全点：副作用开始前检查取消
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	switch payload.EventType {
 	case "card.transaction":
-		h.handleTransaction(r.Context(), payload)
+		h.handleTransaction(ctx, payload)
 	case "card.created":
-		h.handleCardCreated(r.Context(), payload)
+		h.handleCardCreated(ctx, payload)
 	case "card.activated":
-		h.handleCardActivated(r.Context(), payload)
+		h.handleCardActivated(ctx, payload)
 	case "card.settlement":
-		h.handleSettlement(r.Context(), payload)
+		h.handleSettlement(ctx, payload)
 	default:
 		log.Warn().Str("event_type", payload.EventType).Msg("Unknown event type")
 	}
-
-	// 标记为已处理
-	if err := h.store.MarkProcessed(r.Context(), payload.EventID, string(body)); err != nil {
-		log.Error().Err(err).Msg("Failed to mark as processed")
-	}
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	return nil
 }
 
 // HandleAuthorizationRequest 处理实时授权请求
@@ -145,8 +224,8 @@ func (h *RainHandler) HandleAuthorizationRequest(w http.ResponseWriter, r *http.
 	}
 
 	// 验证签名
-	signature := r.Header.Get("X-Rain-Signature")
-	timestamp := r.Header.Get("X-Rain-Timestamp")
+	signature := r.Header.Get(rainSignatureHeader)
+	timestamp := r.Header.Get(rainTimestampHeader)
 	if !h.verifySignature(body, signature, timestamp) {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
@@ -158,6 +237,34 @@ func (h *RainHandler) HandleAuthorizationRequest(w http.ResponseWriter, r *http.
 		return
 	}
 
+	if authReq.AuthorizationID == "" {
+		writeAuthorizationResponse(w, authReq.AuthorizationID, false, declineInvalidRequest)
+		return
+	}
+
+	// 拒绝过期请求（时间戳已包含在签名中，无法被篡改）
+	if !h.timestampFresh(timestamp, time.Now()) {
+		log.Warn().
+			Str("auth_id", authReq.AuthorizationID).
+			Str("timestamp", timestamp).
+			Msg("Stale authorization request")
+		writeAuthorizationResponse(w, authReq.AuthorizationID, false, declineStaleRequest)
+		return
+	}
+
+	// 拒绝完全相同的重放：记录时长覆盖整个时间窗口（前后各一个偏差）
+	firstSeen, err := h.store.MarkAuthorizationSeen(r.Context(), authReq.AuthorizationID, 2*h.timestampSkew())
+	if err != nil {
+		log.Error().Err(err).Str("auth_id", authReq.AuthorizationID).Msg("Failed to record authorization ID")
+		writeAuthorizationResponse(w, authReq.AuthorizationID, false, declineUnavailable)
+		return
+	}
+	if !firstSeen {
+		log.Warn().Str("auth_id", authReq.AuthorizationID).Msg("Replayed authorization request")
+		writeAuthorizationResponse(w, authReq.AuthorizationID, false, declineDuplicateRequest)
+		return
+	}
+
 	log.Info().
 		Str("auth_id", authReq.AuthorizationID).
 		Str("card_id", authReq.CardID).
@@ -169,8 +276,13 @@ func (h *RainHandler) HandleAuthorizationRequest(w http.ResponseWriter, r *http.
 	approved, reason := h.checkAuthorization(r.Context(), authReq)
 
 	// 返回授权决定
+	writeAuthorizationResponse(w, authReq.AuthorizationID, approved, reason)
+}
+
+// writeAuthorizationResponse 按 Rain 要求的格式返回授权决定（拒绝同样返回 200）
+func writeAuthorizationResponse(w http.ResponseWriter, authorizationID string, approved bool, reason string) {
 	response := map[string]interface{}{
-		"authorization_id": authReq.AuthorizationID,
+		"authorization_id": authorizationID,
 		"approved":         approved,
 		"reason":           reason,
 	}
@@ -180,10 +292,32 @@ func (h *RainHandler) HandleAuthorizationRequest(w http.ResponseWriter, r *http.
 	json.NewEncoder(w).Encode(response)
 }
 
+// timestampSkew 返回允许的时间戳偏差
+func (h *RainHandler) timestampSkew() time.Duration {
+	if h.cfg.MaxTimestampSkew > 0 {
+		return h.cfg.MaxTimestampSkew
+	}
+	return defaultRainTimestampSkew
+}
+
+// timestampFresh 检查签名时间戳（Unix 秒）与当前时间的偏差是否在允许范围内
+func (h *RainHandler) timestampFresh(timestamp string, now time.Time) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || ts <= 0 {
+		return false
+	}
+	skew := now.Sub(time.Unix(ts, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	return skew <= h.timestampSkew()
+}
+
 // verifySignature 验证 HMAC 签名
+// 未配置 RAIN_WEBHOOK_SECRET 时拒绝所有请求，避免伪造的卡片/授权事件被处理
 func (h *RainHandler) verifySignature(body []byte, signature, timestamp string) bool {
-	if h.cfg.WebhookSecret == "" {
-		return true // 开发环境跳过验证
+	if h.cfg.WebhookSecret == "" || signature == "" {
+		return false
 	}
 
 	// 构造签名消息
@@ -198,7 +332,7 @@ func (h *RainHandler) verifySignature(body []byte, signature, timestamp string)
 }
 
 // handleTransaction 处理交易事件
-func (h *RainHandler) handleTransaction(ctx interface{}, payload RainWebhookPayload) {
+func (h *RainHandler) handleTransaction(ctx context.Context, payload RainWebhookPayload) {
 	var tx RainTransaction
 	if err := json.Unmarshal(payload.Data, &tx); err != nil {
 		log.Error().Err(err).Msg("Failed to parse transaction data")
@@ -216,25 +350,25 @@ func (h *RainHandler) handleTransaction(ctx interface{}, payload RainWebhookPayl
 }
 
 // handleCardCreated 处理卡片创建事件
-func (h *RainHandler) handleCardCreated(ctx interface{}, payload RainWebhookPayload) {
+func (h *RainHandler) handleCardCreated(ctx context.Context, payload RainWebhookPayload) {
 	log.Info().Str("event_id", payload.EventID).Msg("Card created event")
 	// TODO: 更新用户卡片状态
 }
 
 // handleCardActivated 处理卡片激活事件
-func (h *RainHandler) handleCardActivated(ctx interface{}, payload RainWebhookPayload) {
+func (h *RainHandler) handleCardActivated(ctx context.Context, payload RainWebhookPayload) {
 	log.Info().Str("event_id", payload.EventID).Msg("Card activated event")
 	// TODO: 更新用户卡片状态
 }
 
 // handleSettlement 处理结算事件
-func (h *RainHandler) handleSettlement(ctx interface{}, payload RainWebhookPayload) {
+func (h *RainHandler) handleSettlement(ctx context.Context, payload RainWebhookPayload) {
 	log.Info().Str("event_id", payload.EventID).Msg("Settlement event")
 	// TODO: 处理结算，更新用户余额
 }
 
 // checkAuthorization 检查授权
-func (h *RainHandler) checkAuthorization(ctx interface{}, req RainAuthorizationRequest) (bool, string) {
+func (h *RainHandler) checkAuthorization(ctx context.Context, req RainAuthorizationRequest) (bool, string) {
 	// TODO: 实现授权检查逻辑
 	// 1. 检查用户余额
 	// 2. 检查交易限额
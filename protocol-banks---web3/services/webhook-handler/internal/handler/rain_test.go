@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/protocol-bank/webhook-handler/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const rainTestSecret = "rain-secret"
+
+func signRain(secret, timestamp, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestRainVerifySignature(t *testing.T) {
+	h := NewRainHandler(config.RainConfig{WebhookSecret: rainTestSecret}, nil)
+	body := `{"event_id":"evt_1","event_type":"card.created"}`
+	timestamp := "1704067200"
+	valid := signRain(rainTestSecret, timestamp, body)
+
+	tests := []struct {
+		name      string
+		body      string
+		signature string
+		timestamp string
+		valid     bool
+	}{
+		{"valid signature", body, valid, timestamp, true},
+		{"invalid signature", body, signRain("other-secret", timestamp, body), timestamp, false},
+		{"missing signature", body, "", timestamp, false},
+		{"tampered body", body + " ", valid, timestamp, false},
+		{"tampered timestamp", body, valid, "1704067201", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.valid, h.verifySignature([]byte(tt.body), tt.signature, tt.timestamp))
+		})
+	}
+}
+
+func TestRainVerifySignatureWithoutSecret(t *testing.T) {
+	h := NewRainHandler(config.RainConfig{}, nil)
+	body := `{"event_id":"evt_1"}`
+	assert.False(t, h.verifySignature([]byte(body), signRain("", "1", body), "1"))
+}
+
+func TestRainWebhookRejectsBadSignatures(t *testing.T) {
+	h := NewRainHandler(config.RainConfig{WebhookSecret: rainTestSecret}, nil)
+	body := `{"event_id":"evt_2","event_type":"card.transaction"}`
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	tests := []struct {
+		name      string
+		signature string
+	}{
+		{"missing signature", ""},
+		{"invalid signature", signRain("other-secret", timestamp, body)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/rain", bytes.NewBufferString(body))
+			if tt.signature != "" {
+				req.Header.Set(rainSignatureHeader, tt.signature)
+			}
+			req.Header.Set(rainTimestampHeader, timestamp)
+			rec := httptest.NewRecorder()
+
+			// store 为 nil：签名校验失败必须在任何处理之前返回
+			h.HandleWebhook(rec, req)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		})
+	}
+}
+
+// memoryRainStore 内存版 Rain 存储
+type memoryRainStore struct {
+	*memoryEventStore
+	seen map[string]bool
+}
+
+func newMemoryRainStore() *memoryRainStore {
+	return &memoryRainStore{memoryEventStore: newMemoryEventStore(), seen: make(map[string]bool)}
+}
+
+func (s *memoryRainStore) MarkAuthorizationSeen(ctx context.Context, authorizationID string, ttl time.Duration) (bool, error) {
+	if s.seen[authorizationID] {
+		return false, nil
+	}
+	s.seen[authorizationID] = true
+	return true, nil
+}
+
+func requestAuthorization(h *RainHandler, body string, at time.Time) map[string]interface{} {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/rain/auth", bytes.NewBufferString(body))
+	req.Header.Set(rainSignatureHeader, signRain(rainTestSecret, timestamp, body))
+	req.Header.Set(rainTimestampHeader, timestamp)
+	rec := httptest.NewRecorder()
+	h.HandleAuthorizationRequest(rec, req)
+
+	var response map[string]interface{}
+	_ = json.Unmarshal(rec.Body.Bytes(), &response)
+	response["status"] = rec.Code
+	return response
+}
+
+func TestRainAuthorizationReplayProtection(t *testing.T) {
+	h := NewRainHandler(config.RainConfig{WebhookSecret: rainTestSecret, MaxTimestampSkew: 5 * time.Minute}, newMemoryRainStore())
+	now := time.Now()
+
+	t.Run("fresh request is approved", func(t *testing.T) {
+		resp := requestAuthorization(h, `{"authorization_id":"auth_1","amount":10}`, now)
+		assert.Equal(t, http.StatusOK, resp["status"])
+		assert.Equal(t, true, resp["approved"])
+		assert.Equal(t, "auth_1", resp["authorization_id"])
+	})
+
+	t.Run("exact replay is declined", func(t *testing.T) {
+		resp := requestAuthorization(h, `{"authorization_id":"auth_1","amount":10}`, now)
+		assert.Equal(t, http.StatusOK, resp["status"])
+		assert.Equal(t, false, resp["approved"])
+		assert.Equal(t, declineDuplicateRequest, resp["reason"])
+	})
+
+	t.Run("stale request is declined", func(t *testing.T) {
+		resp := requestAuthorization(h, `{"authorization_id":"auth_2","amount":10}`, now.Add(-6*time.Minute))
+		assert.Equal(t, http.StatusOK, resp["status"])
+		assert.Equal(t, false, resp["approved"])
+		assert.Equal(t, declineStaleRequest, resp["reason"])
+	})
+
+	t.Run("far future request is declined", func(t *testing.T) {
+		resp := requestAuthorization(h, `{"authorization_id":"auth_3","amount":10}`, now.Add(6*time.Minute))
+		assert.Equal(t, false, resp["approved"])
+		assert.Equal(t, declineStaleRequest, resp["reason"])
+	})
+}
+
+func TestRainWebhookIsIdempotent(t *testing.T) {
+	events := newMemoryRainStore()
+	h := NewRainHandler(config.RainConfig{WebhookSecret: rainTestSecret}, events)
+	body := `{"event_id":"evt_3","event_type":"card.created"}`
+
+	deliver := func() int {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/rain", bytes.NewBufferString(body))
+		req.Header.Set(rainSignatureHeader, signRain(rainTestSecret, timestamp, body))
+		req.Header.Set(rainTimestampHeader, timestamp)
+		rec := httptest.NewRecorder()
+		h.HandleWebhook(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, deliver())
+	assert.Equal(t, http.StatusOK, deliver())
+
+	event := events.event(rainSource, "evt_3")
+	if assert.NotNil(t, event) {
+		assert.NotNil(t, event.ProcessedAt)
+	}
+}
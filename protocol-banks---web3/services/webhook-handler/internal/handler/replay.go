@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/protocol-bank/webhook-handler/internal/store"
+	"github.com/rs/zerolog/log"
+)
+
+// adminSecretHeader 管理端点的 API 密钥请求头
+const adminSecretHeader = "X-Admin-Secret"
+
+// 单次回放的事件数量
+const (
+	defaultReplayLimit = 100
+	maxReplayLimit     = 1000
+)
+
+// ReplayStore 回放所需的事件存储，由 store.WebhookStore 实现
+type ReplayStore interface {
+	EventStore
+	ListEvents(ctx context.Context, filter store.EventFilter) ([]store.WebhookEvent, error)
+}
+
+// ReplayRequest 回放请求：提供方必填，时间与 ID 区间可选
+type ReplayRequest struct {
+	Provider string     `json:"provider"`
+	From     *time.Time `json:"from,omitempty"`   // received_at >= from（RFC 3339）
+	To       *time.Time `json:"to,omitempty"`     // received_at < to
+	FromID   int64      `json:"fromId,omitempty"` // webhook_events.id >= fromId
+	ToID     int64      `json:"toId,omitempty"`   // webhook_events.id <= toId
+	// Force 为 true 时连已处理的事件也重新处理；否则只处理 processed_at 为空的事件
+	Force bool `json:"force"`
+	Limit int  `json:"limit,omitempty"` // 默认 100，最大 1000
+}
+
+// ReplayResult 单个事件的回放结果
+type ReplayResult struct {
+	ID         int64  `json:"id"`
+	ExternalID string `json:"externalId"`
+	EventType  string `json:"eventType"`
+	Status     string `json:"status"` // replayed / skipped / failed
+	Error      string `json:"error,omitempty"`
+}
+
+// ReplayResponse 回放汇总
+type ReplayResponse struct {
+	Replayed int            `json:"replayed"`
+	Skipped  int            `json:"skipped"`
+	Failed   int            `json:"failed"`
+	Events   []ReplayResult `json:"events"`
+}
+
+// ReplayHandler 重新处理 webhook_events 中的历史事件
+// 修复下游处理的缺陷后，用同一套 Handle 逻辑重放已存储的负载；回放不经过 Redis 幂等键。
+type ReplayHandler struct {
+	registry *Registry
+	events   ReplayStore
+	secret   string
+}
+
+// NewReplayHandler 创建回放处理器；secret 为空时拒绝所有请求
+func NewReplayHandler(registry *Registry, events ReplayStore, secret string) *ReplayHandler {
+	return &ReplayHandler{registry: registry, events: events, secret: secret}
+}
+
+// Routes 在 /admin 下挂载路由
+func (h *ReplayHandler) Routes(r chi.Router) {
+	r.Post("/webhooks/replay", h.HandleReplay)
+}
+
+// authorized 校验 X-Admin-Secret（常量时间比较）
+func (h *ReplayHandler) authorized(r *http.Request) bool {
+	given := r.Header.Get(adminSecretHeader)
+	return h.secret != "" && subtle.ConstantTimeCompare([]byte(given), []byte(h.secret)) == 1
+}
+
+// HandleReplay 处理 POST /admin/webhooks/replay
+func (h *ReplayHandler) HandleReplay(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		log.Warn().Str("remote_addr", r.RemoteAddr).Msg("Rejected webhook replay request")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req ReplayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	provider, ok := h.registry.Provider(req.Provider)
+	if !ok {
+		http.Error(w, "Unknown provider", http.StatusBadRequest)
+		return
+	}
+
+	filter := store.EventFilter{Provider: req.Provider, FromID: req.FromID, ToID: req.ToID, Limit: req.Limit}
+	if req.From != nil {
+		filter.From = *req.From
+	}
+	if req.To != nil {
+		filter.To = *req.To
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultReplayLimit
+	}
+	if filter.Limit > maxReplayLimit {
+		filter.Limit = maxReplayLimit
+	}
+
+	events, err := h.events.ListEvents(r.Context(), filter)
+	if err != nil {
+		log.Error().Err(err).Str("provider", req.Provider).Msg("Failed to list webhook events for replay")
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Info().
+		Str("provider", req.Provider).
+		Bool("force", req.Force).
+		Int("events", len(events)).
+		Msg("Replaying webhook events")
+
+	resp := ReplayResponse{Events: make([]ReplayResult, 0, len(events))}
+	for _, stored := range events {
+		result := h.replay(r.Context(), provider, stored, req.Force)
+		switch result.Status {
+		case "replayed":
+			resp.Replayed++
+		case "skipped":
+			resp.Skipped++
+		default:
+			resp.Failed++
+		}
+		resp.Events = append(resp.Events, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// replay 重新处理一个已存储的事件，成功后标记为已处理
+func (h *ReplayHandler) replay(ctx context.Context, provider WebhookProvider, stored store.WebhookEvent, force bool) ReplayResult {
+	result := ReplayResult{ID: stored.ID, ExternalID: stored.ExternalID, EventType: stored.EventType}
+	logger := log.With().
+		Str("provider", stored.Provider).
+		Int64("event_id", stored.ID).
+		Str("idempotency_key", generateIdempotencyKey(stored.Provider, stored.ExternalID)).
+		Str("event_type", stored.EventType).
+		Bool("force", force).
+		Logger()
+
+	if stored.ProcessedAt != nil && !force {
+		result.Status = "skipped"
+		logger.Info().Msg("Webhook event already processed, skipping replay")
+		return result
+	}
+
+	// 已存储的负载是验签通过后的原始请求体
+	event := &Event{
+		Provider:   stored.Provider,
+		Type:       stored.EventType,
+		ExternalID: stored.ExternalID,
+		Body:       stored.Payload,
+		Payload:    stored.Payload,
+	}
+	if err := provider.Handle(ctx, event); err != nil {
+		WebhookProcessed.WithLabelValues(stored.Provider, stored.EventType, "replay_failed").Inc()
+		result.Status, result.Error = "failed", err.Error()
+		logger.Error().Err(err).Msg("Webhook event replay failed")
+		return result
+	}
+
+	markCtx, cancel := bookkeepingContext(ctx)
+	defer cancel()
+	if err := h.events.MarkProcessed(markCtx, stored.Provider, stored.ExternalID); err != nil {
+		// 处理已完成，只是未能记录；不算回放失败
+		logger.Error().Err(err).Msg("Failed to mark replayed webhook event as processed")
+	}
+
+	WebhookProcessed.WithLabelValues(stored.Provider, stored.EventType, "replayed").Inc()
+	result.Status = "replayed"
+	logger.Info().Msg("Replayed webhook event")
+	return result
+}
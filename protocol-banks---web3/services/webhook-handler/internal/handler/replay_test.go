@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/protocol-bank/webhook-handler/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const adminTestSecret = "admin-secret"
+
+// newReplayRouter 挂载 Transak 提供方与回放端点，completed 统计 ORDER_COMPLETED 副作用的执行次数
+func newReplayRouter(t *testing.T, events *memoryEventStore) (*TransakHandler, http.Handler, *int) {
+	t.Helper()
+	transak := NewTransakHandler(config.TransakConfig{WebhookSecret: transakTestSecret}, events)
+	completed := new(int)
+	transak.orderHandlers["ORDER_COMPLETED"] = func(ctx context.Context, order TransakOrder) error {
+		*completed++
+		return nil
+	}
+
+	registry := NewRegistry()
+	require.NoError(t, registry.Register(transak))
+	r := chi.NewRouter()
+	r.Route("/admin", NewReplayHandler(registry, events, adminTestSecret).Routes)
+	return transak, r, completed
+}
+
+func postReplay(router http.Handler, secret string, req ReplayRequest) (int, ReplayResponse) {
+	body, _ := json.Marshal(req)
+	httpReq := httptest.NewRequest(http.MethodPost, "/admin/webhooks/replay", bytes.NewReader(body))
+	httpReq.Header.Set(adminSecretHeader, secret)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httpReq)
+
+	var resp ReplayResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec.Code, resp
+}
+
+func TestReplayReprocessesStoredEvent(t *testing.T) {
+	events := newMemoryEventStore()
+	transak, router, completed := newReplayRouter(t, events)
+
+	body := transakBody("wh_replay", "ORDER_COMPLETED", "order_r")
+	require.Equal(t, http.StatusOK, deliverTransakWebhook(transak, body))
+	require.Equal(t, 1, *completed)
+
+	// 已处理的事件不加 force 时跳过
+	code, resp := postReplay(router, adminTestSecret, ReplayRequest{Provider: "transak"})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, resp.Skipped)
+	assert.Equal(t, 1, *completed)
+
+	// force 绕过幂等键，副作用再次执行
+	code, resp = postReplay(router, adminTestSecret, ReplayRequest{Provider: "transak", Force: true})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, resp.Replayed)
+	require.Len(t, resp.Events, 1)
+	assert.Equal(t, "wh_replay", resp.Events[0].ExternalID)
+	assert.Equal(t, 2, *completed, "replay must run the side effect again")
+
+	// 正常重投递仍然去重
+	require.Equal(t, http.StatusOK, deliverTransakWebhook(transak, body))
+	assert.Equal(t, 2, *completed)
+}
+
+func TestReplayProcessesFailedEventAndFiltersByID(t *testing.T) {
+	events := newMemoryEventStore()
+	transak, router, completed := newReplayRouter(t, events)
+
+	// 处理失败的事件留在日志中，processed_at 为空
+	transak.orderHandlers["ORDER_FAILED"] = func(ctx context.Context, order TransakOrder) error {
+		return errors.New("downstream bug")
+	}
+	require.Equal(t, http.StatusInternalServerError, deliverTransakWebhook(transak, transakBody("wh_f1", "ORDER_FAILED", "order_1")))
+	require.Equal(t, http.StatusOK, deliverTransakWebhook(transak, transakBody("wh_c2", "ORDER_COMPLETED", "order_2")))
+	first := events.event(transakSource, "wh_f1")
+	require.Nil(t, first.ProcessedAt)
+
+	// 修复缺陷后按 ID 区间回放
+	transak.orderHandlers["ORDER_FAILED"] = func(ctx context.Context, order TransakOrder) error { return nil }
+	code, resp := postReplay(router, adminTestSecret, ReplayRequest{Provider: "transak", FromID: first.ID, ToID: first.ID})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, ReplayResponse{Replayed: 1, Events: []ReplayResult{{ID: first.ID, ExternalID: "wh_f1", EventType: "ORDER_FAILED", Status: "replayed"}}}, resp)
+	assert.NotNil(t, events.event(transakSource, "wh_f1").ProcessedAt)
+	assert.Equal(t, 1, *completed, "events outside the range are not replayed")
+}
+
+func TestReplayRequiresSecretAndKnownProvider(t *testing.T) {
+	_, router, _ := newReplayRouter(t, newMemoryEventStore())
+
+	code, _ := postReplay(router, "wrong", ReplayRequest{Provider: "transak"})
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = postReplay(router, "", ReplayRequest{Provider: "transak"})
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = postReplay(router, adminTestSecret, ReplayRequest{Provider: "moonpay"})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// 未配置密钥时拒绝所有请求
+	disabled := chi.NewRouter()
+	disabled.Route("/admin", NewReplayHandler(NewRegistry(), newMemoryEventStore(), "").Routes)
+	code, _ = postReplay(disabled, "", ReplayRequest{Provider: "transak"})
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
@@ -1,15 +1,16 @@
 package handler
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"io"
+	"fmt"
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/protocol-bank/webhook-handler/internal/config"
-	"github.com/protocol-bank/webhook-handler/internal/store"
 	"github.com/rs/zerolog/log"
 )
 
@@ -35,55 +36,116 @@ type TransakOrder struct {
 	CompletedAt    string  `json:"completedAt"`
 }
 
+// transakRequiredFields Transak 负载的必填字段，指针区分缺失与零值
+type transakRequiredFields struct {
+	EventType *string `json:"eventType"`
+	Data      *struct {
+		OrderID      *string  `json:"id"`
+		Status       *string  `json:"status"`
+		FiatCurrency *string  `json:"fiatCurrency"`
+		FiatAmount   *float64 `json:"fiatAmount"`
+	} `json:"data"`
+}
+
+// validateTransakPayload 校验必填字段：事件类型与订单 ID、状态、法币金额和币种
+func validateTransakPayload(body []byte) error {
+	var fields transakRequiredFields
+	if err := decodePayload(transakSource, "", body, &fields); err != nil {
+		return err
+	}
+	check := fieldChecker{provider: transakSource}
+	check.requireString("eventType", fields.EventType)
+	if fields.Data == nil {
+		check.fail("data", "is missing")
+		return check.Err()
+	}
+	check.requireString("data.id", fields.Data.OrderID)
+	check.requireString("data.status", fields.Data.Status)
+	check.requireAmount("data.fiatAmount", fields.Data.FiatAmount, true)
+	check.requireString("data.fiatCurrency", fields.Data.FiatCurrency)
+	return check.Err()
+}
+
+// transakSource Transak 提供方名称
+const transakSource = "transak"
+
 // TransakHandler Transak Webhook 处理器
 type TransakHandler struct {
 	cfg   config.TransakConfig
-	store *store.WebhookStore
+	store EventStore
+
+	// orderHandlers 按事件类型分发订单处理
+	orderHandlers map[string]func(ctx context.Context, order TransakOrder) error
 }
 
 // NewTransakHandler 创建 Transak 处理器
-func NewTransakHandler(cfg config.TransakConfig, store *store.WebhookStore) *TransakHandler {
-	return &TransakHandler{
+func NewTransakHandler(cfg config.TransakConfig, store EventStore) *TransakHandler {
+	h := &TransakHandler{
 		cfg:   cfg,
 		store: store,
 	}
+	h.orderHandlers = map[string]func(ctx context.Context, order TransakOrder) error{
+		"ORDER_COMPLETED":  h.handleOrderCompleted,
+		"ORDER_PROCESSING": h.handleOrderProcessing,
+		"ORDER_FAILED":     h.handleOrderFailed,
+		"ORDER_CANCELLED":  h.handleOrderCancelled,
+	}
+	return h
+}
+
+// transakEventID 取 Transak 事件 ID；缺失时退化为 事件类型+订单 ID+状态，同一状态变更的重投递得到相同的键
+func transakEventID(payload TransakWebhookPayload) string {
+	if payload.WebhookID != "" {
+		return payload.WebhookID
+	}
+	return payload.EventType + ":" + payload.Data.OrderID + ":" + payload.Data.Status
+}
+
+// Name 提供方名称
+func (h *TransakHandler) Name() string {
+	return transakSource
+}
+
+// Routes 挂载 Transak 路由
+func (h *TransakHandler) Routes(r chi.Router) {
+	r.Post("/transak", h.HandleWebhook)
 }
 
 // HandleWebhook 处理 Transak Webhook
 func (h *TransakHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to read request body")
-		http.Error(w, "Bad request", http.StatusBadRequest)
-		return
-	}
+	ServeWebhook(w, r, h, h.store)
+}
 
-	// 验证签名
+// Verify 验证签名并解析 Transak 事件
+func (h *TransakHandler) Verify(r *http.Request, body []byte) (*Event, error) {
 	signature := r.Header.Get("X-Transak-Signature")
 	if !h.verifySignature(body, signature) {
-		log.Warn().Str("signature", signature).Msg("Invalid Transak webhook signature")
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
+		return nil, fmt.Errorf("%w: invalid Transak signature", ErrUnauthorized)
 	}
 
+	// 校验必填字段，残缺或类型不符的负载不进入处理流程
+	if err := validateTransakPayload(body); err != nil {
+		return nil, err
+	}
 	var payload TransakWebhookPayload
-	if err := json.Unmarshal(body, &payload); err != nil {
-		log.Error().Err(err).Msg("Failed to parse webhook payload")
-		http.Error(w, "Bad request", http.StatusBadRequest)
-		return
+	if err := decodePayload(transakSource, "", body, &payload); err != nil {
+		return nil, err
 	}
 
-	// 检查重复
-	processed, err := h.store.IsProcessed(r.Context(), payload.WebhookID)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to check duplicate")
-		http.Error(w, "Internal error", http.StatusInternalServerError)
-		return
-	}
-	if processed {
-		log.Info().Str("webhook_id", payload.WebhookID).Msg("Duplicate webhook")
-		w.WriteHeader(http.StatusOK)
-		return
+	return &Event{
+		Provider:   transakSource,
+		Type:       payload.EventType,
+		ExternalID: transakEventID(payload),
+		Body:       body,
+		Payload:    body,
+	}, nil
+}
+
+// Handle 按事件类型分发订单处理
+func (h *TransakHandler) Handle(ctx context.Context, event *Event) error {
+	var payload TransakWebhookPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid Transak payload: %w", err)
 	}
 
 	log.Info().
@@ -92,25 +154,17 @@ func (h *TransakHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		Str("order_id", payload.Data.OrderID).
 		Msg("Processing Transak webhook")
 
-	switch payload.EventType {
-	case "ORDER_COMPLETED":
-		h.handleOrderCompleted(r.Context(), payload.Data)
-	case "ORDER_PROCESSING":
-		h.handleOrderProcessing(r.Context(), payload.Data)
-	case "ORDER_FAILED":
-		h.handleOrderFailed(r.Context(), payload.Data)
-	case "ORDER_CANCELLED":
-		h.handleOrderCancelled(r.Context(), payload.Data)
-	default:
+	handle, ok := h.orderHandlers[payload.EventType]
+	if !ok {
 		log.Warn().Str("event_type", payload.EventType).Msg("Unknown Transak event type")
+		return nil
 	}
 
-	if err := h.store.MarkProcessed(r.Context(), payload.WebhookID, string(body)); err != nil {
-		log.Error().Err(err).Msg("Failed to mark as processed")
+	// 安全点：副作用开始前检查取消
+	if err := ctx.Err(); err != nil {
+		return err
 	}
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	return handle(ctx, payload.Data)
 }
 
 // verifySignature 验证签名
@@ -126,7 +180,7 @@ func (h *TransakHandler) verifySignature(body []byte, signature string) bool {
 	return hmac.Equal([]byte(signature), []byte(expectedSig))
 }
 
-func (h *TransakHandler) handleOrderCompleted(ctx interface{}, order TransakOrder) {
+func (h *TransakHandler) handleOrderCompleted(ctx context.Context, order TransakOrder) error {
 	log.Info().
 		Str("order_id", order.OrderID).
 		Float64("fiat_amount", order.FiatAmount).
@@ -135,16 +189,20 @@ func (h *TransakHandler) handleOrderCompleted(ctx interface{}, order TransakOrde
 		Str("tx_hash", order.TxHash).
 		Msg("Transak order completed")
 	// TODO: 更新用户购买记录
+	return nil
 }
 
-func (h *TransakHandler) handleOrderProcessing(ctx interface{}, order TransakOrder) {
+func (h *TransakHandler) handleOrderProcessing(ctx context.Context, order TransakOrder) error {
 	log.Info().Str("order_id", order.OrderID).Msg("Transak order processing")
+	return nil
 }
 
-func (h *TransakHandler) handleOrderFailed(ctx interface{}, order TransakOrder) {
+func (h *TransakHandler) handleOrderFailed(ctx context.Context, order TransakOrder) error {
 	log.Warn().Str("order_id", order.OrderID).Msg("Transak order failed")
+	return nil
 }
 
-func (h *TransakHandler) handleOrderCancelled(ctx interface{}, order TransakOrder) {
+func (h *TransakHandler) handleOrderCancelled(ctx context.Context, order TransakOrder) error {
 	log.Info().Str("order_id", order.OrderID).Msg("Transak order cancelled")
+	return nil
 }
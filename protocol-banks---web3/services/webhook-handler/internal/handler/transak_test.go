@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/protocol-bank/webhook-handler/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const transakTestSecret = "transak-secret"
+
+func signedTransakRequest(body string) *http.Request {
+	mac := hmac.New(sha256.New, []byte(transakTestSecret))
+	mac.Write([]byte(body))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/transak", bytes.NewBufferString(body))
+	req.Header.Set("X-Transak-Signature", hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+// transakBody 构造带齐必填字段的 Transak 负载
+func transakBody(webhookID, eventType, orderID string) string {
+	return fmt.Sprintf(`{"webhookId":%q,"eventType":%q,"data":{"id":%q,"status":"PROCESSING","fiatCurrency":"USD","fiatAmount":100}}`, webhookID, eventType, orderID)
+}
+
+func deliverTransakWebhook(h *TransakHandler, body string) int {
+	rec := httptest.NewRecorder()
+	h.HandleWebhook(rec, signedTransakRequest(body))
+	return rec.Code
+}
+
+func TestTransakWebhookIsIdempotent(t *testing.T) {
+	h := NewTransakHandler(config.TransakConfig{WebhookSecret: transakTestSecret}, newMemoryEventStore())
+
+	completed := 0
+	h.orderHandlers["ORDER_COMPLETED"] = func(ctx context.Context, order TransakOrder) error {
+		completed++
+		return nil
+	}
+
+	body := transakBody("wh_1", "ORDER_COMPLETED", "order_1")
+
+	assert.Equal(t, http.StatusOK, deliverTransakWebhook(h, body))
+	assert.Equal(t, http.StatusOK, deliverTransakWebhook(h, body))
+	assert.Equal(t, 1, completed, "side effect must run once for a redelivered webhook")
+}
+
+func TestTransakWebhookReleasesKeyOnFailure(t *testing.T) {
+	h := NewTransakHandler(config.TransakConfig{WebhookSecret: transakTestSecret}, newMemoryEventStore())
+
+	calls := 0
+	h.orderHandlers["ORDER_COMPLETED"] = func(ctx context.Context, order TransakOrder) error {
+		calls++
+		if calls == 1 {
+			return errors.New("downstream unavailable")
+		}
+		return nil
+	}
+
+	body := transakBody("wh_2", "ORDER_COMPLETED", "order_2")
+
+	require.Equal(t, http.StatusInternalServerError, deliverTransakWebhook(h, body))
+	assert.Equal(t, http.StatusOK, deliverTransakWebhook(h, body))
+	assert.Equal(t, http.StatusOK, deliverTransakWebhook(h, body))
+	assert.Equal(t, 2, calls, "a failed delivery must be retried, a successful one deduplicated")
+}
+
+func TestTransakEventIDFallback(t *testing.T) {
+	payload := TransakWebhookPayload{EventType: "ORDER_COMPLETED", Data: TransakOrder{OrderID: "order_3", Status: "COMPLETED"}}
+	assert.Equal(t, "ORDER_COMPLETED:order_3:COMPLETED", transakEventID(payload))
+
+	payload.WebhookID = "wh_3"
+	assert.Equal(t, "wh_3", transakEventID(payload))
+}
+
+func TestTransakWebhookPersistsEvent(t *testing.T) {
+	events := newMemoryEventStore()
+	h := NewTransakHandler(config.TransakConfig{WebhookSecret: transakTestSecret}, events)
+
+	body := transakBody("wh_4", "ORDER_PROCESSING", "order_4")
+	require.Equal(t, http.StatusOK, deliverTransakWebhook(h, body))
+
+	event := events.event(transakSource, "wh_4")
+	require.NotNil(t, event)
+	assert.Equal(t, "ORDER_PROCESSING", event.EventType)
+	assert.JSONEq(t, body, string(event.Payload))
+	assert.NotNil(t, event.ProcessedAt)
+}
@@ -3,6 +3,8 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -11,7 +13,23 @@ import (
 	"github.com/protocol-bank/webhook-handler/internal/config"
 )
 
+// ErrEventNotFound 事件不存在于 webhook_events 表
+var ErrEventNotFound = errors.New("webhook event not found")
+
+// WebhookEvent webhook_events 表中的一条事件记录
+type WebhookEvent struct {
+	ID          int64
+	Provider    string
+	EventType   string
+	ExternalID  string
+	Payload     json.RawMessage
+	ReceivedAt  time.Time
+	ProcessedAt *time.Time
+}
+
 // WebhookStore Webhook 存储
+// Postgres 的 webhook_events 表是持久的审计日志，Redis 只是去重缓存；
+// Redis 键被淘汰后以数据库中的 processed_at 为准。
 type WebhookStore struct {
 	db    *sql.DB
 	redis *redis.Client
@@ -46,32 +64,229 @@ func NewWebhookStore(ctx context.Context, dbURL string, redisCfg config.RedisCon
 	}, nil
 }
 
-// IsProcessed 检查是否已处理
-func (s *WebhookStore) IsProcessed(ctx context.Context, eventID string) (bool, error) {
-	key := fmt.Sprintf("webhook:processed:%s", eventID)
-	exists, err := s.redis.Exists(ctx, key).Result()
+// ClaimStatus 幂等键抢占结果
+type ClaimStatus int
+
+const (
+	// ClaimAcquired 首次收到该事件，调用方负责处理
+	ClaimAcquired ClaimStatus = iota
+	// ClaimDuplicate 事件已处理完成
+	ClaimDuplicate
+	// ClaimInFlight 事件正在被另一个请求处理
+	ClaimInFlight
+)
+
+const (
+	// processedTTL 已处理事件的保留时间
+	processedTTL = 7 * 24 * time.Hour
+	// claimTTL 处理中标记的过期时间，进程崩溃后允许重投递再次处理
+	claimTTL = 5 * time.Minute
+	// claimMarker 处理中标记的值
+	claimMarker = "processing"
+	// processedMarker 已处理标记的值（负载保存在 webhook_events 表中）
+	processedMarker = "processed"
+)
+
+// processedKey Redis 去重键，provider 与 externalID 组成幂等键 "<来源>:<事件 ID>"
+func processedKey(provider, externalID string) string {
+	return fmt.Sprintf("webhook:processed:%s:%s", provider, externalID)
+}
+
+// StoreEvent 将收到的事件追加写入 webhook_events 表
+// 同一 provider/externalID 只保留首次收到的记录，重投递返回已有记录。
+func (s *WebhookStore) StoreEvent(ctx context.Context, provider, eventType, externalID string, payload []byte) (*WebhookEvent, error) {
+	query := `
+		INSERT INTO webhook_events (provider, event_type, external_id, payload, received_at)
+		VALUES ($1, $2, $3, $4::jsonb, NOW())
+		ON CONFLICT (provider, external_id) DO NOTHING
+	`
+	if _, err := s.db.ExecContext(ctx, query, provider, eventType, externalID, string(payload)); err != nil {
+		return nil, fmt.Errorf("failed to store webhook event: %w", err)
+	}
+	return s.GetEvent(ctx, provider, externalID)
+}
+
+// GetEvent 查询事件记录
+func (s *WebhookStore) GetEvent(ctx context.Context, provider, externalID string) (*WebhookEvent, error) {
+	return getEvent(ctx, s.db, provider, externalID, "")
+}
+
+// EventFilter 按提供方与时间/ID 区间筛选 webhook_events，零值条件不参与筛选
+type EventFilter struct {
+	Provider string
+	From     time.Time // received_at >= From
+	To       time.Time // received_at < To
+	FromID   int64     // id >= FromID
+	ToID     int64     // id <= ToID
+	Limit    int
+}
+
+// ListEvents 按 ID 升序（即接收顺序）返回符合条件的事件
+func (s *WebhookStore) ListEvents(ctx context.Context, filter EventFilter) ([]WebhookEvent, error) {
+	query := `
+		SELECT id, provider, event_type, external_id, payload, received_at, processed_at
+		FROM webhook_events
+		WHERE provider = $1
+	`
+	args := []interface{}{filter.Provider}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		query += fmt.Sprintf(" AND %s $%d", condition, len(args))
+	}
+	if !filter.From.IsZero() {
+		addCondition("received_at >=", filter.From)
+	}
+	if !filter.To.IsZero() {
+		addCondition("received_at <", filter.To)
+	}
+	if filter.FromID > 0 {
+		addCondition("id >=", filter.FromID)
+	}
+	if filter.ToID > 0 {
+		addCondition("id <=", filter.ToID)
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY id LIMIT $%d", len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []WebhookEvent
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list webhook events: %w", err)
+		}
+		events = append(events, *event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list webhook events: %w", err)
+	}
+	return events, nil
+}
+
+// queryer 由 *sql.DB 与 *sql.Tx 实现
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func getEvent(ctx context.Context, q queryer, provider, externalID, lockClause string) (*WebhookEvent, error) {
+	query := `
+		SELECT id, provider, event_type, external_id, payload, received_at, processed_at
+		FROM webhook_events
+		WHERE provider = $1 AND external_id = $2
+	` + lockClause
+
+	event, err := scanEvent(q.QueryRowContext(ctx, query, provider, externalID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEventNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook event: %w", err)
+	}
+	return event, nil
+}
+
+// scanner 由 *sql.Row 与 *sql.Rows 实现
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanEvent 读取 SELECT id, provider, event_type, external_id, payload, received_at, processed_at 的一行
+func scanEvent(row scanner) (*WebhookEvent, error) {
+	var event WebhookEvent
+	var payload []byte
+	var processedAt sql.NullTime
+	if err := row.Scan(
+		&event.ID, &event.Provider, &event.EventType, &event.ExternalID, &payload, &event.ReceivedAt, &processedAt,
+	); err != nil {
+		return nil, err
+	}
+	event.Payload = payload
+	if processedAt.Valid {
+		event.ProcessedAt = &processedAt.Time
+	}
+	return &event, nil
+}
+
+// ClaimEvent 通过 SETNX 原子地抢占幂等键，保证并发重投递时只有一个请求执行处理
+// Redis 中没有记录时再核对数据库，避免 Redis 淘汰键后重复处理。
+func (s *WebhookStore) ClaimEvent(ctx context.Context, provider, externalID string) (ClaimStatus, error) {
+	key := processedKey(provider, externalID)
+	acquired, err := s.redis.SetNX(ctx, key, claimMarker, claimTTL).Result()
+	if err != nil {
+		return 0, err
+	}
+	if acquired {
+		event, err := s.GetEvent(ctx, provider, externalID)
+		if err != nil && !errors.Is(err, ErrEventNotFound) {
+			s.redis.Del(ctx, key)
+			return 0, err
+		}
+		if event != nil && event.ProcessedAt != nil {
+			// 恢复被淘汰的去重键
+			if err := s.redis.Set(ctx, key, processedMarker, processedTTL).Err(); err != nil {
+				return 0, err
+			}
+			return ClaimDuplicate, nil
+		}
+		return ClaimAcquired, nil
+	}
+
+	value, err := s.redis.Get(ctx, key).Result()
+	if err == redis.Nil {
+		// 标记恰好过期，按处理中返回，由提供方稍后重试
+		return ClaimInFlight, nil
+	}
 	if err != nil {
-		return false, err
+		return 0, err
 	}
-	return exists > 0, nil
+	if value == claimMarker {
+		return ClaimInFlight, nil
+	}
+	return ClaimDuplicate, nil
+}
+
+// ReleaseEvent 处理失败时释放幂等键，允许重投递再次处理
+func (s *WebhookStore) ReleaseEvent(ctx context.Context, provider, externalID string) error {
+	return s.redis.Del(ctx, processedKey(provider, externalID)).Err()
 }
 
 // MarkProcessed 标记为已处理
-func (s *WebhookStore) MarkProcessed(ctx context.Context, eventID, payload string) error {
-	key := fmt.Sprintf("webhook:processed:%s", eventID)
+// 先在事务中锁定并更新 webhook_events 的 processed_at，提交后才写 Redis，
+// 因此事件未持久化时绝不会被标记为已处理。
+func (s *WebhookStore) MarkProcessed(ctx context.Context, provider, externalID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	event, err := getEvent(ctx, tx, provider, externalID, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	if event.ProcessedAt == nil {
+		query := `UPDATE webhook_events SET processed_at = NOW() WHERE id = $1`
+		if _, err := tx.ExecContext(ctx, query, event.ID); err != nil {
+			return fmt.Errorf("failed to mark webhook event processed: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	// 保存 7 天
-	return s.redis.Set(ctx, key, payload, 7*24*time.Hour).Err()
+	return s.redis.Set(ctx, processedKey(provider, externalID), processedMarker, processedTTL).Err()
 }
 
-// SaveWebhook 保存 Webhook 记录到数据库
-func (s *WebhookStore) SaveWebhook(ctx context.Context, source, eventType, eventID, payload string) error {
-	query := `
-		INSERT INTO webhooks (source, event_type, event_id, payload, created_at)
-		VALUES ($1, $2, $3, $4, NOW())
-		ON CONFLICT (event_id) DO NOTHING
-	`
-	_, err := s.db.ExecContext(ctx, query, source, eventType, eventID, payload)
-	return err
+// MarkAuthorizationSeen 记录授权请求 ID，返回 false 表示该 ID 在 ttl 内已出现过（重放）
+func (s *WebhookStore) MarkAuthorizationSeen(ctx context.Context, authorizationID string, ttl time.Duration) (bool, error) {
+	key := fmt.Sprintf("rain:authorization:seen:%s", authorizationID)
+	return s.redis.SetNX(ctx, key, time.Now().Unix(), ttl).Result()
 }
 
 // Close 关闭连接
@@ -45,6 +45,26 @@ func DialEthClientWithTimeout(ctx context.Context, url string, disableHTTP2 bool
 	return ethclient.DialContext(ctxt, url)
 }
 
+// ErrChainIDMismatch RPC 节点所在链与 cp_chain_id 不一致，按配置签名的交易都会被拒绝
+var ErrChainIDMismatch = errors.New("rpc chain id does not match cp_chain_id")
+
+// ChainIDReader 查询 eth_chainId，*ethclient.Client 满足该接口
+type ChainIDReader interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+// CheckChainID 启动时校验 RPC 节点的 eth_chainId 与配置的链 ID 一致
+func CheckChainID(ctx context.Context, cli ChainIDReader, chainId uint64) error {
+	actual, err := cli.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get chain id, err: %v", err)
+	}
+	if !actual.IsUint64() || actual.Uint64() != chainId {
+		return fmt.Errorf("%w: configured %d, rpc returned %s", ErrChainIDMismatch, chainId, actual)
+	}
+	return nil
+}
+
 func NewTransactOpts(ctx context.Context, chainId uint64, privateKey *ecdsa.PrivateKey) (*bind.TransactOpts, error) {
 	var opts *bind.TransactOpts
 	var err error
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeChainID 返回固定的 eth_chainId
+type fakeChainID struct {
+	id  *big.Int
+	err error
+}
+
+func (c fakeChainID) ChainID(context.Context) (*big.Int, error) {
+	return c.id, c.err
+}
+
+func TestCheckChainID(t *testing.T) {
+	ctx := context.Background()
+	assert.NoError(t, CheckChainID(ctx, fakeChainID{id: big.NewInt(86606)}, 86606))
+
+	// 配置为 cpchain 测试网，RPC 却指向主网
+	err := CheckChainID(ctx, fakeChainID{id: big.NewInt(1)}, 86606)
+	assert.ErrorIs(t, err, ErrChainIDMismatch)
+	assert.ErrorContains(t, err, "configured 86606, rpc returned 1")
+
+	err = CheckChainID(ctx, fakeChainID{err: errors.New("connection refused")}, 86606)
+	assert.ErrorContains(t, err, "connection refused")
+	assert.NotErrorIs(t, err, ErrChainIDMismatch)
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/urfave/cli/v2"
@@ -18,6 +19,7 @@ import (
 	"github.com/cpchain-network/oracle-node/node"
 	"github.com/cpchain-network/oracle-node/node/conversion"
 	"github.com/cpchain-network/oracle-node/sign"
+	"github.com/cpchain-network/oracle-node/signer"
 	"github.com/cpchain-network/oracle-node/store"
 	"github.com/cpchain-network/oracle-node/ws/server"
 )
@@ -166,14 +168,9 @@ func runManager(ctx *cli.Context, shutdown context.CancelCauseFunc) (cliapp.Life
 		return nil, err
 	}
 
-	var privKey *ecdsa.PrivateKey
-	if ctx.IsSet(PrivateKeyFlagName) {
-		privKey, err = crypto.HexToECDSA(ctx.String(PrivateKeyFlagName))
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		return nil, errors.New("need to config private key")
+	txSigner, err := newManagerSigner(ctx, cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	db, err := store.NewStorage(cfg.Manager.LevelDbFolder)
@@ -185,7 +182,26 @@ func runManager(ctx *cli.Context, shutdown context.CancelCauseFunc) (cliapp.Life
 	if err != nil {
 		return nil, err
 	}
-	return manager.NewOracleManager(ctx.Context, db, wsServer, cfg, shutdown, logger, privKey)
+	return manager.NewOracleManager(ctx.Context, db, wsServer, cfg, shutdown, logger, txSigner)
+}
+
+// newManagerSigner 配置了外部签名服务时使用外部签名，否则使用 --private-key
+func newManagerSigner(ctx *cli.Context, cfg *config.Config) (signer.Signer, error) {
+	if cfg.Manager.SignerEndpoint != "" {
+		if !common.IsHexAddress(cfg.Manager.SignerAddress) {
+			return nil, fmt.Errorf("invalid signer address %q", cfg.Manager.SignerAddress)
+		}
+		return signer.NewExternalSigner(cfg.Manager.SignerEndpoint, common.HexToAddress(cfg.Manager.SignerAddress))
+	}
+
+	if !ctx.IsSet(PrivateKeyFlagName) {
+		return nil, errors.New("need to config private key")
+	}
+	privKey, err := crypto.HexToECDSA(ctx.String(PrivateKeyFlagName))
+	if err != nil {
+		return nil, err
+	}
+	return signer.NewPrivateKeySigner(privKey), nil
 }
 
 func runParsePeerID(ctx *cli.Context) error {
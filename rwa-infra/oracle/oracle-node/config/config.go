@@ -1,7 +1,9 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v2"
@@ -19,15 +21,31 @@ type Config struct {
 	CPUSDTPodAddress      string        `yaml:"cpusdt_pod_address"`
 	Caller                string        `yaml:"caller"`
 	PrivateKey            string        `yaml:"private_key"`
+
+	// 多品种喂价，配置后忽略 cpusdt_pod_address 与 node.data_source(s)；未配置时只有一个品种（symbol 为空）
+	Symbols []SymbolConfig `yaml:"symbols"`
+}
+
+// SymbolConfig 一个喂价品种：节点按 data_source(s) 取价签名，manager 提交到 oracle_pod_address
+type SymbolConfig struct {
+	Symbol           string             `yaml:"symbol"`
+	OraclePodAddress string             `yaml:"oracle_pod_address"`
+	DataSource       DataSourceConfig   `yaml:"data_source"`
+	DataSources      []DataSourceConfig `yaml:"data_sources"` // 配置后取中位数并忽略 data_source
 }
 
 type NodeConfig struct {
-	LevelDbFolder    string           `yaml:"level_db_folder"`
-	DataSource       DataSourceConfig `yaml:"data_source"` // 新增：通用数据源配置
-	KeyPath          string           `yaml:"key_path"`
-	WsAddr           string           `yaml:"ws_addr"`
-	SignTimeout      time.Duration    `yaml:"sign_timeout"`
-	WaitScanInterval time.Duration    `yaml:"wait_scan_interval"`
+	LevelDbFolder    string                `yaml:"level_db_folder"`
+	DataSource       DataSourceConfig      `yaml:"data_source"`      // 新增：通用数据源配置
+	DataSources      []DataSourceConfig    `yaml:"data_sources"`     // 多数据源，配置后取中位数并忽略 data_source
+	Aggregation      AggregationConfig     `yaml:"aggregation"`      // 多数据源聚合配置
+	PriceValidation  PriceValidationConfig `yaml:"price_validation"` // 签名前的价格校验
+	HTTPClient       HTTPClientConfig      `yaml:"http_client"`      // 数据源 HTTP 超时与重试
+	KeyPath          string                `yaml:"key_path"`
+	WsAddr           string                `yaml:"ws_addr"`
+	SignTimeout      time.Duration         `yaml:"sign_timeout"`
+	WaitScanInterval time.Duration         `yaml:"wait_scan_interval"`
+	MaxInFlightSigns int                   `yaml:"max_in_flight_signs"` // 同时取价签名的请求上限，超出时拒绝
 
 	// 保留旧配置兼容性（deprecated）
 	ExchangeConfig ExchangeConfig `yaml:"exchange_config"`
@@ -39,26 +57,105 @@ type ManagerConfig struct {
 	SubmitPriceTime time.Duration `yaml:"submit_price_time"`
 	SignTimeout     time.Duration `yaml:"sign_timeout"`
 	WsAddr          string        `yaml:"ws_addr"`
+	HttpAddr        string        `yaml:"http_addr"`
+
+	// 每次提交间隔在 submit_price_time 上随机偏移 ±submit_jitter，错开多个部署的上链时间；默认 0，超过 submit_price_time 的一半时按一半计
+	SubmitJitter time.Duration `yaml:"submit_jitter"`
+	// 上一轮提交结束到下一轮开始的最小间隔，避免签名耗时过长导致连续提交；默认 submit_price_time 的一半
+	MinSubmitInterval time.Duration `yaml:"min_submit_interval"`
+
+	// 法定签名比例 quorum_numerator/quorum_denominator（按活跃成员数向上取整），默认 2/3
+	QuorumNumerator   uint64 `yaml:"quorum_numerator"`
+	QuorumDenominator uint64 `yaml:"quorum_denominator"`
+
+	// 节点连续在线满 node_admission_grace 才参与签名，离线满 node_eviction_threshold 才被剔除；默认 0，即按当前连接状态
+	NodeAdmissionGrace    time.Duration `yaml:"node_admission_grace"`
+	NodeEvictionThreshold time.Duration `yaml:"node_eviction_threshold"`
+
+	// 停止时等待进行中的价格提交完成的最长时间，默认 30s
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+
+	// 提交交易的 max fee 上限（gwei），建议费用超过时跳过本轮；默认 0，不限制
+	MaxFeePerGasGwei uint64 `yaml:"max_fee_per_gas_gwei"`
+	// 交易 confirm_timeout 内未确认时以同一 nonce 提高 20% 费用重发，最多 max_fee_bumps 次；默认 60s、3 次，负数不重发
+	ConfirmTimeout time.Duration `yaml:"confirm_timeout"`
+	MaxFeeBumps    int           `yaml:"max_fee_bumps"`
+
+	// 配置 signer_endpoint 时由外部签名服务（clef 兼容）用 signer_address 签名交易，不再需要 --private-key
+	SignerEndpoint string `yaml:"signer_endpoint"`
+	SignerAddress  string `yaml:"signer_address"`
 }
 
 // DataSourceConfig 通用数据源配置
 type DataSourceConfig struct {
+	// 数据源类型: http（默认，通用 HTTP + JSONPath）, coingecko
+	Type string `yaml:"type"`
+
 	// 资产信息
 	AssetType string `yaml:"asset_type"` // 资产类型: stock, gold, oil, house, wine 等
 	AssetName string `yaml:"asset_name"` // 资产名称: 贵州茅台, 黄金现货 等
 
 	// HTTP 请求配置
-	URL     string            `yaml:"url"`     // 完整的 API URL
-	Method  string            `yaml:"method"`  // HTTP 方法，默认 GET
-	Headers map[string]string `yaml:"headers"` // 自定义请求头
+	URL     string               `yaml:"url"`     // 完整的 API URL
+	Method  string               `yaml:"method"`  // HTTP 方法，默认 GET
+	Headers map[string]string    `yaml:"headers"` // 自定义请求头
+	Body    string               `yaml:"body"`    // POST 请求体（可选）
+	Auth    DataSourceAuthConfig `yaml:"auth"`    // 需要鉴权的交易所 API
 
-	// 响应解析配置（使用 JSONPath）
+	// 响应解析配置，路径为 gjson 语法（"data.price"）或 JSONPath（"$.data[0].price"）
 	PricePath string `yaml:"price_path"` // 价格字段路径，如 "data.price"
 	BidPath   string `yaml:"bid_path"`   // 买价字段路径，如 "data.bid"（可选）
 	AskPath   string `yaml:"ask_path"`   // 卖价字段路径，如 "data.ask"（可选）
+	// 价格时间字段路径，如 "data.timestamp"（可选，Unix 秒/毫秒或 RFC3339），用于拒绝过期数据
+	TimestampPath string `yaml:"timestamp_path"`
 
 	// 价格精度
 	Decimals int `yaml:"decimals"` // 小数位数，默认 6
+
+	// CoinGecko 配置（type: coingecko），url 可选，用于覆盖默认 API 地址
+	CoinID     string `yaml:"coin_id"`     // CoinGecko 币种 ID，如 bitcoin, tether-gold
+	VsCurrency string `yaml:"vs_currency"` // 计价货币，默认 usd
+	APIKey     string `yaml:"api_key"`     // Demo API Key（可选）
+}
+
+// DataSourceAuthConfig 数据源鉴权，type 为空时不鉴权
+// api_key / api_secret 可写成 "${ENV_NAME}" 从环境变量读取，避免密钥明文写入配置文件
+type DataSourceAuthConfig struct {
+	// 鉴权方式: api_key（密钥放入请求头或查询参数）, bearer（Authorization: Bearer）,
+	// hmac（密钥放入请求头，并对 timestamp + METHOD + path?query + body 做 HMAC-SHA256 签名）
+	Type      string `yaml:"type"`
+	APIKey    string `yaml:"api_key"`
+	APISecret string `yaml:"api_secret"` // hmac 签名密钥
+
+	KeyHeader         string `yaml:"key_header"`         // 放置 api_key 的请求头，默认 X-API-KEY
+	KeyQuery          string `yaml:"key_query"`          // api_key 方式下改为放入该查询参数
+	SignatureHeader   string `yaml:"signature_header"`   // hmac 签名请求头，默认 X-SIGNATURE
+	TimestampHeader   string `yaml:"timestamp_header"`   // hmac 时间戳请求头（Unix 毫秒），默认 X-TIMESTAMP
+	SignatureEncoding string `yaml:"signature_encoding"` // hmac 签名编码: hex（默认）, base64
+}
+
+// AggregationConfig 多数据源聚合配置
+type AggregationConfig struct {
+	SourceTimeout time.Duration `yaml:"source_timeout"` // 单个数据源超时，默认 5s
+	MaxDeviation  float64       `yaml:"max_deviation"`  // 偏离中位数超过该百分比的数据源被剔除，默认 5
+	MinSources    int           `yaml:"min_sources"`    // 至少需要的有效数据源数，默认 1
+}
+
+// PriceValidationConfig 签名前的价格校验，非正价格总是拒绝，其余检查为 0 时不启用
+type PriceValidationConfig struct {
+	MaxDeviation    float64       `yaml:"max_deviation"`    // 与上次签名价格偏离超过该百分比时拒绝
+	DeviationWindow time.Duration `yaml:"deviation_window"` // 距上次签名超过该时间后不再检查偏离，0 表示总是检查
+	MaxAge          time.Duration `yaml:"max_age"`          // 数据源报告的价格时间早于该时长时拒绝（未报告时间的数据源不检查）
+
+	MaxProposalDeviation float64 `yaml:"max_proposal_deviation"` // manager 提议的聚合价与本轮上报价格偏离超过该百分比时拒绝签名
+}
+
+// HTTPClientConfig 数据源 HTTP 请求配置，包括重试在内的总时长不超过 node.sign_timeout
+type HTTPClientConfig struct {
+	Timeout         time.Duration `yaml:"timeout"`           // 单次请求超时，默认 5s
+	MaxRetries      int           `yaml:"max_retries"`       // 5xx 或连接错误时的重试次数，默认 2，负数不重试
+	RetryBackoff    time.Duration `yaml:"retry_backoff"`     // 首次重试等待，之后指数增长，默认 200ms
+	MaxRetryBackoff time.Duration `yaml:"max_retry_backoff"` // 重试等待上限，默认 2s
 }
 
 // ExchangeConfig 旧配置（保留兼容性）
@@ -79,15 +176,75 @@ func NewConfig(path string) (*Config, error) {
 	}
 
 	// 设置默认值
-	if config.Node.DataSource.Method == "" {
-		config.Node.DataSource.Method = "GET"
+	config.Node.DataSource.setDefaults()
+	for i := range config.Node.DataSources {
+		config.Node.DataSources[i].setDefaults()
+	}
+	seen := make(map[string]struct{}, len(config.Symbols))
+	for i := range config.Symbols {
+		symbol := &config.Symbols[i]
+		if symbol.Symbol == "" {
+			return nil, fmt.Errorf("symbols[%d]: symbol is required", i)
+		}
+		if _, ok := seen[symbol.Symbol]; ok {
+			return nil, fmt.Errorf("symbols[%d]: duplicate symbol %q", i, symbol.Symbol)
+		}
+		seen[symbol.Symbol] = struct{}{}
+		symbol.DataSource.setDefaults()
+		for j := range symbol.DataSources {
+			symbol.DataSources[j].setDefaults()
+		}
+	}
+	if config.Node.Aggregation.SourceTimeout == 0 {
+		config.Node.Aggregation.SourceTimeout = 5 * time.Second
+	}
+	if config.Node.Aggregation.MaxDeviation == 0 {
+		config.Node.Aggregation.MaxDeviation = 5
+	}
+	if config.Node.Aggregation.MinSources == 0 {
+		config.Node.Aggregation.MinSources = 1
+	}
+	if config.Node.MaxInFlightSigns <= 0 {
+		config.Node.MaxInFlightSigns = 16
+	}
+	if config.Manager.MinSubmitInterval == 0 {
+		config.Manager.MinSubmitInterval = config.Manager.SubmitPriceTime / 2
+	}
+	if config.Manager.ConfirmTimeout == 0 {
+		config.Manager.ConfirmTimeout = time.Minute
 	}
-	if config.Node.DataSource.Decimals == 0 {
-		config.Node.DataSource.Decimals = 6
+	if config.Manager.MaxFeeBumps == 0 {
+		config.Manager.MaxFeeBumps = 3
 	}
-	if config.Node.DataSource.PricePath == "" {
-		config.Node.DataSource.PricePath = "data.price"
+	if config.Manager.ShutdownTimeout == 0 {
+		config.Manager.ShutdownTimeout = 30 * time.Second
+	}
+	if config.Manager.QuorumNumerator == 0 || config.Manager.QuorumDenominator == 0 {
+		config.Manager.QuorumNumerator = 2
+		config.Manager.QuorumDenominator = 3
 	}
 
 	return config, nil
 }
+
+func (c *DataSourceConfig) setDefaults() {
+	if c.Method == "" {
+		c.Method = "GET"
+	}
+	if c.Decimals == 0 {
+		c.Decimals = 6
+	}
+	if c.PricePath == "" {
+		c.PricePath = "data.price"
+	}
+	c.Auth.APIKey = expandEnvRef(c.Auth.APIKey)
+	c.Auth.APISecret = expandEnvRef(c.Auth.APISecret)
+}
+
+// expandEnvRef 整个值为 "${NAME}" 时读取环境变量 NAME，否则原样返回
+func expandEnvRef(value string) string {
+	if strings.HasPrefix(value, "${") && strings.HasSuffix(value, "}") {
+		return os.Getenv(value[2 : len(value)-1])
+	}
+	return value
+}
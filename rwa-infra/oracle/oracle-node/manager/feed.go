@@ -0,0 +1,31 @@
+package manager
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/cpchain-network/oracle-node/bindings/oracle"
+	"github.com/cpchain-network/oracle-node/config"
+)
+
+// priceFeed 一个喂价品种及其价格提交到的 oracle pod
+type priceFeed struct {
+	symbol string
+	pod    common.Address
+}
+
+// newPriceFeeds 按配置的 symbols 生成喂价品种；未配置时只有 symbol 为空、提交到 cpusdt_pod_address 的一个品种
+func newPriceFeeds(cfg *config.Config) []priceFeed {
+	if len(cfg.Symbols) == 0 {
+		return []priceFeed{{pod: common.HexToAddress(cfg.CPUSDTPodAddress)}}
+	}
+	feeds := make([]priceFeed, 0, len(cfg.Symbols))
+	for _, symbol := range cfg.Symbols {
+		feeds = append(feeds, priceFeed{symbol: symbol.Symbol, pod: common.HexToAddress(symbol.OraclePodAddress)})
+	}
+	return feeds
+}
+
+// priceSubmitter 将聚合签名后的价格提交到 oracle pod，返回交易哈希
+type priceSubmitter interface {
+	submitBatch(pod common.Address, batch oracle.IOracleManagerOracleBatch, signature oracle.IBLSApkRegistryOracleNonSignerAndSignature) (common.Hash, error)
+}
@@ -0,0 +1,121 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cpchain-network/oracle-node/bindings/oracle"
+	"github.com/cpchain-network/oracle-node/manager/types"
+)
+
+type submittedBatch struct {
+	pod   common.Address
+	batch oracle.IOracleManagerOracleBatch
+}
+
+// fakeSubmitter 记录提交的批次，不发送交易
+type fakeSubmitter struct {
+	batches []submittedBatch
+}
+
+func (s *fakeSubmitter) submitBatch(pod common.Address, batch oracle.IOracleManagerOracleBatch, _ oracle.IBLSApkRegistryOracleNonSignerAndSignature) (common.Hash, error) {
+	s.batches = append(s.batches, submittedBatch{pod: pod, batch: batch})
+	return common.BigToHash(common.Big1), nil
+}
+
+var (
+	goldPod = common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	oilPod  = common.HexToAddress("0x00000000000000000000000000000000000000a2")
+)
+
+func newFeedManager(t *testing.T, nodes []*testNode) (*Manager, *fakeSubmitter) {
+	t.Helper()
+	m := newQuorumManager(t, nodes)
+	submitter := &fakeSubmitter{}
+	m.submitter = submitter
+	m.feeds = []priceFeed{{symbol: "GOLD", pod: goldPod}, {symbol: "OIL", pod: oilPod}}
+	return m, submitter
+}
+
+func TestSubmitFeedsPerSymbol(t *testing.T) {
+	nodes := []*testNode{
+		newTestNode(t, 0, true, true),
+		newTestNode(t, 0, true, true),
+		newTestNode(t, 0, true, true),
+	}
+	for _, n := range nodes {
+		n.prices = map[string]float64{"GOLD": 2402, "OIL": 81}
+	}
+	m, submitter := newFeedManager(t, nodes)
+
+	m.submitFeeds(testRequest)
+
+	require.Len(t, submitter.batches, 2)
+	expected := map[common.Address]struct {
+		symbol string
+		price  string
+	}{
+		goldPod: {"GOLD", "2402000000"},
+		oilPod:  {"OIL", "81000000"},
+	}
+	for _, submitted := range submitter.batches {
+		e, ok := expected[submitted.pod]
+		require.True(t, ok, "unexpected pod %s", submitted.pod)
+		assert.Equal(t, e.price, submitted.batch.SymbolPrice, e.symbol)
+
+		request := testRequest
+		request.Symbol = e.symbol
+		assert.Equal(t, types.PriceMessageHash(types.PriceMessage(e.price, request)), common.Hash(submitted.batch.MsgHash), e.symbol)
+		delete(expected, submitted.pod)
+	}
+	assert.Equal(t, uint64(2), m.batchId)
+}
+
+func TestSubmitFeedWithDifferingNodePrices(t *testing.T) {
+	nodes := []*testNode{
+		newTestNode(t, 2391.57, true, true),
+		newTestNode(t, 2392.10, true, true),
+		newTestNode(t, 2390.00, true, true),
+	}
+	m, submitter := newFeedManager(t, nodes)
+	request := testRequest
+	request.Symbol = "GOLD"
+
+	// 聚合签名校验不通过时 submitFeed 返回错误且不提交
+	require.NoError(t, m.submitFeed(m.feeds[0], request))
+
+	require.Len(t, submitter.batches, 1)
+	assert.Equal(t, "2391223333", submitter.batches[0].batch.SymbolPrice)
+	assert.Equal(t, types.PriceMessageHash(types.PriceMessage("2391223333", request)), common.Hash(submitter.batches[0].batch.MsgHash))
+	assert.Len(t, m.lastSubmission.signers, 3)
+}
+
+func TestSubmitFeedsIndependently(t *testing.T) {
+	nodes := []*testNode{
+		newTestNode(t, 0, true, true),
+		newTestNode(t, 0, true, true),
+		newTestNode(t, 0, true, true),
+	}
+	// 只有一个节点能给出 OIL 价格，低于法定数
+	nodes[0].prices = map[string]float64{"GOLD": 2400, "OIL": 80}
+	nodes[1].prices = map[string]float64{"GOLD": 2400}
+	nodes[2].prices = map[string]float64{"GOLD": 2400}
+	m, submitter := newFeedManager(t, nodes)
+
+	m.submitFeeds(testRequest)
+
+	require.Len(t, submitter.batches, 1)
+	assert.Equal(t, goldPod, submitter.batches[0].pod)
+	assert.Equal(t, "2400000000", submitter.batches[0].batch.SymbolPrice)
+	assert.Equal(t, uint64(1), m.batchId)
+
+	// 品种顺序不影响：OIL 先于 GOLD 时 GOLD 仍然提交
+	m, submitter = newFeedManager(t, nodes)
+	m.feeds[0], m.feeds[1] = m.feeds[1], m.feeds[0]
+	m.submitFeeds(testRequest)
+	require.Len(t, submitter.batches, 1)
+	assert.Equal(t, goldPod, submitter.batches[0].pod)
+}
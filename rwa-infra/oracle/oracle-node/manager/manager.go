@@ -2,14 +2,12 @@ package manager
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
 	"math/rand"
 	"net/http"
-	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -32,6 +30,7 @@ import (
 	"github.com/cpchain-network/oracle-node/manager/router"
 	"github.com/cpchain-network/oracle-node/manager/types"
 	"github.com/cpchain-network/oracle-node/sign"
+	"github.com/cpchain-network/oracle-node/signer"
 	"github.com/cpchain-network/oracle-node/store"
 	"github.com/cpchain-network/oracle-node/synchronizer"
 	"github.com/cpchain-network/oracle-node/ws/server"
@@ -40,6 +39,9 @@ import (
 var (
 	errNotEnoughSignNode = errors.New("not enough available nodes to sign")
 	errNotEnoughSignal   = errors.New("not enough available nodes to signal")
+
+	errInvalidNodeSignature       = errors.New("node signature does not match its price message")
+	errInvalidAggregatedSignature = errors.New("aggregated signature does not match the price message")
 )
 
 type Manager struct {
@@ -50,14 +52,17 @@ type Manager struct {
 	wsServer           server.IWebsocketManager
 	NodeMembers        []string
 	httpAddr           string
+	lastSubmission     *submission
 	httpServer         *http.Server
 	mu                 sync.Mutex
 	ctx                context.Context
 	stopped            atomic.Bool
 	ethChainID         uint64
-	privateKey         *ecdsa.PrivateKey
+	signer             signer.Signer
 	from               common.Address
 	ethClient          *ethclient.Client
+	txClient           txClient
+	fees               feePolicy
 	oracleContract     *oracle.OracleManager
 	oracleContractAddr common.Address
 	rawOracleContract  *bind.BoundContract
@@ -67,18 +72,27 @@ type Manager struct {
 	batchId            uint64
 	isFirstBatch       bool
 	signTimeout        time.Duration
-	submitPriceTime    time.Duration
+	shutdownTimeout    time.Duration
+	quorumNumerator    uint64
+	quorumDenominator  uint64
+	schedule           *submitSchedule
+	nextSubmit         time.Time // 下一轮提交时间，由 mu 保护
+	health             *nodeHealthTracker
 	synchronizer       *synchronizer.Synchronizer
 	eventProcessor     *synchronizer.EventProcess
 	contractEventChan  chan store.ContractEvent
-	cpUSDTPodAddr      common.Address
+	feeds              []priceFeed
+	submitter          priceSubmitter
 }
 
-func NewOracleManager(ctx context.Context, db *store.Storage, wsServer server.IWebsocketManager, cfg *config.Config, shutdown context.CancelCauseFunc, logger log.Logger, priv *ecdsa.PrivateKey) (*Manager, error) {
+func NewOracleManager(ctx context.Context, db *store.Storage, wsServer server.IWebsocketManager, cfg *config.Config, shutdown context.CancelCauseFunc, logger log.Logger, txSigner signer.Signer) (*Manager, error) {
 	ethCli, err := client.DialEthClientWithTimeout(ctx, cfg.CpChainRpc, false)
 	if err != nil {
 		return nil, err
 	}
+	if err := client.CheckChainID(ctx, ethCli, cfg.CpChainID); err != nil {
+		return nil, err
+	}
 
 	log.Info("oracle manage address", "OracleManagerAddress", cfg.OracleManagerAddress)
 
@@ -115,7 +129,7 @@ func NewOracleManager(ctx context.Context, db *store.Storage, wsServer server.IW
 
 	cOpts := &bind.CallOpts{
 		BlockNumber: big.NewInt(int64(latestBlock)),
-		From:        crypto.PubkeyToAddress(priv.PublicKey),
+		From:        txSigner.Address(),
 	}
 
 	batchId, err := oracleContract.ConfirmBatchId(cOpts)
@@ -130,6 +144,11 @@ func NewOracleManager(ctx context.Context, db *store.Storage, wsServer server.IW
 		}
 	}
 
+	health, err := newNodeHealthTracker(db, cfg.Manager.NodeAdmissionGrace, cfg.Manager.NodeEvictionThreshold, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load node health, err: %v", err)
+	}
+
 	contractEventChan := make(chan store.ContractEvent, 100)
 
 	cpChainSynchronizer, err := synchronizer.NewSynchronizer(cfg, db, ctx, logger, contractEventChan, shutdown)
@@ -144,19 +163,26 @@ func NewOracleManager(ctx context.Context, db *store.Storage, wsServer server.IW
 		return nil, err
 	}
 
-	return &Manager{
+	m := &Manager{
 		done:               make(chan struct{}),
 		log:                logger,
 		db:                 db,
 		wsServer:           wsServer,
 		NodeMembers:        nodeMemberS,
 		ctx:                ctx,
-		privateKey:         priv,
-		from:               crypto.PubkeyToAddress(priv.PublicKey),
+		httpAddr:           cfg.Manager.HttpAddr,
+		signer:             txSigner,
+		from:               txSigner.Address(),
 		signTimeout:        cfg.Manager.SignTimeout,
-		submitPriceTime:    cfg.Manager.SubmitPriceTime,
+		shutdownTimeout:    cfg.Manager.ShutdownTimeout,
+		quorumNumerator:    cfg.Manager.QuorumNumerator,
+		quorumDenominator:  cfg.Manager.QuorumDenominator,
+		schedule:           newSubmitSchedule(cfg.Manager.SubmitPriceTime, cfg.Manager.SubmitJitter, cfg.Manager.MinSubmitInterval),
+		health:             health,
 		ethChainID:         cfg.CpChainID,
 		ethClient:          ethCli,
+		txClient:           ethCli,
+		fees:               newFeePolicy(cfg.Manager.MaxFeePerGasGwei, cfg.Manager.ConfirmTimeout, cfg.Manager.MaxFeeBumps),
 		oracleContract:     oracleContract,
 		oracleContractAddr: common.HexToAddress(cfg.OracleManagerAddress),
 		rawOracleContract:  rawOracleContract,
@@ -167,8 +193,10 @@ func NewOracleManager(ctx context.Context, db *store.Storage, wsServer server.IW
 		synchronizer:       cpChainSynchronizer,
 		eventProcessor:     eventProcessor,
 		contractEventChan:  contractEventChan,
-		cpUSDTPodAddr:      common.HexToAddress(cfg.CPUSDTPodAddress),
-	}, nil
+		feeds:              newPriceFeeds(cfg),
+	}
+	m.submitter = m
+	return m, nil
 }
 
 func (m *Manager) Start(ctx context.Context) error {
@@ -189,7 +217,7 @@ func (m *Manager) Start(ctx context.Context) error {
 		}
 	}
 
-	registry := router.NewRegistry(m, m.db)
+	registry := router.NewRegistry(m, m, m.db)
 	r := gin.Default()
 	registry.Register(r)
 
@@ -200,7 +228,7 @@ func (m *Manager) Start(ctx context.Context) error {
 	}
 
 	go func() {
-		if err := s.ListenAndServe(); err != nil && errors.Is(err, http.ErrServerClosed) {
+		if err := s.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			m.log.Error("api server starts failed", "err", err)
 		}
 	}()
@@ -228,15 +256,53 @@ func (m *Manager) Start(ctx context.Context) error {
 	return nil
 }
 
+// Stop 不再开始新的提交，等待进行中的一批提交完成（最长 shutdownTimeout）后关闭 http 服务
 func (m *Manager) Stop(ctx context.Context) error {
 	close(m.done)
-	if err := m.httpServer.Shutdown(ctx); err != nil {
-		m.log.Error("http server forced to shutdown", "err", err)
-		return err
+
+	waitErr := m.waitSubmissions(ctx)
+	if m.httpServer != nil {
+		if err := m.httpServer.Shutdown(ctx); err != nil {
+			m.log.Error("http server forced to shutdown", "err", err)
+			return err
+		}
 	}
 	m.stopped.Store(true)
 	m.log.Info("Server exiting")
-	return nil
+	return waitErr
+}
+
+// waitSubmissions 等待 work 循环退出，超时后放弃等待
+func (m *Manager) waitSubmissions(ctx context.Context) error {
+	if m.shutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.shutdownTimeout)
+		defer cancel()
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		m.log.Warn("stopped waiting for in-flight price submission", "err", ctx.Err())
+		return fmt.Errorf("wait for in-flight price submission: %w", ctx.Err())
+	}
+}
+
+// stopping Stop 已被调用，不再开始新的提交
+func (m *Manager) stopping() bool {
+	select {
+	case <-m.done:
+		return true
+	default:
+		return false
+	}
 }
 
 func (m *Manager) Stopped() bool {
@@ -244,118 +310,158 @@ func (m *Manager) Stopped() bool {
 }
 
 func (m *Manager) work() {
-	fpTicker := time.NewTicker(m.submitPriceTime)
 	defer m.wg.Done()
 
+	// 首轮同样加随机偏移，同时启动的实例不会同时提交
+	next := time.Now().Add(m.schedule.jittered())
+	m.setNextSubmit(next)
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+
 	for {
 		select {
-		case <-fpTicker.C:
-			requestBody, err := m.signMarketPriceSignal()
-			if err != nil || requestBody == nil {
-				m.log.Error("failed to get market price sign signal fail", "err", err)
-				continue
+		case <-timer.C:
+			// 与 done 同时就绪时 select 随机选择，停止后不再开始新一轮
+			if m.stopping() {
+				return
 			}
-			m.log.Info("success to fetch sign signal", "RequestId", requestBody.RequestId, "blockNumber", requestBody.BlockNumber)
+			roundStart := time.Now()
+			m.submitPrice()
+			next := m.schedule.next(roundStart, time.Now())
+			m.setNextSubmit(next)
+			m.log.Info("next price submission scheduled", "at", next)
+			timer.Reset(time.Until(next))
+		case <-m.done:
+			return
+		}
+	}
+}
 
-			var signature *sign.G1Point
-			var g2Point *sign.G2Point
+func (m *Manager) setNextSubmit(t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextSubmit = t
+}
 
-			var NonSignerPubkeys []oracle.BN254G1Point
+// submitPrice 执行一轮价格提交：各品种分别收集节点签名、聚合并提交到对应 pod，单个品种失败不影响其他品种
+func (m *Manager) submitPrice() {
+	requestBody, err := m.signMarketPriceSignal()
+	if err != nil || requestBody == nil {
+		m.log.Error("failed to get market price sign signal fail", "err", err)
+		return
+	}
+	m.log.Info("success to fetch sign signal", "RequestId", requestBody.RequestId, "blockNumber", requestBody.BlockNumber)
+	m.submitFeeds(*requestBody)
+}
 
-			res, err := m.NotifyNodeSubmitPriceWithSignature(*requestBody)
-			if err != nil {
-				log.Error("sign batch fail", "err", err)
-				continue
-			}
+// submitFeeds 按品种依次签名并提交，各品种使用同一块高与 requestId
+func (m *Manager) submitFeeds(requestBody types.RequestBody) {
+	for _, feed := range m.feeds {
+		if m.stopping() {
+			m.log.Info("manager stopping, skip remaining symbols", "symbol", feed.symbol)
+			return
+		}
+		request := requestBody
+		request.Symbol = feed.symbol
+		if err := m.submitFeed(feed, request); err != nil {
+			m.log.Error("failed to submit price", "symbol", feed.symbol, "pod", feed.pod, "err", err)
+		}
+	}
+}
 
-			// 改动：使用价格数组计算加权平均（用于签名消息）
-			avgPrice := res.CalculateWeightedAverage()
-			avgPriceStr := fmt.Sprintf("%f", avgPrice)
-
-			m.log.Info("collected prices from nodes",
-				"priceCount", len(res.Prices),
-				"prices", res.Prices,
-				"weights", res.Weights,
-				"weightedAverage", avgPrice)
-
-			marketPriceMessage := avgPriceStr + requestBody.RequestId + strconv.Itoa(int(requestBody.BlockNumber))
-			m.log.Info("success to sign message", "signature", res.Signature, "msg", marketPriceMessage)
-
-			signature = res.Signature
-			g2Point = res.G2Point
-			for _, v := range res.NonSignerPubkeys {
-				NonSignerPubkeys = append(NonSignerPubkeys, oracle.BN254G1Point{
-					X: v.X.BigInt(new(big.Int)),
-					Y: v.Y.BigInt(new(big.Int)),
-				})
-			}
+// submitFeed 收集单个品种的节点价格，再让节点对加权平均价签名、聚合并提交到其 pod
+// 链上只校验一个 msgHash，各节点必须签同一价格，聚合签名才能通过校验
+func (m *Manager) submitFeed(feed priceFeed, request types.RequestBody) error {
+	var NonSignerPubkeys []oracle.BN254G1Point
 
-			m.log.Info("oracle caller", "address", crypto.PubkeyToAddress(m.privateKey.PublicKey))
+	reports, err := m.NotifyNodeSubmitPriceWithSignature(request)
+	if err != nil {
+		return fmt.Errorf("sign batch fail: %w", err)
+	}
 
-			opts, err := client.NewTransactOpts(m.ctx, m.ethChainID, m.privateKey)
-			if err != nil {
-				m.log.Error("failed to new transact opts", "err", err)
-				continue
-			}
+	// 改动：使用价格数组计算加权平均（用于签名消息）
+	// 使用与合约相同的整数算法，保证签名的价格与链上聚合结果一致
+	avgPrice := reports.CalculateWeightedAverageScaled(types.PriceDecimals)
+	avgPriceStr := avgPrice.String()
+
+	m.log.Info("collected prices from nodes",
+		"symbol", feed.symbol,
+		"priceCount", len(reports.Prices),
+		"prices", reports.Prices,
+		"weights", reports.Weights,
+		"weightedAverage", avgPriceStr,
+		"decimals", types.PriceDecimals)
+
+	// 第二轮：各节点校验提议价与自己上报的价格足够接近后对其签名
+	request.ProposedPrice = avgPriceStr
+	res, err := m.NotifyNodeSubmitPriceWithSignature(request)
+	if err != nil {
+		return fmt.Errorf("sign proposed price fail: %w", err)
+	}
 
-			// 改动：使用计算的加权平均价格
-			oracleBatch := oracle.IOracleManagerOracleBatch{
-				SymbolPrice: avgPriceStr,
-				BlockHash:   common.Hash{},
-				BlockNumber: big.NewInt(0),
-				MsgHash:     crypto.Keccak256Hash(common.Hex2Bytes(marketPriceMessage)),
-			}
+	marketPriceMessage := types.PriceMessage(avgPriceStr, request)
+	msgHash := types.PriceMessageHash(marketPriceMessage)
+	m.log.Info("success to sign message", "signature", res.Signature, "msg", marketPriceMessage)
+
+	signature := res.Signature
+	g2Point := res.G2Point
+	for _, v := range res.NonSignerPubkeys {
+		NonSignerPubkeys = append(NonSignerPubkeys, oracle.BN254G1Point{
+			X: v.X.BigInt(new(big.Int)),
+			Y: v.Y.BigInt(new(big.Int)),
+		})
+	}
 
-			oracleNonSignerAndSignature := oracle.IBLSApkRegistryOracleNonSignerAndSignature{
-				NonSignerPubkeys: NonSignerPubkeys,
-				ApkG2: oracle.BN254G2Point{
-					X: [2]*big.Int{g2Point.X.A1.BigInt(new(big.Int)), g2Point.X.A0.BigInt(new(big.Int))},
-					Y: [2]*big.Int{g2Point.Y.A1.BigInt(new(big.Int)), g2Point.Y.A0.BigInt(new(big.Int))},
-				},
-				Sigma: oracle.BN254G1Point{
-					X: signature.X.BigInt(new(big.Int)),
-					Y: signature.Y.BigInt(new(big.Int)),
-				},
-				TotalStake: big.NewInt(0),
-			}
+	// 改动：使用计算的加权平均价格
+	oracleBatch := oracle.IOracleManagerOracleBatch{
+		SymbolPrice: avgPriceStr,
+		BlockHash:   common.Hash{},
+		BlockNumber: big.NewInt(0),
+		MsgHash:     msgHash,
+	}
 
-			// 改动：使用加权平均价格验证签名
-			signatureIsValid, err := sign.VerifySig(signature.G1Affine, g2Point.G2Affine, crypto.Keccak256Hash(common.Hex2Bytes(avgPriceStr)))
-			if err != nil {
-				m.log.Error("failed to check signature is valid", "err", err)
-				continue
-			}
-			m.log.Info("signature verification", "isValid", signatureIsValid, "avgPrice", avgPriceStr)
+	oracleNonSignerAndSignature := oracle.IBLSApkRegistryOracleNonSignerAndSignature{
+		NonSignerPubkeys: NonSignerPubkeys,
+		ApkG2: oracle.BN254G2Point{
+			X: [2]*big.Int{g2Point.X.A1.BigInt(new(big.Int)), g2Point.X.A0.BigInt(new(big.Int))},
+			Y: [2]*big.Int{g2Point.Y.A1.BigInt(new(big.Int)), g2Point.Y.A0.BigInt(new(big.Int))},
+		},
+		Sigma: oracle.BN254G1Point{
+			X: signature.X.BigInt(new(big.Int)),
+			Y: signature.Y.BigInt(new(big.Int)),
+		},
+		TotalStake: big.NewInt(0),
+	}
 
-			tx, err := m.oracleContract.FillSymbolPriceWithSignature(opts, m.cpUSDTPodAddr, oracleBatch, oracleNonSignerAndSignature)
-			if err != nil {
-				m.log.Error("failed to craft VerifyOracleSignature transaction", "err", err)
-				continue
-			}
-			rTx, err := m.rawOracleContract.RawTransact(opts, tx.Data())
-			if err != nil {
-				m.log.Error("failed to raw VerifyOracleSignature transaction", "err", err)
-				continue
-			}
-			err = m.ethClient.SendTransaction(m.ctx, tx)
-			if err != nil {
-				m.log.Error("failed to send VerifyOracleSignature transaction", "err", err)
-				continue
-			}
+	// 验证聚合签名确实是对链上校验的同一消息的签名
+	signatureIsValid, err := sign.VerifySig(signature.G1Affine, g2Point.G2Affine, msgHash)
+	if err != nil {
+		return fmt.Errorf("failed to check signature is valid: %w", err)
+	}
+	m.log.Info("signature verification", "isValid", signatureIsValid, "msg", marketPriceMessage)
+	if !signatureIsValid {
+		m.log.Error("aggregated signature does not match the price message, skipping submission",
+			"msg", marketPriceMessage, "prices", reports.Prices)
+		return errInvalidAggregatedSignature
+	}
 
-			receipt, err := client.GetTransactionReceipt(m.ctx, m.ethClient, rTx.Hash())
-			if err != nil {
-				m.log.Error("failed to get verify finality transaction receipt", "err", err)
-				continue
-			}
+	txHash, err := m.submitter.submitBatch(feed.pod, oracleBatch, oracleNonSignerAndSignature)
+	if err != nil {
+		return err
+	}
 
-			m.log.Info("success to send verify finality signature transaction", "tx_hash", receipt.TxHash.String())
+	m.log.Info("success to send verify finality signature transaction", "symbol", feed.symbol, "tx_hash", txHash.String())
 
-			m.batchId++
-		case <-m.done:
-			return
-		}
-	}
+	m.recordSubmission(submission{
+		batchId:         m.batchId,
+		aggregatedPrice: avgPriceStr,
+		signers:         res.Signers,
+		txHash:          txHash,
+		time:            time.Now(),
+	})
+
+	m.batchId++
+	return nil
 }
 
 func (m *Manager) NotifyNodeSubmitPriceWithSignature(request types.RequestBody) (*types.SignResult, error) {
@@ -370,12 +476,13 @@ func (m *Manager) NotifyNodeSubmitPriceWithSignature(request types.RequestBody)
 	for _, mbr := range activeMember.Members {
 		m.log.Info("address is", "member", mbr)
 	}
+	quorum := m.quorumSize(len(activeMember.Members))
 	availableNodes := m.availableNodes(activeMember.Members)
-	if len(availableNodes) == 0 {
-		m.log.Warn("not enough sign node", "availableNodes", availableNodes)
+	if len(availableNodes) == 0 || len(availableNodes) < quorum {
+		m.log.Warn("not enough sign node", "availableNodes", availableNodes, "quorum", quorum)
 		return nil, errNotEnoughSignNode
 	}
-	ctx := types.NewContext().WithAvailableNodes(availableNodes).WithRequestId(randomRequestId())
+	ctx := types.NewContext().WithAvailableNodes(availableNodes).WithRequestId(randomRequestId()).WithQuorum(quorum)
 	var resp types.SignResult
 	var signErr error
 	resp, signErr = m.sign(ctx, request, types.NotifyNodeSubmitPriceWithSignature)
@@ -386,31 +493,74 @@ func (m *Manager) NotifyNodeSubmitPriceWithSignature(request types.RequestBody)
 		return nil, errNotEnoughSignal
 	}
 
+	resp.NonSignerPubkeys, err = m.nonSignerPubkeys(activeMember.Members, resp.Signers)
+	if err != nil {
+		m.log.Error("failed to collect non-signer pubkeys", "err", err)
+		return nil, err
+	}
+	m.log.Info("signing quorum reached", "signers", len(resp.Signers), "nonSigners", len(resp.NonSignerPubkeys), "quorum", quorum)
+
 	return &resp, nil
 }
 
-func (m *Manager) availableNodes(nodeMembers []string) []string {
-	aliveNodes := m.wsServer.AliveNodes()
-	m.log.Info("check available nodes", "expected", fmt.Sprintf("%v", nodeMembers), "alive nodes", fmt.Sprintf("%v", aliveNodes))
-	availableNodes := make([]string, 0)
-	for _, n := range aliveNodes {
-		pubkeyBytes, err := hex.DecodeString(n)
+// quorumSize 活跃成员中需要签名的最少节点数，向上取整
+func (m *Manager) quorumSize(members int) int {
+	if m.quorumDenominator == 0 {
+		return members
+	}
+	return int((uint64(members)*m.quorumNumerator + m.quorumDenominator - 1) / m.quorumDenominator)
+}
+
+// nonSignerPubkeys 返回未参与聚合签名的活跃成员在 BLSApkRegistry 注册的 G1 公钥，
+// 合约用聚合公钥减去这些公钥得到签名者公钥。未注册公钥的成员不在聚合公钥中，直接跳过。
+func (m *Manager) nonSignerPubkeys(members []string, signers []string) ([]*sign.G1Point, error) {
+	signed := make([]string, 0, len(signers))
+	for _, node := range signers {
+		address, err := nodeAddress(node)
 		if err != nil {
+			return nil, err
+		}
+		signed = append(signed, address.String())
+	}
+
+	var pubkeys []*sign.G1Point
+	for _, member := range members {
+		member = strings.TrimSpace(member)
+		if ExistsIgnoreCase(signed, member) {
 			continue
 		}
-		pubkey, err := crypto.DecompressPubkey(pubkeyBytes)
+		pubkey, found, err := m.db.GetOperatorPubkeyG1(common.HexToAddress(member))
 		if err != nil {
+			return nil, err
+		}
+		if !found {
+			m.log.Warn("non-signer has no registered bls pubkey", "member", member)
 			continue
 		}
-		address := crypto.PubkeyToAddress(*pubkey)
+		pubkeys = append(pubkeys, sign.NewG1Point(pubkey.X, pubkey.Y))
+	}
+	return pubkeys, nil
+}
 
-		log.Info("public key to address", "address", address.String())
+// availableNodes 已准入签名的成员节点，准入/剔除规则见 nodeHealthTracker
+func (m *Manager) availableNodes(nodeMembers []string) []string {
+	aliveNodes := m.wsServer.AliveNodes()
+	availableNodes := m.health.observe(aliveNodes, nodeMembers)
+	m.log.Info("check available nodes", "expected", fmt.Sprintf("%v", nodeMembers), "alive nodes", fmt.Sprintf("%v", aliveNodes), "available nodes", fmt.Sprintf("%v", availableNodes))
+	return availableNodes
+}
 
-		if ExistsIgnoreCase(nodeMembers, address.String()) {
-			availableNodes = append(availableNodes, n)
-		}
+// nodeAddress 节点 ID（压缩公钥 hex）对应的地址
+func nodeAddress(node string) (common.Address, error) {
+	pubkeyBytes, err := hex.DecodeString(node)
+	if err != nil {
+		return common.Address{}, err
 	}
-	return availableNodes
+	pubkey, err := crypto.DecompressPubkey(pubkeyBytes)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubkey), nil
 }
 
 func randomRequestId() string {
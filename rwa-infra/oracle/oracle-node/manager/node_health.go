@@ -0,0 +1,148 @@
+package manager
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/cpchain-network/oracle-node/manager/types"
+	"github.com/cpchain-network/oracle-node/store"
+)
+
+// nodeHealthTracker 对节点连接状态做滞回：连续在线满 admitAfter 才参与签名，
+// 离线满 evictAfter 才被剔除，短暂断线重连的节点不会在签名轮之间反复进出
+type nodeHealthTracker struct {
+	mu         sync.Mutex
+	db         *store.Storage
+	log        log.Logger
+	admitAfter time.Duration
+	evictAfter time.Duration
+	nodes      map[string]*store.NodeHealth
+	now        func() time.Time
+}
+
+// newNodeHealthTracker 从 db 恢复上次记录的节点状态
+func newNodeHealthTracker(db *store.Storage, admitAfter, evictAfter time.Duration, logger log.Logger) (*nodeHealthTracker, error) {
+	healths, err := db.GetNodeHealths()
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(map[string]*store.NodeHealth, len(healths))
+	for i := range healths {
+		nodes[healths[i].Node] = &healths[i]
+	}
+	return &nodeHealthTracker{
+		db:         db,
+		log:        logger,
+		admitAfter: admitAfter,
+		evictAfter: evictAfter,
+		nodes:      nodes,
+		now:        time.Now,
+	}, nil
+}
+
+// observe 按本次在线节点更新状态，返回可参与签名的节点
+func (t *nodeHealthTracker) observe(alive []string, nodeMembers []string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	aliveSet := make(map[string]struct{}, len(alive))
+	for _, n := range alive {
+		address, err := nodeAddress(n)
+		if err != nil {
+			continue
+		}
+		if !ExistsIgnoreCase(nodeMembers, address.String()) {
+			continue
+		}
+		aliveSet[n] = struct{}{}
+
+		health, ok := t.nodes[n]
+		if !ok {
+			health = &store.NodeHealth{Node: n, Address: address.String()}
+			t.nodes[n] = health
+		}
+		if health.AliveSince.IsZero() {
+			health.AliveSince = now
+		}
+		health.LastSeen = now
+		if !health.Available && now.Sub(health.AliveSince) >= t.admitAfter {
+			health.Available = true
+			t.log.Info("node admitted", "node", n, "address", health.Address, "aliveSince", health.AliveSince)
+		}
+		t.persist(health)
+	}
+
+	for n, health := range t.nodes {
+		if _, ok := aliveSet[n]; ok {
+			continue
+		}
+		changed := !health.AliveSince.IsZero()
+		health.AliveSince = time.Time{}
+		if health.Available && now.Sub(health.LastSeen) >= t.evictAfter {
+			health.Available = false
+			changed = true
+			t.log.Warn("node evicted", "node", n, "address", health.Address, "lastSeen", health.LastSeen)
+		}
+		if changed {
+			t.persist(health)
+		}
+	}
+
+	available := make([]string, 0)
+	for n, health := range t.nodes {
+		if health.Available && ExistsIgnoreCase(nodeMembers, health.Address) {
+			available = append(available, n)
+		}
+	}
+	sort.Strings(available)
+	return available
+}
+
+func (t *nodeHealthTracker) persist(health *store.NodeHealth) {
+	if err := t.db.SetNodeHealth(*health); err != nil {
+		t.log.Warn("failed to persist node health", "node", health.Node, "err", err)
+	}
+}
+
+// memberHealth 按成员地址给出最近一次观察到的状态，未连接过的成员只有地址
+func (t *nodeHealthTracker) memberHealth(members []string) []types.MemberHealth {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make([]types.MemberHealth, 0, len(members))
+	for _, member := range members {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+		mh := types.MemberHealth{Address: member}
+		for _, health := range t.nodes {
+			if !strings.EqualFold(health.Address, member) {
+				continue
+			}
+			// 同一地址换了连接时以最近在线的记录为准
+			if mh.Node != "" && health.LastSeen.Unix() < mh.LastSeen {
+				continue
+			}
+			mh.Node = health.Node
+			mh.Alive = !health.AliveSince.IsZero()
+			mh.Available = health.Available
+			mh.AliveSince = unixOrZero(health.AliveSince)
+			mh.LastSeen = unixOrZero(health.LastSeen)
+		}
+		result = append(result, mh)
+	}
+	return result
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
@@ -0,0 +1,132 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cpchain-network/oracle-node/manager/types"
+	"github.com/cpchain-network/oracle-node/store"
+)
+
+// newHealthManager 使用可控时钟的 manager，准入宽限 10s，剔除阈值 30s
+func newHealthManager(t *testing.T, db *store.Storage, nodes []*testNode, clock *time.Time) *Manager {
+	t.Helper()
+	ws := &fakeWsServer{nodes: make(map[string]*testNode)}
+	for _, n := range nodes {
+		ws.nodes[n.id] = n
+	}
+	health, err := newNodeHealthTracker(db, 10*time.Second, 30*time.Second, log.Root())
+	require.NoError(t, err)
+	health.now = func() time.Time { return *clock }
+	return &Manager{log: log.Root(), db: db, wsServer: ws, health: health}
+}
+
+func TestNodeHealthFlapping(t *testing.T) {
+	db, err := store.NewStorage("")
+	require.NoError(t, err)
+	stable := newTestNode(t, 100, true, true)
+	flapping := newTestNode(t, 100, false, true)
+	members := []string{stable.address, flapping.address}
+
+	clock := time.Unix(1760000000, 0)
+	m := newHealthManager(t, db, []*testNode{stable, flapping}, &clock)
+	at := func(offset time.Duration) []string {
+		clock = time.Unix(1760000000, 0).Add(offset)
+		return m.availableNodes(members)
+	}
+
+	// 刚连接的节点在宽限期内不参与签名
+	assert.Empty(t, at(0))
+	assert.Equal(t, []string{stable.id}, at(10*time.Second))
+
+	// 宽限期内掉线重连，重新计时
+	flapping.online = true
+	assert.Equal(t, []string{stable.id}, at(12*time.Second))
+	flapping.online = false
+	assert.Equal(t, []string{stable.id}, at(15*time.Second))
+	flapping.online = true
+	assert.Equal(t, []string{stable.id}, at(18*time.Second))
+	assert.Equal(t, []string{stable.id}, at(27*time.Second))
+	assert.ElementsMatch(t, []string{stable.id, flapping.id}, at(28*time.Second))
+
+	// 短暂掉线不剔除
+	flapping.online = false
+	assert.ElementsMatch(t, []string{stable.id, flapping.id}, at(40*time.Second))
+	flapping.online = true
+	assert.ElementsMatch(t, []string{stable.id, flapping.id}, at(45*time.Second))
+
+	// 离线超过阈值才剔除，之后需重新经过宽限期
+	flapping.online = false
+	assert.ElementsMatch(t, []string{stable.id, flapping.id}, at(74*time.Second))
+	assert.Equal(t, []string{stable.id}, at(75*time.Second))
+	flapping.online = true
+	assert.Equal(t, []string{stable.id}, at(80*time.Second))
+	assert.ElementsMatch(t, []string{stable.id, flapping.id}, at(90*time.Second))
+}
+
+func TestNodeHealthIgnoresNonMembers(t *testing.T) {
+	db, err := store.NewStorage("")
+	require.NoError(t, err)
+	member := newTestNode(t, 100, true, true)
+	outsider := newTestNode(t, 100, true, true)
+
+	clock := time.Unix(1760000000, 0)
+	m := newHealthManager(t, db, []*testNode{member, outsider}, &clock)
+	m.availableNodes([]string{member.address})
+	clock = clock.Add(time.Minute)
+	assert.Equal(t, []string{member.id}, m.availableNodes([]string{member.address}))
+}
+
+func TestNodeHealthPersisted(t *testing.T) {
+	db, err := store.NewStorage("")
+	require.NoError(t, err)
+	admitted := newTestNode(t, 100, true, true)
+	pending := newTestNode(t, 100, false, true)
+	members := []string{admitted.address, pending.address}
+
+	clock := time.Unix(1760000000, 0)
+	m := newHealthManager(t, db, []*testNode{admitted, pending}, &clock)
+	m.availableNodes(members)
+	clock = clock.Add(10 * time.Second)
+	pending.online = true
+	require.Equal(t, []string{admitted.id}, m.availableNodes(members))
+
+	// 重启后沿用已准入状态和宽限期计时
+	clock = clock.Add(5 * time.Second)
+	restarted := newHealthManager(t, db, []*testNode{admitted, pending}, &clock)
+	assert.Equal(t, []string{admitted.id}, restarted.availableNodes(members))
+	clock = clock.Add(5 * time.Second)
+	assert.ElementsMatch(t, []string{admitted.id, pending.id}, restarted.availableNodes(members))
+}
+
+func TestStatusReportsMemberHealth(t *testing.T) {
+	db, err := store.NewStorage("")
+	require.NoError(t, err)
+	online := newTestNode(t, 100, true, true)
+	joining := newTestNode(t, 100, false, true)
+	absent := newTestNode(t, 100, false, true)
+	for _, n := range []*testNode{online, joining, absent} {
+		require.NoError(t, db.SetActiveMember(n.address))
+	}
+	members := []string{online.address, joining.address, absent.address}
+
+	start := time.Unix(1760000000, 0)
+	clock := start
+	m := newHealthManager(t, db, []*testNode{online, joining, absent}, &clock)
+	m.availableNodes(members)
+	clock = start.Add(10 * time.Second)
+	joining.online = true
+	m.availableNodes(members)
+
+	status, err := m.Status()
+	require.NoError(t, err)
+	assert.Equal(t, []types.MemberHealth{
+		{Address: online.address, Node: online.id, Alive: true, Available: true, AliveSince: start.Unix(), LastSeen: clock.Unix()},
+		{Address: joining.address, Node: joining.id, Alive: true, AliveSince: clock.Unix(), LastSeen: clock.Unix()},
+		{Address: absent.address},
+	}, status.Members)
+}
@@ -0,0 +1,213 @@
+package manager
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tmtypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+
+	"github.com/cpchain-network/oracle-node/bindings/bls"
+	"github.com/cpchain-network/oracle-node/manager/types"
+	"github.com/cpchain-network/oracle-node/sign"
+	"github.com/cpchain-network/oracle-node/store"
+	"github.com/cpchain-network/oracle-node/ws/server"
+)
+
+type testNode struct {
+	id      string
+	address string
+	bls     *sign.KeyPair
+	price   float64
+	prices  map[string]float64 // 按品种覆盖 price
+	online  bool
+	respond bool
+}
+
+func newTestNode(t *testing.T, price float64, online, respond bool) *testNode {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	blsKey, err := sign.GenRandomBlsKeys()
+	require.NoError(t, err)
+	return &testNode{
+		id:      hex.EncodeToString(crypto.CompressPubkey(&key.PublicKey)),
+		address: crypto.PubkeyToAddress(key.PublicKey).String(),
+		bls:     blsKey,
+		price:   price,
+		online:  online,
+		respond: respond,
+	}
+}
+
+// fakeWsServer 模拟 ws 服务，只有 respond 的节点会返回签名，响应按请求 ID 路由
+type fakeWsServer struct {
+	mu        sync.Mutex
+	nodes     map[string]*testNode
+	respChans map[string]chan server.ResponseMsg
+	stopChans map[string]chan struct{}
+}
+
+func (s *fakeWsServer) AliveNodes() []string {
+	var alive []string
+	for id, n := range s.nodes {
+		if n.online {
+			alive = append(alive, id)
+		}
+	}
+	return alive
+}
+
+func (s *fakeWsServer) RegisterResChannel(id string, respChan chan server.ResponseMsg, stopChan chan struct{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.respChans == nil {
+		s.respChans = make(map[string]chan server.ResponseMsg)
+		s.stopChans = make(map[string]chan struct{})
+	}
+	s.respChans[id], s.stopChans[id] = respChan, stopChan
+	return nil
+}
+
+func (s *fakeWsServer) SendMsg(request server.RequestMsg) error {
+	n := s.nodes[request.TargetNode]
+	if !n.respond {
+		return nil
+	}
+
+	var nodeRequest types.NodeSignRequest
+	if err := json.Unmarshal(request.RpcRequest.Params, &nodeRequest); err != nil {
+		return err
+	}
+	price := n.price
+	if p, ok := n.prices[nodeRequest.RequestBody.Symbol]; ok {
+		price = p
+	}
+	// 第二轮签 manager 提议的聚合价，仍上报自己的价格
+	signedPrice := types.FormatPrice(price)
+	if nodeRequest.RequestBody.ProposedPrice != "" {
+		signedPrice = nodeRequest.RequestBody.ProposedPrice
+	}
+	message := types.PriceMessage(signedPrice, nodeRequest.RequestBody)
+	response := types.SignMsgResponse{
+		Signature:  n.bls.SignMessage(types.PriceMessageHash(message)).Serialize(),
+		G2Point:    n.bls.GetPubKeyG2().Serialize(),
+		AssetPrice: price,
+		Symbol:     nodeRequest.RequestBody.Symbol,
+	}
+
+	id := request.RpcRequest.ID.(tmtypes.JSONRPCStringID).String()
+	s.mu.Lock()
+	respChan, stopChan := s.respChans[id], s.stopChans[id]
+	s.mu.Unlock()
+	go func() {
+		select {
+		case respChan <- server.ResponseMsg{
+			RpcResponse: tmtypes.NewRPCSuccessResponse(request.RpcRequest.ID, response),
+			SourceNode:  n.id,
+		}:
+		case <-stopChan:
+		}
+	}()
+	return nil
+}
+
+func newQuorumManager(t *testing.T, nodes []*testNode) *Manager {
+	t.Helper()
+	db, err := store.NewStorage("")
+	require.NoError(t, err)
+
+	ws := &fakeWsServer{nodes: make(map[string]*testNode)}
+	for _, n := range nodes {
+		ws.nodes[n.id] = n
+		require.NoError(t, db.SetActiveMember(n.address))
+		pubkey := n.bls.GetPubKeyG1()
+		require.NoError(t, db.SetNewPubkeyRegistrationEvent(store.NewPubkeyRegistration{
+			Operator: common.HexToAddress(n.address),
+			PubkeyG1: bls.BN254G1Point{X: pubkey.X.BigInt(new(big.Int)), Y: pubkey.Y.BigInt(new(big.Int))},
+		}))
+	}
+
+	health, err := newNodeHealthTracker(db, 0, 0, log.Root())
+	require.NoError(t, err)
+
+	return &Manager{
+		log:               log.Root(),
+		db:                db,
+		wsServer:          ws,
+		health:            health,
+		signTimeout:       200 * time.Millisecond,
+		quorumNumerator:   2,
+		quorumDenominator: 3,
+	}
+}
+
+func TestQuorumSize(t *testing.T) {
+	m := &Manager{quorumNumerator: 2, quorumDenominator: 3}
+	for members, expected := range map[int]int{1: 1, 2: 2, 3: 2, 4: 3, 6: 4, 7: 5} {
+		assert.Equal(t, expected, m.quorumSize(members), "members=%d", members)
+	}
+}
+
+func TestPartialQuorumSubmission(t *testing.T) {
+	signerA := newTestNode(t, 100.5, true, true)
+	signerB := newTestNode(t, 101, true, true)
+	silent := newTestNode(t, 102, true, false)
+	m := newQuorumManager(t, []*testNode{signerA, signerB, silent})
+
+	start := time.Now()
+	res, err := m.NotifyNodeSubmitPriceWithSignature(testRequest)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), m.signTimeout, "waits the signing window for the silent node")
+
+	assert.ElementsMatch(t, []string{signerA.id, signerB.id}, res.Signers)
+	assert.ElementsMatch(t, []float64{100.5, 101}, res.Prices)
+
+	// 未响应节点作为 non-signer
+	require.Len(t, res.NonSignerPubkeys, 1)
+	assert.True(t, res.NonSignerPubkeys[0].Equal(silent.bls.GetPubKeyG1().G1Affine))
+
+	// 聚合签名对应签名节点的公钥和
+	expectedApk := signerA.bls.GetPubKeyG2().Clone()
+	expectedApk.Add(signerB.bls.GetPubKeyG2())
+	assert.True(t, res.G2Point.Equal(expectedApk.G2Affine))
+}
+
+func TestOfflineMemberIsNonSigner(t *testing.T) {
+	signerA := newTestNode(t, 100, true, true)
+	signerB := newTestNode(t, 100, true, true)
+	offline := newTestNode(t, 100, false, false)
+	m := newQuorumManager(t, []*testNode{signerA, signerB, offline})
+
+	res, err := m.NotifyNodeSubmitPriceWithSignature(testRequest)
+	require.NoError(t, err)
+	assert.Len(t, res.Signers, 2)
+	require.Len(t, res.NonSignerPubkeys, 1)
+	assert.True(t, res.NonSignerPubkeys[0].Equal(offline.bls.GetPubKeyG1().G1Affine))
+}
+
+func TestBelowQuorum(t *testing.T) {
+	signer := newTestNode(t, 100, true, true)
+	silentA := newTestNode(t, 100, true, false)
+	silentB := newTestNode(t, 100, true, false)
+	m := newQuorumManager(t, []*testNode{signer, silentA, silentB})
+
+	_, err := m.NotifyNodeSubmitPriceWithSignature(testRequest)
+	assert.ErrorIs(t, err, errNotEnoughSignal)
+
+	offlineA := newTestNode(t, 100, false, false)
+	offlineB := newTestNode(t, 100, false, false)
+	m = newQuorumManager(t, []*testNode{signer, offlineA, offlineB})
+
+	_, err = m.NotifyNodeSubmitPriceWithSignature(testRequest)
+	assert.ErrorIs(t, err, errNotEnoughSignNode)
+}
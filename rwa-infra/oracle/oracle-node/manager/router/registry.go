@@ -15,14 +15,16 @@ import (
 )
 
 type Registry struct {
-	signService types.SignService
-	db          *store.Storage
+	signService   types.SignService
+	statusService types.StatusService
+	db            *store.Storage
 }
 
-func NewRegistry(signService types.SignService, db *store.Storage) *Registry {
+func NewRegistry(signService types.SignService, statusService types.StatusService, db *store.Storage) *Registry {
 	return &Registry{
-		signService: signService,
-		db:          db,
+		signService:   signService,
+		statusService: statusService,
+		db:            db,
 	}
 }
 
@@ -53,6 +55,18 @@ func (registry *Registry) SignMsgHandler() gin.HandlerFunc {
 	}
 }
 
+func (registry *Registry) OracleStatusHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status, err := registry.statusService.Status()
+		if err != nil {
+			c.String(http.StatusInternalServerError, "failed to get oracle status")
+			log.Error("failed to get oracle status", "error", err)
+			return
+		}
+		c.JSON(http.StatusOK, status)
+	}
+}
+
 func (registry *Registry) PrometheusHandler() gin.HandlerFunc {
 	h := promhttp.InstrumentMetricHandler(
 		prometheus.DefaultRegisterer, promhttp.HandlerFor(
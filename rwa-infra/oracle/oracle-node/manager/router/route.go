@@ -11,6 +11,8 @@ func (registry *Registry) Register(r *gin.Engine) {
 		c.Status(http.StatusOK)
 	})
 
+	r.GET("/oracle/status", registry.OracleStatusHandler())
+
 	v1Router := r.Group("/api/v1")
 	v1Router.POST("/sign/state", registry.SignMsgHandler())
 	v1Router.GET("/metrics", registry.PrometheusHandler())
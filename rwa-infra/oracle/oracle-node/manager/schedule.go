@@ -0,0 +1,53 @@
+package manager
+
+import (
+	"math/rand"
+	"time"
+)
+
+// submitSchedule 价格提交节拍
+// 每轮间隔在 interval 上随机偏移 ±jitter，错开多个部署的上链时间；
+// 下一轮距上一轮结束至少 minInterval，签名耗时过长时不会紧接着再次提交。
+type submitSchedule struct {
+	interval    time.Duration
+	jitter      time.Duration
+	minInterval time.Duration
+	rand        func() float64 // [0, 1)
+}
+
+func newSubmitSchedule(interval, jitter, minInterval time.Duration) *submitSchedule {
+	// 偏移不超过间隔的一半，保证间隔为正
+	if jitter < 0 {
+		jitter = 0
+	}
+	if jitter > interval/2 {
+		jitter = interval / 2
+	}
+	if minInterval < 0 {
+		minInterval = 0
+	}
+	return &submitSchedule{
+		interval:    interval,
+		jitter:      jitter,
+		minInterval: minInterval,
+		rand:        rand.Float64,
+	}
+}
+
+// jittered 返回 [interval-jitter, interval+jitter] 内的随机间隔
+func (s *submitSchedule) jittered() time.Duration {
+	if s.jitter == 0 {
+		return s.interval
+	}
+	offset := time.Duration((2*s.rand() - 1) * float64(s.jitter))
+	return s.interval + offset
+}
+
+// next 根据本轮开始与结束时间计算下一轮提交时间
+func (s *submitSchedule) next(roundStart, roundEnd time.Time) time.Time {
+	next := roundStart.Add(s.jittered())
+	if earliest := roundEnd.Add(s.minInterval); next.Before(earliest) {
+		next = earliest
+	}
+	return next
+}
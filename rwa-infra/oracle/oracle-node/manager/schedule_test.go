@@ -0,0 +1,51 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubmitScheduleJitterWithinBounds(t *testing.T) {
+	s := newSubmitSchedule(10*time.Second, 2*time.Second, 0)
+
+	var minSeen, maxSeen time.Duration = time.Hour, 0
+	for i := 0; i < 10000; i++ {
+		d := s.jittered()
+		if d < 8*time.Second || d > 12*time.Second {
+			t.Fatalf("jittered interval %v outside [8s, 12s]", d)
+		}
+		minSeen, maxSeen = min(minSeen, d), max(maxSeen, d)
+	}
+	// 偏移确实分布在两侧
+	assert.Less(t, minSeen, 9*time.Second)
+	assert.Greater(t, maxSeen, 11*time.Second)
+
+	// 边界值
+	s.rand = func() float64 { return 0 }
+	assert.Equal(t, 8*time.Second, s.jittered())
+	s.rand = func() float64 { return 0.999999999 }
+	assert.InDelta(t, float64(12*time.Second), float64(s.jittered()), float64(time.Microsecond))
+}
+
+func TestSubmitScheduleClampsJitter(t *testing.T) {
+	assert.Equal(t, 5*time.Second, newSubmitSchedule(10*time.Second, time.Minute, 0).jitter)
+	assert.Equal(t, time.Duration(0), newSubmitSchedule(10*time.Second, -time.Second, 0).jitter)
+
+	s := newSubmitSchedule(10*time.Second, 0, 0)
+	s.rand = func() float64 { t.Fatal("rand used without jitter"); return 0 }
+	assert.Equal(t, 10*time.Second, s.jittered())
+}
+
+func TestSubmitScheduleMinInterval(t *testing.T) {
+	s := newSubmitSchedule(10*time.Second, 0, 4*time.Second)
+	start := time.Unix(1760000000, 0)
+
+	// 快速完成的一轮：按间隔从本轮开始计
+	assert.Equal(t, start.Add(10*time.Second), s.next(start, start.Add(time.Second)))
+
+	// 签名耗时超过间隔：下一轮距本轮结束至少 minInterval，而不是立即提交
+	end := start.Add(15 * time.Second)
+	assert.Equal(t, end.Add(4*time.Second), s.next(start, end))
+}
@@ -30,15 +30,13 @@ func (m *Manager) sign(ctx types.Context, request types.RequestBody, method type
 	var err error
 	var respNumber int
 	var validSignResult types.SignResult
-	var g2Point *sign.G2Point
 	var g2Points []*sign.G2Point
-	var g1Point *sign.G1Point
 	var g1Points []*sign.G1Point
-	var NonSignerPubkeys []*sign.G1Point
 
 	// 改动：收集所有节点的价格（不做平均）
 	var allPrices []float64
 	var allWeights []uint64
+	var signers []string
 
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
@@ -54,11 +52,17 @@ func (m *Manager) sign(ctx types.Context, request types.RequestBody, method type
 			select {
 			case <-errSendChan:
 				return
+			case <-cctx.Done():
+				m.log.Warn("wait for signature timeout", "requestId", ctx.RequestId(), "received responses len", respNumber, "signatures", len(g1Points), "quorum", ctx.Quorum())
+				return
 			case resp := <-respChan:
 				m.log.Info(fmt.Sprintf("signed response: %s", resp.RpcResponse.String()), "node", resp.SourceNode)
 				if !ExistsIgnoreCase(ctx.AvailableNodes(), resp.SourceNode) { // ignore the message which the sender should not be involved in approver set
 					continue
 				}
+				if _, ok := responseNodes[resp.SourceNode]; ok { // 每个节点只接受第一次响应
+					continue
+				}
 				respNumber++
 				func() {
 					defer func() {
@@ -77,46 +81,41 @@ func (m *Manager) sign(ctx types.Context, request types.RequestBody, method type
 							return
 						}
 
-						// 改动：使用 AssetPrice 字段
-						if signResponse.AssetPrice <= 0 {
-							g1Point, err = new(sign.G1Point).Deserialize(signResponse.NonSignerPubkey)
-							if err != nil {
-								m.log.Error("failed to deserialize g1Point", "err", err)
-								return
-							}
-							NonSignerPubkeys = append(NonSignerPubkeys, g1Point)
+						// 同一轮各品种的请求 ID 不同，品种不一致说明节点答非所问
+						if signResponse.Symbol != request.Symbol {
+							m.log.Error("rejected response for another symbol", "node", resp.SourceNode, "expected", request.Symbol, "got", signResponse.Symbol)
 							return
-						} else {
-							// 改动：收集单个价格到数组（不做累加）
-							allPrices = append(allPrices, signResponse.AssetPrice)
-							allWeights = append(allWeights, 1) // 默认权重为 1
-
-							m.log.Info("collected price from node",
-								"node", resp.SourceNode,
-								"price", signResponse.AssetPrice)
 						}
 
-						dG2Point, err := g2Point.Deserialize(signResponse.G2Point)
-						if err != nil {
-							m.log.Error("failed to deserialize g2Point", "err", err)
+						// 未上报价格的节点按未签名处理，公钥由 NotifyNodeSubmitPriceWithSignature 从注册表补齐
+						if signResponse.AssetPrice <= 0 {
+							m.log.Warn("node declined to sign", "node", resp.SourceNode)
 							return
 						}
 
-						dSign, err := g1Point.Deserialize(signResponse.Signature)
+						// 只接受签名与其上报价格一致的节点
+						dSign, dG2Point, err := verifyNodeSignature(signResponse, request)
 						if err != nil {
-							m.log.Error("failed to deserialize signature", "err", err)
+							m.log.Error("rejected node signature", "node", resp.SourceNode, "err", err)
 							return
 						}
+
+						// 改动：收集单个价格到数组（不做累加）
+						allPrices = append(allPrices, signResponse.AssetPrice)
+						allWeights = append(allWeights, 1) // 默认权重为 1
+						signers = append(signers, resp.SourceNode)
+
+						m.log.Info("collected price from node",
+							"node", resp.SourceNode,
+							"price", signResponse.AssetPrice,
+							"sources", signResponse.SourceCount)
+
 						g2Points = append(g2Points, dG2Point)
 						g1Points = append(g1Points, dSign)
 						return
 					}
 				}()
 
-			case <-cctx.Done():
-				m.log.Warn("wait for signature timeout", "requestId", ctx.RequestId(), "received responses len", respNumber)
-				return
-			default:
 				if respNumber == len(ctx.AvailableNodes()) {
 					m.log.Info("received all signing responses", "requestId", ctx.RequestId(), "received responses len", respNumber)
 					return
@@ -128,19 +127,21 @@ func (m *Manager) sign(ctx types.Context, request types.RequestBody, method type
 	m.sendToNodes(ctx, request, method, errSendChan)
 	wg.Wait()
 
-	if respNumber < len(ctx.AvailableNodes())*2/3 {
+	// 只要有效签名数达到法定数即可提交，未响应的节点作为 non-signer
+	if len(g1Points) == 0 || len(g1Points) < ctx.Quorum() {
+		m.log.Warn("signatures below quorum", "requestId", ctx.RequestId(), "signatures", len(g1Points), "quorum", ctx.Quorum())
 		return validSignResult, errNotEnoughSignal
 	}
 
 	aSign, aG2Point := aggregateSignaturesAndG2Point(g1Points, g2Points)
 	if aSign != nil {
 		validSignResult = types.SignResult{
-			NonSignerPubkeys: NonSignerPubkeys,
-			Signature:        aSign,
-			G2Point:          aG2Point,
+			Signature: aSign,
+			G2Point:   aG2Point,
 			// 改动：存储价格数组（不是平均值）
 			Prices:  allPrices,
 			Weights: allWeights,
+			Signers: signers,
 		}
 
 		// 计算加权平均用于日志
@@ -169,19 +170,40 @@ func (m *Manager) sendToNodes(ctx types.Context, request types.RequestBody, meth
 	rpcRequest := tmtypes.NewRPCRequest(tmtypes.JSONRPCStringID(ctx.RequestId()), method.String(), requestBz)
 	for _, node := range nodes {
 		go func(node string, request tmtypes.RPCRequest) {
+			// 单个节点发送失败不中断签名，该节点按未签名处理
 			if err := m.wsServer.SendMsg(
 				server.RequestMsg{
 					RpcRequest: request,
 					TargetNode: node,
 				}); err != nil {
-				m.log.Error("failed to send sign request to nodes", "err", err)
-				errSendChan <- struct{}{}
-				return
+				m.log.Error("failed to send sign request to nodes", "node", node, "err", err)
 			}
 		}(node, rpcRequest)
 	}
 }
 
+// verifyNodeSignature 校验节点签名的正是其上报价格对应的消息，请求带提议价时校验的是提议价对应的消息
+func verifyNodeSignature(response types.SignMsgResponse, request types.RequestBody) (*sign.G1Point, *sign.G2Point, error) {
+	g2Point, err := new(sign.G2Point).Deserialize(response.G2Point)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to deserialize g2Point: %w", err)
+	}
+	signature, err := new(sign.G1Point).Deserialize(response.Signature)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to deserialize signature: %w", err)
+	}
+
+	price := request.ProposedPrice
+	if price == "" {
+		price = types.FormatPrice(response.AssetPrice)
+	}
+	message := types.PriceMessage(price, request)
+	if !signature.Verify(g2Point, types.PriceMessageHash(message)) {
+		return nil, nil, fmt.Errorf("message %q: %w", message, errInvalidNodeSignature)
+	}
+	return signature, g2Point, nil
+}
+
 func aggregateSignaturesAndG2Point(signatures []*sign.G1Point, points []*sign.G2Point) (*sign.G1Point, *sign.G2Point) {
 	if len(signatures) == 0 {
 		return nil, nil
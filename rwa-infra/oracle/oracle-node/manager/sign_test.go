@@ -0,0 +1,93 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cpchain-network/oracle-node/manager/types"
+	"github.com/cpchain-network/oracle-node/sign"
+)
+
+var testRequest = types.RequestBody{BlockNumber: 619700, RequestId: "3f2a9c1e-5b7d-4e21-9a0f-2c8d6b1e4f37"}
+
+// nodeResponse 模拟节点对 message 签名并上报 price
+func nodeResponse(t *testing.T, keyPairs *sign.KeyPair, price float64, message string) types.SignMsgResponse {
+	t.Helper()
+	return types.SignMsgResponse{
+		G2Point:    keyPairs.GetPubKeyG2().Serialize(),
+		Signature:  keyPairs.SignMessage(types.PriceMessageHash(message)).Serialize(),
+		AssetPrice: price,
+	}
+}
+
+func TestVerifyNodeSignature(t *testing.T) {
+	keyPairs, err := sign.GenRandomBlsKeys()
+	require.NoError(t, err)
+
+	expected := types.PriceMessage(types.FormatPrice(2391.57), testRequest)
+	assert.Equal(t, "23915700003f2a9c1e-5b7d-4e21-9a0f-2c8d6b1e4f37619700", expected)
+
+	_, _, err = verifyNodeSignature(nodeResponse(t, keyPairs, 2391.57, expected), testRequest)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		message string
+	}{
+		{"price only", types.FormatPrice(2391.57)},
+		{"different price than reported", types.PriceMessage(types.FormatPrice(2391.58), testRequest)},
+		{"different request", types.PriceMessage(types.FormatPrice(2391.57), types.RequestBody{BlockNumber: testRequest.BlockNumber, RequestId: "other"})},
+		{"different block", types.PriceMessage(types.FormatPrice(2391.57), types.RequestBody{BlockNumber: 1, RequestId: testRequest.RequestId})},
+		{"different symbol", types.PriceMessage(types.FormatPrice(2391.57), types.RequestBody{BlockNumber: testRequest.BlockNumber, RequestId: testRequest.RequestId, Symbol: "GOLD"})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := verifyNodeSignature(nodeResponse(t, keyPairs, 2391.57, tt.message), testRequest)
+			assert.ErrorIs(t, err, errInvalidNodeSignature)
+		})
+	}
+}
+
+func TestAggregatedSignatureMatchesManagerMessage(t *testing.T) {
+	prices := []float64{2391.57, 2392.10, 2390.00}
+	reports := &types.SignResult{Prices: prices, Weights: []uint64{1, 1, 1}}
+	proposal := testRequest
+	proposal.ProposedPrice = reports.CalculateWeightedAverageScaled(types.PriceDecimals).String()
+	assert.Equal(t, "2391223333", proposal.ProposedPrice)
+
+	var ownSignatures, signatures []*sign.G1Point
+	var pubKeys []*sign.G2Point
+	for _, price := range prices {
+		keyPairs, err := sign.GenRandomBlsKeys()
+		require.NoError(t, err)
+
+		// 第一轮：各节点签自己的价格
+		own, _, err := verifyNodeSignature(nodeResponse(t, keyPairs, price, types.PriceMessage(types.FormatPrice(price), testRequest)), testRequest)
+		require.NoError(t, err)
+		ownSignatures = append(ownSignatures, own)
+
+		// 第二轮：各节点签提议价，签自己价格的响应不被接受
+		_, _, err = verifyNodeSignature(nodeResponse(t, keyPairs, price, types.PriceMessage(types.FormatPrice(price), proposal)), proposal)
+		assert.ErrorIs(t, err, errInvalidNodeSignature)
+		signature, pubKey, err := verifyNodeSignature(nodeResponse(t, keyPairs, price, types.PriceMessage(proposal.ProposedPrice, proposal)), proposal)
+		require.NoError(t, err)
+
+		signatures = append(signatures, signature)
+		pubKeys = append(pubKeys, pubKey)
+	}
+
+	managerMessage := types.PriceMessageHash(types.PriceMessage(proposal.ProposedPrice, proposal))
+
+	aggSig, aggPubKey := aggregateSignaturesAndG2Point(signatures, pubKeys)
+	ok, err := sign.VerifySig(aggSig.G1Affine, aggPubKey.G2Affine, managerMessage)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// 各节点价格不同时，各自价格的签名聚合后无法通过对聚合价的校验
+	ownSig, ownPubKey := aggregateSignaturesAndG2Point(ownSignatures, pubKeys)
+	ok, err = sign.VerifySig(ownSig.G1Affine, ownPubKey.G2Affine, managerMessage)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,102 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/cpchain-network/oracle-node/manager/types"
+)
+
+// submission 本 manager 最近一次成功上链的价格提交
+type submission struct {
+	batchId         uint64
+	aggregatedPrice string
+	signers         []string
+	txHash          common.Hash
+	time            time.Time
+}
+
+func (m *Manager) recordSubmission(s submission) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastSubmission = &s
+}
+
+// Status 合并本地提交记录与链上最近的 PricesSubmitted/VerifyOracleSig 事件，
+// 签名节点只有本地记录了对应交易时才能给出；NextSubmitTime 和 Members 总是本 manager 的视角
+func (m *Manager) Status() (types.OracleStatus, error) {
+	m.mu.Lock()
+	local := m.lastSubmission
+	nextSubmit := m.nextSubmit
+	m.mu.Unlock()
+
+	status, err := m.submissionStatus(local)
+	if err != nil {
+		return types.OracleStatus{}, err
+	}
+	if !nextSubmit.IsZero() {
+		status.NextSubmitTime = nextSubmit.Unix()
+	}
+	if m.health != nil {
+		activeMember, err := m.db.GetActiveMember()
+		if err != nil {
+			return types.OracleStatus{}, err
+		}
+		if len(activeMember.Members) > 0 {
+			status.Members = m.health.memberHealth(activeMember.Members)
+		}
+	}
+	return status, nil
+}
+
+func (m *Manager) submissionStatus(local *submission) (types.OracleStatus, error) {
+	status := types.OracleStatus{
+		Decimals: types.PriceDecimals,
+		Signers:  []string{},
+	}
+	if local != nil {
+		status.BatchId = local.batchId
+		status.AggregatedPrice = local.aggregatedPrice
+		status.NodeCount = uint64(len(local.signers))
+		status.Signers = append(status.Signers, local.signers...)
+		status.LastSubmitTxHash = local.txHash.String()
+		status.LastSubmitTime = local.time.Unix()
+	}
+
+	onChain, found, err := m.db.GetLatestSubmission()
+	if err != nil {
+		return types.OracleStatus{}, err
+	}
+	if !found {
+		return status, nil
+	}
+
+	if local != nil && onChain.TxHash == local.txHash {
+		// 链上数据为准，签名节点保留本地记录
+		status.BatchId = onChain.BatchId
+		status.AggregatedPrice = onChain.AggregatedPrice
+		if onChain.NodeCount > 0 {
+			status.NodeCount = onChain.NodeCount
+		}
+		if onChain.Timestamp > 0 {
+			status.LastSubmitTime = int64(onChain.Timestamp)
+		}
+		return status, nil
+	}
+
+	if local != nil && local.time.Unix() > int64(onChain.Timestamp) {
+		// 链上事件尚未同步到本地提交
+		return status, nil
+	}
+
+	return types.OracleStatus{
+		BatchId:          onChain.BatchId,
+		AggregatedPrice:  onChain.AggregatedPrice,
+		Decimals:         types.PriceDecimals,
+		NodeCount:        onChain.NodeCount,
+		Signers:          []string{},
+		LastSubmitTxHash: onChain.TxHash.String(),
+		LastSubmitTime:   int64(onChain.Timestamp),
+	}, nil
+}
@@ -0,0 +1,111 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cpchain-network/oracle-node/manager/types"
+	"github.com/cpchain-network/oracle-node/store"
+)
+
+func TestStatus(t *testing.T) {
+	submittedAt := time.Unix(1760000000, 0)
+	local := submission{
+		batchId:         7,
+		aggregatedPrice: "2391570000",
+		signers:         []string{"node-a", "node-b"},
+		txHash:          common.HexToHash("0x01"),
+		time:            submittedAt,
+	}
+
+	tests := []struct {
+		name     string
+		local    *submission
+		onChain  *store.OracleSubmission
+		expected types.OracleStatus
+	}{
+		{
+			name:     "nothing submitted",
+			expected: types.OracleStatus{Decimals: types.PriceDecimals, Signers: []string{}},
+		},
+		{
+			name:  "local submission not yet synced",
+			local: &local,
+			expected: types.OracleStatus{
+				BatchId: 7, AggregatedPrice: "2391570000", Decimals: types.PriceDecimals, NodeCount: 2,
+				Signers: []string{"node-a", "node-b"}, LastSubmitTxHash: local.txHash.String(), LastSubmitTime: submittedAt.Unix(),
+			},
+		},
+		{
+			name:  "synced event of the local submission",
+			local: &local,
+			onChain: &store.OracleSubmission{
+				BlockNumber: 100, TxHash: local.txHash, BatchId: 8, AggregatedPrice: "2391570000", NodeCount: 3, Timestamp: 1760000004,
+			},
+			expected: types.OracleStatus{
+				BatchId: 8, AggregatedPrice: "2391570000", Decimals: types.PriceDecimals, NodeCount: 3,
+				Signers: []string{"node-a", "node-b"}, LastSubmitTxHash: local.txHash.String(), LastSubmitTime: 1760000004,
+			},
+		},
+		{
+			name:  "newer submission from another manager",
+			local: &local,
+			onChain: &store.OracleSubmission{
+				BlockNumber: 120, TxHash: common.HexToHash("0x02"), BatchId: 9, AggregatedPrice: "2400000000", NodeCount: 4, Timestamp: 1760000060,
+			},
+			expected: types.OracleStatus{
+				BatchId: 9, AggregatedPrice: "2400000000", Decimals: types.PriceDecimals, NodeCount: 4,
+				Signers: []string{}, LastSubmitTxHash: common.HexToHash("0x02").String(), LastSubmitTime: 1760000060,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := store.NewStorage("")
+			require.NoError(t, err)
+			if tt.onChain != nil {
+				require.NoError(t, db.SetLatestSubmission(*tt.onChain))
+			}
+
+			m := &Manager{db: db, lastSubmission: tt.local}
+			status, err := m.Status()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, status)
+		})
+	}
+}
+
+func TestStatusReportsNextSubmitTime(t *testing.T) {
+	db, err := store.NewStorage("")
+	require.NoError(t, err)
+
+	next := time.Unix(1760000123, 0)
+	m := &Manager{db: db, nextSubmit: next}
+	status, err := m.Status()
+	require.NoError(t, err)
+	assert.Equal(t, next.Unix(), status.NextSubmitTime)
+
+	// 链上有更新的提交时仍报告本 manager 的调度
+	require.NoError(t, db.SetLatestSubmission(store.OracleSubmission{BlockNumber: 120, BatchId: 9, Timestamp: 1760000060}))
+	status, err = m.Status()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(9), status.BatchId)
+	assert.Equal(t, next.Unix(), status.NextSubmitTime)
+}
+
+func TestSetLatestSubmissionKeepsNewest(t *testing.T) {
+	db, err := store.NewStorage("")
+	require.NoError(t, err)
+
+	require.NoError(t, db.SetLatestSubmission(store.OracleSubmission{BlockNumber: 20, BatchId: 2}))
+	require.NoError(t, db.SetLatestSubmission(store.OracleSubmission{BlockNumber: 10, BatchId: 1}))
+
+	latest, found, err := db.GetLatestSubmission()
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, uint64(2), latest.BatchId)
+}
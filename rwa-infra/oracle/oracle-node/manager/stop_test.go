@@ -0,0 +1,93 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cpchain-network/oracle-node/bindings/oracle"
+)
+
+// blockingSubmitter 提交时阻塞直到 release，模拟等待交易回执
+type blockingSubmitter struct {
+	fakeSubmitter
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingSubmitter) submitBatch(pod common.Address, batch oracle.IOracleManagerOracleBatch, signature oracle.IBLSApkRegistryOracleNonSignerAndSignature) (common.Hash, error) {
+	s.started <- struct{}{}
+	<-s.release
+	return s.fakeSubmitter.submitBatch(pod, batch, signature)
+}
+
+// startRound 与 work 相同，在 wg 覆盖的 goroutine 中执行一轮提交
+func startRound(t *testing.T, shutdownTimeout time.Duration) (*Manager, *blockingSubmitter) {
+	t.Helper()
+	nodes := []*testNode{
+		newTestNode(t, 2400, true, true),
+		newTestNode(t, 2400, true, true),
+		newTestNode(t, 2400, true, true),
+	}
+	m, _ := newFeedManager(t, nodes)
+	submitter := &blockingSubmitter{started: make(chan struct{}, 2), release: make(chan struct{})}
+	m.submitter = submitter
+	m.done = make(chan struct{})
+	m.shutdownTimeout = shutdownTimeout
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.submitFeeds(testRequest)
+	}()
+	select {
+	case <-submitter.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("submission did not start")
+	}
+	return m, submitter
+}
+
+func TestStopWaitsForInFlightSubmission(t *testing.T) {
+	m, submitter := startRound(t, 5*time.Second)
+
+	stopErr := make(chan error, 1)
+	go func() { stopErr <- m.Stop(context.Background()) }()
+
+	select {
+	case <-stopErr:
+		t.Fatal("Stop returned before the in-flight submission completed")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(submitter.release)
+	select {
+	case err := <-stopErr:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not return after the submission completed")
+	}
+
+	// 进行中的 GOLD 提交完成，之后的 OIL 不再开始
+	require.Len(t, submitter.batches, 1)
+	assert.Equal(t, goldPod, submitter.batches[0].pod)
+	assert.Empty(t, submitter.started)
+	assert.Equal(t, uint64(1), m.batchId)
+	assert.NotNil(t, m.lastSubmission)
+	assert.True(t, m.Stopped())
+}
+
+func TestStopTimesOutWaitingForSubmission(t *testing.T) {
+	m, submitter := startRound(t, 50*time.Millisecond)
+	defer close(submitter.release)
+
+	start := time.Now()
+	err := m.Stop(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Empty(t, submitter.batches)
+}
@@ -5,45 +5,242 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/cpchain-network/oracle-node/bindings/oracle"
 )
 
-func (m *Manager) craftTx(ctx context.Context, data []byte, to common.Address) (*types.Transaction, error) {
-	if m.privateKey == nil {
-		m.log.Error("finality manager create signer error")
-		return nil, errors.New("finality manager create signer error")
+const (
+	// feeBumpPercent 重发时费用的提高比例，需高于节点替换交易要求的 10%
+	feeBumpPercent        = 20
+	defaultConfirmTimeout = time.Minute
+	defaultPollInterval   = 2 * time.Second
+)
+
+var (
+	errFeeAboveCap    = errors.New("gas fee exceeds max_fee_per_gas")
+	errTxNotConfirmed = errors.New("transaction not confirmed after fee bumps")
+	errConfirmTimeout = errors.New("transaction not confirmed within confirm timeout")
+	errTxReverted     = errors.New("transaction reverted")
+)
+
+// txClient 发送交易用到的链上接口，*ethclient.Client 满足该接口
+type txClient interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// feePolicy 提交交易的费用上限与未确认时的重发策略
+type feePolicy struct {
+	maxFeePerGas   *big.Int      // nil 表示不限制
+	confirmTimeout time.Duration // 每次发送后等待确认的时间
+	maxBumps       int           // 提高费用重发的最多次数
+	pollInterval   time.Duration // 查询回执的间隔
+}
+
+func newFeePolicy(maxFeePerGasGwei uint64, confirmTimeout time.Duration, maxBumps int) feePolicy {
+	policy := feePolicy{confirmTimeout: confirmTimeout, maxBumps: maxBumps, pollInterval: defaultPollInterval}
+	if maxFeePerGasGwei > 0 {
+		policy.maxFeePerGas = new(big.Int).Mul(new(big.Int).SetUint64(maxFeePerGasGwei), big.NewInt(1e9))
+	}
+	if policy.confirmTimeout <= 0 {
+		policy.confirmTimeout = defaultConfirmTimeout
+	}
+	if policy.maxBumps < 0 {
+		policy.maxBumps = 0
+	}
+	return policy
+}
+
+// submitBatch 调用 OracleManager.fillSymbolPriceWithSignature 提交到 pod 并等待回执
+func (m *Manager) submitBatch(pod common.Address, batch oracle.IOracleManagerOracleBatch, signature oracle.IBLSApkRegistryOracleNonSignerAndSignature) (common.Hash, error) {
+	m.log.Info("oracle caller", "address", m.from)
+
+	parsed, err := oracle.OracleManagerMetaData.GetAbi()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	data, err := parsed.Pack("fillSymbolPriceWithSignature", pod, batch, signature)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to pack fillSymbolPriceWithSignature: %w", err)
 	}
 
-	nonce, err := m.ethClient.NonceAt(ctx, m.from, nil)
+	receipt, err := m.sendWithFeeBump(m.ctx, data, m.oracleContractAddr)
 	if err != nil {
-		m.log.Error("failed to get account nonce", "err", err)
-		return nil, err
+		return common.Hash{}, err
 	}
+	return receipt.TxHash, nil
+}
 
-	tip, err := m.ethClient.SuggestGasTipCap(ctx)
+// sendWithFeeBump 按建议费用发送交易，建议费用超过上限时不发送；confirmTimeout 内未确认时
+// 以同一 nonce 提高 feeBumpPercent 重发，最多 maxBumps 次，任一次发送的交易确认即返回；
+// 交易执行失败时返回 errTxReverted
+func (m *Manager) sendWithFeeBump(ctx context.Context, data []byte, to common.Address) (*types.Receipt, error) {
+	tip, err := m.txClient.SuggestGasTipCap(ctx)
 	if err != nil {
-		m.log.Error(fmt.Errorf("failed to fetch the suggested gas tip cap: %w", err).Error())
-		return nil, err
+		return nil, fmt.Errorf("failed to fetch the suggested gas tip cap: %w", err)
+	}
+	header, err := m.txClient.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the suggested base fee: %w", err)
+	}
+	feeCap := calcGasFeeCap(header.BaseFee, tip)
+	if m.fees.exceedsCap(feeCap) {
+		m.log.Warn("gas fee above cap, skip submission", "feeCap", feeCap, "maxFeePerGas", m.fees.maxFeePerGas)
+		return nil, fmt.Errorf("fee cap %s wei: %w", feeCap, errFeeAboveCap)
 	}
 
-	header, err := m.ethClient.HeaderByNumber(ctx, nil)
+	nonce, err := m.txClient.PendingNonceAt(ctx, m.from)
 	if err != nil {
-		m.log.Error(fmt.Errorf("failed to fetch the suggested base fee: %w", err).Error())
-		return nil, err
+		return nil, fmt.Errorf("failed to get account nonce: %w", err)
 	}
-	baseFee := header.BaseFee
-	gasFeeCap := calcGasFeeCap(baseFee, tip)
 
-	gasLimit, err := m.ethClient.EstimateGas(ctx, ethereum.CallMsg{
-		From: m.from,
-		//To:        &m.msmContractAddr,
+	var sent []common.Hash
+	txs := make(map[common.Hash]*types.Transaction)
+	for bump := 0; ; bump++ {
+		tx, err := m.craftTx(ctx, data, to, nonce, tip, feeCap)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.txClient.SendTransaction(ctx, tx); err != nil {
+			// 重发前上一笔可能已经上链，此时节点会拒绝同 nonce 的交易
+			if receipt, found := m.findReceipt(ctx, sent); found {
+				return m.checkReceipt(ctx, receipt, txs[receipt.TxHash])
+			}
+			return nil, fmt.Errorf("failed to send transaction: %w", err)
+		}
+		sent = append(sent, tx.Hash())
+		txs[tx.Hash()] = tx
+		m.log.Info("sent transaction", "tx_hash", tx.Hash(), "nonce", nonce, "tip", tip, "feeCap", feeCap, "bump", bump)
+
+		receipt, err := m.waitReceipt(ctx, sent)
+		if err == nil {
+			return m.checkReceipt(ctx, receipt, txs[receipt.TxHash])
+		}
+		if !errors.Is(err, errConfirmTimeout) {
+			return nil, err
+		}
+		if bump >= m.fees.maxBumps {
+			return nil, fmt.Errorf("nonce %d after %d bumps: %w", nonce, bump, errTxNotConfirmed)
+		}
+
+		tip, feeCap = bumpFee(tip), bumpFee(feeCap)
+		if m.fees.exceedsCap(feeCap) {
+			m.log.Warn("bumped gas fee above cap, stop resubmitting", "feeCap", feeCap, "maxFeePerGas", m.fees.maxFeePerGas, "nonce", nonce)
+			return nil, fmt.Errorf("bumped fee cap %s wei: %w", feeCap, errFeeAboveCap)
+		}
+		m.log.Warn("transaction not confirmed, resubmitting with higher fee", "nonce", nonce, "tip", tip, "feeCap", feeCap)
+	}
+}
+
+// waitReceipt 等待已发送的任一交易确认，超过 confirmTimeout 返回 errConfirmTimeout
+func (m *Manager) waitReceipt(ctx context.Context, sent []common.Hash) (*types.Receipt, error) {
+	deadline := time.NewTimer(m.fees.confirmTimeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(m.fees.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline.C:
+			if receipt, found := m.findReceipt(ctx, sent); found {
+				return receipt, nil
+			}
+			return nil, errConfirmTimeout
+		case <-ticker.C:
+			if receipt, found := m.findReceipt(ctx, sent); found {
+				return receipt, nil
+			}
+		}
+	}
+}
+
+func (m *Manager) findReceipt(ctx context.Context, sent []common.Hash) (*types.Receipt, bool) {
+	for _, hash := range sent {
+		receipt, err := m.txClient.TransactionReceipt(ctx, hash)
+		if err == nil && receipt != nil {
+			return receipt, true
+		}
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			m.log.Warn("failed to get transaction receipt", "tx_hash", hash, "err", err)
+		}
+	}
+	return nil, false
+}
+
+// checkReceipt 交易执行失败时重放取得 revert 原因，返回 errTxReverted
+func (m *Manager) checkReceipt(ctx context.Context, receipt *types.Receipt, tx *types.Transaction) (*types.Receipt, error) {
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		return receipt, nil
+	}
+	reason := m.revertReason(ctx, tx, receipt.BlockNumber)
+	m.log.Error("transaction reverted", "tx_hash", receipt.TxHash, "block", receipt.BlockNumber, "reason", reason)
+	return nil, fmt.Errorf("tx %s: %s: %w", receipt.TxHash, reason, errTxReverted)
+}
+
+// revertReason 在交易所在块用 eth_call 重放交易，仅用于诊断，取不到原因时返回调用错误
+func (m *Manager) revertReason(ctx context.Context, tx *types.Transaction, blockNumber *big.Int) string {
+	if tx == nil {
+		return "unknown"
+	}
+	_, err := m.txClient.CallContract(ctx, ethereum.CallMsg{
+		From:      m.from,
+		To:        tx.To(),
+		Gas:       tx.Gas(),
+		GasFeeCap: tx.GasFeeCap(),
+		GasTipCap: tx.GasTipCap(),
+		Value:     tx.Value(),
+		Data:      tx.Data(),
+	}, blockNumber)
+	if err == nil {
+		// 重放成功说明失败与执行时的状态有关
+		return "replay succeeded"
+	}
+
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if data, ok := dataErr.ErrorData().(string); ok {
+			if reason, unpackErr := abi.UnpackRevert(common.FromHex(data)); unpackErr == nil {
+				return reason
+			}
+		}
+	}
+	return err.Error()
+}
+
+func (p feePolicy) exceedsCap(feeCap *big.Int) bool {
+	return p.maxFeePerGas != nil && feeCap.Cmp(p.maxFeePerGas) > 0
+}
+
+func (m *Manager) craftTx(ctx context.Context, data []byte, to common.Address, nonce uint64, tip, gasFeeCap *big.Int) (*types.Transaction, error) {
+	if m.signer == nil {
+		m.log.Error("finality manager create signer error")
+		return nil, errors.New("finality manager create signer error")
+	}
+
+	gasLimit, err := m.txClient.EstimateGas(ctx, ethereum.CallMsg{
+		From:      m.from,
+		To:        &to,
 		GasFeeCap: gasFeeCap,
 		GasTipCap: tip,
 		Data:      data,
 	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate gas: %w", err)
+	}
 
 	rawTx := &types.DynamicFeeTx{
 		ChainID:   big.NewInt(int64(m.ethChainID)),
@@ -55,7 +252,7 @@ func (m *Manager) craftTx(ctx context.Context, data []byte, to common.Address) (
 		Data:      data,
 	}
 
-	tx, err := types.SignNewTx(m.privateKey, types.LatestSignerForChainID(big.NewInt(int64(m.ethChainID))), rawTx)
+	tx, err := m.signer.SignTx(ctx, types.NewTx(rawTx), big.NewInt(int64(m.ethChainID)))
 	if err != nil {
 		m.log.Error("failed to sign transaction", "err", err)
 		return nil, err
@@ -70,3 +267,10 @@ func calcGasFeeCap(baseFee, gasTipCap *big.Int) *big.Int {
 		new(big.Int).Mul(baseFee, big.NewInt(2)),
 	)
 }
+
+// bumpFee 费用提高 feeBumpPercent，向上取整
+func bumpFee(fee *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(100+feeBumpPercent))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
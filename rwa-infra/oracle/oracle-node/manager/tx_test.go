@@ -0,0 +1,261 @@
+package manager
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gwei(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e9))
+}
+
+// fakeTxClient 只有 feeCap 不低于 confirmFeeCap 的交易会被确认
+type fakeTxClient struct {
+	mu            sync.Mutex
+	tip           *big.Int
+	baseFee       *big.Int
+	nonce         uint64
+	confirmFeeCap *big.Int // nil 表示永不确认
+	reverted      bool     // 确认的交易执行失败
+	callErr       error    // 重放交易时返回的错误
+	calls         []ethereum.CallMsg
+	sent          []*types.Transaction
+}
+
+func (c *fakeTxClient) PendingNonceAt(context.Context, common.Address) (uint64, error) {
+	return c.nonce, nil
+}
+
+func (c *fakeTxClient) SuggestGasTipCap(context.Context) (*big.Int, error) {
+	return c.tip, nil
+}
+
+func (c *fakeTxClient) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	return &types.Header{BaseFee: c.baseFee}, nil
+}
+
+func (c *fakeTxClient) EstimateGas(context.Context, ethereum.CallMsg) (uint64, error) {
+	return 100000, nil
+}
+
+func (c *fakeTxClient) SendTransaction(_ context.Context, tx *types.Transaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, tx)
+	return nil
+}
+
+func (c *fakeTxClient) TransactionReceipt(_ context.Context, hash common.Hash) (*types.Receipt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, tx := range c.sent {
+		if tx.Hash() == hash && c.confirmFeeCap != nil && tx.GasFeeCap().Cmp(c.confirmFeeCap) >= 0 {
+			status := types.ReceiptStatusSuccessful
+			if c.reverted {
+				status = types.ReceiptStatusFailed
+			}
+			return &types.Receipt{TxHash: hash, Status: status, BlockNumber: big.NewInt(42)}, nil
+		}
+	}
+	return nil, ethereum.NotFound
+}
+
+func (c *fakeTxClient) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, msg)
+	return nil, c.callErr
+}
+
+// fakeSigner 模拟外部签名服务：manager 只拿到签名后的交易，不持有私钥
+type fakeSigner struct {
+	mu      sync.Mutex
+	key     *ecdsa.PrivateKey
+	signed  []*types.Transaction
+	chainID *big.Int
+}
+
+func newFakeSigner(t *testing.T) *fakeSigner {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	return &fakeSigner{key: key}
+}
+
+func (s *fakeSigner) Address() common.Address {
+	return crypto.PubkeyToAddress(s.key.PublicKey)
+}
+
+func (s *fakeSigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+	if err != nil {
+		return nil, err
+	}
+	s.signed = append(s.signed, signed)
+	s.chainID = chainID
+	return signed, nil
+}
+
+func newTxManager(t *testing.T, client *fakeTxClient, maxFeePerGasGwei uint64, maxBumps int) *Manager {
+	t.Helper()
+	txSigner := newFakeSigner(t)
+	fees := newFeePolicy(maxFeePerGasGwei, 50*time.Millisecond, maxBumps)
+	fees.pollInterval = 5 * time.Millisecond
+	return &Manager{
+		log:        log.Root(),
+		signer:     txSigner,
+		from:       txSigner.Address(),
+		ethChainID: 1,
+		txClient:   client,
+		fees:       fees,
+	}
+}
+
+var testTo = common.HexToAddress("0x00000000000000000000000000000000000000b1")
+
+func TestSendWithFeeBumpConfirmsAfterBump(t *testing.T) {
+	// 初始 feeCap = 1 + 2*10 = 21 gwei，提高一次后 25.2 gwei 才被确认
+	client := &fakeTxClient{tip: gwei(1), baseFee: gwei(10), nonce: 7, confirmFeeCap: gwei(25)}
+	m := newTxManager(t, client, 100, 3)
+
+	receipt, err := m.sendWithFeeBump(context.Background(), []byte{0x01}, testTo)
+	require.NoError(t, err)
+
+	require.Len(t, client.sent, 2)
+	first, second := client.sent[0], client.sent[1]
+	assert.Equal(t, uint64(7), first.Nonce())
+	assert.Equal(t, first.Nonce(), second.Nonce(), "resubmission reuses the nonce")
+	assert.Equal(t, gwei(21), first.GasFeeCap())
+	assert.Equal(t, bumpFee(gwei(21)), second.GasFeeCap())
+	assert.Equal(t, bumpFee(gwei(1)), second.GasTipCap())
+	assert.Equal(t, second.Hash(), receipt.TxHash)
+
+	// 每次发送的交易都经 signer 签名
+	txSigner := m.signer.(*fakeSigner)
+	require.Len(t, txSigner.signed, 2)
+	assert.Equal(t, big.NewInt(1), txSigner.chainID)
+	for _, tx := range client.sent {
+		sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1)), tx)
+		require.NoError(t, err)
+		assert.Equal(t, txSigner.Address(), sender)
+	}
+}
+
+func TestSendWithFeeBumpSkipsAboveCap(t *testing.T) {
+	client := &fakeTxClient{tip: gwei(1), baseFee: gwei(10), confirmFeeCap: gwei(1)}
+	m := newTxManager(t, client, 20, 3)
+
+	_, err := m.sendWithFeeBump(context.Background(), []byte{0x01}, testTo)
+	assert.ErrorIs(t, err, errFeeAboveCap)
+	assert.Empty(t, client.sent)
+}
+
+func TestSendWithFeeBumpBounded(t *testing.T) {
+	client := &fakeTxClient{tip: gwei(1), baseFee: gwei(10)}
+	m := newTxManager(t, client, 0, 2)
+
+	_, err := m.sendWithFeeBump(context.Background(), []byte{0x01}, testTo)
+	assert.ErrorIs(t, err, errTxNotConfirmed)
+	assert.Len(t, client.sent, 3)
+
+	// 提高后的费用超过上限时停止重发
+	client = &fakeTxClient{tip: gwei(1), baseFee: gwei(10)}
+	m = newTxManager(t, client, 22, 3)
+
+	_, err = m.sendWithFeeBump(context.Background(), []byte{0x01}, testTo)
+	assert.ErrorIs(t, err, errFeeAboveCap)
+	assert.Len(t, client.sent, 1)
+}
+
+// revertError 模拟节点 eth_call 返回的 revert 错误，ErrorData 为 Error(string) 编码
+type revertError struct {
+	data string
+}
+
+func (e revertError) Error() string          { return "execution reverted" }
+func (e revertError) ErrorData() interface{} { return e.data }
+
+func newRevertError(t *testing.T, reason string) revertError {
+	t.Helper()
+	stringType, err := abi.NewType("string", "", nil)
+	require.NoError(t, err)
+	packed, err := abi.Arguments{{Type: stringType}}.Pack(reason)
+	require.NoError(t, err)
+	selector := crypto.Keccak256([]byte("Error(string)"))[:4]
+	return revertError{data: hexutil.Encode(append(selector, packed...))}
+}
+
+func TestSendWithFeeBumpReverted(t *testing.T) {
+	client := &fakeTxClient{
+		tip: gwei(1), baseFee: gwei(10), confirmFeeCap: gwei(1), reverted: true,
+		callErr: newRevertError(t, "OracleManager: pod not whitelisted"),
+	}
+	m := newTxManager(t, client, 0, 3)
+
+	_, err := m.sendWithFeeBump(context.Background(), []byte{0x01}, testTo)
+	assert.ErrorIs(t, err, errTxReverted)
+	assert.Contains(t, err.Error(), "OracleManager: pod not whitelisted")
+
+	// 在交易所在块重放同一交易
+	require.Len(t, client.calls, 1)
+	assert.Equal(t, client.sent[0].Data(), client.calls[0].Data)
+	assert.Equal(t, &testTo, client.calls[0].To)
+	assert.Equal(t, m.from, client.calls[0].From)
+
+	// 取不到 revert 数据时给出调用错误
+	client = &fakeTxClient{tip: gwei(1), baseFee: gwei(10), confirmFeeCap: gwei(1), reverted: true, callErr: errors.New("missing trie node")}
+	m = newTxManager(t, client, 0, 3)
+	_, err = m.sendWithFeeBump(context.Background(), []byte{0x01}, testTo)
+	assert.ErrorIs(t, err, errTxReverted)
+	assert.Contains(t, err.Error(), "missing trie node")
+}
+
+func TestRevertedSubmissionNotRecorded(t *testing.T) {
+	nodes := []*testNode{
+		newTestNode(t, 2400, true, true),
+		newTestNode(t, 2400, true, true),
+		newTestNode(t, 2400, true, true),
+	}
+	m, _ := newFeedManager(t, nodes)
+	client := &fakeTxClient{tip: gwei(1), baseFee: gwei(10), confirmFeeCap: gwei(1), reverted: true, callErr: newRevertError(t, "BLSApkRegistry: signature is invalid")}
+	tm := newTxManager(t, client, 0, 3)
+	m.ctx = context.Background()
+	m.signer, m.from, m.ethChainID, m.txClient, m.fees = tm.signer, tm.from, tm.ethChainID, client, tm.fees
+	m.submitter = m
+	m.batchId = 5
+	request := testRequest
+	request.Symbol = m.feeds[0].symbol
+
+	err := m.submitFeed(m.feeds[0], request)
+	assert.ErrorIs(t, err, errTxReverted)
+	assert.Equal(t, uint64(5), m.batchId)
+	assert.Nil(t, m.lastSubmission)
+
+	client.reverted = false
+	require.NoError(t, m.submitFeed(m.feeds[0], request))
+	assert.Equal(t, uint64(6), m.batchId)
+	require.NotNil(t, m.lastSubmission)
+	assert.Equal(t, client.sent[len(client.sent)-1].Hash(), m.lastSubmission.txHash)
+}
+
+func TestBumpFee(t *testing.T) {
+	assert.Equal(t, big.NewInt(120), bumpFee(big.NewInt(100)))
+	assert.Equal(t, big.NewInt(2), bumpFee(big.NewInt(1)), "rounds up so small fees still increase")
+}
@@ -3,3 +3,7 @@ package types
 type SignService interface {
 	NotifyNodeSubmitPriceWithSignature(request RequestBody) (*SignResult, error)
 }
+
+type StatusService interface {
+	Status() (OracleStatus, error)
+}
@@ -0,0 +1,41 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// FormatPrice 价格转换为 PriceDecimals 位小数的整数字符串，与合约及 CalculateWeightedAverageScaled 格式一致
+func FormatPrice(price float64) string {
+	return (&SignResult{Prices: []float64{price}}).ToBigIntPrices(PriceDecimals)[0].String()
+}
+
+// ParsePrice 解析 FormatPrice 格式的整数字符串，用于节点比较 manager 提议的价格
+func ParsePrice(price string) (float64, error) {
+	scaled, ok := new(big.Int).SetString(price, 10)
+	if !ok || scaled.Sign() <= 0 {
+		return 0, fmt.Errorf("invalid price %q", price)
+	}
+	multiplier := new(big.Int).Exp(big.NewInt(10), big.NewInt(PriceDecimals), nil)
+	value, _ := new(big.Rat).SetFrac(scaled, multiplier).Float64()
+	return value, nil
+}
+
+// PriceMessage 节点与 manager 签名的消息：价格 + requestId + 块高，多品种时再加 ":品种"，
+// 同一轮各品种的签名不能互换
+func PriceMessage(price string, request RequestBody) string {
+	message := price + request.RequestId + strconv.FormatUint(request.BlockNumber, 10)
+	if request.Symbol != "" {
+		message += ":" + request.Symbol
+	}
+	return message
+}
+
+// PriceMessageHash 签名消息的哈希，节点签名、manager 验签与链上 msgHash 都使用该值
+func PriceMessageHash(message string) common.Hash {
+	return crypto.Keccak256Hash([]byte(message))
+}
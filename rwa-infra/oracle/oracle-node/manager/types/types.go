@@ -2,6 +2,7 @@ package types
 
 import (
 	"context"
+	"math"
 	"math/big"
 
 	"github.com/cpchain-network/oracle-node/sign"
@@ -10,6 +11,11 @@ import (
 type RequestBody struct {
 	BlockNumber uint64 `json:"block_number"`
 	RequestId   string `json:"request_id"`
+	Symbol      string `json:"symbol,omitempty"` // 喂价品种，未配置多品种时为空
+
+	// ProposedPrice manager 根据各节点上报价格算出的聚合价（PriceDecimals 位小数的整数字符串），
+	// 非空时节点不再签自己的价格，而是在与本轮上报价格足够接近时签该价格，使聚合签名对应链上同一消息
+	ProposedPrice string `json:"proposed_price,omitempty"`
 }
 
 type NodeSignRequest struct {
@@ -21,7 +27,9 @@ type SignMsgResponse struct {
 	Signature       []byte  `json:"signature"`
 	G2Point         []byte  `json:"g2_point"`
 	NonSignerPubkey []byte  `json:"non_signer_pubkey"`
-	AssetPrice      float64 `json:"asset_price"` // 改名：MarketPrice -> AssetPrice
+	AssetPrice      float64 `json:"asset_price"`            // 改名：MarketPrice -> AssetPrice
+	SourceCount     int     `json:"source_count,omitempty"` // 参与计算价格的数据源数，用于评估置信度
+	Symbol          string  `json:"symbol,omitempty"`       // 请求中的品种，manager 据此拒绝答非所问的响应
 }
 
 // PriceSubmission 单个节点的价格提交
@@ -41,18 +49,44 @@ type SignResult struct {
 	Prices  []float64 `json:"prices"`  // 各节点价格
 	Weights []uint64  `json:"weights"` // 各节点权重
 
+	// 签名被接受的节点
+	Signers []string `json:"signers"`
+
 	// 保留旧字段兼容性（deprecated）
 	MarketPrice string `json:"market_price,omitempty"`
 }
 
+// OracleStatus 最近一次价格提交状态
+type OracleStatus struct {
+	BatchId          uint64         `json:"batchId"`
+	AggregatedPrice  string         `json:"aggregatedPrice"`
+	Decimals         int            `json:"decimals"`
+	NodeCount        uint64         `json:"nodeCount"`
+	Signers          []string       `json:"signers"`
+	LastSubmitTxHash string         `json:"lastSubmitTxHash"`
+	LastSubmitTime   int64          `json:"lastSubmitTime"` // Unix 秒，尚未提交时为 0
+	NextSubmitTime   int64          `json:"nextSubmitTime"` // 本 manager 下一轮提交的 Unix 秒（含随机偏移），未调度时为 0
+	Members          []MemberHealth `json:"members,omitempty"`
+}
+
+// MemberHealth 签名成员最近一次检查时的连接与准入状态
+type MemberHealth struct {
+	Address    string `json:"address"`
+	Node       string `json:"node,omitempty"` // ws 节点 ID，从未连接过时为空
+	Alive      bool   `json:"alive"`          // 是否在线
+	Available  bool   `json:"available"`      // 是否参与签名
+	AliveSince int64  `json:"aliveSince"`     // 本次连续在线起点的 Unix 秒，离线时为 0
+	LastSeen   int64  `json:"lastSeen"`       // 最近一次在线的 Unix 秒，从未在线时为 0
+}
+
 // ToBigIntPrices 转换为合约需要的 big.Int 格式（18位小数）
 func (r *SignResult) ToBigIntPrices(decimals int) []*big.Int {
 	multiplier := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
 	result := make([]*big.Int, len(r.Prices))
 
 	for i, price := range r.Prices {
-		// 先乘以 1e6 保留精度，再转换
-		priceInt := int64(price * 1e6)
+		// 先乘以 1e6 保留精度，再转换；四舍五入避免 0.29*1e6 = 289999.99… 被截断
+		priceInt := int64(math.Round(price * 1e6))
 		priceBig := big.NewInt(priceInt)
 
 		// 调整到目标精度
@@ -105,6 +139,31 @@ func (r *SignResult) CalculateWeightedAverage() float64 {
 	return totalPrice / float64(totalWeight)
 }
 
+// PriceDecimals 链上价格精度，与 OraclePod.PRICE_DECIMALS 一致
+const PriceDecimals = 6
+
+// CalculateWeightedAverageScaled 按合约 _calculateWeightedAverage 的整数算法计算加权平均
+// 价格按 ToBigIntPrices 转换为 decimals 位小数的整数，sum(price*weight) / sum(weight) 向下取整，
+// 与链上 uint256 运算结果一致。
+func (r *SignResult) CalculateWeightedAverageScaled(decimals int) *big.Int {
+	weightedSum := new(big.Int)
+	totalWeight := new(big.Int)
+
+	for i, price := range r.ToBigIntPrices(decimals) {
+		weight := big.NewInt(1)
+		if i < len(r.Weights) {
+			weight = new(big.Int).SetUint64(r.Weights[i])
+		}
+		weightedSum.Add(weightedSum, new(big.Int).Mul(price, weight))
+		totalWeight.Add(totalWeight, weight)
+	}
+
+	if totalWeight.Sign() == 0 {
+		return new(big.Int)
+	}
+	return weightedSum.Div(weightedSum, totalWeight)
+}
+
 type Method string
 
 const (
@@ -124,6 +183,7 @@ type Context struct {
 	unApprovers    []string
 	electionId     uint64
 	stateBatchRoot [32]byte
+	quorum         int
 }
 
 func NewContext() Context {
@@ -151,6 +211,15 @@ func (c Context) StateBatchRoot() [32]byte {
 	return c.stateBatchRoot
 }
 
+func (c Context) Quorum() int {
+	return c.quorum
+}
+
+func (c Context) WithQuorum(quorum int) Context {
+	c.quorum = quorum
+	return c
+}
+
 func (c Context) WithRequestId(requestId string) Context {
 	c.requestId = requestId
 	return c
@@ -0,0 +1,89 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// onChainWeightedAverage 与 OraclePod._calculateWeightedAverage 相同的 uint256 运算
+func onChainWeightedAverage(prices, weights []int64) *big.Int {
+	weightedSum, totalWeight := new(big.Int), new(big.Int)
+	for i := range prices {
+		weightedSum.Add(weightedSum, new(big.Int).Mul(big.NewInt(prices[i]), big.NewInt(weights[i])))
+		totalWeight.Add(totalWeight, big.NewInt(weights[i]))
+	}
+	return weightedSum.Div(weightedSum, totalWeight)
+}
+
+func TestCalculateWeightedAverageScaled_MatchesContract(t *testing.T) {
+	tests := []struct {
+		name     string
+		prices   []float64
+		weights  []uint64
+		onChain  []int64 // 提交到合约的 6 位小数价格
+		expected string
+	}{
+		{
+			// (1500000*1 + 2250000*2 + 3333333*3) / 6 = 2666666.5，链上向下取整
+			name:     "truncates like uint256 division",
+			prices:   []float64{1.5, 2.25, 3.333333},
+			weights:  []uint64{1, 2, 3},
+			onChain:  []int64{1500000, 2250000, 3333333},
+			expected: "2666666",
+		},
+		{
+			// 0.29 * 1e6 在浮点下为 289999.99…
+			name:     "rounds float prices to 6 decimals",
+			prices:   []float64{0.29, 0.31},
+			weights:  []uint64{1, 1},
+			onChain:  []int64{290000, 310000},
+			expected: "300000",
+		},
+		{
+			name:     "single price",
+			prices:   []float64{1821.123456},
+			weights:  []uint64{1},
+			onChain:  []int64{1821123456},
+			expected: "1821123456",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &SignResult{Prices: tt.prices, Weights: tt.weights}
+
+			weights := make([]int64, len(tt.weights))
+			for i, w := range tt.weights {
+				weights[i] = int64(w)
+			}
+
+			assert.Equal(t, tt.onChain, bigInts(res.ToBigIntPrices(PriceDecimals)))
+			assert.Equal(t, onChainWeightedAverage(tt.onChain, weights), res.CalculateWeightedAverageScaled(PriceDecimals))
+			assert.Equal(t, tt.expected, res.CalculateWeightedAverageScaled(PriceDecimals).String())
+		})
+	}
+}
+
+func TestCalculateWeightedAverageScaled_DiffersFromFloat(t *testing.T) {
+	res := &SignResult{Prices: []float64{1.5, 2.25, 3.333333}, Weights: []uint64{1, 2, 3}}
+
+	// 浮点平均 2.6666665 按 %f 输出会进位，与链上结果不一致
+	assert.Equal(t, "2.666667", fmt.Sprintf("%f", res.CalculateWeightedAverage()))
+	assert.Equal(t, "2666666", res.CalculateWeightedAverageScaled(PriceDecimals).String())
+}
+
+func TestCalculateWeightedAverageScaled_Empty(t *testing.T) {
+	assert.Equal(t, "0", (&SignResult{}).CalculateWeightedAverageScaled(PriceDecimals).String())
+	assert.Equal(t, "0", (&SignResult{Prices: []float64{1}, Weights: []uint64{0}}).CalculateWeightedAverageScaled(PriceDecimals).String())
+}
+
+func bigInts(values []*big.Int) []int64 {
+	result := make([]int64, len(values))
+	for i, v := range values {
+		result[i] = v.Int64()
+	}
+	return result
+}
@@ -20,25 +20,29 @@ func (n *Node) ProcessMessage() {
 		return
 	}
 
-	go func() {
-		defer func() {
-			close(stopChan)
-		}()
-		for {
-			select {
-			case rpcReq := <-reqChan:
-				reqId := rpcReq.ID.(tdtypes.JSONRPCStringID).String()
-				n.log.Info(fmt.Sprintf("receive request method : %s", rpcReq.Method), "reqId", reqId)
-				if rpcReq.Method == types.NotifyNodeSubmitPriceWithSignature.String() {
-					if err := n.writeChan(n.signRequestChan, rpcReq); err != nil {
-						n.log.Error("failed to write msg to sign channel,channel blocked ", "err", err)
-					}
-				} else {
-					n.log.Error(fmt.Sprintf("unknown rpc request method : %s ", rpcReq.Method))
+	defer close(stopChan)
+
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case rpcReq := <-reqChan:
+			id, ok := rpcReq.ID.(tdtypes.JSONRPCStringID)
+			if !ok {
+				n.log.Error("unexpected rpc request id", "id", rpcReq.ID)
+				continue
+			}
+			reqId := id.String()
+			n.log.Info(fmt.Sprintf("receive request method : %s", rpcReq.Method), "reqId", reqId)
+			if rpcReq.Method == types.NotifyNodeSubmitPriceWithSignature.String() {
+				if err := n.writeChan(n.signRequestChan, rpcReq); err != nil {
+					n.log.Error("failed to write msg to sign channel,channel blocked ", "err", err)
 				}
+			} else {
+				n.log.Error(fmt.Sprintf("unknown rpc request method : %s ", rpcReq.Method))
 			}
 		}
-	}()
+	}
 }
 
 func (n *Node) writeChan(cache chan tdtypes.RPCRequest, msg tdtypes.RPCRequest) error {
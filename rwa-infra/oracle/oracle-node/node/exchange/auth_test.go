@@ -0,0 +1,144 @@
+package exchange
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cpchain-network/oracle-node/config"
+)
+
+const testPriceBody = `{"code":0,"data":[{"symbol":"XAU","last-price":"2391.57"}]}`
+
+// newAuthServer 只有 authorized 通过时返回价格，否则返回 401
+func newAuthServer(t *testing.T, authorized func(r *http.Request, body string) bool) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !authorized(r, string(body)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"unauthorized"}`))
+			return
+		}
+		w.Write([]byte(testPriceBody))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDataProvider_APIKeyAuth(t *testing.T) {
+	server := newAuthServer(t, func(r *http.Request, _ string) bool {
+		return r.Header.Get("X-MBX-APIKEY") == "key-1"
+	})
+	cfg := config.DataSourceConfig{
+		URL:       server.URL + "/api/v3/ticker?symbol=XAU",
+		PricePath: "$.data[0]['last-price']",
+		Auth:      config.DataSourceAuthConfig{Type: "api_key", APIKey: "key-1", KeyHeader: "X-MBX-APIKEY"},
+	}
+
+	provider, err := NewDataProvider(cfg, NewHTTPClient(testHTTPClient, time.Second))
+	require.NoError(t, err)
+	price, err := provider.GetPrice()
+	require.NoError(t, err)
+	assert.Equal(t, 2391.57, price)
+
+	// 未配置鉴权时被拒绝
+	cfg.Auth = config.DataSourceAuthConfig{}
+	provider, err = NewDataProvider(cfg, NewHTTPClient(testHTTPClient, time.Second))
+	require.NoError(t, err)
+	_, err = provider.GetPrice()
+	assert.ErrorIs(t, err, ErrHTTPRequest)
+}
+
+func TestDataProvider_APIKeyQueryAndBearer(t *testing.T) {
+	server := newAuthServer(t, func(r *http.Request, _ string) bool {
+		return r.URL.Query().Get("apikey") == "key-2" || r.Header.Get("Authorization") == "Bearer token-3"
+	})
+
+	for _, auth := range []config.DataSourceAuthConfig{
+		{Type: "api_key", APIKey: "key-2", KeyQuery: "apikey"},
+		{Type: "bearer", APIKey: "token-3"},
+	} {
+		provider, err := NewDataProvider(config.DataSourceConfig{URL: server.URL + "/price?symbol=XAU", PricePath: "data.0.last-price", Auth: auth}, nil)
+		require.NoError(t, err, auth.Type)
+		price, err := provider.GetPrice()
+		require.NoError(t, err, auth.Type)
+		assert.Equal(t, 2391.57, price, auth.Type)
+	}
+}
+
+func TestDataProvider_HMACAuth(t *testing.T) {
+	const secret = "secret-1"
+	server := newAuthServer(t, func(r *http.Request, body string) bool {
+		timestamp := r.Header.Get("OK-ACCESS-TIMESTAMP")
+		if r.Header.Get("OK-ACCESS-KEY") != "key-1" || timestamp == "" {
+			return false
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp + r.Method + r.URL.RequestURI() + body))
+		return r.Header.Get("OK-ACCESS-SIGN") == hex.EncodeToString(mac.Sum(nil))
+	})
+
+	cfg := config.DataSourceConfig{
+		URL:       server.URL + "/api/v5/market/ticker?instId=XAU-USDT",
+		Method:    "POST",
+		Body:      `{"instId":"XAU-USDT"}`,
+		PricePath: "$.data[0].last-price",
+		Auth: config.DataSourceAuthConfig{
+			Type:            "hmac",
+			APIKey:          "key-1",
+			APISecret:       secret,
+			KeyHeader:       "OK-ACCESS-KEY",
+			SignatureHeader: "OK-ACCESS-SIGN",
+			TimestampHeader: "OK-ACCESS-TIMESTAMP",
+		},
+	}
+
+	provider, err := NewDataProvider(cfg, NewHTTPClient(testHTTPClient, time.Second))
+	require.NoError(t, err)
+	price, err := provider.GetPrice()
+	require.NoError(t, err)
+	assert.Equal(t, 2391.57, price)
+
+	// 签名密钥错误
+	cfg.Auth.APISecret = "wrong"
+	provider, err = NewDataProvider(cfg, NewHTTPClient(testHTTPClient, time.Second))
+	require.NoError(t, err)
+	_, err = provider.GetPrice()
+	assert.ErrorIs(t, err, ErrHTTPRequest)
+}
+
+func TestRequestAuth_Sign(t *testing.T) {
+	auth, err := newRequestAuth(config.DataSourceAuthConfig{Type: "hmac", APISecret: "s"}, "https://api.example.com/v1/price?symbol=XAU")
+	require.NoError(t, err)
+
+	mac := hmac.New(sha256.New, []byte("s"))
+	mac.Write([]byte(strconv.FormatInt(1700000000000, 10) + "GET/v1/price?symbol=XAU"))
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), auth.sign("1700000000000", "get", ""))
+}
+
+func TestNewRequestAuth_Invalid(t *testing.T) {
+	for _, cfg := range []config.DataSourceAuthConfig{
+		{Type: "api_key"},
+		{Type: "bearer"},
+		{Type: "hmac", APIKey: "k"},
+		{Type: "hmac", APISecret: "s", SignatureEncoding: "base32"},
+		{Type: "oauth"},
+	} {
+		_, err := newRequestAuth(cfg, "https://api.example.com")
+		assert.Error(t, err, cfg.Type)
+	}
+
+	auth, err := newRequestAuth(config.DataSourceAuthConfig{}, "https://api.example.com")
+	require.NoError(t, err)
+	assert.Nil(t, auth)
+}
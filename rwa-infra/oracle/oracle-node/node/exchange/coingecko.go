@@ -0,0 +1,191 @@
+package exchange
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cpchain-network/oracle-node/config"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+
+	gresty "github.com/go-resty/resty/v2"
+)
+
+const (
+	coinGeckoBaseURL = "https://api.coingecko.com/api/v3"
+	// coinGeckoMaxRetryWait Retry-After 不超过该时长时等待后重试一次，否则直接返回限流错误
+	coinGeckoMaxRetryWait = 5 * time.Second
+	// coinGeckoDefaultRetryAfter 429 未携带 Retry-After 时的冷却时间
+	coinGeckoDefaultRetryAfter = time.Minute
+)
+
+// CoinGeckoProvider CoinGecko 公共 API 价格提供者
+// 使用 /simple/price 接口，按币种 ID（如 bitcoin）而非交易对符号查询。
+type CoinGeckoProvider struct {
+	client       *gresty.Client
+	http         *HTTPClient
+	coinID       string
+	vsCurrency   string
+	assetType    string
+	assetName    string
+	maxRetryWait time.Duration
+
+	mu           sync.Mutex
+	blockedUntil time.Time // 被限流后在此之前不再请求
+}
+
+// NewCoinGeckoProvider 创建 CoinGecko 价格提供者，httpClient 为 nil 时使用默认超时与重试
+func NewCoinGeckoProvider(cfg config.DataSourceConfig, httpClient *HTTPClient) (*CoinGeckoProvider, error) {
+	if cfg.CoinID == "" {
+		return nil, errors.New("coingecko coin_id is required")
+	}
+
+	coinID := strings.ToLower(cfg.CoinID)
+	vsCurrency := strings.ToLower(cfg.VsCurrency)
+	if vsCurrency == "" {
+		vsCurrency = "usd"
+	}
+
+	baseURL := cfg.URL
+	if baseURL == "" {
+		baseURL = coinGeckoBaseURL
+	}
+
+	httpClient = httpClient.orDefault()
+	client := httpClient.newResty()
+	client.SetBaseURL(baseURL)
+	for key, value := range cfg.Headers {
+		client.SetHeader(key, value)
+	}
+	if cfg.APIKey != "" {
+		client.SetHeader("x-cg-demo-api-key", cfg.APIKey)
+	}
+
+	assetType := cfg.AssetType
+	if assetType == "" {
+		assetType = "crypto"
+	}
+	assetName := cfg.AssetName
+	if assetName == "" {
+		assetName = strings.ToUpper(coinID) + "/" + strings.ToUpper(vsCurrency)
+	}
+
+	return &CoinGeckoProvider{
+		client:       client,
+		http:         httpClient,
+		coinID:       coinID,
+		vsCurrency:   vsCurrency,
+		assetType:    assetType,
+		assetName:    assetName,
+		maxRetryWait: coinGeckoMaxRetryWait,
+	}, nil
+}
+
+// GetPrice 获取价格
+func (p *CoinGeckoProvider) GetPrice() (float64, error) {
+	quote, err := p.GetQuote()
+	if err != nil {
+		return 0, err
+	}
+	return quote.Price, nil
+}
+
+// GetQuote 获取价格及 CoinGecko 报告的更新时间
+// 遇到 429 时遵守 Retry-After：等待时间较短则等待后重试一次，否则在冷却结束前直接返回 ErrRateLimited。
+func (p *CoinGeckoProvider) GetQuote() (Quote, error) {
+	ctx, cancel := p.http.context()
+	defer cancel()
+
+	for retried := false; ; retried = true {
+		if wait := p.cooldown(); wait > 0 {
+			return Quote{}, fmt.Errorf("retry after %s: %w", wait.Round(time.Second), ErrRateLimited)
+		}
+
+		resp, err := p.client.R().
+			SetContext(ctx).
+			SetQueryParam("ids", p.coinID).
+			SetQueryParam("vs_currencies", p.vsCurrency).
+			SetQueryParam("include_last_updated_at", "true").
+			Get("/simple/price")
+		if err != nil {
+			return Quote{}, fmt.Errorf("request failed: %w", err)
+		}
+
+		if resp.StatusCode() == http.StatusTooManyRequests {
+			wait := parseRetryAfter(resp.Header().Get("Retry-After"), time.Now())
+			if !retried && wait <= p.maxRetryWait {
+				select {
+				case <-time.After(wait):
+					continue
+				case <-ctx.Done():
+				}
+			}
+			p.block(wait)
+			return Quote{}, fmt.Errorf("retry after %s: %w", wait.Round(time.Second), ErrRateLimited)
+		}
+		if resp.StatusCode() >= 400 {
+			return Quote{}, fmt.Errorf("%d %s: %w", resp.StatusCode(), resp.Request.URL, ErrHTTPRequest)
+		}
+
+		return p.parseQuote(resp.String())
+	}
+}
+
+// parseQuote 解析 {"<coin_id>":{"<vs_currency>":<price>,"last_updated_at":<unix>}}
+func (p *CoinGeckoProvider) parseQuote(body string) (Quote, error) {
+	if body == "" {
+		return Quote{}, ErrEmptyResponse
+	}
+
+	coin := gjson.Get(body, gjson.Escape(p.coinID))
+	result := coin.Get(gjson.Escape(p.vsCurrency))
+	if !result.Exists() {
+		return Quote{}, fmt.Errorf("price for %s/%s not found in response: %w", p.coinID, p.vsCurrency, ErrParseFailed)
+	}
+
+	price := result.Float()
+	if price <= 0 {
+		return Quote{}, fmt.Errorf("price is %f: %w", price, ErrInvalidPrice)
+	}
+
+	quote := Quote{Price: price}
+	if updatedAt := coin.Get("last_updated_at"); updatedAt.Exists() {
+		quote.UpdatedAt = time.Unix(updatedAt.Int(), 0)
+	}
+	return quote, nil
+}
+
+// GetAssetInfo 获取资产信息
+func (p *CoinGeckoProvider) GetAssetInfo() (assetType, assetName string) {
+	return p.assetType, p.assetName
+}
+
+func (p *CoinGeckoProvider) cooldown() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return time.Until(p.blockedUntil)
+}
+
+func (p *CoinGeckoProvider) block(wait time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.blockedUntil = time.Now().Add(wait)
+}
+
+// parseRetryAfter 解析 Retry-After（秒数或 HTTP 日期），缺失或无法解析时使用默认冷却时间
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return coinGeckoDefaultRetryAfter
+	}
+	if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return coinGeckoDefaultRetryAfter
+}
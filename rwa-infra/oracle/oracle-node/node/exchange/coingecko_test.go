@@ -0,0 +1,122 @@
+package exchange
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cpchain-network/oracle-node/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCoinGeckoServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request, hit int32)) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	hits := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r, hits.Add(1))
+	}))
+	t.Cleanup(server.Close)
+	return server, hits
+}
+
+func TestCoinGeckoProvider_GetPrice(t *testing.T) {
+	server, _ := newCoinGeckoServer(t, func(w http.ResponseWriter, r *http.Request, _ int32) {
+		assert.Equal(t, "/simple/price", r.URL.Path)
+		assert.Equal(t, "tether-gold", r.URL.Query().Get("ids"))
+		assert.Equal(t, "usd", r.URL.Query().Get("vs_currencies"))
+		assert.Equal(t, "true", r.URL.Query().Get("include_last_updated_at"))
+		assert.Equal(t, "demo-key", r.Header.Get("x-cg-demo-api-key"))
+		w.Write([]byte(`{"tether-gold":{"usd":2391.57,"last_updated_at":1714564800}}`))
+	})
+
+	provider, err := NewCoinGeckoProvider(config.DataSourceConfig{URL: server.URL, CoinID: "tether-gold", APIKey: "demo-key"}, nil)
+	require.NoError(t, err)
+
+	price, err := provider.GetPrice()
+	require.NoError(t, err)
+	assert.Equal(t, 2391.57, price)
+
+	quote, err := provider.GetQuote()
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1714564800, 0), quote.UpdatedAt)
+
+	assetType, assetName := provider.GetAssetInfo()
+	assert.Equal(t, "crypto", assetType)
+	assert.Equal(t, "TETHER-GOLD/USD", assetName)
+}
+
+func TestCoinGeckoProvider_MissingPrice(t *testing.T) {
+	server, _ := newCoinGeckoServer(t, func(w http.ResponseWriter, r *http.Request, _ int32) {
+		w.Write([]byte(`{}`))
+	})
+
+	provider, err := NewCoinGeckoProvider(config.DataSourceConfig{URL: server.URL, CoinID: "unknown-coin", VsCurrency: "EUR"}, nil)
+	require.NoError(t, err)
+
+	_, err = provider.GetPrice()
+	assert.ErrorIs(t, err, ErrParseFailed)
+}
+
+func TestCoinGeckoProvider_RetriesShortRetryAfter(t *testing.T) {
+	server, hits := newCoinGeckoServer(t, func(w http.ResponseWriter, r *http.Request, hit int32) {
+		if hit == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"bitcoin":{"usd":67000}}`))
+	})
+
+	provider, err := NewCoinGeckoProvider(config.DataSourceConfig{URL: server.URL, CoinID: "bitcoin"}, nil)
+	require.NoError(t, err)
+
+	price, err := provider.GetPrice()
+	require.NoError(t, err)
+	assert.Equal(t, float64(67000), price)
+	assert.Equal(t, int32(2), hits.Load())
+}
+
+func TestCoinGeckoProvider_BacksOffUntilRetryAfter(t *testing.T) {
+	server, hits := newCoinGeckoServer(t, func(w http.ResponseWriter, r *http.Request, _ int32) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	provider, err := NewCoinGeckoProvider(config.DataSourceConfig{URL: server.URL, CoinID: "bitcoin"}, nil)
+	require.NoError(t, err)
+
+	_, err = provider.GetPrice()
+	assert.ErrorIs(t, err, ErrRateLimited)
+
+	// 冷却期内不再请求
+	_, err = provider.GetPrice()
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.Equal(t, int32(1), hits.Load())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 30*time.Second, parseRetryAfter("30", now))
+	assert.Equal(t, 90*time.Second, parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+	assert.Equal(t, coinGeckoDefaultRetryAfter, parseRetryAfter("", now))
+	assert.Equal(t, coinGeckoDefaultRetryAfter, parseRetryAfter("soon", now))
+}
+
+func TestNewProviderFromConfig_CoinGecko(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Node.DataSource = config.DataSourceConfig{Type: "coingecko", CoinID: "bitcoin"}
+
+	provider, err := NewProviderFromConfig(cfg)
+	require.NoError(t, err)
+	assert.IsType(t, &CoinGeckoProvider{}, provider)
+
+	cfg.Node.DataSource.Type = "binance"
+	_, err = NewProviderFromConfig(cfg)
+	assert.Error(t, err)
+}
@@ -38,11 +38,13 @@ type CoinUpClient interface {
 
 type Client struct {
 	client *gresty.Client
+	http   *HTTPClient
 	db     *store.Storage
 }
 
-func NewCoinUpClient(baseUrl string) (*Client, error) {
-	client := gresty.New()
+func NewCoinUpClient(baseUrl string, httpClient *HTTPClient) (*Client, error) {
+	httpClient = httpClient.orDefault()
+	client := httpClient.newResty()
 	client.SetBaseURL(baseUrl)
 	client.OnAfterResponse(func(c *gresty.Client, r *gresty.Response) error {
 		statusCode := r.StatusCode()
@@ -55,12 +57,16 @@ func NewCoinUpClient(baseUrl string) (*Client, error) {
 	})
 	return &Client{
 		client: client,
+		http:   httpClient,
 	}, nil
 }
 
 func (c *Client) GetMarketPrice() (float64, error) {
 	var metaData MetaData
+	ctx, cancel := c.http.context()
+	defer cancel()
 	response, err := c.client.R().
+		SetContext(ctx).
 		SetResult(&metaData).
 		SetQueryParam("symbol", "cpusdt").
 		Get("/open/api/get_ticker")
@@ -0,0 +1,85 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/cpchain-network/oracle-node/config"
+
+	gresty "github.com/go-resty/resty/v2"
+)
+
+const (
+	defaultHTTPTimeout     = 5 * time.Second
+	defaultHTTPMaxRetries  = 2
+	defaultRetryBackoff    = 200 * time.Millisecond
+	defaultMaxRetryBackoff = 2 * time.Second
+)
+
+// HTTPClient 各数据源共享的 HTTP 客户端
+// 共享同一个 http.Client（连接池及单次请求超时），对 5xx 和连接错误做有限次退避重试，
+// 并用 deadline 限制包括重试在内的总时长，避免慢数据源拖过节点签名超时。
+type HTTPClient struct {
+	client   *http.Client
+	cfg      config.HTTPClientConfig
+	deadline time.Duration
+}
+
+// NewHTTPClient 创建共享 HTTP 客户端，deadline 为 0 时不限制总时长
+func NewHTTPClient(cfg config.HTTPClientConfig, deadline time.Duration) *HTTPClient {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultHTTPTimeout
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = defaultHTTPMaxRetries
+	} else if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBackoff == 0 {
+		cfg.RetryBackoff = defaultRetryBackoff
+	}
+	if cfg.MaxRetryBackoff == 0 {
+		cfg.MaxRetryBackoff = defaultMaxRetryBackoff
+	}
+
+	return &HTTPClient{
+		client:   &http.Client{Timeout: cfg.Timeout},
+		cfg:      cfg,
+		deadline: deadline,
+	}
+}
+
+// newResty 基于共享 http.Client 创建带重试的 resty 客户端
+func (c *HTTPClient) newResty() *gresty.Client {
+	return gresty.NewWithClient(c.client).
+		SetRetryCount(c.cfg.MaxRetries).
+		SetRetryWaitTime(c.cfg.RetryBackoff).
+		SetRetryMaxWaitTime(c.cfg.MaxRetryBackoff).
+		AddRetryCondition(retryable)
+}
+
+// context 单次取价的上下文，包括重试在内不超过 deadline
+func (c *HTTPClient) context() (context.Context, context.CancelFunc) {
+	if c.deadline <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), c.deadline)
+}
+
+// retryable 只重试 5xx、连接错误和单次请求超时；4xx（包括 429）由调用方处理。
+// 总时限到期后 resty 不再重试。
+func retryable(resp *gresty.Response, err error) bool {
+	if resp != nil && resp.StatusCode() > 0 {
+		return resp.StatusCode() >= http.StatusInternalServerError
+	}
+	return err != nil
+}
+
+// orDefault nil 时使用默认配置
+func (c *HTTPClient) orDefault() *HTTPClient {
+	if c == nil {
+		return NewHTTPClient(config.HTTPClientConfig{}, 0)
+	}
+	return c
+}
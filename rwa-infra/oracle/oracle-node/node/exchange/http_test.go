@@ -0,0 +1,97 @@
+package exchange
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cpchain-network/oracle-node/config"
+)
+
+var testHTTPClient = config.HTTPClientConfig{
+	Timeout:         100 * time.Millisecond,
+	MaxRetries:      2,
+	RetryBackoff:    10 * time.Millisecond,
+	MaxRetryBackoff: 20 * time.Millisecond,
+}
+
+func newPriceServer(t *testing.T, handler func(w http.ResponseWriter, hit int32)) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	hits := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, hits.Add(1))
+	}))
+	t.Cleanup(server.Close)
+	return server, hits
+}
+
+func TestDataProvider_RetriesSlowResponse(t *testing.T) {
+	server, hits := newPriceServer(t, func(w http.ResponseWriter, hit int32) {
+		if hit == 1 {
+			time.Sleep(300 * time.Millisecond) // 超过单次请求超时
+		}
+		w.Write([]byte(`{"data":{"price":2391.57}}`))
+	})
+
+	provider, err := NewDataProvider(config.DataSourceConfig{URL: server.URL, PricePath: "data.price"}, NewHTTPClient(testHTTPClient, time.Second))
+	require.NoError(t, err)
+
+	price, err := provider.GetPrice()
+	require.NoError(t, err)
+	assert.Equal(t, 2391.57, price)
+	assert.Equal(t, int32(2), hits.Load())
+}
+
+func TestDataProvider_RetriesServerError(t *testing.T) {
+	server, hits := newPriceServer(t, func(w http.ResponseWriter, hit int32) {
+		if hit < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"data":{"price":2391.57}}`))
+	})
+
+	provider, err := NewDataProvider(config.DataSourceConfig{URL: server.URL, PricePath: "data.price"}, NewHTTPClient(testHTTPClient, time.Second))
+	require.NoError(t, err)
+
+	price, err := provider.GetPrice()
+	require.NoError(t, err)
+	assert.Equal(t, 2391.57, price)
+	assert.Equal(t, int32(3), hits.Load())
+}
+
+func TestDataProvider_DoesNotRetryClientError(t *testing.T) {
+	server, hits := newPriceServer(t, func(w http.ResponseWriter, _ int32) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	provider, err := NewDataProvider(config.DataSourceConfig{URL: server.URL, PricePath: "data.price"}, NewHTTPClient(testHTTPClient, time.Second))
+	require.NoError(t, err)
+
+	_, err = provider.GetPrice()
+	assert.ErrorIs(t, err, ErrHTTPRequest)
+	assert.Equal(t, int32(1), hits.Load())
+}
+
+func TestDataProvider_DeadlineBoundsRetries(t *testing.T) {
+	server, _ := newPriceServer(t, func(w http.ResponseWriter, _ int32) {
+		time.Sleep(300 * time.Millisecond)
+		w.Write([]byte(`{"data":{"price":2391.57}}`))
+	})
+
+	cfg := testHTTPClient
+	cfg.MaxRetries = 10
+	provider, err := NewDataProvider(config.DataSourceConfig{URL: server.URL, PricePath: "data.price"}, NewHTTPClient(cfg, 250*time.Millisecond))
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = provider.GetPrice()
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 400*time.Millisecond, "retries must stop at the sign deadline")
+}
@@ -0,0 +1,62 @@
+package exchange
+
+import (
+	"fmt"
+	"strings"
+)
+
+// compileFieldPath 将字段路径转为 gjson 路径
+// 以 "$" 开头的按 JSONPath 解析，支持 "$.data.price"、"$.data[0].price"、"$['data']['last-price']"；
+// 其余视为 gjson 语法原样返回。
+func compileFieldPath(path string) (string, error) {
+	if !strings.HasPrefix(path, "$") {
+		return path, nil
+	}
+
+	var segments []string
+	rest := path[1:]
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return "", fmt.Errorf("invalid json path %q: missing ']'", path)
+			}
+			segment := strings.TrimSpace(rest[1:end])
+			if len(segment) >= 2 && (segment[0] == '\'' || segment[0] == '"') && segment[len(segment)-1] == segment[0] {
+				segment = segment[1 : len(segment)-1]
+			}
+			if segment == "" {
+				return "", fmt.Errorf("invalid json path %q: empty segment", path)
+			}
+			segments = append(segments, escapeGJSON(segment))
+			rest = rest[end+1:]
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			segments = append(segments, escapeGJSON(rest[:end]))
+			rest = rest[end:]
+		}
+	}
+	if len(segments) == 0 {
+		return "", fmt.Errorf("invalid json path %q: no field", path)
+	}
+	return strings.Join(segments, "."), nil
+}
+
+// escapeGJSON 转义 gjson 路径中的特殊字符，字段名按字面匹配
+func escapeGJSON(segment string) string {
+	var b strings.Builder
+	for _, c := range segment {
+		switch c {
+		case '.', '*', '?', '|', '#', '@', '\\', '!', '=', '<', '>', '%':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
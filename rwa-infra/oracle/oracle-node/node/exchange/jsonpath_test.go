@@ -0,0 +1,34 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestCompileFieldPath(t *testing.T) {
+	body := `{"data":[{"last-price":"2391.57","quote":{"ask.px":2392}}],"result":{"XAUUSD":{"c":["2390.10","1"]}}}`
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"data.0.last-price", "2391.57"}, // gjson 语法原样使用
+		{"$.data[0].last-price", "2391.57"},
+		{"$['data'][0]['last-price']", "2391.57"},
+		{`$.data[0].quote["ask.px"]`, "2392"},
+		{"$.result.XAUUSD.c[0]", "2390.10"},
+	}
+	for _, tt := range tests {
+		compiled, err := compileFieldPath(tt.path)
+		require.NoError(t, err, tt.path)
+		assert.Equal(t, tt.expected, gjson.Get(body, compiled).String(), tt.path)
+	}
+
+	for _, path := range []string{"$", "$.data[0", "$.data[]"} {
+		_, err := compileFieldPath(path)
+		assert.Error(t, err, path)
+	}
+}
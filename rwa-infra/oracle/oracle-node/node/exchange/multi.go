@@ -0,0 +1,160 @@
+package exchange
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/cpchain-network/oracle-node/config"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/pkg/errors"
+)
+
+var ErrInsufficientSources = errors.New("not enough valid price sources")
+
+// AggregatedPrice 多数据源聚合结果
+type AggregatedPrice struct {
+	Price   float64 // 有效数据源价格的中位数
+	Sources int     // 参与计算的有效数据源数
+	Total   int     // 配置的数据源总数
+	// 有效数据源中最旧的价格时间，零值表示均未报告
+	UpdatedAt time.Time
+}
+
+// MultiSourceProvider 多数据源价格提供者
+// 并发请求所有数据源，剔除出错、超时或偏离中位数过大的数据源后返回中位数，避免单一数据源出错或被操纵。
+type MultiSourceProvider struct {
+	sources       []PriceProvider
+	sourceTimeout time.Duration
+	maxDeviation  float64 // 允许偏离中位数的比例
+	minSources    int
+}
+
+// NewMultiSourceProvider 创建多数据源价格提供者
+func NewMultiSourceProvider(sources []PriceProvider, cfg config.AggregationConfig) (*MultiSourceProvider, error) {
+	if len(sources) == 0 {
+		return nil, errors.New("at least one price source is required")
+	}
+	if cfg.MinSources > len(sources) {
+		return nil, fmt.Errorf("min_sources %d exceeds the %d configured sources", cfg.MinSources, len(sources))
+	}
+
+	return &MultiSourceProvider{
+		sources:       sources,
+		sourceTimeout: cfg.SourceTimeout,
+		maxDeviation:  cfg.MaxDeviation / 100,
+		minSources:    max(cfg.MinSources, 1),
+	}, nil
+}
+
+// GetPrice 实现 PriceProvider 接口
+func (p *MultiSourceProvider) GetPrice() (float64, error) {
+	result, err := p.GetAggregatedPrice()
+	if err != nil {
+		return 0, err
+	}
+	return result.Price, nil
+}
+
+// GetAggregatedPrice 获取聚合价格及参与计算的数据源数
+func (p *MultiSourceProvider) GetAggregatedPrice() (*AggregatedPrice, error) {
+	quotes := p.fetchAll()
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("0 of %d sources returned a price: %w", len(p.sources), ErrInsufficientSources)
+	}
+
+	// 剔除偏离中位数过大的数据源
+	prices := make([]float64, 0, len(quotes))
+	for _, quote := range quotes {
+		prices = append(prices, quote.Price)
+	}
+	mid := median(prices)
+
+	var valid []float64
+	var updatedAt time.Time
+	for _, quote := range quotes {
+		if p.maxDeviation > 0 && math.Abs(quote.Price-mid)/mid > p.maxDeviation {
+			log.Warn("discarding price source deviating from median", "price", quote.Price, "median", mid, "maxDeviation", p.maxDeviation)
+			continue
+		}
+		valid = append(valid, quote.Price)
+		if !quote.UpdatedAt.IsZero() && (updatedAt.IsZero() || quote.UpdatedAt.Before(updatedAt)) {
+			updatedAt = quote.UpdatedAt
+		}
+	}
+
+	if len(valid) < p.minSources {
+		return nil, fmt.Errorf("%d of %d sources valid, need %d: %w", len(valid), len(p.sources), p.minSources, ErrInsufficientSources)
+	}
+
+	return &AggregatedPrice{
+		Price:     median(valid),
+		Sources:   len(valid),
+		Total:     len(p.sources),
+		UpdatedAt: updatedAt,
+	}, nil
+}
+
+// fetchAll 并发请求所有数据源，返回按时返回的有效价格
+func (p *MultiSourceProvider) fetchAll() []Quote {
+	type result struct {
+		index int
+		quote Quote
+		err   error
+	}
+
+	// 带缓冲，超时的数据源返回后不会阻塞
+	results := make(chan result, len(p.sources))
+	for i, source := range p.sources {
+		go func(i int, source PriceProvider) {
+			quote, err := GetQuote(source)
+			results <- result{i, quote, err}
+		}(i, source)
+	}
+
+	var timeout <-chan time.Time
+	if p.sourceTimeout > 0 {
+		timer := time.NewTimer(p.sourceTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	quotes := make([]Quote, 0, len(p.sources))
+	for pending := len(p.sources); pending > 0; pending-- {
+		select {
+		case r := <-results:
+			if r.err != nil {
+				_, name := p.sources[r.index].GetAssetInfo()
+				log.Warn("price source failed", "source", r.index, "asset", name, "err", r.err)
+				continue
+			}
+			if price := r.quote.Price; price <= 0 || math.IsNaN(price) || math.IsInf(price, 0) {
+				log.Warn("price source returned invalid price", "source", r.index, "price", price)
+				continue
+			}
+			quotes = append(quotes, r.quote)
+		case <-timeout:
+			log.Warn("price sources timed out", "pending", pending, "timeout", p.sourceTimeout)
+			return quotes
+		}
+	}
+	return quotes
+}
+
+// GetAssetInfo 实现 PriceProvider 接口，使用第一个数据源的资产信息
+func (p *MultiSourceProvider) GetAssetInfo() (assetType, assetName string) {
+	return p.sources[0].GetAssetInfo()
+}
+
+// median 中位数，偶数个时取中间两个的平均值
+func median(prices []float64) float64 {
+	sorted := append([]float64(nil), prices...)
+	sort.Float64s(sorted)
+
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
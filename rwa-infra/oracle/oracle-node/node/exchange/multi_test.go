@@ -0,0 +1,122 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cpchain-network/oracle-node/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubProvider 固定返回价格或错误，可模拟慢数据源
+type stubProvider struct {
+	price     float64
+	updatedAt time.Time
+	err       error
+	delay     time.Duration
+}
+
+func (s stubProvider) GetPrice() (float64, error) {
+	quote, err := s.GetQuote()
+	return quote.Price, err
+}
+
+func (s stubProvider) GetQuote() (Quote, error) {
+	time.Sleep(s.delay)
+	return Quote{Price: s.price, UpdatedAt: s.updatedAt}, s.err
+}
+
+func (s stubProvider) GetAssetInfo() (assetType, assetName string) {
+	return "gold", "XAU/USD"
+}
+
+var testAggregation = config.AggregationConfig{SourceTimeout: 200 * time.Millisecond, MaxDeviation: 5, MinSources: 2}
+
+func TestMultiSourceProvider_DiscardsOutlier(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	provider, err := NewMultiSourceProvider([]PriceProvider{
+		stubProvider{price: 2390, updatedAt: now.Add(-time.Minute)},
+		stubProvider{price: 2400},
+		stubProvider{price: 2410, updatedAt: now},
+		stubProvider{price: 9999, updatedAt: now.Add(-time.Hour)}, // 被操纵的数据源
+	}, testAggregation)
+	require.NoError(t, err)
+
+	result, err := provider.GetAggregatedPrice()
+	require.NoError(t, err)
+	assert.Equal(t, float64(2400), result.Price)
+	assert.Equal(t, 3, result.Sources)
+	assert.Equal(t, 4, result.Total)
+	assert.Equal(t, now.Add(-time.Minute), result.UpdatedAt)
+
+	price, err := provider.GetPrice()
+	require.NoError(t, err)
+	assert.Equal(t, float64(2400), price)
+}
+
+func TestMultiSourceProvider_IgnoresFailingAndSlowSources(t *testing.T) {
+	provider, err := NewMultiSourceProvider([]PriceProvider{
+		stubProvider{price: 2400},
+		stubProvider{price: 2420},
+		stubProvider{err: errors.New("503 Service Unavailable")},
+		stubProvider{price: 0},
+		stubProvider{price: 2000, delay: time.Second},
+	}, testAggregation)
+	require.NoError(t, err)
+
+	start := time.Now()
+	result, err := provider.GetAggregatedPrice()
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, float64(2410), result.Price)
+	assert.Equal(t, 2, result.Sources)
+}
+
+func TestMultiSourceProvider_InsufficientSources(t *testing.T) {
+	provider, err := NewMultiSourceProvider([]PriceProvider{
+		stubProvider{price: 2400},
+		stubProvider{err: errors.New("timeout")},
+		stubProvider{err: errors.New("timeout")},
+	}, testAggregation)
+	require.NoError(t, err)
+
+	_, err = provider.GetPrice()
+	assert.ErrorIs(t, err, ErrInsufficientSources)
+
+	// 两个数据源分歧过大时都不可信
+	provider, err = NewMultiSourceProvider([]PriceProvider{
+		stubProvider{price: 100},
+		stubProvider{price: 200},
+	}, testAggregation)
+	require.NoError(t, err)
+
+	_, err = provider.GetPrice()
+	assert.ErrorIs(t, err, ErrInsufficientSources)
+}
+
+func TestNewMultiSourceProvider_InvalidConfig(t *testing.T) {
+	_, err := NewMultiSourceProvider(nil, testAggregation)
+	assert.Error(t, err)
+
+	_, err = NewMultiSourceProvider([]PriceProvider{stubProvider{price: 1}}, testAggregation)
+	assert.Error(t, err)
+}
+
+func TestNewProviderFromConfig_MultiSource(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Node.DataSources = []config.DataSourceConfig{
+		{URL: "http://localhost:8888/api/price", PricePath: "data.price"},
+		{Type: "coingecko", CoinID: "tether-gold"},
+	}
+	cfg.Node.Aggregation = testAggregation
+
+	provider, err := NewProviderFromConfig(cfg)
+	require.NoError(t, err)
+	assert.IsType(t, &MultiSourceProvider{}, provider)
+
+	cfg.Node.DataSources[1].CoinID = ""
+	_, err = NewProviderFromConfig(cfg)
+	assert.Error(t, err)
+}
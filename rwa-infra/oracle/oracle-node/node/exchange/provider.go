@@ -2,6 +2,8 @@ package exchange
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/cpchain-network/oracle-node/config"
 	"github.com/pkg/errors"
@@ -15,6 +17,7 @@ var (
 	ErrParseFailed   = errors.New("failed to parse price from response")
 	ErrInvalidPrice  = errors.New("invalid price value")
 	ErrEmptyResponse = errors.New("empty response body")
+	ErrRateLimited   = errors.New("rate limited by data source")
 )
 
 // PriceProvider 通用价格数据提供者接口
@@ -23,21 +26,66 @@ type PriceProvider interface {
 	GetAssetInfo() (assetType, assetName string)
 }
 
+// Quote 价格及数据源报告的价格时间
+type Quote struct {
+	Price     float64
+	UpdatedAt time.Time // 零值表示数据源未报告时间
+}
+
+// QuoteProvider 能报告价格时间的提供者
+type QuoteProvider interface {
+	GetQuote() (Quote, error)
+}
+
+// GetQuote 获取价格及其时间，提供者不报告时间时 UpdatedAt 为零值
+func GetQuote(p PriceProvider) (Quote, error) {
+	if qp, ok := p.(QuoteProvider); ok {
+		return qp.GetQuote()
+	}
+
+	price, err := p.GetPrice()
+	if err != nil {
+		return Quote{}, err
+	}
+	return Quote{Price: price}, nil
+}
+
 // DataProvider 通用数据源提供者
 type DataProvider struct {
 	client    *gresty.Client
+	http      *HTTPClient
 	cfg       config.DataSourceConfig
 	assetType string
 	assetName string
+
+	// 编译后的 gjson 字段路径
+	pricePath     string
+	bidPath       string
+	askPath       string
+	timestampPath string
 }
 
-// NewDataProvider 创建通用数据源提供者
-func NewDataProvider(cfg config.DataSourceConfig) (*DataProvider, error) {
+// NewDataProvider 创建通用数据源提供者，httpClient 为 nil 时使用默认超时与重试
+func NewDataProvider(cfg config.DataSourceConfig, httpClient *HTTPClient) (*DataProvider, error) {
 	if cfg.URL == "" {
 		return nil, errors.New("data source URL is required")
 	}
 
-	client := gresty.New()
+	paths := make([]string, 4)
+	for i, path := range []string{cfg.PricePath, cfg.BidPath, cfg.AskPath, cfg.TimestampPath} {
+		compiled, err := compileFieldPath(path)
+		if err != nil {
+			return nil, err
+		}
+		paths[i] = compiled
+	}
+	auth, err := newRequestAuth(cfg.Auth, cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient = httpClient.orDefault()
+	client := httpClient.newResty()
 
 	// 设置自定义请求头
 	if cfg.Headers != nil {
@@ -46,6 +94,14 @@ func NewDataProvider(cfg config.DataSourceConfig) (*DataProvider, error) {
 		}
 	}
 
+	// 鉴权在每次发送（包括重试）前设置，hmac 签名使用发送时的时间戳
+	if auth != nil {
+		client.OnBeforeRequest(func(_ *gresty.Client, r *gresty.Request) error {
+			auth.apply(r, r.Method, cfg.Body)
+			return nil
+		})
+	}
+
 	// 设置错误处理
 	client.OnAfterResponse(func(c *gresty.Client, r *gresty.Response) error {
 		if r.StatusCode() >= 400 {
@@ -55,66 +111,97 @@ func NewDataProvider(cfg config.DataSourceConfig) (*DataProvider, error) {
 	})
 
 	return &DataProvider{
-		client:    client,
-		cfg:       cfg,
-		assetType: cfg.AssetType,
-		assetName: cfg.AssetName,
+		client:        client,
+		http:          httpClient,
+		cfg:           cfg,
+		assetType:     cfg.AssetType,
+		assetName:     cfg.AssetName,
+		pricePath:     paths[0],
+		bidPath:       paths[1],
+		askPath:       paths[2],
+		timestampPath: paths[3],
 	}, nil
 }
 
 // GetPrice 获取价格
 func (p *DataProvider) GetPrice() (float64, error) {
+	quote, err := p.GetQuote()
+	if err != nil {
+		return 0, err
+	}
+	return quote.Price, nil
+}
+
+// GetQuote 获取价格，配置 TimestampPath 时同时解析价格时间
+func (p *DataProvider) GetQuote() (Quote, error) {
 	var resp *gresty.Response
 	var err error
 
+	ctx, cancel := p.http.context()
+	defer cancel()
+
 	// 发送请求
 	switch p.cfg.Method {
 	case "POST":
-		resp, err = p.client.R().Post(p.cfg.URL)
+		req := p.client.R().SetContext(ctx)
+		if p.cfg.Body != "" {
+			req.SetBody(p.cfg.Body)
+		}
+		resp, err = req.Post(p.cfg.URL)
 	default:
-		resp, err = p.client.R().Get(p.cfg.URL)
+		resp, err = p.client.R().SetContext(ctx).Get(p.cfg.URL)
 	}
 
 	if err != nil {
-		return 0, fmt.Errorf("request failed: %w", err)
+		return Quote{}, fmt.Errorf("request failed: %w", err)
 	}
 
 	body := resp.String()
 	if body == "" {
-		return 0, ErrEmptyResponse
+		return Quote{}, ErrEmptyResponse
 	}
 
 	// 解析价格
 	var price float64
 
 	// 优先使用 PricePath
-	if p.cfg.PricePath != "" {
-		result := gjson.Get(body, p.cfg.PricePath)
+	if p.pricePath != "" {
+		result := gjson.Get(body, p.pricePath)
 		if !result.Exists() {
-			return 0, fmt.Errorf("price path '%s' not found in response: %w", p.cfg.PricePath, ErrParseFailed)
+			return Quote{}, fmt.Errorf("price path '%s' not found in response: %w", p.cfg.PricePath, ErrParseFailed)
 		}
 		price = result.Float()
-	} else if p.cfg.BidPath != "" && p.cfg.AskPath != "" {
+	} else if p.bidPath != "" && p.askPath != "" {
 		// 使用 bid/ask 平均值
-		bidResult := gjson.Get(body, p.cfg.BidPath)
-		askResult := gjson.Get(body, p.cfg.AskPath)
+		bidResult := gjson.Get(body, p.bidPath)
+		askResult := gjson.Get(body, p.askPath)
 
 		if !bidResult.Exists() || !askResult.Exists() {
-			return 0, fmt.Errorf("bid/ask path not found in response: %w", ErrParseFailed)
+			return Quote{}, fmt.Errorf("bid/ask path not found in response: %w", ErrParseFailed)
 		}
 
 		bid := bidResult.Float()
 		ask := askResult.Float()
 		price = (bid + ask) / 2
 	} else {
-		return 0, errors.New("no price path configured")
+		return Quote{}, errors.New("no price path configured")
 	}
 
 	if price <= 0 {
-		return 0, fmt.Errorf("price is %f: %w", price, ErrInvalidPrice)
+		return Quote{}, fmt.Errorf("price is %f: %w", price, ErrInvalidPrice)
+	}
+
+	quote := Quote{Price: price}
+	if p.timestampPath != "" {
+		result := gjson.Get(body, p.timestampPath)
+		updatedAt, ok := parseTimestamp(result)
+		if !ok {
+			return Quote{}, fmt.Errorf("timestamp path '%s' not found in response: %w", p.cfg.TimestampPath, ErrParseFailed)
+		}
+		quote.UpdatedAt = updatedAt
 	}
 
-	return price, nil
+	return quote, nil
 }
 
 // GetAssetInfo 获取资产信息
@@ -122,17 +209,100 @@ func (p *DataProvider) GetAssetInfo() (assetType, assetName string) {
 	return p.assetType, p.assetName
 }
 
+// parseTimestamp 解析 Unix 秒、Unix 毫秒或 RFC3339 时间
+func parseTimestamp(result gjson.Result) (time.Time, bool) {
+	switch result.Type {
+	case gjson.Number:
+		return unixTime(result.Int()), true
+	case gjson.String:
+		if t, err := time.Parse(time.RFC3339, result.Str); err == nil {
+			return t, true
+		}
+		if ts, err := strconv.ParseInt(result.Str, 10, 64); err == nil {
+			return unixTime(ts), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// unixTime 大于 1e12 的时间戳视为毫秒
+func unixTime(ts int64) time.Time {
+	if ts > 1e12 {
+		return time.UnixMilli(ts)
+	}
+	return time.Unix(ts, 0)
+}
+
 // NewProviderFromConfig 从配置创建提供者（兼容旧配置）
 func NewProviderFromConfig(cfg *config.Config) (PriceProvider, error) {
-	// 优先使用新配置
-	if cfg.Node.DataSource.URL != "" {
-		return NewDataProvider(cfg.Node.DataSource)
+	// 所有数据源共享 HTTP 客户端，单次取价不超过节点签名超时
+	httpClient := NewHTTPClient(cfg.Node.HTTPClient, cfg.Node.SignTimeout)
+
+	provider, err := newProvider(cfg.Node.DataSource, cfg.Node.DataSources, cfg.Node.Aggregation, httpClient)
+	if !errors.Is(err, errNoDataSource) {
+		return provider, err
 	}
 
 	// 兼容旧的 CoinUp 配置
 	if cfg.Node.ExchangeConfig.BaseHttpUrl != "" {
-		return NewCoinUpClient(cfg.Node.ExchangeConfig.BaseHttpUrl)
+		return NewCoinUpClient(cfg.Node.ExchangeConfig.BaseHttpUrl, httpClient)
+	}
+
+	return nil, errNoDataSource
+}
+
+// NewSymbolProviders 按品种创建提供者；未配置 symbols 时只有 symbol 为空的一个品种
+func NewSymbolProviders(cfg *config.Config) (map[string]PriceProvider, error) {
+	if len(cfg.Symbols) == 0 {
+		provider, err := NewProviderFromConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]PriceProvider{"": provider}, nil
 	}
 
-	return nil, errors.New("no data source configured")
+	httpClient := NewHTTPClient(cfg.Node.HTTPClient, cfg.Node.SignTimeout)
+	providers := make(map[string]PriceProvider, len(cfg.Symbols))
+	for _, symbol := range cfg.Symbols {
+		provider, err := newProvider(symbol.DataSource, symbol.DataSources, cfg.Node.Aggregation, httpClient)
+		if err != nil {
+			return nil, fmt.Errorf("symbol %s: %w", symbol.Symbol, err)
+		}
+		providers[symbol.Symbol] = provider
+	}
+	return providers, nil
+}
+
+var errNoDataSource = errors.New("no data source configured")
+
+// newProvider 配置多个数据源时取中位数，否则使用单个数据源
+func newProvider(single config.DataSourceConfig, multi []config.DataSourceConfig, aggregation config.AggregationConfig, httpClient *HTTPClient) (PriceProvider, error) {
+	if len(multi) > 0 {
+		sources := make([]PriceProvider, 0, len(multi))
+		for i, sourceCfg := range multi {
+			source, err := newSourceProvider(sourceCfg, httpClient)
+			if err != nil {
+				return nil, fmt.Errorf("data source %d: %w", i, err)
+			}
+			sources = append(sources, source)
+		}
+		return NewMultiSourceProvider(sources, aggregation)
+	}
+
+	if single.Type != "" || single.URL != "" {
+		return newSourceProvider(single, httpClient)
+	}
+	return nil, errNoDataSource
+}
+
+// newSourceProvider 按类型创建单个数据源
+func newSourceProvider(cfg config.DataSourceConfig, httpClient *HTTPClient) (PriceProvider, error) {
+	switch cfg.Type {
+	case "coingecko":
+		return NewCoinGeckoProvider(cfg, httpClient)
+	case "", "http":
+		return NewDataProvider(cfg, httpClient)
+	default:
+		return nil, fmt.Errorf("unknown data source type %q", cfg.Type)
+	}
 }
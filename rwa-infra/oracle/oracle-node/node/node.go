@@ -6,23 +6,15 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"math/big"
-	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/consensys/gnark-crypto/ecc/bn254"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	types2 "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	tdtypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
 
-	"github.com/cpchain-network/oracle-node/bindings/bls"
-	"github.com/cpchain-network/oracle-node/bindings/oracle"
-	"github.com/cpchain-network/oracle-node/client"
 	"github.com/cpchain-network/oracle-node/config"
 	"github.com/cpchain-network/oracle-node/manager/types"
 	"github.com/cpchain-network/oracle-node/node/exchange"
@@ -31,6 +23,24 @@ import (
 	wsclient "github.com/cpchain-network/oracle-node/ws/client"
 )
 
+// unknownSymbolCode 请求的品种未在本节点配置时返回给 manager 的错误码
+const unknownSymbolCode = 203
+
+// signBusyCode 进行中的签名请求已达上限时返回给 manager 的错误码
+const signBusyCode = 204
+
+// symbolFeed 单个品种的取价与签名前校验，各品种的偏离检查互不影响
+type symbolFeed struct {
+	provider  exchange.PriceProvider
+	validator *priceValidator
+}
+
+// wsConn 与 oracle manager 的 websocket 连接，由 wsclient.WSClients 实现
+type wsConn interface {
+	RegisterResChannel(requestMsg chan tdtypes.RPCRequest, stopChan chan struct{}) error
+	SendMsg(rsp tdtypes.RPCResponse) error
+}
+
 type Node struct {
 	wg         sync.WaitGroup
 	done       chan struct{}
@@ -39,18 +49,19 @@ type Node struct {
 	privateKey *ecdsa.PrivateKey
 	from       common.Address
 
-	ctx      context.Context
-	cancel   context.CancelFunc
-	stopChan chan struct{}
-	stopped  atomic.Bool
+	// ctx 随 Stop 取消，签名协程由 wg 跟踪
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped atomic.Bool
 
-	wsClient      *wsclient.WSClients
-	keyPairs      *sign.KeyPair
-	priceProvider exchange.PriceProvider // 改动：使用通用接口
+	wsClient wsConn
+	keyPairs *sign.KeyPair
+	feeds    map[string]*symbolFeed // 按品种取价与校验，未配置多品种时只有 "" 一项
 
 	signTimeout      time.Duration
 	waitScanInterval time.Duration
 	signRequestChan  chan tdtypes.RPCRequest
+	signSlots        chan struct{} // 容量为进行中签名请求的上限
 }
 
 func NewOracleNode(ctx context.Context, db *store.Storage, privKey *ecdsa.PrivateKey, keyPairs *sign.KeyPair, shouldRegister bool, cfg *config.Config, logger log.Logger, shutdown context.CancelCauseFunc) (*Node, error) {
@@ -61,25 +72,27 @@ func NewOracleNode(ctx context.Context, db *store.Storage, privKey *ecdsa.Privat
 	logger.Info("oracle node register information", "publicKey", pubkeyHex, "address", from)
 	if shouldRegister {
 		logger.Info("register to operator ...")
-		tx, err := registerOperator(ctx, cfg, privKey, pubkeyHex, keyPairs)
+		registry, err := newChainRegistry(ctx, cfg, privKey)
 		if err != nil {
 			logger.Error("failed to register operator", "err", err)
 			return nil, err
 		}
-		logger.Info("success to register operator", "tx_hash", tx.Hash())
+		tx, err := registerOperator(ctx, registry, from, pubkeyHex, keyPairs, logger)
+		if err != nil {
+			logger.Error("failed to register operator", "err", err)
+			return nil, err
+		}
+		if tx != nil {
+			logger.Info("success to register operator", "tx_hash", tx.Hash())
+		}
 	}
 
 	// 改动：使用通用数据源提供者
-	priceProvider, err := exchange.NewProviderFromConfig(cfg)
+	priceProviders, err := exchange.NewSymbolProviders(cfg)
 	if err != nil {
 		log.Error("failed to create price provider", "err", err)
 		return nil, err
 	}
-	assetType, assetName := priceProvider.GetAssetInfo()
-	log.Info("initialized price provider",
-		"assetType", assetType,
-		"assetName", assetName,
-		"url", cfg.Node.DataSource.URL)
 
 	log.Info("web socket url", "WsAddr", cfg.Node.WsAddr)
 	wsClient, err := wsclient.NewWSClient(cfg.Node.WsAddr, "/ws", privKey, pubkeyHex)
@@ -88,21 +101,41 @@ func NewOracleNode(ctx context.Context, db *store.Storage, privKey *ecdsa.Privat
 		return nil, err
 	}
 
+	// 用各品种最近一次签名的价格初始化偏离检查，重启后仍能与历史价格比较
+	feeds := make(map[string]*symbolFeed, len(priceProviders))
+	for symbol, provider := range priceProviders {
+		assetType, assetName := provider.GetAssetInfo()
+		log.Info("initialized price provider", "symbol", symbol, "assetType", assetType, "assetName", assetName)
+
+		validator := newPriceValidator(cfg.Node.PriceValidation)
+		signedPrice, found, err := db.GetLatestSignedPrice(symbol)
+		if err != nil {
+			log.Error("failed to load signed price history", "symbol", symbol, "err", err)
+			return nil, err
+		}
+		if found {
+			validator.restore(signedPrice.Price, time.Unix(int64(signedPrice.Timestamp), 0))
+		}
+		feeds[symbol] = &symbolFeed{provider: provider, validator: validator}
+	}
+
+	nodeCtx, cancel := context.WithCancel(ctx)
 	return &Node{
 		wg:               sync.WaitGroup{},
 		done:             make(chan struct{}),
-		stopChan:         make(chan struct{}),
 		log:              logger,
 		db:               db,
 		privateKey:       privKey,
 		from:             from,
-		ctx:              ctx,
+		ctx:              nodeCtx,
+		cancel:           cancel,
 		wsClient:         wsClient,
-		priceProvider:    priceProvider, // 改动：使用通用接口
+		feeds:            feeds,
 		keyPairs:         keyPairs,
 		signRequestChan:  make(chan tdtypes.RPCRequest, 100),
 		signTimeout:      cfg.Node.SignTimeout,
 		waitScanInterval: cfg.Node.WaitScanInterval,
+		signSlots:        make(chan struct{}, cfg.Node.MaxInFlightSigns),
 	}, nil
 }
 
@@ -113,6 +146,7 @@ func (n *Node) Start(ctx context.Context) error {
 	return nil
 }
 
+// Stop 取消节点上下文并等待消息处理与进行中的签名协程退出
 func (n *Node) Stop(ctx context.Context) error {
 	n.cancel()
 	close(n.done)
@@ -125,209 +159,218 @@ func (n *Node) Stopped() bool {
 	return n.stopped.Load()
 }
 
+// sign 处理签名请求，每个请求在独立协程中取价签名，协程计入 wg，Stop 时等待其退出
+// 进行中的请求达到 signSlots 上限时直接回复错误，由 manager 在下一轮重新请求
 func (n *Node) sign() {
 	defer n.wg.Done()
+	defer n.log.Info("exit sign process")
 
 	n.log.Info("start to sign message")
 
-	go func() {
-		defer func() {
-			n.log.Info("exit sign process")
-		}()
-		for {
-			select {
-			case <-n.stopChan:
-				return
-			case req := <-n.signRequestChan:
-				var resId = req.ID.(tdtypes.JSONRPCStringID).String()
-				n.log.Info(fmt.Sprintf("dealing resId (%s) ", resId))
-
-				var nodeSignRequest types.NodeSignRequest
-				if err := json.Unmarshal(req.Params, &nodeSignRequest); err != nil {
-					n.log.Error("failed to unmarshal ask request")
-					RpcResponse := tdtypes.NewRPCErrorResponse(req.ID, 201, "failed", err.Error())
-					if err := n.wsClient.SendMsg(RpcResponse); err != nil {
-						n.log.Error("failed to send msg to manager", "err", err)
-					}
-					continue
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case req := <-n.signRequestChan:
+			var resId = req.ID.(tdtypes.JSONRPCStringID).String()
+			n.log.Info(fmt.Sprintf("dealing resId (%s) ", resId))
+
+			var nodeSignRequest types.NodeSignRequest
+			if err := json.Unmarshal(req.Params, &nodeSignRequest); err != nil {
+				n.log.Error("failed to unmarshal ask request")
+				RpcResponse := tdtypes.NewRPCErrorResponse(req.ID, 201, "failed", err.Error())
+				if err := n.wsClient.SendMsg(RpcResponse); err != nil {
+					n.log.Error("failed to send msg to manager", "err", err)
 				}
+				continue
+			}
 
-				log.Info("request body market price", "RequestId", nodeSignRequest.RequestBody.RequestId)
+			log.Info("request body market price", "RequestId", nodeSignRequest.RequestBody.RequestId)
 
-				if nodeSignRequest.RequestBody.BlockNumber == 0 || nodeSignRequest.RequestBody.RequestId == "" {
-					n.log.Error("block number and request id is empty")
-					RpcResponse := tdtypes.NewRPCErrorResponse(req.ID, 201, "failed", "block number and request id is empty")
-					if err := n.wsClient.SendMsg(RpcResponse); err != nil {
-						n.log.Error("failed to send msg to manager", "err", err)
-					}
-					continue
+			if nodeSignRequest.RequestBody.BlockNumber == 0 || nodeSignRequest.RequestBody.RequestId == "" {
+				n.log.Error("block number and request id is empty")
+				RpcResponse := tdtypes.NewRPCErrorResponse(req.ID, 201, "failed", "block number and request id is empty")
+				if err := n.wsClient.SendMsg(RpcResponse); err != nil {
+					n.log.Error("failed to send msg to manager", "err", err)
 				}
-				go func() {
-					err := n.fetchMarketPriceAndSign(req.ID.(tdtypes.JSONRPCStringID), nodeSignRequest)
-					if err != nil {
-						log.Error("handle exchange price sign fail", "err", err)
-					}
-				}()
+				continue
 			}
+			select {
+			case n.signSlots <- struct{}{}:
+			default:
+				n.log.Warn("too many in-flight sign requests, rejecting", "requestId", nodeSignRequest.RequestBody.RequestId, "limit", cap(n.signSlots))
+				RpcResponse := tdtypes.NewRPCErrorResponse(req.ID, signBusyCode, "node busy", "too many in-flight sign requests")
+				if err := n.wsClient.SendMsg(RpcResponse); err != nil {
+					n.log.Error("failed to send msg to manager", "err", err)
+				}
+				continue
+			}
+			n.wg.Add(1)
+			go func() {
+				defer n.wg.Done()
+				defer func() { <-n.signSlots }()
+				err := n.fetchMarketPriceAndSign(n.ctx, req.ID.(tdtypes.JSONRPCStringID), nodeSignRequest)
+				if err != nil {
+					log.Error("handle exchange price sign fail", "err", err)
+				}
+			}()
 		}
-	}()
+	}
 }
 
-func (n *Node) fetchMarketPriceAndSign(resId tdtypes.JSONRPCStringID, req types.NodeSignRequest) error {
-	var err error
-	var bSign *sign.Signature
-	requestBody := req.RequestBody
+// fetchMarketPriceAndSign 取价签名并回复 manager；ctx 已取消（节点停止）时不再取价或发送响应
+func (n *Node) fetchMarketPriceAndSign(ctx context.Context, resId tdtypes.JSONRPCStringID, req types.NodeSignRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	RpcResponse := n.signRequest(resId, req.RequestBody)
+	if err := ctx.Err(); err != nil {
+		n.log.Warn("node stopping, dropping sign response", "requestId", req.RequestBody.RequestId, "symbol", req.RequestBody.Symbol)
+		return err
+	}
+	if err := n.wsClient.SendMsg(RpcResponse); err != nil {
+		n.log.Error("failed to send message to oracle manager", "err", err)
+		return err
+	}
+	n.log.Info("sent sign response to oracle manager successfully", "symbol", req.RequestBody.Symbol)
+	return nil
+}
+
+// signRequest 获取请求品种的价格并签名，未配置的品种或取价失败时返回错误响应
+func (n *Node) signRequest(resId tdtypes.JSONRPCStringID, requestBody types.RequestBody) tdtypes.RPCResponse {
+	feed, ok := n.feeds[requestBody.Symbol]
+	if !ok {
+		n.log.Warn("unknown symbol in sign request", "symbol", requestBody.Symbol)
+		return tdtypes.NewRPCErrorResponse(resId, unknownSymbolCode, "unknown symbol", requestBody.Symbol)
+	}
+	if requestBody.ProposedPrice != "" {
+		return n.signProposal(resId, requestBody, feed)
+	}
 
 	// 改动：使用通用价格提供者获取价格
-	assetPrice, err := n.priceProvider.GetPrice()
+	assetPrice, err := fetchPrice(feed.provider)
 	if err != nil {
-		n.log.Error("failed to get asset price", "err", err)
-		return err
+		n.log.Error("failed to get asset price", "symbol", requestBody.Symbol, "err", err)
+		return tdtypes.NewRPCErrorResponse(resId, 201, "failed", err.Error())
 	}
 
-	assetType, assetName := n.priceProvider.GetAssetInfo()
+	assetType, assetName := feed.provider.GetAssetInfo()
 	n.log.Info("fetched asset price successfully",
+		"symbol", requestBody.Symbol,
 		"assetType", assetType,
 		"assetName", assetName,
-		"price", assetPrice)
+		"price", assetPrice.Price,
+		"sources", assetPrice.Sources,
+		"updatedAt", assetPrice.UpdatedAt)
 
-	priceMessage := fmt.Sprintf("%f", assetPrice) + requestBody.RequestId + strconv.Itoa(int(requestBody.BlockNumber))
-	n.log.Info("sign msg", "msg", priceMessage)
-
-	bSign, err = n.SignMessage(priceMessage)
-	if bSign != nil {
-		// 改动：使用 AssetPrice 字段
-		signResponse := types.SignMsgResponse{
-			G2Point:    n.keyPairs.GetPubKeyG2().Serialize(),
-			Signature:  bSign.Serialize(),
-			AssetPrice: assetPrice,
-		}
-		RpcResponse := tdtypes.NewRPCSuccessResponse(resId, signResponse)
-		n.log.Info("node signed the message, sending response to oracle manager")
-		err = n.wsClient.SendMsg(RpcResponse)
-		if err != nil {
-			n.log.Error("failed to send message to oracle manager", "err", err)
-			return err
-		} else {
-			n.log.Info("sent sign response to oracle manager successfully")
-			return nil
-		}
-	}
-	return nil
+	return n.signPrice(resId, requestBody, assetPrice)
 }
 
-func (n *Node) SignMessage(marketPriceMessage string) (*sign.Signature, error) {
-	var bSign *sign.Signature
-	n.log.Info("msg hash", "data", crypto.Keccak256Hash(common.Hex2Bytes(marketPriceMessage)))
-	bSign = n.keyPairs.SignMessage(crypto.Keccak256Hash(common.Hex2Bytes(marketPriceMessage)))
-	n.log.Info("success to sign SubmitOracleSignatureMsg", "signature", bSign.String())
-	return bSign, nil
-}
-
-func registerOperator(ctx context.Context, cfg *config.Config, priKey *ecdsa.PrivateKey, node string, keyPairs *sign.KeyPair) (*types2.Transaction, error) {
-	ethCli, err := client.DialEthClientWithTimeout(ctx, cfg.CpChainRpc, false)
-	if err != nil {
-		return nil, fmt.Errorf("failed to dial eth client, err: %v", err)
+// signPrice 校验并签名价格；校验不通过时返回错误响应，不签名
+func (n *Node) signPrice(resId tdtypes.JSONRPCStringID, requestBody types.RequestBody, assetPrice *exchange.AggregatedPrice) tdtypes.RPCResponse {
+	feed, ok := n.feeds[requestBody.Symbol]
+	if !ok {
+		return tdtypes.NewRPCErrorResponse(resId, unknownSymbolCode, "unknown symbol", requestBody.Symbol)
 	}
-	oracleContract, err := oracle.NewOracleManager(common.HexToAddress(cfg.OracleManagerAddress), ethCli)
-	if err != nil {
-		return nil, fmt.Errorf("failed to new OracleManager contract, err: %v", err)
+	if err := feed.validator.check(assetPrice); err != nil {
+		n.log.Warn("refusing to sign price", "symbol", requestBody.Symbol, "price", assetPrice.Price, "err", err)
+		return tdtypes.NewRPCErrorResponse(resId, priceRejectedCode, "price rejected", err.Error())
 	}
 
-	fParsed, err := oracle.OracleManagerMetaData.GetAbi()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get OracleManager contract abis, err: %v", err)
-	}
-	rawOracleContract := bind.NewBoundContract(
-		common.HexToAddress(cfg.OracleManagerAddress), *fParsed, ethCli, ethCli,
-		ethCli,
-	)
+	priceMessage := types.PriceMessage(types.FormatPrice(assetPrice.Price), requestBody)
+	n.log.Info("sign msg", "msg", priceMessage)
 
-	blsRegContract, err := bls.NewBLSApkRegistry(common.HexToAddress(cfg.BlsRegistryAddress), ethCli)
+	bSign, err := n.SignMessage(priceMessage)
 	if err != nil {
-		return nil, fmt.Errorf("failed to new BLSApkRegistry contract, err: %v", err)
+		n.log.Error("failed to sign price", "err", err)
+		return tdtypes.NewRPCErrorResponse(resId, 201, "failed", err.Error())
 	}
-
-	bParsed, err := bls.BLSApkRegistryMetaData.GetAbi()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get BLSApkRegistry contract abis, err: %v", err)
+	feed.validator.record(requestBody.RequestId, assetPrice.Price)
+
+	if err := n.db.SetSignedPrice(store.SignedPrice{
+		RequestId:   requestBody.RequestId,
+		Symbol:      requestBody.Symbol,
+		BlockNumber: requestBody.BlockNumber,
+		Price:       assetPrice.Price,
+		Message:     priceMessage,
+		Signature:   bSign.Serialize(),
+		Timestamp:   uint64(time.Now().Unix()),
+	}); err != nil {
+		// 记录失败不影响签名响应
+		n.log.Error("failed to store signed price", "requestId", requestBody.RequestId, "err", err)
 	}
-	rawBlsRegContract := bind.NewBoundContract(
-		common.HexToAddress(cfg.BlsRegistryAddress), *bParsed, ethCli, ethCli,
-		ethCli,
-	)
 
-	topts, err := client.NewTransactOpts(ctx, cfg.CpChainID, priKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to new transaction option, err: %v", err)
+	// 改动：使用 AssetPrice 字段
+	signResponse := types.SignMsgResponse{
+		G2Point:     n.keyPairs.GetPubKeyG2().Serialize(),
+		Signature:   bSign.Serialize(),
+		AssetPrice:  assetPrice.Price,
+		SourceCount: assetPrice.Sources,
+		Symbol:      requestBody.Symbol,
 	}
+	n.log.Info("node signed the message, sending response to oracle manager")
+	return tdtypes.NewRPCSuccessResponse(resId, signResponse)
+}
 
-	nodeAddr := crypto.PubkeyToAddress(priKey.PublicKey)
-	latestBlock, err := ethCli.BlockNumber(ctx)
+// signProposal 签名 manager 提议的聚合价，只有与本节点本轮上报的价格足够接近时才签
+// 提议价不作为上次签名价格参与后续偏离检查
+func (n *Node) signProposal(resId tdtypes.JSONRPCStringID, requestBody types.RequestBody, feed *symbolFeed) tdtypes.RPCResponse {
+	proposed, err := types.ParsePrice(requestBody.ProposedPrice)
+	if err == nil {
+		err = feed.validator.checkProposal(requestBody.RequestId, proposed)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get latest block, err: %v", err)
+		n.log.Warn("refusing to sign proposed price", "symbol", requestBody.Symbol, "proposed", requestBody.ProposedPrice, "err", err)
+		return tdtypes.NewRPCErrorResponse(resId, priceRejectedCode, "price rejected", err.Error())
 	}
 
-	cOpts := &bind.CallOpts{
-		BlockNumber: big.NewInt(int64(latestBlock)),
-		From:        nodeAddr,
-	}
+	priceMessage := types.PriceMessage(requestBody.ProposedPrice, requestBody)
+	n.log.Info("sign proposed msg", "msg", priceMessage)
 
-	msg, err := blsRegContract.GetPubkeyRegMessageHash(cOpts, nodeAddr)
+	bSign, err := n.SignMessage(priceMessage)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get PubkeyRegistrationMessageHash, err: %v", err)
+		n.log.Error("failed to sign price", "err", err)
+		return tdtypes.NewRPCErrorResponse(resId, 201, "failed", err.Error())
 	}
 
-	sigMsg := new(bn254.G1Affine).ScalarMultiplication(sign.NewG1Point(msg.X, msg.Y).G1Affine, keyPairs.PrivKey.BigInt(new(big.Int)))
-
-	params := bls.IBLSApkRegistryPubkeyRegistrationParams{
-		PubkeyRegistrationSignature: bls.BN254G1Point{
-			X: sigMsg.X.BigInt(new(big.Int)),
-			Y: sigMsg.Y.BigInt(new(big.Int)),
-		},
-		PubkeyG1: bls.BN254G1Point{
-			X: keyPairs.GetPubKeyG1().X.BigInt(new(big.Int)),
-			Y: keyPairs.GetPubKeyG1().Y.BigInt(new(big.Int)),
-		},
-		PubkeyG2: bls.BN254G2Point{
-			X: [2]*big.Int{keyPairs.GetPubKeyG2().X.A1.BigInt(new(big.Int)), keyPairs.GetPubKeyG2().X.A0.BigInt(new(big.Int))},
-			Y: [2]*big.Int{keyPairs.GetPubKeyG2().Y.A1.BigInt(new(big.Int)), keyPairs.GetPubKeyG2().Y.A0.BigInt(new(big.Int))},
-		},
+	if err := n.db.SetSignedPrice(store.SignedPrice{
+		RequestId:   requestBody.RequestId,
+		Symbol:      requestBody.Symbol,
+		BlockNumber: requestBody.BlockNumber,
+		Price:       proposed,
+		Message:     priceMessage,
+		Signature:   bSign.Serialize(),
+		Timestamp:   uint64(time.Now().Unix()),
+	}); err != nil {
+		n.log.Error("failed to store signed price", "requestId", requestBody.RequestId, "err", err)
 	}
 
-	regBlsTx, err := blsRegContract.RegisterBLSPublicKey(topts, nodeAddr, params, msg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to craft RegisterBLSPublicKey transaction, err: %v", err)
-	}
-	fRegBlsTx, err := rawBlsRegContract.RawTransact(topts, regBlsTx.Data())
-	if err != nil {
-		return nil, fmt.Errorf("failed to raw RegisterBLSPublicKey transaction, err: %v", err)
-	}
-	err = ethCli.SendTransaction(ctx, fRegBlsTx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send RegisterBLSPublicKey transaction, err: %v", err)
-	}
+	return tdtypes.NewRPCSuccessResponse(resId, types.SignMsgResponse{
+		G2Point:    n.keyPairs.GetPubKeyG2().Serialize(),
+		Signature:  bSign.Serialize(),
+		AssetPrice: proposed,
+		Symbol:     requestBody.Symbol,
+	})
+}
 
-	_, err = client.GetTransactionReceipt(ctx, ethCli, fRegBlsTx.Hash())
-	if err != nil {
-		return nil, fmt.Errorf("failed to get RegisterBLSPublicKey transaction receipt, err: %v, tx_hash: %v", err, fRegBlsTx.Hash().String())
+// fetchPrice 获取价格、参与计算的数据源数及价格时间
+func fetchPrice(provider exchange.PriceProvider) (*exchange.AggregatedPrice, error) {
+	if multi, ok := provider.(*exchange.MultiSourceProvider); ok {
+		return multi.GetAggregatedPrice()
 	}
 
-	regOTx, err := oracleContract.RegisterOperator(topts, node)
+	quote, err := exchange.GetQuote(provider)
 	if err != nil {
-		return nil, fmt.Errorf("failed to craft RegisterOperator transaction, err: %v", err)
-	}
-	fRegOTx, err := rawOracleContract.RawTransact(topts, regOTx.Data())
-	if err != nil {
-		return nil, fmt.Errorf("failed to raw RegisterOperator transaction, err: %v", err)
-	}
-	err = ethCli.SendTransaction(ctx, fRegOTx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send RegisterOperator transaction, err: %v", err)
-	}
-	_, err = client.GetTransactionReceipt(ctx, ethCli, fRegOTx.Hash())
-	if err != nil {
-		return nil, fmt.Errorf("failed to get RegisterOperator transaction receipt, err: %v, tx_hash: %v", err, fRegOTx.Hash().String())
+		return nil, err
 	}
-	return fRegOTx, nil
+	return &exchange.AggregatedPrice{Price: quote.Price, Sources: 1, Total: 1, UpdatedAt: quote.UpdatedAt}, nil
+}
+
+func (n *Node) SignMessage(marketPriceMessage string) (*sign.Signature, error) {
+	var bSign *sign.Signature
+	msgHash := types.PriceMessageHash(marketPriceMessage)
+	n.log.Info("msg hash", "data", msgHash)
+	bSign = n.keyPairs.SignMessage(msgHash)
+	n.log.Info("success to sign SubmitOracleSignatureMsg", "signature", bSign.String())
+	return bSign, nil
 }
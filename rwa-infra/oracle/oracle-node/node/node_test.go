@@ -0,0 +1,154 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmjson "github.com/tendermint/tendermint/libs/json"
+	tdtypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+
+	"github.com/cpchain-network/oracle-node/config"
+	"github.com/cpchain-network/oracle-node/manager/types"
+	"github.com/cpchain-network/oracle-node/sign"
+	"github.com/cpchain-network/oracle-node/store"
+)
+
+type staticProvider struct {
+	price float64
+}
+
+func (p *staticProvider) GetPrice() (float64, error) { return p.price, nil }
+
+func (p *staticProvider) GetAssetInfo() (string, string) { return "test", "test" }
+
+func TestSignRequest_PerSymbol(t *testing.T) {
+	keyPairs, err := sign.GenRandomBlsKeys()
+	require.NoError(t, err)
+	db, err := store.NewStorage("")
+	require.NoError(t, err)
+
+	gold := &staticProvider{price: 2400}
+	oil := &staticProvider{price: 80}
+	goldValidator, _ := newTestValidator(testValidation)
+	oilValidator, _ := newTestValidator(testValidation)
+	n := &Node{log: log.Root(), db: db, keyPairs: keyPairs, feeds: map[string]*symbolFeed{
+		"GOLD": {provider: gold, validator: goldValidator},
+		"OIL":  {provider: oil, validator: oilValidator},
+	}}
+
+	for symbol, price := range map[string]float64{"GOLD": 2400, "OIL": 80} {
+		body := types.RequestBody{BlockNumber: 100, RequestId: "req-1", Symbol: symbol}
+		resp := n.signRequest(tdtypes.JSONRPCStringID("req-"+symbol), body)
+		require.Nil(t, resp.Error, symbol)
+		assert.Equal(t, tdtypes.JSONRPCStringID("req-"+symbol), resp.ID)
+
+		var signResponse types.SignMsgResponse
+		require.NoError(t, tmjson.Unmarshal(resp.Result, &signResponse))
+		assert.Equal(t, symbol, signResponse.Symbol)
+		assert.Equal(t, price, signResponse.AssetPrice)
+
+		// 签名消息带品种，不能用于另一品种
+		signature, err := new(sign.G1Point).Deserialize(signResponse.Signature)
+		require.NoError(t, err)
+		message := types.PriceMessage(types.FormatPrice(price), body)
+		assert.True(t, signature.Verify(keyPairs.GetPubKeyG2(), types.PriceMessageHash(message)))
+		other := types.PriceMessage(types.FormatPrice(price), types.RequestBody{BlockNumber: 100, RequestId: "req-1"})
+		assert.False(t, signature.Verify(keyPairs.GetPubKeyG2(), types.PriceMessageHash(other)))
+
+		signed, found, err := db.GetLatestSignedPrice(symbol)
+		require.NoError(t, err)
+		require.True(t, found)
+		assert.Equal(t, price, signed.Price)
+	}
+
+	// 偏离检查按品种独立
+	gold.price = 2450
+	assert.Nil(t, n.signRequest("req-2", types.RequestBody{BlockNumber: 101, RequestId: "req-2", Symbol: "GOLD"}).Error)
+	oil.price = 100
+	resp := n.signRequest("req-2", types.RequestBody{BlockNumber: 101, RequestId: "req-2", Symbol: "OIL"})
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, priceRejectedCode, resp.Error.Code)
+
+	resp = n.signRequest("req-3", types.RequestBody{BlockNumber: 101, RequestId: "req-3", Symbol: "WINE"})
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, unknownSymbolCode, resp.Error.Code)
+}
+
+func TestSignRequest_SignsProposedPrice(t *testing.T) {
+	keyPairs, err := sign.GenRandomBlsKeys()
+	require.NoError(t, err)
+	db, err := store.NewStorage("")
+	require.NoError(t, err)
+
+	validator, _ := newTestValidator(config.PriceValidationConfig{MaxDeviation: 10, MaxProposalDeviation: 1})
+	n := &Node{log: log.Root(), db: db, keyPairs: keyPairs, feeds: map[string]*symbolFeed{
+		"GOLD": {provider: &staticProvider{price: 2391.57}, validator: validator},
+	}}
+	body := types.RequestBody{BlockNumber: 100, RequestId: "req-1", Symbol: "GOLD"}
+
+	// 未上报过价格的请求不签提议价
+	proposal := body
+	proposal.ProposedPrice = "2391223333"
+	resp := n.signRequest("round-2", proposal)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, priceRejectedCode, resp.Error.Code)
+
+	require.Nil(t, n.signRequest("round-1", body).Error)
+
+	resp = n.signRequest("round-2", proposal)
+	require.Nil(t, resp.Error)
+	var signResponse types.SignMsgResponse
+	require.NoError(t, tmjson.Unmarshal(resp.Result, &signResponse))
+	signature, err := new(sign.G1Point).Deserialize(signResponse.Signature)
+	require.NoError(t, err)
+	assert.True(t, signature.Verify(keyPairs.GetPubKeyG2(), types.PriceMessageHash(types.PriceMessage("2391223333", proposal))))
+
+	// 提议价不影响后续偏离检查的参照价格
+	assert.Equal(t, 2391.57, validator.lastPrice)
+
+	for _, rejected := range []string{"2300000000", "not-a-price", "-1"} {
+		proposal.ProposedPrice = rejected
+		resp = n.signRequest("round-2", proposal)
+		require.NotNil(t, resp.Error, rejected)
+		assert.Equal(t, priceRejectedCode, resp.Error.Code, rejected)
+	}
+}
+
+func TestSign_LimitsInFlightRequests(t *testing.T) {
+	const limit, requests = 2, 5
+	provider := &blockingProvider{
+		staticProvider: staticProvider{price: 2400},
+		started:        make(chan struct{}, requests),
+		release:        make(chan struct{}),
+	}
+	n, ws := newTestNode(t, provider, limit)
+	require.NoError(t, n.Start(n.ctx))
+	t.Cleanup(func() { n.Stop(context.Background()) })
+
+	for i := 0; i < requests; i++ {
+		n.signRequestChan <- signRPCRequest(t, fmt.Sprintf("req-%d", i))
+	}
+
+	// 超出上限的请求立即收到 busy 错误，不会开始取价
+	require.Eventually(t, func() bool {
+		return ws.errorCodes()[signBusyCode] == requests-limit
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Len(t, provider.started, limit)
+
+	close(provider.release)
+	require.Eventually(t, func() bool {
+		return ws.errorCodes()[0] == limit
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// 签名完成后释放名额，新请求可以继续处理
+	n.signRequestChan <- signRPCRequest(t, "req-after")
+	require.Eventually(t, func() bool {
+		return ws.errorCodes()[0] == limit+1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, requests-limit, ws.errorCodes()[signBusyCode])
+}
@@ -0,0 +1,221 @@
+package node
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	types2 "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/cpchain-network/oracle-node/bindings/bls"
+	"github.com/cpchain-network/oracle-node/bindings/oracle"
+	"github.com/cpchain-network/oracle-node/client"
+	"github.com/cpchain-network/oracle-node/config"
+	"github.com/cpchain-network/oracle-node/sign"
+)
+
+// operatorRegistry 注册 operator 用到的链上调用，测试中用假实现替换
+type operatorRegistry interface {
+	// pubkeyHash BLSApkRegistry.operatorToPubkeyHash，未注册 BLS 公钥时为零值
+	pubkeyHash(ctx context.Context, operator common.Address) ([32]byte, error)
+	// isRegistered BLSApkRegistry.operatorIsRegister
+	isRegistered(ctx context.Context, operator common.Address) (bool, error)
+	registerBLSPublicKey(ctx context.Context, operator common.Address, keyPairs *sign.KeyPair) (*types2.Transaction, error)
+	registerOperator(ctx context.Context, node string) (*types2.Transaction, error)
+}
+
+// registerOperator 注册 BLS 公钥并注册 operator，链上已注册的步骤跳过，重复启动不会因 revert 失败
+// 两步都已注册时返回 nil 交易；operator 已注册了其他 BLS 公钥时返回错误
+func registerOperator(ctx context.Context, registry operatorRegistry, operator common.Address, node string, keyPairs *sign.KeyPair, logger log.Logger) (*types2.Transaction, error) {
+	registeredHash, err := registry.pubkeyHash(ctx, operator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registered pubkey hash, err: %v", err)
+	}
+	pubkeyHash := keyPairs.GetPubKeyG1().Hash()
+	switch registeredHash {
+	case [32]byte{}:
+		tx, err := registry.registerBLSPublicKey(ctx, operator, keyPairs)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("success to register bls public key", "tx_hash", tx.Hash())
+	case pubkeyHash:
+		logger.Info("bls public key already registered, skip", "operator", operator, "pubkey_hash", common.Hash(pubkeyHash))
+	default:
+		return nil, fmt.Errorf("operator %s already registered a different bls public key %s", operator, common.Hash(registeredHash))
+	}
+
+	registered, err := registry.isRegistered(ctx, operator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check operator registration, err: %v", err)
+	}
+	if registered {
+		logger.Info("operator already registered, skip", "operator", operator)
+		return nil, nil
+	}
+	return registry.registerOperator(ctx, node)
+}
+
+// chainRegistry 通过 OracleManager 与 BLSApkRegistry 合约注册
+type chainRegistry struct {
+	ethCli            *ethclient.Client
+	topts             *bind.TransactOpts
+	oracleContract    *oracle.OracleManager
+	rawOracleContract *bind.BoundContract
+	blsRegContract    *bls.BLSApkRegistry
+	rawBlsRegContract *bind.BoundContract
+}
+
+func newChainRegistry(ctx context.Context, cfg *config.Config, priKey *ecdsa.PrivateKey) (*chainRegistry, error) {
+	ethCli, err := client.DialEthClientWithTimeout(ctx, cfg.CpChainRpc, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial eth client, err: %v", err)
+	}
+	if err := client.CheckChainID(ctx, ethCli, cfg.CpChainID); err != nil {
+		return nil, err
+	}
+	oracleContract, err := oracle.NewOracleManager(common.HexToAddress(cfg.OracleManagerAddress), ethCli)
+	if err != nil {
+		return nil, fmt.Errorf("failed to new OracleManager contract, err: %v", err)
+	}
+
+	fParsed, err := oracle.OracleManagerMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OracleManager contract abis, err: %v", err)
+	}
+	rawOracleContract := bind.NewBoundContract(
+		common.HexToAddress(cfg.OracleManagerAddress), *fParsed, ethCli, ethCli,
+		ethCli,
+	)
+
+	blsRegContract, err := bls.NewBLSApkRegistry(common.HexToAddress(cfg.BlsRegistryAddress), ethCli)
+	if err != nil {
+		return nil, fmt.Errorf("failed to new BLSApkRegistry contract, err: %v", err)
+	}
+
+	bParsed, err := bls.BLSApkRegistryMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get BLSApkRegistry contract abis, err: %v", err)
+	}
+	rawBlsRegContract := bind.NewBoundContract(
+		common.HexToAddress(cfg.BlsRegistryAddress), *bParsed, ethCli, ethCli,
+		ethCli,
+	)
+
+	topts, err := client.NewTransactOpts(ctx, cfg.CpChainID, priKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to new transaction option, err: %v", err)
+	}
+
+	return &chainRegistry{
+		ethCli:            ethCli,
+		topts:             topts,
+		oracleContract:    oracleContract,
+		rawOracleContract: rawOracleContract,
+		blsRegContract:    blsRegContract,
+		rawBlsRegContract: rawBlsRegContract,
+	}, nil
+}
+
+// callOpts 在最新块上以 operator 身份查询
+func (r *chainRegistry) callOpts(ctx context.Context, operator common.Address) (*bind.CallOpts, error) {
+	latestBlock, err := r.ethCli.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block, err: %v", err)
+	}
+	return &bind.CallOpts{
+		Context:     ctx,
+		BlockNumber: big.NewInt(int64(latestBlock)),
+		From:        operator,
+	}, nil
+}
+
+func (r *chainRegistry) pubkeyHash(ctx context.Context, operator common.Address) ([32]byte, error) {
+	cOpts, err := r.callOpts(ctx, operator)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return r.blsRegContract.OperatorToPubkeyHash(cOpts, operator)
+}
+
+func (r *chainRegistry) isRegistered(ctx context.Context, operator common.Address) (bool, error) {
+	cOpts, err := r.callOpts(ctx, operator)
+	if err != nil {
+		return false, err
+	}
+	return r.blsRegContract.OperatorIsRegister(cOpts, operator)
+}
+
+func (r *chainRegistry) registerBLSPublicKey(ctx context.Context, nodeAddr common.Address, keyPairs *sign.KeyPair) (*types2.Transaction, error) {
+	cOpts, err := r.callOpts(ctx, nodeAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := r.blsRegContract.GetPubkeyRegMessageHash(cOpts, nodeAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PubkeyRegistrationMessageHash, err: %v", err)
+	}
+
+	sigMsg := new(bn254.G1Affine).ScalarMultiplication(sign.NewG1Point(msg.X, msg.Y).G1Affine, keyPairs.PrivKey.BigInt(new(big.Int)))
+
+	params := bls.IBLSApkRegistryPubkeyRegistrationParams{
+		PubkeyRegistrationSignature: bls.BN254G1Point{
+			X: sigMsg.X.BigInt(new(big.Int)),
+			Y: sigMsg.Y.BigInt(new(big.Int)),
+		},
+		PubkeyG1: bls.BN254G1Point{
+			X: keyPairs.GetPubKeyG1().X.BigInt(new(big.Int)),
+			Y: keyPairs.GetPubKeyG1().Y.BigInt(new(big.Int)),
+		},
+		PubkeyG2: bls.BN254G2Point{
+			X: [2]*big.Int{keyPairs.GetPubKeyG2().X.A1.BigInt(new(big.Int)), keyPairs.GetPubKeyG2().X.A0.BigInt(new(big.Int))},
+			Y: [2]*big.Int{keyPairs.GetPubKeyG2().Y.A1.BigInt(new(big.Int)), keyPairs.GetPubKeyG2().Y.A0.BigInt(new(big.Int))},
+		},
+	}
+
+	regBlsTx, err := r.blsRegContract.RegisterBLSPublicKey(r.topts, nodeAddr, params, msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to craft RegisterBLSPublicKey transaction, err: %v", err)
+	}
+	fRegBlsTx, err := r.rawBlsRegContract.RawTransact(r.topts, regBlsTx.Data())
+	if err != nil {
+		return nil, fmt.Errorf("failed to raw RegisterBLSPublicKey transaction, err: %v", err)
+	}
+	err = r.ethCli.SendTransaction(ctx, fRegBlsTx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send RegisterBLSPublicKey transaction, err: %v", err)
+	}
+
+	_, err = client.GetTransactionReceipt(ctx, r.ethCli, fRegBlsTx.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get RegisterBLSPublicKey transaction receipt, err: %v, tx_hash: %v", err, fRegBlsTx.Hash().String())
+	}
+	return fRegBlsTx, nil
+}
+
+func (r *chainRegistry) registerOperator(ctx context.Context, node string) (*types2.Transaction, error) {
+	regOTx, err := r.oracleContract.RegisterOperator(r.topts, node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to craft RegisterOperator transaction, err: %v", err)
+	}
+	fRegOTx, err := r.rawOracleContract.RawTransact(r.topts, regOTx.Data())
+	if err != nil {
+		return nil, fmt.Errorf("failed to raw RegisterOperator transaction, err: %v", err)
+	}
+	err = r.ethCli.SendTransaction(ctx, fRegOTx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send RegisterOperator transaction, err: %v", err)
+	}
+	_, err = client.GetTransactionReceipt(ctx, r.ethCli, fRegOTx.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get RegisterOperator transaction receipt, err: %v, tx_hash: %v", err, fRegOTx.Hash().String())
+	}
+	return fRegOTx, nil
+}
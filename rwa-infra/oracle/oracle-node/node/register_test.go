@@ -0,0 +1,99 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	types2 "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cpchain-network/oracle-node/sign"
+)
+
+// fakeRegistry 模拟链上注册状态，注册交易会更新状态
+type fakeRegistry struct {
+	hash       [32]byte
+	registered bool
+	viewErr    error
+
+	blsTxs      int
+	operatorTxs int
+}
+
+func (r *fakeRegistry) pubkeyHash(context.Context, common.Address) ([32]byte, error) {
+	return r.hash, r.viewErr
+}
+
+func (r *fakeRegistry) isRegistered(context.Context, common.Address) (bool, error) {
+	return r.registered, r.viewErr
+}
+
+func (r *fakeRegistry) registerBLSPublicKey(_ context.Context, _ common.Address, keyPairs *sign.KeyPair) (*types2.Transaction, error) {
+	r.blsTxs++
+	r.hash = keyPairs.GetPubKeyG1().Hash()
+	return types2.NewTx(&types2.LegacyTx{Nonce: 0}), nil
+}
+
+func (r *fakeRegistry) registerOperator(context.Context, string) (*types2.Transaction, error) {
+	r.operatorTxs++
+	r.registered = true
+	return types2.NewTx(&types2.LegacyTx{Nonce: 1}), nil
+}
+
+func TestRegisterOperator_Idempotent(t *testing.T) {
+	keyPairs, err := sign.GenRandomBlsKeys()
+	require.NoError(t, err)
+	operator := common.HexToAddress("0x00000000000000000000000000000000000000c1")
+	ctx := context.Background()
+
+	registry := &fakeRegistry{}
+	tx, err := registerOperator(ctx, registry, operator, "node", keyPairs, log.Root())
+	require.NoError(t, err)
+	require.NotNil(t, tx)
+	assert.Equal(t, uint64(1), tx.Nonce())
+	assert.Equal(t, 1, registry.blsTxs)
+	assert.Equal(t, 1, registry.operatorTxs)
+
+	// 重启时两步都已注册，不再发送交易
+	tx, err = registerOperator(ctx, registry, operator, "node", keyPairs, log.Root())
+	require.NoError(t, err)
+	assert.Nil(t, tx)
+	assert.Equal(t, 1, registry.blsTxs)
+	assert.Equal(t, 1, registry.operatorTxs)
+}
+
+func TestRegisterOperator_BlsKeyAlreadyRegistered(t *testing.T) {
+	keyPairs, err := sign.GenRandomBlsKeys()
+	require.NoError(t, err)
+	operator := common.HexToAddress("0x00000000000000000000000000000000000000c1")
+
+	// 上次启动注册了 BLS 公钥后 RegisterOperator 失败
+	registry := &fakeRegistry{hash: keyPairs.GetPubKeyG1().Hash()}
+	tx, err := registerOperator(context.Background(), registry, operator, "node", keyPairs, log.Root())
+	require.NoError(t, err)
+	require.NotNil(t, tx)
+	assert.Equal(t, 0, registry.blsTxs)
+	assert.Equal(t, 1, registry.operatorTxs)
+}
+
+func TestRegisterOperator_Errors(t *testing.T) {
+	keyPairs, err := sign.GenRandomBlsKeys()
+	require.NoError(t, err)
+	operator := common.HexToAddress("0x00000000000000000000000000000000000000c1")
+
+	// operator 已注册了另一个 BLS 公钥，无法用当前密钥签名
+	registry := &fakeRegistry{hash: common.BigToHash(big.NewInt(1)), registered: true}
+	_, err = registerOperator(context.Background(), registry, operator, "node", keyPairs, log.Root())
+	assert.ErrorContains(t, err, "different bls public key")
+	assert.Equal(t, 0, registry.blsTxs+registry.operatorTxs)
+
+	registry = &fakeRegistry{viewErr: errors.New("connection refused")}
+	_, err = registerOperator(context.Background(), registry, operator, "node", keyPairs, log.Root())
+	assert.ErrorContains(t, err, "connection refused")
+	assert.Equal(t, 0, registry.blsTxs+registry.operatorTxs)
+}
@@ -0,0 +1,162 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tdtypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+
+	"github.com/cpchain-network/oracle-node/manager/types"
+	"github.com/cpchain-network/oracle-node/sign"
+	"github.com/cpchain-network/oracle-node/store"
+)
+
+// fakeWS 记录发给 manager 的响应，ProcessMessage 注册的通道由测试直接投递请求
+type fakeWS struct {
+	mu   sync.Mutex
+	reqs chan tdtypes.RPCRequest
+	sent []tdtypes.RPCResponse
+}
+
+func (w *fakeWS) RegisterResChannel(requestMsg chan tdtypes.RPCRequest, stopChan chan struct{}) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.reqs = requestMsg
+	return nil
+}
+
+func (w *fakeWS) SendMsg(rsp tdtypes.RPCResponse) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.sent = append(w.sent, rsp)
+	return nil
+}
+
+func (w *fakeWS) sentCount() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.sent)
+}
+
+// errorCodes 按错误码统计已发送的响应，成功响应计为 0
+func (w *fakeWS) errorCodes() map[int]int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	codes := make(map[int]int)
+	for _, rsp := range w.sent {
+		if rsp.Error != nil {
+			codes[rsp.Error.Code]++
+		} else {
+			codes[0]++
+		}
+	}
+	return codes
+}
+
+// blockingProvider 取价时阻塞直到 release，模拟缓慢的交易所接口
+type blockingProvider struct {
+	staticProvider
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingProvider) GetPrice() (float64, error) {
+	p.started <- struct{}{}
+	<-p.release
+	return p.price, nil
+}
+
+// newTestNode 构造未启动的节点，wsClient 为 fakeWS，最多 maxInFlight 个请求同时签名
+func newTestNode(t *testing.T, provider *blockingProvider, maxInFlight int) (*Node, *fakeWS) {
+	t.Helper()
+	keyPairs, err := sign.GenRandomBlsKeys()
+	require.NoError(t, err)
+	db, err := store.NewStorage("")
+	require.NoError(t, err)
+	validator, _ := newTestValidator(testValidation)
+
+	ws := &fakeWS{}
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &Node{
+		done:            make(chan struct{}),
+		log:             log.Root(),
+		db:              db,
+		ctx:             ctx,
+		cancel:          cancel,
+		wsClient:        ws,
+		keyPairs:        keyPairs,
+		feeds:           map[string]*symbolFeed{"GOLD": {provider: provider, validator: validator}},
+		signRequestChan: make(chan tdtypes.RPCRequest, 100),
+		signSlots:       make(chan struct{}, maxInFlight),
+	}
+	return n, ws
+}
+
+func signRPCRequest(t *testing.T, id string) tdtypes.RPCRequest {
+	t.Helper()
+	params, err := json.Marshal(types.NodeSignRequest{
+		RequestBody: types.RequestBody{BlockNumber: 100, RequestId: id, Symbol: "GOLD"},
+	})
+	require.NoError(t, err)
+	return tdtypes.RPCRequest{
+		ID:     tdtypes.JSONRPCStringID(id),
+		Method: types.NotifyNodeSubmitPriceWithSignature.String(),
+		Params: params,
+	}
+}
+
+func TestStop_WaitsForSignGoroutines(t *testing.T) {
+	provider := &blockingProvider{
+		staticProvider: staticProvider{price: 2400},
+		started:        make(chan struct{}, 1),
+		release:        make(chan struct{}),
+	}
+	n, ws := newTestNode(t, provider, 16)
+	// store 的 leveldb 后台协程不属于节点，基线取在 Start 之前
+	baseline := runtime.NumGoroutine()
+	require.NoError(t, n.Start(n.ctx))
+	n.signRequestChan <- signRPCRequest(t, "req-1")
+
+	select {
+	case <-provider.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sign request was never processed")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		require.NoError(t, n.Stop(context.Background()))
+		close(stopped)
+	}()
+
+	// 取价未结束前 Stop 不能返回
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned while a sign goroutine was still running")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(provider.release)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not return after the sign goroutine finished")
+	}
+
+	// 节点已停止，响应不再发送给 manager
+	assert.Zero(t, ws.sentCount())
+	assert.True(t, n.Stopped())
+	// 在测试协程中轮询，assert.Eventually 自身的检查协程会计入 NumGoroutine
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), baseline, "node goroutines leaked after Stop")
+}
@@ -0,0 +1,103 @@
+package node
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cpchain-network/oracle-node/config"
+	"github.com/cpchain-network/oracle-node/node/exchange"
+)
+
+// priceRejectedCode 价格未通过校验时返回给 manager 的错误码
+const priceRejectedCode = 202
+
+var (
+	ErrNonPositivePrice  = errors.New("price is not positive")
+	ErrPriceDeviation    = errors.New("price deviates too far from last signed price")
+	ErrStalePrice        = errors.New("price data is stale")
+	ErrNoReportedPrice   = errors.New("no price reported for this request")
+	ErrProposalDeviation = errors.New("proposed price deviates too far from reported price")
+)
+
+// priceValidator 签名前校验价格，避免数据源返回 0、异常跳变或过期数据时仍被签名
+type priceValidator struct {
+	cfg config.PriceValidationConfig
+	now func() time.Time
+
+	mu           sync.Mutex
+	lastPrice    float64 // 上次签名的价格
+	lastSignedAt time.Time
+	lastRequest  string // 上次签名价格所属的请求，重启恢复后为空
+}
+
+func newPriceValidator(cfg config.PriceValidationConfig) *priceValidator {
+	return &priceValidator{cfg: cfg, now: time.Now}
+}
+
+// check 校验价格
+func (v *priceValidator) check(price *exchange.AggregatedPrice) error {
+	if !(price.Price > 0) || math.IsInf(price.Price, 0) {
+		return fmt.Errorf("got %f: %w", price.Price, ErrNonPositivePrice)
+	}
+
+	now := v.now()
+	if v.cfg.MaxAge > 0 && !price.UpdatedAt.IsZero() {
+		if age := now.Sub(price.UpdatedAt); age > v.cfg.MaxAge {
+			return fmt.Errorf("updated %s ago, max age %s: %w", age.Round(time.Second), v.cfg.MaxAge, ErrStalePrice)
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// 距上次签名足够久时允许价格大幅变化，避免行情真实变动后永远无法签名
+	if v.cfg.MaxDeviation > 0 && v.lastPrice > 0 {
+		if v.cfg.DeviationWindow == 0 || now.Sub(v.lastSignedAt) < v.cfg.DeviationWindow {
+			deviation := math.Abs(price.Price-v.lastPrice) / v.lastPrice * 100
+			if deviation > v.cfg.MaxDeviation {
+				return fmt.Errorf("%f is %.2f%% from last signed %f, max %.2f%%: %w",
+					price.Price, deviation, v.lastPrice, v.cfg.MaxDeviation, ErrPriceDeviation)
+			}
+		}
+	}
+	return nil
+}
+
+// checkProposal 校验 manager 提议的聚合价：只签本轮已上报过价格的请求，且与上报价格足够接近
+func (v *priceValidator) checkProposal(requestId string, proposed float64) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.lastRequest != requestId {
+		return fmt.Errorf("request %s: %w", requestId, ErrNoReportedPrice)
+	}
+	if v.cfg.MaxProposalDeviation > 0 {
+		deviation := math.Abs(proposed-v.lastPrice) / v.lastPrice * 100
+		if deviation > v.cfg.MaxProposalDeviation {
+			return fmt.Errorf("%f is %.2f%% from reported %f, max %.2f%%: %w",
+				proposed, deviation, v.lastPrice, v.cfg.MaxProposalDeviation, ErrProposalDeviation)
+		}
+	}
+	return nil
+}
+
+// record 记录请求中已签名上报的价格
+func (v *priceValidator) record(requestId string, price float64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.lastPrice = price
+	v.lastSignedAt = v.now()
+	v.lastRequest = requestId
+}
+
+// restore 从签名历史恢复上次签名的价格
+func (v *priceValidator) restore(price float64, signedAt time.Time) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.lastPrice = price
+	v.lastSignedAt = signedAt
+}
@@ -0,0 +1,131 @@
+package node
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmjson "github.com/tendermint/tendermint/libs/json"
+	tdtypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+
+	"github.com/cpchain-network/oracle-node/config"
+	"github.com/cpchain-network/oracle-node/manager/types"
+	"github.com/cpchain-network/oracle-node/node/exchange"
+	"github.com/cpchain-network/oracle-node/sign"
+	"github.com/cpchain-network/oracle-node/store"
+)
+
+var testValidation = config.PriceValidationConfig{MaxDeviation: 10, DeviationWindow: 10 * time.Minute, MaxAge: 5 * time.Minute}
+
+// newTestValidator 使用可控时钟的校验器
+func newTestValidator(cfg config.PriceValidationConfig) (*priceValidator, *time.Time) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	v := newPriceValidator(cfg)
+	v.now = func() time.Time { return now }
+	return v, &now
+}
+
+func TestPriceValidator_RejectsNonPositivePrice(t *testing.T) {
+	v, _ := newTestValidator(testValidation)
+
+	for _, price := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		err := v.check(&exchange.AggregatedPrice{Price: price})
+		assert.ErrorIs(t, err, ErrNonPositivePrice, "price %f", price)
+	}
+}
+
+func TestPriceValidator_RejectsStaleData(t *testing.T) {
+	v, now := newTestValidator(testValidation)
+
+	err := v.check(&exchange.AggregatedPrice{Price: 100, UpdatedAt: now.Add(-6 * time.Minute)})
+	assert.ErrorIs(t, err, ErrStalePrice)
+
+	assert.NoError(t, v.check(&exchange.AggregatedPrice{Price: 100, UpdatedAt: now.Add(-time.Minute)}))
+	// 数据源未报告时间时不检查
+	assert.NoError(t, v.check(&exchange.AggregatedPrice{Price: 100}))
+}
+
+func TestPriceValidator_RejectsDeviationFromLastSignedPrice(t *testing.T) {
+	v, now := newTestValidator(testValidation)
+
+	// 首次签名没有参照价格
+	require.NoError(t, v.check(&exchange.AggregatedPrice{Price: 100}))
+	v.record("req-1", 100)
+
+	assert.NoError(t, v.check(&exchange.AggregatedPrice{Price: 109}))
+	assert.ErrorIs(t, v.check(&exchange.AggregatedPrice{Price: 111}), ErrPriceDeviation)
+	assert.ErrorIs(t, v.check(&exchange.AggregatedPrice{Price: 85}), ErrPriceDeviation)
+
+	// 超过偏离检查窗口后接受
+	*now = now.Add(11 * time.Minute)
+	assert.NoError(t, v.check(&exchange.AggregatedPrice{Price: 150}))
+}
+
+func TestPriceValidator_DisabledChecks(t *testing.T) {
+	v, now := newTestValidator(config.PriceValidationConfig{})
+	v.record("req-1", 100)
+
+	assert.NoError(t, v.check(&exchange.AggregatedPrice{Price: 1000, UpdatedAt: now.Add(-24 * time.Hour)}))
+	assert.ErrorIs(t, v.check(&exchange.AggregatedPrice{Price: 0}), ErrNonPositivePrice)
+}
+
+func TestPriceValidator_ChecksProposalAgainstReportedPrice(t *testing.T) {
+	v, _ := newTestValidator(config.PriceValidationConfig{MaxProposalDeviation: 1})
+
+	// 本请求尚未上报价格，包括从签名历史恢复的价格
+	v.restore(100, time.Now())
+	assert.ErrorIs(t, v.checkProposal("req-1", 100), ErrNoReportedPrice)
+
+	v.record("req-1", 100)
+	assert.NoError(t, v.checkProposal("req-1", 100.9))
+	assert.ErrorIs(t, v.checkProposal("req-1", 98.5), ErrProposalDeviation)
+	assert.ErrorIs(t, v.checkProposal("req-2", 100), ErrNoReportedPrice)
+
+	v.cfg.MaxProposalDeviation = 0
+	assert.NoError(t, v.checkProposal("req-1", 150))
+}
+
+func TestSignPrice_SendsErrorResponseOnRejection(t *testing.T) {
+	keyPairs, err := sign.GenRandomBlsKeys()
+	require.NoError(t, err)
+
+	db, err := store.NewStorage("")
+	require.NoError(t, err)
+
+	v, _ := newTestValidator(testValidation)
+	n := &Node{log: log.Root(), db: db, keyPairs: keyPairs, feeds: map[string]*symbolFeed{"": {validator: v}}}
+	resId := tdtypes.JSONRPCStringID("req-1")
+	body := types.RequestBody{BlockNumber: 100, RequestId: "req-1"}
+
+	resp := n.signPrice(resId, body, &exchange.AggregatedPrice{Price: 2400, Sources: 3})
+	require.Nil(t, resp.Error)
+	var signResponse types.SignMsgResponse
+	require.NoError(t, tmjson.Unmarshal(resp.Result, &signResponse))
+	assert.Equal(t, float64(2400), signResponse.AssetPrice)
+	assert.Equal(t, 3, signResponse.SourceCount)
+	assert.NotEmpty(t, signResponse.Signature)
+
+	// 异常跳变不签名
+	resp = n.signPrice(resId, body, &exchange.AggregatedPrice{Price: 24000, Sources: 3})
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, priceRejectedCode, resp.Error.Code)
+	assert.Contains(t, resp.Error.Data, ErrPriceDeviation.Error())
+	assert.Nil(t, resp.Result)
+
+	resp = n.signPrice(resId, body, &exchange.AggregatedPrice{Price: 0})
+	require.NotNil(t, resp.Error)
+	assert.Contains(t, resp.Error.Data, ErrNonPositivePrice.Error())
+
+	// 只记录签过的价格
+	signed, err := db.GetLatestSignedPrices(10)
+	require.NoError(t, err)
+	require.Len(t, signed, 1)
+	assert.Equal(t, "req-1", signed[0].RequestId)
+	assert.Equal(t, uint64(100), signed[0].BlockNumber)
+	assert.Equal(t, float64(2400), signed[0].Price)
+	assert.Equal(t, types.PriceMessage(types.FormatPrice(2400), body), signed[0].Message)
+	assert.Equal(t, signResponse.Signature, signed[0].Signature)
+}
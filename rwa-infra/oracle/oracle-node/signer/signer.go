@@ -0,0 +1,81 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/external"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var ErrSignerMismatch = errors.New("signed transaction sender does not match signer address")
+
+// Signer 签名 ETH 交易，私钥可以在本进程内存中，也可以由外部签名服务托管
+type Signer interface {
+	Address() common.Address
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// PrivateKeySigner 使用内存中的私钥签名
+type PrivateKeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+func NewPrivateKeySigner(key *ecdsa.PrivateKey) *PrivateKeySigner {
+	return &PrivateKeySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+func (s *PrivateKeySigner) Address() common.Address {
+	return s.address
+}
+
+func (s *PrivateKeySigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// ExternalSigner 通过 clef 兼容的外部签名服务（account_signTransaction）签名，私钥不进入本进程；
+// KMS/HSM 托管的密钥经实现该接口的签名代理接入
+type ExternalSigner struct {
+	client  *external.ExternalSigner
+	account accounts.Account
+}
+
+// NewExternalSigner 连接签名服务并确认其管理 address 对应的账户
+func NewExternalSigner(endpoint string, address common.Address) (*ExternalSigner, error) {
+	client, err := external.NewExternalSigner(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect external signer: %w", err)
+	}
+	account := accounts.Account{Address: address}
+	if !client.Contains(account) {
+		return nil, fmt.Errorf("external signer does not manage account %s", address)
+	}
+	return &ExternalSigner{client: client, account: account}, nil
+}
+
+func (s *ExternalSigner) Address() common.Address {
+	return s.account.Address
+}
+
+// SignTx 签名服务返回的交易必须由本账户签名，避免服务配置错误时用其他账户发送
+func (s *ExternalSigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signed, err := s.client.SignTx(s.account, tx, chainID)
+	if err != nil {
+		return nil, err
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	if err != nil {
+		return nil, err
+	}
+	if sender != s.account.Address {
+		return nil, fmt.Errorf("got %s, want %s: %w", sender, s.account.Address, ErrSignerMismatch)
+	}
+	return signed, nil
+}
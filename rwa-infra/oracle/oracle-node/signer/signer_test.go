@@ -0,0 +1,127 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testChainID = big.NewInt(86606)
+
+func testTx() *types.Transaction {
+	to := common.HexToAddress("0x00000000000000000000000000000000000000b1")
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   testChainID,
+		Nonce:     3,
+		To:        &to,
+		Gas:       100000,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(21e9),
+		Data:      []byte{0x01, 0x02},
+	})
+}
+
+func TestPrivateKeySigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	s := NewPrivateKeySigner(key)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), s.Address())
+
+	signed, err := s.SignTx(context.Background(), testTx(), testChainID)
+	require.NoError(t, err)
+	sender, err := types.Sender(types.LatestSignerForChainID(testChainID), signed)
+	require.NoError(t, err)
+	assert.Equal(t, s.Address(), sender)
+}
+
+// fakeClef 实现外部签名服务的 account_ 接口，用 key 签名
+type fakeClef struct {
+	key      *ecdsa.PrivateKey
+	accounts []common.Address
+}
+
+func (c *fakeClef) Version() string {
+	return "7.0.1"
+}
+
+func (c *fakeClef) List() []common.Address {
+	return c.accounts
+}
+
+func (c *fakeClef) SignTransaction(args apitypes.SendTxArgs) (map[string]interface{}, error) {
+	tx, err := args.ToTransaction()
+	if err != nil {
+		return nil, err
+	}
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID((*big.Int)(args.ChainID)), c.key)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"raw": hexutil.Bytes(raw), "tx": signed}, nil
+}
+
+func startFakeClef(t *testing.T, clef *fakeClef) string {
+	t.Helper()
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("account", clef))
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+	return httpServer.URL
+}
+
+func TestExternalSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	endpoint := startFakeClef(t, &fakeClef{key: key, accounts: []common.Address{address}})
+
+	s, err := NewExternalSigner(endpoint, address)
+	require.NoError(t, err)
+	assert.Equal(t, address, s.Address())
+
+	tx := testTx()
+	signed, err := s.SignTx(context.Background(), tx, testChainID)
+	require.NoError(t, err)
+	sender, err := types.Sender(types.LatestSignerForChainID(testChainID), signed)
+	require.NoError(t, err)
+	assert.Equal(t, address, sender)
+	assert.Equal(t, tx.Nonce(), signed.Nonce())
+	assert.Equal(t, tx.GasFeeCap(), signed.GasFeeCap())
+	assert.Equal(t, tx.Data(), signed.Data())
+
+	_, err = NewExternalSigner(endpoint, common.HexToAddress("0x01"))
+	assert.ErrorContains(t, err, "does not manage account")
+}
+
+func TestExternalSignerRejectsOtherSender(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	// 签名服务列出了该账户，却用另一把私钥签名
+	endpoint := startFakeClef(t, &fakeClef{key: other, accounts: []common.Address{address}})
+
+	s, err := NewExternalSigner(endpoint, address)
+	require.NoError(t, err)
+	_, err = s.SignTx(context.Background(), testTx(), testChainID)
+	assert.ErrorIs(t, err, ErrSignerMismatch)
+}
@@ -2,8 +2,10 @@ package store
 
 import (
 	"encoding/json"
+	"errors"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/syndtr/goleveldb/leveldb"
 
 	"github.com/cpchain-network/oracle-node/bindings/bls"
 )
@@ -22,5 +24,30 @@ func (s *Storage) SetNewPubkeyRegistrationEvent(event NewPubkeyRegistration) err
 	if err != nil {
 		return err
 	}
-	return s.db.Put(getNewPubkeyRegistrationKey(event.TxHash.Bytes()), bz, nil)
+	if err = s.db.Put(getNewPubkeyRegistrationKey(event.TxHash.Bytes()), bz, nil); err != nil {
+		return err
+	}
+
+	pubkeyBz, err := json.Marshal(event.PubkeyG1)
+	if err != nil {
+		return err
+	}
+	return s.db.Put(getOperatorPubkeyKey(event.Operator.Bytes()), pubkeyBz, nil)
+}
+
+// GetOperatorPubkeyG1 获取 operator 在 BLSApkRegistry 注册的 G1 公钥
+func (s *Storage) GetOperatorPubkeyG1(operator common.Address) (bls.BN254G1Point, bool, error) {
+	bz, err := s.db.Get(getOperatorPubkeyKey(operator.Bytes()), nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return bls.BN254G1Point{}, false, nil
+		}
+		return bls.BN254G1Point{}, false, err
+	}
+
+	var pubkey bls.BN254G1Point
+	if err = json.Unmarshal(bz, &pubkey); err != nil {
+		return bls.BN254G1Point{}, false, err
+	}
+	return pubkey, true, nil
 }
@@ -10,7 +10,11 @@ var (
 	EthScannedHeightKeyPrefix   = []byte{0x05}
 	VerifyOracleSigKeyMsgPrefix = []byte{0x06}
 	VerifyOracleSigKeyPrefix    = []byte{0x07}
+	LatestSubmissionKeyPrefix   = []byte{0x08}
 	NewPubkeyRegistrationfix    = []byte{0x10}
+	OperatorPubkeyKeyPrefix     = []byte{0x11}
+	SignedPriceKeyPrefix        = []byte{0x12}
+	NodeHealthKeyPrefix         = []byte{0x13}
 )
 
 func getMarketPriceMessageKey(txHash []byte) []byte {
@@ -45,6 +49,24 @@ func getVerifyOracleSigKey(timestamp uint64) []byte {
 	return append(VerifyOracleSigKeyPrefix, timestampBz...)
 }
 
+func getLatestSubmissionKey() []byte {
+	return LatestSubmissionKeyPrefix
+}
+
 func getNewPubkeyRegistrationKey(txHash []byte) []byte {
 	return append(NewPubkeyRegistrationfix, txHash[:]...)
 }
+
+func getSignedPriceKey(seq uint64) []byte {
+	seqBz := make([]byte, 8)
+	binary.BigEndian.PutUint64(seqBz, seq)
+	return append(SignedPriceKeyPrefix, seqBz...)
+}
+
+func getOperatorPubkeyKey(operator []byte) []byte {
+	return append(OperatorPubkeyKeyPrefix, operator[:]...)
+}
+
+func getNodeHealthKey(node string) []byte {
+	return append(NodeHealthKeyPrefix, []byte(node)...)
+}
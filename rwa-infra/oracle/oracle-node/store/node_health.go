@@ -0,0 +1,42 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// NodeHealth manager 记录的签名节点在线状态，重启后据此继续准入/剔除判断
+type NodeHealth struct {
+	Node       string    `json:"node"`        // ws 节点 ID（压缩公钥 hex）
+	Address    string    `json:"address"`     // 节点地址
+	Available  bool      `json:"available"`   // 是否参与签名
+	AliveSince time.Time `json:"alive_since"` // 本次连续在线的起点，离线时为零值
+	LastSeen   time.Time `json:"last_seen"`   // 最近一次在线的时间
+}
+
+// SetNodeHealth 保存节点在线状态
+func (s *Storage) SetNodeHealth(health NodeHealth) error {
+	bz, err := json.Marshal(health)
+	if err != nil {
+		return err
+	}
+	return s.db.Put(getNodeHealthKey(health.Node), bz, nil)
+}
+
+// GetNodeHealths 获取所有节点的在线状态
+func (s *Storage) GetNodeHealths() ([]NodeHealth, error) {
+	iter := s.db.NewIterator(util.BytesPrefix(NodeHealthKeyPrefix), nil)
+	defer iter.Release()
+
+	var healths []NodeHealth
+	for iter.Next() {
+		var health NodeHealth
+		if err := json.Unmarshal(iter.Value(), &health); err != nil {
+			return nil, err
+		}
+		healths = append(healths, health)
+	}
+	return healths, iter.Error()
+}
@@ -36,6 +36,50 @@ type VerifyOracleSig struct {
 	Timestamp           uint64      `json:"timestamp"`
 }
 
+// OracleSubmission 链上最近一次价格提交（来自 VerifyOracleSig 或 PricesSubmitted 事件）
+type OracleSubmission struct {
+	BlockNumber     int64       `json:"block_number"`
+	TxHash          common.Hash `json:"tx_hash"`
+	BatchId         uint64      `json:"batch_id"`
+	AggregatedPrice string      `json:"aggregated_price"`
+	NodeCount       uint64      `json:"node_count"` // VerifyOracleSig 事件不含节点数，为 0
+	Timestamp       uint64      `json:"timestamp"`
+}
+
+// SetLatestSubmission 记录最近一次价格提交，只保留块高最大的
+func (s *Storage) SetLatestSubmission(submission OracleSubmission) error {
+	latest, found, err := s.GetLatestSubmission()
+	if err != nil {
+		return err
+	}
+	if found && latest.BlockNumber > submission.BlockNumber {
+		return nil
+	}
+
+	bz, err := json.Marshal(submission)
+	if err != nil {
+		return err
+	}
+	return s.db.Put(getLatestSubmissionKey(), bz, nil)
+}
+
+// GetLatestSubmission 获取最近一次价格提交
+func (s *Storage) GetLatestSubmission() (OracleSubmission, bool, error) {
+	bz, err := s.db.Get(getLatestSubmissionKey(), nil)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return OracleSubmission{}, false, nil
+		}
+		return OracleSubmission{}, false, err
+	}
+
+	var submission OracleSubmission
+	if err = json.Unmarshal(bz, &submission); err != nil {
+		return OracleSubmission{}, false, err
+	}
+	return submission, true, nil
+}
+
 func (s *Storage) SetVerifyOracleSigEvent(event VerifyOracleSig) error {
 	bz, err := json.Marshal(event)
 	if err != nil {
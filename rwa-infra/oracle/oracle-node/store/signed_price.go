@@ -0,0 +1,79 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// SignedPrice 节点签过的价格，用于事后审计
+type SignedPrice struct {
+	RequestId   string  `json:"request_id"`
+	Symbol      string  `json:"symbol,omitempty"`
+	BlockNumber uint64  `json:"block_number"`
+	Price       float64 `json:"price"`
+	Message     string  `json:"message"`   // 实际签名的消息
+	Signature   []byte  `json:"signature"` // 序列化的 BLS 签名
+	Timestamp   uint64  `json:"timestamp"`
+}
+
+// SetSignedPrice 按签名顺序追加一条签名记录
+func (s *Storage) SetSignedPrice(price SignedPrice) error {
+	bz, err := json.Marshal(price)
+	if err != nil {
+		return err
+	}
+
+	s.signedPriceMu.Lock()
+	defer s.signedPriceMu.Unlock()
+
+	seq, err := s.lastSignedPriceSeq()
+	if err != nil {
+		return err
+	}
+	return s.db.Put(getSignedPriceKey(seq+1), bz, nil)
+}
+
+// GetLatestSignedPrices 获取最近 n 条签名记录，按时间倒序
+func (s *Storage) GetLatestSignedPrices(n int) ([]SignedPrice, error) {
+	iter := s.db.NewIterator(util.BytesPrefix(SignedPriceKeyPrefix), nil)
+	defer iter.Release()
+
+	prices := make([]SignedPrice, 0, n)
+	for ok := iter.Last(); ok && len(prices) < n; ok = iter.Prev() {
+		var price SignedPrice
+		if err := json.Unmarshal(iter.Value(), &price); err != nil {
+			return nil, err
+		}
+		prices = append(prices, price)
+	}
+	return prices, iter.Error()
+}
+
+// GetLatestSignedPrice 获取某品种最近一条签名记录
+func (s *Storage) GetLatestSignedPrice(symbol string) (SignedPrice, bool, error) {
+	iter := s.db.NewIterator(util.BytesPrefix(SignedPriceKeyPrefix), nil)
+	defer iter.Release()
+
+	for ok := iter.Last(); ok; ok = iter.Prev() {
+		var price SignedPrice
+		if err := json.Unmarshal(iter.Value(), &price); err != nil {
+			return SignedPrice{}, false, err
+		}
+		if price.Symbol == symbol {
+			return price, true, nil
+		}
+	}
+	return SignedPrice{}, false, iter.Error()
+}
+
+func (s *Storage) lastSignedPriceSeq() (uint64, error) {
+	iter := s.db.NewIterator(util.BytesPrefix(SignedPriceKeyPrefix), nil)
+	defer iter.Release()
+
+	if !iter.Last() {
+		return 0, iter.Error()
+	}
+	return binary.BigEndian.Uint64(iter.Key()[len(SignedPriceKeyPrefix):]), nil
+}
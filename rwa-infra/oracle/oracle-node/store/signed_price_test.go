@@ -0,0 +1,61 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLatestSignedPrices(t *testing.T) {
+	db, err := NewStorage("")
+	require.NoError(t, err)
+
+	prices, err := db.GetLatestSignedPrices(5)
+	require.NoError(t, err)
+	assert.Empty(t, prices)
+
+	// 同一秒内的多条记录也按签名顺序返回
+	for i := 1; i <= 300; i++ {
+		require.NoError(t, db.SetSignedPrice(SignedPrice{
+			RequestId:   fmt.Sprintf("req-%d", i),
+			BlockNumber: uint64(i),
+			Price:       float64(i),
+			Timestamp:   1760000000,
+		}))
+	}
+	// 确保与其他前缀的记录不混淆
+	require.NoError(t, db.SetLatestSubmission(OracleSubmission{BlockNumber: 1}))
+
+	prices, err = db.GetLatestSignedPrices(3)
+	require.NoError(t, err)
+	require.Len(t, prices, 3)
+	assert.Equal(t, []string{"req-300", "req-299", "req-298"},
+		[]string{prices[0].RequestId, prices[1].RequestId, prices[2].RequestId})
+
+	prices, err = db.GetLatestSignedPrices(1000)
+	require.NoError(t, err)
+	assert.Len(t, prices, 300)
+	assert.Equal(t, "req-1", prices[299].RequestId)
+}
+
+func TestGetLatestSignedPriceBySymbol(t *testing.T) {
+	db, err := NewStorage("")
+	require.NoError(t, err)
+
+	for i, symbol := range []string{"", "GOLD", "GOLD", "OIL", ""} {
+		require.NoError(t, db.SetSignedPrice(SignedPrice{RequestId: fmt.Sprintf("req-%d", i), Symbol: symbol, Price: float64(i)}))
+	}
+
+	for symbol, expected := range map[string]string{"": "req-4", "GOLD": "req-2", "OIL": "req-3"} {
+		price, found, err := db.GetLatestSignedPrice(symbol)
+		require.NoError(t, err)
+		require.True(t, found, "symbol %q", symbol)
+		assert.Equal(t, expected, price.RequestId, "symbol %q", symbol)
+	}
+
+	_, found, err := db.GetLatestSignedPrice("WINE")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
@@ -2,6 +2,8 @@ package store
 
 import (
 	"fmt"
+	"sync"
+
 	"github.com/pkg/errors"
 
 	"github.com/syndtr/goleveldb/leveldb"
@@ -10,6 +12,8 @@ import (
 
 type Storage struct {
 	db *leveldb.DB
+
+	signedPriceMu sync.Mutex // 保证签名记录序号递增
 }
 
 func NewStorage(levelDbFolder string) (*Storage, error) {
@@ -70,11 +70,53 @@ func (om *OracleManager) ProcessSubmitDataWithSignature(db *store.Storage, event
 		if err = db.SetVerifyOracleSigEvent(*verifyOracleSig); err != nil {
 			return err
 		}
+		if err = db.SetLatestSubmission(store.OracleSubmission{
+			BlockNumber:     verifyOracleSig.BlockNumber,
+			TxHash:          verifyOracleSig.TxHash,
+			BatchId:         verifyOracleSig.ConfirmBatchId,
+			AggregatedPrice: verifyOracleSig.SymbolPrice,
+			Timestamp:       verifyOracleSig.Timestamp,
+		}); err != nil {
+			return err
+		}
 		om.log.Info("store verify oracle sign event success")
 	}
 	return nil
 }
 
+func (om *OracleManager) ProcessPricesSubmittedEvent(db *store.Storage, event store.ContractEvent) error {
+	if event.EventSignature.String() != om.OracleManagerABI.Events["PricesSubmitted"].ID.String() {
+		return nil
+	}
+
+	header, err := db.GetEthBlockHeader(int64(event.BlockHeight))
+	if err != nil {
+		om.log.Error("ProcessPricesSubmittedEvent db Blocks BlockHeader by BlockHash fail", "err", err)
+		return err
+	}
+
+	pricesSubmitted, err := om.OracleManagerFilterer.ParsePricesSubmitted(*event.RLPLog)
+	if err != nil {
+		om.log.Error("parse prices submitted fail", "err", err)
+		return err
+	}
+
+	log.Info("parse prices submitted success", "batchId", pricesSubmitted.BatchId, "aggregatedPrice", pricesSubmitted.AggregatedPrice)
+
+	if err = db.SetLatestSubmission(store.OracleSubmission{
+		BlockNumber:     header.Number,
+		TxHash:          event.TransactionHash,
+		BatchId:         pricesSubmitted.BatchId.Uint64(),
+		AggregatedPrice: pricesSubmitted.AggregatedPrice.String(),
+		NodeCount:       pricesSubmitted.NodeCount.Uint64(),
+		Timestamp:       event.Timestamp,
+	}); err != nil {
+		return err
+	}
+	om.log.Info("store prices submitted event success")
+	return nil
+}
+
 func (om *OracleManager) ProcessOperatorRegisteredEvent(db *store.Storage, event store.ContractEvent) error {
 	var operatorRegistered *store.OperatorRegistered
 	header, err := db.GetEthBlockHeader(int64(event.BlockHeight))
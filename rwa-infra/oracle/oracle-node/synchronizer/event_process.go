@@ -54,6 +54,10 @@ func (e *EventProcess) Start() error {
 				e.log.Error("failed to process ProcessSubmitDataWithSignature event", "err", err)
 				continue
 			}
+			if err := e.oracleManager.ProcessPricesSubmittedEvent(e.db, event); err != nil {
+				e.log.Error("failed to process ProcessPricesSubmittedEvent event", "err", err)
+				continue
+			}
 			if err := e.blsRegister.ProcessNewPubkeyRegistrationEvent(e.db, event); err != nil {
 				e.log.Error("failed to process ProcessNewPubkeyRegistrationEvent event", "err", err)
 				continue
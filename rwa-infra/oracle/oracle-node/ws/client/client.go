@@ -3,16 +3,21 @@ package client
 import (
 	"context"
 	"crypto/ecdsa"
+	"os"
 	"time"
 
 	"github.com/ethereum/go-ethereum/log"
 
 	"github.com/cpchain-network/oracle-node/ws/client/tm"
 
+	tmlog "github.com/tendermint/tendermint/libs/log"
 	tmsync "github.com/tendermint/tendermint/libs/sync"
 	tmtypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
 )
 
+// sendTimeout 断线重连期间发送响应的最长等待时间，超时后丢弃响应
+const sendTimeout = 10 * time.Second
+
 type WSClients struct {
 	mtx      tmsync.RWMutex
 	ReqChan  chan tmtypes.RPCRequest
@@ -20,12 +25,17 @@ type WSClients struct {
 	Cli      *tm.WSClient
 }
 
-func NewWSClient(remoteAddr, endpoint string, privKey *ecdsa.PrivateKey, pubkey string) (*WSClients, error) {
-	if client, err := tm.NewWS(remoteAddr, endpoint); err != nil {
+func NewWSClient(remoteAddr, endpoint string, privKey *ecdsa.PrivateKey, pubkey string, options ...func(*tm.WSClient)) (*WSClients, error) {
+	options = append([]func(*tm.WSClient){tm.OnReconnect(func() {
+		log.Info("websocket reconnected to manager", "addr", remoteAddr)
+	})}, options...)
+
+	if client, err := tm.NewWS(remoteAddr, endpoint, options...); err != nil {
 		return nil, err
 	} else {
 		client.PubKey = pubkey
 		client.PriKey = privKey
+		client.SetLogger(tmlog.NewTMLogger(tmlog.NewSyncWriter(os.Stdout)).With("protocol", "ws"))
 
 		wsc := &WSClients{
 			Cli: client,
@@ -54,8 +64,10 @@ func (wsc *WSClients) RegisterResChannel(requestMsg chan tmtypes.RPCRequest, sto
 }
 
 func (wsc *WSClients) SendMsg(rsp tmtypes.RPCResponse) error {
-	if err := wsc.Cli.Send(context.Background(), rsp); err != nil {
-		log.Error("send rsp failed!")
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	if err := wsc.Cli.Send(ctx, rsp); err != nil {
+		log.Error("send rsp failed!", "reconnecting", wsc.Cli.IsReconnecting(), "err", err)
 		return err
 	}
 	log.Info("send rsp success!")
@@ -64,16 +76,25 @@ func (wsc *WSClients) SendMsg(rsp tmtypes.RPCResponse) error {
 
 func (wsc *WSClients) rspListener() {
 	ticker := time.NewTicker(100 * time.Second)
+	defer ticker.Stop()
 	for {
 		select {
-		case msg := <-wsc.Cli.RequestsCh:
-			wsc.ReqChan <- msg
+		case msg, ok := <-wsc.Cli.RequestsCh:
+			if !ok {
+				// 只有客户端停止（重连次数用尽）时才会关闭
+				log.Error("websocket client stopped, no more sign requests")
+				return
+			}
+			select {
+			case wsc.ReqChan <- msg:
+			case <-wsc.StopChan:
+				return
+			}
 		case <-wsc.StopChan:
 			wsc.Cli.Logger.Info("we are stopping channel")
-			wsc.StopChan = nil
 			return
 		case <-ticker.C:
-			wsc.Cli.Logger.Info("rsp goroutine is alive")
+			wsc.Cli.Logger.Info("rsp goroutine is alive", "active", wsc.Cli.IsActive())
 		}
 	}
 }
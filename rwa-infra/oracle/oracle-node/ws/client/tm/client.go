@@ -5,7 +5,9 @@ import (
 	"crypto/ecdsa"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
 	"net"
 	"net/http"
 	"strconv"
@@ -22,11 +24,12 @@ import (
 )
 
 const (
-	defaultMaxReconnectAttempts = 25
+	defaultMaxReconnectAttempts = 0 // 0 表示一直重连，直到 Stop
 	defaultWriteWait            = 0
 	defaultReadWait             = 0
 	defaultPingPeriod           = 0
-	defaultMaxSleepTime         = 3600 * time.Second
+	defaultReconnectBackoff     = time.Second
+	defaultMaxSleepTime         = time.Minute
 )
 
 // WSClient is a JSON-RPC client, which uses WebSocket for communication with
@@ -56,9 +59,13 @@ type WSClient struct { // nolint: maligned
 	reconnectAfter  chan error             // reconnect requests
 	readRoutineQuit chan struct{}          // a way for readRoutine to close writeRoutine
 
-	// Maximum reconnect attempts (0 or greater; default: 25).
+	// Maximum reconnect attempts (0 means unlimited; default: 0).
 	maxReconnectAttempts int
 
+	// Initial and maximum backoff between reconnect attempts.
+	reconnectBackoff    time.Duration
+	maxReconnectBackoff time.Duration
+
 	// Support both ws and wss protocols
 	protocol string
 
@@ -112,6 +119,8 @@ func NewWS(remoteAddr, endpoint string, options ...func(*WSClient)) (*WSClient,
 		PingPongLatencyTimer: metrics.NewTimer(),
 
 		maxReconnectAttempts: defaultMaxReconnectAttempts,
+		reconnectBackoff:     defaultReconnectBackoff,
+		maxReconnectBackoff:  defaultMaxSleepTime,
 		readWait:             defaultReadWait,
 		writeWait:            defaultWriteWait,
 		pingPeriod:           defaultPingPeriod,
@@ -125,6 +134,7 @@ func NewWS(remoteAddr, endpoint string, options ...func(*WSClient)) (*WSClient,
 }
 
 // MaxReconnectAttempts sets the maximum number of reconnect attempts before returning an error.
+// 0 means the client keeps reconnecting until it is stopped.
 // It should only be used in the constructor and is not Goroutine-safe.
 func MaxReconnectAttempts(max int) func(*WSClient) {
 	return func(c *WSClient) {
@@ -132,6 +142,16 @@ func MaxReconnectAttempts(max int) func(*WSClient) {
 	}
 }
 
+// ReconnectBackoff sets the initial and maximum backoff between reconnect
+// attempts. The backoff doubles after every failed attempt and is jittered.
+// It should only be used in the constructor and is not Goroutine-safe.
+func ReconnectBackoff(initial, max time.Duration) func(*WSClient) {
+	return func(c *WSClient) {
+		c.reconnectBackoff = initial
+		c.maxReconnectBackoff = max
+	}
+}
+
 // ReadWait sets the amount of time to wait before a websocket read times out.
 // It should only be used in the constructor and is not Goroutine-safe.
 func ReadWait(readWait time.Duration) func(*WSClient) {
@@ -266,8 +286,22 @@ func (c *WSClient) dial() error {
 	return nil
 }
 
-// reconnect tries to redial up to maxReconnectAttempts with exponential
-// backoff.
+// reconnectDelay returns the exponential backoff for the given attempt with
+// equal jitter, so that nodes don't redial the manager in lockstep.
+func reconnectDelay(initial, max time.Duration, attempt int) time.Duration {
+	backoff := initial
+	for i := 0; i < attempt && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		backoff = max
+	}
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(backoff-half)+1))
+}
+
+// reconnect tries to redial up to maxReconnectAttempts (or until the client
+// is stopped) with exponential backoff and jitter.
 func (c *WSClient) reconnect() error {
 	attempt := 0
 
@@ -281,18 +315,20 @@ func (c *WSClient) reconnect() error {
 	}()
 
 	for {
-		backoffDuration := (1 << uint(attempt)) * time.Second
-		if backoffDuration > defaultMaxSleepTime {
-			backoffDuration = defaultMaxSleepTime
-		}
+		backoffDuration := reconnectDelay(c.reconnectBackoff, c.maxReconnectBackoff, attempt)
 		c.Logger.Info("reconnecting", "attempt", attempt+1, "backoff_duration", backoffDuration)
-		time.Sleep(backoffDuration)
+		select {
+		case <-time.After(backoffDuration):
+		case <-c.Quit():
+			return errors.New("client stopped while reconnecting")
+		}
 
+		// dial 每次都会带上公钥和新的签名，manager 据此重新登记节点
 		err := c.dial()
 		if err != nil {
-			c.Logger.Error("failed to redial", "err", err)
+			c.Logger.Error("failed to redial", "attempt", attempt+1, "err", err)
 		} else {
-			c.Logger.Info("reconnected")
+			c.Logger.Info("reconnected", "attempt", attempt+1)
 			if c.onReconnect != nil {
 				go c.onReconnect()
 			}
@@ -301,7 +337,7 @@ func (c *WSClient) reconnect() error {
 
 		attempt++
 
-		if attempt > c.maxReconnectAttempts {
+		if c.maxReconnectAttempts > 0 && attempt >= c.maxReconnectAttempts {
 			return fmt.Errorf("reached maximum reconnect attempts: %w", err)
 		}
 	}
@@ -467,11 +503,14 @@ func (c *WSClient) readRoutine() {
 		}
 		_, data, err := c.conn.ReadMessage()
 		if err != nil {
-			if !websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure) {
+			// 客户端主动停止时不重连；其余情况（包括服务端正常关闭和连接被直接断开）都重连
+			select {
+			case <-c.Quit():
 				return
+			default:
 			}
 
-			c.Logger.Error("failed to read response", "err", err)
+			c.Logger.Error("websocket disconnected", "err", err)
 			close(c.readRoutineQuit)
 			c.reconnectAfter <- err
 			return
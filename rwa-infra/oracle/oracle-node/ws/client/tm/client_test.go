@@ -0,0 +1,107 @@
+package tm
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/rpc/jsonrpc/types"
+)
+
+// closingServer 每个连接发送一个请求后断开，记录每次连接携带的公钥
+type closingServer struct {
+	mu      sync.Mutex
+	pubKeys []string
+	// normalClose 为 true 时发送 close 帧，否则直接断开 TCP 连接
+	normalClose bool
+}
+
+func (s *closingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	s.mu.Lock()
+	s.pubKeys = append(s.pubKeys, r.Header.Get("pubKey"))
+	id := len(s.pubKeys)
+	s.mu.Unlock()
+
+	request := types.NewRPCRequest(types.JSONRPCStringID(strings.Repeat("a", id)), "signMsgBatch", nil)
+	if err = conn.WriteJSON(request); err != nil {
+		return
+	}
+	if s.normalClose {
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	}
+}
+
+func (s *closingServer) connections() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.pubKeys...)
+}
+
+func newTestClient(t *testing.T, addr string) *WSClient {
+	t.Helper()
+	privKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	c, err := NewWS("tcp://"+addr, "/ws", ReconnectBackoff(10*time.Millisecond, 50*time.Millisecond))
+	require.NoError(t, err)
+	c.PubKey = hex.EncodeToString(crypto.CompressPubkey(&privKey.PublicKey))
+	c.PriKey = privKey
+	require.NoError(t, c.Start())
+	t.Cleanup(func() { _ = c.Stop() })
+	return c
+}
+
+func TestReconnectAfterServerCloses(t *testing.T) {
+	for _, normalClose := range []bool{false, true} {
+		name := "dropped"
+		if normalClose {
+			name = "close frame"
+		}
+		t.Run(name, func(t *testing.T) {
+			server := &closingServer{normalClose: normalClose}
+			ts := httptest.NewServer(server)
+			defer ts.Close()
+
+			c := newTestClient(t, strings.TrimPrefix(ts.URL, "http://"))
+
+			// 每次重连后都能继续收到请求
+			for i := 1; i <= 3; i++ {
+				select {
+				case request := <-c.RequestsCh:
+					assert.Equal(t, types.JSONRPCStringID(strings.Repeat("a", i)), request.ID)
+				case <-time.After(2 * time.Second):
+					t.Fatalf("no request after %d connections", len(server.connections()))
+				}
+			}
+
+			// 每次重连都重新带上节点公钥
+			for _, pubKey := range server.connections() {
+				assert.Equal(t, c.PubKey, pubKey)
+			}
+		})
+	}
+}
+
+func TestReconnectDelay(t *testing.T) {
+	for attempt, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second} {
+		delay := reconnectDelay(time.Second, 8*time.Second, attempt)
+		assert.GreaterOrEqual(t, delay, expected/2, "attempt %d", attempt)
+		assert.LessOrEqual(t, delay, expected, "attempt %d", attempt)
+	}
+	assert.LessOrEqual(t, reconnectDelay(time.Second, time.Minute, 1000), time.Minute)
+}